@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	ApiTokenPrefix = "rbl_"
+	// requests per token per minute
+	ApiTokenRatelimit = 60
+)
+
+// NewApiToken returns a new random api token and its hash, only the hash should be stored
+func NewApiToken() (token, tokenHash string, err error) {
+	data := make([]byte, 32)
+	_, err = rand.Read(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	token = ApiTokenPrefix + hex.EncodeToString(data)
+	return token, HashApiToken(token), nil
+}
+
+// HashApiToken returns the hex encoded sha256 hash of an api token
+func HashApiToken(token string) string {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(hash[:])
+}
+
+// ParseApiScopes parses a comma separated list of scopes, returns false if one of the scopes is unknown
+func ParseApiScopes(text string) (scopes []string, ok bool) {
+	scopes = make([]string, 0)
+NextScope:
+	for _, scope := range strings.Split(strings.ToLower(text), ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		for _, existingScope := range scopes {
+			if existingScope == scope {
+				continue NextScope
+			}
+		}
+		for _, knownScope := range models.ApiScopes {
+			if knownScope == scope {
+				scopes = append(scopes, scope)
+				continue NextScope
+			}
+		}
+		return nil, false
+	}
+
+	return scopes, len(scopes) > 0
+}
+
+// ApiTokenHasScope returns true if the api token has been granted the scope
+func ApiTokenHasScope(entry models.ApiTokenEntry, scope string) bool {
+	for _, tokenScope := range entry.Scopes {
+		if tokenScope == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetApiTokenByToken returns the active api token entry matching a token
+func GetApiTokenByToken(token string) (entry models.ApiTokenEntry, err error) {
+	if !strings.HasPrefix(token, ApiTokenPrefix) {
+		return entry, errors.New("invalid api token")
+	}
+
+	err = MdbOne(
+		MdbCollection(models.ApiTokensTable).Find(bson.M{"tokenhash": HashApiToken(token), "revoked": false}),
+		&entry,
+	)
+	return entry, err
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewApiToken(t *testing.T) {
+	token, tokenHash, err := NewApiToken()
+	if err != nil {
+		t.Fatal("helpers.NewApiToken() returned an error:", err)
+	}
+	if !strings.HasPrefix(token, ApiTokenPrefix) {
+		t.Fatal("helpers.NewApiToken() returned a token without prefix:", token)
+	}
+	if tokenHash != HashApiToken(token) || tokenHash == token {
+		t.Fatal("helpers.NewApiToken() returned an invalid hash")
+	}
+
+	otherToken, _, _ := NewApiToken()
+	if otherToken == token {
+		t.Fatal("helpers.NewApiToken() returned the same token twice")
+	}
+}
+
+func TestParseApiScopes(t *testing.T) {
+	scopes, ok := ParseApiScopes("Stats:Read, eventlog:read,stats:read")
+	if !ok || len(scopes) != 2 || scopes[0] != "stats:read" || scopes[1] != "eventlog:read" {
+		t.Fatal("helpers.ParseApiScopes() returned wrong scopes:", scopes, ok)
+	}
+
+	if _, ok = ParseApiScopes("stats:read,admin"); ok {
+		t.Fatal("helpers.ParseApiScopes() accepted an unknown scope")
+	}
+
+	if _, ok = ParseApiScopes(" , "); ok {
+		t.Fatal("helpers.ParseApiScopes() accepted no scopes")
+	}
+}
@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/renstrom/fuzzysearch/fuzzy"
+)
+
+const (
+	BiasGameRatingInitial = 1500
+	// how much a single round can change a rating
+	BiasGameRatingK = 32
+
+	BiasGameQuizPointsCorrect = 10
+	// extra points for every correct answer in a row, up to BiasGameQuizStreakBonusMax
+	BiasGameQuizPointsStreakBonus = 2
+	BiasGameQuizStreakBonusMax    = 10
+)
+
+var biasGameGuessRegex = regexp.MustCompile("[^a-z0-9]+")
+
+// BiasGameRatingExpected returns the expected score (0 to 1) of an idol with the rating against an idol with the opponent rating
+func BiasGameRatingExpected(rating, opponentRating float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponentRating-rating)/400))
+}
+
+// BiasGameRatingUpdate returns the new Elo ratings of the winner and the loser of a round
+func BiasGameRatingUpdate(winnerRating, loserRating float64) (newWinnerRating, newLoserRating float64) {
+	change := BiasGameRatingK * (1 - BiasGameRatingExpected(winnerRating, loserRating))
+	return winnerRating + change, loserRating - change
+}
+
+// BiasGameHeadToHeadOrder returns the two idols in the order they are stored in head to head records, and true if they have been swapped
+func BiasGameHeadToHeadOrder(groupNameA, nameA, groupNameB, nameB string) (firstGroupName, firstName, secondGroupName, secondName string, swapped bool) {
+	if groupNameA > groupNameB || (groupNameA == groupNameB && nameA > nameB) {
+		return groupNameB, nameB, groupNameA, nameA, true
+	}
+	return groupNameA, nameA, groupNameB, nameB, false
+}
+
+// BiasGameBracketMatch returns the bracket round (starting at 0) and the position in the round of a match of a bracket with the size
+// matches are counted across all rounds in the order they are played, the winner of a match moves to the position in the next round
+func BiasGameBracketMatch(size, match int) (round, position int) {
+	matchesInRound := size / 2
+	for matchesInRound > 1 && match >= matchesInRound {
+		match -= matchesInRound
+		matchesInRound /= 2
+		round++
+	}
+	return round, match
+}
+
+// BiasGameBracketRounds returns the amount of rounds of a bracket with the size
+func BiasGameBracketRounds(size int) (rounds int) {
+	for size > 1 {
+		size /= 2
+		rounds++
+	}
+	return rounds
+}
+
+// BiasGameGuessMatches returns true if the guess matches one of the solutions
+// case, spaces, and symbols are ignored, and one typo is allowed for every five characters of a solution
+func BiasGameGuessMatches(guess string, solutions ...string) bool {
+	guess = normalizeBiasGameGuess(guess)
+	if guess == "" {
+		return false
+	}
+
+	for _, solution := range solutions {
+		solution = normalizeBiasGameGuess(solution)
+		if solution == "" {
+			continue
+		}
+
+		if guess == solution || fuzzy.LevenshteinDistance(guess, solution) <= len(solution)/5 {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeBiasGameGuess(text string) string {
+	return biasGameGuessRegex.ReplaceAllString(strings.ToLower(text), "")
+}
+
+// BiasGameQuizPoints returns the points for a correct quiz answer, streak is the amount of correct answers in a row including this one
+func BiasGameQuizPoints(streak int) int {
+	bonus := 0
+	if streak > 1 {
+		bonus = (streak - 1) * BiasGameQuizPointsStreakBonus
+	}
+	if bonus > BiasGameQuizStreakBonusMax {
+		bonus = BiasGameQuizStreakBonusMax
+	}
+	return BiasGameQuizPointsCorrect + bonus
+}
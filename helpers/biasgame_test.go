@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBiasGameRatingUpdate(t *testing.T) {
+	winnerRating, loserRating := BiasGameRatingUpdate(BiasGameRatingInitial, BiasGameRatingInitial)
+	if winnerRating != BiasGameRatingInitial+BiasGameRatingK/2 || loserRating != BiasGameRatingInitial-BiasGameRatingK/2 {
+		t.Fatalf("helpers.BiasGameRatingUpdate() returned %f and %f for equal ratings", winnerRating, loserRating)
+	}
+
+	// an upset moves the ratings more than an expected result
+	favouriteRating, underdogRating := BiasGameRatingUpdate(1700, 1300)
+	upsetUnderdogRating, upsetFavouriteRating := BiasGameRatingUpdate(1300, 1700)
+	if favouriteRating-1700 >= upsetUnderdogRating-1300 {
+		t.Fatal("helpers.BiasGameRatingUpdate() rewarded an expected result more than an upset")
+	}
+	if math.Abs((1700+1300)-(favouriteRating+underdogRating)) > 0.0001 ||
+		math.Abs((1700+1300)-(upsetFavouriteRating+upsetUnderdogRating)) > 0.0001 {
+		t.Fatal("helpers.BiasGameRatingUpdate() did not keep the sum of the ratings")
+	}
+}
+
+func TestBiasGameHeadToHeadOrder(t *testing.T) {
+	groupNameA, nameA, groupNameB, nameB, swapped := BiasGameHeadToHeadOrder("TWICE", "Nayeon", "PRISTIN", "Nayoung")
+	if !swapped || groupNameA != "PRISTIN" || nameA != "Nayoung" || groupNameB != "TWICE" || nameB != "Nayeon" {
+		t.Fatal("helpers.BiasGameHeadToHeadOrder() returned the wrong order")
+	}
+
+	_, nameA, _, _, swapped = BiasGameHeadToHeadOrder("TWICE", "Mina", "TWICE", "Nayeon")
+	if swapped || nameA != "Mina" {
+		t.Fatal("helpers.BiasGameHeadToHeadOrder() swapped idols already in order")
+	}
+}
+
+func TestBiasGameBracketMatch(t *testing.T) {
+	for match, expected := range map[int][2]int{
+		0:  {0, 0},
+		7:  {0, 7},
+		8:  {1, 0},
+		11: {1, 3},
+		12: {2, 0},
+		14: {3, 0},
+	} {
+		round, position := BiasGameBracketMatch(16, match)
+		if round != expected[0] || position != expected[1] {
+			t.Fatalf("helpers.BiasGameBracketMatch(16, %d) returned round %d position %d", match, round, position)
+		}
+	}
+
+	if BiasGameBracketRounds(16) != 4 || BiasGameBracketRounds(64) != 6 {
+		t.Fatal("helpers.BiasGameBracketRounds() returned the wrong amount of rounds")
+	}
+}
+
+func TestBiasGameGuessMatches(t *testing.T) {
+	for guess, expected := range map[string]bool{
+		"nayoung":   true,
+		"NaYoung":   true,
+		"na young!": true,
+		"nayoungg":  true,
+		"nayeon":    false,
+		"":          false,
+		"!!!":       false,
+	} {
+		if BiasGameGuessMatches(guess, "Nayoung") != expected {
+			t.Fatalf("helpers.BiasGameGuessMatches(%q) should return %v", guess, expected)
+		}
+	}
+
+	// short solutions allow no typos
+	if BiasGameGuessMatches("jin", "Jun") {
+		t.Fatal("helpers.BiasGameGuessMatches() allowed a typo in a short solution")
+	}
+	if !BiasGameGuessMatches("gfriend", "GFRIEND", "여자친구") || !BiasGameGuessMatches("sonamoo", "Girls Generation", "SONAMOO") {
+		t.Fatal("helpers.BiasGameGuessMatches() did not match all solutions")
+	}
+}
+
+func TestBiasGameQuizPoints(t *testing.T) {
+	for streak, expected := range map[int]int{
+		1:  10,
+		2:  12,
+		4:  16,
+		6:  20,
+		10: 20,
+	} {
+		if points := BiasGameQuizPoints(streak); points != expected {
+			t.Fatalf("helpers.BiasGameQuizPoints(%d) returned %d instead of %d", streak, points, expected)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+const (
+	// BirthdayFormat is the format birthdays are stored in, the year is stored separately
+	BirthdayFormat = "01/02"
+)
+
+// GetUserLocation returns the location of the user's timezone, UTC if the user didn't set a timezone
+func GetUserLocation(userdata models.ProfileUserdataEntry) *time.Location {
+	if userdata.Timezone != "" {
+		location, err := time.LoadLocation(userdata.Timezone)
+		if err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
+// GetBirthdayInYear returns the start of the user's birthday in the given year in the user's timezone
+// birthdays on february 29th are celebrated on february 28th in non leap years
+func GetBirthdayInYear(userdata models.ProfileUserdataEntry, year int) (birthday time.Time, err error) {
+	parsed, err := time.Parse(BirthdayFormat, userdata.Birthday)
+	if err != nil {
+		return birthday, err
+	}
+
+	day := parsed.Day()
+	if parsed.Month() == time.February && day == 29 && !isLeapYear(year) {
+		day = 28
+	}
+
+	return time.Date(year, parsed.Month(), day, 0, 0, 0, 0, GetUserLocation(userdata)), nil
+}
+
+// GetNextBirthday returns the start of the user's next birthday in the user's timezone
+// if the user has birthday at the moment the current birthday is returned
+func GetNextBirthday(userdata models.ProfileUserdataEntry, now time.Time) (birthday time.Time, err error) {
+	now = now.In(GetUserLocation(userdata))
+
+	birthday, err = GetBirthdayInYear(userdata, now.Year())
+	if err != nil {
+		return birthday, err
+	}
+	if now.Sub(birthday) >= 24*time.Hour {
+		return GetBirthdayInYear(userdata, now.Year()+1)
+	}
+	return birthday, nil
+}
+
+// IsBirthday returns true if the user has birthday at the given time in the user's timezone
+func IsBirthday(userdata models.ProfileUserdataEntry, now time.Time) bool {
+	birthday, err := GetNextBirthday(userdata, now)
+	if err != nil {
+		return false
+	}
+	return !now.Before(birthday)
+}
+
+// GetBirthdayAge returns the age the user turns on the given birthday, 0 if the user didn't set a year or hides it
+func GetBirthdayAge(userdata models.ProfileUserdataEntry, birthday time.Time) int {
+	if userdata.BirthdayYear <= 0 || userdata.BirthdayHideYear {
+		return 0
+	}
+	age := birthday.Year() - userdata.BirthdayYear
+	if age <= 0 {
+		return 0
+	}
+	return age
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestGetNextBirthday(t *testing.T) {
+	userdata := models.ProfileUserdataEntry{Birthday: "03/14", Timezone: "Asia/Seoul"}
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+
+	// 2018-03-13 16:00 UTC is already March 14th in Seoul
+	now := time.Date(2018, time.March, 13, 16, 0, 0, 0, time.UTC)
+	birthday, err := GetNextBirthday(userdata, now)
+	if err != nil {
+		t.Fatal("helpers.GetNextBirthday() returned an error: " + err.Error())
+	}
+	if !birthday.Equal(time.Date(2018, time.March, 14, 0, 0, 0, 0, seoul)) {
+		t.Fatal("helpers.GetNextBirthday() returned the wrong birthday: " + birthday.String())
+	}
+	if !IsBirthday(userdata, now) {
+		t.Fatal("helpers.IsBirthday() didn't detect the birthday in the user's timezone")
+	}
+
+	now = time.Date(2018, time.March, 14, 16, 0, 0, 0, time.UTC)
+	birthday, _ = GetNextBirthday(userdata, now)
+	if birthday.Year() != 2019 || IsBirthday(userdata, now) {
+		t.Fatal("helpers.GetNextBirthday() didn't skip to the next year after the birthday ended")
+	}
+}
+
+func TestGetBirthdayInYearLeapDay(t *testing.T) {
+	userdata := models.ProfileUserdataEntry{Birthday: "02/29"}
+
+	birthday, err := GetBirthdayInYear(userdata, 2019)
+	if err != nil || birthday.Month() != time.February || birthday.Day() != 28 {
+		t.Fatal("helpers.GetBirthdayInYear() didn't move february 29th in a non leap year")
+	}
+	birthday, err = GetBirthdayInYear(userdata, 2020)
+	if err != nil || birthday.Month() != time.February || birthday.Day() != 29 {
+		t.Fatal("helpers.GetBirthdayInYear() moved february 29th in a leap year")
+	}
+}
+
+func TestGetBirthdayAge(t *testing.T) {
+	birthday := time.Date(2018, time.March, 14, 0, 0, 0, 0, time.UTC)
+
+	if GetBirthdayAge(models.ProfileUserdataEntry{BirthdayYear: 1995}, birthday) != 23 {
+		t.Fatal("helpers.GetBirthdayAge() returned the wrong age")
+	}
+	if GetBirthdayAge(models.ProfileUserdataEntry{BirthdayYear: 1995, BirthdayHideYear: true}, birthday) != 0 {
+		t.Fatal("helpers.GetBirthdayAge() returned an age for a hidden year")
+	}
+}
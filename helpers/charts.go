@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/ungerik/go-cairo"
+)
+
+// ChartSeries is a line in a line chart, Values has to have one value per label
+type ChartSeries struct {
+	Name   string
+	Values []float64
+	Color  string // as hex string
+}
+
+// DrawLineChart draws a line chart with a legend and returns it as png
+func DrawLineChart(title string, labels []string, series []ChartSeries, width, height int) (pngBytes []byte) {
+	return drawLineChart(title, labels, series, width, height, false)
+}
+
+// DrawRankChart draws a line chart of ranks with the first rank at the top and returns it as png
+// values of zero are not ranked and leave a gap in the line
+func DrawRankChart(title string, labels []string, series []ChartSeries, width, height int) (pngBytes []byte) {
+	return drawLineChart(title, labels, series, width, height, true)
+}
+
+func drawLineChart(title string, labels []string, series []ChartSeries, width, height int, ranks bool) (pngBytes []byte) {
+	const (
+		paddingLeft   = 60.0
+		paddingRight  = 20.0
+		paddingTop    = 60.0
+		paddingBottom = 40.0
+		gridLines     = 5
+		maxLabels     = 8
+	)
+
+	surface := cairo.NewSurface(cairo.FORMAT_RGB24, width, height)
+	background, _ := colorful.Hex("#36393e")
+	surface.SetSourceRGB(background.R, background.G, background.B)
+	surface.Paint()
+	surface.SelectFontFace("UnDotum", cairo.FONT_SLANT_NORMAL, cairo.FONT_WEIGHT_NORMAL)
+
+	chartWidth := float64(width) - paddingLeft - paddingRight
+	chartHeight := float64(height) - paddingTop - paddingBottom
+
+	// title
+	surface.SetFontSize(20)
+	surface.SetSourceRGB(1, 1, 1)
+	surface.MoveTo(paddingLeft, 32)
+	surface.ShowText(title)
+
+	// legend, right aligned
+	surface.SetFontSize(14)
+	legendX := float64(width) - paddingRight
+	for i := len(series) - 1; i >= 0; i-- {
+		extents := surface.TextExtents(series[i].Name)
+		legendX -= extents.Width
+		surface.SetSourceRGB(1, 1, 1)
+		surface.MoveTo(legendX, 32)
+		surface.ShowText(series[i].Name)
+		legendX -= 18
+		color, _ := colorful.Hex(series[i].Color)
+		surface.SetSourceRGB(color.R, color.G, color.B)
+		surface.Rectangle(legendX, 22, 12, 12)
+		surface.Fill()
+		legendX -= 16
+	}
+
+	maxValue := 1.0
+	for _, item := range series {
+		for _, value := range item.Values {
+			maxValue = math.Max(maxValue, value)
+		}
+	}
+	// round up to a multiple of the grid lines to get whole numbers as grid labels
+	maxValue = math.Ceil(maxValue/gridLines) * gridLines
+
+	yForValue := func(value float64) float64 {
+		if ranks {
+			return paddingTop + chartHeight*(value-1)/(maxValue-1)
+		}
+		return paddingTop + chartHeight - chartHeight*value/maxValue
+	}
+
+	// grid and y labels
+	surface.SetFontSize(12)
+	surface.SetLineWidth(1)
+	for i := 0; i <= gridLines; i++ {
+		value := maxValue * float64(i) / gridLines
+		if ranks && i == 0 {
+			value = 1
+		}
+		y := yForValue(value)
+		surface.SetSourceRGB(0.3, 0.3, 0.33)
+		surface.MoveTo(paddingLeft, y)
+		surface.LineTo(paddingLeft+chartWidth, y)
+		surface.Stroke()
+
+		label := strconv.FormatFloat(value, 'f', 0, 64)
+		if ranks {
+			label = "#" + label
+		}
+		extents := surface.TextExtents(label)
+		surface.SetSourceRGB(0.8, 0.8, 0.8)
+		surface.MoveTo(paddingLeft-8-extents.Width, y+4)
+		surface.ShowText(label)
+	}
+
+	xForIndex := func(i int) float64 {
+		if len(labels) <= 1 {
+			return paddingLeft + chartWidth/2
+		}
+		return paddingLeft + chartWidth*float64(i)/float64(len(labels)-1)
+	}
+
+	// x labels
+	labelStep := int(math.Ceil(float64(len(labels)) / maxLabels))
+	if labelStep < 1 {
+		labelStep = 1
+	}
+	surface.SetSourceRGB(0.8, 0.8, 0.8)
+	for i := 0; i < len(labels); i += labelStep {
+		extents := surface.TextExtents(labels[i])
+		surface.MoveTo(xForIndex(i)-extents.Width/2, float64(height)-paddingBottom+20)
+		surface.ShowText(labels[i])
+	}
+
+	// lines
+	surface.SetLineWidth(2.5)
+	for _, item := range series {
+		color, _ := colorful.Hex(item.Color)
+		surface.SetSourceRGB(color.R, color.G, color.B)
+		drawing := false
+		for i, value := range item.Values {
+			if ranks && value <= 0 {
+				drawing = false
+				continue
+			}
+			if !drawing {
+				if ranks {
+					// finish the previous line and draw a dot, ranks after a gap have no line to the previous rank
+					surface.Stroke()
+					surface.Arc(xForIndex(i), yForValue(value), 2.5, 0, 2*math.Pi)
+					surface.Fill()
+				}
+				surface.MoveTo(xForIndex(i), yForValue(value))
+				drawing = true
+			} else {
+				surface.LineTo(xForIndex(i), yForValue(value))
+			}
+		}
+		surface.Stroke()
+	}
+
+	pngBytes, _ = surface.WriteToPNGStream()
+	return pngBytes
+}
@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"strings"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+const (
+	ChartsChangeEntry   = "entry"   // entered the chart
+	ChartsChangePeak    = "peak"    // reached a new best rank
+	ChartsChangeTop     = "top"     // reached #1
+	ChartsChangeDropOut = "dropout" // left the chart
+)
+
+// ChartsRankChange is a change of a song between two snapshots of a chart
+type ChartsRankChange struct {
+	Type         string
+	Rank         models.ChartsRank // the previous rank for drop outs
+	PreviousRank int               // zero for entries
+}
+
+// ChartsRankKey returns the key identifying a song across snapshots of a chart
+func ChartsRankKey(rank models.ChartsRank) string {
+	return normalizeChartsText(rank.Artist) + "\n" + normalizeChartsText(rank.Title)
+}
+
+// ChartsRankChanges compares two snapshots of a chart and returns at most one change per song
+// peaks	: the best ranks of the songs before the current snapshot by ChartsRankKey
+func ChartsRankChanges(previous, current []models.ChartsRank, peaks map[string]int) (changes []ChartsRankChange) {
+	previousRanks := make(map[string]int)
+	for _, rank := range previous {
+		previousRanks[ChartsRankKey(rank)] = rank.Rank
+	}
+
+	currentKeys := make(map[string]bool)
+	for _, rank := range current {
+		key := ChartsRankKey(rank)
+		currentKeys[key] = true
+
+		previousRank, wasCharted := previousRanks[key]
+		peak, hasPeak := peaks[key]
+		if !hasPeak || (wasCharted && previousRank < peak) {
+			peak = previousRank
+		}
+
+		switch {
+		case rank.Rank == 1 && previousRank != 1:
+			changes = append(changes, ChartsRankChange{Type: ChartsChangeTop, Rank: rank, PreviousRank: previousRank})
+		case !wasCharted:
+			changes = append(changes, ChartsRankChange{Type: ChartsChangeEntry, Rank: rank})
+		case rank.Rank < previousRank && rank.Rank < peak:
+			changes = append(changes, ChartsRankChange{Type: ChartsChangePeak, Rank: rank, PreviousRank: previousRank})
+		}
+	}
+
+	for _, rank := range previous {
+		if !currentKeys[ChartsRankKey(rank)] {
+			changes = append(changes, ChartsRankChange{Type: ChartsChangeDropOut, Rank: rank, PreviousRank: rank.Rank})
+		}
+	}
+
+	return changes
+}
+
+// ChartsSubscriptionMatches returns true if the song of the rank is watched by the subscription
+// artists match if the query is part of the artist, to match features and groups of multiple artists
+func ChartsSubscriptionMatches(subscription models.ChartsSubscriptionEntry, rank models.ChartsRank) bool {
+	query := normalizeChartsText(subscription.Query)
+	if query == "" {
+		return false
+	}
+
+	switch subscription.Type {
+	case models.ChartsSubscriptionTypeArtist:
+		return strings.Contains(normalizeChartsText(rank.Artist), query)
+	case models.ChartsSubscriptionTypeSong:
+		return normalizeChartsText(rank.Title) == query
+	}
+	return false
+}
+
+// normalizeChartsText lowers the text and removes all spaces, other characters are kept to not break korean titles
+func normalizeChartsText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), ""))
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestChartsRankChanges(t *testing.T) {
+	previous := []models.ChartsRank{
+		{Rank: 1, Title: "Love Scenario", Artist: "iKON"},
+		{Rank: 2, Title: "Bboom Bboom", Artist: "MOMOLAND"},
+		{Rank: 3, Title: "What is Love?", Artist: "TWICE"},
+		{Rank: 4, Title: "Bad Boy", Artist: "Red Velvet"},
+	}
+	current := []models.ChartsRank{
+		{Rank: 1, Title: "What is Love?", Artist: "TWICE"},
+		{Rank: 2, Title: "Love Scenario", Artist: "iKON"},
+		{Rank: 3, Title: "Bad Boy", Artist: "Red Velvet"},
+		{Rank: 4, Title: "Fake Love", Artist: "BTS"},
+	}
+	peaks := map[string]int{
+		ChartsRankKey(previous[0]): 1,
+		ChartsRankKey(previous[1]): 2,
+		ChartsRankKey(previous[2]): 3,
+		// had been higher before
+		ChartsRankKey(previous[3]): 2,
+	}
+
+	changes := make(map[string]ChartsRankChange)
+	for _, change := range ChartsRankChanges(previous, current, peaks) {
+		changes[change.Rank.Title] = change
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("helpers.ChartsRankChanges() returned %d changes instead of 3: %+v", len(changes), changes)
+	}
+	if changes["What is Love?"].Type != ChartsChangeTop || changes["What is Love?"].PreviousRank != 3 {
+		t.Fatalf("helpers.ChartsRankChanges() returned the wrong change for a new #1: %+v", changes["What is Love?"])
+	}
+	if changes["Fake Love"].Type != ChartsChangeEntry {
+		t.Fatalf("helpers.ChartsRankChanges() returned the wrong change for an entry: %+v", changes["Fake Love"])
+	}
+	if changes["Bboom Bboom"].Type != ChartsChangeDropOut || changes["Bboom Bboom"].PreviousRank != 2 {
+		t.Fatalf("helpers.ChartsRankChanges() returned the wrong change for a drop out: %+v", changes["Bboom Bboom"])
+	}
+	if _, ok := changes["Bad Boy"]; ok {
+		t.Fatal("helpers.ChartsRankChanges() returned a peak below the previous peak")
+	}
+
+	// without a known peak a rise above the previous rank is a new peak
+	peakChanges := ChartsRankChanges(previous, []models.ChartsRank{{Rank: 2, Title: "Bad Boy", Artist: "Red Velvet"}}, nil)
+	if len(peakChanges) != 4 || peakChanges[0].Type != ChartsChangePeak {
+		t.Fatalf("helpers.ChartsRankChanges() did not return a new peak: %+v", peakChanges)
+	}
+}
+
+func TestChartsSubscriptionMatches(t *testing.T) {
+	rank := models.ChartsRank{Rank: 1, Title: "What is Love?", Artist: "TWICE (트와이스)"}
+
+	for subscription, expected := range map[models.ChartsSubscriptionEntry]bool{
+		{Type: models.ChartsSubscriptionTypeArtist, Query: "twice"}:        true,
+		{Type: models.ChartsSubscriptionTypeArtist, Query: "트와이스"}:         true,
+		{Type: models.ChartsSubscriptionTypeArtist, Query: "red velvet"}:   false,
+		{Type: models.ChartsSubscriptionTypeSong, Query: "what is love?"}:  true,
+		{Type: models.ChartsSubscriptionTypeSong, Query: "WhatIsLove?"}:    true,
+		{Type: models.ChartsSubscriptionTypeSong, Query: "what is"}:        false,
+		{Type: models.ChartsSubscriptionTypeSong, Query: " "}:              false,
+		{Type: models.ChartsSubscriptionTypeArtist, Query: "twice (트와이스)"}: true,
+	} {
+		if ChartsSubscriptionMatches(subscription, rank) != expected {
+			t.Fatalf("helpers.ChartsSubscriptionMatches(%+v) should return %v", subscription, expected)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package helpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type ChartsSongScore struct {
+	Title         string
+	Artist        string
+	Album         string
+	CurrentRank   int
+	PastRank      int
+	IsNew         bool
+	MusicVideoUrl string
+}
+
+type ChartsAlbumScore struct {
+	Artist      string
+	Album       string
+	CurrentRank int
+	PastRank    int
+	IsNew       bool
+}
+
+// ParseMelonChart parses the realtime or the daily chart page of melon, the daily chart time has no hour
+// limit	: the maximum amount of ranks to parse
+func ParseMelonChart(doc *goquery.Document, withHour bool, limit int) (time string, ranks []ChartsSongScore) {
+	ranks = make([]ChartsSongScore, 0)
+
+	time = doc.Find(".calendar_prid > .yyyymmdd > .year").Text()
+	if withHour {
+		time += " " + doc.Find(".calendar_prid > .hhmm > .hour").Text()
+	}
+
+	doc.Find(".lst50").EachWithBreak(func(i int, selection *goquery.Selection) bool {
+		if i >= limit {
+			return false
+		}
+
+		currentRank := ChartsSongScore{}
+		node := goquery.NewDocumentFromNode(selection.Get(0))
+
+		currentRank.Title = strings.TrimSpace(node.Find(".rank01 > span > a").Text())
+		currentRank.Artist = strings.TrimSpace(node.Find(".rank02 > a").First().Text())
+		currentRank.Album = strings.TrimSpace(node.Find(".rank03 > a").Text())
+		currentRankN, _ := strconv.Atoi(strings.TrimSpace(node.Find(".rank").Text()))
+		currentRank.CurrentRank = currentRankN
+		currentRank.PastRank = currentRank.CurrentRank
+
+		if len(node.Find("td").Nodes) > 2 {
+			rankingChangeDoc := goquery.NewDocumentFromNode(node.Find("td").Get(2))
+			if len(rankingChangeDoc.Find(".up").Nodes) > 0 {
+				rankUpChangeN, _ := strconv.Atoi(strings.TrimSpace(rankingChangeDoc.Find(".up").Text()))
+				currentRank.PastRank += rankUpChangeN
+			}
+			if len(rankingChangeDoc.Find(".down").Nodes) > 0 {
+				rankDownChangeN, _ := strconv.Atoi(strings.TrimSpace(rankingChangeDoc.Find(".down").Text()))
+				currentRank.PastRank -= rankDownChangeN
+			}
+		}
+
+		ranks = append(ranks, currentRank)
+		return true
+	})
+
+	return time, ranks
+}
+
+// ParseIChartChart parses the realtime or the weekly chart page of iChart
+// limit	: the maximum amount of ranks to parse
+func ParseIChartChart(doc *goquery.Document, limit int) (time string, ranks []ChartsSongScore, maintenance bool, overloaded bool) {
+	if IChartInMaintenance(doc) {
+		return "", ranks, true, false
+	}
+
+	if IChartOverloaded(doc) {
+		return "", ranks, false, true
+	}
+
+	time = strings.TrimSpace(strings.Replace(doc.Find("#content > div.ichart_score_title > div.ichart_score_title_right.minitext3").Text(), "기준", "", -1))
+
+	if limit <= 0 || len(doc.Find("#score_1st").Nodes) <= 0 {
+		return time, ranks, false, false
+	}
+
+	// the first rank has its own layout
+	firstRank := ChartsSongScore{CurrentRank: 1, PastRank: 1}
+	firstRank.Title = doc.Find("#score_1st > div.ichart_score_song > div.ichart_score_song1 > b").Text()
+	firstRank.Artist = doc.Find("#score_1st > div.ichart_score_artist > div.ichart_score_artist1 > b").Text()
+	firstRank.Album = doc.Find("#score_1st > div.ichart_score_song > div.ichart_score_song2 > span > a").Text()
+	if musicVideoUrl, ok := doc.Find("#yttop").Attr("href"); ok {
+		firstRank.MusicVideoUrl = IChartHrefExtractMVUrl(musicVideoUrl)
+	}
+	if len(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow1").Nodes) > 0 {
+		pastRankUncalculated, err := strconv.Atoi(strings.TrimSpace(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow1").Parent().Text()))
+		if err == nil {
+			firstRank.PastRank = firstRank.CurrentRank + pastRankUncalculated
+		}
+	}
+	if len(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow2").Nodes) > 0 {
+		pastRankUncalculated, err := strconv.Atoi(strings.TrimSpace(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow2").Parent().Text()))
+		if err == nil {
+			firstRank.PastRank = firstRank.CurrentRank - pastRankUncalculated
+		}
+	}
+	ranks = append(ranks, firstRank)
+
+	items := doc.Find("#content > div.spage_intistore_body > div.spage_score_item")
+	submenus := doc.Find("#content > div.spage_intistore_body > div.ichart_submenu")
+	for i := 0; i < len(items.Nodes) && len(ranks) < limit; i++ {
+		currentRank := ChartsSongScore{CurrentRank: i + 2, PastRank: i + 2}
+
+		itemDoc := goquery.NewDocumentFromNode(items.Get(i))
+		currentRank.Title = itemDoc.Find("div.ichart_score2_song > div.ichart_score2_song1").Text()
+		currentRank.Artist = itemDoc.Find("div.ichart_score2_artist > div.ichart_score2_artist1").Text()
+		currentRank.Album = itemDoc.Find("div.ichart_score2_song > div.ichart_score2_song2 > span > a").Text()
+		if i < len(submenus.Nodes) {
+			submenuDoc := goquery.NewDocumentFromNode(submenus.Get(i))
+			if musicVideoUrl, ok := submenuDoc.Find("ul > li.ichart_mv > a").Attr("href"); ok {
+				currentRank.MusicVideoUrl = IChartHrefExtractMVUrl(musicVideoUrl)
+			}
+		}
+		if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow1").Nodes) > 0 {
+			pastRankUncalculated, err := strconv.Atoi(strings.TrimSpace(itemDoc.Find("div.ichart_score2_change.rank").Text()))
+			if err == nil {
+				currentRank.PastRank = currentRank.CurrentRank + pastRankUncalculated
+			}
+		}
+		if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow2").Nodes) > 0 {
+			pastRankUncalculated, err := strconv.Atoi(strings.TrimSpace(itemDoc.Find("div.ichart_score2_change.rank").Text()))
+			if err == nil {
+				currentRank.PastRank = currentRank.CurrentRank - pastRankUncalculated
+			}
+		}
+		if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow4").Nodes) > 0 {
+			currentRank.IsNew = true
+		}
+
+		ranks = append(ranks, currentRank)
+	}
+
+	return time, ranks, false, false
+}
+
+func IChartInMaintenance(doc *goquery.Document) bool {
+	if strings.Contains(doc.Text(), "서버 점검으로 인해 현재 서비스가 일시 중단되었습니다") { // maintenance
+		return true
+	}
+	return false
+}
+
+func IChartOverloaded(doc *goquery.Document) bool {
+	isOverloaded := false
+	if strings.Contains(doc.Text(), "사이트 이용자가 많습니다") {
+		isOverloaded = true
+	}
+	return isOverloaded
+}
+
+func IChartHrefExtractMVUrl(musicVideoUrl string) string {
+	if strings.Contains(musicVideoUrl, "javascript:show_youtube") {
+		parts := strings.Split(musicVideoUrl, "'")
+		if len(parts) == 3 {
+			return "https://youtu.be/" + parts[1]
+		}
+	}
+	return ""
+}
+
+// ParseGaonChart parses the weekly, monthly, or yearly album chart page of gaon
+// limit	: the maximum amount of ranks to parse
+func ParseGaonChart(doc *goquery.Document, limit int) (time string, ranks []ChartsAlbumScore) {
+	time = strings.TrimSpace(strings.Replace(doc.Find("#wrap > div.now > div.fl").Text(), "Album Chart", "", -1))
+
+	// the first row is the header
+	rows := len(doc.Find("#wrap > div.chart > table > tbody > tr").Nodes) - 1
+	for i := 0; i < rows && i < limit; i++ {
+		currentRank := ChartsAlbumScore{CurrentRank: i + 1, PastRank: i + 1}
+		row := fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d)", currentRank.CurrentRank+1)
+
+		currentRank.Album = strings.TrimSpace(doc.Find(row + " > td.subject > p:nth-child(1)").Text())
+		currentRank.Artist = strings.TrimSpace(doc.Find(row + " > td.subject > p.singer").Text())
+		if len(doc.Find(row+" > td.change > .up").Nodes) > 0 {
+			pastRankUncalculated, err := strconv.Atoi(strings.TrimSpace(doc.Find(row + " > td.change > .up").Text()))
+			if err == nil {
+				currentRank.PastRank = currentRank.CurrentRank + pastRankUncalculated
+			}
+		}
+		if len(doc.Find(row+" > td.change > .down").Nodes) > 0 {
+			pastRankUncalculated, err := strconv.Atoi(strings.TrimSpace(doc.Find(row + " > td.change > .down").Text()))
+			if err == nil {
+				currentRank.PastRank = currentRank.CurrentRank - pastRankUncalculated
+			}
+		}
+		if len(doc.Find(row+" > td.change > .new").Nodes) > 0 {
+			currentRank.IsNew = true
+		}
+
+		ranks = append(ranks, currentRank)
+	}
+
+	return time, ranks
+}
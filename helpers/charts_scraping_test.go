@@ -0,0 +1,149 @@
+package helpers
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// the fixtures in testdata/charts are hand-written after the markup of the chart pages, they are not captured responses
+// run go test -run TestCaptureChartsFixtures -charts.capture to replace them with the live pages, the capture date is written to testdata/charts/CAPTURED
+// the expected ranks below have to be updated after capturing
+var captureChartsFixtures = flag.Bool("charts.capture", false, "download the chart pages into testdata/charts")
+
+func TestCaptureChartsFixtures(t *testing.T) {
+	if !*captureChartsFixtures {
+		t.Skip("use -charts.capture to download the chart pages")
+	}
+
+	for name, pageURL := range map[string]string{
+		"melon.html":  "http://www.melon.com/chart/index.htm",
+		"ichart.html": "http://www.instiz.net/iframe_ichart_score.htm?real=1",
+		"gaon.html":   "http://gaonchart.co.kr/main/section/chart/album.gaon?nationGbn=T&serviceGbn=&termGbn=week",
+	} {
+		data, err := NetGetUAWithError(pageURL, DEFAULT_UA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile("testdata/charts/"+name, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := ioutil.WriteFile("testdata/charts/CAPTURED", []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadChartsFixture(t *testing.T, name string) *goquery.Document {
+	file, err := os.Open("testdata/charts/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParseMelonChart(t *testing.T) {
+	doc := loadChartsFixture(t, "melon.html")
+
+	chartTime, ranks := ParseMelonChart(doc, true, 10)
+	if chartTime != "2018.05.21 15:00" {
+		t.Fatalf("helpers.ParseMelonChart() returned the wrong time: %s", chartTime)
+	}
+	expected := []ChartsSongScore{
+		{Title: "What is Love?", Artist: "TWICE (트와이스)", Album: "What is Love?", CurrentRank: 1, PastRank: 3},
+		{Title: "Love Scenario", Artist: "iKON", Album: "Return", CurrentRank: 2, PastRank: 1},
+		{Title: "Bboom Bboom", Artist: "MOMOLAND (모모랜드)", Album: "GREAT!", CurrentRank: 3, PastRank: 3},
+	}
+	if len(ranks) != len(expected) {
+		t.Fatalf("helpers.ParseMelonChart() returned %d ranks instead of %d", len(ranks), len(expected))
+	}
+	for i := range expected {
+		if ranks[i] != expected[i] {
+			t.Fatalf("helpers.ParseMelonChart() returned %+v instead of %+v", ranks[i], expected[i])
+		}
+	}
+
+	chartTime, ranks = ParseMelonChart(doc, false, 2)
+	if chartTime != "2018.05.21" || len(ranks) != 2 {
+		t.Fatalf("helpers.ParseMelonChart() ignored the options: %s, %d ranks", chartTime, len(ranks))
+	}
+}
+
+func TestParseIChartChart(t *testing.T) {
+	doc := loadChartsFixture(t, "ichart.html")
+
+	chartTime, ranks, maintenance, overloaded := ParseIChartChart(doc, 10)
+	if maintenance || overloaded {
+		t.Fatal("helpers.ParseIChartChart() detected maintenance or an overload")
+	}
+	if chartTime != "2018.05.21 15:00" {
+		t.Fatalf("helpers.ParseIChartChart() returned the wrong time: %s", chartTime)
+	}
+	expected := []ChartsSongScore{
+		{Title: "What is Love?", Artist: "TWICE", Album: "What is Love?", CurrentRank: 1, PastRank: 3, MusicVideoUrl: "https://youtu.be/i0p1bmr0EmE"},
+		{Title: "Love Scenario", Artist: "iKON", Album: "Return", CurrentRank: 2, PastRank: 1, MusicVideoUrl: "https://youtu.be/vecSVX1QYbQ"},
+		{Title: "Fake Love", Artist: "BTS", Album: "LOVE YOURSELF 轉 'Tear'", CurrentRank: 3, PastRank: 3, IsNew: true},
+	}
+	if len(ranks) != len(expected) {
+		t.Fatalf("helpers.ParseIChartChart() returned %d ranks instead of %d", len(ranks), len(expected))
+	}
+	for i := range expected {
+		if ranks[i] != expected[i] {
+			t.Fatalf("helpers.ParseIChartChart() returned %+v instead of %+v", ranks[i], expected[i])
+		}
+	}
+
+	maintenanceDoc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		"<html><body>서버 점검으로 인해 현재 서비스가 일시 중단되었습니다</body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ranks, maintenance, _ = ParseIChartChart(maintenanceDoc, 10); !maintenance || len(ranks) != 0 {
+		t.Fatal("helpers.ParseIChartChart() did not detect the maintenance")
+	}
+}
+
+func TestParseGaonChart(t *testing.T) {
+	doc := loadChartsFixture(t, "gaon.html")
+
+	chartTime, ranks := ParseGaonChart(doc, 10)
+	if chartTime != "2018.05.13~2018.05.19" {
+		t.Fatalf("helpers.ParseGaonChart() returned the wrong time: %s", chartTime)
+	}
+	expected := []ChartsAlbumScore{
+		{Artist: "BTS", Album: "LOVE YOURSELF 轉 'Tear'", CurrentRank: 1, PastRank: 1, IsNew: true},
+		{Artist: "TWICE", Album: "What is Love?", CurrentRank: 2, PastRank: 1},
+		{Artist: "iKON", Album: "Return", CurrentRank: 3, PastRank: 7},
+	}
+	if len(ranks) != len(expected) {
+		t.Fatalf("helpers.ParseGaonChart() returned %d ranks instead of %d", len(ranks), len(expected))
+	}
+	for i := range expected {
+		if ranks[i] != expected[i] {
+			t.Fatalf("helpers.ParseGaonChart() returned %+v instead of %+v", ranks[i], expected[i])
+		}
+	}
+}
+
+func TestIChartHrefExtractMVUrl(t *testing.T) {
+	if url := IChartHrefExtractMVUrl("javascript:show_youtube('abc')"); url != "https://youtu.be/abc" {
+		t.Fatalf("helpers.IChartHrefExtractMVUrl() returned %s", url)
+	}
+	if url := IChartHrefExtractMVUrl("https://example.com"); url != "" {
+		t.Fatalf("helpers.IChartHrefExtractMVUrl() returned %s", url)
+	}
+}
@@ -12,8 +12,26 @@ import (
 
 var visionClient *vision.ImageAnnotatorClient
 
+// PictureSafetyRatings are the likelihoods of the cloud vision safe search categories of a picture
+type PictureSafetyRatings struct {
+	Adult    string
+	Medical  string
+	Violence string
+	Racy     string
+}
+
 func PictureIsSafe(reader io.Reader) (safe bool) {
-	var err error
+	ratings, err := GetPictureSafetyRatings(reader)
+	if err != nil {
+		RelaxLog(err)
+		return false
+	}
+
+	return ratings.Safe()
+}
+
+// GetPictureSafetyRatings runs a cloud vision safe search on a picture
+func GetPictureSafetyRatings(reader io.Reader) (ratings PictureSafetyRatings, err error) {
 	ctx := context.Background()
 
 	if visionClient == nil {
@@ -24,38 +42,37 @@ func PictureIsSafe(reader io.Reader) (safe bool) {
 
 		visionClient, err = vision.NewImageAnnotatorClient(ctx)
 		if err != nil {
-			RelaxLog(err)
-			return false
+			return ratings, err
 		}
 	}
 
 	image, err := vision.NewImageFromReader(reader)
 	if err != nil {
-		RelaxLog(err)
-		return false
+		return ratings, err
 	}
 
 	safeData, err := visionClient.DetectSafeSearch(ctx, image, nil)
 	if err != nil {
-		RelaxLog(err)
-		return false
+		return ratings, err
 	}
 
-	if safeData.GetAdult() == vision2.Likelihood_VERY_LIKELY {
-		return false
-	}
-
-	if safeData.GetMedical() == vision2.Likelihood_VERY_LIKELY {
-		return false
-	}
-
-	if safeData.GetViolence() == vision2.Likelihood_VERY_LIKELY {
-		return false
-	}
+	ratings.Adult = safeData.GetAdult().String()
+	ratings.Medical = safeData.GetMedical().String()
+	ratings.Violence = safeData.GetViolence().String()
+	ratings.Racy = safeData.GetRacy().String()
+	return ratings, nil
+}
 
-	if safeData.GetRacy() == vision2.Likelihood_VERY_LIKELY {
-		return false
+// Safe returns false if any category is very likely
+func (r PictureSafetyRatings) Safe() bool {
+	for _, likelihood := range []string{r.Adult, r.Medical, r.Violence, r.Racy} {
+		if likelihood == vision2.Likelihood_VERY_LIKELY.String() {
+			return false
+		}
 	}
-
 	return true
 }
+
+func (r PictureSafetyRatings) String() string {
+	return "adult: " + r.Adult + ", medical: " + r.Medical + ", violence: " + r.Violence + ", racy: " + r.Racy
+}
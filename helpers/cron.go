@@ -0,0 +1,175 @@
+package helpers
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed standard five field cron expression (minute hour day-of-month month day-of-week)
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+type cronBounds struct {
+	min, max int
+}
+
+var (
+	cronMinuteBounds = cronBounds{0, 59}
+	cronHourBounds   = cronBounds{0, 23}
+	cronDomBounds    = cronBounds{1, 31}
+	cronMonthBounds  = cronBounds{1, 12}
+	cronDowBounds    = cronBounds{0, 7}
+
+	cronFieldRegex = regexp.MustCompile(`^[0-9*,/-]+$`)
+
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// IsCronExpression returns true if the input looks like a cron expression, it does not validate the values
+func IsCronExpression(input string) bool {
+	input = strings.TrimSpace(input)
+	if _, ok := cronMacros[strings.ToLower(input)]; ok {
+		return true
+	}
+	fields := strings.Fields(input)
+	if len(fields) != 5 {
+		return false
+	}
+	for _, field := range fields {
+		if !cronFieldRegex.MatchString(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseCron parses a standard five field cron expression, or one of the @yearly, @monthly, @weekly, @daily, @hourly macros
+func ParseCron(spec string) (schedule *CronSchedule, err error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.New("cron expression has to have five fields")
+	}
+
+	schedule = new(CronSchedule)
+	if schedule.minute, err = parseCronField(fields[0], cronMinuteBounds); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], cronHourBounds); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = parseCronField(fields[2], cronDomBounds); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = parseCronField(fields[3], cronMonthBounds); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = parseCronField(fields[4], cronDowBounds); err != nil {
+		return nil, err
+	}
+	// sunday can be 0 or 7
+	if schedule.dow&(1<<7) > 0 {
+		schedule.dow |= 1 << 0
+	}
+	schedule.domStar = fields[2] == "*"
+	schedule.dowStar = fields[4] == "*"
+
+	return schedule, nil
+}
+
+func parseCronField(field string, bounds cronBounds) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		rangeText := part
+		if strings.Contains(part, "/") {
+			stepParts := strings.SplitN(part, "/", 2)
+			rangeText = stepParts[0]
+			step, err = strconv.Atoi(stepParts[1])
+			if err != nil || step <= 0 {
+				return 0, errors.New("invalid cron step: " + part)
+			}
+		}
+
+		start, end := bounds.min, bounds.max
+		if rangeText != "*" {
+			rangeParts := strings.SplitN(rangeText, "-", 2)
+			start, err = strconv.Atoi(rangeParts[0])
+			if err != nil {
+				return 0, errors.New("invalid cron value: " + part)
+			}
+			end = start
+			if len(rangeParts) == 2 {
+				end, err = strconv.Atoi(rangeParts[1])
+				if err != nil {
+					return 0, errors.New("invalid cron value: " + part)
+				}
+			} else if strings.Contains(part, "/") {
+				end = bounds.max
+			}
+		}
+
+		if start < bounds.min || end > bounds.max || start > end {
+			return 0, errors.New("cron value out of range: " + part)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t matching the schedule, in the location of t
+// returns a zero time if there is no matching time within the next five years
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// if both day fields are restricted a day matches if either field matches, same as the original cron
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) > 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) > 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
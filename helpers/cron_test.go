@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Fatalf("helpers.ParseCron() accepted the invalid expression %q", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	start := time.Date(2018, time.March, 14, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2018, time.March, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2018, time.March, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 20 * * *", time.Date(2018, time.March, 14, 20, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1", time.Date(2018, time.March, 19, 8, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2018, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 9 29 2 *", time.Date(2020, time.February, 29, 9, 30, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2018, time.March, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * 5", time.Date(2018, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2018, time.March, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.spec)
+		if err != nil {
+			t.Fatalf("helpers.ParseCron() failed to parse %q: %s", test.spec, err.Error())
+		}
+		next := schedule.Next(start)
+		if !next.Equal(test.next) {
+			t.Fatalf("helpers.CronSchedule.Next() for %q returned %s, expected %s", test.spec, next, test.next)
+		}
+	}
+}
+
+func TestIsCronExpression(t *testing.T) {
+	if !IsCronExpression("0 20 * * 5") || !IsCronExpression("@weekly") {
+		t.Fatal("helpers.IsCronExpression() didn't detect a cron expression")
+	}
+	if IsCronExpression("tomorrow at 8pm") || IsCronExpression("next friday at 8 pm") {
+		t.Fatal("helpers.IsCronExpression() detected a cron expression in a time text")
+	}
+}
@@ -883,6 +883,11 @@ func GetMessage(channelID string, messageID string) (*discordgo.Message, error)
 	return targetMessage, nil
 }
 
+// GetMessageLink returns the jump link to a message
+func GetMessageLink(guildID, channelID, messageID string) string {
+	return fmt.Sprintf("https://discordapp.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
 func GetChannelFromMention(msg *discordgo.Message, mention string) (*discordgo.Channel, error) {
 	result, err := GetChannelOfAnyTypeFromMention(msg, mention)
 	if err != nil {
@@ -1606,3 +1611,31 @@ func ReplaceEmojis(content string) (result string) {
 
 	return content
 }
+
+// MentionRoles returns the mentions for the given roles, roles which are not mentionable are made mentionable
+// call reset after sending the message to make the roles unmentionable again
+func MentionRoles(guildID string, roleIDs []string) (mentionsText string, reset func()) {
+	madeMentionable := make([]*discordgo.Role, 0)
+	for _, roleID := range roleIDs {
+		role, err := cache.GetSession().State.Role(guildID, roleID)
+		if err != nil {
+			continue
+		}
+		if !role.Mentionable {
+			_, err = cache.GetSession().GuildRoleEdit(
+				guildID, role.ID, role.Name, role.Color, role.Hoist, role.Permissions, true)
+			if err == nil {
+				madeMentionable = append(madeMentionable, role)
+			}
+		}
+		mentionsText += "<@&" + role.ID + "> "
+	}
+
+	return mentionsText, func() {
+		for _, role := range madeMentionable {
+			_, err := cache.GetSession().GuildRoleEdit(
+				guildID, role.ID, role.Name, role.Color, role.Hoist, role.Permissions, false)
+			RelaxLog(err)
+		}
+	}
+}
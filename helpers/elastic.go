@@ -43,6 +43,8 @@ func ElasticOnMessageCreate(session *discordgo.Session, message *discordgo.Messa
 	go func() {
 		defer Recover()
 
+		go OnEventlogMessageCreate(channel.GuildID, message.Message)
+
 		err := ElasticAddMessage(message.Message)
 		if err != nil {
 			if errE, ok := err.(*elastic.Error); ok {
@@ -137,6 +139,41 @@ func ElasticOnMessageDelete(session *discordgo.Session, message *discordgo.Messa
 	}()
 }
 
+func ElasticOnMessageDeleteBulk(session *discordgo.Session, messages *discordgo.MessageDeleteBulk) {
+	channel, err := GetChannelWithoutApi(messages.ChannelID)
+	if err != nil {
+		return
+	}
+
+	if IsBlacklistedGuild(channel.GuildID) {
+		return
+	}
+
+	if IsLimitedGuild(channel.GuildID) {
+		return
+	}
+
+	if channel.Type == discordgo.ChannelTypeDM {
+		return
+	}
+
+	go func() {
+		defer Recover()
+
+		err := ElasticDeleteMessageBulk(messages.ChannelID, messages.Messages)
+		if err != nil {
+			if errE, ok := err.(*elastic.Error); ok {
+				if errE.Status == 429 {
+					cache.GetLogger().WithField("module", "elastic").Warn(
+						"unable to log MessageDeleteBulk event, too many requests")
+					return
+				}
+			}
+			RelaxLog(err)
+		}
+	}()
+}
+
 func ElasticOnGuildMemberRemove(session *discordgo.Session, member *discordgo.GuildMemberRemove) {
 	go func() {
 		defer Recover()
@@ -316,6 +353,8 @@ func ElasticUpdateMessage(message *discordgo.Message) error {
 		return nil
 	}
 
+	go OnEventlogMessageUpdate(channel.GuildID, oldElasticMessage, message)
+
 	if len(oldElasticMessage.Content) >= 10 {
 		return nil
 	}
@@ -349,23 +388,62 @@ func ElasticDeleteMessage(message *discordgo.Message) error {
 		return nil
 	}
 
-	elasticID, _, err := getElasticMessage(message.ID, channel.ID, channel.GuildID)
+	elasticID, oldElasticMessage, err := getElasticMessage(message.ID, channel.ID, channel.GuildID)
 	if err != nil {
 		return err
 	}
 
-	_, err = cache.GetElastic().Update().Index(models.ElasticIndexMessages).Type("doc").Id(elasticID).
+	go OnEventlogMessageDelete(channel.GuildID, oldElasticMessage)
+
+	return elasticMarkMessageDeleted(elasticID)
+}
+
+// ElasticDeleteMessageBulk marks all given messages as deleted, messages which are not stored are skipped
+func ElasticDeleteMessageBulk(channelID string, messageIDs []string) error {
+	if !cache.HasElastic() {
+		return errors.New("no elastic client")
+	}
+
+	channel, err := GetChannel(channelID)
+	if err != nil {
+		return err
+	}
+
+	if GuildSettingsGetCached(channel.GuildID).ChatlogDisabled {
+		return nil
+	}
+
+	oldElasticMessages := make([]models.ElasticMessage, 0)
+	for _, messageID := range messageIDs {
+		elasticID, oldElasticMessage, err := getElasticMessage(messageID, channel.ID, channel.GuildID)
+		if err != nil {
+			if strings.Contains(err.Error(), "unable to find elastic message") {
+				continue
+			}
+			return err
+		}
+
+		err = elasticMarkMessageDeleted(elasticID)
+		if err != nil {
+			return err
+		}
+		oldElasticMessages = append(oldElasticMessages, oldElasticMessage)
+	}
+
+	go OnEventlogMessageDeleteBulk(channel.GuildID, channel.ID, oldElasticMessages)
+
+	return nil
+}
+
+func elasticMarkMessageDeleted(elasticID string) error {
+	_, err := cache.GetElastic().Update().Index(models.ElasticIndexMessages).Type("doc").Id(elasticID).
 		Script(elastic.
 			NewScript("ctx._source.Deleted = params.deleted").
 			Param("deleted", true).
 			Lang("painless")).
 		Upsert(map[string]interface{}{"deleted": 0}).
 		Do(context.Background())
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
 func ElasticAddJoin(member *discordgo.Member, usedInvite, usedVanityName string) error {
@@ -390,6 +468,27 @@ func ElasticAddJoin(member *discordgo.Member, usedInvite, usedVanityName string)
 		VanityInvite:   usedVanityName,
 	}
 
+	if usedVanityName != "" {
+		lastClick, err := getLastVanityInviteClick(member.GuildID, usedVanityName, joinedAt)
+		RelaxLog(err)
+		if err == nil {
+			elasticJoinData.VanityInviteReferer = lastClick.Referer
+			elasticJoinData.VanityInviteCampaign = lastClick.Campaign
+		}
+	}
+
+	go func() {
+		defer Recover()
+
+		OutgoingWebhookEmit(member.GuildID, models.OutgoingWebhookEventMemberJoin, models.OutgoingWebhookMemberJoinData{
+			UserID:               member.User.ID,
+			UsedInviteCode:       usedInvite,
+			VanityInvite:         usedVanityName,
+			VanityInviteReferer:  elasticJoinData.VanityInviteReferer,
+			VanityInviteCampaign: elasticJoinData.VanityInviteCampaign,
+		})
+	}()
+
 	if GuildSettingsGetCached(member.GuildID).ChatlogDisabled {
 		elasticJoinData.UserID = ""
 	}
@@ -434,7 +533,7 @@ func ElasticAddLeave(member *discordgo.Member) error {
 	return err
 }
 
-func ElasticAddVanityInviteClick(vanityInvite models.VanityInviteEntry, referer string) error {
+func ElasticAddVanityInviteClick(vanityInvite models.VanityInviteEntry, referer, campaign string) error {
 	if !cache.HasElastic() {
 		return errors.New("no elastic client")
 	}
@@ -450,6 +549,7 @@ func ElasticAddVanityInviteClick(vanityInvite models.VanityInviteEntry, referer
 		VanityInviteName: vanityInvite.VanityName,
 		GuildID:          vanityInvite.GuildID,
 		Referer:          referer,
+		Campaign:         campaign,
 	}
 
 	_, err = cache.GetElastic().Index().
@@ -460,6 +560,36 @@ func ElasticAddVanityInviteClick(vanityInvite models.VanityInviteEntry, referer
 	return err
 }
 
+// getLastVanityInviteClick returns the last click on the vanity invite within VanityInviteAttributionWindow before the join
+func getLastVanityInviteClick(guildID, vanityName string, joinedAt time.Time) (click models.ElasticVanityInviteClick, err error) {
+	boolQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", guildID)).
+		Must(elastic.NewMatchQuery("VanityInviteName", vanityName)).
+		Must(elastic.NewRangeQuery("CreatedAt").Gte(joinedAt.Add(-VanityInviteAttributionWindow)).Lte(joinedAt))
+
+	searchResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndexVanityInviteClicks).
+		Type("doc").
+		Query(boolQuery).
+		Size(1).
+		Sort("CreatedAt", false).
+		Do(context.Background())
+	if err != nil {
+		return click, err
+	}
+
+	for _, item := range searchResult.Hits.Hits {
+		if item == nil {
+			continue
+		}
+
+		err = json.Unmarshal(*item.Source, &click)
+		return click, err
+	}
+
+	return click, nil
+}
+
 func ElasticAddVoiceSession(guildID, channelID, userID string, joinTime, leaveTime time.Time) (err error) {
 	if !cache.HasElastic() {
 		return errors.New("no elastic client")
@@ -495,9 +625,9 @@ func ElasticAddVoiceSession(guildID, channelID, userID string, joinTime, leaveTi
 }
 
 func ElasticAddEventlog(createdAt time.Time, guildID, targetID, targetType, userID, actionType, reason string,
-	changes []models.ElasticEventlogChange, options []models.ElasticEventlogOption, waitingForAuditLogBackfill bool, messageIDs []string) (err error) {
+	changes []models.ElasticEventlogChange, options []models.ElasticEventlogOption, waitingForAuditLogBackfill bool, messageIDs []string) (elasticID string, err error) {
 	if !cache.HasElastic() {
-		return errors.New("no elastic client")
+		return "", errors.New("no elastic client")
 	}
 
 	elasticEventlog := models.ElasticEventlog{
@@ -518,11 +648,30 @@ func ElasticAddEventlog(createdAt time.Time, guildID, targetID, targetType, user
 		EventlogMessages: messageIDs,
 	}
 
-	_, err = cache.GetElastic().Index().
+	result, err := cache.GetElastic().Index().
 		Index(models.ElasticIndexEventlogs).
 		Type("doc").
 		BodyJson(elasticEventlog).
 		Do(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return result.Id, nil
+}
+
+// adds messages to an eventlog, they will be edited if the eventlog is updated
+func ElasticAddEventlogMessages(elasticID string, messageIDs []string) (err error) {
+	if !cache.HasElastic() {
+		return errors.New("no elastic client")
+	}
+
+	_, err = cache.GetElastic().Update().Index(models.ElasticIndexEventlogs).Type("doc").Id(elasticID).
+		Script(elastic.
+			NewScript("if (ctx._source.EventlogMessages == null) { ctx._source.EventlogMessages = params.messageIDs } else { ctx._source.EventlogMessages.addAll(params.messageIDs) }").
+			Param("messageIDs", messageIDs).
+			Lang("painless")).
+		RetryOnConflict(3).
+		Do(context.Background())
 	return err
 }
 
@@ -676,6 +825,60 @@ func GetElasticPendingAuditLogBackfillEventlogs(createdAt time.Time, guildID, ta
 	}
 }
 
+// returns message delete eventlogs of messages by authorID without a moderator
+// the audit log groups message deletes by the same moderator, so deletes up to five minutes after createdAt are returned
+func GetElasticPendingAuditLogBackfillMessageDeletes(createdAt time.Time, guildID, authorID string) (result []GetElasticEventlogsResult, err error) {
+	boolQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", guildID)).
+		Must(elastic.NewMatchQuery("ActionType", models.EventlogTypeMessageDelete)).
+		Must(elastic.NewRangeQuery("CreatedAt").Gte(createdAt.Add(-5 * time.Second)).Lte(createdAt.Add(5 * time.Minute)))
+
+	searchResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndexEventlogs).
+		Type("doc").
+		Query(boolQuery).
+		Size(100).
+		Sort("CreatedAt", false).
+		Do(context.Background())
+	if err != nil {
+		return result, err
+	}
+
+	result = make([]GetElasticEventlogsResult, 0)
+
+	for _, item := range searchResult.Hits.Hits {
+		if item == nil {
+			continue
+		}
+
+		var eventlog models.ElasticEventlog
+		err := json.Unmarshal(*item.Source, &eventlog)
+		if err != nil {
+			continue
+		}
+
+		if eventlog.UserID != "" {
+			continue
+		}
+
+		for _, option := range eventlog.Options {
+			if option.Key == "message_authorid" && option.Value == authorID {
+				result = append(result, GetElasticEventlogsResult{
+					ElasticID: item.Id,
+					Entry:     eventlog,
+				})
+				break
+			}
+		}
+	}
+
+	if len(result) <= 0 {
+		return nil, errors.New("no fitting items found")
+	} else {
+		return
+	}
+}
+
 func GetMinTimeForInterval(interval string, count int) (minTime time.Time) {
 	switch interval {
 	case "second":
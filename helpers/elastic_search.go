@@ -0,0 +1,279 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/olivere/elastic"
+)
+
+const (
+	ElasticSearchDefaultLimit = 50
+	ElasticSearchMaxLimit     = 100
+)
+
+// ElasticCursor points to the position after the last returned item of a search sorted by CreatedAt, newest first
+// Skip is the number of items at Before which have already been returned
+type ElasticCursor struct {
+	Before time.Time
+	Skip   int
+}
+
+// ElasticEventlogQuery filters eventlogs, all fields except GuildID are optional
+type ElasticEventlogQuery struct {
+	GuildID     string
+	ActionTypes []string
+	TargetID    string
+	UserID      string
+	ChannelID   string // the target channel, or the channel of message events
+	From        time.Time
+	Until       time.Time
+	Search      string // full text search in the reason, changes and options
+	Cursor      ElasticCursor
+	Limit       int
+}
+
+// ElasticMessageQuery filters messages, all fields except GuildID are optional
+type ElasticMessageQuery struct {
+	GuildID     string
+	ChannelID   string
+	UserID      string
+	From        time.Time
+	Until       time.Time
+	Search      string // full text search in all versions of the content
+	OnlyDeleted bool
+	Cursor      ElasticCursor
+	Limit       int
+}
+
+// ParseElasticCursor parses a cursor created by ElasticCursor.String(), an empty cursor starts at the newest item
+func ParseElasticCursor(cursor string) (result ElasticCursor, err error) {
+	if cursor == "" {
+		return result, nil
+	}
+
+	parts := strings.Split(cursor, "-")
+	if len(parts) != 2 {
+		return result, errors.New("invalid cursor")
+	}
+
+	milliseconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return result, errors.New("invalid cursor")
+	}
+	result.Skip, err = strconv.Atoi(parts[1])
+	if err != nil || result.Skip < 0 {
+		return result, errors.New("invalid cursor")
+	}
+	result.Before = time.Unix(0, milliseconds*int64(time.Millisecond)).UTC()
+
+	return result, nil
+}
+
+// String returns the cursor as text, returns an empty string for the empty cursor
+func (c ElasticCursor) String() string {
+	if c.Before.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(elasticMilliseconds(c.Before), 10) + "-" + strconv.Itoa(c.Skip)
+}
+
+// NextElasticCursor returns the cursor for the page after the given items, createdAts have to be sorted newest first
+func NextElasticCursor(previous ElasticCursor, createdAts []time.Time) (next ElasticCursor) {
+	if len(createdAts) <= 0 {
+		return previous
+	}
+
+	last := elasticMilliseconds(createdAts[len(createdAts)-1])
+	next.Before = time.Unix(0, last*int64(time.Millisecond)).UTC()
+	for _, createdAt := range createdAts {
+		if elasticMilliseconds(createdAt) == last {
+			next.Skip++
+		}
+	}
+	// all items of this page have the same time as the previous cursor
+	if !previous.Before.IsZero() && elasticMilliseconds(previous.Before) == last {
+		next.Skip += previous.Skip
+	}
+
+	return next
+}
+
+// elastic stores dates with millisecond precision
+func elasticMilliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func normalizeElasticSearchLimit(limit int) int {
+	if limit <= 0 {
+		return ElasticSearchDefaultLimit
+	}
+	if limit > ElasticSearchMaxLimit {
+		return ElasticSearchMaxLimit
+	}
+	return limit
+}
+
+// SearchElasticEventlogs returns the eventlogs matching the query, newest first
+// next is the cursor for the next page, it is empty if there are no more eventlogs
+func SearchElasticEventlogs(query ElasticEventlogQuery) (result []models.ElasticEventlog, next ElasticCursor, err error) {
+	if !cache.HasElastic() {
+		return nil, next, errors.New("no elastic client")
+	}
+
+	limit := normalizeElasticSearchLimit(query.Limit)
+
+	boolQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", query.GuildID))
+
+	if len(query.ActionTypes) > 0 {
+		actionTypes := make([]interface{}, 0)
+		for _, actionType := range query.ActionTypes {
+			actionTypes = append(actionTypes, actionType)
+		}
+		boolQuery.Must(elastic.NewTermsQuery("ActionType.keyword", actionTypes...))
+	}
+	if query.TargetID != "" {
+		boolQuery.Must(elastic.NewTermQuery("TargetID.keyword", query.TargetID))
+	}
+	if query.UserID != "" {
+		boolQuery.Must(elastic.NewTermQuery("UserID.keyword", query.UserID))
+	}
+	if query.ChannelID != "" {
+		boolQuery.Must(elastic.NewBoolQuery().
+			Should(elastic.NewTermQuery("TargetID.keyword", query.ChannelID)).
+			Should(elastic.NewNestedQuery("Options", elastic.NewBoolQuery().
+				Must(elastic.NewMatchQuery("Options.Key", "message_channelid")).
+				Must(elastic.NewMatchQuery("Options.Value", query.ChannelID)))).
+			MinimumNumberShouldMatch(1))
+	}
+	if query.Search != "" {
+		boolQuery.Must(elastic.NewBoolQuery().
+			Should(elastic.NewMatchQuery("Reason", query.Search)).
+			Should(elastic.NewNestedQuery("Changes",
+				elastic.NewMultiMatchQuery(query.Search, "Changes.OldValue", "Changes.NewValue"))).
+			Should(elastic.NewNestedQuery("Options",
+				elastic.NewMatchQuery("Options.Value", query.Search))).
+			MinimumNumberShouldMatch(1))
+	}
+	boolQuery = withElasticDateRange(boolQuery, query.From, query.Until, query.Cursor)
+
+	searchResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndexEventlogs).
+		Type("doc").
+		Query(boolQuery).
+		From(query.Cursor.Skip).
+		Size(limit).
+		Sort("CreatedAt", false).
+		Do(context.Background())
+	if err != nil {
+		return nil, next, err
+	}
+
+	result = make([]models.ElasticEventlog, 0)
+	createdAts := make([]time.Time, 0)
+	for _, item := range searchResult.Hits.Hits {
+		if item == nil {
+			continue
+		}
+
+		var eventlog models.ElasticEventlog
+		err = json.Unmarshal(*item.Source, &eventlog)
+		if err != nil {
+			return nil, next, err
+		}
+		result = append(result, eventlog)
+		createdAts = append(createdAts, eventlog.CreatedAt)
+	}
+
+	if len(searchResult.Hits.Hits) >= limit {
+		next = NextElasticCursor(query.Cursor, createdAts)
+	}
+
+	return result, next, nil
+}
+
+// SearchElasticMessages returns the messages matching the query, newest first
+// next is the cursor for the next page, it is empty if there are no more messages
+func SearchElasticMessages(query ElasticMessageQuery) (result []models.ElasticMessage, next ElasticCursor, err error) {
+	if !cache.HasElastic() {
+		return nil, next, errors.New("no elastic client")
+	}
+
+	limit := normalizeElasticSearchLimit(query.Limit)
+
+	boolQuery := elasticMessagesQuery(query)
+
+	searchResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndexMessages).
+		Type("doc").
+		Query(boolQuery).
+		From(query.Cursor.Skip).
+		Size(limit).
+		Sort("CreatedAt", false).
+		Do(context.Background())
+	if err != nil {
+		return nil, next, err
+	}
+
+	result = make([]models.ElasticMessage, 0)
+	createdAts := make([]time.Time, 0)
+	for _, item := range searchResult.Hits.Hits {
+		if item == nil {
+			continue
+		}
+
+		message := UnmarshalElasticMessage(item)
+		createdAts = append(createdAts, message.CreatedAt)
+		if message.MessageID == "" {
+			continue
+		}
+		result = append(result, message)
+	}
+
+	if len(searchResult.Hits.Hits) >= limit {
+		next = NextElasticCursor(query.Cursor, createdAts)
+	}
+
+	return result, next, nil
+}
+
+// elasticMessagesQuery builds the query of SearchElasticMessages, the fields have to be indexed in models.ElasticMessagesMapping
+func elasticMessagesQuery(query ElasticMessageQuery) *elastic.BoolQuery {
+	boolQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", query.GuildID))
+
+	if query.ChannelID != "" {
+		boolQuery.Must(elastic.NewMatchQuery("ChannelID", query.ChannelID))
+	}
+	if query.UserID != "" {
+		boolQuery.Must(elastic.NewMatchQuery("UserID", query.UserID))
+	}
+	if query.Search != "" {
+		boolQuery.Must(elastic.NewMatchQuery("Content", query.Search).Operator("and"))
+	}
+	if query.OnlyDeleted {
+		boolQuery.Must(elastic.NewTermQuery("Deleted", true))
+	}
+	return withElasticDateRange(boolQuery, query.From, query.Until, query.Cursor)
+}
+
+func withElasticDateRange(boolQuery *elastic.BoolQuery, from, until time.Time, cursor ElasticCursor) *elastic.BoolQuery {
+	if !from.IsZero() {
+		boolQuery.Must(elastic.NewRangeQuery("CreatedAt").Gte(from))
+	}
+	if !until.IsZero() {
+		boolQuery.Must(elastic.NewRangeQuery("CreatedAt").Lte(until))
+	}
+	// items at the time of the cursor are included, the already returned ones are skipped
+	if !cursor.Before.IsZero() {
+		boolQuery.Must(elastic.NewRangeQuery("CreatedAt").Lte(cursor.Before))
+	}
+	return boolQuery
+}
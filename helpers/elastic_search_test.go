@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestElasticCursor(t *testing.T) {
+	base := time.Date(2018, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	next := NextElasticCursor(ElasticCursor{}, []time.Time{
+		base.Add(3 * time.Second),
+		base,
+		base,
+	})
+	if !next.Before.Equal(base) || next.Skip != 2 {
+		t.Fatalf("helpers.NextElasticCursor() returned %+v, expected two skipped items at %s", next, base)
+	}
+
+	// the whole page has the same time as the cursor
+	next = NextElasticCursor(next, []time.Time{base, base})
+	if !next.Before.Equal(base) || next.Skip != 4 {
+		t.Fatalf("helpers.NextElasticCursor() returned %+v, expected four skipped items at %s", next, base)
+	}
+
+	parsed, err := ParseElasticCursor(next.String())
+	if err != nil || parsed != next {
+		t.Fatalf("helpers.ParseElasticCursor() returned %+v, expected %+v", parsed, next)
+	}
+
+	_, err = ParseElasticCursor("foo-bar")
+	if err == nil {
+		t.Fatal("helpers.ParseElasticCursor() accepted an invalid cursor")
+	}
+}
+
+func TestElasticMessagesQuery(t *testing.T) {
+	source, err := elasticMessagesQuery(ElasticMessageQuery{
+		GuildID:     "208673735580844032",
+		ChannelID:   "208673735580844032",
+		UserID:      "116620585638821891",
+		From:        time.Date(2018, time.July, 1, 12, 0, 0, 0, time.UTC),
+		Search:      "hello world",
+		OnlyDeleted: true,
+	}).Source()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Bool struct {
+			Must []map[string]map[string]interface{}
+		}
+	}
+	err = json.Unmarshal(data, &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	properties := models.ElasticMessagesMapping["mappings"].(map[string]interface{})["doc"].(map[string]interface{})["properties"].(map[string]interface{})
+	fields := make(map[string]bool)
+	for _, must := range parsed.Bool.Must {
+		for _, fieldQuery := range must {
+			for field := range fieldQuery {
+				fields[field] = true
+				mapping, ok := properties[field].(map[string]interface{})
+				if !ok {
+					t.Fatalf("helpers.elasticMessagesQuery() queries %s which isn't mapped", field)
+				}
+				if index, ok := mapping["index"].(bool); ok && !index {
+					t.Fatalf("helpers.elasticMessagesQuery() queries %s which isn't indexed", field)
+				}
+			}
+		}
+	}
+	for _, field := range []string{"GuildID", "ChannelID", "UserID", "Content", "Deleted", "CreatedAt"} {
+		if !fields[field] {
+			t.Fatalf("helpers.elasticMessagesQuery() doesn't query %s: %s", field, string(data))
+		}
+	}
+}
@@ -12,13 +12,32 @@ import (
 
 	"errors"
 
+	"sort"
+
 	"github.com/Seklfreak/Robyul2/cache"
 	"github.com/Seklfreak/Robyul2/models"
 	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	// storage metadata key of attachments re-hosted by the eventlog, the value is the Discord URL
+	discordAttachmentMetadataKey = "discord_attachment"
+	// larger attachments are not re-hosted, the Discord URL is logged instead
+	EventlogAttachmentMaxSize = 8 * 1024 * 1024
+	// unused re-hosted attachments are deleted after the messages retention, or after this time without one
+	EventlogAttachmentDefaultExpiry = 30 * 24 * time.Hour
+	eventlogAttachmentStorageSource = "eventlog"
 )
 
 var (
 	AuditLogBackfillRequestsLock = sync.Mutex{}
+
+	// can be replaced in tests
+	findDiscordAttachmentObject    = findDiscordAttachmentObjectInStorage
+	downloadDiscordAttachment      = downloadDiscordAttachmentWithLimit
+	addDiscordAttachmentObject     = AddFile
+	publishDiscordAttachmentObject = PublishFile
 )
 
 /*
@@ -70,18 +89,41 @@ func EventlogLog(createdAt time.Time, guildID, targetID, targetType, userID, act
 		)
 	*/
 
-	messageIDs := make([]string, 0)
-	eventlogChannelIDs := GuildSettingsGetCached(guildID).EventlogChannelIDs
-	for _, eventlogChannelID := range eventlogChannelIDs {
-		messages, _ := SendEmbed(eventlogChannelID, getEventlogEmbed(createdAt, guildID, targetID, targetType, userID,
-			actionType, reason, cleanChanges(changes), cleanOptions(options), waitingForAuditLogBackfill))
-		if messages != nil && len(messages) >= 1 {
-			messageIDs = append(messageIDs, eventlogChannelID+"|"+messages[0].ID)
+	elasticID, err := ElasticAddEventlog(createdAt, guildID, targetID, targetType, userID, actionType, reason, changes, options, waitingForAuditLogBackfill, make([]string, 0))
+
+	go func() {
+		defer Recover()
+
+		OutgoingWebhookEmit(guildID, models.OutgoingWebhookEventEventlog, models.OutgoingWebhookEventlogData{
+			ActionType: actionType,
+			TargetID:   targetID,
+			TargetType: targetType,
+			UserID:     userID,
+			Reason:     reason,
+			Changes:    cleanChanges(changes),
+			Options:    cleanOptions(options),
+		})
+		if actionType == models.EventlogTypeBanAdd {
+			OutgoingWebhookEmit(guildID, models.OutgoingWebhookEventBan, models.OutgoingWebhookEventlogData{
+				ActionType: actionType,
+				TargetID:   targetID,
+				TargetType: targetType,
+				UserID:     userID,
+				Reason:     reason,
+			})
+		}
+	}()
+
+	// the messages are added to the elastic eventlog after the delivery
+	eventlogChannelIDs := getEventlogChannelIDs(GuildSettingsGetCached(guildID), targetID, userID, actionType)
+	if len(eventlogChannelIDs) > 0 {
+		embed := getEventlogEmbed(createdAt, guildID, targetID, targetType, userID,
+			actionType, reason, cleanChanges(changes), cleanOptions(options), waitingForAuditLogBackfill)
+		for _, eventlogChannelID := range eventlogChannelIDs {
+			queueEventlogDelivery(guildID, eventlogChannelID, elasticID, embed)
 		}
 	}
 
-	err = ElasticAddEventlog(createdAt, guildID, targetID, targetType, userID, actionType, reason, changes, options, waitingForAuditLogBackfill, messageIDs)
-
 	if err != nil {
 		return false, err
 	}
@@ -103,10 +145,16 @@ func EventlogLogUpdate(elasticID string, UserID string,
 			eventlogItem.TargetType, eventlogItem.UserID, eventlogItem.ActionType, eventlogItem.Reason,
 			eventlogItem.Changes, eventlogItem.Options, eventlogItem.WaitingFor.AuditLogBackfill)
 		for _, messageID := range eventlogItem.EventlogMessages {
-			if strings.Contains(messageID, "|") {
-				parts := strings.SplitN(messageID, "|", 2)
-				if len(parts) >= 2 {
-					EditEmbed(parts[0], parts[1], embed)
+			parts := strings.Split(messageID, "|")
+			switch len(parts) {
+			case 2:
+				EditEmbed(parts[0], parts[1], embed)
+			case 3:
+				// sent by a webhook, together with other eventlogs
+				embedIndex, err := strconv.Atoi(parts[2])
+				if err == nil {
+					err = editEventlogWebhookEmbed(eventlogItem.GuildID, parts[0], parts[1], embedIndex, embed)
+					RelaxLog(err)
 				}
 			}
 		}
@@ -117,6 +165,13 @@ func EventlogLogUpdate(elasticID string, UserID string,
 
 func eventlogTargetsToText(guildID, targetType, idsText string) (names []string) {
 	names = make([]string, 0)
+	// message contents can contain commas, they are shortened to fit old and new content into one embed field
+	if targetType == models.EventlogTargetTypeMessageContent {
+		if len([]rune(idsText)) > 500 {
+			idsText = string([]rune(idsText)[:499]) + "…"
+		}
+		return append(names, idsText)
+	}
 	ids := strings.Split(idsText, ",")
 	for _, id := range ids {
 		targetName := id
@@ -223,7 +278,16 @@ func getEventlogEmbed(createdAt time.Time, guildID, targetID, targetType, userID
 		actionType == models.EventlogTypeRobyulTroublemakerReport ||
 		actionType == models.EventlogTypeRobyulPersistencyRoleRemove ||
 		actionType == models.EventlogTypeRobyulEventlogConfigUpdate ||
-		actionType == models.EventlogTypeRobyulTwitterFeedRemove {
+		actionType == models.EventlogTypeRobyulTwitterFeedRemove ||
+		actionType == models.EventlogTypeRobyulScheduledPostDelete ||
+		actionType == models.EventlogTypeRobyulIdolCalendarUnsubscribe ||
+		actionType == models.EventlogTypeRobyulApiTokenRevoke ||
+		actionType == models.EventlogTypeRobyulOutgoingWebhookRemove ||
+		actionType == models.EventlogTypeRobyulGuildAnnouncementsJoinRemove ||
+		actionType == models.EventlogTypeRobyulGuildAnnouncementsLeaveRemove ||
+		actionType == models.EventlogTypeRobyulGuildAnnouncementsBanRemove ||
+		actionType == models.EventlogTypeMessageDelete ||
+		actionType == models.EventlogTypeMessageDeleteBulk {
 		embed.Color = GetDiscordColorFromHex("#b22222") // firebrick red
 	}
 	if waitingForAuditLogBackfill {
@@ -988,6 +1052,316 @@ func OnEventlogRoleUpdate(guildID string, oldRole, newRole *discordgo.Role) {
 	}
 }
 
+func eventlogChannelIsIgnored(guildID, channelID string) bool {
+	for _, ignoredChannelID := range GuildSettingsGetCached(guildID).EventlogIgnoredChannelIDs {
+		if ignoredChannelID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// downloads the attachments and stores them in the object storage, Discord removes attachments of deleted messages
+// attachments stored by OnEventlogMessageCreate are reused and made public
+// if an attachment can not be stored the Discord URL will be used
+func getDiscordAttachmentsOption(key string, attachmentURLs []string, userID, channelID, guildID string) models.ElasticEventlogOption {
+	return models.ElasticEventlogOption{
+		Key:   key,
+		Value: strings.Join(storeDiscordAttachments(attachmentURLs, userID, channelID, guildID, true), ","),
+		Type:  models.EventlogTargetTypeRobyulPublicObject,
+	}
+}
+
+// storeDiscordAttachments returns the object names of the attachments, or the Discord URLs of the attachments which could not be stored
+// objects are only public once they are used in the eventlog, unused objects are deleted by DeleteExpiredEventlogAttachments
+func storeDiscordAttachments(attachmentURLs []string, userID, channelID, guildID string, public bool) (objectNames []string) {
+	objectNames = make([]string, 0)
+	for _, attachmentURL := range attachmentURLs {
+		oldObject, err := findDiscordAttachmentObject(attachmentURL)
+		if err == nil {
+			if public && !oldObject.Public {
+				err = publishDiscordAttachmentObject(oldObject.ObjectName)
+				RelaxLog(err)
+			}
+			objectNames = append(objectNames, oldObject.ObjectName)
+			continue
+		}
+
+		objectName := attachmentURL
+		attachmentData, err := downloadDiscordAttachment(attachmentURL)
+		if err != nil && err != errNetResponseTooLarge {
+			RelaxLog(err)
+		}
+		if err == nil {
+			urlParts := strings.Split(attachmentURL, "/")
+			newObjectName, err := addDiscordAttachmentObject("", attachmentData, AddFileMetadata{
+				Filename:  urlParts[len(urlParts)-1],
+				UserID:    userID,
+				ChannelID: channelID,
+				GuildID:   guildID,
+				AdditionalMetadata: map[string]string{
+					discordAttachmentMetadataKey: attachmentURL,
+				},
+			}, eventlogAttachmentStorageSource, public)
+			RelaxLog(err)
+			if err == nil {
+				objectName = newObjectName
+			}
+		}
+		objectNames = append(objectNames, objectName)
+	}
+
+	return objectNames
+}
+
+func findDiscordAttachmentObjectInStorage(attachmentURL string) (entry models.StorageEntry, err error) {
+	err = MdbOneWithoutLogging(
+		MdbCollection(models.StorageTable).Find(bson.M{"metadata." + discordAttachmentMetadataKey: attachmentURL}),
+		&entry,
+	)
+	return entry, err
+}
+
+func downloadDiscordAttachmentWithLimit(attachmentURL string) (data []byte, err error) {
+	return NetGetUAWithLimit(attachmentURL, DEFAULT_UA, EventlogAttachmentMaxSize)
+}
+
+// DeleteExpiredEventlogAttachments deletes the attachments of the guild stored before the time which haven't been used in the eventlog
+func DeleteExpiredEventlogAttachments(guildID string, before time.Time) (deleted int, err error) {
+	var entries []models.StorageEntry
+	err = MDbIterWithoutLogging(MdbCollection(models.StorageTable).Find(bson.M{
+		"source":     eventlogAttachmentStorageSource,
+		"guildid":    guildID,
+		"public":     false,
+		"uploaddate": bson.M{"$lt": before},
+		"metadata." + discordAttachmentMetadataKey: bson.M{"$exists": true},
+	})).All(&entries)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		err = DeleteFile(entry.ObjectName)
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// OnEventlogMessageCreate stores the attachments of a new message on servers logging to an eventlog channel
+// Discord removes attachments soon after a message has been deleted, getDiscordAttachmentsOption reuses the stored objects
+func OnEventlogMessageCreate(guildID string, message *discordgo.Message) {
+	if len(message.Attachments) <= 0 || message.Author == nil {
+		return
+	}
+
+	settings := GuildSettingsGetCached(guildID)
+	if settings.EventlogDisabled || settings.ChatlogDisabled ||
+		(len(settings.EventlogChannelIDs) <= 0 && len(settings.EventlogRoutes) <= 0) {
+		return
+	}
+
+	if eventlogChannelIsIgnored(guildID, message.ChannelID) {
+		return
+	}
+
+	attachmentURLs := make([]string, 0)
+	for _, attachment := range message.Attachments {
+		if attachment.Size > EventlogAttachmentMaxSize {
+			continue
+		}
+		attachmentURLs = append(attachmentURLs, attachment.URL)
+	}
+	storeDiscordAttachments(attachmentURLs, message.Author.ID, message.ChannelID, guildID, false)
+}
+
+func OnEventlogMessageUpdate(guildID string, oldMessage models.ElasticMessage, newMessage *discordgo.Message) {
+	if eventlogChannelIsIgnored(guildID, newMessage.ChannelID) {
+		return
+	}
+
+	updatedAt := time.Now()
+	if newMessage.EditedTimestamp != "" {
+		editedAt, err := newMessage.EditedTimestamp.Parse()
+		if err == nil {
+			updatedAt = editedAt
+		}
+	}
+
+	var oldContent string
+	if len(oldMessage.Content) > 0 {
+		oldContent = oldMessage.Content[len(oldMessage.Content)-1]
+	}
+
+	changes := []models.ElasticEventlogChange{
+		{
+			Key:      "message_content",
+			OldValue: oldContent,
+			NewValue: newMessage.Content,
+			Type:     models.EventlogTargetTypeMessageContent,
+		},
+	}
+
+	options := []models.ElasticEventlogOption{
+		{
+			Key:   "message_channelid",
+			Value: newMessage.ChannelID,
+			Type:  models.EventlogTargetTypeChannel,
+		},
+	}
+
+	// attachments can be removed when editing a message
+	if newMessage.Attachments != nil {
+		newAttachmentURLs := make([]string, 0)
+		for _, attachment := range newMessage.Attachments {
+			newAttachmentURLs = append(newAttachmentURLs, attachment.URL)
+		}
+		_, removedAttachmentURLs := StringSliceDiff(oldMessage.Attachments, newAttachmentURLs)
+		if len(removedAttachmentURLs) > 0 {
+			options = append(options, getDiscordAttachmentsOption("message_attachments_removed",
+				removedAttachmentURLs, oldMessage.UserID, newMessage.ChannelID, guildID))
+		}
+	}
+
+	_, err := EventlogLog(updatedAt, guildID, newMessage.ID, models.EventlogTargetTypeMessage, oldMessage.UserID, models.EventlogTypeMessageUpdate, "", changes, options, false)
+	RelaxLog(err)
+}
+
+func OnEventlogMessageDelete(guildID string, message models.ElasticMessage) {
+	if eventlogChannelIsIgnored(guildID, message.ChannelID) {
+		return
+	}
+
+	deletedAt := time.Now()
+
+	var content string
+	if len(message.Content) > 0 {
+		content = message.Content[len(message.Content)-1]
+	}
+
+	changes := []models.ElasticEventlogChange{
+		{
+			Key:      "message_content",
+			OldValue: content,
+			Type:     models.EventlogTargetTypeMessageContent,
+		},
+	}
+
+	options := []models.ElasticEventlogOption{
+		{
+			Key:   "message_channelid",
+			Value: message.ChannelID,
+			Type:  models.EventlogTargetTypeChannel,
+		},
+		{
+			Key:   "message_authorid",
+			Value: message.UserID,
+			Type:  models.EventlogTargetTypeUser,
+		},
+		{
+			Key:   "message_createdat",
+			Value: message.CreatedAt.UTC().Format(time.RFC3339),
+		},
+	}
+
+	if len(message.Attachments) > 0 {
+		options = append(options, getDiscordAttachmentsOption("message_attachments",
+			message.Attachments, message.UserID, message.ChannelID, guildID))
+	}
+
+	// the moderator is only known if the message has been deleted by someone else than the author
+	added, err := EventlogLog(deletedAt, guildID, message.MessageID, models.EventlogTargetTypeMessage, "", models.EventlogTypeMessageDelete, "", changes, options, false)
+	RelaxLog(err)
+	if added {
+		err := RequestAuditLogBackfill(guildID, models.AuditLogBackfillTypeMessageDelete, "")
+		RelaxLog(err)
+	}
+}
+
+func OnEventlogMessageDeleteBulk(guildID, channelID string, messages []models.ElasticMessage) {
+	if eventlogChannelIsIgnored(guildID, channelID) {
+		return
+	}
+
+	if len(messages) <= 0 {
+		return
+	}
+
+	deletedAt := time.Now()
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	// store all deleted messages as text file, they won't fit into the embed
+	var messageLog string
+	authorIDs := make([]string, 0)
+	authorIDsAdded := make(map[string]bool)
+	for _, message := range messages {
+		authorName := "N/A"
+		author, err := GetUserWithoutAPI(message.UserID)
+		if err == nil {
+			authorName = author.Username + "#" + author.Discriminator
+		}
+
+		var content string
+		if len(message.Content) > 0 {
+			content = message.Content[len(message.Content)-1]
+		}
+
+		messageLog += "[" + message.CreatedAt.UTC().Format("2006-01-02 15:04:05") + " UTC] " +
+			authorName + " (#" + message.UserID + "): " + content + "\n"
+
+		if len(message.Attachments) > 0 {
+			attachmentsOption := getDiscordAttachmentsOption("", message.Attachments, message.UserID, channelID, guildID)
+			for _, objectName := range strings.Split(attachmentsOption.Value, ",") {
+				attachmentLink, err := GetFileLink(objectName)
+				if err != nil {
+					attachmentLink = objectName
+				}
+				messageLog += "\tAttachment: " + attachmentLink + "\n"
+			}
+		}
+
+		if !authorIDsAdded[message.UserID] {
+			authorIDs = append(authorIDs, message.UserID)
+			authorIDsAdded[message.UserID] = true
+		}
+	}
+
+	options := []models.ElasticEventlogOption{
+		{
+			Key:   "message_count",
+			Value: strconv.Itoa(len(messages)),
+		},
+		{
+			Key:   "message_authorids",
+			Value: strings.Join(authorIDs, ","),
+			Type:  models.EventlogTargetTypeUser,
+		},
+	}
+
+	objectName, err := AddFile("", []byte(messageLog), AddFileMetadata{
+		Filename:  "deleted-messages-" + channelID + ".txt",
+		ChannelID: channelID,
+		GuildID:   guildID,
+	}, "eventlog", true)
+	RelaxLog(err)
+	if err == nil {
+		options = append(options, models.ElasticEventlogOption{
+			Key:   "message_log",
+			Value: objectName,
+			Type:  models.EventlogTargetTypeRobyulPublicObject,
+		})
+	}
+
+	_, err = EventlogLog(deletedAt, guildID, channelID, models.EventlogTargetTypeChannel, "", models.EventlogTypeMessageDeleteBulk, "", nil, options, false)
+	RelaxLog(err)
+}
+
 func StoreBoolAsString(input bool) (output string) {
 	if input {
 		return "yes"
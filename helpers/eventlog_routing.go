@@ -0,0 +1,237 @@
+package helpers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// events for the same channel within this delay are sent together in one message
+	EventlogDeliveryBatchDelay = 3 * time.Second
+	// webhook messages can have at most 10 embeds with 6000 characters in total
+	eventlogDeliveryMaxEmbeds      = 10
+	eventlogDeliveryMaxEmbedLength = 6000
+)
+
+type eventlogDelivery struct {
+	elasticID string
+	embed     *discordgo.MessageEmbed
+}
+
+var (
+	eventlogDeliveryQueue     = make(map[string][]eventlogDelivery)
+	eventlogDeliveryQueueLock = sync.Mutex{}
+)
+
+// GetEventlogCategory returns the category of an event type, returns an empty string for unknown event types
+func GetEventlogCategory(actionType string) string {
+	switch actionType {
+	case models.EventlogTypeMemberJoin, models.EventlogTypeMemberLeave, models.EventlogTypeMemberUpdate:
+		return models.EventlogCategoryMembers
+	case models.EventlogTypeRoleCreate, models.EventlogTypeRoleDelete, models.EventlogTypeRoleUpdate:
+		return models.EventlogCategoryRoles
+	case models.EventlogTypeChannelCreate, models.EventlogTypeChannelDelete, models.EventlogTypeChannelUpdate:
+		return models.EventlogCategoryChannels
+	case models.EventlogTypeBanAdd, models.EventlogTypeBanRemove, models.EventlogTypeRobyulMute,
+		models.EventlogTypeRobyulUnmute, models.EventlogTypeRobyulCleanup, models.EventlogTypeRobyulTroublemakerReport:
+		return models.EventlogCategoryModeration
+	case models.EventlogTypeMessageUpdate, models.EventlogTypeMessageDelete, models.EventlogTypeMessageDeleteBulk,
+		models.EventlogTypeInvitePosted:
+		return models.EventlogCategoryMessages
+	case models.EventlogTypeGuildUpdate, models.EventlogTypeEmojiCreate, models.EventlogTypeEmojiDelete,
+		models.EventlogTypeEmojiUpdate:
+		return models.EventlogCategoryServer
+	}
+
+	if strings.HasPrefix(actionType, "Robyul_") {
+		return models.EventlogCategoryRobyul
+	}
+
+	return ""
+}
+
+// IsEventlogType returns true if actionType is one of models.EventlogTypes, ignoring the case
+func IsEventlogType(actionType string) bool {
+	for _, eventlogType := range models.EventlogTypes {
+		if strings.EqualFold(eventlogType, actionType) {
+			return true
+		}
+	}
+	return false
+}
+
+// EventlogRouteMatches returns true if an event should be sent to the channel of the route
+func EventlogRouteMatches(route models.EventlogRoute, targetID, userID, actionType string) bool {
+	if userID != "" {
+		for _, excludedUserID := range route.ExcludedUserIDs {
+			if excludedUserID == userID {
+				return false
+			}
+		}
+	}
+
+	if len(route.TargetIDs) > 0 {
+		var targetMatches bool
+		for _, routeTargetID := range route.TargetIDs {
+			for _, eventTargetID := range strings.Split(targetID, ",") {
+				if routeTargetID == eventTargetID {
+					targetMatches = true
+				}
+			}
+		}
+		if !targetMatches {
+			return false
+		}
+	}
+
+	if len(route.ActionTypes) <= 0 && len(route.Categories) <= 0 {
+		return true
+	}
+
+	for _, routeActionType := range route.ActionTypes {
+		if routeActionType == strings.ToLower(actionType) {
+			return true
+		}
+	}
+
+	category := GetEventlogCategory(actionType)
+	for _, routeCategory := range route.Categories {
+		if routeCategory == category {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns all channels an event should be sent to, EventlogChannelIDs receive all events
+func getEventlogChannelIDs(settings models.Config, targetID, userID, actionType string) (channelIDs []string) {
+	channelIDs = make([]string, 0)
+	channelIDs = append(channelIDs, settings.EventlogChannelIDs...)
+
+NextRoute:
+	for _, route := range settings.EventlogRoutes {
+		if !EventlogRouteMatches(route, targetID, userID, actionType) {
+			continue
+		}
+
+		for _, channelID := range channelIDs {
+			if channelID == route.ChannelID {
+				continue NextRoute
+			}
+		}
+		channelIDs = append(channelIDs, route.ChannelID)
+	}
+
+	return channelIDs
+}
+
+// queues an eventlog embed for a channel, the first event starts a batch which is sent after EventlogDeliveryBatchDelay
+func queueEventlogDelivery(guildID, channelID, elasticID string, embed *discordgo.MessageEmbed) {
+	eventlogDeliveryQueueLock.Lock()
+	defer eventlogDeliveryQueueLock.Unlock()
+
+	if len(eventlogDeliveryQueue[channelID]) <= 0 {
+		time.AfterFunc(EventlogDeliveryBatchDelay, func() {
+			deliverEventlogs(guildID, channelID)
+		})
+	}
+
+	eventlogDeliveryQueue[channelID] = append(eventlogDeliveryQueue[channelID], eventlogDelivery{
+		elasticID: elasticID,
+		embed:     TruncateEmbed(embed),
+	})
+}
+
+// sends all queued eventlog embeds for a channel using a webhook
+// messages are stored as channelID|messageID|embedIndex, or as channelID|messageID if they have been sent without a webhook
+func deliverEventlogs(guildID, channelID string) {
+	defer Recover()
+
+	eventlogDeliveryQueueLock.Lock()
+	deliveries := eventlogDeliveryQueue[channelID]
+	delete(eventlogDeliveryQueue, channelID)
+	eventlogDeliveryQueueLock.Unlock()
+
+	webhook, err := GetWebhook(guildID, channelID)
+	if err != nil {
+		cache.GetLogger().WithField("module", "helpers/eventlog").Warnf(
+			"unable to get webhook for eventlog channel #%s, sending eventlogs without webhook: %s", channelID, err.Error())
+	}
+
+	for len(deliveries) > 0 {
+		var batchLength, batchSize int
+		for batchSize < len(deliveries) && batchSize < eventlogDeliveryMaxEmbeds {
+			embedLength := CalculateFullEmbedLength(deliveries[batchSize].embed)
+			if batchSize > 0 && batchLength+embedLength > eventlogDeliveryMaxEmbedLength {
+				break
+			}
+			batchLength += embedLength
+			batchSize++
+		}
+		batch := deliveries[:batchSize]
+		deliveries = deliveries[batchSize:]
+
+		if webhook != nil {
+			embeds := make([]*discordgo.MessageEmbed, 0)
+			for _, delivery := range batch {
+				embeds = append(embeds, delivery.embed)
+			}
+
+			message, err := WebhookExecuteWithResult(webhook.ID, webhook.Token, &discordgo.WebhookParams{
+				Username:  "Robyul Eventlog",
+				AvatarURL: cache.GetSession().State.User.AvatarURL("256"),
+				Embeds:    embeds,
+			})
+			if err == nil && message != nil {
+				for i, delivery := range batch {
+					if delivery.elasticID == "" {
+						continue
+					}
+					err = ElasticAddEventlogMessages(delivery.elasticID, []string{channelID + "|" + message.ID + "|" + strconv.Itoa(i)})
+					RelaxLog(err)
+				}
+				continue
+			}
+			RelaxLog(err)
+		}
+
+		// fall back to sending the embeds one by one
+		for _, delivery := range batch {
+			messages, _ := SendEmbed(channelID, delivery.embed)
+			if messages != nil && len(messages) >= 1 && delivery.elasticID != "" {
+				err = ElasticAddEventlogMessages(delivery.elasticID, []string{channelID + "|" + messages[0].ID})
+				RelaxLog(err)
+			}
+		}
+	}
+}
+
+// replaces one embed of an eventlog message sent by a webhook
+func editEventlogWebhookEmbed(guildID, channelID, messageID string, embedIndex int, embed *discordgo.MessageEmbed) (err error) {
+	webhook, err := GetWebhook(guildID, channelID)
+	if err != nil {
+		return err
+	}
+
+	message, err := cache.GetSession().ChannelMessage(channelID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if embedIndex < 0 || embedIndex >= len(message.Embeds) {
+		return errors.New("eventlog embed not found")
+	}
+	message.Embeds[embedIndex] = TruncateEmbed(embed)
+
+	return WebhookEditMessage(webhook.ID, webhook.Token, messageID, &discordgo.WebhookParams{
+		Embeds: message.Embeds,
+	})
+}
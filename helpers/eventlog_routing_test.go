@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestEventlogRouteMatches(t *testing.T) {
+	route := models.EventlogRoute{
+		ActionTypes:     []string{"role_update"},
+		Categories:      []string{models.EventlogCategoryModeration},
+		ExcludedUserIDs: []string{"2"},
+	}
+
+	if !EventlogRouteMatches(route, "10", "1", models.EventlogTypeRoleUpdate) {
+		t.Fatal("helpers.EventlogRouteMatches() didn't match the event type")
+	}
+	if !EventlogRouteMatches(route, "10", "1", models.EventlogTypeBanAdd) {
+		t.Fatal("helpers.EventlogRouteMatches() didn't match the category")
+	}
+	if EventlogRouteMatches(route, "10", "1", models.EventlogTypeMemberUpdate) {
+		t.Fatal("helpers.EventlogRouteMatches() matched an event type which isn't part of the route")
+	}
+	if EventlogRouteMatches(route, "10", "2", models.EventlogTypeRoleUpdate) {
+		t.Fatal("helpers.EventlogRouteMatches() matched an excluded user")
+	}
+
+	route = models.EventlogRoute{TargetIDs: []string{"11"}}
+	if !EventlogRouteMatches(route, "10,11", "", models.EventlogTypeMemberUpdate) {
+		t.Fatal("helpers.EventlogRouteMatches() didn't match the target")
+	}
+	if EventlogRouteMatches(route, "10", "", models.EventlogTypeMemberUpdate) {
+		t.Fatal("helpers.EventlogRouteMatches() matched another target")
+	}
+}
+
+func TestGetEventlogCategory(t *testing.T) {
+	if GetEventlogCategory(models.EventlogTypeRobyulPrefixUpdate) != models.EventlogCategoryRobyul {
+		t.Fatal("helpers.GetEventlogCategory() returned the wrong category for Robyul events")
+	}
+	if GetEventlogCategory(models.EventlogTypeRobyulMute) != models.EventlogCategoryModeration {
+		t.Fatal("helpers.GetEventlogCategory() returned the wrong category for moderation events")
+	}
+}
+
+func TestIsEventlogType(t *testing.T) {
+	for _, actionType := range []string{"role_update", "Robyul_Rep_Cooldown_Set", models.EventlogTypeMemberJoin} {
+		if !IsEventlogType(actionType) {
+			t.Fatal("helpers.IsEventlogType() rejected", actionType)
+		}
+	}
+	for _, actionType := range []string{"role_updat", "roles", ""} {
+		if IsEventlogType(actionType) {
+			t.Fatal("helpers.IsEventlogType() accepted", actionType)
+		}
+	}
+}
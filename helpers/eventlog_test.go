@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestDiscordAttachmentsReuse(t *testing.T) {
+	objects := make(map[string]models.StorageEntry)
+	downloads := 0
+
+	defaultFind, defaultDownload, defaultAdd, defaultPublish :=
+		findDiscordAttachmentObject, downloadDiscordAttachment, addDiscordAttachmentObject, publishDiscordAttachmentObject
+	defer func() {
+		findDiscordAttachmentObject, downloadDiscordAttachment, addDiscordAttachmentObject, publishDiscordAttachmentObject =
+			defaultFind, defaultDownload, defaultAdd, defaultPublish
+	}()
+	findDiscordAttachmentObject = func(attachmentURL string) (models.StorageEntry, error) {
+		for _, entry := range objects {
+			if entry.Metadata[discordAttachmentMetadataKey] == attachmentURL {
+				return entry, nil
+			}
+		}
+		return models.StorageEntry{}, errors.New("not found")
+	}
+	downloadDiscordAttachment = func(attachmentURL string) ([]byte, error) {
+		downloads++
+		if attachmentURL == "https://cdn.discordapp.com/attachments/1/3/large.mp4" {
+			return nil, errNetResponseTooLarge
+		}
+		return []byte("attachment"), nil
+	}
+	addDiscordAttachmentObject = func(name string, data []byte, metadata AddFileMetadata, source string, public bool) (string, error) {
+		objectName := "object-" + metadata.Filename
+		objects[objectName] = models.StorageEntry{ObjectName: objectName, Source: source, Public: public, Metadata: metadata.AdditionalMetadata}
+		return objectName, nil
+	}
+	publishDiscordAttachmentObject = func(objectName string) error {
+		entry := objects[objectName]
+		entry.Public = true
+		objects[objectName] = entry
+		return nil
+	}
+
+	attachmentURLs := []string{
+		"https://cdn.discordapp.com/attachments/1/2/image.png",
+		"https://cdn.discordapp.com/attachments/1/3/large.mp4",
+	}
+
+	// stored when the message is created, not public until it is used in the eventlog
+	objectNames := storeDiscordAttachments(attachmentURLs[:1], "10", "1", "1", false)
+	if len(objectNames) != 1 || objectNames[0] != "object-image.png" || objects["object-image.png"].Public {
+		t.Fatal("helpers.storeDiscordAttachments() didn't store a private object:", objectNames, objects)
+	}
+
+	// reused when the message is deleted
+	option := getDiscordAttachmentsOption("message_attachments", attachmentURLs, "10", "1", "1")
+	if option.Value != "object-image.png,"+attachmentURLs[1] {
+		t.Fatal("helpers.getDiscordAttachmentsOption() returned the wrong objects:", option.Value)
+	}
+	if downloads != 2 {
+		t.Fatalf("helpers.getDiscordAttachmentsOption() downloaded %d attachments instead of reusing the stored object", downloads-1)
+	}
+	if !objects["object-image.png"].Public {
+		t.Fatal("helpers.getDiscordAttachmentsOption() didn't publish the reused object")
+	}
+	if len(objects) != 1 {
+		t.Fatal("helpers.getDiscordAttachmentsOption() stored an attachment larger than the limit")
+	}
+}
@@ -0,0 +1,221 @@
+package helpers
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+const (
+	// images with an average hash distance up to this are duplicates, same as for biasgame suggestions
+	GalleryDuplicateMaxDistance = 1
+	GalleryDuplicateWindow      = 7 * 24 * time.Hour
+	GalleryMaxDownloadSize      = 8 * 1024 * 1024
+	galleryDownloadTimeout      = 15 * time.Second
+)
+
+var (
+	galleryImageExtensions = []string{".png", ".jpg", ".jpeg", ".webp", ".bmp"}
+	galleryVideoExtensions = []string{".mp4", ".webm", ".mov", ".mkv"}
+
+	// can be replaced in tests
+	galleryDownloadClient = newGalleryDownloadClient()
+)
+
+// newGalleryDownloadClient returns a client that only connects to public addresses, like the outgoing webhooks client
+// links are posted by users, so they must not be able to make the bot request internal services
+func newGalleryDownloadClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: galleryDownloadTimeout,
+		Control: outgoingWebhookDialControl,
+	}
+
+	return &http.Client{
+		Timeout: galleryDownloadTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: galleryDownloadTimeout,
+		},
+	}
+}
+
+// DownloadGalleryFile downloads an attachment or link of a gallery post, returns an error if it is larger than GalleryMaxDownloadSize
+func DownloadGalleryFile(link string) (data []byte, err error) {
+	return netGetLimited(galleryDownloadClient, link, DEFAULT_UA, GalleryMaxDownloadSize)
+}
+
+// GetGalleryTargetChannelIDs returns all target channels of the gallery, including the target of galleries created before multiple targets
+func GetGalleryTargetChannelIDs(entry models.GalleryEntry) (channelIDs []string) {
+	channelIDs = make([]string, 0)
+	if entry.TargetChannelID != "" {
+		channelIDs = append(channelIDs, entry.TargetChannelID)
+	}
+	for _, channelID := range entry.TargetChannelIDs {
+		if channelID != entry.TargetChannelID {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	return channelIDs
+}
+
+// GetGalleryMediaType returns the media type of an attachment filename or a link based on its extension
+func GetGalleryMediaType(name string, isAttachment bool) string {
+	if parsedURL, err := url.Parse(name); err == nil && !isAttachment {
+		name = parsedURL.Path
+	}
+
+	extension := strings.ToLower(path.Ext(name))
+	if extension == ".gif" {
+		return models.GalleryMediaTypeGif
+	}
+	for _, imageExtension := range galleryImageExtensions {
+		if extension == imageExtension {
+			return models.GalleryMediaTypeImage
+		}
+	}
+	for _, videoExtension := range galleryVideoExtensions {
+		if extension == videoExtension {
+			return models.GalleryMediaTypeVideo
+		}
+	}
+
+	if isAttachment {
+		return models.GalleryMediaTypeFile
+	}
+	return models.GalleryMediaTypeLink
+}
+
+// GalleryMediaTypeAllowed returns true if the gallery posts the media type
+func GalleryMediaTypeAllowed(entry models.GalleryEntry, mediaType string) bool {
+	if len(entry.MediaTypes) <= 0 {
+		return true
+	}
+	for _, allowedMediaType := range entry.MediaTypes {
+		if allowedMediaType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// GalleryLinkDomainAllowed returns true if the host of the link is one of the link domains of the gallery or a subdomain of them
+func GalleryLinkDomainAllowed(entry models.GalleryEntry, link string) bool {
+	if len(entry.LinkDomains) <= 0 {
+		return true
+	}
+
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+
+	for _, domain := range entry.LinkDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// GalleryDimensionsAllowed returns true if the dimensions reach the minimum dimensions of the gallery, unknown dimensions (0x0) are allowed
+func GalleryDimensionsAllowed(entry models.GalleryEntry, width, height int) bool {
+	if width <= 0 && height <= 0 {
+		return true
+	}
+	return width >= entry.MinWidth && height >= entry.MinHeight
+}
+
+// ParseGalleryMediaTypes parses a comma separated list of media types, "all" allows every media type
+func ParseGalleryMediaTypes(text string) (mediaTypes []string, ok bool) {
+	mediaTypes = make([]string, 0)
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "all" {
+		return mediaTypes, true
+	}
+
+NextMediaType:
+	for _, mediaType := range strings.Split(text, ",") {
+		mediaType = strings.TrimSuffix(strings.TrimSpace(mediaType), "s")
+		for _, knownMediaType := range models.GalleryMediaTypes {
+			if mediaType == knownMediaType {
+				mediaTypes = append(mediaTypes, mediaType)
+				continue NextMediaType
+			}
+		}
+		return nil, false
+	}
+
+	return mediaTypes, len(mediaTypes) > 0
+}
+
+// ParseGalleryLinkDomains parses a comma separated list of domains, "all" allows every domain
+func ParseGalleryLinkDomains(text string) (domains []string, ok bool) {
+	domains = make([]string, 0)
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "all" {
+		return domains, true
+	}
+
+	for _, domain := range strings.Split(text, ",") {
+		domain = strings.TrimPrefix(strings.TrimSpace(domain), "www.")
+		if domain == "" || strings.ContainsAny(domain, "/:@ ") || !strings.Contains(domain, ".") {
+			return nil, false
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, true
+}
+
+// ParseGalleryDimensions parses minimum dimensions like "500x300", "off" removes the minimum dimensions
+func ParseGalleryDimensions(text string) (width, height int, ok bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "off" {
+		return 0, 0, true
+	}
+
+	parts := strings.Split(text, "x")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	width, err := strconv.Atoi(parts[0])
+	if err != nil || width < 0 {
+		return 0, 0, false
+	}
+	height, err = strconv.Atoi(parts[1])
+	if err != nil || height < 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// NormalizeGalleryURL returns the URL without fragment, trailing slash and with a lowercase host, to compare links
+func NormalizeGalleryURL(link string) string {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	parsedURL.Fragment = ""
+	parsedURL.Host = strings.TrimPrefix(strings.ToLower(parsedURL.Host), "www.")
+	parsedURL.Scheme = "https"
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+	return parsedURL.String()
+}
+
+// ParseGalleryToggle parses options like "on" or "off"
+func ParseGalleryToggle(text string) (enabled, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "on", "yes", "true", "enable":
+		return true, true
+	case "off", "no", "false", "disable":
+		return false, true
+	}
+	return false, false
+}
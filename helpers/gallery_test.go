@@ -0,0 +1,145 @@
+package helpers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestGetGalleryTargetChannelIDs(t *testing.T) {
+	channelIDs := GetGalleryTargetChannelIDs(models.GalleryEntry{
+		TargetChannelID:  "1",
+		TargetChannelIDs: []string{"1", "2"},
+	})
+
+	if len(channelIDs) != 2 || channelIDs[0] != "1" || channelIDs[1] != "2" {
+		t.Fatalf("helpers.GetGalleryTargetChannelIDs() returned the wrong channels: %v", channelIDs)
+	}
+}
+
+func TestGetGalleryMediaType(t *testing.T) {
+	for name, expected := range map[string]string{
+		"https://cdn.discordapp.com/attachments/1/2/IMG_01.JPG": models.GalleryMediaTypeImage,
+		"https://example.com/clip.mp4?width=300":                models.GalleryMediaTypeVideo,
+		"https://example.com/funny.gif":                         models.GalleryMediaTypeGif,
+		"https://twitter.com/user/status/1":                     models.GalleryMediaTypeLink,
+	} {
+		if mediaType := GetGalleryMediaType(name, false); mediaType != expected {
+			t.Fatalf("helpers.GetGalleryMediaType(%s) returned %s, expected %s", name, mediaType, expected)
+		}
+	}
+
+	if mediaType := GetGalleryMediaType("notes.txt", true); mediaType != models.GalleryMediaTypeFile {
+		t.Fatal("helpers.GetGalleryMediaType() returned the wrong media type for an attachment: " + mediaType)
+	}
+}
+
+func TestGalleryLinkDomainAllowed(t *testing.T) {
+	entry := models.GalleryEntry{LinkDomains: []string{"twitter.com"}}
+
+	if !GalleryLinkDomainAllowed(entry, "https://mobile.twitter.com/user/status/1") {
+		t.Fatal("helpers.GalleryLinkDomainAllowed() denied a subdomain")
+	}
+	if GalleryLinkDomainAllowed(entry, "https://nottwitter.com/user") {
+		t.Fatal("helpers.GalleryLinkDomainAllowed() allowed another domain")
+	}
+	if !GalleryLinkDomainAllowed(models.GalleryEntry{}, "https://example.com") {
+		t.Fatal("helpers.GalleryLinkDomainAllowed() denied a link without link domains")
+	}
+}
+
+func TestGalleryDimensionsAllowed(t *testing.T) {
+	entry := models.GalleryEntry{MinWidth: 500, MinHeight: 300}
+
+	if GalleryDimensionsAllowed(entry, 400, 400) {
+		t.Fatal("helpers.GalleryDimensionsAllowed() allowed a too small image")
+	}
+	if !GalleryDimensionsAllowed(entry, 500, 300) || !GalleryDimensionsAllowed(entry, 0, 0) {
+		t.Fatal("helpers.GalleryDimensionsAllowed() denied valid dimensions")
+	}
+}
+
+func TestParseGalleryMediaTypes(t *testing.T) {
+	mediaTypes, ok := ParseGalleryMediaTypes("images, gif")
+	if !ok || len(mediaTypes) != 2 || mediaTypes[0] != models.GalleryMediaTypeImage || mediaTypes[1] != models.GalleryMediaTypeGif {
+		t.Fatalf("helpers.ParseGalleryMediaTypes() returned the wrong media types: %v", mediaTypes)
+	}
+
+	mediaTypes, ok = ParseGalleryMediaTypes("all")
+	if !ok || len(mediaTypes) != 0 {
+		t.Fatal("helpers.ParseGalleryMediaTypes() did not allow all media types")
+	}
+
+	if _, ok = ParseGalleryMediaTypes("image,audio"); ok {
+		t.Fatal("helpers.ParseGalleryMediaTypes() accepted an unknown media type")
+	}
+}
+
+func TestParseGalleryLinkDomains(t *testing.T) {
+	domains, ok := ParseGalleryLinkDomains("www.Instagram.com,twitter.com")
+	if !ok || len(domains) != 2 || domains[0] != "instagram.com" || domains[1] != "twitter.com" {
+		t.Fatalf("helpers.ParseGalleryLinkDomains() returned the wrong domains: %v", domains)
+	}
+
+	if _, ok = ParseGalleryLinkDomains("https://twitter.com"); ok {
+		t.Fatal("helpers.ParseGalleryLinkDomains() accepted an URL")
+	}
+}
+
+func TestParseGalleryDimensions(t *testing.T) {
+	width, height, ok := ParseGalleryDimensions("500x300")
+	if !ok || width != 500 || height != 300 {
+		t.Fatalf("helpers.ParseGalleryDimensions() returned %dx%d", width, height)
+	}
+
+	if _, _, ok = ParseGalleryDimensions("500"); ok {
+		t.Fatal("helpers.ParseGalleryDimensions() accepted invalid dimensions")
+	}
+}
+
+func TestNormalizeGalleryURL(t *testing.T) {
+	if NormalizeGalleryURL("http://www.Example.com/image/#top") != NormalizeGalleryURL("https://example.com/image") {
+		t.Fatal("helpers.NormalizeGalleryURL() returned different URLs for the same link")
+	}
+}
+
+func TestDownloadGalleryFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := 1024
+		if r.URL.Path != "/small" {
+			size = GalleryMaxDownloadSize + 1
+		}
+		if r.URL.Path == "/chunked" {
+			// no content length, the body has to be limited while reading
+			w.(http.Flusher).Flush()
+		}
+		w.Write(bytes.Repeat([]byte{'a'}, size))
+	}))
+	defer server.Close()
+
+	// localhost resolves to a private address when connecting
+	_, err := DownloadGalleryFile(strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/small")
+	if err == nil || !strings.Contains(err.Error(), errOutgoingWebhookPrivateAddress.Error()) {
+		t.Fatal("helpers.DownloadGalleryFile() connected to a private address:", err)
+	}
+
+	defaultClient := galleryDownloadClient
+	galleryDownloadClient = server.Client()
+	defer func() {
+		galleryDownloadClient = defaultClient
+	}()
+
+	data, err := DownloadGalleryFile(server.URL + "/small")
+	if err != nil || len(data) != 1024 {
+		t.Fatal("helpers.DownloadGalleryFile() failed to download a small file:", len(data), err)
+	}
+	for _, path := range []string{"/large", "/chunked"} {
+		if _, err = DownloadGalleryFile(server.URL + path); err != errNetResponseTooLarge {
+			t.Fatalf("helpers.DownloadGalleryFile(%q) accepted a file larger than the limit: %v", path, err)
+		}
+	}
+}
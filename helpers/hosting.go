@@ -51,3 +51,48 @@ func UploadImage(imageData []byte) (hostedUrl string, err error) {
 	cache.GetLogger().WithField("module", "levels").Info("uploaded a picture to imgur: " + imgurResponse.Data.Link)
 	return imgurResponse.Data.Link, nil
 }
+
+type ImgurImage struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Type     string `json:"type"`
+	Size     int64  `json:"size"`
+	Link     string `json:"link"`
+	Animated bool   `json:"animated"`
+}
+
+// ImgurAlbumImages returns all images of an imgur album
+// albumID	: the album ID, for example Z0Jy3 for https://imgur.com/a/Z0Jy3
+func ImgurAlbumImages(albumID string) (images []ImgurImage, err error) {
+	client := &http.Client{
+		Timeout: 60 * time.Second,
+	}
+
+	req, err := http.NewRequest("GET", "https://api.imgur.com/3/album/"+url.PathEscape(albumID)+"/images", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Client-ID "+GetConfig().Path("imgur.client_id").Data().(string))
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var imgurResponse struct {
+		Data    []ImgurImage `json:"data"`
+		Status  int          `json:"status"`
+		Success bool         `json:"success"`
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&imgurResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if imgurResponse.Success == false {
+		return nil, errors.New(fmt.Sprintf("Imgur API Error: %d", imgurResponse.Status))
+	}
+
+	return imgurResponse.Data, nil
+}
@@ -0,0 +1,192 @@
+package helpers
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+const (
+	IdolCalendarDateFormat = "2006-01-02"
+)
+
+var (
+	// bias roles like "Jisoo (BLACKPINK)" name the group of the idol
+	idolCalendarQualifiedRoleRegex = regexp.MustCompile(`^(.+?)\s*\(([^()]+)\)$`)
+)
+
+type IdolCalendarOccurrence struct {
+	Event models.IdolCalendarEventEntry
+	Date  time.Time
+	Years int // age or years since the debut for yearly events
+}
+
+// IdolCalendarOccurrences returns all occurrences of the events between from and until (exclusive), ordered by date
+func IdolCalendarOccurrences(events []models.IdolCalendarEventEntry, from, until time.Time) (occurrences []IdolCalendarOccurrence) {
+	for _, event := range events {
+		eventDate := event.Date.UTC()
+
+		if event.Type == models.IdolCalendarEventTypeRelease {
+			date := time.Date(eventDate.Year(), eventDate.Month(), eventDate.Day(), 0, 0, 0, 0, from.Location())
+			if !date.Before(from) && date.Before(until) {
+				occurrences = append(occurrences, IdolCalendarOccurrence{Event: event, Date: date})
+			}
+			continue
+		}
+
+		for year := from.Year(); year <= until.Year(); year++ {
+			if year < eventDate.Year() {
+				continue
+			}
+			date := time.Date(year, eventDate.Month(), eventDate.Day(), 0, 0, 0, 0, from.Location())
+			// february 29th in non leap years
+			if date.Month() != eventDate.Month() {
+				date = time.Date(year, eventDate.Month(), eventDate.Day()-1, 0, 0, 0, 0, from.Location())
+			}
+			if !date.Before(from) && date.Before(until) {
+				occurrences = append(occurrences, IdolCalendarOccurrence{Event: event, Date: date, Years: year - eventDate.Year()})
+			}
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Date.Before(occurrences[j].Date)
+	})
+	return occurrences
+}
+
+// ParseIdolCalendarRecord parses a record in the format type, date (YYYY-MM-DD), group, idol, title
+func ParseIdolCalendarRecord(record []string) (event models.IdolCalendarEventEntry, err error) {
+	for len(record) < 5 {
+		record = append(record, "")
+	}
+	for i := range record {
+		record[i] = strings.TrimSpace(record[i])
+	}
+
+	event.Type = strings.ToLower(record[0])
+	event.GroupName = record[2]
+	event.IdolName = record[3]
+	event.Title = record[4]
+
+	event.Date, err = time.Parse(IdolCalendarDateFormat, record[1])
+	if err != nil {
+		return event, err
+	}
+
+	if event.GroupName == "" {
+		return event, errors.New("group is required")
+	}
+	switch event.Type {
+	case models.IdolCalendarEventTypeBirthday:
+		if event.IdolName == "" {
+			return event, errors.New("birthdays require an idol")
+		}
+	case models.IdolCalendarEventTypeDebut:
+	case models.IdolCalendarEventTypeRelease:
+		if event.Title == "" {
+			return event, errors.New("releases require a title")
+		}
+	default:
+		return event, errors.New("invalid type " + record[0])
+	}
+
+	return event, nil
+}
+
+// IdolCalendarBiasRoleGroups returns the known groups of the bias roles of a guild
+// a group is matched by a role or a category named after the group, or by a role qualified with the group like "Jisoo (BLACKPINK)"
+// idol names alone don't match a group, many idols of different groups share the same name
+func IdolCalendarBiasRoleGroups(biasEntries []models.BiasEntry, knownGroupNames []string) (groupNames []string) {
+	knownGroups := make(map[string]string)
+	for _, groupName := range knownGroupNames {
+		knownGroups[strings.ToLower(groupName)] = groupName
+	}
+
+	addGroup := func(name string) {
+		groupName, ok := knownGroups[strings.ToLower(name)]
+		if ok && !idolCalendarContainsFold(groupNames, groupName) {
+			groupNames = append(groupNames, groupName)
+		}
+	}
+
+	for _, biasEntry := range biasEntries {
+		for _, category := range biasEntry.Categories {
+			addGroup(category.Label)
+			for _, role := range category.Roles {
+				for _, name := range idolCalendarBiasRoleNames(role) {
+					addGroup(name)
+					if _, groupName, ok := splitIdolCalendarRoleName(name); ok {
+						addGroup(groupName)
+					}
+				}
+			}
+		}
+	}
+	return groupNames
+}
+
+// IdolCalendarBiasRoleMatches returns true if the bias role of the category is for the idol of the group, or for the group if idolName is empty
+// idol roles only match within their group: in a category named after the group, qualified like "Jisoo (BLACKPINK)",
+// or on a guild which has a role for the group in its bias entries
+func IdolCalendarBiasRoleMatches(guildBiasEntries []models.BiasEntry, category models.BiasEntryCategory, role models.BiasEntryRole, groupName, idolName string) bool {
+	roleNames := idolCalendarBiasRoleNames(role)
+	if idolName == "" {
+		return idolCalendarContainsFold(roleNames, groupName)
+	}
+
+	for _, name := range roleNames {
+		if roleIdolName, roleGroupName, ok := splitIdolCalendarRoleName(name); ok {
+			if strings.EqualFold(roleIdolName, idolName) && strings.EqualFold(roleGroupName, groupName) {
+				return true
+			}
+		}
+	}
+	if !idolCalendarContainsFold(roleNames, idolName) {
+		return false
+	}
+
+	if strings.EqualFold(category.Label, groupName) {
+		return true
+	}
+	for _, biasEntry := range guildBiasEntries {
+		for _, guildCategory := range biasEntry.Categories {
+			for _, guildRole := range guildCategory.Roles {
+				if idolCalendarContainsFold(idolCalendarBiasRoleNames(guildRole), groupName) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func idolCalendarBiasRoleNames(role models.BiasEntryRole) (names []string) {
+	names = append(names, strings.ToLower(role.Name), strings.ToLower(role.Print))
+	for _, alias := range role.Aliases {
+		names = append(names, strings.ToLower(alias))
+	}
+	return names
+}
+
+// splitIdolCalendarRoleName splits a role name like "Jisoo (BLACKPINK)" into the idol and the group
+func splitIdolCalendarRoleName(name string) (idolName, groupName string, ok bool) {
+	parts := idolCalendarQualifiedRoleRegex.FindStringSubmatch(strings.TrimSpace(name))
+	if len(parts) < 3 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2]), true
+}
+
+func idolCalendarContainsFold(list []string, item string) bool {
+	for _, listItem := range list {
+		if strings.EqualFold(listItem, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestParseIdolCalendarRecord(t *testing.T) {
+	event, err := ParseIdolCalendarRecord([]string{" Birthday", "1995-01-03 ", "BLACKPINK", "Jisoo"})
+	if err != nil || event.Type != models.IdolCalendarEventTypeBirthday || event.GroupName != "BLACKPINK" ||
+		event.IdolName != "Jisoo" || !event.Date.Equal(time.Date(1995, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("helpers.ParseIdolCalendarRecord() returned a wrong event:", event, err)
+	}
+
+	for _, record := range [][]string{
+		{"birthday", "1995-01-03", "BLACKPINK"},
+		{"release", "2018-06-15", "BLACKPINK"},
+		{"debut", "2016-08-08", ""},
+		{"debut", "08/08/2016", "BLACKPINK"},
+		{"concert", "2016-08-08", "BLACKPINK"},
+	} {
+		if _, err = ParseIdolCalendarRecord(record); err == nil {
+			t.Fatalf("helpers.ParseIdolCalendarRecord() accepted the invalid record %q", record)
+		}
+	}
+}
+
+func TestIdolCalendarOccurrences(t *testing.T) {
+	kst := time.FixedZone("KST", 9*60*60)
+	events := []models.IdolCalendarEventEntry{
+		{Type: models.IdolCalendarEventTypeBirthday, Date: time.Date(1996, 2, 29, 0, 0, 0, 0, time.UTC), GroupName: "A", IdolName: "Leap"},
+		{Type: models.IdolCalendarEventTypeDebut, Date: time.Date(2016, 12, 31, 0, 0, 0, 0, time.UTC), GroupName: "B"},
+		{Type: models.IdolCalendarEventTypeRelease, Date: time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), GroupName: "C", Title: "Single"},
+		{Type: models.IdolCalendarEventTypeRelease, Date: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC), GroupName: "C", Title: "Old"},
+		{Type: models.IdolCalendarEventTypeDebut, Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), GroupName: "D"},
+	}
+
+	// the range spans the end of the year
+	occurrences := IdolCalendarOccurrences(events, time.Date(2018, 12, 30, 0, 0, 0, 0, kst), time.Date(2019, 1, 6, 0, 0, 0, 0, kst))
+	if len(occurrences) != 2 ||
+		occurrences[0].Event.GroupName != "B" || occurrences[0].Years != 2 ||
+		!occurrences[0].Date.Equal(time.Date(2018, 12, 31, 0, 0, 0, 0, kst)) ||
+		occurrences[1].Event.Title != "Single" || !occurrences[1].Date.Equal(time.Date(2019, 1, 2, 0, 0, 0, 0, kst)) {
+		t.Fatal("helpers.IdolCalendarOccurrences() returned wrong occurrences:", occurrences)
+	}
+
+	// february 29th is on the 28th in non leap years
+	occurrences = IdolCalendarOccurrences(events[:1], time.Date(2019, 2, 28, 0, 0, 0, 0, kst), time.Date(2019, 3, 2, 0, 0, 0, 0, kst))
+	if len(occurrences) != 1 || !occurrences[0].Date.Equal(time.Date(2019, 2, 28, 0, 0, 0, 0, kst)) || occurrences[0].Years != 23 {
+		t.Fatal("helpers.IdolCalendarOccurrences() returned wrong occurrences for a non leap year:", occurrences)
+	}
+	occurrences = IdolCalendarOccurrences(events[:1], time.Date(2020, 2, 28, 0, 0, 0, 0, kst), time.Date(2020, 3, 2, 0, 0, 0, 0, kst))
+	if len(occurrences) != 1 || !occurrences[0].Date.Equal(time.Date(2020, 2, 29, 0, 0, 0, 0, kst)) {
+		t.Fatal("helpers.IdolCalendarOccurrences() returned wrong occurrences for a leap year:", occurrences)
+	}
+
+	// yearly events start in the year of the event, every year of the range is included
+	occurrences = IdolCalendarOccurrences(events[1:2], time.Date(2015, 1, 1, 0, 0, 0, 0, kst), time.Date(2019, 1, 1, 0, 0, 0, 0, kst))
+	if len(occurrences) != 3 || occurrences[0].Years != 0 || occurrences[2].Years != 2 {
+		t.Fatal("helpers.IdolCalendarOccurrences() returned wrong occurrences for multiple years:", occurrences)
+	}
+}
+
+func TestIdolCalendarBiasRoles(t *testing.T) {
+	jisoo := models.BiasEntryRole{Name: "Jisoo"}
+	jennie := models.BiasEntryRole{Name: "jennie (BLACKPINK)"}
+	biasEntries := []models.BiasEntry{{
+		GuildID: "1",
+		Categories: []models.BiasEntryCategory{
+			{Label: "Bias", Roles: []models.BiasEntryRole{jisoo, jennie, {Name: "Hyejin"}}},
+			{Label: "TWICE", Roles: []models.BiasEntryRole{{Name: "Nayeon"}}},
+		},
+	}}
+
+	groupNames := IdolCalendarBiasRoleGroups(biasEntries, []string{"BLACKPINK", "TWICE", "MAMAMOO"})
+	if len(groupNames) != 2 || groupNames[0] != "BLACKPINK" || groupNames[1] != "TWICE" {
+		t.Fatal("helpers.IdolCalendarBiasRoleGroups() returned the wrong groups:", groupNames)
+	}
+
+	category := biasEntries[0].Categories[0]
+	if !IdolCalendarBiasRoleMatches(biasEntries, category, jennie, "BLACKPINK", "Jennie") {
+		t.Fatal("helpers.IdolCalendarBiasRoleMatches() didn't match a qualified role")
+	}
+	if IdolCalendarBiasRoleMatches(biasEntries, category, jisoo, "BLACKPINK", "Jisoo") {
+		t.Fatal("helpers.IdolCalendarBiasRoleMatches() matched an idol without the group")
+	}
+	if !IdolCalendarBiasRoleMatches(biasEntries, biasEntries[0].Categories[1], models.BiasEntryRole{Name: "Nayeon"}, "TWICE", "Nayeon") {
+		t.Fatal("helpers.IdolCalendarBiasRoleMatches() didn't match an idol in the category of the group")
+	}
+
+	biasEntries[0].Categories[0].Roles = append(biasEntries[0].Categories[0].Roles, models.BiasEntryRole{Name: "MAMAMOO"})
+	if !IdolCalendarBiasRoleMatches(biasEntries, category, models.BiasEntryRole{Name: "Hyejin"}, "MAMAMOO", "Hyejin") {
+		t.Fatal("helpers.IdolCalendarBiasRoleMatches() didn't match an idol on a server with a role for the group")
+	}
+	if !IdolCalendarBiasRoleMatches(biasEntries, category, models.BiasEntryRole{Name: "MAMAMOO"}, "MAMAMOO", "") {
+		t.Fatal("helpers.IdolCalendarBiasRoleMatches() didn't match a group role")
+	}
+}
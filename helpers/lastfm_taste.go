@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"math"
+	"sort"
+)
+
+// LastFmTasteCompatibility compares the artist play counts of two users, the keys have to be normalized the same way
+// returns the shared artists, the artists both users listen to the most first, and the compatibility from 0 to 100
+func LastFmTasteCompatibility(playsA, playsB map[string]int) (sharedArtists []string, compatibility int) {
+	var totalA, totalB int
+	for _, plays := range playsA {
+		totalA += plays
+	}
+	for _, plays := range playsB {
+		totalB += plays
+	}
+	if totalA <= 0 || totalB <= 0 {
+		return nil, 0
+	}
+
+	// the compatibility is the overlap of the shares of the plays of both users
+	sharedShares := make(map[string]float64)
+	var overlap float64
+	for artist, plays := range playsA {
+		if playsB[artist] <= 0 || plays <= 0 {
+			continue
+		}
+
+		sharedShares[artist] = math.Min(float64(plays)/float64(totalA), float64(playsB[artist])/float64(totalB))
+		overlap += sharedShares[artist]
+		sharedArtists = append(sharedArtists, artist)
+	}
+
+	sort.Slice(sharedArtists, func(i, j int) bool {
+		if sharedShares[sharedArtists[i]] == sharedShares[sharedArtists[j]] {
+			return sharedArtists[i] < sharedArtists[j]
+		}
+		return sharedShares[sharedArtists[i]] > sharedShares[sharedArtists[j]]
+	})
+
+	return sharedArtists, int(math.Round(overlap * 100))
+}
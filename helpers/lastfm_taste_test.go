@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestLastFmTasteCompatibility(t *testing.T) {
+	sharedArtists, compatibility := LastFmTasteCompatibility(
+		map[string]int{"twice": 50, "red velvet": 30, "bts": 20},
+		map[string]int{"twice": 10, "red velvet": 60, "exo": 30},
+	)
+	if len(sharedArtists) != 2 || sharedArtists[0] != "red velvet" || sharedArtists[1] != "twice" {
+		t.Fatalf("helpers.LastFmTasteCompatibility() returned the wrong shared artists: %v", sharedArtists)
+	}
+	// min(0.3, 0.6) + min(0.5, 0.1)
+	if compatibility != 40 {
+		t.Fatalf("helpers.LastFmTasteCompatibility() returned %d instead of 40", compatibility)
+	}
+
+	_, compatibility = LastFmTasteCompatibility(map[string]int{"twice": 5}, map[string]int{"twice": 100})
+	if compatibility != 100 {
+		t.Fatalf("helpers.LastFmTasteCompatibility() returned %d for the same taste", compatibility)
+	}
+
+	sharedArtists, compatibility = LastFmTasteCompatibility(map[string]int{"twice": 5}, map[string]int{})
+	if len(sharedArtists) != 0 || compatibility != 0 {
+		t.Fatal("helpers.LastFmTasteCompatibility() found shared artists without plays")
+	}
+}
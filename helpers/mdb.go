@@ -497,6 +497,29 @@ func MdbPipeOneWithoutLogging(collection models.MongoDbCollection, pipeline inte
 	return MdbCollection(collection).Pipe(pipeline).One(object)
 }
 
+func MdbPipeAll(collection models.MongoDbCollection, pipeline interface{}, result interface{}) (err error) {
+	start := time.Now()
+	err = MdbCollection(collection).Pipe(pipeline).All(result)
+	took := time.Since(start)
+	if cache.HasKeen() {
+		go func() {
+			defer Recover()
+
+			err := cache.GetKeen().AddEvent("Robyul_MongoDB", &KeenMongoDbEvent{
+				Seconds:    took.Seconds(),
+				Type:       "pipeline",
+				Method:     "MdbPipeAll()",
+				Collection: stripRobyulDatabaseFromCollection(collection.String()),
+				Query:      truncateKeenValue(fmt.Sprintf("%+v", pipeline)),
+			})
+			if err != nil {
+				cache.GetLogger().WithField("module", "mdb").Error("Error logging MongoDB request to keen: ", err.Error())
+			}
+		}()
+	}
+	return err
+}
+
 func MdbCount(collection models.MongoDbCollection, query interface{}) (count int, err error) {
 	start := time.Now()
 	count, err = MdbCollection(collection).Find(query).Count()
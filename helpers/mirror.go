@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	MirrorMessageLinkRegex = regexp.MustCompile(`https?://(?:(?:canary|ptb)\.)?discordapp\.com/channels/\d+/\d+/(\d+)`)
+)
+
+// MirrorRewriteMessageLinks replaces jump links with the link returned by resolve for the message ID, links resolve returns false for are kept
+func MirrorRewriteMessageLinks(content string, resolve func(messageID string) (link string, ok bool)) string {
+	return MirrorMessageLinkRegex.ReplaceAllStringFunc(content, func(link string) string {
+		parts := MirrorMessageLinkRegex.FindStringSubmatch(link)
+		if newLink, ok := resolve(parts[1]); ok {
+			return newLink
+		}
+		return link
+	})
+}
+
+// MirrorFormatReactions returns reaction counts like "👍 3  <:blob:1234> 1", sorted by count
+// the keys are emoji API names, custom emoji are formatted as "name:id"
+func MirrorFormatReactions(counts map[string]int) (text string) {
+	emojis := make([]string, 0)
+	for emoji, count := range counts {
+		if count > 0 {
+			emojis = append(emojis, emoji)
+		}
+	}
+	sort.Slice(emojis, func(i, j int) bool {
+		if counts[emojis[i]] != counts[emojis[j]] {
+			return counts[emojis[i]] > counts[emojis[j]]
+		}
+		return emojis[i] < emojis[j]
+	})
+
+	parts := make([]string, 0, len(emojis))
+	for _, emoji := range emojis {
+		emojiText := emoji
+		if strings.Contains(emoji, ":") {
+			emojiText = "<:" + emoji + ">"
+		}
+		parts = append(parts, emojiText+" "+strconv.Itoa(counts[emoji]))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// MirrorUploadAttachmentIDs returns the IDs of the attachments which fit into one upload of up to maxSize bytes, in the order of the message
+// attachments which don't fit are skipped, smaller attachments after them are still added
+func MirrorUploadAttachmentIDs(attachments []*discordgo.MessageAttachment, maxSize int) (attachmentIDs map[string]bool) {
+	attachmentIDs = make(map[string]bool)
+	var size int
+	for _, attachment := range attachments {
+		if size+attachment.Size > maxSize {
+			continue
+		}
+		size += attachment.Size
+		attachmentIDs[attachment.ID] = true
+	}
+	return attachmentIDs
+}
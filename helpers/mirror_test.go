@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMirrorRewriteMessageLinks(t *testing.T) {
+	content := "look at https://discordapp.com/channels/1/2/3 and https://canary.discordapp.com/channels/1/2/4"
+	rewritten := MirrorRewriteMessageLinks(content, func(messageID string) (link string, ok bool) {
+		if messageID == "3" {
+			return "https://discordapp.com/channels/5/6/7", true
+		}
+		return "", false
+	})
+
+	if rewritten != "look at https://discordapp.com/channels/5/6/7 and https://canary.discordapp.com/channels/1/2/4" {
+		t.Fatal("helpers.MirrorRewriteMessageLinks() returned the wrong content: " + rewritten)
+	}
+}
+
+func TestMirrorFormatReactions(t *testing.T) {
+	text := MirrorFormatReactions(map[string]int{
+		"👍":           3,
+		"blob:1234":   5,
+		"❤":           3,
+		"removed:123": 0,
+	})
+
+	if text != "<:blob:1234> 5  ❤ 3  👍 3" {
+		t.Fatal("helpers.MirrorFormatReactions() returned the wrong text: " + text)
+	}
+	if MirrorFormatReactions(map[string]int{"👍": -1}) != "" {
+		t.Fatal("helpers.MirrorFormatReactions() included reactions without count")
+	}
+}
+
+func TestMirrorUploadAttachmentIDs(t *testing.T) {
+	attachmentIDs := MirrorUploadAttachmentIDs([]*discordgo.MessageAttachment{
+		{ID: "1", Size: 4},
+		{ID: "2", Size: 5},
+		{ID: "3", Size: 3},
+		{ID: "4", Size: 11},
+		{ID: "5", Size: 1},
+	}, 8)
+
+	if len(attachmentIDs) != 3 || !attachmentIDs["1"] || !attachmentIDs["3"] || !attachmentIDs["5"] {
+		t.Fatal("helpers.MirrorUploadAttachmentIDs() returned the wrong attachments:", attachmentIDs)
+	}
+}
@@ -74,6 +74,9 @@ const (
 	ModulePermEventlog  // eventlog/
 	ModulePermCrypto    // crypto.go
 	ModulePermImgur     // imgur.go
+	ModulePermGiveaway  // giveaway/
+	ModulePermBirthdays // birthdays.go
+	ModulePermCalendar  // idolcalendar.go
 
 	ModulePermAll = ModulePermStats | ModulePermTranslator | ModulePermUrban | ModulePermWeather | ModulePermVLive |
 		ModulePermInstagram | ModulePermFacebook | ModulePermWolframAlpha | ModulePermLastFm | ModulePermTwitter |
@@ -85,7 +88,8 @@ const (
 		ModulePermAutoRole | ModulePermBias | ModulePermDiscordmoney | ModulePermGallery |
 		ModulePermGuildAnnouncements | ModulePermMirror | ModulePermMirror | ModulePermMod | ModulePermNotifications |
 		ModulePermNuke | ModulePermPersistency | ModulePermPing | ModulePermTroublemaker | ModulePermVanityInvite |
-		ModulePerm8ball | ModulePermFeedback | ModulePermEmbedPost | ModulePermEventlog | ModulePermCrypto | ModulePermImgur
+		ModulePerm8ball | ModulePermFeedback | ModulePermEmbedPost | ModulePermEventlog | ModulePermCrypto | ModulePermImgur |
+		ModulePermGiveaway | ModulePermBirthdays | ModulePermCalendar
 )
 
 var (
@@ -144,6 +148,9 @@ var (
 		{Names: []string{"eventlog"}, Permission: ModulePermEventlog},
 		{Names: []string{"crypto"}, Permission: ModulePermCrypto},
 		{Names: []string{"imgur"}, Permission: ModulePermImgur},
+		{Names: []string{"giveaway", "giveaways"}, Permission: ModulePermGiveaway},
+		{Names: []string{"birthdays", "birthday"}, Permission: ModulePermBirthdays},
+		{Names: []string{"calendar"}, Permission: ModulePermCalendar},
 	}
 )
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 
@@ -15,6 +16,8 @@ import (
 
 var DEFAULT_UA = "Robyul2/" + version.BOT_VERSION + " (https://robyul.chat)"
 
+var errNetResponseTooLarge = errors.New("the response is too large")
+
 // NetGet executes a GET request to url with the Karen/Discord-Bot user-agent
 func NetGet(url string) []byte {
 	return NetGetUA(url, DEFAULT_UA)
@@ -94,6 +97,42 @@ func NetGetUAWithError(url string, useragent string) ([]byte, error) {
 	return []byte{}, errors.New("internal error")
 }
 
+// NetGetUAWithLimit performs a GET request with a custom user-agent, returns an error if the response is larger than limit bytes
+func NetGetUAWithLimit(url string, useragent string, limit int64) ([]byte, error) {
+	return netGetLimited(&http.Client{Timeout: time.Duration(15 * time.Second)}, url, useragent, limit)
+}
+
+func netGetLimited(client *http.Client, url string, useragent string, limit int64) ([]byte, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return []byte{}, err
+	}
+	request.Header.Set("User-Agent", useragent)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return []byte{}, errors.New("expected status 200; got " + strconv.Itoa(response.StatusCode))
+	}
+	if response.ContentLength > limit {
+		return []byte{}, errNetResponseTooLarge
+	}
+
+	// the content length is optional, read at most one byte more than allowed to notice larger responses
+	data, err := ioutil.ReadAll(io.LimitReader(response.Body, limit+1))
+	if err != nil {
+		return []byte{}, err
+	}
+	if int64(len(data)) > limit {
+		return []byte{}, errNetResponseTooLarge
+	}
+	return data, nil
+}
+
 func NetGetUAWithErrorAndTransport(url string, useragent string, transport http.Transport) ([]byte, error) {
 	// Allocate client
 	client := &http.Client{
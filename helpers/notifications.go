@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+const (
+	NotificationsRegexMaxLength = 200
+	NotificationsRegexPerUser   = 10
+	// messages are cut to this length before being matched against word and regex keywords
+	NotificationsMatchMaxLength = 2000
+	// matches above this many notifications per user and hour are collected in a digest instead
+	NotificationsMaxPerHour        = 20
+	NotificationsDigestMinInterval = 5
+	NotificationsDigestMaxInterval = 1440
+	// used for matches deferred by quiet hours or the hourly cap of users without digest mode
+	NotificationsDigestDefaultInterval = 60
+	NotificationsDigestMaxEntries      = 50
+	NotificationsSnoozeMax             = 30 * 24 * time.Hour
+)
+
+// CompileNotificationKeyword returns the case insensitive expression for word and regex keywords, nil for default keywords
+// Go uses RE2, matching runs in linear time to the input so user supplied expressions can not backtrack catastrophically
+func CompileNotificationKeyword(keyword, matchMode string) (expression *regexp.Regexp, err error) {
+	switch matchMode {
+	case models.NotificationsMatchModeWord:
+		return regexp.Compile(`(?i)(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(keyword) + `(?:$|[^\p{L}\p{N}_])`)
+	case models.NotificationsMatchModeRegex:
+		if len(keyword) > NotificationsRegexMaxLength {
+			return nil, errors.New("expression is too long")
+		}
+		return regexp.Compile(`(?i)` + keyword)
+	case models.NotificationsMatchModeDefault:
+		return nil, nil
+	}
+	return nil, errors.New("unknown match mode")
+}
+
+// NotificationKeywordMatches returns true if the compiled keyword matches the content
+func NotificationKeywordMatches(expression *regexp.Regexp, content string) bool {
+	if expression == nil {
+		return false
+	}
+	if len(content) > NotificationsMatchMaxLength {
+		content = content[:NotificationsMatchMaxLength]
+	}
+	return expression.MatchString(content)
+}
+
+// ParseQuietHours parses quiet hours like "22-7", the hours are in the timezone of the user
+func ParseQuietHours(text string) (start, end int, ok bool) {
+	parts := strings.Split(strings.TrimSpace(text), "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || start < 0 || start > 23 {
+		return 0, 0, false
+	}
+	end, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || end < 0 || end > 23 || start == end {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// InQuietHours returns true if the time is between start (inclusive) and end (exclusive), quiet hours can span midnight
+func InQuietHours(start, end int, now time.Time) bool {
+	hour := now.Hour()
+	if start < end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+// ParseDigestInterval parses a digest interval like "30", "30m", "2h", or "off", 0 disables the digest mode
+func ParseDigestInterval(text string) (minutes int, ok bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	switch text {
+	case "off", "disable", "0":
+		return 0, true
+	}
+
+	minutes, err := strconv.Atoi(text)
+	if err != nil {
+		duration, err := time.ParseDuration(text)
+		if err != nil {
+			return 0, false
+		}
+		minutes = int(duration.Minutes())
+	}
+
+	if minutes < NotificationsDigestMinInterval || minutes > NotificationsDigestMaxInterval {
+		return 0, false
+	}
+	return minutes, true
+}
+
+// NotificationsAllowImmediate counts a notification for the user, returns false once the user reached NotificationsMaxPerHour in the current hour
+func NotificationsAllowImmediate(userID string) (allowed bool) {
+	key := fmt.Sprintf(models.NotificationsSentCountRedisKey, userID, time.Now().UTC().Format("2006010215"))
+
+	count, err := cache.GetRedisClient().Incr(key).Result()
+	if err != nil {
+		RelaxLog(err)
+		return true
+	}
+	if count == 1 {
+		cache.GetRedisClient().Expire(key, time.Hour)
+	}
+
+	return count <= NotificationsMaxPerHour
+}
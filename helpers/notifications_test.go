@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestCompileNotificationKeyword(t *testing.T) {
+	expression, err := CompileNotificationKeyword("Jimin", models.NotificationsMatchModeWord)
+	if err != nil {
+		t.Fatal("helpers.CompileNotificationKeyword() returned an error: " + err.Error())
+	}
+	for content, expected := range map[string]bool{
+		"jimin":              true,
+		"I love JIMIN!":      true,
+		"(jimin)":            true,
+		"jimins solo":        false,
+		"park_jimin":         false,
+		"지민 jimin 지민":        true,
+		"jiminie is so cute": false,
+	} {
+		if NotificationKeywordMatches(expression, content) != expected {
+			t.Fatalf("helpers.NotificationKeywordMatches() returned %t for %q in word mode", !expected, content)
+		}
+	}
+
+	expression, err = CompileNotificationKeyword(`ji+min(ie)?`, models.NotificationsMatchModeRegex)
+	if err != nil {
+		t.Fatal("helpers.CompileNotificationKeyword() returned an error: " + err.Error())
+	}
+	if !NotificationKeywordMatches(expression, "JIIIMINIE") || NotificationKeywordMatches(expression, "jmin") {
+		t.Fatal("helpers.NotificationKeywordMatches() didn't match the regex keyword")
+	}
+
+	if _, err = CompileNotificationKeyword(`(unclosed`, models.NotificationsMatchModeRegex); err == nil {
+		t.Fatal("helpers.CompileNotificationKeyword() accepted an invalid expression")
+	}
+	if expression, _ = CompileNotificationKeyword("jimin", models.NotificationsMatchModeDefault); expression != nil {
+		t.Fatal("helpers.CompileNotificationKeyword() compiled a default keyword")
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	start, end, ok := ParseQuietHours("22-7")
+	if !ok || start != 22 || end != 7 {
+		t.Fatal("helpers.ParseQuietHours() didn't parse 22-7")
+	}
+	for _, text := range []string{"", "22", "7-7", "24-7", "a-b"} {
+		if _, _, ok = ParseQuietHours(text); ok {
+			t.Fatalf("helpers.ParseQuietHours(%q) accepted invalid quiet hours", text)
+		}
+	}
+
+	for hour, expected := range map[int]bool{21: false, 22: true, 0: true, 6: true, 7: false, 12: false} {
+		if InQuietHours(22, 7, time.Date(2018, time.May, 1, hour, 30, 0, 0, time.UTC)) != expected {
+			t.Fatalf("helpers.InQuietHours(22, 7) returned %t at %d:30", !expected, hour)
+		}
+	}
+	if !InQuietHours(1, 5, time.Date(2018, time.May, 1, 3, 0, 0, 0, time.UTC)) ||
+		InQuietHours(1, 5, time.Date(2018, time.May, 1, 5, 0, 0, 0, time.UTC)) {
+		t.Fatal("helpers.InQuietHours(1, 5) returned the wrong result")
+	}
+}
+
+func TestParseDigestInterval(t *testing.T) {
+	for text, expected := range map[string]int{"30": 30, "2h": 120, "45m": 45, "off": 0} {
+		minutes, ok := ParseDigestInterval(text)
+		if !ok || minutes != expected {
+			t.Fatalf("helpers.ParseDigestInterval(%q) returned %d, %t, expected %d", text, minutes, ok, expected)
+		}
+	}
+	for _, text := range []string{"1", "2d", "25h", "soon"} {
+		if _, ok := ParseDigestInterval(text); ok {
+			t.Fatalf("helpers.ParseDigestInterval(%q) accepted an invalid interval", text)
+		}
+	}
+}
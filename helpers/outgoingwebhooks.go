@@ -0,0 +1,451 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	OutgoingWebhooksPerGuild = 5
+	// retries use a fibonacci backoff starting at OutgoingWebhookRetryTimeout seconds, about 16 minutes in total
+	OutgoingWebhookMaxRetries   = 8
+	OutgoingWebhookRetryTimeout = 10
+	outgoingWebhookTimeout      = 10 * time.Second
+	// deliveries above the limits are postponed instead of waiting, to keep machinery workers free for other tasks
+	OutgoingWebhookMaxInFlightPerGuild = 2
+	OutgoingWebhookMaxInFlight         = 4
+	outgoingWebhookPostponeDelay       = 5 * time.Second
+)
+
+var (
+	outgoingWebhooksCache     = make(map[string][]models.OutgoingWebhookEntry)
+	outgoingWebhooksCacheLock = sync.RWMutex{}
+
+	outgoingWebhookPrivateNetworks = []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/4", "::/128", "::1/128", "64:ff9b::/96", "fc00::/7", "fe80::/10",
+		"ff00::/8",
+	}
+	// can be replaced in tests
+	outgoingWebhookLookupIP = net.LookupIP
+
+	errOutgoingWebhookPrivateAddress = errors.New("private addresses are not allowed")
+)
+
+// NewOutgoingWebhookSecret returns a new random secret to sign the deliveries with
+func NewOutgoingWebhookSecret() (secret string, err error) {
+	data := make([]byte, 32)
+	_, err = rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// SignOutgoingWebhookPayload returns the hex encoded HMAC-SHA256 of "{timestamp}.{body}" using the secret
+func SignOutgoingWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateOutgoingWebhookURL returns an error if the URL is not a public HTTPS URL
+// hostnames are resolved, the addresses are checked again when connecting to the webhook
+func ValidateOutgoingWebhookURL(rawURL string) (err error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if parsedURL.Scheme != "https" {
+		return errors.New("only https urls are allowed")
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("invalid host")
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ips, err = outgoingWebhookLookupIP(host)
+		if err != nil {
+			return err
+		}
+	}
+	for _, ip := range ips {
+		if isOutgoingWebhookPrivateIP(ip) {
+			return errOutgoingWebhookPrivateAddress
+		}
+	}
+
+	return nil
+}
+
+func isOutgoingWebhookPrivateIP(ip net.IP) bool {
+	for _, network := range outgoingWebhookPrivateNetworks {
+		_, privateNetwork, _ := net.ParseCIDR(network)
+		if privateNetwork.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// outgoingWebhookDialControl rejects connections to private addresses, the hostname could resolve to a different address since the validation
+func outgoingWebhookDialControl(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isOutgoingWebhookPrivateIP(ip) {
+		return errOutgoingWebhookPrivateAddress
+	}
+	return nil
+}
+
+func newOutgoingWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: outgoingWebhookTimeout,
+		Control: outgoingWebhookDialControl,
+	}
+
+	return &http.Client{
+		Timeout: outgoingWebhookTimeout,
+		// no proxy from the environment, the dialer has to see the address of the webhook
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: outgoingWebhookTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ParseOutgoingWebhookEvents parses a comma separated list of event types, returns false if one of the event types is unknown
+func ParseOutgoingWebhookEvents(text string) (eventTypes []string, ok bool) {
+	eventTypes = make([]string, 0)
+NextEventType:
+	for _, eventType := range strings.Split(strings.ToLower(text), ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		for _, existingEventType := range eventTypes {
+			if existingEventType == eventType {
+				continue NextEventType
+			}
+		}
+		for _, knownEventType := range models.OutgoingWebhookEvents {
+			if knownEventType == eventType {
+				eventTypes = append(eventTypes, eventType)
+				continue NextEventType
+			}
+		}
+		return nil, false
+	}
+
+	return eventTypes, len(eventTypes) > 0
+}
+
+// OutgoingWebhookSubscribed returns true if the webhook should receive events of the event type
+func OutgoingWebhookSubscribed(entry models.OutgoingWebhookEntry, eventType string) bool {
+	for _, subscribedEventType := range entry.EventTypes {
+		if subscribedEventType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// OutgoingWebhooksCacheReset has to be called after changing the webhooks of a guild
+func OutgoingWebhooksCacheReset(guildID string) {
+	outgoingWebhooksCacheLock.Lock()
+	defer outgoingWebhooksCacheLock.Unlock()
+
+	delete(outgoingWebhooksCache, guildID)
+}
+
+func getOutgoingWebhooks(guildID string) (entries []models.OutgoingWebhookEntry, err error) {
+	outgoingWebhooksCacheLock.RLock()
+	entries, ok := outgoingWebhooksCache[guildID]
+	outgoingWebhooksCacheLock.RUnlock()
+	if ok {
+		return entries, nil
+	}
+
+	err = MDbIterWithoutLogging(MdbCollection(models.OutgoingWebhooksTable).Find(bson.M{"guildid": guildID})).All(&entries)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = make([]models.OutgoingWebhookEntry, 0)
+	}
+
+	outgoingWebhooksCacheLock.Lock()
+	outgoingWebhooksCache[guildID] = entries
+	outgoingWebhooksCacheLock.Unlock()
+
+	return entries, nil
+}
+
+// OutgoingWebhookEmit queues a delivery of the event for every webhook of the guild subscribed to the event type
+func OutgoingWebhookEmit(guildID, eventType string, data interface{}) {
+	if guildID == "" {
+		return
+	}
+
+	entries, err := getOutgoingWebhooks(guildID)
+	if err != nil {
+		RelaxLog(err)
+		return
+	}
+
+	for _, entry := range entries {
+		if !OutgoingWebhookSubscribed(entry, eventType) {
+			continue
+		}
+
+		err = queueOutgoingWebhookDelivery(entry, eventType, data)
+		RelaxLog(err)
+	}
+}
+
+// OutgoingWebhookEmitFeedPost emits a feed post for the guild of the channel, meant to be run as a goroutine
+func OutgoingWebhookEmitFeedPost(feed, channelID, name, postURL string) {
+	defer Recover()
+
+	channel, err := GetChannelWithoutApi(channelID)
+	if err != nil {
+		return
+	}
+
+	OutgoingWebhookEmit(channel.GuildID, models.OutgoingWebhookEventFeedPost, models.OutgoingWebhookFeedPostData{
+		Feed:      feed,
+		ChannelID: channelID,
+		Name:      name,
+		URL:       postURL,
+	})
+}
+
+func queueOutgoingWebhookDelivery(entry models.OutgoingWebhookEntry, eventType string, data interface{}) (err error) {
+	delivery := models.OutgoingWebhookDeliveryEntry{
+		ID:        bson.NewObjectId(),
+		WebhookID: MdbIdToHuman(entry.ID),
+		GuildID:   entry.GuildID,
+		EventType: eventType,
+		Status:    models.OutgoingWebhookDeliveryStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	payload, err := json.Marshal(models.OutgoingWebhookPayload{
+		DeliveryID: MdbIdToHuman(delivery.ID),
+		Event:      eventType,
+		GuildID:    entry.GuildID,
+		CreatedAt:  delivery.CreatedAt,
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+	delivery.Payload = string(payload)
+
+	_, err = MDbInsertWithoutLogging(models.OutgoingWebhookDeliveriesTable, delivery)
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.GetMachineryServer().SendTask(OutgoingWebhookDeliverSignature(MdbIdToHuman(delivery.ID)))
+	return err
+}
+
+func OutgoingWebhookDeliverSignature(deliveryID string) (signature *tasks.Signature) {
+	signature = &tasks.Signature{
+		Name: "deliver_outgoing_webhook",
+		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: deliveryID,
+			},
+		},
+	}
+	signature.RetryCount = OutgoingWebhookMaxRetries
+	signature.RetryTimeout = OutgoingWebhookRetryTimeout
+	signature.OnError = []*tasks.Signature{{Name: "log_error"}}
+	return signature
+}
+
+// OutgoingWebhookDeliverMachinery sends a delivery to its webhook, returns an error to be retried by machinery
+func OutgoingWebhookDeliverMachinery(deliveryID string) (err error) {
+	var delivery models.OutgoingWebhookDeliveryEntry
+	err = MdbOneWithoutLogging(
+		MdbCollection(models.OutgoingWebhookDeliveriesTable).Find(bson.M{"_id": HumanToMdbId(deliveryID)}),
+		&delivery,
+	)
+	if err != nil {
+		if IsMdbNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if delivery.Status != models.OutgoingWebhookDeliveryStatusPending {
+		return nil
+	}
+
+	acquired, err := acquireOutgoingWebhookSlot(delivery.GuildID)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		signature := OutgoingWebhookDeliverSignature(deliveryID)
+		postponeTo := time.Now().Add(outgoingWebhookPostponeDelay)
+		signature.ETA = &postponeTo
+		_, err = cache.GetMachineryServer().SendTask(signature)
+		return err
+	}
+	defer releaseOutgoingWebhookSlot(delivery.GuildID)
+
+	var entry models.OutgoingWebhookEntry
+	err = MdbOneWithoutLogging(
+		MdbCollection(models.OutgoingWebhooksTable).Find(bson.M{"_id": HumanToMdbId(delivery.WebhookID)}),
+		&entry,
+	)
+	if err != nil {
+		if IsMdbNotFound(err) {
+			// the webhook has been removed in the meantime
+			delivery.Status = models.OutgoingWebhookDeliveryStatusFailed
+			delivery.LastError = "webhook removed"
+			return MDbUpdateWithoutLogging(models.OutgoingWebhookDeliveriesTable, delivery.ID, delivery)
+		}
+		return err
+	}
+
+	statusCode, deliveryErr := sendOutgoingWebhookDelivery(entry, delivery)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = time.Now()
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	if deliveryErr != nil {
+		delivery.LastError = deliveryErr.Error()
+	}
+
+	var retry bool
+	switch {
+	case deliveryErr == nil:
+		delivery.Status = models.OutgoingWebhookDeliveryStatusDelivered
+	// client errors, except timeouts and rate limits, will not be fixed by retrying
+	case statusCode >= 400 && statusCode < 500 && statusCode != http.StatusRequestTimeout && statusCode != http.StatusTooManyRequests,
+		delivery.Attempts > OutgoingWebhookMaxRetries:
+		delivery.Status = models.OutgoingWebhookDeliveryStatusFailed
+	default:
+		retry = true
+	}
+
+	err = MDbUpdateWithoutLogging(models.OutgoingWebhookDeliveriesTable, delivery.ID, delivery)
+	if err != nil {
+		return err
+	}
+
+	if retry {
+		return deliveryErr
+	}
+	return nil
+}
+
+// acquireOutgoingWebhookSlot returns false if the guild or all guilds together have too many deliveries in flight
+func acquireOutgoingWebhookSlot(guildID string) (acquired bool, err error) {
+	guildKey := fmt.Sprintf(models.OutgoingWebhookInFlightRedisKey, guildID)
+	allKey := fmt.Sprintf(models.OutgoingWebhookInFlightRedisKey, "all")
+
+	acquired, err = incrOutgoingWebhookInFlight(guildKey, OutgoingWebhookMaxInFlightPerGuild)
+	if err != nil || !acquired {
+		return false, err
+	}
+	acquired, err = incrOutgoingWebhookInFlight(allKey, OutgoingWebhookMaxInFlight)
+	if err != nil || !acquired {
+		cache.GetRedisClient().Decr(guildKey)
+		return false, err
+	}
+	return true, nil
+}
+
+func incrOutgoingWebhookInFlight(key string, limit int64) (acquired bool, err error) {
+	count, err := cache.GetRedisClient().Incr(key).Result()
+	if err != nil {
+		return false, err
+	}
+	// in case a worker dies while sending
+	cache.GetRedisClient().Expire(key, outgoingWebhookTimeout*3)
+
+	if count > limit {
+		cache.GetRedisClient().Decr(key)
+		return false, nil
+	}
+	return true, nil
+}
+
+func releaseOutgoingWebhookSlot(guildID string) {
+	cache.GetRedisClient().Decr(fmt.Sprintf(models.OutgoingWebhookInFlightRedisKey, guildID))
+	cache.GetRedisClient().Decr(fmt.Sprintf(models.OutgoingWebhookInFlightRedisKey, "all"))
+}
+
+func sendOutgoingWebhookDelivery(entry models.OutgoingWebhookEntry, delivery models.OutgoingWebhookDeliveryEntry) (statusCode int, err error) {
+	err = ValidateOutgoingWebhookURL(entry.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	client := newOutgoingWebhookClient()
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	request, err := http.NewRequest("POST", entry.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", DEFAULT_UA)
+	request.Header.Set("X-Robyul-Event", delivery.EventType)
+	request.Header.Set("X-Robyul-Delivery", MdbIdToHuman(delivery.ID))
+	request.Header.Set("X-Robyul-Timestamp", strconv.FormatInt(timestamp, 10))
+	request.Header.Set("X-Robyul-Signature", "sha256="+SignOutgoingWebhookPayload(entry.Secret, timestamp, body))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1024*64))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
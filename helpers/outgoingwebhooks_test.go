@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestSignOutgoingWebhookPayload(t *testing.T) {
+	signature := SignOutgoingWebhookPayload("secret", 1500000000, []byte(`{"event":"member.join"}`))
+	if signature != "53401f6b1d9dd5e7dd3e16963469f9bcd35b9217c67d69a7cd2e22c4262c4502" {
+		t.Fatal("helpers.SignOutgoingWebhookPayload() returned a wrong signature:", signature)
+	}
+
+	if SignOutgoingWebhookPayload("other", 1500000000, []byte(`{"event":"member.join"}`)) == signature {
+		t.Fatal("helpers.SignOutgoingWebhookPayload() ignored the secret")
+	}
+}
+
+func TestValidateOutgoingWebhookURL(t *testing.T) {
+	defer func(lookupIP func(string) ([]net.IP, error)) {
+		outgoingWebhookLookupIP = lookupIP
+	}(outgoingWebhookLookupIP)
+	outgoingWebhookLookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "internal.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.5")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	for _, validURL := range []string{
+		"https://example.com/hooks/robyul",
+		"https://8.8.8.8:8443/",
+	} {
+		if err := ValidateOutgoingWebhookURL(validURL); err != nil {
+			t.Fatal("helpers.ValidateOutgoingWebhookURL() rejected a valid url:", validURL, err)
+		}
+	}
+
+	for _, invalidURL := range []string{
+		"http://example.com/",
+		"https://localhost/",
+		"https://127.0.0.1/",
+		"https://192.168.1.1/",
+		"https://[::1]/",
+		"https://[::ffff:127.0.0.1]/",
+		"https://198.18.0.1/",
+		"https://224.0.0.1/",
+		"https://[ff02::1]/",
+		"https://[64:ff9b::a00:5]/",
+		"https://internal.example.com/",
+		"https://unknown.example.com/",
+		"ftp://example.com/",
+		"https:///",
+	} {
+		if err := ValidateOutgoingWebhookURL(invalidURL); err == nil {
+			t.Fatal("helpers.ValidateOutgoingWebhookURL() accepted an invalid url:", invalidURL)
+		}
+	}
+}
+
+func TestOutgoingWebhookClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// localhost resolves to a private address when connecting
+	_, err := newOutgoingWebhookClient().Get(strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+	if err == nil || !strings.Contains(err.Error(), errOutgoingWebhookPrivateAddress.Error()) {
+		t.Fatal("the outgoing webhook client connected to a private address:", err)
+	}
+}
+
+func TestParseOutgoingWebhookEvents(t *testing.T) {
+	eventTypes, ok := ParseOutgoingWebhookEvents("Member.Join, levels.levelup,member.join")
+	if !ok || len(eventTypes) != 2 || eventTypes[0] != models.OutgoingWebhookEventMemberJoin ||
+		eventTypes[1] != models.OutgoingWebhookEventLevelUp {
+		t.Fatal("helpers.ParseOutgoingWebhookEvents() returned wrong event types:", eventTypes, ok)
+	}
+
+	if _, ok = ParseOutgoingWebhookEvents("member.join,member.leave"); ok {
+		t.Fatal("helpers.ParseOutgoingWebhookEvents() accepted an unknown event type")
+	}
+
+	if !OutgoingWebhookSubscribed(models.OutgoingWebhookEntry{EventTypes: eventTypes}, models.OutgoingWebhookEventLevelUp) ||
+		OutgoingWebhookSubscribed(models.OutgoingWebhookEntry{EventTypes: eventTypes}, models.OutgoingWebhookEventBan) {
+		t.Fatal("helpers.OutgoingWebhookSubscribed() returned a wrong result")
+	}
+}
@@ -0,0 +1,281 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo/bson"
+	"github.com/olivere/elastic"
+)
+
+const (
+	RetentionMaxDays = 3650
+	// the maximum number of documents per index in an export
+	PrivacyExportMaxDocuments = 100000
+	// uploaded exports are deleted after this time
+	PrivacyExportExpiry        = 24 * time.Hour
+	PrivacyExportStorageSource = "privacy"
+)
+
+// ParseRetentionDays parses a retention like "30", "30d", or "off", 0 keeps the documents forever
+func ParseRetentionDays(text string) (days int, ok bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	switch text {
+	case "off", "forever", "disable", "0":
+		return 0, true
+	}
+
+	days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+	if err != nil || days <= 0 || days > RetentionMaxDays {
+		return 0, false
+	}
+	return days, true
+}
+
+// GetRetentionDays returns the retention of one of models.RetentionIndexes
+func GetRetentionDays(settings models.Config, retentionIndex string) (days int) {
+	switch retentionIndex {
+	case models.RetentionIndexMessages:
+		return settings.ChatlogRetentionMessagesDays
+	case models.RetentionIndexJoins:
+		return settings.ChatlogRetentionJoinsDays
+	case models.RetentionIndexLeaves:
+		return settings.ChatlogRetentionLeavesDays
+	case models.RetentionIndexVoiceSessions:
+		return settings.ChatlogRetentionVoiceSessionsDays
+	}
+	return 0
+}
+
+// SetRetentionDays sets the retention of one of models.RetentionIndexes, returns false for unknown indexes
+func SetRetentionDays(settings *models.Config, retentionIndex string, days int) (ok bool) {
+	switch retentionIndex {
+	case models.RetentionIndexMessages:
+		settings.ChatlogRetentionMessagesDays = days
+	case models.RetentionIndexJoins:
+		settings.ChatlogRetentionJoinsDays = days
+	case models.RetentionIndexLeaves:
+		settings.ChatlogRetentionLeavesDays = days
+	case models.RetentionIndexVoiceSessions:
+		settings.ChatlogRetentionVoiceSessionsDays = days
+	default:
+		return false
+	}
+	return true
+}
+
+// GetRetentionElasticIndex returns the elastic index of one of models.RetentionIndexes
+func GetRetentionElasticIndex(retentionIndex string) string {
+	switch retentionIndex {
+	case models.RetentionIndexMessages:
+		return models.ElasticIndexMessages
+	case models.RetentionIndexJoins:
+		return models.ElasticIndexJoins
+	case models.RetentionIndexLeaves:
+		return models.ElasticIndexLeaves
+	case models.RetentionIndexVoiceSessions:
+		return models.ElasticIndexVoiceSessions
+	}
+	return ""
+}
+
+// ElasticPurgeBefore deletes all documents created before the time, an empty guildID purges the documents of all guilds
+func ElasticPurgeBefore(index, guildID string, before time.Time) (deleted int64, err error) {
+	if !cache.HasElastic() {
+		return 0, errors.New("no elastic client")
+	}
+
+	query := elastic.NewBoolQuery().
+		Must(elastic.NewRangeQuery("CreatedAt").Lt(before))
+	if guildID != "" {
+		query.Must(elastic.NewMatchQuery("GuildID", guildID))
+	}
+
+	result, err := cache.GetElastic().DeleteByQuery(index).
+		Type("doc").
+		Query(query).
+		ProceedOnVersionConflict().
+		Do(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Deleted, nil
+}
+
+// ElasticGetUserDocuments returns up to PrivacyExportMaxDocuments documents of an index having the user as UserID or TargetID
+func ElasticGetUserDocuments(index, userID string) (documents []json.RawMessage, err error) {
+	if !cache.HasElastic() {
+		return nil, errors.New("no elastic client")
+	}
+
+	scroll := cache.GetElastic().Scroll(index).
+		Type("doc").
+		Query(elasticUserQuery(index, userID)).
+		Size(1000)
+	defer scroll.Clear(context.Background())
+
+	documents = make([]json.RawMessage, 0)
+	for len(documents) < PrivacyExportMaxDocuments {
+		result, err := scroll.Do(context.Background())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hit := range result.Hits.Hits {
+			if hit == nil || hit.Source == nil {
+				continue
+			}
+			documents = append(documents, *hit.Source)
+		}
+	}
+
+	return documents, nil
+}
+
+// ElasticEraseUser deletes the messages, presence updates, and voice sessions of the user
+// joins, leaves, and eventlogs are kept for the statistics and moderation history but the user is removed from them
+func ElasticEraseUser(userID string) (err error) {
+	if !cache.HasElastic() {
+		return errors.New("no elastic client")
+	}
+
+	for _, index := range []string{
+		models.ElasticIndexMessages,
+		models.ElasticIndexPresenceUpdates,
+		models.ElasticIndexVoiceSessions,
+	} {
+		_, err = cache.GetElastic().DeleteByQuery(index).
+			Type("doc").
+			Query(elasticUserQuery(index, userID)).
+			ProceedOnVersionConflict().
+			Do(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
+	script := elastic.NewScript(
+		"if (ctx._source.UserID == params.userID) { ctx._source.UserID = '' } " +
+			"if (ctx._source.TargetID == params.userID) { ctx._source.TargetID = '' }",
+	).Params(map[string]interface{}{"userID": userID})
+	for _, index := range []string{
+		models.ElasticIndexJoins,
+		models.ElasticIndexLeaves,
+		models.ElasticIndexEventlogs,
+	} {
+		_, err = cache.GetElastic().UpdateByQuery(index).
+			Type("doc").
+			Query(elasticUserQuery(index, userID)).
+			Script(script).
+			ProceedOnVersionConflict().
+			Do(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eventlogs can mention the user as target and map the IDs as keywords, all other indexes only have an UserID mapped as text
+func elasticUserQuery(index, userID string) elastic.Query {
+	if index == models.ElasticIndexEventlogs {
+		return elastic.NewBoolQuery().
+			Should(elastic.NewTermQuery("UserID.keyword", userID)).
+			Should(elastic.NewTermQuery("TargetID.keyword", userID)).
+			MinimumNumberShouldMatch(1)
+	}
+	return elastic.NewMatchQuery("UserID", userID)
+}
+
+// DeleteExpiredPrivacyExports deletes uploaded exports older than PrivacyExportExpiry
+func DeleteExpiredPrivacyExports() (err error) {
+	var entries []models.StorageEntry
+	err = MDbIterWithoutLogging(MdbCollection(models.StorageTable).Find(bson.M{
+		"source":     PrivacyExportStorageSource,
+		"uploaddate": bson.M{"$lt": time.Now().Add(-PrivacyExportExpiry)},
+	})).All(&entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = DeleteFile(entry.ObjectName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MdbEraseUser deletes everything stored about the user in MongoDB and removes the files uploaded for the user
+func MdbEraseUser(userID string) (err error) {
+	var userdata models.ProfileUserdataEntry
+	err = MdbOneWithoutLogging(MdbCollection(models.ProfileUserdataTable).Find(bson.M{"userid": userID}), &userdata)
+	if err != nil && !IsMdbNotFound(err) {
+		return err
+	}
+	if err == nil && userdata.BackgroundObjectName != "" {
+		err = DeleteFile(userdata.BackgroundObjectName)
+		RelaxLog(err)
+	}
+
+	// previous exports and attachments of the user re-hosted by the eventlog
+	var files []models.StorageEntry
+	err = MDbIterWithoutLogging(MdbCollection(models.StorageTable).Find(bson.M{
+		"userid": userID,
+		"$or": []bson.M{
+			{"source": PrivacyExportStorageSource},
+			{"metadata." + discordAttachmentMetadataKey: bson.M{"$exists": true}},
+		},
+	})).All(&files)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		err = DeleteFile(file.ObjectName)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, collection := range []models.MongoDbCollection{
+		models.NamesTable,
+		models.LevelsServerusersTable,
+		models.LevelsRoleOverwritesTable,
+		models.ProfileUserdataTable,
+		models.RemindersTable,
+		models.NotificationsTable,
+		models.NotificationsDigestTable,
+		models.UserConfigTable,
+		models.LastFmTable,
+		models.ProfileBadgeAwardsTable,
+	} {
+		_, err = MdbCollection(collection).RemoveAll(bson.M{"userid": userID})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = MdbCollection(models.ProfileRepTable).RemoveAll(bson.M{"$or": []bson.M{
+		{"giveruserid": userID},
+		{"receiveruserid": userID},
+	}})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
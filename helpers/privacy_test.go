@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestParseRetentionDays(t *testing.T) {
+	for text, expected := range map[string]int{
+		"30":      30,
+		"30d":     30,
+		" 7D ":    7,
+		"off":     0,
+		"forever": 0,
+		"0":       0,
+		"3650":    RetentionMaxDays,
+	} {
+		days, ok := ParseRetentionDays(text)
+		if !ok || days != expected {
+			t.Fatalf("helpers.ParseRetentionDays(%q) returned %d, %t, expected %d", text, days, ok, expected)
+		}
+	}
+
+	for _, text := range []string{"", "-1", "3651", "one month", "d"} {
+		if _, ok := ParseRetentionDays(text); ok {
+			t.Fatalf("helpers.ParseRetentionDays(%q) accepted an invalid retention", text)
+		}
+	}
+}
+
+func TestSetRetentionDays(t *testing.T) {
+	var settings models.Config
+
+	for i, retentionIndex := range models.RetentionIndexes {
+		if !SetRetentionDays(&settings, retentionIndex, i+1) {
+			t.Fatal("helpers.SetRetentionDays() rejected the index " + retentionIndex)
+		}
+		if GetRetentionElasticIndex(retentionIndex) == "" {
+			t.Fatal("helpers.GetRetentionElasticIndex() returned no elastic index for " + retentionIndex)
+		}
+	}
+	for i, retentionIndex := range models.RetentionIndexes {
+		if GetRetentionDays(settings, retentionIndex) != i+1 {
+			t.Fatal("helpers.GetRetentionDays() returned the wrong retention for " + retentionIndex)
+		}
+	}
+
+	if SetRetentionDays(&settings, "presence", 1) {
+		t.Fatal("helpers.SetRetentionDays() accepted an unknown index")
+	}
+}
+
+func TestElasticUserQuery(t *testing.T) {
+	for index, expected := range map[string]string{
+		models.ElasticIndexMessages:        `{"match":{"UserID":{"query":"116620585638821891"}}}`,
+		models.ElasticIndexJoins:           `{"match":{"UserID":{"query":"116620585638821891"}}}`,
+		models.ElasticIndexLeaves:          `{"match":{"UserID":{"query":"116620585638821891"}}}`,
+		models.ElasticIndexVoiceSessions:   `{"match":{"UserID":{"query":"116620585638821891"}}}`,
+		models.ElasticIndexPresenceUpdates: `{"match":{"UserID":{"query":"116620585638821891"}}}`,
+		models.ElasticIndexEventlogs:       `{"bool":{"minimum_should_match":"1","should":[{"term":{"UserID.keyword":"116620585638821891"}},{"term":{"TargetID.keyword":"116620585638821891"}}]}}`,
+	} {
+		source, err := elasticUserQuery(index, "116620585638821891").Source()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Fatalf("helpers.elasticUserQuery() returned %s for %s", string(data), index)
+		}
+	}
+}
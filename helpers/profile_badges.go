@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	ProfileBadgeMaxCriteria = 5
+)
+
+// ProfileBadgeMetrics are all metrics badge criteria can be based on
+var ProfileBadgeMetrics = []string{
+	models.ProfileBadgeMetricMessages,
+	models.ProfileBadgeMetricRep,
+	models.ProfileBadgeMetricTenureDays,
+	models.ProfileBadgeMetricStarboard,
+	models.ProfileBadgeMetricBiasgameGames,
+	models.ProfileBadgeMetricLastFmScrobbles,
+	models.ProfileBadgeMetricDonator,
+}
+
+type ProfileBadgeCriterionProgress struct {
+	Metric    string
+	Value     int64
+	Threshold int64
+	Met       bool
+}
+
+// ProfileBadgeMetricIsValid returns true if metric is one of ProfileBadgeMetrics
+func ProfileBadgeMetricIsValid(metric string) bool {
+	for _, validMetric := range ProfileBadgeMetrics {
+		if validMetric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// SetProfileBadgeCriterion replaces the criterion with the same metric, or adds it if the badge has none for the metric yet
+// a threshold <= 0 removes the criterion
+func SetProfileBadgeCriterion(criteria []models.ProfileBadgeCriterion, metric string, threshold int64) (newCriteria []models.ProfileBadgeCriterion) {
+	newCriteria = make([]models.ProfileBadgeCriterion, 0)
+	replaced := false
+	for _, criterion := range criteria {
+		if criterion.Metric == metric {
+			replaced = true
+			if threshold <= 0 {
+				continue
+			}
+			criterion.Threshold = threshold
+		}
+		newCriteria = append(newCriteria, criterion)
+	}
+	if !replaced && threshold > 0 {
+		newCriteria = append(newCriteria, models.ProfileBadgeCriterion{
+			Metric:    metric,
+			Threshold: threshold,
+		})
+	}
+	return newCriteria
+}
+
+// GetProfileBadgeProgress compares the values of a user with the criteria of a badge
+// met is only true if the badge has criteria and all of them are met
+func GetProfileBadgeProgress(criteria []models.ProfileBadgeCriterion, values map[string]int64) (progress []ProfileBadgeCriterionProgress, met bool) {
+	progress = make([]ProfileBadgeCriterionProgress, 0)
+	met = len(criteria) > 0
+	for _, criterion := range criteria {
+		criterionProgress := ProfileBadgeCriterionProgress{
+			Metric:    criterion.Metric,
+			Value:     values[criterion.Metric],
+			Threshold: criterion.Threshold,
+		}
+		criterionProgress.Met = criterionProgress.Value >= criterionProgress.Threshold
+		if !criterionProgress.Met {
+			met = false
+		}
+		progress = append(progress, criterionProgress)
+	}
+	return progress, met
+}
+
+// ProfileBadgeProgressPercent returns the average progress over all criteria, each criterion counts at most 100%
+func ProfileBadgeProgressPercent(progress []ProfileBadgeCriterionProgress) int {
+	if len(progress) <= 0 {
+		return 0
+	}
+	var total float64
+	for _, criterionProgress := range progress {
+		if criterionProgress.Met || criterionProgress.Threshold <= 0 {
+			total += 1
+			continue
+		}
+		if criterionProgress.Value > 0 {
+			total += float64(criterionProgress.Value) / float64(criterionProgress.Threshold)
+		}
+	}
+	return int(total / float64(len(progress)) * 100)
+}
+
+// FormatProfileBadgeCriteria returns a human readable list of the criteria, eg: messages >= 1,000, donator
+func FormatProfileBadgeCriteria(criteria []models.ProfileBadgeCriterion) string {
+	texts := make([]string, 0)
+	for _, criterion := range criteria {
+		if criterion.Metric == models.ProfileBadgeMetricDonator {
+			texts = append(texts, criterion.Metric)
+			continue
+		}
+		texts = append(texts, fmt.Sprintf("%s >= %s", criterion.Metric, humanize.Comma(criterion.Threshold)))
+	}
+	return strings.Join(texts, ", ")
+}
+
+// FormatProfileBadgeProgress returns a human readable progress for a single criterion, eg: messages 340/1,000
+func FormatProfileBadgeProgress(progress ProfileBadgeCriterionProgress) string {
+	if progress.Metric == models.ProfileBadgeMetricDonator {
+		if progress.Met {
+			return progress.Metric + " ✅"
+		}
+		return progress.Metric + " ❌"
+	}
+	text := fmt.Sprintf("%s %s/%s", progress.Metric, humanize.Comma(progress.Value), humanize.Comma(progress.Threshold))
+	if progress.Met {
+		text += " ✅"
+	}
+	return text
+}
@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestSetProfileBadgeCriterion(t *testing.T) {
+	criteria := SetProfileBadgeCriterion(nil, models.ProfileBadgeMetricMessages, 100)
+	criteria = SetProfileBadgeCriterion(criteria, models.ProfileBadgeMetricRep, 5)
+	criteria = SetProfileBadgeCriterion(criteria, models.ProfileBadgeMetricMessages, 1000)
+	if len(criteria) != 2 || criteria[0].Metric != models.ProfileBadgeMetricMessages || criteria[0].Threshold != 1000 {
+		t.Fatalf("helpers.SetProfileBadgeCriterion() returned %v", criteria)
+	}
+
+	criteria = SetProfileBadgeCriterion(criteria, models.ProfileBadgeMetricMessages, 0)
+	if len(criteria) != 1 || criteria[0].Metric != models.ProfileBadgeMetricRep {
+		t.Fatalf("helpers.SetProfileBadgeCriterion() didn't remove the criterion: %v", criteria)
+	}
+}
+
+func TestGetProfileBadgeProgress(t *testing.T) {
+	criteria := []models.ProfileBadgeCriterion{
+		{Metric: models.ProfileBadgeMetricMessages, Threshold: 1000},
+		{Metric: models.ProfileBadgeMetricDonator, Threshold: 1},
+	}
+
+	progress, met := GetProfileBadgeProgress(criteria, map[string]int64{
+		models.ProfileBadgeMetricMessages: 250,
+		models.ProfileBadgeMetricDonator:  1,
+	})
+	if met || len(progress) != 2 || progress[0].Met || !progress[1].Met {
+		t.Fatalf("helpers.GetProfileBadgeProgress() returned %v, %t", progress, met)
+	}
+	if percent := ProfileBadgeProgressPercent(progress); percent != 62 {
+		t.Fatalf("helpers.ProfileBadgeProgressPercent() returned %d instead of 62", percent)
+	}
+	if text := FormatProfileBadgeProgress(progress[0]); text != "messages 250/1,000" {
+		t.Fatalf("helpers.FormatProfileBadgeProgress() returned %q", text)
+	}
+
+	_, met = GetProfileBadgeProgress(criteria, map[string]int64{
+		models.ProfileBadgeMetricMessages: 5000,
+		models.ProfileBadgeMetricDonator:  1,
+	})
+	if !met {
+		t.Fatal("helpers.GetProfileBadgeProgress() didn't meet the criteria")
+	}
+
+	if _, met = GetProfileBadgeProgress(nil, nil); met {
+		t.Fatal("helpers.GetProfileBadgeProgress() met a badge without criteria")
+	}
+}
+
+func TestFormatProfileBadgeCriteria(t *testing.T) {
+	text := FormatProfileBadgeCriteria([]models.ProfileBadgeCriterion{
+		{Metric: models.ProfileBadgeMetricStarboard, Threshold: 1500},
+		{Metric: models.ProfileBadgeMetricDonator, Threshold: 1},
+	})
+	if text != "starboard >= 1,500, donator" {
+		t.Fatalf("helpers.FormatProfileBadgeCriteria() returned %q", text)
+	}
+	if !ProfileBadgeMetricIsValid(models.ProfileBadgeMetricTenureDays) || ProfileBadgeMetricIsValid("exp") {
+		t.Fatal("helpers.ProfileBadgeMetricIsValid() returned the wrong result")
+	}
+}
@@ -0,0 +1,656 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybons/gogif"
+	"github.com/golang/freetype/truetype"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/nfnt/resize"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	ProfileCardWidth     = 400
+	ProfileCardHeight    = 300
+	ProfileCardMaxFrames = 50 // longer animations get cut off to keep the gifs small enough for discord
+	// the longest emoji sequences, families with skin tones, have 11 code points
+	profileCardEmojiMaxRunes = 11
+)
+
+const (
+	profileCardAlignLeft = iota
+	profileCardAlignCenter
+	profileCardAlignRight
+)
+
+// ProfileCardFonts are the fonts profile cards are drawn with
+// glyphs missing in Regular or Bold are taken from Fallback or FallbackBold, for example hangul
+type ProfileCardFonts struct {
+	Regular      *truetype.Font
+	Bold         *truetype.Font
+	Fallback     *truetype.Font
+	FallbackBold *truetype.Font
+}
+
+// ProfileCardBadge is a badge shown above the profile box
+type ProfileCardBadge struct {
+	Image       image.Image
+	BorderColor string // as hex string
+}
+
+// ProfileCard is everything shown on a profile card
+type ProfileCard struct {
+	Background      []image.Image // one image per frame, gets scaled to the size of the card
+	BackgroundDelay []int         // the delay of each frame in 100ths of a second
+	Avatar          []image.Image // one image per frame
+	AvatarDelay     []int         // the delay of each frame in 100ths of a second
+	Badges          []ProfileCardBadge
+
+	Username string
+	Title    string
+	Bio      string
+	Playing  []string // one line each, shown at the top
+	Stats    string
+
+	ServerLevel        string
+	ServerRank         string
+	ServerLevelPercent int
+	GlobalLevel        string
+	GlobalRank         string
+	Rep                int
+
+	BackgroundColor string // as hex strings
+	AccentColor     string
+	TextColor       string
+
+	BackgroundOpacity float64 // from 0 to 1
+	DetailOpacity     float64
+	EXPOpacity        float64
+	BadgeOpacity      float64
+
+	// Emoji returns the emoji at the beginning of text and its length in bytes, or nil if there is none, optional
+	Emoji func(text string) (emoji image.Image, length int)
+}
+
+// MatchProfileCardEmoji returns the longest emoji file name in emojiFiles at the beginning of text and its length in bytes
+// file names are the hex code points joined by "-" like the twemoji files, the scan stops at the first miss unless a ZWJ or variation selector follows
+func MatchProfileCardEmoji(text string, emojiFiles map[string]bool) (name string, length int) {
+	runes := make([]rune, 0, profileCardEmojiMaxRunes)
+	for _, r := range text {
+		if len(runes) >= profileCardEmojiMaxRunes {
+			break
+		}
+		runes = append(runes, r)
+	}
+	if len(runes) <= 0 || runes[0] < 0x80 {
+		return "", 0
+	}
+
+	var filename string
+	var position int
+	for i, r := range runes {
+		if filename != "" {
+			filename += "-"
+		}
+		filename += strconv.FormatInt(int64(r), 16)
+		position += utf8.RuneLen(r)
+
+		if emojiFiles[filename] {
+			name, length = filename, position
+			continue
+		}
+		if isProfileCardEmojiJoiner(r) || (i+1 < len(runes) && isProfileCardEmojiJoiner(runes[i+1])) {
+			continue
+		}
+		break
+	}
+	return name, length
+}
+
+func isProfileCardEmojiJoiner(r rune) bool {
+	return r == 0x200d || r == 0xfe0e || r == 0xfe0f
+}
+
+// ParseProfileCardFonts parses the truetype fonts for profile cards
+func ParseProfileCardFonts(regular, bold, fallback, fallbackBold []byte) (fonts *ProfileCardFonts, err error) {
+	fonts = new(ProfileCardFonts)
+	for _, item := range []struct {
+		data   []byte
+		target **truetype.Font
+	}{
+		{regular, &fonts.Regular},
+		{bold, &fonts.Bold},
+		{fallback, &fonts.Fallback},
+		{fallbackBold, &fonts.FallbackBold},
+	} {
+		*item.target, err = truetype.Parse(item.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fonts, nil
+}
+
+// DecodeProfileCardFrames decodes a JPEG, PNG or GIF into full frames
+// frames of animated GIFs are composed according to their disposal method
+func DecodeProfileCardFrames(data []byte) (frames []image.Image, delays []int, err error) {
+	if mimeType, _ := SniffMime(data); mimeType != "image/gif" {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []image.Image{decoded}, []int{0}, nil
+	}
+
+	decodedGif, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(decodedGif.Image) <= 0 {
+		return nil, nil, errors.New("gif has no frames")
+	}
+
+	bounds := image.Rect(0, 0, decodedGif.Config.Width, decodedGif.Config.Height)
+	if bounds.Empty() {
+		bounds = decodedGif.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range decodedGif.Image {
+		if i >= ProfileCardMaxFrames {
+			break
+		}
+
+		var previous *image.RGBA
+		if i < len(decodedGif.Disposal) && decodedGif.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		composed := image.NewRGBA(bounds)
+		draw.Draw(composed, bounds, canvas, bounds.Min, draw.Src)
+		frames = append(frames, composed)
+		delays = append(delays, decodedGif.Delay[i])
+
+		if i < len(decodedGif.Disposal) {
+			switch decodedGif.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.ZP, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+	}
+	return frames, delays, nil
+}
+
+// DrawProfileCard draws a profile card
+// returns a gif if animated is true and the background or the avatar has more than one frame, otherwise a png
+// an animated background takes precedence over an animated avatar, the avatar shows its first frame then
+func DrawProfileCard(card ProfileCard, fonts *ProfileCardFonts, animated bool) (data []byte, extension string, err error) {
+	if len(card.Background) <= 0 || len(card.Avatar) <= 0 {
+		return nil, "", errors.New("profile card needs a background and an avatar")
+	}
+
+	overlay := drawProfileCardOverlay(card, fonts)
+	cardRect := image.Rect(0, 0, ProfileCardWidth, ProfileCardHeight)
+
+	var frames []*image.RGBA
+	var delays []int
+	switch {
+	case animated && len(card.Background) > 1:
+		avatar := drawProfileCardAvatar(card.Avatar[0])
+		for i, background := range card.Background {
+			frames = append(frames, composeProfileCard(background, overlay, avatar))
+			delays = append(delays, profileCardDelay(card.BackgroundDelay, i))
+		}
+	case animated && len(card.Avatar) > 1:
+		base := composeProfileCard(card.Background[0], overlay, nil)
+		for i, avatarFrame := range card.Avatar {
+			frame := image.NewRGBA(cardRect)
+			draw.Draw(frame, cardRect, base, image.ZP, draw.Src)
+			drawProfileCardAvatarOnto(frame, drawProfileCardAvatar(avatarFrame))
+			frames = append(frames, frame)
+			delays = append(delays, profileCardDelay(card.AvatarDelay, i))
+		}
+	default:
+		frame := composeProfileCard(card.Background[0], overlay, drawProfileCardAvatar(card.Avatar[0]))
+
+		var buf bytes.Buffer
+		err = png.Encode(&buf, frame)
+		if err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "png", nil
+	}
+
+	outGif := &gif.GIF{LoopCount: 0}
+	for i, frame := range frames {
+		if i >= ProfileCardMaxFrames {
+			break
+		}
+		pm := image.NewPaletted(cardRect, nil)
+		q := gogif.MedianCutQuantizer{NumColor: 256}
+		q.Quantize(pm, cardRect, frame, image.ZP)
+		draw.FloydSteinberg.Draw(pm, cardRect, frame, image.ZP)
+
+		outGif.Image = append(outGif.Image, pm)
+		outGif.Delay = append(outGif.Delay, delays[i])
+	}
+
+	var buf bytes.Buffer
+	err = gif.EncodeAll(&buf, outGif)
+	if err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "gif", nil
+}
+
+func profileCardDelay(delays []int, i int) int {
+	if i < len(delays) && delays[i] > 0 {
+		return delays[i]
+	}
+	return 10
+}
+
+// composeProfileCard draws the background, the overlay and the avatar, avatar can be nil
+func composeProfileCard(background image.Image, overlay *image.RGBA, avatar *image.RGBA) *image.RGBA {
+	cardRect := image.Rect(0, 0, ProfileCardWidth, ProfileCardHeight)
+	frame := image.NewRGBA(cardRect)
+	draw.Draw(frame, cardRect, image.Black, image.ZP, draw.Src)
+
+	if background.Bounds().Dx() != ProfileCardWidth || background.Bounds().Dy() != ProfileCardHeight {
+		background = resize.Resize(ProfileCardWidth, ProfileCardHeight, background, resize.Bilinear)
+	}
+	draw.Draw(frame, cardRect, background, background.Bounds().Min, draw.Over)
+	draw.Draw(frame, cardRect, overlay, image.ZP, draw.Over)
+
+	if avatar != nil {
+		drawProfileCardAvatarOnto(frame, avatar)
+	}
+	return frame
+}
+
+var profileCardAvatarCircle = &circle{image.Pt(44, 194), 40}
+
+// drawProfileCardAvatar scales the avatar and cuts it into a circle
+func drawProfileCardAvatar(avatar image.Image) *image.RGBA {
+	resizedAvatar := resize.Resize(80, 80, avatar, resize.Bilinear)
+	cutAvatar := image.NewRGBA(profileCardAvatarCircle.Bounds())
+	draw.DrawMask(
+		cutAvatar, cutAvatar.Bounds(), resizedAvatar, resizedAvatar.Bounds().Min,
+		profileCardAvatarCircle, cutAvatar.Bounds().Min, draw.Over)
+	return cutAvatar
+}
+
+func drawProfileCardAvatarOnto(frame *image.RGBA, avatar *image.RGBA) {
+	draw.Draw(frame, avatar.Bounds(), avatar, avatar.Bounds().Min, draw.Over)
+}
+
+// drawProfileCardOverlay draws everything that doesn't change between frames
+// the layout follows the profile.html template used for the website
+func drawProfileCardOverlay(card ProfileCard, fonts *ProfileCardFonts) *image.RGBA {
+	overlay := image.NewRGBA(image.Rect(0, 0, ProfileCardWidth, ProfileCardHeight))
+
+	backgroundColor := profileCardColor(card.BackgroundColor, "000000", card.BackgroundOpacity)
+	detailColor := profileCardColor("000000", "000000", card.DetailOpacity)
+	accentColor := profileCardColor(card.AccentColor, "46d42e", card.EXPOpacity)
+	textColor := profileCardColor(card.TextColor, "ffffff", 1)
+
+	// exp bar
+	draw.Draw(overlay, image.Rect(0, 0, ProfileCardWidth, 5), image.NewUniform(detailColor), image.ZP, draw.Over)
+	progress := card.ServerLevelPercent
+	if progress < 0 {
+		progress = 0
+	}
+	if progress > 100 {
+		progress = 100
+	}
+	draw.Draw(overlay, image.Rect(0, 0, ProfileCardWidth*progress/100, 5), image.NewUniform(accentColor), image.ZP, draw.Over)
+
+	// the box, the background stays visible around the avatar
+	avatarHole := &circle{profileCardAvatarCircle.p, 44}
+	container := &profileCardShape{rect: image.Rect(5, 190, 395, 295), radius: 8, hole: avatarHole}
+	draw.DrawMask(overlay, container.rect, image.NewUniform(backgroundColor), image.ZP, container, container.rect.Min, draw.Over)
+	header := &profileCardShape{rect: image.Rect(5, 190, 395, 220), radius: 8, hole: avatarHole}
+	draw.DrawMask(overlay, header.rect, image.NewUniform(detailColor), image.ZP, header, header.rect.Min, draw.Over)
+	avatarBorder := &profileCardRing{outer: &circle{profileCardAvatarCircle.p, 43}, inner: profileCardAvatarCircle}
+	draw.DrawMask(overlay, avatarBorder.Bounds(), image.NewUniform(detailColor), image.ZP, avatarBorder, avatarBorder.Bounds().Min, draw.Over)
+
+	// badges, in two lines above the box
+	for i, badge := range card.Badges {
+		if i >= 18 {
+			break
+		}
+		x, y := 87+(i%9)*34, 155
+		if i >= 9 {
+			y = 120
+		}
+		drawProfileCardBadge(overlay, image.Pt(x, y), badge, card.BadgeOpacity)
+	}
+
+	text := &profileCardText{fonts: fonts, emoji: card.Emoji, faces: make(map[profileCardFaceKey]font.Face)}
+
+	for i, line := range card.Playing {
+		if i >= 2 {
+			break
+		}
+		text.Draw(overlay, image.Rect(2, 6, 398, 190), line, 2, 6+i*14, 14, false, profileCardAlignLeft, textColor)
+	}
+
+	text.Draw(overlay, image.Rect(92, 190, 292, 220), card.Username, 92, 197, 20, true, profileCardAlignLeft, textColor)
+	text.Draw(overlay, image.Rect(277, 190, 395, 220), "+"+strconv.Itoa(card.Rep)+" REP", 337, 197, 20, false, profileCardAlignCenter, textColor)
+	text.Draw(overlay, image.Rect(92, 219, 274, 245), card.Title, 92, 223, 16, true, profileCardAlignLeft, textColor)
+
+	levelsClip := image.Rect(270, 220, 395, 275)
+	text.Draw(overlay, levelsClip, "Level", 291, 220, 9, false, profileCardAlignCenter, textColor)
+	text.Draw(overlay, levelsClip, card.ServerLevel, 291, 229, 12, false, profileCardAlignCenter, textColor)
+	text.Draw(overlay, levelsClip, "Rank", 291, 248, 9, false, profileCardAlignCenter, textColor)
+	text.Draw(overlay, levelsClip, card.ServerRank, 291, 257, 12, false, profileCardAlignCenter, textColor)
+	text.Draw(overlay, levelsClip, "Global Level", 325, 220, 9, false, profileCardAlignLeft, textColor)
+	text.Draw(overlay, levelsClip, card.GlobalLevel, 366, 229, 12, false, profileCardAlignCenter, textColor)
+	text.Draw(overlay, levelsClip, "Global Rank", 325, 250, 9, false, profileCardAlignLeft, textColor)
+	text.Draw(overlay, levelsClip, card.GlobalRank, 368, 259, 12, false, profileCardAlignCenter, textColor)
+
+	bioClip := image.Rect(11, 243, 256, 293)
+	for i, line := range text.Wrap(card.Bio, 14, false, bioClip.Dx()) {
+		top := bioClip.Min.Y + i*14
+		if top >= bioClip.Max.Y {
+			break
+		}
+		text.Draw(overlay, bioClip, line, bioClip.Min.X, top, 14, false, profileCardAlignLeft, textColor)
+	}
+
+	text.Draw(overlay, image.Rect(256, 278, 391, 295), card.Stats, 391, 278, 12, false, profileCardAlignRight, textColor)
+
+	return overlay
+}
+
+// drawProfileCardBadge draws a badge with a round border at point
+func drawProfileCardBadge(dst *image.RGBA, point image.Point, badge ProfileCardBadge, opacity float64) {
+	badgeImage := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	border := &circle{image.Pt(16, 16), 16}
+	draw.DrawMask(badgeImage, badgeImage.Bounds(), image.NewUniform(profileCardColor(badge.BorderColor, "ffffff", 1)), image.ZP, border, image.ZP, draw.Over)
+	inner := &circle{image.Pt(16, 16), 14}
+	draw.DrawMask(badgeImage, badgeImage.Bounds(), image.NewUniform(color.RGBA{128, 128, 128, 255}), image.ZP, inner, image.ZP, draw.Over)
+	if badge.Image != nil {
+		resizedBadge := resize.Resize(28, 28, badge.Image, resize.Bilinear)
+		draw.DrawMask(badgeImage, inner.Bounds(), resizedBadge, resizedBadge.Bounds().Min, inner, inner.Bounds().Min, draw.Over)
+	}
+
+	target := badgeImage.Bounds().Add(point)
+	draw.DrawMask(dst, target, badgeImage, image.ZP, image.NewUniform(color.Alpha{profileCardAlpha(opacity)}), image.ZP, draw.Over)
+}
+
+// profileCardColor parses a hex colour, falls back to fallback if it's invalid
+func profileCardColor(hex, fallback string, opacity float64) color.NRGBA {
+	parsedColor, err := colorful.Hex("#" + strings.TrimPrefix(hex, "#"))
+	if err != nil {
+		parsedColor, _ = colorful.Hex("#" + fallback)
+	}
+	r, g, b := parsedColor.RGB255()
+	return color.NRGBA{R: r, G: g, B: b, A: profileCardAlpha(opacity)}
+}
+
+func profileCardAlpha(opacity float64) uint8 {
+	if opacity < 0 {
+		opacity = 0
+	}
+	if opacity > 1 {
+		opacity = 1
+	}
+	return uint8(opacity*255 + 0.5)
+}
+
+type circle struct {
+	p image.Point
+	r int
+}
+
+func (c *circle) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (c *circle) Bounds() image.Rectangle {
+	return image.Rect(c.p.X-c.r, c.p.Y-c.r, c.p.X+c.r, c.p.Y+c.r)
+}
+
+func (c *circle) At(x, y int) color.Color {
+	xx, yy, rr := float64(x-c.p.X)+0.5, float64(y-c.p.Y)+0.5, float64(c.r)
+	if xx*xx+yy*yy < rr*rr {
+		return color.Alpha{255}
+	}
+	return color.Alpha{0}
+}
+
+// profileCardRing is the area of outer that isn't covered by inner
+type profileCardRing struct {
+	outer *circle
+	inner *circle
+}
+
+func (r *profileCardRing) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (r *profileCardRing) Bounds() image.Rectangle {
+	return r.outer.Bounds()
+}
+
+func (r *profileCardRing) At(x, y int) color.Color {
+	if r.inner.At(x, y) == (color.Alpha{255}) {
+		return color.Alpha{0}
+	}
+	return r.outer.At(x, y)
+}
+
+// profileCardShape is a rectangle with rounded corners, hole is left out if set
+type profileCardShape struct {
+	rect   image.Rectangle
+	radius int
+	hole   *circle
+}
+
+func (s *profileCardShape) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (s *profileCardShape) Bounds() image.Rectangle {
+	return s.rect
+}
+
+func (s *profileCardShape) At(x, y int) color.Color {
+	if !(image.Point{x, y}).In(s.rect) {
+		return color.Alpha{0}
+	}
+	if s.hole != nil && s.hole.At(x, y) == (color.Alpha{255}) {
+		return color.Alpha{0}
+	}
+
+	cornerX, cornerY := x, y
+	if x < s.rect.Min.X+s.radius {
+		cornerX = s.rect.Min.X + s.radius
+	} else if x >= s.rect.Max.X-s.radius {
+		cornerX = s.rect.Max.X - s.radius
+	}
+	if y < s.rect.Min.Y+s.radius {
+		cornerY = s.rect.Min.Y + s.radius
+	} else if y >= s.rect.Max.Y-s.radius {
+		cornerY = s.rect.Max.Y - s.radius
+	}
+	if cornerX == x || cornerY == y {
+		return color.Alpha{255}
+	}
+	return (&circle{image.Pt(cornerX, cornerY), s.radius}).At(x, y)
+}
+
+type profileCardFaceKey struct {
+	size     float64
+	bold     bool
+	fallback bool
+}
+
+type profileCardGlyph struct {
+	r       rune
+	face    font.Face
+	emoji   image.Image
+	advance fixed.Int26_6
+}
+
+// profileCardText lays out and draws text, falling back to other fonts for missing glyphs
+type profileCardText struct {
+	fonts *ProfileCardFonts
+	emoji func(text string) (emoji image.Image, length int)
+	faces map[profileCardFaceKey]font.Face
+}
+
+func (t *profileCardText) face(size float64, bold, fallback bool) font.Face {
+	key := profileCardFaceKey{size: size, bold: bold, fallback: fallback}
+	if face, ok := t.faces[key]; ok {
+		return face
+	}
+
+	textFont := t.fonts.Regular
+	switch {
+	case bold && fallback:
+		textFont = t.fonts.FallbackBold
+	case bold:
+		textFont = t.fonts.Bold
+	case fallback:
+		textFont = t.fonts.Fallback
+	}
+	face := truetype.NewFace(textFont, &truetype.Options{Size: size, DPI: 72, Hinting: font.HintingFull})
+	t.faces[key] = face
+	return face
+}
+
+// layout picks a font or an emoji for every rune, runes no font has a glyph for are skipped
+func (t *profileCardText) layout(text string, size float64, bold bool) (glyphs []profileCardGlyph, width fixed.Int26_6) {
+	primaryFont, fallbackFont := t.fonts.Regular, t.fonts.Fallback
+	if bold {
+		primaryFont, fallbackFont = t.fonts.Bold, t.fonts.FallbackBold
+	}
+
+	for i := 0; i < len(text); {
+		if t.emoji != nil {
+			if emoji, length := t.emoji(text[i:]); emoji != nil && length > 0 {
+				glyph := profileCardGlyph{emoji: emoji, advance: fixed.I(int(size + 0.5))}
+				glyphs = append(glyphs, glyph)
+				width += glyph.advance
+				i += length
+				continue
+			}
+		}
+
+		r, length := utf8.DecodeRuneInString(text[i:])
+		i += length
+
+		var face font.Face
+		switch {
+		case primaryFont.Index(r) != 0:
+			face = t.face(size, bold, false)
+		case fallbackFont.Index(r) != 0:
+			face = t.face(size, bold, true)
+		default:
+			continue
+		}
+		advance, ok := face.GlyphAdvance(r)
+		if !ok {
+			continue
+		}
+		glyphs = append(glyphs, profileCardGlyph{r: r, face: face, advance: advance})
+		width += advance
+	}
+	return glyphs, width
+}
+
+// Width returns the width of text in pixels
+func (t *profileCardText) Width(text string, size float64, bold bool) int {
+	_, width := t.layout(text, size, bold)
+	return width.Ceil()
+}
+
+// Wrap breaks text into lines no wider than width, at line breaks, spaces, or inside words too long for a line
+func (t *profileCardText) Wrap(text string, size float64, bold bool, width int) (lines []string) {
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line string
+		for _, word := range strings.Split(paragraph, " ") {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if t.Width(candidate, size, bold) <= width {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = word
+			for t.Width(line, size, bold) > width {
+				runes := []rune(line)
+				// the longest prefix fitting into the line, at least one rune
+				cut := sort.Search(len(runes)-1, func(i int) bool {
+					return t.Width(string(runes[:i+2]), size, bold) > width
+				}) + 1
+				lines = append(lines, string(runes[:cut]))
+				line = string(runes[cut:])
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Draw draws a single line of text, top is the top of the line box, x the left edge, center or right edge depending on align
+// everything outside of clip is cut off
+func (t *profileCardText) Draw(dst *image.RGBA, clip image.Rectangle, text string, x, top int, size float64, bold bool, align int, textColor color.Color) {
+	if text == "" {
+		return
+	}
+	glyphs, width := t.layout(text, size, bold)
+
+	startX := fixed.I(x)
+	switch align {
+	case profileCardAlignCenter:
+		startX -= width / 2
+	case profileCardAlignRight:
+		startX -= width
+	}
+
+	clipped, ok := dst.SubImage(clip).(*image.RGBA)
+	if !ok {
+		return
+	}
+	// line-height: 100% in the template puts the baseline at about 84% of the font size
+	baseline := top + int(size*0.84+0.5)
+	source := image.NewUniform(textColor)
+	dot := fixed.Point26_6{X: startX, Y: fixed.I(baseline)}
+	for _, glyph := range glyphs {
+		if glyph.emoji != nil {
+			emojiSize := int(size + 0.5)
+			resizedEmoji := resize.Resize(uint(emojiSize), uint(emojiSize), glyph.emoji, resize.Bilinear)
+			emojiRect := image.Rect(dot.X.Round(), baseline-int(size*0.84+0.5), dot.X.Round()+emojiSize, baseline-int(size*0.84+0.5)+emojiSize)
+			draw.Draw(clipped, emojiRect, resizedEmoji, resizedEmoji.Bounds().Min, draw.Over)
+		} else {
+			dr, mask, maskp, _, ok := glyph.face.Glyph(dot, glyph.r)
+			if ok {
+				draw.DrawMask(clipped, dr, source, image.ZP, mask, maskp, draw.Over)
+			}
+		}
+		dot.X += glyph.advance
+	}
+}
@@ -0,0 +1,297 @@
+package helpers
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+)
+
+var updateProfileCards = flag.Bool("update-profile-cards", false, "rewrite the golden images in testdata/profile")
+
+// profileCardTolerance allows for small rounding differences between architectures
+const profileCardTolerance = 3
+
+func loadProfileCardFonts(t *testing.T) *ProfileCardFonts {
+	var fontsData [][]byte
+	for _, name := range []string{"Roboto/Roboto-Regular.ttf", "Roboto/Roboto-Bold.ttf", "UnDotum.ttf", "UnDotumBold.ttf"} {
+		data, err := ioutil.ReadFile("../_assets/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fontsData = append(fontsData, data)
+	}
+
+	fonts, err := ParseProfileCardFonts(fontsData[0], fontsData[1], fontsData[2], fontsData[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fonts
+}
+
+func testProfileCardBackground(offset int) image.Image {
+	background := image.NewRGBA(image.Rect(0, 0, ProfileCardWidth, ProfileCardHeight))
+	for x := 0; x < ProfileCardWidth; x++ {
+		for y := 0; y < ProfileCardHeight; y++ {
+			background.Set(x, y, color.RGBA{uint8((x + offset) * 255 / ProfileCardWidth), uint8(y * 255 / ProfileCardHeight), 160, 255})
+		}
+	}
+	return background
+}
+
+func testProfileCardAvatar(base color.RGBA) image.Image {
+	avatar := image.NewRGBA(image.Rect(0, 0, 128, 128))
+	draw.Draw(avatar, avatar.Bounds(), image.NewUniform(base), image.ZP, draw.Src)
+	draw.Draw(avatar, image.Rect(64, 0, 128, 64), image.White, image.ZP, draw.Src)
+	draw.Draw(avatar, image.Rect(0, 64, 64, 128), image.Black, image.ZP, draw.Src)
+	return avatar
+}
+
+func testProfileCardEmoji(text string) (emoji image.Image, length int) {
+	if !strings.HasPrefix(text, "🎂") {
+		return nil, 0
+	}
+	square := image.NewRGBA(image.Rect(0, 0, 72, 72))
+	draw.Draw(square, square.Bounds(), image.NewUniform(color.RGBA{230, 60, 90, 255}), image.ZP, draw.Src)
+	return square, len("🎂")
+}
+
+func testProfileCard(badges int) ProfileCard {
+	card := ProfileCard{
+		Background:         []image.Image{testProfileCardBackground(0)},
+		Avatar:             []image.Image{testProfileCardAvatar(color.RGBA{40, 120, 220, 255})},
+		Username:           "Robyul#1234",
+		Title:              "Robyul's friend",
+		Bio:                "Robyul would like to know more about me!",
+		Stats:              "🎂 01/02",
+		ServerLevel:        "12",
+		ServerRank:         "3",
+		ServerLevelPercent: 42,
+		GlobalLevel:        "20",
+		GlobalRank:         "N/A",
+		Rep:                7,
+		BackgroundOpacity:  0.5,
+		DetailOpacity:      0.5,
+		EXPOpacity:         0.5,
+		BadgeOpacity:       1,
+		Emoji:              testProfileCardEmoji,
+	}
+	for i := 0; i < badges; i++ {
+		badge := image.NewRGBA(image.Rect(0, 0, 64, 64))
+		draw.Draw(badge, badge.Bounds(), image.NewUniform(color.RGBA{uint8(i * 20), 200, uint8(255 - i*20), 255}), image.ZP, draw.Src)
+		card.Badges = append(card.Badges, ProfileCardBadge{Image: badge, BorderColor: "ffd700"})
+	}
+	return card
+}
+
+func compareProfileCardGolden(t *testing.T, name string, data []byte) {
+	path := "testdata/profile/" + name
+	if *updateProfileCards {
+		err := ioutil.WriteFile(path, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goldenData, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := png.Decode(bytes.NewReader(goldenData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drawn, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if drawn.Bounds() != golden.Bounds() {
+		t.Fatalf("profile card %s has the size %v instead of %v", name, drawn.Bounds(), golden.Bounds())
+	}
+	for x := golden.Bounds().Min.X; x < golden.Bounds().Max.X; x++ {
+		for y := golden.Bounds().Min.Y; y < golden.Bounds().Max.Y; y++ {
+			r1, g1, b1, a1 := drawn.At(x, y).RGBA()
+			r2, g2, b2, a2 := golden.At(x, y).RGBA()
+			for _, difference := range []int{int(r1>>8) - int(r2>>8), int(g1>>8) - int(g2>>8), int(b1>>8) - int(b2>>8), int(a1>>8) - int(a2>>8)} {
+				if difference > profileCardTolerance || difference < -profileCardTolerance {
+					t.Fatalf("profile card %s differs from the golden image at %d, %d, run the tests with -update-profile-cards if the change is intended", name, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestDrawProfileCard(t *testing.T) {
+	fonts := loadProfileCardFonts(t)
+
+	data, extension, err := DrawProfileCard(testProfileCard(3), fonts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extension != "png" {
+		t.Fatalf("helpers.DrawProfileCard() returned a %s for a still profile", extension)
+	}
+	compareProfileCardGolden(t, "default.png", data)
+}
+
+func TestDrawProfileCardCustomised(t *testing.T) {
+	fonts := loadProfileCardFonts(t)
+
+	card := testProfileCard(12)
+	card.Username = "로빈 (Robyul)"
+	card.Title = "최고의 친구"
+	card.Bio = "안녕하세요! This bio is long enough to be wrapped over multiple lines, and the last of them gets cut off at the bottom of the box."
+	card.Playing = []string{"Feel Special by TWICE", "TWICE (1,234 plays)"}
+	card.Stats = "Mon, 15:04 🎂 Today!"
+	card.ServerLevelPercent = 100
+	card.Rep = 1234
+	card.BackgroundColor = "2e86d4"
+	card.AccentColor = "d42e86"
+	card.TextColor = "fff3b0"
+	card.BackgroundOpacity = 0.8
+	card.DetailOpacity = 0.2
+	card.EXPOpacity = 1
+	card.BadgeOpacity = 0.6
+
+	data, _, err := DrawProfileCard(card, fonts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareProfileCardGolden(t, "customised.png", data)
+}
+
+func TestDrawProfileCardAnimated(t *testing.T) {
+	fonts := loadProfileCardFonts(t)
+
+	card := testProfileCard(0)
+	card.Background = []image.Image{testProfileCardBackground(0), testProfileCardBackground(100), testProfileCardBackground(200)}
+	card.BackgroundDelay = []int{5, 0, 20}
+
+	data, extension, err := DrawProfileCard(card, fonts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extension != "gif" {
+		t.Fatalf("helpers.DrawProfileCard() returned a %s for an animated background", extension)
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Image) != 3 || decoded.Delay[0] != 5 || decoded.Delay[1] != 10 || decoded.Delay[2] != 20 {
+		t.Fatalf("helpers.DrawProfileCard() returned %d frames with the delays %v", len(decoded.Image), decoded.Delay)
+	}
+	if decoded.Config.Width != ProfileCardWidth || decoded.Config.Height != ProfileCardHeight {
+		t.Fatalf("helpers.DrawProfileCard() returned a gif of %dx%d", decoded.Config.Width, decoded.Config.Height)
+	}
+
+	card = testProfileCard(0)
+	card.Avatar = append(card.Avatar, testProfileCardAvatar(color.RGBA{220, 40, 40, 255}))
+	card.AvatarDelay = []int{7, 7}
+	data, extension, err = DrawProfileCard(card, fonts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || extension != "gif" || len(decoded.Image) != 2 {
+		t.Fatalf("helpers.DrawProfileCard() didn't animate the avatar: %s, %v", extension, err)
+	}
+
+	_, extension, err = DrawProfileCard(card, fonts, false)
+	if err != nil || extension != "png" {
+		t.Fatalf("helpers.DrawProfileCard() returned a %s although no animation was requested: %v", extension, err)
+	}
+}
+
+func TestDecodeProfileCardFrames(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}}
+	first := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	draw.Draw(first, first.Bounds(), image.NewUniform(palette[1]), image.ZP, draw.Src)
+	second := image.NewPaletted(image.Rect(2, 2, 4, 4), palette)
+	draw.Draw(second, second.Bounds(), image.NewUniform(palette[2]), image.ZP, draw.Src)
+
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:    []*image.Paletted{first, second},
+		Delay:    []int{10, 30},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{Width: 4, Height: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames, delays, err := DecodeProfileCardFrames(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 || delays[0] != 10 || delays[1] != 30 {
+		t.Fatalf("helpers.DecodeProfileCardFrames() returned %d frames with the delays %v", len(frames), delays)
+	}
+	if r, _, _, _ := frames[1].At(0, 0).RGBA(); r>>8 != 255 {
+		t.Fatal("helpers.DecodeProfileCardFrames() didn't keep the previous frame below a partial frame")
+	}
+	if _, _, b, _ := frames[1].At(3, 3).RGBA(); b>>8 != 255 {
+		t.Fatal("helpers.DecodeProfileCardFrames() didn't draw the partial frame")
+	}
+}
+
+func TestProfileCardTextWrapLongWord(t *testing.T) {
+	text := &profileCardText{fonts: loadProfileCardFonts(t), emoji: testProfileCardEmoji, faces: make(map[profileCardFaceKey]font.Face)}
+	long := strings.Repeat("가나다라마바사", 300)
+
+	start := time.Now()
+	lines := text.Wrap(long, 18, false, 400)
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("profileCardText.Wrap() took %s for %d runes", time.Since(start), len([]rune(long)))
+	}
+	if len(lines) < 2 {
+		t.Fatalf("profileCardText.Wrap() returned %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if width := text.Width(line, 18, false); width > 400 {
+			t.Fatalf("profileCardText.Wrap() returned a line with the width %d", width)
+		}
+	}
+	if strings.Join(lines, "") != long {
+		t.Fatal("profileCardText.Wrap() lost text")
+	}
+}
+
+func TestMatchProfileCardEmoji(t *testing.T) {
+	files := map[string]bool{
+		"1f382":                       true,
+		"1f468":                       true,
+		"1f468-200d-1f469-200d-1f467": true,
+		"2764-fe0f":                   true,
+	}
+
+	for _, test := range []struct {
+		text   string
+		name   string
+		length int
+	}{
+		{"🎂 01/02", "1f382", len("🎂")},
+		{"👨\u200d👩\u200d👧 family", "1f468-200d-1f469-200d-1f467", len("👨\u200d👩\u200d👧")},
+		{"👨\u200d👩 couple", "1f468", len("👨")},
+		{"❤\ufe0f", "2764-fe0f", len("❤\ufe0f")},
+		{"😀🎂", "", 0},
+		{"a🎂", "", 0},
+		{"", "", 0},
+	} {
+		name, length := MatchProfileCardEmoji(test.text, files)
+		if name != test.name || length != test.length {
+			t.Errorf("helpers.MatchProfileCardEmoji(%q) = %q, %d, want %q, %d", test.text, name, length, test.name, test.length)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo/bson"
+	redisCache "github.com/go-redis/cache"
+)
+
+const (
+	ProfileThemeMaxTags = 5
+	// the counts are aggregated over all users, the public API would run it for every request otherwise
+	profileBackgroundUsageCountsExpiry = 5 * time.Minute
+)
+
+var profileThemeNameRegex = regexp.MustCompile(`^[a-z0-9_-]{2,32}$`)
+
+// ParseProfileThemeTags parses a comma separated list of tags, tags are lowercased and duplicates are removed
+func ParseProfileThemeTags(text string) (tags []string) {
+	tags = make([]string, 0)
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		alreadyInList := false
+		for _, oldTag := range tags {
+			if oldTag == tag {
+				alreadyInList = true
+			}
+		}
+		if !alreadyInList {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ProfileThemeNameIsValid returns true if name can be used as the name of a background, it has to be a single lowercase word
+func ProfileThemeNameIsValid(name string) bool {
+	return profileThemeNameRegex.MatchString(name)
+}
+
+// ProfileBackgroundIsUsable returns false for server exclusive backgrounds of other servers
+func ProfileBackgroundIsUsable(background models.ProfileBackgroundEntry, guildID string) bool {
+	return background.GuildID == "" || background.GuildID == guildID
+}
+
+// ProfileBackgroundMatchesTag returns true if the background has the tag, or if tag is empty
+func ProfileBackgroundMatchesTag(background models.ProfileBackgroundEntry, tag string) bool {
+	if tag == "" {
+		return true
+	}
+	for _, backgroundTag := range background.Tags {
+		if backgroundTag == strings.ToLower(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProfileBackgroundUsageCounts returns how many users use each background, by lowercase background name
+func GetProfileBackgroundUsageCounts() (counts map[string]int, err error) {
+	cacheCodec := cache.GetRedisCacheCodec()
+	if err = cacheCodec.Get(models.ProfileBackgroundUsageCountsRedisKey, &counts); err == nil {
+		return counts, nil
+	}
+
+	var result []struct {
+		Name  string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	err = MdbPipeAll(models.ProfileUserdataTable, []bson.M{
+		{"$match": bson.M{"background": bson.M{"$nin": []interface{}{nil, ""}}}},
+		{"$group": bson.M{"_id": bson.M{"$toLower": "$background"}, "count": bson.M{"$sum": 1}}},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	counts = make(map[string]int)
+	for _, item := range result {
+		counts[item.Name] = item.Count
+	}
+
+	err = cacheCodec.Set(&redisCache.Item{
+		Key:        models.ProfileBackgroundUsageCountsRedisKey,
+		Object:     counts,
+		Expiration: profileBackgroundUsageCountsExpiry,
+	})
+	RelaxLog(err)
+
+	return counts, nil
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestParseProfileThemeTags(t *testing.T) {
+	tags := ParseProfileThemeTags(" Pastel, dark ,pastel,, TWICE ")
+	expected := []string{"pastel", "dark", "twice"}
+	if len(tags) != len(expected) {
+		t.Fatalf("helpers.ParseProfileThemeTags() returned %v instead of %v", tags, expected)
+	}
+	for i := range expected {
+		if tags[i] != expected[i] {
+			t.Fatalf("helpers.ParseProfileThemeTags() returned %v instead of %v", tags, expected)
+		}
+	}
+
+	if tags := ParseProfileThemeTags(" , "); len(tags) != 0 {
+		t.Fatalf("helpers.ParseProfileThemeTags() returned tags for an empty list: %v", tags)
+	}
+}
+
+func TestProfileThemeNameIsValid(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"sunset-beach": true,
+		"twice_2018":   true,
+		"a":            false,
+		"two words":    false,
+		"Uppercase":    false,
+		"":             false,
+	} {
+		if ProfileThemeNameIsValid(name) != expected {
+			t.Fatalf("helpers.ProfileThemeNameIsValid(%q) returned %t", name, !expected)
+		}
+	}
+}
+
+func TestProfileBackgroundIsUsable(t *testing.T) {
+	global := models.ProfileBackgroundEntry{Name: "sunset"}
+	exclusive := models.ProfileBackgroundEntry{Name: "server-logo", GuildID: "1"}
+
+	if !ProfileBackgroundIsUsable(global, "2") {
+		t.Fatal("helpers.ProfileBackgroundIsUsable() didn't allow a global background")
+	}
+	if !ProfileBackgroundIsUsable(exclusive, "1") {
+		t.Fatal("helpers.ProfileBackgroundIsUsable() didn't allow a server exclusive background on its server")
+	}
+	if ProfileBackgroundIsUsable(exclusive, "2") {
+		t.Fatal("helpers.ProfileBackgroundIsUsable() allowed a server exclusive background on another server")
+	}
+}
+
+func TestProfileBackgroundMatchesTag(t *testing.T) {
+	background := models.ProfileBackgroundEntry{Name: "sunset", Tags: []string{"pastel", "nature"}}
+
+	if !ProfileBackgroundMatchesTag(background, "") || !ProfileBackgroundMatchesTag(background, "Nature") {
+		t.Fatal("helpers.ProfileBackgroundMatchesTag() didn't match a tag of the background")
+	}
+	if ProfileBackgroundMatchesTag(background, "dark") {
+		t.Fatal("helpers.ProfileBackgroundMatchesTag() matched a tag the background doesn't have")
+	}
+}
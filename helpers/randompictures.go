@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"html"
+	"strings"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+const (
+	// names of pictures from reddit are shortened to this many characters
+	RandomPicturesMaxNameLength = 200
+)
+
+// RandomPicturesProvider returns the provider of the source, sources added before there were other providers use google drive
+func RandomPicturesProvider(source models.RandompictureSourceEntry) string {
+	switch source.Provider {
+	case models.RandompictureProviderStorage, models.RandompictureProviderImgur, models.RandompictureProviderReddit:
+		return source.Provider
+	}
+	return models.RandompictureProviderDrive
+}
+
+// RandomPicturesIsImageLink returns true if the link points to a picture which can be embedded
+func RandomPicturesIsImageLink(link string) bool {
+	link = strings.ToLower(link)
+	for _, extension := range []string{".jpg", ".jpeg", ".gif", ".png"} {
+		if strings.HasSuffix(link, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// RandomPicturesRedditName returns the unescaped title of a reddit submission, shortened to RandomPicturesMaxNameLength characters
+func RandomPicturesRedditName(title string) string {
+	name := []rune(html.UnescapeString(title))
+	if len(name) > RandomPicturesMaxNameLength {
+		return string(name[:RandomPicturesMaxNameLength-1]) + "…"
+	}
+	return string(name)
+}
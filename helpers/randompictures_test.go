@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestRandomPicturesProvider(t *testing.T) {
+	for provider, expected := range map[string]string{
+		"":                                  models.RandompictureProviderDrive,
+		models.RandompictureProviderDrive:   models.RandompictureProviderDrive,
+		models.RandompictureProviderStorage: models.RandompictureProviderStorage,
+		models.RandompictureProviderImgur:   models.RandompictureProviderImgur,
+		models.RandompictureProviderReddit:  models.RandompictureProviderReddit,
+		"unknown":                           models.RandompictureProviderDrive,
+	} {
+		if result := RandomPicturesProvider(models.RandompictureSourceEntry{Provider: provider}); result != expected {
+			t.Fatalf("helpers.RandomPicturesProvider() returned %q for %q, expected %q", result, provider, expected)
+		}
+	}
+}
+
+func TestRandomPicturesIsImageLink(t *testing.T) {
+	for link, expected := range map[string]bool{
+		"https://i.redd.it/abc.jpg":          true,
+		"https://i.imgur.com/ABC.PNG":        true,
+		"https://i.imgur.com/abc.gif":        true,
+		"https://i.imgur.com/abc.jpeg":       true,
+		"https://i.imgur.com/abc.gifv":       false,
+		"https://v.redd.it/abc":              false,
+		"https://www.reddit.com/r/kpop/abc/": false,
+	} {
+		if RandomPicturesIsImageLink(link) != expected {
+			t.Fatalf("helpers.RandomPicturesIsImageLink(%q) didn't return %t", link, expected)
+		}
+	}
+}
+
+func TestRandomPicturesRedditName(t *testing.T) {
+	if name := RandomPicturesRedditName("Jisoo &amp; Jennie"); name != "Jisoo & Jennie" {
+		t.Fatal("helpers.RandomPicturesRedditName() didn't unescape the title:", name)
+	}
+
+	name := RandomPicturesRedditName(strings.Repeat("블랙핑크", 100))
+	if !utf8.ValidString(name) || utf8.RuneCountInString(name) != RandomPicturesMaxNameLength || !strings.HasSuffix(name, "…") {
+		t.Fatal("helpers.RandomPicturesRedditName() didn't shorten the title by characters:", name)
+	}
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	RepDefaultCooldown   = 12 * time.Hour
+	RepMinimumAccountAge = 7 * 24 * time.Hour
+	// a user can't return rep to a user who gave them rep within this window
+	RepMutualWindow    = 7 * 24 * time.Hour
+	RepMaxReasonLength = 200
+)
+
+var (
+	ErrRepAccountTooNew = errors.New("account is too new to give rep")
+	ErrRepCooldown      = errors.New("rep has been given too recently")
+	ErrRepMutual        = errors.New("the receiver gave rep to the giver recently")
+)
+
+// GetRepCooldown returns the cooldown between two reps on a server, cooldownHours is the server setting
+// servers can only use cooldowns longer than RepDefaultCooldown, rep counts towards the global total
+func GetRepCooldown(cooldownHours int) time.Duration {
+	cooldown := time.Duration(cooldownHours) * time.Hour
+	if cooldown < RepDefaultCooldown {
+		return RepDefaultCooldown
+	}
+	return cooldown
+}
+
+// CheckRepAllowed checks the anti abuse rules for giving rep
+// lastGiven is the last time the giver gave rep on the server, lastGivenGlobal on any server, lastReceivedFromReceiver the last time the receiver gave rep to the giver, zero times if never
+// RepDefaultCooldown always applies to lastGivenGlobal, cooldown to lastGiven
+// wait is set for ErrRepCooldown
+func CheckRepAllowed(accountCreatedAt, lastGiven, lastGivenGlobal, lastReceivedFromReceiver time.Time, cooldown time.Duration, now time.Time) (wait time.Duration, err error) {
+	if now.Sub(accountCreatedAt) < RepMinimumAccountAge {
+		return 0, ErrRepAccountTooNew
+	}
+	if !lastGiven.IsZero() && now.Sub(lastGiven) < cooldown {
+		wait = lastGiven.Add(cooldown).Sub(now)
+	}
+	if !lastGivenGlobal.IsZero() && now.Sub(lastGivenGlobal) < RepDefaultCooldown {
+		if globalWait := lastGivenGlobal.Add(RepDefaultCooldown).Sub(now); globalWait > wait {
+			wait = globalWait
+		}
+	}
+	if wait > 0 {
+		return wait, ErrRepCooldown
+	}
+	if !lastReceivedFromReceiver.IsZero() && now.Sub(lastReceivedFromReceiver) < RepMutualWindow {
+		return 0, ErrRepMutual
+	}
+	return 0, nil
+}
@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetRepCooldown(t *testing.T) {
+	if cooldown := GetRepCooldown(0); cooldown != RepDefaultCooldown {
+		t.Fatalf("helpers.GetRepCooldown(0) returned %s", cooldown)
+	}
+	if cooldown := GetRepCooldown(24); cooldown != 24*time.Hour {
+		t.Fatalf("helpers.GetRepCooldown(24) returned %s", cooldown)
+	}
+	if cooldown := GetRepCooldown(1); cooldown != RepDefaultCooldown {
+		t.Fatalf("helpers.GetRepCooldown(1) returned %s", cooldown)
+	}
+}
+
+func TestCheckRepAllowed(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	oldAccount := now.Add(-365 * 24 * time.Hour)
+
+	if _, err := CheckRepAllowed(oldAccount, time.Time{}, time.Time{}, time.Time{}, RepDefaultCooldown, now); err != nil {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v for a first rep", err)
+	}
+
+	if _, err := CheckRepAllowed(now.Add(-24*time.Hour), time.Time{}, time.Time{}, time.Time{}, RepDefaultCooldown, now); err != ErrRepAccountTooNew {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v for a new account", err)
+	}
+
+	wait, err := CheckRepAllowed(oldAccount, now.Add(-2*time.Hour), now.Add(-2*time.Hour), time.Time{}, RepDefaultCooldown, now)
+	if err != ErrRepCooldown || wait != 10*time.Hour {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v and %s during the cooldown", err, wait)
+	}
+
+	if _, err := CheckRepAllowed(oldAccount, now.Add(-13*time.Hour), now.Add(-13*time.Hour), time.Time{}, RepDefaultCooldown, now); err != nil {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v after the cooldown", err)
+	}
+
+	// rep given on another server counts towards the global cooldown
+	wait, err = CheckRepAllowed(oldAccount, time.Time{}, now.Add(-4*time.Hour), time.Time{}, RepDefaultCooldown, now)
+	if err != ErrRepCooldown || wait != 8*time.Hour {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v and %s during the global cooldown", err, wait)
+	}
+	// a longer server cooldown applies on top
+	wait, err = CheckRepAllowed(oldAccount, now.Add(-13*time.Hour), now.Add(-13*time.Hour), time.Time{}, 24*time.Hour, now)
+	if err != ErrRepCooldown || wait != 11*time.Hour {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v and %s during the server cooldown", err, wait)
+	}
+
+	if _, err := CheckRepAllowed(oldAccount, time.Time{}, time.Time{}, now.Add(-3*24*time.Hour), RepDefaultCooldown, now); err != ErrRepMutual {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v for mutual rep", err)
+	}
+	if _, err := CheckRepAllowed(oldAccount, time.Time{}, time.Time{}, now.Add(-8*24*time.Hour), RepDefaultCooldown, now); err != nil {
+		t.Fatalf("helpers.CheckRepAllowed() returned %v for mutual rep outside of the window", err)
+	}
+}
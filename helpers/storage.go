@@ -366,6 +366,16 @@ func GetFileLink(objectName string) (url string, err error) {
 	return url, nil
 }
 
+// Makes a stored file available via the website proxy
+// objectName	: the name of the object
+func PublishFile(objectName string) (err error) {
+	return MDbUpdateQueryWithoutLogging(
+		models.StorageTable,
+		bson.M{"objectname": objectName},
+		bson.M{"$set": bson.M{"public": true, "metadata.public": "yes"}},
+	)
+}
+
 // Deletes a file
 // objectName	: the name of the object
 func DeleteFile(objectName string) (err error) {
@@ -0,0 +1,308 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/olivere/elastic"
+)
+
+const (
+	// joins using the vanity invite are attributed to the last click within this window
+	VanityInviteAttributionWindow = 30 * time.Minute
+	// the leaves of the joined users are requested in batches of user IDs
+	vanityInviteRetentionBatchSize = 1000
+)
+
+var (
+	VanityInviteRetentionDays = []int{1, 7, 30}
+
+	vanityInviteCampaignCleanRegex = regexp.MustCompile(`[^a-z0-9_-]`)
+)
+
+// VanityInviteConversion are the clicks and attributed joins for a referer or campaign
+type VanityInviteConversion struct {
+	Key    string
+	Clicks int64
+	Joins  int64
+}
+
+// Rate returns the share of clicks which resulted in a join
+func (c VanityInviteConversion) Rate() float64 {
+	if c.Clicks <= 0 {
+		return 0
+	}
+	return float64(c.Joins) / float64(c.Clicks)
+}
+
+// VanityInviteRetention are the joined users which were still on the server after Days
+// only joins which are at least Days old are counted
+type VanityInviteRetention struct {
+	Days     int
+	Joined   int
+	Retained int
+}
+
+// NormalizeVanityInviteCampaign lowercases a campaign and removes invalid characters, campaigns are at most 32 characters long
+func NormalizeVanityInviteCampaign(campaign string) string {
+	campaign = vanityInviteCampaignCleanRegex.ReplaceAllString(strings.ToLower(strings.TrimSpace(campaign)), "")
+	if len(campaign) > 32 {
+		campaign = campaign[:32]
+	}
+	return campaign
+}
+
+// GetVanityInviteConversions returns the clicks and joins since from grouped by field, Referer or Campaign
+func GetVanityInviteConversions(vanityInvite models.VanityInviteEntry, field string, from time.Time) (conversions []VanityInviteConversion, err error) {
+	clicksQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", vanityInvite.GuildID)).
+		Must(elastic.NewRangeQuery("CreatedAt").Gte(from))
+	clicksResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndexVanityInviteClicks).
+		Type("doc").
+		Query(clicksQuery).
+		Aggregation("keys", elastic.NewTermsAggregation().Field(field+".keyword").Missing("").Size(100)).
+		Size(0).
+		Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	joinsQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", vanityInvite.GuildID)).
+		Must(elastic.NewMatchQuery("VanityInvite", vanityInvite.VanityName)).
+		Must(elastic.NewRangeQuery("CreatedAt").Gte(from))
+	joinsResult, err := cache.GetElastic().Search().
+		Index(models.ElasticIndexJoins).
+		Type("doc").
+		Query(joinsQuery).
+		Aggregation("keys", elastic.NewTermsAggregation().Field("VanityInvite"+field+".keyword").Missing("").Size(100)).
+		Size(0).
+		Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	conversionsByKey := make(map[string]*VanityInviteConversion)
+	if agg, found := clicksResult.Aggregations.Terms("keys"); found {
+		for _, bucket := range agg.Buckets {
+			key, _ := bucket.Key.(string)
+			conversionsByKey[key] = &VanityInviteConversion{Key: key, Clicks: bucket.DocCount}
+		}
+	}
+	if agg, found := joinsResult.Aggregations.Terms("keys"); found {
+		for _, bucket := range agg.Buckets {
+			key, _ := bucket.Key.(string)
+			if _, ok := conversionsByKey[key]; !ok {
+				conversionsByKey[key] = &VanityInviteConversion{Key: key}
+			}
+			conversionsByKey[key].Joins = bucket.DocCount
+		}
+	}
+
+	conversions = make([]VanityInviteConversion, 0)
+	for _, conversion := range conversionsByKey {
+		conversions = append(conversions, *conversion)
+	}
+	sort.Slice(conversions, func(i, j int) bool {
+		if conversions[i].Clicks == conversions[j].Clicks {
+			return conversions[i].Joins > conversions[j].Joins
+		}
+		return conversions[i].Clicks > conversions[j].Clicks
+	})
+	return conversions, nil
+}
+
+// GetVanityInviteRetention returns how many users who joined using the vanity invite since from stayed on the server
+// users are only known if the chatlog is enabled on the server
+func GetVanityInviteRetention(vanityInvite models.VanityInviteEntry, from time.Time) (retention []VanityInviteRetention, err error) {
+	joinsQuery := elastic.NewBoolQuery().
+		Must(elastic.NewMatchQuery("GuildID", vanityInvite.GuildID)).
+		Must(elastic.NewMatchQuery("VanityInvite", vanityInvite.VanityName)).
+		Must(elastic.NewRangeQuery("CreatedAt").Gte(from))
+	joins := make([]models.ElasticJoin, 0)
+	err = scrollElasticDocuments(models.ElasticIndexJoins, joinsQuery, func(source json.RawMessage) {
+		var join models.ElasticJoin
+		err := json.Unmarshal(source, &join)
+		if err != nil || join.UserID == "" {
+			return
+		}
+		joins = append(joins, join)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]interface{}, 0, len(joins))
+	knownUserIDs := make(map[string]bool)
+	for _, join := range joins {
+		if knownUserIDs[join.UserID] {
+			continue
+		}
+		knownUserIDs[join.UserID] = true
+		userIDs = append(userIDs, join.UserID)
+	}
+
+	leaves := make([]models.ElasticLeave, 0)
+	for len(userIDs) > 0 {
+		batch := userIDs
+		if len(batch) > vanityInviteRetentionBatchSize {
+			batch = batch[:vanityInviteRetentionBatchSize]
+		}
+		userIDs = userIDs[len(batch):]
+
+		// UserID is mapped as text, the IDs are single tokens
+		leavesQuery := elastic.NewBoolQuery().
+			Must(elastic.NewMatchQuery("GuildID", vanityInvite.GuildID)).
+			Must(elastic.NewTermsQuery("UserID", batch...)).
+			Must(elastic.NewRangeQuery("CreatedAt").Gte(from))
+		err = scrollElasticDocuments(models.ElasticIndexLeaves, leavesQuery, func(source json.RawMessage) {
+			var leave models.ElasticLeave
+			err := json.Unmarshal(source, &leave)
+			if err != nil || leave.UserID == "" {
+				return
+			}
+			leaves = append(leaves, leave)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return CalculateVanityInviteRetention(joins, leaves, time.Now(), VanityInviteRetentionDays), nil
+}
+
+// scrollElasticDocuments calls handle with the source of every document matching the query
+func scrollElasticDocuments(index string, query elastic.Query, handle func(source json.RawMessage)) (err error) {
+	scroll := cache.GetElastic().Scroll(index).
+		Type("doc").
+		Query(query).
+		Size(1000)
+	defer scroll.Clear(context.Background())
+
+	for {
+		result, err := scroll.Do(context.Background())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, hit := range result.Hits.Hits {
+			if hit == nil || hit.Source == nil {
+				continue
+			}
+			handle(*hit.Source)
+		}
+	}
+}
+
+// CalculateVanityInviteRetention counts for each number of days how many of the joined users didn't leave within that time
+func CalculateVanityInviteRetention(joins []models.ElasticJoin, leaves []models.ElasticLeave, now time.Time, days []int) (retention []VanityInviteRetention) {
+	leavesByUser := make(map[string][]time.Time)
+	for _, leave := range leaves {
+		leavesByUser[leave.UserID] = append(leavesByUser[leave.UserID], leave.CreatedAt)
+	}
+
+	for _, dayCount := range days {
+		item := VanityInviteRetention{Days: dayCount}
+		period := time.Duration(dayCount) * 24 * time.Hour
+
+		for _, join := range joins {
+			if join.CreatedAt.Add(period).After(now) {
+				continue
+			}
+			item.Joined++
+
+			retained := true
+			for _, leftAt := range leavesByUser[join.UserID] {
+				if leftAt.After(join.CreatedAt) && !leftAt.After(join.CreatedAt.Add(period)) {
+					retained = false
+					break
+				}
+			}
+			if retained {
+				item.Retained++
+			}
+		}
+
+		retention = append(retention, item)
+	}
+	return retention
+}
+
+// GetVanityInviteDailyHistogram returns the clicks and joins per day for the last days, oldest first
+func GetVanityInviteDailyHistogram(vanityInvite models.VanityInviteEntry, days int) (labels []string, clicks, joins []float64, err error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days+1)
+
+	indexByDay := make(map[string]int)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i).Format("2006-01-02")
+		indexByDay[day] = i
+		labels = append(labels, from.AddDate(0, 0, i).Format("Jan 2"))
+	}
+	clicks = make([]float64, days)
+	joins = make([]float64, days)
+
+	agg := elastic.NewDateHistogramAggregation().
+		Field("CreatedAt").
+		Interval("day").
+		MinDocCount(0).
+		ExtendedBoundsMin(from).
+		ExtendedBoundsMax(now)
+
+	queries := []struct {
+		index  string
+		query  elastic.Query
+		values []float64
+	}{
+		{
+			models.ElasticIndexVanityInviteClicks,
+			elastic.NewBoolQuery().
+				Must(elastic.NewMatchQuery("GuildID", vanityInvite.GuildID)).
+				Must(elastic.NewRangeQuery("CreatedAt").Gte(from)),
+			clicks,
+		},
+		{
+			models.ElasticIndexJoins,
+			elastic.NewBoolQuery().
+				Must(elastic.NewMatchQuery("GuildID", vanityInvite.GuildID)).
+				Must(elastic.NewMatchQuery("VanityInvite", vanityInvite.VanityName)).
+				Must(elastic.NewRangeQuery("CreatedAt").Gte(from)),
+			joins,
+		},
+	}
+
+	for _, query := range queries {
+		searchResult, err := cache.GetElastic().Search().
+			Index(query.index).
+			Type("doc").
+			Query(query.query).
+			Aggregation("days", agg).
+			Size(0).
+			Do(context.Background())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if result, found := searchResult.Aggregations.DateHistogram("days"); found {
+			for _, bucket := range result.Buckets {
+				day := time.Unix(int64(bucket.Key/1000), 0).UTC().Format("2006-01-02")
+				if i, ok := indexByDay[day]; ok {
+					query.values[i] = float64(bucket.DocCount)
+				}
+			}
+		}
+	}
+
+	return labels, clicks, joins, nil
+}
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/models"
+)
+
+func TestCalculateVanityInviteRetention(t *testing.T) {
+	now := time.Date(2018, time.June, 30, 12, 0, 0, 0, time.UTC)
+
+	joins := []models.ElasticJoin{
+		{UserID: "1", CreatedAt: now.AddDate(0, 0, -40)}, // left after 3 days
+		{UserID: "2", CreatedAt: now.AddDate(0, 0, -40)}, // stayed
+		{UserID: "3", CreatedAt: now.AddDate(0, 0, -10)}, // left after 12 hours
+		{UserID: "4", CreatedAt: now.AddDate(0, 0, -3)},  // too new for 7 and 30 days
+	}
+	leaves := []models.ElasticLeave{
+		{UserID: "1", CreatedAt: now.AddDate(0, 0, -37)},
+		{UserID: "2", CreatedAt: now.AddDate(0, 0, -50)}, // left before joining again
+		{UserID: "3", CreatedAt: now.AddDate(0, 0, -10).Add(12 * time.Hour)},
+	}
+
+	retention := CalculateVanityInviteRetention(joins, leaves, now, []int{1, 7, 30})
+	expected := []VanityInviteRetention{
+		{Days: 1, Joined: 4, Retained: 3},
+		{Days: 7, Joined: 3, Retained: 1},
+		{Days: 30, Joined: 2, Retained: 1},
+	}
+
+	if len(retention) != len(expected) {
+		t.Fatal("helpers.CalculateVanityInviteRetention() returned the wrong number of items")
+	}
+	for i := range expected {
+		if retention[i] != expected[i] {
+			t.Fatalf("helpers.CalculateVanityInviteRetention() returned %+v, expected %+v", retention[i], expected[i])
+		}
+	}
+}
+
+func TestNormalizeVanityInviteCampaign(t *testing.T) {
+	if NormalizeVanityInviteCampaign(" Twitter!") != "twitter" {
+		t.Fatal("helpers.NormalizeVanityInviteCampaign() didn't clean the campaign")
+	}
+}
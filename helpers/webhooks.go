@@ -1,7 +1,12 @@
 package helpers
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
 
 	"time"
 
@@ -29,6 +34,83 @@ func WebhookExecuteWithResult(id, token string, data *discordgo.WebhookParams) (
 	return message, err
 }
 
+// Executes a webhook with file uploads and waits for the response
+// id		: the ID of the webhook to use
+// token	: the token of the webhook to use
+// data		: webhook params to send
+// files	: files to upload with the message
+func WebhookExecuteWithFilesWithResult(id, token string, data *discordgo.WebhookParams, files []*discordgo.File) (message *discordgo.Message, err error) {
+	if len(files) <= 0 {
+		return WebhookExecuteWithResult(id, token, data)
+	}
+
+	body := &bytes.Buffer{}
+	bodyWriter := multipart.NewWriter(body)
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return message, err
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", "application/json")
+	part, err := bodyWriter.CreatePart(header)
+	if err != nil {
+		return message, err
+	}
+	_, err = part.Write(payload)
+	if err != nil {
+		return message, err
+	}
+
+	for i, file := range files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file%d"; filename="%s"`,
+			i, strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(file.Name)))
+		header.Set("Content-Type", contentType)
+		part, err := bodyWriter.CreatePart(header)
+		if err != nil {
+			return message, err
+		}
+		_, err = io.Copy(part, file.Reader)
+		if err != nil {
+			return message, err
+		}
+	}
+
+	err = bodyWriter.Close()
+	if err != nil {
+		return message, err
+	}
+
+	session := cache.GetSession()
+	uri := discordgo.EndpointWebhookToken(id, token) + "?wait=true"
+	result, err := session.RequestWithLockedBucket("POST", uri, bodyWriter.FormDataContentType(), body.Bytes(),
+		session.Ratelimiter.LockBucket(discordgo.EndpointWebhookToken("", "")), 0)
+	if err != nil {
+		return message, err
+	}
+
+	err = json.Unmarshal(result, &message)
+	return message, err
+}
+
+// Edits a message sent by a webhook
+// id			: the ID of the webhook which sent the message
+// token		: the token of the webhook which sent the message
+// messageID	: the ID of the message to edit
+// data			: webhook params to send, only content and embeds can be changed
+func WebhookEditMessage(id, token, messageID string, data *discordgo.WebhookParams) (err error) {
+	uri := discordgo.EndpointWebhookToken(id, token) + "/messages/" + messageID
+
+	_, err = cache.GetSession().RequestWithBucketID("PATCH", uri, data, discordgo.EndpointWebhookToken("", "")+"/messages/")
+	return err
+}
+
 // Gets a webhook for a channel (checks for permission, and uses cache)
 // guildID		: the guild from which to get the webhook
 // channelID	: the channel for which to get the webhook
@@ -282,6 +282,7 @@ func main() {
 		discord.AddHandler(helpers.ElasticOnMessageCreate)
 		discord.AddHandler(helpers.ElasticOnMessageUpdate)
 		discord.AddHandler(helpers.ElasticOnMessageDelete)
+		discord.AddHandler(helpers.ElasticOnMessageDeleteBulk)
 		discord.AddHandler(helpers.ElasticOnGuildMemberRemove)
 		discord.AddHandler(helpers.ElasticOnPresenceUpdate)
 		// Guild Member Add in modules/plugins/mod.go
@@ -418,12 +419,17 @@ func main() {
 	}
 	log.WithField("module", "launcher").Info("started machinery server, default queue: robyul_tasks")
 	machineryServer.RegisterTasks(map[string]interface{}{
-		"unmute_user":    helpers.UnmuteUserMachinery,
-		"apply_autorole": plugins.AutoroleApply,
-		"log_error":      helpers.LogMachineryError,
+		"unmute_user":              helpers.UnmuteUserMachinery,
+		"apply_autorole":           plugins.AutoroleApply,
+		"post_scheduled":           plugins.ScheduledPostRun,
+		"delete_scheduled_post":    plugins.ScheduledPostDeleteMessage,
+		"remove_birthday_role":     plugins.BirthdayRoleRemove,
+		"deliver_outgoing_webhook": helpers.OutgoingWebhookDeliverMachinery,
+		"log_error":                helpers.LogMachineryError,
 	})
 	cache.SetMachineryServer(machineryServer)
-	worker := machineryServer.NewWorker("robyul_worker_1", 1)
+	// outgoing webhook deliveries use at most helpers.OutgoingWebhookMaxInFlight of the slots
+	worker := machineryServer.NewWorker("robyul_worker_1", 10)
 	go func() {
 		cache.AddMachineryActiveWorker(worker)
 		err = worker.Launch()
@@ -435,7 +441,7 @@ func main() {
 			}
 		}
 	}()
-	log.WithField("module", "launcher").Info("started machinery worker robyul_worker_1 with concurrency 1")
+	log.WithField("module", "launcher").Info("started machinery worker robyul_worker_1 with concurrency 10")
 	machineryRedisClient := redis.NewClient(&redis.Options{
 		Addr:     config.Path("redis.address").Data().(string),
 		Password: "", // no password set
@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/olivere/elastic"
+)
+
+// the messages index is replaced by this index with an indexed Content, robyul-messages becomes an alias
+const m56MessagesIndex = "robyul-messages-v2"
+
+func m56_reindex_elastic_messages_content() {
+	if !cache.HasElastic() {
+		return
+	}
+
+	elasticClient := cache.GetElastic()
+	exists, err := elasticClient.IndexExists(models.ElasticIndexMessages).Do(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		return
+	}
+
+	// the alias is only added once the reindex has been finished
+	aliases, err := elasticClient.Aliases().Index(models.ElasticIndexMessages).Do(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	for _, indexName := range aliases.IndicesByAlias(models.ElasticIndexMessages) {
+		if indexName == m56MessagesIndex {
+			return
+		}
+	}
+
+	// left over from a reindex which has been interrupted, start again
+	exists, err = elasticClient.IndexExists(m56MessagesIndex).Do(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	if exists {
+		cache.GetLogger().WithField("module", "migrations").Warn("deleting incomplete ElasticSearch messages index")
+		_, err = elasticClient.DeleteIndex(m56MessagesIndex).Do(context.Background())
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	index, err := elasticClient.CreateIndex(m56MessagesIndex).BodyJson(models.ElasticMessagesMapping).Do(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	if !index.Acknowledged {
+		cache.GetLogger().WithField("module", "migrations").Error("ElasticSearch index not acknowledged")
+	}
+
+	// reindexing all messages takes a while, messages are still stored in the old index until it has been replaced
+	go m56ReindexMessages()
+}
+
+func m56ReindexMessages() {
+	defer helpers.Recover()
+
+	elasticClient := cache.GetElastic()
+	log := cache.GetLogger().WithField("module", "migrations")
+	log.Info("reindexing ElasticSearch messages index in the background")
+
+	startedAt := time.Now()
+	src := elastic.NewReindexSource().Index(models.ElasticIndexMessages).Type("doc")
+	dst := elastic.NewReindexDestination().Index(m56MessagesIndex).Type("doc")
+	task, err := elasticClient.Reindex().Source(src).Destination(dst).DoAsync(context.Background())
+	if err != nil {
+		log.Error("starting the messages reindex failed: ", err.Error())
+		return
+	}
+
+	for {
+		time.Sleep(30 * time.Second)
+
+		taskStatus, err := elasticClient.TasksGetTask().TaskId(task.TaskId).Do(context.Background())
+		if err != nil {
+			log.Error("checking the messages reindex failed: ", err.Error())
+			return
+		}
+		if taskStatus.Completed {
+			break
+		}
+	}
+
+	// copy the messages stored while reindexing, messages stored after this are lost
+	src = elastic.NewReindexSource().Index(models.ElasticIndexMessages).Type("doc").
+		Query(elastic.NewRangeQuery("CreatedAt").Gte(startedAt.Add(-1 * time.Minute)))
+	res, err := elasticClient.Reindex().Source(src).Destination(dst).Refresh("true").Do(context.Background())
+	if err != nil {
+		log.Error("reindexing the recent messages failed: ", err.Error())
+		return
+	}
+	log.Infof("reindexed the messages index, %d recent message documents have been copied again", res.Total)
+
+	// the alias has to be added right after deleting the index, a new message would create the index again
+	deleteIndex, err := elasticClient.DeleteIndex(models.ElasticIndexMessages).Do(context.Background())
+	if err != nil {
+		log.Error("deleting the old messages index failed: ", err.Error())
+		return
+	}
+	if !deleteIndex.Acknowledged {
+		log.Error("ElasticSearch index not acknowledged")
+	}
+
+	alias, err := elasticClient.Alias().Add(m56MessagesIndex, models.ElasticIndexMessages).Do(context.Background())
+	if err != nil {
+		log.Error("adding the messages alias failed: ", err.Error())
+		return
+	}
+	if !alias.Acknowledged {
+		log.Error("ElasticSearch alias not acknowledged")
+	}
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo"
+)
+
+func m57_create_mongodb_storage_indexes() {
+	// eventlog attachments are looked up by their Discord URL for every deleted message, EnsureIndex does nothing if the index exists
+	err := helpers.MdbCollection(models.StorageTable).EnsureIndex(mgo.Index{
+		Key:        []string{"metadata.discord_attachment"},
+		Background: true,
+		Sparse:     true,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+func m58_create_mongodb_badge_award_indexes() {
+	// badge checks running at the same time could award a badge twice before the index existed, keep the first award
+	var duplicates []struct {
+		AwardIDs []bson.ObjectId `bson:"awardids"`
+	}
+	err := helpers.MdbCollection(models.ProfileBadgeAwardsTable).Pipe([]bson.M{
+		{"$sort": bson.M{"awardedat": 1}},
+		{"$group": bson.M{
+			"_id":      bson.M{"badgeid": "$badgeid", "userid": "$userid"},
+			"awardids": bson.M{"$push": "$_id"},
+			"count":    bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}).AllowDiskUse().All(&duplicates)
+	if err != nil {
+		panic(err)
+	}
+	for _, duplicate := range duplicates {
+		_, err = helpers.MdbCollection(models.ProfileBadgeAwardsTable).RemoveAll(bson.M{
+			"_id": bson.M{"$in": duplicate.AwardIDs[1:]},
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(duplicates) > 0 {
+		cache.GetLogger().WithField("module", "migrations").Infof("removed duplicate awards of %d badges", len(duplicates))
+	}
+
+	err = helpers.MdbCollection(models.ProfileBadgeAwardsTable).EnsureIndex(mgo.Index{
+		Key:        []string{"badgeid", "userid"},
+		Unique:     true,
+		Background: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -20,6 +20,9 @@ var migrations = []helpers.Callback{
 	m51_reindex_elasticv5_to_v6,
 	m52_create_elastic_index_voice_sessions,
 	m55_create_elastic_index_eventlogs,
+	m56_reindex_elastic_messages_content,
+	m57_create_mongodb_storage_indexes,
+	m58_create_mongodb_badge_award_indexes,
 }
 
 // Run executes all registered migrations
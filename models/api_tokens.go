@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	ApiTokensTable        MongoDbCollection = "api_tokens"
+	ApiTokenRequestsTable MongoDbCollection = "api_token_requests"
+
+	// {token id}:{unix minute} resolves to the number of requests in that minute
+	ApiTokenRatelimitRedisKey = "robyul2-discord:api:token-ratelimit:%s:%d"
+	// {session token} resolves to ApiSessionRedisEntry
+	ApiSessionRedisKey = "robyul2-discord:api:session:%s"
+	// {state} is set while a login using that state is pending
+	ApiOAuth2StateRedisKey = "robyul2-discord:api:oauth2-state:%s"
+
+	ApiScopeGuildRead     = "guild:read"
+	ApiScopeStatsRead     = "stats:read"
+	ApiScopeEventlogRead  = "eventlog:read"
+	ApiScopeChatlogRead   = "chatlog:read"
+	ApiScopeSettingsWrite = "settings:write"
+)
+
+var ApiScopes = []string{
+	ApiScopeGuildRead,
+	ApiScopeStatsRead,
+	ApiScopeEventlogRead,
+	ApiScopeChatlogRead,
+	ApiScopeSettingsWrite,
+}
+
+type ApiTokenEntry struct {
+	ID              bson.ObjectId `bson:"_id,omitempty"`
+	GuildID         string
+	Name            string
+	TokenHash       string // hex encoded sha256 of the token, the token itself is never stored
+	Scopes          []string
+	CreatedByUserID string
+	CreatedAt       time.Time
+	LastUsedAt      time.Time
+	Revoked         bool
+	RevokedByUserID string
+	RevokedAt       time.Time
+}
+
+type ApiTokenRequestEntry struct {
+	ID         bson.ObjectId `bson:"_id,omitempty"`
+	TokenID    string
+	GuildID    string
+	Method     string
+	Path       string
+	StatusCode int
+	RemoteAddr string
+	CreatedAt  time.Time
+}
+
+type ApiSessionRedisEntry struct {
+	UserID    string
+	CreatedAt time.Time
+}
@@ -10,6 +10,17 @@ const (
 	BiasGameTable            MongoDbCollection = "biasgame"
 	BiasGameSuggestionsTable MongoDbCollection = "biasgame_suggestions"
 	BiasGameIdolsTable       MongoDbCollection = "biasgame_idols"
+	BiasGameRatingsTable     MongoDbCollection = "biasgame_ratings"
+	BiasGameHeadToHeadTable  MongoDbCollection = "biasgame_headtohead"
+	BiasGamePoolsTable       MongoDbCollection = "biasgame_pools"
+	BiasGameTournamentsTable MongoDbCollection = "biasgame_tournaments"
+	BiasGameQuizScoresTable  MongoDbCollection = "biasgame_quiz_scores"
+	// daily snapshots of the global ratings
+	BiasGameRatingHistoryTable MongoDbCollection = "biasgame_rating_history"
+
+	BiasGameRatingScopeGlobal = "global"
+	BiasGameRatingScopeGuild  = "guild"
+	BiasGameRatingScopeUser   = "user"
 )
 
 type BiasGameIdolEntry struct {
@@ -30,7 +41,7 @@ type BiasGameEntry struct {
 	RoundWinners []BiasGameIdolEntry
 	RoundLosers  []BiasGameIdolEntry
 	Gender       string // girl, boy, mixed
-	GameType     string // single, multi
+	GameType     string // single, multi, tournament
 }
 
 type BiasGameSuggestionEntry struct {
@@ -50,3 +61,82 @@ type BiasGameSuggestionEntry struct {
 	ImageHashString   string
 	ObjectName        string
 }
+
+// BiasGameRatingEntry is the Elo rating of an idol, globally or for a guild or user
+type BiasGameRatingEntry struct {
+	ID        bson.ObjectId `bson:"_id,omitempty"`
+	Scope     string        // BiasGameRatingScopeGlobal, BiasGameRatingScopeGuild, or BiasGameRatingScopeUser
+	ScopeID   string        // guild or user id, empty for global ratings
+	GroupName string
+	Name      string
+	Gender    string
+	Rating    float64
+	Wins      int // rounds won
+	Losses    int // rounds lost
+	UpdatedAt time.Time
+}
+
+type BiasGameRatingHistoryEntry struct {
+	ID        bson.ObjectId `bson:"_id,omitempty"`
+	GroupName string
+	Name      string
+	Day       string // 2006-01-02
+	Rating    float64
+}
+
+// BiasGameHeadToHeadEntry counts the rounds between two idols, idol A is always the idol sorting first
+type BiasGameHeadToHeadEntry struct {
+	ID         bson.ObjectId `bson:"_id,omitempty"`
+	GroupNameA string
+	NameA      string
+	GroupNameB string
+	NameB      string
+	WinsA      int
+	WinsB      int
+}
+
+// BiasGamePoolEntry is the server curated list of idols used by games with the server pool
+type BiasGamePoolEntry struct {
+	ID      bson.ObjectId `bson:"_id,omitempty"`
+	GuildID string
+	Idols   []BiasGamePoolIdol
+}
+
+type BiasGamePoolIdol struct {
+	GroupName string
+	Name      string // empty adds the whole group
+}
+
+// BiasGameTournamentEntry is a server wide bracket playing one match per day
+type BiasGameTournamentEntry struct {
+	ID                 bson.ObjectId `bson:"_id,omitempty"`
+	GuildID            string
+	ChannelID          string
+	StartedByUserID    string
+	Gender             string
+	Entrants           []BiasGameTournamentIdol // in bracket order
+	RoundWinners       []BiasGameIdolEntry
+	RoundLosers        []BiasGameIdolEntry
+	CurrentMessageID   string
+	CurrentMatchEndsAt time.Time
+	Running            bool
+	StartedAt          time.Time
+}
+
+type BiasGameTournamentIdol struct {
+	GroupName  string
+	Name       string
+	Gender     string
+	ImageIndex int // the same image is used throughout the tournament
+}
+
+// BiasGameQuizScoreEntry is the quiz leaderboard entry of a user on a server
+type BiasGameQuizScoreEntry struct {
+	ID             bson.ObjectId `bson:"_id,omitempty"`
+	GuildID        string
+	UserID         string
+	Points         int
+	CorrectAnswers int
+	BestStreak     int
+	GamesPlayed    int
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	BirthdayAnnouncementsTable MongoDbCollection = "birthday_announcements"
+)
+
+// BirthdayAnnouncementEntry prevents announcing the same birthday twice on a guild
+type BirthdayAnnouncementEntry struct {
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	GuildID     string
+	UserID      string
+	Year        int
+	AnnouncedAt time.Time
+	MessageIDs  []string
+}
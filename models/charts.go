@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	ChartsSnapshotsTable     MongoDbCollection = "charts_snapshots"
+	ChartsPeaksTable         MongoDbCollection = "charts_peaks"
+	ChartsSubscriptionsTable MongoDbCollection = "charts_subscriptions"
+
+	ChartsSubscriptionTypeArtist = "artist"
+	ChartsSubscriptionTypeSong   = "song"
+)
+
+// ChartsSnapshotEntry is a chart at one point in time, a new snapshot is only stored if the chart time changed
+type ChartsSnapshotEntry struct {
+	ID        bson.ObjectId `bson:"_id,omitempty"`
+	Chart     string        // melon-realtime, ichart-weekly, gaon-monthly, …
+	ChartTime string        // as shown on the chart
+	CreatedAt time.Time
+	Ranks     []ChartsRank
+}
+
+type ChartsRank struct {
+	Rank   int
+	Title  string // album name for album charts
+	Artist string
+	Album  string
+}
+
+// ChartsPeakEntry is the best rank a song ever reached on a chart
+type ChartsPeakEntry struct {
+	ID    bson.ObjectId `bson:"_id,omitempty"`
+	Chart string
+	Key   string // see helpers.ChartsRankKey
+	Peak  int
+}
+
+type ChartsSubscriptionEntry struct {
+	ID            bson.ObjectId `bson:"_id,omitempty"`
+	GuildID       string
+	ChannelID     string
+	AddedByUserID string
+	Type          string // ChartsSubscriptionTypeArtist or ChartsSubscriptionTypeSong
+	Query         string
+	CreatedAt     time.Time
+}
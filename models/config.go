@@ -42,6 +42,8 @@ type Config struct {
 	LevelsNotificationDeleteAfter int
 	LevelsMaxBadges               int
 
+	RepCooldownHours int // 0 uses the default cooldown
+
 	MutedMembers []string // deprecated
 
 	TroublemakerIsParticipating bool
@@ -55,9 +57,16 @@ type Config struct {
 	StarboardEmoji     []string
 
 	ChatlogDisabled bool
+	// days to keep the documents of the chatlog and statistics indexes for, 0 keeps them forever
+	ChatlogRetentionMessagesDays      int
+	ChatlogRetentionJoinsDays         int
+	ChatlogRetentionLeavesDays        int
+	ChatlogRetentionVoiceSessionsDays int
 
-	EventlogDisabled   bool
-	EventlogChannelIDs []string
+	EventlogDisabled          bool
+	EventlogChannelIDs        []string
+	EventlogIgnoredChannelIDs []string
+	EventlogRoutes            []EventlogRoute
 
 	PersistencyBiasEnabled bool
 	PersistencyRoleIDs     []string
@@ -73,6 +82,10 @@ type Config struct {
 
 	AdminRoleIDs []string
 	ModRoleIDs   []string
+
+	BirthdaysChannelID string
+	BirthdaysCode      string
+	BirthdaysRoleID    string
 }
 
 type InspectTriggersEnabled struct {
@@ -90,6 +103,15 @@ type DelayedAutoRole struct {
 	Delay  time.Duration
 }
 
+// EventlogRoute sends matching events to ChannelID, if no ActionTypes and Categories are set all events match
+type EventlogRoute struct {
+	ChannelID       string
+	ActionTypes     []string // lowercase
+	Categories      []string
+	TargetIDs       []string // if set only events for these targets match
+	ExcludedUserIDs []string // events by these users never match
+}
+
 // Default is a helper for generating default config values
 func (c Config) Default(guild string) Config {
 	return Config{
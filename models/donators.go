@@ -13,6 +13,7 @@ const (
 type DonatorEntry struct {
 	ID            bson.ObjectId `bson:"_id,omitempty"`
 	Name          string
+	UserID        string
 	HeartOverride string
 	AddedAt       time.Time
 }
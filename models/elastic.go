@@ -12,6 +12,51 @@ const (
 	ElasticIndexEventlogs          = "robyul-eventlogs"
 )
 
+// ElasticMessagesMapping is the mapping of the messages index, Content is indexed for the message search
+var ElasticMessagesMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"doc": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"CreatedAt": map[string]interface{}{
+					"type": "date",
+				},
+				"MessageID": map[string]interface{}{
+					"type": "text",
+					"fields": map[string]interface{}{
+						"keyword": map[string]interface{}{
+							"type": "keyword",
+						},
+					},
+				},
+				"Content": map[string]interface{}{
+					"type": "text",
+				},
+				"ContentLength": map[string]interface{}{
+					"type": "long",
+				},
+				"Attachments": map[string]interface{}{
+					"type": "text",
+				},
+				"UserID": map[string]interface{}{
+					"type": "text",
+				},
+				"GuildID": map[string]interface{}{
+					"type": "text",
+				},
+				"ChannelID": map[string]interface{}{
+					"type": "text",
+				},
+				"Embeds": map[string]interface{}{
+					"type": "integer",
+				},
+				"Deleted": map[string]interface{}{
+					"type": "boolean",
+				},
+			},
+		},
+	},
+}
+
 type ElasticLegacyMessage struct {
 	CreatedAt     time.Time
 	MessageID     string
@@ -38,11 +83,13 @@ type ElasticMessage struct {
 }
 
 type ElasticJoin struct {
-	CreatedAt      time.Time
-	GuildID        string
-	UserID         string
-	UsedInviteCode string
-	VanityInvite   string
+	CreatedAt            time.Time
+	GuildID              string
+	UserID               string
+	UsedInviteCode       string
+	VanityInvite         string
+	VanityInviteReferer  string // referer of the last vanity invite click before the join
+	VanityInviteCampaign string // campaign of the last vanity invite click before the join
 }
 
 type ElasticLeave struct {
@@ -66,6 +113,7 @@ type ElasticVanityInviteClick struct {
 	VanityInviteName string
 	GuildID          string
 	Referer          string
+	Campaign         string // ?c= parameter of the vanity url
 }
 
 type ElasticVoiceSession struct {
@@ -19,6 +19,10 @@ const (
 
 	EventlogTypeInvitePosted = "Invite_Posted" // EvenlogTargetTypeGuild
 
+	EventlogTypeMessageUpdate     = "Message_Update"      // EventlogTargetTypeMessage
+	EventlogTypeMessageDelete     = "Message_Delete"      // EventlogTargetTypeMessage
+	EventlogTypeMessageDeleteBulk = "Message_Delete_Bulk" // EventlogTargetTypeChannel
+
 	EventlogTargetTypeUser       = "user"
 	EventlogTargetTypeChannel    = "channel"
 	EventlogTargetTypeRole       = "role"
@@ -27,10 +31,13 @@ const (
 	EventlogTargetTypeMessage    = "message"
 	EventlogTargetTypeInviteCode = "invite_code"
 
+	EventlogTargetTypeMessageContent = "message_content"
+
 	EventlogTypeRobyulBadgeCreate                   = "Robyul_Badge_Create"                    // EventlogTargetTypeRobyulBadge
 	EventlogTypeRobyulBadgeDelete                   = "Robyul_Badge_Delete"                    // EventlogTargetTypeRobyulBadge
 	EventlogTypeRobyulBadgeAllow                    = "Robyul_Badge_Allow"                     // EventlogTargetTypeRobyulBadge
 	EventlogTypeRobyulBadgeDeny                     = "Robyul_Badge_Deny"                      // EventlogTargetTypeRobyulBadge
+	EventlogTypeRobyulBadgeCriteria                 = "Robyul_Badge_Criteria"                  // EventlogTargetTypeRobyulBadge
 	EventlogTypeRobyulLevelsReset                   = "Robyul_Levels_Reset"                    // EventlogTargetTypeUser
 	EventlogTypeRobyulLevelsIgnoreUser              = "Robyul_Levels_Ignore_User"              // EventlogTargetTypeUser
 	EventlogTypeRobyulLevelsIgnoreChannel           = "Robyul_Levels_Ignore_Channel"           // EventlogTargetTypeChannel
@@ -40,6 +47,7 @@ const (
 	EventlogTypeRobyulLevelsRoleDelete              = "Robyul_Levels_Role_Delete"              // EventlogTargetTypeRole
 	EventlogTypeRobyulLevelsRoleGrant               = "Robyul_Levels_Role_Grant"               // EventlogTargetTypeUser
 	EventlogTypeRobyulLevelsRoleDeny                = "Robyul_Levels_Role_Deny"                // EventlogTargetTypeUser
+	EventlogTypeRobyulRepCooldownSet                = "Robyul_Rep_Cooldown_Set"                // EventlogTargetTypeGuild
 	EventlogTypeRobyulNotificationsChannelIgnore    = "Robyul_Notifications_Channel_Ignore"    // EventlogTargetTypeChannel
 	EventlogTypeRobyulVliveFeedAdd                  = "Robyul_Vlive_Feed_Add"                  // EventlogTargetTypeRobyulVliveFeed
 	EventlogTypeRobyulVliveFeedRemove               = "Robyul_Vlive_Feed_Remove"               // EventlogTargetTypeRobyulVliveFeed
@@ -62,6 +70,7 @@ const (
 	EventlogTypeRobyulAutoInspectsChannel           = "Robyul_AutoInspectsChannel"             // EventlogTargetTypeChannel
 	EventlogTypeRobyulPrefixUpdate                  = "Robyul_Prefix_Update"                   // EventlogTargetTypeGuild
 	EventlogTypeRobyulChatlogUpdate                 = "Robyul_Chatlog_Update"                  // EventlogTargetTypeGuild
+	EventlogTypeRobyulChatlogRetentionUpdate        = "Robyul_Chatlog_Retention_Update"        // EventlogTargetTypeGuild
 	EventlogTypeRobyulVanityInviteCreate            = "Robyul_VanityInvite_Create"             // EventlogTargetTypeGuild
 	EventlogTypeRobyulVanityInviteDelete            = "Robyul_VanityInvite_Delete"             // EventlogTargetTypeGuild
 	EventlogTypeRobyulVanityInviteUpdate            = "Robyul_VanityInvite_Update"             // EventlogTargetTypeGuild
@@ -76,8 +85,10 @@ const (
 	EventlogTypeRobyulGuildAnnouncementsLeaveSet    = "Robyul_GuildAnnouncements_Leave_Set"    // EventlogTargetTypeChannel
 	EventlogTypeRobyulGuildAnnouncementsLeaveRemove = "Robyul_GuildAnnouncements_Leave_Remove" // EventlogTargetTypeChannel
 	EventlogTypeRobyulGuildAnnouncementsBanSet      = "Robyul_GuildAnnouncements_Ban_Set"      // EventlogTargetTypeChannel
+	EventlogTypeRobyulGuildAnnouncementsBanRemove   = "Robyul_GuildAnnouncements_Ban_Remove"   // EventlogTargetTypeChannel
 	EventlogTypeRobyulGalleryAdd                    = "Robyul_Gallery_Add"                     // EventlogTargetTypeRobyulGallery
 	EventlogTypeRobyulGalleryRemove                 = "Robyul_Gallery_Remove"                  // EventlogTargetTypeRobyulGallery
+	EventlogTypeRobyulGalleryUpdate                 = "Robyul_Gallery_Update"                  // EventlogTargetTypeRobyulGallery
 	EventlogTypeRobyulMirrorCreate                  = "Robyul_Mirror_Create"                   // EventlogTargetTypeRobyulMirror
 	EventlogTypeRobyulMirrorDelete                  = "Robyul_Mirror_Delete"                   // EventlogTargetTypeRobyulMirror
 	EventlogTypeRobyulMirrorUpdate                  = "Robyul_Mirror_Update"                   // EventlogTargetTypeRobyulMirror
@@ -111,6 +122,17 @@ const (
 	EventlogTypeRobyulEventlogConfigUpdate          = "Robyul_Module_Eventlog_Config_Update"   // EventlogTargetTypeGuild
 	EventlogTypeRobyulTwitterFeedAdd                = "Robyul_Twitter_Feed_Add"                // EventlogTargetTypeRobyulTwitterFeed
 	EventlogTypeRobyulTwitterFeedRemove             = "Robyul_Twitter_Feed_Remove"             // EventlogTargetTypeRobyulTwitterFeed
+	EventlogTypeRobyulGiveawayStart                 = "Robyul_Giveaway_Start"                  // EventlogTargetTypeRobyulGiveaway
+	EventlogTypeRobyulGiveawayEnd                   = "Robyul_Giveaway_End"                    // EventlogTargetTypeRobyulGiveaway
+	EventlogTypeRobyulGiveawayReroll                = "Robyul_Giveaway_Reroll"                 // EventlogTargetTypeRobyulGiveaway
+	EventlogTypeRobyulScheduledPostCreate           = "Robyul_ScheduledPost_Create"            // EventlogTargetTypeRobyulScheduledPost
+	EventlogTypeRobyulScheduledPostDelete           = "Robyul_ScheduledPost_Delete"            // EventlogTargetTypeRobyulScheduledPost
+	EventlogTypeRobyulIdolCalendarSubscribe         = "Robyul_IdolCalendar_Subscribe"          // EventlogTargetTypeRobyulIdolCalendar
+	EventlogTypeRobyulIdolCalendarUnsubscribe       = "Robyul_IdolCalendar_Unsubscribe"        // EventlogTargetTypeRobyulIdolCalendar
+	EventlogTypeRobyulApiTokenCreate                = "Robyul_ApiToken_Create"                 // EventlogTargetTypeRobyulApiToken
+	EventlogTypeRobyulApiTokenRevoke                = "Robyul_ApiToken_Revoke"                 // EventlogTargetTypeRobyulApiToken
+	EventlogTypeRobyulOutgoingWebhookAdd            = "Robyul_OutgoingWebhook_Add"             // EventlogTargetTypeRobyulOutgoingWebhook
+	EventlogTypeRobyulOutgoingWebhookRemove         = "Robyul_OutgoingWebhook_Remove"          // EventlogTargetTypeRobyulOutgoingWebhook
 
 	EventlogTargetTypeRobyulBadge               = "robyul-badge"
 	EventlogTargetTypeRobyulVliveFeed           = "robyul-vlive-feed"
@@ -125,10 +147,158 @@ const (
 	EventlogTargetTypeRobyulTwitterFeed         = "robyul-twitter-feed"
 	EventlogTargetTypeRobyulPublicObject        = "robyul-public-object"
 	EventlogTargetTypeRobyulMirrorType          = "robyul-mirror-type"
+	EventlogTargetTypeRobyulGiveaway            = "robyul-giveaway"
+	EventlogTargetTypeRobyulScheduledPost       = "robyul-scheduled-post"
+	EventlogTargetTypeRobyulIdolCalendar        = "robyul-idol-calendar"
+	EventlogTargetTypeRobyulApiToken            = "robyul-api-token"
+	EventlogTargetTypeRobyulOutgoingWebhook     = "robyul-outgoing-webhook"
 
 	AuditLogBackfillRedisList = "robyul-discord:eventlog:auditlog-backfills:v2"
 )
 
+const (
+	EventlogCategoryMembers    = "members"
+	EventlogCategoryRoles      = "roles"
+	EventlogCategoryChannels   = "channels"
+	EventlogCategoryModeration = "moderation"
+	EventlogCategoryMessages   = "messages"
+	EventlogCategoryServer     = "server"
+	EventlogCategoryRobyul     = "robyul"
+)
+
+// EventlogTypes are all known event types
+var EventlogTypes = []string{
+	EventlogTypeMemberJoin,
+	EventlogTypeMemberLeave,
+	EventlogTypeChannelCreate,
+	EventlogTypeChannelDelete,
+	EventlogTypeChannelUpdate,
+	EventlogTypeRoleCreate,
+	EventlogTypeRoleDelete,
+	EventlogTypeBanAdd,
+	EventlogTypeBanRemove,
+	EventlogTypeEmojiCreate,
+	EventlogTypeEmojiDelete,
+	EventlogTypeEmojiUpdate,
+	EventlogTypeGuildUpdate,
+	EventlogTypeMemberUpdate,
+	EventlogTypeRoleUpdate,
+	EventlogTypeInvitePosted,
+	EventlogTypeMessageUpdate,
+	EventlogTypeMessageDelete,
+	EventlogTypeMessageDeleteBulk,
+	EventlogTypeRobyulBadgeCreate,
+	EventlogTypeRobyulBadgeDelete,
+	EventlogTypeRobyulBadgeAllow,
+	EventlogTypeRobyulBadgeDeny,
+	EventlogTypeRobyulBadgeCriteria,
+	EventlogTypeRobyulLevelsReset,
+	EventlogTypeRobyulLevelsIgnoreUser,
+	EventlogTypeRobyulLevelsIgnoreChannel,
+	EventlogTypeRobyulLevelsProcessedHistory,
+	EventlogTypeRobyulLevelsRoleAdd,
+	EventlogTypeRobyulLevelsRoleApply,
+	EventlogTypeRobyulLevelsRoleDelete,
+	EventlogTypeRobyulLevelsRoleGrant,
+	EventlogTypeRobyulLevelsRoleDeny,
+	EventlogTypeRobyulRepCooldownSet,
+	EventlogTypeRobyulNotificationsChannelIgnore,
+	EventlogTypeRobyulVliveFeedAdd,
+	EventlogTypeRobyulVliveFeedRemove,
+	EventlogTypeRobyulYouTubeChannelFeedAdd,
+	EventlogTypeRobyulYouTubeChannelFeedRemove,
+	EventlogTypeRobyulInstagramFeedAdd,
+	EventlogTypeRobyulInstagramFeedRemove,
+	EventlogTypeRobyulInstagramFeedUpdate,
+	EventlogTypeRobyulRedditFeedAdd,
+	EventlogTypeRobyulRedditFeedRemove,
+	EventlogTypeRobyulRedditFeedUpdate,
+	EventlogTypeRobyulFacebookFeedAdd,
+	EventlogTypeRobyulFacebookFeedRemove,
+	EventlogTypeRobyulCleanup,
+	EventlogTypeRobyulMute,
+	EventlogTypeRobyulUnmute,
+	EventlogTypeRobyulPostCreate,
+	EventlogTypeRobyulPostUpdate,
+	EventlogTypeRobyulBatchRolesCreate,
+	EventlogTypeRobyulAutoInspectsChannel,
+	EventlogTypeRobyulPrefixUpdate,
+	EventlogTypeRobyulChatlogUpdate,
+	EventlogTypeRobyulChatlogRetentionUpdate,
+	EventlogTypeRobyulVanityInviteCreate,
+	EventlogTypeRobyulVanityInviteDelete,
+	EventlogTypeRobyulVanityInviteUpdate,
+	EventlogTypeRobyulBiasConfigCreate,
+	EventlogTypeRobyulBiasConfigDelete,
+	EventlogTypeRobyulBiasConfigUpdate,
+	EventlogTypeRobyulAutoroleAdd,
+	EventlogTypeRobyulAutoroleRemove,
+	EventlogTypeRobyulAutoroleApply,
+	EventlogTypeRobyulGuildAnnouncementsJoinSet,
+	EventlogTypeRobyulGuildAnnouncementsJoinRemove,
+	EventlogTypeRobyulGuildAnnouncementsLeaveSet,
+	EventlogTypeRobyulGuildAnnouncementsLeaveRemove,
+	EventlogTypeRobyulGuildAnnouncementsBanSet,
+	EventlogTypeRobyulGuildAnnouncementsBanRemove,
+	EventlogTypeRobyulGalleryAdd,
+	EventlogTypeRobyulGalleryRemove,
+	EventlogTypeRobyulGalleryUpdate,
+	EventlogTypeRobyulMirrorCreate,
+	EventlogTypeRobyulMirrorDelete,
+	EventlogTypeRobyulMirrorUpdate,
+	EventlogTypeRobyulStarboardCreate,
+	EventlogTypeRobyulStarboardDelete,
+	EventlogTypeRobyulStarboardUpdate,
+	EventlogTypeRobyulRandomPictureSourceCreate,
+	EventlogTypeRobyulRandomPictureConfigUpdate,
+	EventlogTypeRobyulRandomPictureSourceRemove,
+	EventlogTypeRobyulCommandsAdd,
+	EventlogTypeRobyulCommandsDelete,
+	EventlogTypeRobyulCommandsUpdate,
+	EventlogTypeRobyulCommandsJsonExport,
+	EventlogTypeRobyulCommandsJsonImport,
+	EventlogTypeRobyulTwitchFeedAdd,
+	EventlogTypeRobyulTwitchFeedRemove,
+	EventlogTypeRobyulNukeParticipate,
+	EventlogTypeRobyulTroublemakerParticipate,
+	EventlogTypeRobyulTroublemakerReport,
+	EventlogTypeRobyulPersistencyBiasRoles,
+	EventlogTypeRobyulPersistencyRoleAdd,
+	EventlogTypeRobyulPersistencyRoleRemove,
+	EventlogTypeRobyulModuleAllowRoleAdd,
+	EventlogTypeRobyulModuleAllowRoleRemove,
+	EventlogTypeRobyulModuleAllowChannelAdd,
+	EventlogTypeRobyulModuleAllowChannelRemove,
+	EventlogTypeRobyulModuleDenyRoleAdd,
+	EventlogTypeRobyulModuleDenyRoleRemove,
+	EventlogTypeRobyulModuleDenyChannelAdd,
+	EventlogTypeRobyulModuleDenyChannelRemove,
+	EventlogTypeRobyulEventlogConfigUpdate,
+	EventlogTypeRobyulTwitterFeedAdd,
+	EventlogTypeRobyulTwitterFeedRemove,
+	EventlogTypeRobyulGiveawayStart,
+	EventlogTypeRobyulGiveawayEnd,
+	EventlogTypeRobyulGiveawayReroll,
+	EventlogTypeRobyulScheduledPostCreate,
+	EventlogTypeRobyulScheduledPostDelete,
+	EventlogTypeRobyulIdolCalendarSubscribe,
+	EventlogTypeRobyulIdolCalendarUnsubscribe,
+	EventlogTypeRobyulApiTokenCreate,
+	EventlogTypeRobyulApiTokenRevoke,
+	EventlogTypeRobyulOutgoingWebhookAdd,
+	EventlogTypeRobyulOutgoingWebhookRemove,
+}
+
+var EventlogCategories = []string{
+	EventlogCategoryMembers,
+	EventlogCategoryRoles,
+	EventlogCategoryChannels,
+	EventlogCategoryModeration,
+	EventlogCategoryMessages,
+	EventlogCategoryServer,
+	EventlogCategoryRobyul,
+}
+
 type AuditLogBackfillType int
 
 const (
@@ -147,6 +317,7 @@ const (
 	AuditLogBackfillTypeRoleUpdate
 	AuditlogBackfillTypeMemberRoleUpdate
 	AuditlogBackfillTypeMemberUpdate
+	AuditLogBackfillTypeMessageDelete
 )
 
 type AuditLogBackfillRequest struct {
@@ -1,17 +1,53 @@
 package models
 
 import (
+	"time"
+
 	"github.com/globalsign/mgo/bson"
 )
 
 const (
-	GalleryTable MongoDbCollection = "galleries"
+	GalleryTable       MongoDbCollection = "galleries"
+	GalleryHashesTable MongoDbCollection = "gallery_hashes"
+
+	GalleryMediaTypeImage = "image"
+	GalleryMediaTypeVideo = "video"
+	GalleryMediaTypeGif   = "gif"
+	GalleryMediaTypeLink  = "link"
+	// attachments which are neither images, videos, nor gifs, only posted if a gallery allows every media type
+	GalleryMediaTypeFile = "file"
+
+	// {source message id}, list of the posts of the message
+	GalleryPostedMessagesRedisKey = "robyul2-discord:gallery:postedmessage:%s"
 )
 
+var GalleryMediaTypes = []string{
+	GalleryMediaTypeImage,
+	GalleryMediaTypeVideo,
+	GalleryMediaTypeGif,
+	GalleryMediaTypeLink,
+}
+
 type GalleryEntry struct {
-	ID              bson.ObjectId `bson:"_id,omitempty"`
-	SourceChannelID string
-	TargetChannelID string
-	GuildID         string
-	AddedByUserID   string
+	ID                 bson.ObjectId `bson:"_id,omitempty"`
+	SourceChannelID    string
+	TargetChannelID    string // galleries created before multiple targets, use helpers.GetGalleryTargetChannelIDs
+	TargetChannelIDs   []string
+	GuildID            string
+	AddedByUserID      string
+	MediaTypes         []string // empty allows every media type
+	LinkDomains        []string // if set only links to these domains and their subdomains are posted
+	MinWidth           int      // minimum dimensions of images, videos, and gifs
+	MinHeight          int
+	SuppressDuplicates bool
+	KeepOnSourceDelete bool
+}
+
+// GalleryHashEntry is a posted image or link of a gallery, used to detect duplicates
+type GalleryHashEntry struct {
+	ID        bson.ObjectId `bson:"_id,omitempty"`
+	GalleryID string
+	ImageHash string // average hash of images, empty for other media types
+	URL       string // the normalized URL of links
+	CreatedAt time.Time
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	GiveawaysTable MongoDbCollection = "giveaways"
+)
+
+type GiveawayEntry struct {
+	ID              bson.ObjectId `bson:"_id,omitempty"`
+	GuildID         string
+	ChannelID       string
+	MessageID       string
+	CreatedByUserID string
+	CreatedAt       time.Time
+	EndsAt          time.Time
+	EndedAt         time.Time
+	Prize           string
+	WinnersCount    int
+	Requirements    GiveawayRequirements
+	Active          bool
+	EntrantUserIDs  []string
+	WinnerUserIDs   []string
+	Draws           []GiveawayDraw
+}
+
+type GiveawayRequirements struct {
+	MinimumLevel        int
+	RoleID              string
+	MinimumAccountAge   time.Duration
+	MinimumServerTenure time.Duration
+}
+
+// GiveawayDraw stores everything needed to reproduce a winner drawing
+type GiveawayDraw struct {
+	DrawnAt         time.Time
+	DrawnByUserID   string
+	Seed            string
+	EntrantsHash    string
+	EntrantsCount   int
+	ExcludedUserIDs []string
+	WinnerUserIDs   []string
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	IdolCalendarEventsTable        MongoDbCollection = "idol_calendar_events"
+	IdolCalendarSubscriptionsTable MongoDbCollection = "idol_calendar_subscriptions"
+)
+
+const (
+	IdolCalendarEventTypeBirthday = "birthday" // yearly, IdolName is set
+	IdolCalendarEventTypeDebut    = "debut"    // yearly
+	IdolCalendarEventTypeRelease  = "release"  // once, Title is set
+)
+
+type IdolCalendarEventEntry struct {
+	ID            bson.ObjectId `bson:"_id,omitempty"`
+	Type          string
+	GroupName     string
+	IdolName      string // empty for group events
+	Title         string
+	Date          time.Time // the year is the year of birth or debut for yearly events
+	AddedByUserID string
+	AddedAt       time.Time
+}
+
+type IdolCalendarSubscriptionEntry struct {
+	ID             bson.ObjectId `bson:"_id,omitempty"`
+	GuildID        string
+	ChannelID      string
+	GroupNames     []string
+	AddedByUserID  string
+	AddedAt        time.Time
+	LastPostedDate string // 2006-01-02 in KST
+}
@@ -1,15 +1,28 @@
 package models
 
 import (
+	"time"
+
 	"github.com/globalsign/mgo/bson"
 )
 
 const (
-	LastFmTable MongoDbCollection = "lastfm"
+	LastFmTable             MongoDbCollection = "lastfm"
+	LastFmGuildConfigsTable MongoDbCollection = "lastfm_guild_configs"
 )
 
 type LastFmEntry struct {
 	ID             bson.ObjectId `bson:"_id,omitempty"`
 	UserID         string
 	LastFmUsername string
+	HideNowPlaying bool // opt out of the now playing channels
+}
+
+type LastFmGuildConfigEntry struct {
+	ID                  bson.ObjectId `bson:"_id,omitempty"`
+	GuildID             string
+	NowPlayingChannelID string
+	NowPlayingMessageID string // the message kept updated in the now playing channel
+	ChartsChannelID     string
+	ChartsLastPostedAt  time.Time
 }
@@ -7,11 +7,19 @@ const (
 	LevelsRoleOverwritesTable MongoDbCollection = "levels_roles_overwrites"
 )
 
+type LevelsRoleType int
+
+const (
+	LevelsRoleTypeLevel LevelsRoleType = iota
+	LevelsRoleTypeRep
+)
+
 type LevelsRoleEntry struct {
 	ID         bson.ObjectId `bson:"_id,omitempty"`
 	GuildID    string
 	RoleID     string
-	StartLevel int
+	Type       LevelsRoleType
+	StartLevel int // the rep received on the server for rep roles
 	LastLevel  int
 }
 
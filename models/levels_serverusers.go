@@ -11,8 +11,9 @@ const (
 )
 
 type LevelsServerusersEntry struct {
-	ID      bson.ObjectId `bson:"_id,omitempty"`
-	UserID  string
-	GuildID string
-	Exp     int64
+	ID       bson.ObjectId `bson:"_id,omitempty"`
+	UserID   string
+	GuildID  string
+	Exp      int64
+	Messages int64 // messages that earned EXP
 }
@@ -4,6 +4,15 @@ import "github.com/globalsign/mgo/bson"
 
 const (
 	MirrorsTable = "mirrors"
+
+	// {source message id}, list of the mirrored copies of the message
+	MirrorPostedMessagesRedisKey = "robyul2-discord:mirror:postedmessage:%s"
+	// {mirrored message id}, the source message of a mirrored copy
+	MirrorSourceMessageRedisKey = "robyul2-discord:mirror:sourcemessage:%s"
+	// {source message id}, hash of emoji API name to reaction count
+	MirrorReactionsRedisKey = "robyul2-discord:mirror:reactions:%s"
+	// {channel id}, counts the messages relayed from the channel in the current rate limit window
+	MirrorRateLimitRedisKey = "robyul2-discord:mirror:ratelimit:%s"
 )
 
 type MirrorType int
@@ -17,6 +26,8 @@ type MirrorEntry struct {
 	ID                bson.ObjectId `bson:"_id,omitempty"`
 	Type              MirrorType
 	ConnectedChannels []MirrorChannelEntry
+	BlockedUserIDs    []string // messages by these users are not mirrored
+	BridgeReactions   bool     // shows the reaction counts of all copies below mirrored messages, text mirrors only
 }
 
 type MirrorChannelEntry struct {
@@ -1,12 +1,22 @@
 package models
 
 import (
+	"time"
+
 	"github.com/globalsign/mgo/bson"
 )
 
 const (
 	NotificationsTable                MongoDbCollection = "notifications"
 	NotificationsIgnoredChannelsTable MongoDbCollection = "notifications_ignored_channels"
+	NotificationsDigestTable          MongoDbCollection = "notifications_digest"
+
+	NotificationsMatchModeDefault = ""
+	NotificationsMatchModeWord    = "word"
+	NotificationsMatchModeRegex   = "regex"
+
+	// {user id}:{hour}, counts the notifications sent to the user in the hour
+	NotificationsSentCountRedisKey = "robyul2-discord:notifications:sent:%s:%s"
 )
 
 type NotificationsEntry struct {
@@ -16,6 +26,8 @@ type NotificationsEntry struct {
 	UserID          string
 	Triggered       int
 	IgnoredGuildIDs []string
+	MatchMode       string   // NotificationsMatchModeDefault, NotificationsMatchModeWord, or NotificationsMatchModeRegex
+	ChannelIDs      []string // if set the keyword only triggers in these channels
 }
 
 type NotificationsIgnoredChannelsEntry struct {
@@ -23,3 +35,17 @@ type NotificationsIgnoredChannelsEntry struct {
 	GuildID   string
 	ChannelID string
 }
+
+// NotificationsDigestEntry is a match waiting to be sent in the next digest of the user
+type NotificationsDigestEntry struct {
+	ID         bson.ObjectId `bson:"_id,omitempty"`
+	UserID     string
+	GuildID    string
+	ChannelID  string
+	MessageID  string
+	AuthorID   string
+	AuthorName string
+	Keywords   []string
+	Content    string
+	CreatedAt  time.Time
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	OutgoingWebhooksTable          MongoDbCollection = "outgoing_webhooks"
+	OutgoingWebhookDeliveriesTable MongoDbCollection = "outgoing_webhook_deliveries"
+
+	OutgoingWebhookEventMemberJoin     = "member.join"
+	OutgoingWebhookEventLevelUp        = "levels.levelup"
+	OutgoingWebhookEventStarboardEntry = "starboard.entry"
+	OutgoingWebhookEventFeedPost       = "feed.post"
+	OutgoingWebhookEventWarning        = "moderation.warning"
+	OutgoingWebhookEventBan            = "moderation.ban"
+	OutgoingWebhookEventEventlog       = "eventlog"
+
+	OutgoingWebhookDeliveryStatusPending   = "pending"
+	OutgoingWebhookDeliveryStatusDelivered = "delivered"
+	OutgoingWebhookDeliveryStatusFailed    = "failed"
+
+	// {guild id} or "all", the number of deliveries currently being sent
+	OutgoingWebhookInFlightRedisKey = "robyul2-discord:outgoing-webhooks:in-flight:%s"
+)
+
+var OutgoingWebhookEvents = []string{
+	OutgoingWebhookEventMemberJoin,
+	OutgoingWebhookEventLevelUp,
+	OutgoingWebhookEventStarboardEntry,
+	OutgoingWebhookEventFeedPost,
+	OutgoingWebhookEventWarning,
+	OutgoingWebhookEventBan,
+	OutgoingWebhookEventEventlog,
+}
+
+type OutgoingWebhookEntry struct {
+	ID              bson.ObjectId `bson:"_id,omitempty"`
+	GuildID         string
+	URL             string
+	Secret          string
+	EventTypes      []string
+	CreatedByUserID string
+	CreatedAt       time.Time
+}
+
+type OutgoingWebhookDeliveryEntry struct {
+	ID             bson.ObjectId `bson:"_id,omitempty"`
+	WebhookID      string
+	GuildID        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	LastAttemptAt  time.Time
+}
+
+// OutgoingWebhookPayload is the JSON body sent to the endpoints
+type OutgoingWebhookPayload struct {
+	DeliveryID string      `json:"delivery_id"`
+	Event      string      `json:"event"`
+	GuildID    string      `json:"guild_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Data       interface{} `json:"data"`
+}
+
+type OutgoingWebhookMemberJoinData struct {
+	UserID               string `json:"user_id"`
+	UsedInviteCode       string `json:"used_invite_code,omitempty"`
+	VanityInvite         string `json:"vanity_invite,omitempty"`
+	VanityInviteReferer  string `json:"vanity_invite_referer,omitempty"`
+	VanityInviteCampaign string `json:"vanity_invite_campaign,omitempty"`
+}
+
+type OutgoingWebhookLevelUpData struct {
+	UserID      string `json:"user_id"`
+	ChannelID   string `json:"channel_id"`
+	LevelBefore int    `json:"level_before"`
+	LevelAfter  int    `json:"level_after"`
+}
+
+type OutgoingWebhookStarboardEntryData struct {
+	MessageID          string   `json:"message_id"`
+	ChannelID          string   `json:"channel_id"`
+	AuthorID           string   `json:"author_id"`
+	Content            string   `json:"content"`
+	AttachmentURLs     []string `json:"attachment_urls"`
+	Stars              int      `json:"stars"`
+	StarboardMessageID string   `json:"starboard_message_id"`
+}
+
+type OutgoingWebhookFeedPostData struct {
+	Feed      string `json:"feed"`
+	ChannelID string `json:"channel_id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+}
+
+type OutgoingWebhookWarningData struct {
+	UserID         string  `json:"user_id"`
+	ChannelID      string  `json:"channel_id"`
+	Source         string  `json:"source"`
+	SevereToxicity float64 `json:"severe_toxicity"`
+	Inflammatory   float64 `json:"inflammatory"`
+	Obscene        float64 `json:"obscene"`
+}
+
+type OutgoingWebhookEventlogData struct {
+	ActionType string                  `json:"action_type"`
+	TargetID   string                  `json:"target_id"`
+	TargetType string                  `json:"target_type"`
+	UserID     string                  `json:"user_id"`
+	Reason     string                  `json:"reason"`
+	Changes    []ElasticEventlogChange `json:"changes"`
+	Options    []ElasticEventlogOption `json:"options"`
+}
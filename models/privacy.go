@@ -0,0 +1,18 @@
+package models
+
+const (
+	// {user id} is set while the user is not allowed to request another export
+	PrivacyExportRatelimitRedisKey = "robyul2-discord:privacy:export-ratelimit:%s"
+
+	RetentionIndexMessages      = "messages"
+	RetentionIndexJoins         = "joins"
+	RetentionIndexLeaves        = "leaves"
+	RetentionIndexVoiceSessions = "voice"
+)
+
+var RetentionIndexes = []string{
+	RetentionIndexMessages,
+	RetentionIndexJoins,
+	RetentionIndexLeaves,
+	RetentionIndexVoiceSessions,
+}
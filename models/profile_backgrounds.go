@@ -8,6 +8,9 @@ import (
 
 const (
 	ProfileBackgroundsTable MongoDbCollection = "profile_backgrounds"
+
+	// the number of users using each background, cached for the gallery and the API
+	ProfileBackgroundUsageCountsRedisKey = "robyul2-discord:profile:background-usage-counts"
 )
 
 type ProfileBackgroundEntry struct {
@@ -17,4 +20,5 @@ type ProfileBackgroundEntry struct {
 	ObjectName string
 	CreatedAt  time.Time
 	Tags       []string
+	GuildID    string // set for server exclusive backgrounds, only usable on that server
 }
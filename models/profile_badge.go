@@ -9,7 +9,18 @@ import (
 )
 
 const (
-	ProfileBadgesTable MongoDbCollection = "profile_badges"
+	ProfileBadgesTable      MongoDbCollection = "profile_badges"
+	ProfileBadgeAwardsTable MongoDbCollection = "profile_badge_awards"
+)
+
+const (
+	ProfileBadgeMetricMessages        = "messages" // LevelsServerusersEntry.Messages, only counted since automatic badges have been added
+	ProfileBadgeMetricRep             = "rep"
+	ProfileBadgeMetricTenureDays      = "tenure-days"
+	ProfileBadgeMetricStarboard       = "starboard"
+	ProfileBadgeMetricBiasgameGames   = "biasgame-games"
+	ProfileBadgeMetricLastFmScrobbles = "lastfm-scrobbles"
+	ProfileBadgeMetricDonator         = "donator"
 )
 
 type ProfileBadgeEntry struct {
@@ -27,6 +38,21 @@ type ProfileBadgeEntry struct {
 	RoleRequirement  string
 	AllowedUserIDs   []string
 	DeniedUserIDs    []string
+	Criteria         []ProfileBadgeCriterion // all criteria have to be met, badges with criteria are awarded automatically
+}
+
+type ProfileBadgeCriterion struct {
+	Metric    string
+	Threshold int64
+}
+
+// ProfileBadgeAwardEntry is created once a user meets all criteria of a badge
+type ProfileBadgeAwardEntry struct {
+	ID        bson.ObjectId `bson:"_id,omitempty"`
+	BadgeID   string
+	UserID    string
+	GuildID   string
+	AwardedAt time.Time
 }
 
 func (e ProfileBadgeEntry) GetID() (ID string) {
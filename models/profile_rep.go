@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	ProfileRepTable MongoDbCollection = "profile_rep"
+)
+
+// ProfileRepEntry is a single reputation point given with the rep command
+// ProfileUserdataEntry.Rep is the total of all servers, including rep given before entries were stored
+type ProfileRepEntry struct {
+	ID             bson.ObjectId `bson:"_id,omitempty"`
+	GuildID        string
+	GiverUserID    string
+	ReceiverUserID string
+	Reason         string
+	CreatedAt      time.Time
+}
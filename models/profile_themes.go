@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	ProfileThemesTable MongoDbCollection = "profile_themes"
+)
+
+const (
+	ProfileThemeKindBackground = "background"
+	ProfileThemeKindTheme      = "theme"
+
+	ProfileThemeStatusPending  = "pending"
+	ProfileThemeStatusApproved = "approved"
+	ProfileThemeStatusRejected = "rejected"
+)
+
+// ProfileThemeEntry is a background or a full theme submitted by a user
+// approved submissions are added to the profile backgrounds with the same name
+type ProfileThemeEntry struct {
+	ID                   bson.ObjectId `bson:"_id,omitempty"`
+	Name                 string
+	Kind                 string
+	Tags                 []string
+	GuildID              string // set for server exclusive themes, those are reviewed by the moderators of the server
+	SubmittedByUserID    string
+	SubmittedInChannelID string
+	SubmittedAt          time.Time
+	Status               string
+	ReviewedByUserID     string
+	ReviewedAt           time.Time
+	RejectReason         string
+	ObjectName           string
+	SafetyRatings        string // cloud vision safe search result at the time of the submission
+	// colours and opacities, only set for themes
+	BackgroundColor   string
+	AccentColor       string
+	TextColor         string
+	BackgroundOpacity string
+	DetailOpacity     string
+	EXPOpacity        string
+	BadgeOpacity      string
+}
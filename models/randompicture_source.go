@@ -4,14 +4,23 @@ import "github.com/globalsign/mgo/bson"
 
 const (
 	RandompictureSourcesTable MongoDbCollection = "randompicture_sources"
+
+	RandompictureProviderDrive   = "drive"
+	RandompictureProviderStorage = "storage"
+	RandompictureProviderImgur   = "imgur"
+	RandompictureProviderReddit  = "reddit"
 )
 
 type RandompictureSourceEntry struct {
 	ID                 bson.ObjectId `bson:"_id,omitempty"`
 	PreviousID         string
 	GuildID            string
+	Provider           string // empty for google drive sources created before there were other providers
 	PostToChannelIDs   []string
 	DriveFolderIDs     []string
+	ImgurAlbumIDs      []string
+	Subreddits         []string
 	Aliases            []string
 	BlacklistedRoleIDs []string
+	AddedByUserID      string
 }
@@ -55,8 +55,10 @@ type Rest_Channel struct {
 	Position int
 }
 
-type Website_Session_Data struct {
-	DiscordUserID string
+type Rest_Auth_Session struct {
+	SessionToken string
+	User         Rest_User
+	ExpiresAt    time.Time
 }
 
 type Rest_Guild_Features struct {
@@ -213,6 +215,7 @@ type Rest_Statitics_Bot struct {
 type Rest_Chatlog_Message struct {
 	CreatedAt      time.Time
 	ID             string
+	ChannelID      string
 	Content        []string
 	Attachments    []string
 	AuthorID       string
@@ -221,11 +224,36 @@ type Rest_Chatlog_Message struct {
 	Deleted        bool
 }
 
+type Rest_Chatlog_Search struct {
+	Messages   []Rest_Chatlog_Message
+	NextCursor string // empty if there are no more messages
+}
+
 type Rest_VanityInvite_Invite struct {
 	Code    string
 	GuildID string
 }
 
+type Rest_VanityInvite_Conversions struct {
+	From      time.Time
+	Referers  []Rest_VanityInvite_Conversion
+	Campaigns []Rest_VanityInvite_Conversion
+	Retention []Rest_VanityInvite_Retention
+}
+
+type Rest_VanityInvite_Conversion struct {
+	Key    string // empty for clicks without referer or campaign
+	Clicks int64
+	Joins  int64
+	Rate   float64
+}
+
+type Rest_VanityInvite_Retention struct {
+	Days     int
+	Joined   int
+	Retained int
+}
+
 type Rest_Eventlog struct {
 	Channels []Rest_Channel
 	Users    []Rest_User
@@ -235,6 +263,11 @@ type Rest_Eventlog struct {
 	Guilds   []Rest_Guild
 }
 
+type Rest_Eventlog_Search struct {
+	Rest_Eventlog
+	NextCursor string // empty if there are no more entries
+}
+
 type Rest_Eventlog_Entry struct {
 	CreatedAt      time.Time
 	TargetID       string
@@ -254,12 +287,92 @@ type Rest_File struct {
 }
 
 type Rest_Background struct {
-	Name string
-	URL  string
-	Tags []string
+	Name       string
+	URL        string
+	Tags       []string
+	UsageCount int
+}
+
+type Rest_Birthday_Calendar struct {
+	GuildID string
+	Entries []Rest_Birthday_Entry
+}
+
+type Rest_Birthday_Entry struct {
+	User     Rest_User
+	Birthday string    // MM/DD
+	Year     int       // 0 if unknown or hidden
+	Age      int       // age on the upcoming birthday, 0 if unknown or hidden
+	Date     time.Time // start of the upcoming birthday in the timezone of the user
 }
 
 const (
 	Redis_Key_Feature_Levels_Badges  = "robyul2-discord:feature:levels-badges:server:%s"
 	Redis_Key_Feature_RandomPictures = "robyul2-discord:feature:randompictures:server:%s"
 )
+
+type Rest_Config_Greeter struct {
+	Type      string // join, leave, or ban
+	ChannelID string
+	EmbedCode string
+}
+
+type Rest_Config_Autorole struct {
+	RoleID       string
+	DelaySeconds int64 // 0 to apply the role on join
+}
+
+type Rest_Config_Starboard struct {
+	ChannelID string
+	Minimum   int      // 0 for the default minimum
+	Emoji     []string // empty for the default emoji
+}
+
+type Rest_Config_ModulePermission struct {
+	Type     string // channel or role
+	TargetID string
+	Allowed  []string // module names, all for all modules
+	Denied   []string
+}
+
+type Rest_Config_LevelsRole struct {
+	ID         string
+	RoleID     string
+	StartLevel int
+	LastLevel  int  // -1 for no last level
+	Rep        bool // StartLevel and LastLevel are the rep received on the server
+}
+
+type Rest_Config_Persistency struct {
+	BiasRolesEnabled bool
+	RoleIDs          []string
+}
+
+type Rest_Config_Feed struct {
+	Type          string // vlive, youtube, instagram, reddit, facebook, twitch, or twitter
+	ID            string
+	ChannelID     string
+	Name          string
+	MentionRoleID string
+}
+
+type Rest_OutgoingWebhook struct {
+	ID              string
+	URL             string
+	EventTypes      []string
+	CreatedByUserID string
+	CreatedAt       time.Time
+}
+
+type Rest_OutgoingWebhook_Delivery struct {
+	ID             string
+	WebhookID      string
+	EventType      string
+	Payload        string
+	Status         string // pending, delivered, or failed
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	LastAttemptAt  time.Time
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	ScheduledPostsTable MongoDbCollection = "scheduled_posts"
+)
+
+type ScheduledPostEntry struct {
+	ID              bson.ObjectId `bson:"_id,omitempty"`
+	GuildID         string
+	ChannelID       string
+	CreatedByUserID string
+	CreatedAt       time.Time
+	EmbedCode       string
+	Cron            string // empty for one time posts
+	Timezone        string
+	NextRunAt       time.Time
+	LastRunAt       time.Time
+	Runs            int
+	MentionRoleIDs  []string
+	DeleteAfter     time.Duration
+	Active          bool
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/Seklfreak/Robyul2/modules/plugins"
 	"github.com/Seklfreak/Robyul2/modules/plugins/biasgame"
 	"github.com/Seklfreak/Robyul2/modules/plugins/eventlog"
+	"github.com/Seklfreak/Robyul2/modules/plugins/giveaway"
 	"github.com/Seklfreak/Robyul2/modules/plugins/google"
 	"github.com/Seklfreak/Robyul2/modules/plugins/instagram"
 	"github.com/Seklfreak/Robyul2/modules/plugins/levels"
@@ -70,6 +71,12 @@ var (
 		&plugins.Steam{},
 		&plugins.Config{},
 		&plugins.Storage{},
+		&plugins.Schedule{},
+		&plugins.Birthdays{},
+		&plugins.IdolCalendar{},
+		&plugins.ApiTokens{},
+		&plugins.OutgoingWebhooks{},
+		&plugins.Privacy{},
 	}
 
 	PluginExtendedList = []ExtendedPlugin{
@@ -89,5 +96,6 @@ var (
 		&eventlog.Handler{},
 		&plugins.Perspective{},
 		&biasgame.BiasGame{},
+		&giveaway.Handler{},
 	}
 )
@@ -0,0 +1,248 @@
+package plugins
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+type apiTokensAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next apiTokensAction)
+
+type ApiTokens struct{}
+
+const (
+	apiTokensPerGuild = 10
+)
+
+func (m *ApiTokens) Commands() []string {
+	return []string{
+		"api-token",
+		"api-tokens",
+	}
+}
+
+func (m *ApiTokens) Init(session *discordgo.Session) {
+}
+
+func (m *ApiTokens) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := m.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (m *ApiTokens) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) apiTokensAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if !helpers.IsAdmin(in) {
+		*out = m.newMsg("admin.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 1 {
+		return m.actionList
+	}
+
+	switch args[0] {
+	case "create", "add":
+		return m.actionCreate
+	case "list":
+		return m.actionList
+	case "revoke", "delete", "remove":
+		return m.actionRevoke
+	}
+
+	*out = m.newMsg("bot.arguments.invalid")
+	return m.actionFinish
+}
+
+// [p]api-token create <name> <scopes, comma separated>
+func (m *ApiTokens) actionCreate(args []string, in *discordgo.Message, out **discordgo.MessageSend) apiTokensAction {
+	if len(args) < 3 {
+		*out = m.newMsg("plugins.apitokens.create-help", strings.Join(models.ApiScopes, "`, `"))
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	scopes, ok := helpers.ParseApiScopes(args[2])
+	if !ok {
+		*out = m.newMsg("plugins.apitokens.create-invalid-scopes", strings.Join(models.ApiScopes, "`, `"))
+		return m.actionFinish
+	}
+
+	existingTokens, err := helpers.MdbCount(models.ApiTokensTable, bson.M{"guildid": channel.GuildID, "revoked": false})
+	helpers.Relax(err)
+	if existingTokens >= apiTokensPerGuild {
+		*out = m.newMsg("plugins.apitokens.create-too-many", apiTokensPerGuild)
+		return m.actionFinish
+	}
+
+	token, tokenHash, err := helpers.NewApiToken()
+	helpers.Relax(err)
+
+	// the token is only sent by DM, never to the channel
+	dmChannel, err := cache.GetSession().UserChannelCreate(in.Author.ID)
+	if err != nil {
+		*out = m.newMsg("plugins.apitokens.create-dm-failed")
+		return m.actionFinish
+	}
+
+	newEntry := models.ApiTokenEntry{
+		ID:              bson.NewObjectId(),
+		GuildID:         channel.GuildID,
+		Name:            args[1],
+		TokenHash:       tokenHash,
+		Scopes:          scopes,
+		CreatedByUserID: in.Author.ID,
+		CreatedAt:       time.Now(),
+	}
+
+	// stored before sending the DM, so a token the user received always works
+	_, err = helpers.MDbInsert(models.ApiTokensTable, newEntry)
+	helpers.Relax(err)
+
+	_, err = helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.apitokens.create-dm",
+		newEntry.Name, channel.GuildID, strings.Join(scopes, "`, `"), token))
+	if err != nil {
+		// nobody received the token
+		newEntry.Revoked = true
+		newEntry.RevokedByUserID = cache.GetSession().State.User.ID
+		newEntry.RevokedAt = time.Now()
+		err = helpers.MDbUpdate(models.ApiTokensTable, newEntry.ID, newEntry)
+		helpers.Relax(err)
+
+		*out = m.newMsg("plugins.apitokens.create-dm-failed")
+		return m.actionFinish
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(newEntry.ID),
+		models.EventlogTargetTypeRobyulApiToken, in.Author.ID,
+		models.EventlogTypeRobyulApiTokenCreate, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "apitoken_name",
+				Value: newEntry.Name,
+			},
+			{
+				Key:   "apitoken_scopes",
+				Value: strings.Join(newEntry.Scopes, ","),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.apitokens.create-success", newEntry.Name, helpers.MdbIdToHuman(newEntry.ID))
+	return m.actionFinish
+}
+
+// [p]api-token list
+func (m *ApiTokens) actionList(args []string, in *discordgo.Message, out **discordgo.MessageSend) apiTokensAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var entryBucket []models.ApiTokenEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.ApiTokensTable).Find(bson.M{"guildid": channel.GuildID, "revoked": false}).Sort("createdat"),
+	).All(&entryBucket)
+	helpers.Relax(err)
+
+	if len(entryBucket) <= 0 {
+		*out = m.newMsg("plugins.apitokens.list-none")
+		return m.actionFinish
+	}
+
+	var listText string
+	for _, entry := range entryBucket {
+		lastUsedText := helpers.GetText("plugins.apitokens.list-never-used")
+		if !entry.LastUsedAt.IsZero() {
+			lastUsedText = entry.LastUsedAt.Format(time.ANSIC) + " UTC"
+		}
+		listText += helpers.GetTextF("plugins.apitokens.list-entry",
+			helpers.MdbIdToHuman(entry.ID), entry.Name, strings.Join(entry.Scopes, "`, `"),
+			entry.CreatedByUserID, lastUsedText) + "\n"
+	}
+	listText += helpers.GetTextF("plugins.apitokens.list-sum", len(entryBucket))
+
+	for _, page := range helpers.Pagify(listText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+// [p]api-token revoke <id>
+func (m *ApiTokens) actionRevoke(args []string, in *discordgo.Message, out **discordgo.MessageSend) apiTokensAction {
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var entry models.ApiTokenEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.ApiTokensTable).Find(bson.M{
+			"_id":     helpers.HumanToMdbId(strings.TrimLeft(args[1], "#")),
+			"guildid": channel.GuildID,
+			"revoked": false,
+		}),
+		&entry,
+	)
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			*out = m.newMsg("plugins.apitokens.not-found")
+			return m.actionFinish
+		}
+		helpers.Relax(err)
+	}
+
+	entry.Revoked = true
+	entry.RevokedByUserID = in.Author.ID
+	entry.RevokedAt = time.Now()
+	err = helpers.MDbUpdate(models.ApiTokensTable, entry.ID, entry)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(entry.ID),
+		models.EventlogTargetTypeRobyulApiToken, in.Author.ID,
+		models.EventlogTypeRobyulApiTokenRevoke, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "apitoken_name",
+				Value: entry.Name,
+			},
+			{
+				Key:   "apitoken_scopes",
+				Value: strings.Join(entry.Scopes, ","),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.apitokens.revoke-success", entry.Name)
+	return m.actionFinish
+}
+
+func (m *ApiTokens) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) apiTokensAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (m *ApiTokens) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
@@ -0,0 +1,91 @@
+package biasgame
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/nfnt/resize"
+)
+
+const (
+	BRACKET_IMAGE_SIZE    = 50 // size of the idol pictures in the bracket
+	BRACKET_SLOT_HEIGHT   = 60
+	BRACKET_COLUMN_WIDTH  = 90
+	BRACKET_IMAGE_PADDING = 10
+)
+
+var bracketBackgroundColor = color.RGBA{0x36, 0x39, 0x3F, 0xFF} // discord dark theme
+var bracketLineColor = color.RGBA{0x99, 0xAA, 0xB5, 0xFF}
+var bracketEmptySlotColor = color.RGBA{0x2F, 0x31, 0x36, 0xFF}
+
+// makeBracketImage draws the bracket of any power of two size, entrants are in bracket order
+// the winners are the round winners in the order the matches have been played, slots of matches not played yet stay empty
+func makeBracketImage(entrants []image.Image, winners []image.Image) image.Image {
+	rounds := helpers.BiasGameBracketRounds(len(entrants))
+
+	bracketImage := image.NewRGBA(image.Rect(0, 0,
+		BRACKET_IMAGE_PADDING*2+rounds*BRACKET_COLUMN_WIDTH+BRACKET_IMAGE_SIZE,
+		BRACKET_IMAGE_PADDING*2+len(entrants)*BRACKET_SLOT_HEIGHT))
+	draw.Draw(bracketImage, bracketImage.Bounds(), &image.Uniform{bracketBackgroundColor}, image.ZP, draw.Src)
+
+	// collect the pictures of every column, the first column are the entrants
+	columns := make([][]image.Image, rounds+1)
+	columns[0] = entrants
+	for round := 1; round <= rounds; round++ {
+		columns[round] = make([]image.Image, len(entrants)>>uint(round))
+	}
+	for match, winner := range winners {
+		round, position := helpers.BiasGameBracketMatch(len(entrants), match)
+		if round+1 < len(columns) && position < len(columns[round+1]) {
+			columns[round+1][position] = winner
+		}
+	}
+
+	for column, pictures := range columns {
+		slotHeight := BRACKET_SLOT_HEIGHT << uint(column)
+		x := BRACKET_IMAGE_PADDING + column*BRACKET_COLUMN_WIDTH
+
+		for position, picture := range pictures {
+			y := BRACKET_IMAGE_PADDING + position*slotHeight + slotHeight/2 - BRACKET_IMAGE_SIZE/2
+			slot := image.Rect(x, y, x+BRACKET_IMAGE_SIZE, y+BRACKET_IMAGE_SIZE)
+
+			// connect the slot with the slot of the next round
+			if column < rounds {
+				centerY := y + BRACKET_IMAGE_SIZE/2
+				lineEndX := x + BRACKET_IMAGE_SIZE + (BRACKET_COLUMN_WIDTH-BRACKET_IMAGE_SIZE)/2
+				draw.Draw(bracketImage, image.Rect(slot.Max.X, centerY, lineEndX, centerY+2), &image.Uniform{bracketLineColor}, image.ZP, draw.Src)
+
+				// the upper slot of a match draws the vertical line and the line to the next round
+				if position%2 == 0 {
+					nextCenterY := centerY + slotHeight
+					draw.Draw(bracketImage, image.Rect(lineEndX, centerY, lineEndX+2, nextCenterY+2), &image.Uniform{bracketLineColor}, image.ZP, draw.Src)
+					middleY := centerY + slotHeight/2
+					draw.Draw(bracketImage, image.Rect(lineEndX, middleY, x+BRACKET_COLUMN_WIDTH, middleY+2), &image.Uniform{bracketLineColor}, image.ZP, draw.Src)
+				}
+			}
+
+			if picture == nil {
+				draw.Draw(bracketImage, slot, &image.Uniform{bracketEmptySlotColor}, image.ZP, draw.Src)
+				continue
+			}
+
+			resizedPicture := resize.Resize(BRACKET_IMAGE_SIZE, BRACKET_IMAGE_SIZE, picture, resize.Lanczos3)
+			draw.Draw(bracketImage, slot, resizedPicture, resizedPicture.Bounds().Min, draw.Over)
+		}
+	}
+
+	return bracketImage
+}
+
+// encodeBracketImage encodes the bracket image with the fastest png compression
+func encodeBracketImage(bracketImage image.Image) []byte {
+	buf := new(bytes.Buffer)
+	encoder := new(png.Encoder)
+	encoder.CompressionLevel = -2 // -2 compression is best speed, -3 is best compression but end result isn't worth the slower encoding
+	encoder.Encode(buf, bracketImage)
+	return buf.Bytes()
+}
@@ -119,6 +119,8 @@ func (b *BiasGame) Init(session *discordgo.Session) {
 		// set global variables
 		currentSinglePlayerGames = make(map[string]*singleBiasGame)
 		allowedGameSizes = map[int]bool{
+			8:    true,
+			16:   true,
 			32:   true,
 			64:   true,
 			128:  true,
@@ -127,6 +129,8 @@ func (b *BiasGame) Init(session *discordgo.Session) {
 			1024: true,
 		}
 		allowedMultiGameSizes = map[int]bool{
+			8:  true,
+			16: true,
 			32: true,
 			64: true,
 		}
@@ -196,6 +200,9 @@ func (b *BiasGame) Init(session *discordgo.Session) {
 
 		gameIsReady = true
 
+		// end tournament matches when their votes are closed
+		startTournamentLoop()
+
 		// load aliases
 		initAliases()
 
@@ -267,6 +274,24 @@ func (b *BiasGame) Action(command string, content string, msg *discordgo.Message
 
 			showRankings(msg, commandArgs, false)
 
+		} else if isCommandAlias(commandArgs[0], "ratings") {
+
+			showIdolRatings(msg, commandArgs)
+
+		} else if isCommandAlias(commandArgs[0], "rating-history") {
+
+			showIdolRatingHistory(msg, content)
+
+		} else if isCommandAlias(commandArgs[0], "head-to-head") {
+
+			showHeadToHead(msg, content)
+
+		} else if commandArgs[0] == "rebuild-ratings" {
+
+			helpers.RequireRobyulMod(msg, func() {
+				rebuildRatingsFromMsg(msg)
+			})
+
 		} else if commandArgs[0] == "suggest" {
 
 			processImageSuggestion(msg, content)
@@ -350,6 +375,51 @@ func (b *BiasGame) Action(command string, content string, msg *discordgo.Message
 
 			startMultiPlayerGame(msg, commandArgs)
 
+		} else if isCommandAlias(commandArgs[0], "guess") {
+
+			startQuizGame(msg, commandArgs[1:], true)
+
+		} else if commandArgs[0] == "quiz" {
+
+			if len(commandArgs) > 1 && commandArgs[1] == "stop" {
+				stopQuizGame(msg)
+				return
+			}
+			startQuizGame(msg, commandArgs[1:], false)
+
+		} else if isCommandAlias(commandArgs[0], "quiz-leaderboard") {
+
+			showQuizLeaderboard(msg)
+
+		} else if commandArgs[0] == "tournament" {
+
+			handleTournamentCommand(msg, commandArgs)
+
+		} else if commandArgs[0] == "pool" {
+
+			if len(commandArgs) < 2 {
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				return
+			}
+
+			switch commandArgs[1] {
+			case "add":
+				helpers.RequireMod(msg, func() {
+					updateServerPool(msg, content, true)
+				})
+				break
+			case "list":
+				listServerPool(msg)
+				break
+			case "remove", "delete", "del":
+				helpers.RequireMod(msg, func() {
+					updateServerPool(msg, content, false)
+				})
+				break
+			default:
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+			}
+
 		} else if commandArgs[0] == "idols" {
 
 			listIdolsInGame(msg)
@@ -374,6 +444,12 @@ func (b *BiasGame) Action(command string, content string, msg *discordgo.Message
 
 			singleGame := createOrGetSinglePlayerGame(msg, commandArgs)
 			singleGame.sendBiasGameRound()
+
+		} else if strings.Contains(commandArgs[0], "=") {
+
+			// group=<group> and pool=<pool> options
+			singleGame := createOrGetSinglePlayerGame(msg, commandArgs)
+			singleGame.sendBiasGameRound()
 		}
 	} else if command == "biasgame-edit" { // edit is used for changing details of suggestions
 		fieldToUpdate := commandArgs[0]
@@ -423,50 +499,16 @@ func createOrGetSinglePlayerGame(msg *discordgo.Message, commandArgs []string) *
 		game.ChannelID = msg.ChannelID
 		singleGame = game
 	} else {
-		var biasChoices []*biasChoice
-		gameGender := "mixed"
-		gameSize := 32
-
-		// validate game arguments
-		if len(commandArgs) > 0 {
-			for _, arg := range commandArgs {
-
-				// gender check
-				if gender, ok := biasGameGenders[arg]; ok == true {
-					gameGender = gender
-					continue
-				}
-
-				// game size check
-				if requestedGameSize, err := strconv.Atoi(arg); err == nil {
-					if _, ok := allowedGameSizes[requestedGameSize]; ok == true {
-
-						gameSize = requestedGameSize
-						continue
-					} else {
-
-						helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.game.invalid-game-size"))
-						return nil
-					}
-				}
-
-				// if a arg was passed that didn't match any check, send invalid args message
-				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-				return nil
-			}
+		options, ok := parseGameOptions(msg, commandArgs, allowedGameSizes, "plugins.biasgame.game.invalid-game-size", 32)
+		if !ok {
+			return nil
 		}
+		gameGender := options.Gender
+		gameSize := options.Size
 
-		// if this isn't a mixed game then filter all choices by the gender
-		if gameGender != "mixed" {
-
-			for _, bias := range getAllBiases() {
-				if bias.Gender == gameGender {
-					biasChoices = append(biasChoices, bias)
-				}
-			}
-		} else {
-			biasChoices = getAllBiases()
-		}
+		channel, err := helpers.GetChannel(msg.ChannelID)
+		helpers.Relax(err)
+		biasChoices := getGameBiasChoices(channel.GuildID, options)
 
 		// confirm we have enough biases to choose from for the game size this should be
 		if len(biasChoices) < gameSize {
@@ -513,6 +555,11 @@ func createOrGetSinglePlayerGame(msg *discordgo.Message, commandArgs []string) *
 			}
 		}
 
+		// the whole bracket is shown if the game starts with eight idols
+		if len(singleGame.BiasQueue) == 8 {
+			singleGame.TopEight = singleGame.BiasQueue
+		}
+
 		// save game to current running games
 		singleGame.saveGame()
 	}
@@ -759,53 +806,16 @@ func startMultiPlayerGame(msg *discordgo.Message, commandArgs []string) {
 		return
 	}
 
-	commandArgs = commandArgs[1:]
-	gameGender := "mixed"
-	multiGameSize := 32
-
-	// validate multi game options
-	if len(commandArgs) > 0 {
-
-		for _, arg := range commandArgs {
-
-			// gender check
-			if gender, ok := biasGameGenders[arg]; ok == true {
-				gameGender = gender
-				continue
-			}
-
-			// game size check
-			if requestedGameSize, err := strconv.Atoi(arg); err == nil {
-				if _, ok := allowedMultiGameSizes[requestedGameSize]; ok == true {
-
-					multiGameSize = requestedGameSize
-					continue
-				} else {
-
-					helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.game.invalid-game-size-multi"))
-					return
-				}
-			}
-
-			// if a arg was passed that didn't match any check, send invalid args message
-			helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-			return
-		}
+	options, ok := parseGameOptions(msg, commandArgs[1:], allowedMultiGameSizes, "plugins.biasgame.game.invalid-game-size-multi", 32)
+	if !ok {
+		return
 	}
+	gameGender := options.Gender
+	multiGameSize := options.Size
 
-	var biasChoices []*biasChoice
-
-	// if this isn't a mixed game then filter all choices by the gender
-	if gameGender != "mixed" {
-
-		for _, bias := range getAllBiases() {
-			if bias.Gender == gameGender {
-				biasChoices = append(biasChoices, bias)
-			}
-		}
-	} else {
-		biasChoices = getAllBiases()
-	}
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+	biasChoices := getGameBiasChoices(channel.GuildID, options)
 
 	// confirm we have enough biases for a multiplayer game
 	if len(biasChoices) < multiGameSize {
@@ -838,6 +848,11 @@ func startMultiPlayerGame(msg *discordgo.Message, commandArgs []string) {
 		}
 	}
 
+	// the whole bracket is shown if the game starts with eight idols
+	if len(multiGame.BiasQueue) == 8 {
+		multiGame.TopEight = multiGame.BiasQueue
+	}
+
 	// save game to current running games
 	multiGame.saveGame()
 
@@ -1180,9 +1195,19 @@ func (b biasImage) getImgBytes() []byte {
 	}
 }
 
-///// Unused functions requried by ExtendedPlugin interface
+// Called whenever a message is sent, checks answers of running quizzes
 func (b *BiasGame) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	defer helpers.Recover()
+	if gameIsReady == false || msg.Author == nil || msg.Author.Bot {
+		return
+	}
+
+	if game := getQuizGame(msg.ChannelID); game != nil {
+		game.checkAnswer(msg)
+	}
 }
+
+///// Unused functions requried by ExtendedPlugin interface
 func (b *BiasGame) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
 }
 func (b *BiasGame) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
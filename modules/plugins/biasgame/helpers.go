@@ -311,10 +311,28 @@ func isCommandAlias(input, targetCommand string) bool {
 		"multi":       "multi",
 		"multiplayer": "multi",
 
+		"ratings": "ratings",
+		"rating":  "ratings",
+		"elo":     "ratings",
+
+		"rating-history": "rating-history",
+		"elo-history":    "rating-history",
+
+		"head-to-head": "head-to-head",
+		"h2h":          "head-to-head",
+		"vs":           "head-to-head",
+
 		"server-rankings": "server-rankings",
 		"server-ranking":  "server-rankings",
 		"server-ranks":    "server-rankings",
 		"server-rank":     "server-rankings",
+
+		"guess": "guess",
+		"g":     "guess",
+
+		"quiz-leaderboard": "quiz-leaderboard",
+		"quiz-lb":          "quiz-leaderboard",
+		"quiz-top":         "quiz-leaderboard",
 	}
 
 	if attemptedCommand, ok := aliasMap[input]; ok {
@@ -0,0 +1,318 @@
+package biasgame
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	// idols of the server curated list
+	GAME_POOL_SERVER = "server"
+	// idols matching the bias roles of the server
+	GAME_POOL_BIAS = "bias"
+)
+
+var poolNameRegex = regexp.MustCompile("[^a-zA-Z0-9]+")
+
+// gameOptions are the options of single, multi, and tournament games
+type gameOptions struct {
+	Gender string
+	Size   int
+	Groups []string // real group names, empty allows all groups
+	Pool   string   // GAME_POOL_SERVER, GAME_POOL_BIAS, or empty for all idols
+}
+
+// parseGameOptions parses the gender, size, group=<group>, and pool=<server|bias> arguments of a game
+// sends a message and returns false if an argument is invalid
+func parseGameOptions(msg *discordgo.Message, commandArgs []string, allowedSizes map[int]bool, invalidSizeText string, defaultSize int) (options gameOptions, ok bool) {
+	options = gameOptions{
+		Gender: "mixed",
+		Size:   defaultSize,
+	}
+
+	for _, arg := range commandArgs {
+
+		// gender check
+		if gender, ok := biasGameGenders[arg]; ok == true {
+			options.Gender = gender
+			continue
+		}
+
+		// game size check
+		if requestedGameSize, err := strconv.Atoi(arg); err == nil {
+			if _, ok := allowedSizes[requestedGameSize]; ok == true {
+
+				options.Size = requestedGameSize
+				continue
+			} else {
+
+				helpers.SendMessage(msg.ChannelID, helpers.GetText(invalidSizeText))
+				return options, false
+			}
+		}
+
+		// pool checks, group names are compared loosely so groups with spaces can be written without them
+		if argParts := strings.SplitN(arg, "=", 2); len(argParts) == 2 {
+			switch strings.ToLower(argParts[0]) {
+			case "group":
+				if groupMatch, realGroupName := getMatchingGroup(argParts[1]); groupMatch {
+					options.Groups = append(options.Groups, realGroupName)
+					continue
+				}
+
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.stats.no-matching-group"))
+				return options, false
+			case "pool":
+				if argParts[1] == GAME_POOL_SERVER || argParts[1] == GAME_POOL_BIAS {
+					options.Pool = argParts[1]
+					continue
+				}
+			}
+		}
+
+		// if a arg was passed that didn't match any check, send invalid args message
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return options, false
+	}
+
+	return options, true
+}
+
+// getGameBiasChoices returns all idols matching the gender, groups, and pool of the game options
+func getGameBiasChoices(guildID string, options gameOptions) (biasChoices []*biasChoice) {
+	var poolIdols []models.BiasGamePoolIdol
+	var biasRoleNames map[string]bool
+	switch options.Pool {
+	case GAME_POOL_SERVER:
+		var poolEntry models.BiasGamePoolEntry
+		err := helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.BiasGamePoolsTable).Find(bson.M{"guildid": guildID}), &poolEntry)
+		if err != nil && !helpers.IsMdbNotFound(err) {
+			helpers.Relax(err)
+		}
+		poolIdols = poolEntry.Idols
+	case GAME_POOL_BIAS:
+		biasRoleNames = getBiasRoleNames(guildID)
+	}
+
+	for _, bias := range getAllBiases() {
+
+		// if this isn't a mixed game then filter all choices by the gender
+		if options.Gender != "mixed" && bias.Gender != options.Gender {
+			continue
+		}
+
+		if len(options.Groups) > 0 && !poolContainsGroup(options.Groups, bias.GroupName) {
+			continue
+		}
+
+		switch options.Pool {
+		case GAME_POOL_SERVER:
+			if !poolContainsIdol(poolIdols, bias) {
+				continue
+			}
+		case GAME_POOL_BIAS:
+			if !biasRoleNames[normalizePoolName(bias.BiasName)] &&
+				!biasRoleNames[normalizePoolName(bias.GroupName)] &&
+				!biasRoleNames[normalizePoolName(bias.GroupName+bias.BiasName)] {
+				continue
+			}
+		}
+
+		biasChoices = append(biasChoices, bias)
+	}
+
+	return biasChoices
+}
+
+// getBiasRoleNames returns the normalized names of all bias roles set up on the server
+func getBiasRoleNames(guildID string) map[string]bool {
+	var biasChannels []models.BiasEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.BiasTable).Find(bson.M{"guildid": guildID})).All(&biasChannels)
+	helpers.Relax(err)
+
+	roleNames := make(map[string]bool)
+	for _, biasChannel := range biasChannels {
+		for _, category := range biasChannel.Categories {
+			for _, role := range category.Roles {
+				roleNames[normalizePoolName(role.Name)] = true
+				roleNames[normalizePoolName(role.Print)] = true
+			}
+		}
+	}
+	delete(roleNames, "")
+
+	return roleNames
+}
+
+func normalizePoolName(name string) string {
+	return strings.ToLower(poolNameRegex.ReplaceAllString(name, ""))
+}
+
+func poolContainsGroup(groups []string, groupName string) bool {
+	for _, group := range groups {
+		if group == groupName {
+			return true
+		}
+	}
+	return false
+}
+
+func poolContainsIdol(poolIdols []models.BiasGamePoolIdol, bias *biasChoice) bool {
+	for _, poolIdol := range poolIdols {
+		if poolIdol.GroupName == bias.GroupName && (poolIdol.Name == "" || poolIdol.Name == bias.BiasName) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateServerPool adds or removes a group or an idol to the server curated list
+func updateServerPool(msg *discordgo.Message, content string, add bool) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	commandArgs, err := helpers.ToArgv(content)
+	if err != nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+	commandArgs = commandArgs[2:]
+
+	if len(commandArgs) < 1 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	// find the group or idol
+	poolIdol := models.BiasGamePoolIdol{}
+	if len(commandArgs) >= 2 {
+		_, _, bias := getMatchingIdolAndGroup(commandArgs[0], commandArgs[1])
+		if bias == nil {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.stats.no-matching-idol"))
+			return
+		}
+		poolIdol.GroupName = bias.GroupName
+		poolIdol.Name = bias.BiasName
+	} else {
+		groupMatch, realGroupName := getMatchingGroup(commandArgs[0])
+		if !groupMatch {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.stats.no-matching-group"))
+			return
+		}
+		poolIdol.GroupName = realGroupName
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	var poolEntry models.BiasGamePoolEntry
+	err = helpers.MdbOne(helpers.MdbCollection(models.BiasGamePoolsTable).Find(bson.M{"guildid": channel.GuildID}), &poolEntry)
+	if err != nil && !helpers.IsMdbNotFound(err) {
+		helpers.Relax(err)
+	}
+	poolEntry.GuildID = channel.GuildID
+
+	// check if the group or idol is already in the pool
+	poolIdolIndex := -1
+	for i, existingIdol := range poolEntry.Idols {
+		if existingIdol == poolIdol {
+			poolIdolIndex = i
+			break
+		}
+	}
+
+	if add {
+		if poolIdolIndex >= 0 {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.pool.already-added"))
+			return
+		}
+		poolEntry.Idols = append(poolEntry.Idols, poolIdol)
+	} else {
+		if poolIdolIndex < 0 {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.pool.not-found"))
+			return
+		}
+		poolEntry.Idols = append(poolEntry.Idols[:poolIdolIndex], poolEntry.Idols[poolIdolIndex+1:]...)
+	}
+
+	if poolEntry.ID == "" {
+		_, err = helpers.MDbInsert(models.BiasGamePoolsTable, poolEntry)
+	} else {
+		err = helpers.MDbUpdate(models.BiasGamePoolsTable, poolEntry.ID, poolEntry)
+	}
+	helpers.Relax(err)
+
+	poolIdolName := poolIdol.GroupName
+	if poolIdol.Name != "" {
+		poolIdolName = fmt.Sprintf("%s %s", poolIdol.GroupName, poolIdol.Name)
+	}
+	if add {
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.biasgame.pool.add-success", poolIdolName))
+	} else {
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.biasgame.pool.remove-success", poolIdolName))
+	}
+}
+
+// listServerPool lists the groups and idols of the server curated list
+func listServerPool(msg *discordgo.Message) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	var poolEntry models.BiasGamePoolEntry
+	err = helpers.MdbOne(helpers.MdbCollection(models.BiasGamePoolsTable).Find(bson.M{"guildid": channel.GuildID}), &poolEntry)
+	if err != nil && !helpers.IsMdbNotFound(err) {
+		helpers.Relax(err)
+	}
+
+	if len(poolEntry.Idols) == 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.pool.list-empty"))
+		return
+	}
+
+	// create map of groups and there idols in the pool
+	groupIdolMap := make(map[string][]string)
+	for _, poolIdol := range poolEntry.Idols {
+		if poolIdol.Name == "" {
+			groupIdolMap[poolIdol.GroupName] = append(groupIdolMap[poolIdol.GroupName], "*All Idols*")
+		} else {
+			groupIdolMap[poolIdol.GroupName] = append(groupIdolMap[poolIdol.GroupName], poolIdol.Name)
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color: 0x0FADED, // blueish
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: "Server Bias Game Pool",
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d idols available, use pool=server to play with them", len(getGameBiasChoices(channel.GuildID, gameOptions{Gender: "mixed", Pool: GAME_POOL_SERVER}))),
+		},
+	}
+
+	for group, idols := range groupIdolMap {
+		sort.Strings(idols)
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   group,
+			Value:  strings.Join(idols, ", "),
+			Inline: false,
+		})
+	}
+
+	// sort fields by group name
+	sort.Slice(embed.Fields, func(i, j int) bool {
+		return strings.ToLower(embed.Fields[i].Name) < strings.ToLower(embed.Fields[j].Name)
+	})
+
+	helpers.SendPagedMessage(msg, embed, 10)
+}
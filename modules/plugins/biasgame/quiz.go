@@ -0,0 +1,447 @@
+package biasgame
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	QUIZ_MODE_IDOL  = "idol"  // guess the name of the idol
+	QUIZ_MODE_GROUP = "group" // guess the group of the idol
+
+	QUIZ_TRANSFORM_CROP = "crop"
+	QUIZ_TRANSFORM_BLUR = "blur"
+
+	QUIZ_IMAGE_SIZE   = 300
+	QUIZ_BLUR_SIZE    = 12 // the picture is scaled down to this size and back up to blur it
+	QUIZ_CROP_PERCENT = 40 // size of the cropped part of the picture
+)
+
+var allowedQuizRounds = map[int]bool{
+	5:  true,
+	10: true,
+	15: true,
+	20: true,
+}
+
+var quizRoundDuration = time.Second * 20
+
+// quizGame is a guessing game in a channel, everyone in the channel can answer
+type quizGame struct {
+	sync.Mutex
+	ChannelID       string
+	GuildID         string
+	StartedByUserID string
+	Mode            string // QUIZ_MODE_IDOL or QUIZ_MODE_GROUP
+	Transform       string // empty uses a random transform every round
+	Rounds          int
+	BiasChoices     []*biasChoice
+
+	Scores         map[string]int // user id => points
+	CorrectAnswers map[string]int
+	BestStreaks    map[string]int
+	StreakUserID   string
+	Streak         int
+
+	currentBias *biasChoice // nil if no round is waiting for answers
+	roundWinner chan string
+	stop        chan bool
+	stopped     bool
+}
+
+// maps channel id => quiz running in the channel
+var currentQuizGames = make(map[string]*quizGame)
+var currentQuizGamesMutex sync.RWMutex
+
+// getQuizGame returns the quiz running in the channel or nil
+func getQuizGame(channelID string) *quizGame {
+	currentQuizGamesMutex.RLock()
+	defer currentQuizGamesMutex.RUnlock()
+	return currentQuizGames[channelID]
+}
+
+// startQuizGame starts a guess the idol or group game, single round games are used for the guess command
+func startQuizGame(msg *discordgo.Message, commandArgs []string, singleRound bool) {
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	if getQuizGame(msg.ChannelID) != nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.quiz.already-running"))
+		return
+	}
+
+	game := &quizGame{
+		ChannelID:       msg.ChannelID,
+		GuildID:         channel.GuildID,
+		StartedByUserID: msg.Author.ID,
+		Mode:            QUIZ_MODE_IDOL,
+		Scores:          make(map[string]int),
+		CorrectAnswers:  make(map[string]int),
+		BestStreaks:     make(map[string]int),
+		stop:            make(chan bool),
+	}
+
+	// mode and transform arguments, the others are the usual game options
+	var optionArgs []string
+	for _, arg := range commandArgs {
+		switch arg {
+		case QUIZ_MODE_IDOL, QUIZ_MODE_GROUP:
+			game.Mode = arg
+		case QUIZ_TRANSFORM_CROP, QUIZ_TRANSFORM_BLUR:
+			game.Transform = arg
+		default:
+			optionArgs = append(optionArgs, arg)
+		}
+	}
+
+	allowedRounds := allowedQuizRounds
+	defaultRounds := 10
+	if singleRound {
+		allowedRounds = map[int]bool{1: true}
+		defaultRounds = 1
+	}
+	options, ok := parseGameOptions(msg, optionArgs, allowedRounds, "plugins.biasgame.quiz.invalid-rounds", defaultRounds)
+	if !ok {
+		return
+	}
+	game.Rounds = options.Size
+
+	for _, bias := range getGameBiasChoices(channel.GuildID, options) {
+		if len(bias.BiasImages) > 0 {
+			game.BiasChoices = append(game.BiasChoices, bias)
+		}
+	}
+	if len(game.BiasChoices) == 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.game.not-enough-idols"))
+		return
+	}
+
+	currentQuizGamesMutex.Lock()
+	if _, ok := currentQuizGames[msg.ChannelID]; ok {
+		currentQuizGamesMutex.Unlock()
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.quiz.already-running"))
+		return
+	}
+	currentQuizGames[msg.ChannelID] = game
+	currentQuizGamesMutex.Unlock()
+
+	go game.run()
+}
+
+// stopQuizGame stops the quiz in the channel, only the user who started it or mods can stop it
+func stopQuizGame(msg *discordgo.Message) {
+	game := getQuizGame(msg.ChannelID)
+	if game == nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.quiz.not-running"))
+		return
+	}
+
+	if game.StartedByUserID != msg.Author.ID && !helpers.IsMod(msg) {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.quiz.stop-not-allowed"))
+		return
+	}
+
+	game.Lock()
+	if !game.stopped {
+		game.stopped = true
+		close(game.stop)
+	}
+	game.Unlock()
+}
+
+// run plays all rounds of the quiz, then posts the scores and saves them to the leaderboard
+func (g *quizGame) run() {
+	defer helpers.Recover()
+	defer func() {
+		currentQuizGamesMutex.Lock()
+		delete(currentQuizGames, g.ChannelID)
+		currentQuizGamesMutex.Unlock()
+	}()
+
+	// don't repeat idols unless there are more rounds than idols
+	order := rand.Perm(len(g.BiasChoices))
+	for round := 0; round < g.Rounds; round++ {
+		if !g.playRound(round, g.BiasChoices[order[round%len(order)]]) {
+			break
+		}
+	}
+
+	if g.Rounds > 1 {
+		g.sendScores()
+	}
+	g.saveScores()
+}
+
+// playRound posts the picture of the idol and waits for the first correct answer
+// returns false if the quiz has been stopped or the picture couldn't be posted
+func (g *quizGame) playRound(round int, bias *biasChoice) bool {
+	transform := g.Transform
+	if transform == "" {
+		transform = []string{QUIZ_TRANSFORM_CROP, QUIZ_TRANSFORM_BLUR}[rand.Intn(2)]
+	}
+
+	quizImage, err := makeQuizImage(bias, transform)
+	if err != nil {
+		bgLog().Warnf("creating biasgame quiz image failed: %s", err.Error())
+		return false
+	}
+
+	question := "Who is this idol?"
+	answer := bias.BiasName
+	if g.Mode == QUIZ_MODE_GROUP {
+		question = "Which group is this idol from?"
+		answer = bias.GroupName
+	}
+
+	title := fmt.Sprintf("**Bias Game Quiz** - Round %d/%d", round+1, g.Rounds)
+	if g.Rounds == 1 {
+		title = "**Guess the Idol**"
+		if g.Mode == QUIZ_MODE_GROUP {
+			title = "**Guess the Group**"
+		}
+	}
+
+	_, err = helpers.SendFile(g.ChannelID, "biasgame_quiz.png", bytes.NewReader(quizImage),
+		fmt.Sprintf("%s\n%s You have %d seconds to answer in this channel.", title, question, int(quizRoundDuration.Seconds())))
+	if err != nil {
+		checkPermissionError(err, g.ChannelID)
+		return false
+	}
+
+	g.Lock()
+	g.currentBias = bias
+	g.roundWinner = make(chan string, 1)
+	roundWinner := g.roundWinner
+	g.Unlock()
+
+	hint := time.After(quizRoundDuration / 2)
+	timeout := time.After(quizRoundDuration)
+	for {
+		select {
+		case userID := <-roundWinner:
+			g.announceWinner(bias, userID)
+			return true
+		case <-hint:
+			helpers.SendMessage(g.ChannelID, fmt.Sprintf("Hint: It starts with **%s** and has %d characters.",
+				string([]rune(answer)[0]), len([]rune(answer))))
+		case <-timeout:
+			g.Lock()
+			g.currentBias = nil
+			g.Unlock()
+
+			// an answer might have come in right before the round ended
+			select {
+			case userID := <-roundWinner:
+				g.announceWinner(bias, userID)
+				return true
+			default:
+			}
+
+			g.Lock()
+			g.StreakUserID = ""
+			g.Streak = 0
+			g.Unlock()
+
+			helpers.SendMessage(g.ChannelID, fmt.Sprintf("Time's up! It was **%s %s**.", bias.GroupName, bias.BiasName))
+			return true
+		case <-g.stop:
+			g.Lock()
+			g.currentBias = nil
+			g.Unlock()
+
+			helpers.SendMessage(g.ChannelID, helpers.GetText("plugins.biasgame.quiz.stopped"))
+			return false
+		}
+	}
+}
+
+// announceWinner gives the points of the round to the user and posts the answer
+func (g *quizGame) announceWinner(bias *biasChoice, userID string) {
+	points, streak := g.awardPoints(userID)
+
+	messageString := fmt.Sprintf("<@%s> got it! It was **%s %s**. +%d points", userID, bias.GroupName, bias.BiasName, points)
+	if streak > 1 {
+		messageString += fmt.Sprintf(" (%d in a row :fire:)", streak)
+	}
+	helpers.SendMessage(g.ChannelID, messageString)
+}
+
+// checkAnswer checks a message sent during a round, the first correct answer wins the round
+func (g *quizGame) checkAnswer(msg *discordgo.Message) {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.currentBias == nil {
+		return
+	}
+
+	var solutions []string
+	if g.Mode == QUIZ_MODE_GROUP {
+		solutions = append(solutions, g.currentBias.GroupName)
+		if _, aliases := getAlisesForGroup(normalizePoolName(g.currentBias.GroupName)); aliases != nil {
+			solutions = append(solutions, aliases...)
+		}
+	} else {
+		solutions = append(solutions, g.currentBias.BiasName, g.currentBias.GroupName+g.currentBias.BiasName)
+	}
+
+	if helpers.BiasGameGuessMatches(msg.Content, solutions...) {
+		g.currentBias = nil
+		g.roundWinner <- msg.Author.ID
+	}
+}
+
+// awardPoints gives points for a correct answer to the user and returns the points and the current streak
+func (g *quizGame) awardPoints(userID string) (points, streak int) {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.StreakUserID == userID {
+		g.Streak++
+	} else {
+		g.StreakUserID = userID
+		g.Streak = 1
+	}
+
+	points = helpers.BiasGameQuizPoints(g.Streak)
+	g.Scores[userID] += points
+	g.CorrectAnswers[userID]++
+	if g.Streak > g.BestStreaks[userID] {
+		g.BestStreaks[userID] = g.Streak
+	}
+
+	return points, g.Streak
+}
+
+// sendScores posts the scores of the quiz
+func (g *quizGame) sendScores() {
+	g.Lock()
+	defer g.Unlock()
+
+	if len(g.Scores) == 0 {
+		helpers.SendMessage(g.ChannelID, helpers.GetText("plugins.biasgame.quiz.no-scores"))
+		return
+	}
+
+	var userIDs []string
+	for userID := range g.Scores {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool {
+		return g.Scores[userIDs[i]] > g.Scores[userIDs[j]]
+	})
+
+	var scoreLines []string
+	for i, userID := range userIDs {
+		scoreLines = append(scoreLines, fmt.Sprintf("#%d <@%s> - **%d** points, %d correct, best streak %d",
+			i+1, userID, g.Scores[userID], g.CorrectAnswers[userID], g.BestStreaks[userID]))
+	}
+
+	helpers.SendEmbed(g.ChannelID, &discordgo.MessageEmbed{
+		Color:       0x0FADED, // blueish
+		Title:       "Bias Game Quiz - Results",
+		Description: strings.Join(scoreLines, "\n"),
+	})
+}
+
+// saveScores adds the scores of the quiz to the server leaderboard
+func (g *quizGame) saveScores() {
+	g.Lock()
+	defer g.Unlock()
+
+	for userID, points := range g.Scores {
+		err := helpers.MDbUpsert(models.BiasGameQuizScoresTable,
+			bson.M{"guildid": g.GuildID, "userid": userID},
+			bson.M{
+				"$inc": bson.M{"points": points, "correctanswers": g.CorrectAnswers[userID], "gamesplayed": 1},
+				"$max": bson.M{"beststreak": g.BestStreaks[userID]},
+			})
+		helpers.RelaxLog(err)
+	}
+}
+
+// showQuizLeaderboard shows the users with the most quiz points on the server
+func showQuizLeaderboard(msg *discordgo.Message) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	var scores []models.BiasGameQuizScoreEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.BiasGameQuizScoresTable).Find(bson.M{"guildid": channel.GuildID}).Sort("-points").Limit(10)).All(&scores)
+	helpers.Relax(err)
+
+	if len(scores) == 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.quiz.no-scores"))
+		return
+	}
+
+	var scoreLines []string
+	for i, score := range scores {
+		displayName := "*Unknown*"
+		user, err := helpers.GetUser(score.UserID)
+		if err == nil {
+			displayName = user.Username
+		}
+
+		scoreLines = append(scoreLines, fmt.Sprintf("#%d **%s** - %d points, %d correct, best streak %d, %d games",
+			i+1, displayName, score.Points, score.CorrectAnswers, score.BestStreak, score.GamesPlayed))
+	}
+
+	helpers.SendEmbed(msg.ChannelID, &discordgo.MessageEmbed{
+		Color:       0x0FADED, // blueish
+		Title:       "Bias Game Quiz - Server Leaderboard",
+		Description: strings.Join(scoreLines, "\n"),
+	})
+}
+
+// makeQuizImage returns a cropped or blurred picture of the idol as png
+func makeQuizImage(bias *biasChoice, transform string) ([]byte, error) {
+	imgBytes := bias.BiasImages[rand.Intn(len(bias.BiasImages))].getImgBytes()
+
+	if transform == QUIZ_TRANSFORM_BLUR {
+		// scaling the picture down and back up blurs it
+		smallImgBytes, err := helpers.ScaleImage(imgBytes, QUIZ_BLUR_SIZE, QUIZ_BLUR_SIZE)
+		if err != nil {
+			return nil, err
+		}
+		return helpers.ScaleImage(smallImgBytes, QUIZ_IMAGE_SIZE, QUIZ_IMAGE_SIZE)
+	}
+
+	img, _, err := helpers.DecodeImageBytes(imgBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// crop a random part close to the center, faces are rarely at the edges
+	bounds := img.Bounds()
+	cropWidth := bounds.Dx() * QUIZ_CROP_PERCENT / 100
+	cropHeight := bounds.Dy() * QUIZ_CROP_PERCENT / 100
+	x := bounds.Min.X + (bounds.Dx()-cropWidth)/4 + rand.Intn((bounds.Dx()-cropWidth)/2+1)
+	y := bounds.Min.Y + (bounds.Dy()-cropHeight)/4 + rand.Intn((bounds.Dy()-cropHeight)/2+1)
+
+	croppedImg := image.NewRGBA(image.Rect(0, 0, cropWidth, cropHeight))
+	draw.Draw(croppedImg, croppedImg.Bounds(), img, image.Point{x, y}, draw.Src)
+
+	buf := new(bytes.Buffer)
+	err = png.Encode(buf, croppedImg)
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.ScaleImage(buf.Bytes(), QUIZ_IMAGE_SIZE, QUIZ_IMAGE_SIZE)
+}
@@ -0,0 +1,642 @@
+package biasgame
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/globalsign/mgo/bson"
+)
+
+const (
+	// amount of operations sent in one bulk operation
+	ratingsBulkSize = 500
+)
+
+// makes sure games are applied to the ratings one after another
+var ratingsMutex sync.Mutex
+var ratingsRebuilding int32
+
+var errRatingsRebuildRunning = errors.New("a ratings rebuild is already running")
+
+type ratingKey struct {
+	scope     string
+	scopeID   string
+	groupName string
+	name      string
+}
+
+type headToHeadKey struct {
+	groupNameA string
+	nameA      string
+	groupNameB string
+	nameB      string
+}
+
+type ratingHistoryKey struct {
+	groupName string
+	name      string
+	day       string
+}
+
+// biasGameRatings holds ratings while games are applied to them
+// head to head records only hold the rounds applied, they are added to the stored records when saving
+type biasGameRatings struct {
+	ratings     map[ratingKey]*models.BiasGameRatingEntry
+	changed     map[ratingKey]bool
+	headToHeads map[headToHeadKey]*models.BiasGameHeadToHeadEntry
+	history     map[ratingHistoryKey]float64
+}
+
+func newBiasGameRatings() *biasGameRatings {
+	return &biasGameRatings{
+		ratings:     make(map[ratingKey]*models.BiasGameRatingEntry),
+		changed:     make(map[ratingKey]bool),
+		headToHeads: make(map[headToHeadKey]*models.BiasGameHeadToHeadEntry),
+		history:     make(map[ratingHistoryKey]float64),
+	}
+}
+
+// load loads the stored ratings of a scope
+func (r *biasGameRatings) load(scope, scopeID string) error {
+	var entries []models.BiasGameRatingEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.BiasGameRatingsTable).Find(bson.M{"scope": scope, "scopeid": scopeID})).All(&entries)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		r.ratings[ratingKey{scope, scopeID, entries[i].GroupName, entries[i].Name}] = &entries[i]
+	}
+	return nil
+}
+
+// get returns the rating of the idol in the scope, idols without rating start at helpers.BiasGameRatingInitial
+func (r *biasGameRatings) get(scope, scopeID string, idol models.BiasGameIdolEntry) *models.BiasGameRatingEntry {
+	key := ratingKey{scope, scopeID, idol.GroupName, idol.Name}
+	r.changed[key] = true
+
+	if rating, ok := r.ratings[key]; ok {
+		return rating
+	}
+
+	rating := &models.BiasGameRatingEntry{
+		Scope:     scope,
+		ScopeID:   scopeID,
+		GroupName: idol.GroupName,
+		Name:      idol.Name,
+		Gender:    idol.Gender,
+		Rating:    helpers.BiasGameRatingInitial,
+	}
+	r.ratings[key] = rating
+	return rating
+}
+
+// applyGame updates the global, server, and user ratings and the head to head records with every round of the game
+func (r *biasGameRatings) applyGame(game models.BiasGameEntry, playedAt time.Time) {
+	type ratingScope struct {
+		scope   string
+		scopeID string
+	}
+	scopes := []ratingScope{{models.BiasGameRatingScopeGlobal, ""}}
+	if game.GuildID != "" {
+		scopes = append(scopes, ratingScope{models.BiasGameRatingScopeGuild, game.GuildID})
+	}
+	// multi games have no user
+	if game.UserID != "" {
+		scopes = append(scopes, ratingScope{models.BiasGameRatingScopeUser, game.UserID})
+	}
+
+	// round winners and losers are stored in the order the rounds have been played
+	for i := 0; i < len(game.RoundWinners) && i < len(game.RoundLosers); i++ {
+		winner := game.RoundWinners[i]
+		loser := game.RoundLosers[i]
+
+		for _, scope := range scopes {
+			winnerRating := r.get(scope.scope, scope.scopeID, winner)
+			loserRating := r.get(scope.scope, scope.scopeID, loser)
+
+			winnerRating.Rating, loserRating.Rating = helpers.BiasGameRatingUpdate(winnerRating.Rating, loserRating.Rating)
+			winnerRating.Wins++
+			loserRating.Losses++
+			winnerRating.UpdatedAt = playedAt
+			loserRating.UpdatedAt = playedAt
+		}
+
+		groupNameA, nameA, groupNameB, nameB, swapped := helpers.BiasGameHeadToHeadOrder(
+			winner.GroupName, winner.Name, loser.GroupName, loser.Name)
+		key := headToHeadKey{groupNameA, nameA, groupNameB, nameB}
+		headToHead, ok := r.headToHeads[key]
+		if !ok {
+			headToHead = &models.BiasGameHeadToHeadEntry{
+				GroupNameA: groupNameA,
+				NameA:      nameA,
+				GroupNameB: groupNameB,
+				NameB:      nameB,
+			}
+			r.headToHeads[key] = headToHead
+		}
+		if swapped {
+			headToHead.WinsB++
+		} else {
+			headToHead.WinsA++
+		}
+	}
+
+	// remember the global rating of every idol of the game at the end of the day
+	day := playedAt.UTC().Format("2006-01-02")
+	for _, idols := range [][]models.BiasGameIdolEntry{game.RoundWinners, game.RoundLosers} {
+		for _, idol := range idols {
+			if rating, ok := r.ratings[ratingKey{models.BiasGameRatingScopeGlobal, "", idol.GroupName, idol.Name}]; ok {
+				r.history[ratingHistoryKey{idol.GroupName, idol.Name, day}] = rating.Rating
+			}
+		}
+	}
+}
+
+// save stores the changed ratings and history and adds the applied rounds to the head to head records
+func (r *biasGameRatings) save() error {
+	var pairs []interface{}
+	for key := range r.changed {
+		rating := r.ratings[key]
+		pairs = append(pairs,
+			bson.M{"scope": rating.Scope, "scopeid": rating.ScopeID, "groupname": rating.GroupName, "name": rating.Name},
+			bson.M{"$set": bson.M{
+				"gender":    rating.Gender,
+				"rating":    rating.Rating,
+				"wins":      rating.Wins,
+				"losses":    rating.Losses,
+				"updatedat": rating.UpdatedAt,
+			}},
+		)
+	}
+	err := runRatingsBulkUpserts(models.BiasGameRatingsTable, pairs)
+	if err != nil {
+		return err
+	}
+
+	pairs = nil
+	for _, headToHead := range r.headToHeads {
+		pairs = append(pairs,
+			bson.M{"groupnamea": headToHead.GroupNameA, "namea": headToHead.NameA, "groupnameb": headToHead.GroupNameB, "nameb": headToHead.NameB},
+			bson.M{"$inc": bson.M{"winsa": headToHead.WinsA, "winsb": headToHead.WinsB}},
+		)
+	}
+	err = runRatingsBulkUpserts(models.BiasGameHeadToHeadTable, pairs)
+	if err != nil {
+		return err
+	}
+
+	pairs = nil
+	for key, rating := range r.history {
+		pairs = append(pairs,
+			bson.M{"groupname": key.groupName, "name": key.name, "day": key.day},
+			bson.M{"$set": bson.M{"rating": rating}},
+		)
+	}
+	return runRatingsBulkUpserts(models.BiasGameRatingHistoryTable, pairs)
+}
+
+// runRatingsBulkUpserts runs upserts of selector and update pairs in batches of ratingsBulkSize
+func runRatingsBulkUpserts(collection models.MongoDbCollection, pairs []interface{}) error {
+	for len(pairs) > 0 {
+		batchSize := ratingsBulkSize * 2
+		if len(pairs) < batchSize {
+			batchSize = len(pairs)
+		}
+
+		bulkOperation := helpers.MdbCollection(collection).Bulk()
+		bulkOperation.Unordered()
+		bulkOperation.Upsert(pairs[:batchSize]...)
+		_, err := bulkOperation.Run()
+		if err != nil {
+			return err
+		}
+
+		pairs = pairs[batchSize:]
+	}
+	return nil
+}
+
+// updateRatingsForGame applies a finished game to the stored ratings
+func updateRatingsForGame(game models.BiasGameEntry) {
+	defer helpers.Recover()
+
+	ratingsMutex.Lock()
+	defer ratingsMutex.Unlock()
+
+	ratings := newBiasGameRatings()
+	err := ratings.load(models.BiasGameRatingScopeGlobal, "")
+	helpers.Relax(err)
+	if game.GuildID != "" {
+		err = ratings.load(models.BiasGameRatingScopeGuild, game.GuildID)
+		helpers.Relax(err)
+	}
+	if game.UserID != "" {
+		err = ratings.load(models.BiasGameRatingScopeUser, game.UserID)
+		helpers.Relax(err)
+	}
+
+	ratings.applyGame(game, time.Now())
+
+	err = ratings.save()
+	helpers.Relax(err)
+}
+
+// rebuildRatings recalculates all ratings, head to head records, and the rating history from the recorded games
+// returns the amount of games applied
+func rebuildRatings() (games int, err error) {
+	if !atomic.CompareAndSwapInt32(&ratingsRebuilding, 0, 1) {
+		return 0, errRatingsRebuildRunning
+	}
+	defer atomic.StoreInt32(&ratingsRebuilding, 0)
+
+	ratingsMutex.Lock()
+	defer ratingsMutex.Unlock()
+
+	// apply all games in the order they have been played
+	ratings := newBiasGameRatings()
+	iter := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.BiasGameTable).Find(nil).Sort("_id"))
+	var game models.BiasGameEntry
+	for iter.Next(&game) {
+		ratings.applyGame(game, game.ID.Time())
+		games++
+		game = models.BiasGameEntry{}
+	}
+	err = iter.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, collection := range []models.MongoDbCollection{
+		models.BiasGameRatingsTable,
+		models.BiasGameHeadToHeadTable,
+		models.BiasGameRatingHistoryTable,
+	} {
+		_, err = helpers.MdbCollection(collection).RemoveAll(nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	bgLog().Infof("rebuilding biasgame ratings from %d games", games)
+	return games, ratings.save()
+}
+
+// rebuildRatingsFromMsg recalculates all ratings from the recorded games
+func rebuildRatingsFromMsg(msg *discordgo.Message) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	if atomic.LoadInt32(&ratingsRebuilding) != 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.rebuild-running"))
+		return
+	}
+
+	helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.rebuild-started"))
+
+	games, err := rebuildRatings()
+	if err == errRatingsRebuildRunning {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.rebuild-running"))
+		return
+	}
+	helpers.Relax(err)
+
+	helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.biasgame.ratings.rebuild-done", humanize.Comma(int64(games))))
+}
+
+// rebuildRatingsInBackground is used after game stats have been changed
+func rebuildRatingsInBackground() {
+	go func() {
+		defer helpers.Recover()
+
+		_, err := rebuildRatings()
+		if err != nil && err != errRatingsRebuildRunning {
+			helpers.RelaxLog(err)
+		}
+	}()
+}
+
+// getGlobalRating returns the global rating and the rating rank of the idol
+func getGlobalRating(groupName, name string) (rating *models.BiasGameRatingEntry, rank int, err error) {
+	rating = new(models.BiasGameRatingEntry)
+	err = helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.BiasGameRatingsTable).Find(bson.M{
+		"scope":     models.BiasGameRatingScopeGlobal,
+		"scopeid":   "",
+		"groupname": groupName,
+		"name":      name,
+	}), rating)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	higherRated, err := helpers.MdbCountWithoutLogging(models.BiasGameRatingsTable, bson.M{
+		"scope":   models.BiasGameRatingScopeGlobal,
+		"scopeid": "",
+		"rating":  bson.M{"$gt": rating.Rating},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return rating, higherRated + 1, nil
+}
+
+// getGroupBestGlobalRating returns the global rating of the highest rated idol of the group and its rank
+func getGroupBestGlobalRating(groupName string) (rating *models.BiasGameRatingEntry, rank int, err error) {
+	bestRating := new(models.BiasGameRatingEntry)
+	err = helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.BiasGameRatingsTable).Find(bson.M{
+		"scope":     models.BiasGameRatingScopeGlobal,
+		"scopeid":   "",
+		"groupname": groupName,
+	}).Sort("-rating"), bestRating)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return getGlobalRating(bestRating.GroupName, bestRating.Name)
+}
+
+// showIdolRatings displays the idols with the highest ratings globally, on the server, or for a user
+func showIdolRatings(msg *discordgo.Message, commandArgs []string) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	queryParams := bson.M{"scope": models.BiasGameRatingScopeGlobal, "scopeid": ""}
+	embedTitle := "Global Bias Game Idol Ratings"
+	iconURL := cache.GetSession().State.User.AvatarURL("512")
+
+	for _, arg := range commandArgs[1:] {
+		if gender, ok := biasGameGenders[arg]; ok && gender != "mixed" {
+			queryParams["gender"] = gender
+			continue
+		}
+
+		if arg == "server" {
+			channel, err := helpers.GetChannel(msg.ChannelID)
+			helpers.Relax(err)
+			guild, err := helpers.GetGuild(channel.GuildID)
+			helpers.Relax(err)
+
+			queryParams["scope"] = models.BiasGameRatingScopeGuild
+			queryParams["scopeid"] = guild.ID
+			embedTitle = fmt.Sprintf("%s - Bias Game Idol Ratings", guild.Name)
+			iconURL = discordgo.EndpointGuildIcon(guild.ID, guild.Icon)
+			continue
+		}
+
+		targetUser := msg.Author
+		if arg != "me" {
+			user, err := helpers.GetUserFromMention(arg)
+			if err != nil {
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				return
+			}
+			targetUser = user
+		}
+		queryParams["scope"] = models.BiasGameRatingScopeUser
+		queryParams["scopeid"] = targetUser.ID
+		embedTitle = fmt.Sprintf("%s - Bias Game Idol Ratings", targetUser.Username)
+		iconURL = targetUser.AvatarURL("512")
+	}
+
+	var ratings []models.BiasGameRatingEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.BiasGameRatingsTable).Find(queryParams).Sort("-rating").Limit(99)).All(&ratings)
+	helpers.Relax(err)
+
+	if len(ratings) == 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.no-ratings"))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color: 0x0FADED, // blueish
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    embedTitle,
+			IconURL: iconURL,
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Ratings change with every round won or lost, beating higher rated idols counts more",
+		},
+	}
+
+	for i, rating := range ratings {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("Rank #%d", i+1),
+			Value: fmt.Sprintf("**%s** %s\n%s (%s W / %s L)",
+				rating.GroupName, rating.Name,
+				strconv.FormatFloat(rating.Rating, 'f', 0, 64),
+				humanize.Comma(int64(rating.Wins)), humanize.Comma(int64(rating.Losses))),
+			Inline: true,
+		})
+	}
+
+	helpers.SendPagedMessage(msg, embed, 21)
+}
+
+// showIdolRatingHistory displays the daily global ratings of an idol
+func showIdolRatingHistory(msg *discordgo.Message, content string) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	commandArgs, err := helpers.ToArgv(content)
+	if err != nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+	commandArgs = commandArgs[1:]
+
+	if len(commandArgs) < 2 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	_, _, bias := getMatchingIdolAndGroup(commandArgs[0], commandArgs[1])
+	if bias == nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.stats.no-matching-idol"))
+		return
+	}
+
+	var history []models.BiasGameRatingHistoryEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.BiasGameRatingHistoryTable).Find(bson.M{
+		"groupname": bias.GroupName,
+		"name":      bias.BiasName,
+	}).Sort("-day").Limit(30)).All(&history)
+	helpers.Relax(err)
+
+	if len(history) == 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.no-history"))
+		return
+	}
+
+	// display oldest to newest
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Day < history[j].Day
+	})
+
+	var historyText string
+	for i, entry := range history {
+		change := ""
+		if i > 0 {
+			change = fmt.Sprintf(" (%+.0f)", entry.Rating-history[i-1].Rating)
+		}
+		historyText += fmt.Sprintf("%s: %s%s\n", entry.Day, strconv.FormatFloat(entry.Rating, 'f', 0, 64), change)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color: 0x0FADED, // blueish
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: fmt.Sprintf("Rating History for %s %s", bias.GroupName, bias.BiasName),
+		},
+		Description: "```" + historyText + "```",
+	}
+
+	if rating, rank, err := getGlobalRating(bias.GroupName, bias.BiasName); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Current Rating", Value: strconv.FormatFloat(rating.Rating, 'f', 0, 64), Inline: true})
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Rating Rank", Value: fmt.Sprintf("Rank #%d", rank), Inline: true})
+	}
+
+	helpers.SendEmbed(msg.ChannelID, embed)
+}
+
+// showHeadToHead displays the rounds between two idols, or all head to head records of one idol
+func showHeadToHead(msg *discordgo.Message, content string) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	commandArgs, err := helpers.ToArgv(content)
+	if err != nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+	commandArgs = commandArgs[1:]
+
+	if len(commandArgs) != 2 && len(commandArgs) != 4 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+
+	_, _, bias := getMatchingIdolAndGroup(commandArgs[0], commandArgs[1])
+	if bias == nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.stats.no-matching-idol"))
+		return
+	}
+
+	if len(commandArgs) == 4 {
+		_, _, opponent := getMatchingIdolAndGroup(commandArgs[2], commandArgs[3])
+		if opponent == nil {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.stats.no-matching-idol"))
+			return
+		}
+
+		showHeadToHeadOfIdols(msg, bias, opponent)
+		return
+	}
+
+	var headToHeads []models.BiasGameHeadToHeadEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.BiasGameHeadToHeadTable).Find(bson.M{"$or": []bson.M{
+		{"groupnamea": bias.GroupName, "namea": bias.BiasName},
+		{"groupnameb": bias.GroupName, "nameb": bias.BiasName},
+	}})).All(&headToHeads)
+	helpers.Relax(err)
+
+	if len(headToHeads) == 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.no-head-to-head"))
+		return
+	}
+
+	type opponentRecord struct {
+		groupName string
+		name      string
+		wins      int
+		losses    int
+	}
+	records := make([]opponentRecord, 0)
+	for _, headToHead := range headToHeads {
+		if headToHead.GroupNameA == bias.GroupName && headToHead.NameA == bias.BiasName {
+			records = append(records, opponentRecord{headToHead.GroupNameB, headToHead.NameB, headToHead.WinsA, headToHead.WinsB})
+		} else {
+			records = append(records, opponentRecord{headToHead.GroupNameA, headToHead.NameA, headToHead.WinsB, headToHead.WinsA})
+		}
+	}
+
+	// most played opponents first
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].wins+records[i].losses > records[j].wins+records[j].losses
+	})
+	if len(records) > 99 {
+		records = records[:99]
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color: 0x0FADED, // blueish
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: fmt.Sprintf("Head to Head Records of %s %s", bias.GroupName, bias.BiasName),
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Rounds won - rounds lost",
+		},
+	}
+	for _, record := range records {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("vs %s %s", record.groupName, record.name),
+			Value:  fmt.Sprintf("%s - %s", humanize.Comma(int64(record.wins)), humanize.Comma(int64(record.losses))),
+			Inline: true,
+		})
+	}
+
+	helpers.SendPagedMessage(msg, embed, 21)
+}
+
+// showHeadToHeadOfIdols displays the rounds between two idols
+func showHeadToHeadOfIdols(msg *discordgo.Message, bias, opponent *biasChoice) {
+	groupNameA, nameA, groupNameB, nameB, swapped := helpers.BiasGameHeadToHeadOrder(
+		bias.GroupName, bias.BiasName, opponent.GroupName, opponent.BiasName)
+
+	var headToHead models.BiasGameHeadToHeadEntry
+	err := helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.BiasGameHeadToHeadTable).Find(bson.M{
+		"groupnamea": groupNameA,
+		"namea":      nameA,
+		"groupnameb": groupNameB,
+		"nameb":      nameB,
+	}), &headToHead)
+	if helpers.IsMdbNotFound(err) {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.ratings.no-head-to-head"))
+		return
+	}
+	helpers.Relax(err)
+
+	biasWins, opponentWins := headToHead.WinsA, headToHead.WinsB
+	if swapped {
+		biasWins, opponentWins = opponentWins, biasWins
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color: 0x0FADED, // blueish
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: fmt.Sprintf("%s %s vs %s %s", bias.GroupName, bias.BiasName, opponent.GroupName, opponent.BiasName),
+		},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: fmt.Sprintf("%s %s Won", bias.GroupName, bias.BiasName), Value: humanize.Comma(int64(biasWins)), Inline: true},
+			{Name: fmt.Sprintf("%s %s Won", opponent.GroupName, opponent.BiasName), Value: humanize.Comma(int64(opponentWins)), Inline: true},
+			{Name: "Rounds", Value: humanize.Comma(int64(biasWins + opponentWins)), Inline: true},
+		},
+	}
+
+	for _, idol := range []*biasChoice{bias, opponent} {
+		if rating, rank, err := getGlobalRating(idol.GroupName, idol.BiasName); err == nil {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   fmt.Sprintf("%s %s Rating", idol.GroupName, idol.BiasName),
+				Value:  fmt.Sprintf("%s (Rank #%d)", strconv.FormatFloat(rating.Rating, 'f', 0, 64), rank),
+				Inline: true,
+			})
+		}
+	}
+
+	helpers.SendEmbed(msg.ChannelID, embed)
+}
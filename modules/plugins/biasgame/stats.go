@@ -492,6 +492,8 @@ func recordSingleGamesStats(game *singleBiasGame) {
 	}
 
 	helpers.MDbInsert(models.BiasGameTable, biasGameEntry)
+
+	go updateRatingsForGame(biasGameEntry)
 }
 
 // recordSingleGamesStats will record the winner, round winners/losers, and other misc stats of a game
@@ -521,6 +523,8 @@ func recordMultiGamesStats(game *multiBiasGame) {
 	}
 
 	helpers.MDbInsert(models.BiasGameTable, biasGameEntry)
+
+	go updateRatingsForGame(biasGameEntry)
 }
 
 // getStatsQueryInfo will get the stats results based on the stats message
@@ -898,6 +902,10 @@ MultiWinLoop:
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Round Win %", Value: strconv.FormatFloat(roundWinPercentage, 'f', 2, 64) + "%", Inline: true})
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "User With Most Wins", Value: fmt.Sprintf("%s (%s wins)", userNameMostWins, humanize.Comma(int64(highestUserWins))), Inline: true})
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Server With Most Wins", Value: fmt.Sprintf("%s (%s wins)", guildNameMostWins, humanize.Comma(int64(highestServerWins))), Inline: true})
+	if rating, ratingRank, err := getGlobalRating(bias.GroupName, bias.BiasName); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Rating", Value: strconv.FormatFloat(rating.Rating, 'f', 0, 64), Inline: true})
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Rating Rank", Value: fmt.Sprintf("Rank #%d", ratingRank), Inline: true})
+	}
 
 	// get random image from the thumbnail
 	imageIndex := rand.Intn(len(bias.BiasImages))
@@ -1140,6 +1148,10 @@ MultiWinLoop:
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Round Win %", Value: strconv.FormatFloat(roundWinPercentage, 'f', 2, 64) + "%", Inline: true})
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "User With Most Wins", Value: fmt.Sprintf("%s (%s wins)", userNameMostWins, humanize.Comma(int64(highestUserWins))), Inline: true})
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Server With Most Wins", Value: fmt.Sprintf("%s (%s wins)", guildNameMostWins, humanize.Comma(int64(highestServerWins))), Inline: true})
+	if rating, ratingRank, err := getGroupBestGlobalRating(targetGroupName); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Highest Rated Member", Value: fmt.Sprintf("%s (%s)", rating.Name, strconv.FormatFloat(rating.Rating, 'f', 0, 64)), Inline: true})
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Highest Rating Rank", Value: fmt.Sprintf("Rank #%d", ratingRank), Inline: true})
+	}
 
 	// get random image from the thumbnail
 	imageIndex := rand.Intn(len(allGroupImages))
@@ -1223,5 +1235,10 @@ func updateGameStats(targetGroup, targetName, newGroup, newName, newGender strin
 		}
 	}
 
+	// ratings and head to head records are keyed by group and name, recalculate them with the changed games
+	if modified > 0 {
+		rebuildRatingsInBackground()
+	}
+
 	return matched, modified
 }
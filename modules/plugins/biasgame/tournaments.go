@@ -0,0 +1,461 @@
+package biasgame
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/globalsign/mgo/bson"
+)
+
+var tournamentSizes = map[int]bool{
+	8:  true,
+	16: true,
+	32: true,
+	64: true,
+}
+
+// getTournamentMatchDuration returns how long the votes for a tournament match are open, tournaments play one match per day
+func getTournamentMatchDuration() time.Duration {
+	if helpers.DEBUG_MODE {
+		return time.Minute * 2
+	}
+	return time.Hour * 24
+}
+
+// handleTournamentCommand handles the tournament sub commands
+func handleTournamentCommand(msg *discordgo.Message, commandArgs []string) {
+	if len(commandArgs) < 2 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	switch commandArgs[1] {
+	case "start":
+		helpers.RequireMod(msg, func() {
+			startTournament(msg, commandArgs[2:])
+		})
+	case "stop":
+		helpers.RequireMod(msg, func() {
+			stopTournament(msg)
+		})
+	case "status", "bracket":
+		showTournamentBracket(msg)
+	default:
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+	}
+}
+
+// getRunningTournament returns the running tournament of the server
+func getRunningTournament(guildID string) (tournament *models.BiasGameTournamentEntry, err error) {
+	tournament = new(models.BiasGameTournamentEntry)
+	err = helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.BiasGameTournamentsTable).Find(bson.M{"guildid": guildID, "running": true}), tournament)
+	if err != nil {
+		return nil, err
+	}
+	return tournament, nil
+}
+
+// startTournament starts a server wide tournament in the given channel
+func startTournament(msg *discordgo.Message, commandArgs []string) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	if len(commandArgs) < 1 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+	targetChannel, err := helpers.GetChannelFromMention(msg, commandArgs[0])
+	if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+
+	// only one tournament can run per server
+	_, err = getRunningTournament(channel.GuildID)
+	if err == nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.tournament.already-running"))
+		return
+	}
+	if !helpers.IsMdbNotFound(err) {
+		helpers.Relax(err)
+	}
+
+	options, ok := parseGameOptions(msg, commandArgs[1:], tournamentSizes, "plugins.biasgame.tournament.invalid-size", 16)
+	if !ok {
+		return
+	}
+
+	biasChoices := getGameBiasChoices(channel.GuildID, options)
+	if len(biasChoices) < options.Size {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.game.not-enough-idols"))
+		return
+	}
+
+	tournament := &models.BiasGameTournamentEntry{
+		GuildID:         channel.GuildID,
+		ChannelID:       targetChannel.ID,
+		StartedByUserID: msg.Author.ID,
+		Gender:          options.Gender,
+		Running:         true,
+		StartedAt:       time.Now(),
+	}
+
+	// get random biases for the bracket
+	for _, randomIndex := range rand.Perm(len(biasChoices))[:options.Size] {
+		bias := biasChoices[randomIndex]
+
+		imageIndex := 0
+		if len(bias.BiasImages) > 0 {
+			imageIndex = rand.Intn(len(bias.BiasImages))
+		}
+
+		tournament.Entrants = append(tournament.Entrants, models.BiasGameTournamentIdol{
+			GroupName:  bias.GroupName,
+			Name:       bias.BiasName,
+			Gender:     bias.Gender,
+			ImageIndex: imageIndex,
+		})
+	}
+
+	err = postTournamentMatch(tournament, "")
+	if err != nil {
+		if checkPermissionError(err, targetChannel.ID) {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.errors.no-file"))
+			return
+		}
+		helpers.Relax(err)
+	}
+
+	_, err = helpers.MDbInsert(models.BiasGameTournamentsTable, tournament)
+	helpers.Relax(err)
+
+	helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.biasgame.tournament.started", targetChannel.ID))
+}
+
+// stopTournament stops the running tournament of the server without recording it
+func stopTournament(msg *discordgo.Message) {
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	tournament, err := getRunningTournament(channel.GuildID)
+	if helpers.IsMdbNotFound(err) {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.tournament.not-running"))
+		return
+	}
+	helpers.Relax(err)
+
+	tournament.Running = false
+	err = helpers.MDbUpdate(models.BiasGameTournamentsTable, tournament.ID, tournament)
+	helpers.Relax(err)
+
+	helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.tournament.stopped"))
+}
+
+// showTournamentBracket sends the current bracket of the running tournament of the server
+func showTournamentBracket(msg *discordgo.Message) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	tournament, err := getRunningTournament(channel.GuildID)
+	if helpers.IsMdbNotFound(err) {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.biasgame.tournament.not-running"))
+		return
+	}
+	helpers.Relax(err)
+
+	queue := getTournamentQueue(tournament)
+	round, _ := helpers.BiasGameBracketMatch(len(tournament.Entrants), len(tournament.RoundWinners))
+	messageString := fmt.Sprintf("**Bias Game Tournament** in <#%s> - %s\nCurrent Match: %s %s vs %s %s, ends %s",
+		tournament.ChannelID,
+		getTournamentRoundName(len(tournament.Entrants), round),
+		queue[0].GroupName, queue[0].Name,
+		queue[1].GroupName, queue[1].Name,
+		humanize.Time(tournament.CurrentMatchEndsAt))
+
+	_, err = helpers.SendFile(msg.ChannelID, "biasgame_tournament_bracket.png",
+		bytes.NewReader(makeTournamentBracketImage(tournament)), messageString)
+	helpers.Relax(err)
+}
+
+// startTournamentLoop ends the tournament matches when their votes are closed and posts the next match
+func startTournamentLoop() {
+	bgLog().Info("Starting biasgame tournament loop")
+
+	go func() {
+		defer helpers.Recover()
+
+		for {
+			time.Sleep(time.Minute)
+
+			var tournaments []models.BiasGameTournamentEntry
+			err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.BiasGameTournamentsTable).Find(bson.M{
+				"running":            true,
+				"currentmatchendsat": bson.M{"$lte": time.Now()},
+			})).All(&tournaments)
+			if err != nil {
+				helpers.RelaxLog(err)
+				continue
+			}
+
+			for i := range tournaments {
+				processTournamentMatch(&tournaments[i])
+			}
+		}
+	}()
+}
+
+// processTournamentMatch counts the votes of the current match and posts the next match or the winner
+func processTournamentMatch(tournament *models.BiasGameTournamentEntry) {
+	defer helpers.Recover()
+
+	// stop tournaments in deleted channels
+	if _, err := helpers.GetChannelWithoutApi(tournament.ChannelID); err != nil {
+		tournament.Running = false
+		err = helpers.MDbUpdateWithoutLogging(models.BiasGameTournamentsTable, tournament.ID, tournament)
+		helpers.Relax(err)
+		return
+	}
+
+	lastResult := ""
+	// an empty message id means posting the match failed and gets retried
+	if tournament.CurrentMessageID != "" {
+		queue := getTournamentQueue(tournament)
+
+		leftCount := 0
+		rightCount := 0
+		message, err := cache.GetSession().ChannelMessage(tournament.ChannelID, tournament.CurrentMessageID)
+		if err == nil {
+			for _, reaction := range message.Reactions {
+				// ignore reactions not from bot
+				if reaction.Me == false {
+					continue
+				}
+
+				if reaction.Emoji.Name == LEFT_ARROW_EMOJI {
+					leftCount = reaction.Count
+				}
+				if reaction.Emoji.Name == RIGHT_ARROW_EMOJI {
+					rightCount = reaction.Count
+				}
+			}
+		}
+
+		// if votes are even, choose one at random
+		winnerIndex := 0
+		if rightCount > leftCount || (rightCount == leftCount && rand.Intn(100) >= 50) {
+			winnerIndex = 1
+		}
+
+		tournament.RoundWinners = append(tournament.RoundWinners, queue[winnerIndex])
+		tournament.RoundLosers = append(tournament.RoundLosers, queue[1-winnerIndex])
+		tournament.CurrentMessageID = ""
+		lastResult = fmt.Sprintf("%s %s won against %s %s!", queue[winnerIndex].GroupName, queue[winnerIndex].Name,
+			queue[1-winnerIndex].GroupName, queue[1-winnerIndex].Name)
+
+		if len(tournament.RoundWinners) >= len(tournament.Entrants)-1 {
+			finishTournament(tournament)
+			return
+		}
+	}
+
+	err := postTournamentMatch(tournament, lastResult)
+	if err != nil {
+		bgLog().Warnf("posting biasgame tournament match failed, retrying in ten minutes: %s", err.Error())
+		tournament.CurrentMatchEndsAt = time.Now().Add(time.Minute * 10)
+	}
+
+	// the tournament could have been stopped in the meantime
+	err = helpers.MDbUpdateQueryWithoutLogging(models.BiasGameTournamentsTable,
+		bson.M{"_id": tournament.ID, "running": true}, tournament)
+	if helpers.IsMdbNotFound(err) {
+		return
+	}
+	helpers.Relax(err)
+}
+
+// postTournamentMatch posts the next match of the tournament, and the bracket if a new round starts
+func postTournamentMatch(tournament *models.BiasGameTournamentEntry, lastResult string) error {
+	queue := getTournamentQueue(tournament)
+	matchNumber := len(tournament.RoundWinners)
+	round, _ := helpers.BiasGameBracketMatch(len(tournament.Entrants), matchNumber)
+	roundName := getTournamentRoundName(len(tournament.Entrants), round)
+
+	if lastResult != "" {
+		lastResult += "\n"
+	}
+
+	// post the bracket when a new round of the bracket starts
+	previousRound := -1
+	if matchNumber > 0 {
+		previousRound, _ = helpers.BiasGameBracketMatch(len(tournament.Entrants), matchNumber-1)
+	}
+	if round != previousRound {
+		_, err := helpers.SendFile(tournament.ChannelID, "biasgame_tournament_bracket.png",
+			bytes.NewReader(makeTournamentBracketImage(tournament)),
+			fmt.Sprintf("%s**Bias Game Tournament** - %s starts now!", lastResult, roundName))
+		if err != nil {
+			return err
+		}
+		lastResult = ""
+	}
+
+	img1 := getTournamentIdolImage(tournament, queue[0].GroupName, queue[0].Name)
+	img2 := getTournamentIdolImage(tournament, queue[1].GroupName, queue[1].Name)
+
+	matchEndsAt := time.Now().Add(getTournamentMatchDuration())
+	messageString := fmt.Sprintf("%s**Bias Game Tournament** - %s\n%s %s vs %s %s\nVote with the arrows, the match ends %s.",
+		lastResult,
+		roundName,
+		queue[0].GroupName, queue[0].Name,
+		queue[1].GroupName, queue[1].Name,
+		humanize.Time(matchEndsAt))
+
+	fileSendMsg, err := helpers.SendFile(tournament.ChannelID, "combined_pic.png",
+		bytes.NewReader(encodeBracketImage(makeVSImage(img1, img2))), messageString)
+	if err != nil {
+		return err
+	}
+
+	// add reactions
+	cache.GetSession().MessageReactionAdd(tournament.ChannelID, fileSendMsg[0].ID, LEFT_ARROW_EMOJI)
+	cache.GetSession().MessageReactionAdd(tournament.ChannelID, fileSendMsg[0].ID, RIGHT_ARROW_EMOJI)
+
+	tournament.CurrentMessageID = fileSendMsg[0].ID
+	tournament.CurrentMatchEndsAt = matchEndsAt
+	return nil
+}
+
+// finishTournament posts the final bracket and records the tournament as a game
+func finishTournament(tournament *models.BiasGameTournamentEntry) {
+	// stopped tournaments aren't recorded
+	tournament.Running = false
+	err := helpers.MDbUpdateQueryWithoutLogging(models.BiasGameTournamentsTable,
+		bson.M{"_id": tournament.ID, "running": true}, tournament)
+	if helpers.IsMdbNotFound(err) {
+		return
+	}
+	helpers.Relax(err)
+
+	gameWinner := tournament.RoundWinners[len(tournament.RoundWinners)-1]
+
+	messageString := fmt.Sprintf("**Bias Game Tournament**\nWinner: %s %s!", gameWinner.GroupName, gameWinner.Name)
+	winnerMsgs, err := helpers.SendFile(tournament.ChannelID, "biasgame_tournament_winner.png",
+		bytes.NewReader(makeTournamentBracketImage(tournament)), messageString)
+	helpers.RelaxLog(err)
+
+	// if the winner is nayoung, add a nayoung emoji <3 <3 <3
+	if err == nil && strings.ToLower(gameWinner.GroupName) == "pristin" && strings.ToLower(gameWinner.Name) == "nayoung" {
+		cache.GetSession().MessageReactionAdd(tournament.ChannelID, winnerMsgs[0].ID, getRandomNayoungEmoji())
+	}
+
+	biasGameEntry := models.BiasGameEntry{
+		ID:           "",
+		GuildID:      tournament.GuildID,
+		GameType:     "tournament",
+		Gender:       tournament.Gender,
+		RoundWinners: tournament.RoundWinners,
+		RoundLosers:  tournament.RoundLosers,
+		GameWinner:   gameWinner,
+	}
+
+	helpers.MDbInsert(models.BiasGameTable, biasGameEntry)
+
+	go updateRatingsForGame(biasGameEntry)
+}
+
+// getTournamentQueue returns the idols waiting for their next match, the first two play the current match
+func getTournamentQueue(tournament *models.BiasGameTournamentEntry) (queue []models.BiasGameIdolEntry) {
+	for _, entrant := range tournament.Entrants {
+		queue = append(queue, models.BiasGameIdolEntry{
+			Name:      entrant.Name,
+			GroupName: entrant.GroupName,
+			Gender:    entrant.Gender,
+		})
+	}
+
+	// add winner to end of bias queue and remove first two, same as in games
+	for _, winner := range tournament.RoundWinners {
+		queue = append(queue[2:], winner)
+	}
+
+	return queue
+}
+
+// getTournamentRoundName returns the name of a round of a bracket with the size
+func getTournamentRoundName(size, round int) string {
+	switch helpers.BiasGameBracketRounds(size) - round {
+	case 1:
+		return "Final"
+	case 2:
+		return "Semifinals"
+	case 3:
+		return "Quarterfinals"
+	}
+	return fmt.Sprintf("Round of %d", size>>uint(round))
+}
+
+// getTournamentIdolImage returns the image of an idol used throughout the tournament
+func getTournamentIdolImage(tournament *models.BiasGameTournamentEntry, groupName, name string) image.Image {
+	imageIndex := 0
+	for _, entrant := range tournament.Entrants {
+		if entrant.GroupName == groupName && entrant.Name == name {
+			imageIndex = entrant.ImageIndex
+			break
+		}
+	}
+
+	for _, bias := range getAllBiases() {
+		if bias.GroupName != groupName || bias.BiasName != name || len(bias.BiasImages) == 0 {
+			continue
+		}
+
+		// the images might have changed since the tournament started
+		if imageIndex >= len(bias.BiasImages) {
+			imageIndex = 0
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(bias.BiasImages[imageIndex].getImgBytes()))
+		if err == nil {
+			return img
+		}
+	}
+
+	// idols removed from the game get an empty picture
+	return image.NewRGBA(image.Rect(0, 0, IMAGE_RESIZE_HEIGHT, IMAGE_RESIZE_HEIGHT))
+}
+
+// makeTournamentBracketImage draws the bracket of the tournament with all matches played
+func makeTournamentBracketImage(tournament *models.BiasGameTournamentEntry) []byte {
+	idolImages := make(map[string]image.Image)
+	getImage := func(groupName, name string) image.Image {
+		key := groupName + "\x00" + name
+		if _, ok := idolImages[key]; !ok {
+			idolImages[key] = getTournamentIdolImage(tournament, groupName, name)
+		}
+		return idolImages[key]
+	}
+
+	var entrants []image.Image
+	for _, entrant := range tournament.Entrants {
+		entrants = append(entrants, getImage(entrant.GroupName, entrant.Name))
+	}
+	var winners []image.Image
+	for _, winner := range tournament.RoundWinners {
+		winners = append(winners, getImage(winner.GroupName, winner.Name))
+	}
+
+	return encodeBracketImage(makeBracketImage(entrants, winners))
+}
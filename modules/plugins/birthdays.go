@@ -0,0 +1,575 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/sirupsen/logrus"
+)
+
+type birthdaysAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next birthdaysAction)
+
+type Birthdays struct{}
+
+const (
+	birthdaysUpcomingDays = 30
+	birthdaysRoleDuration = 24 * time.Hour
+)
+
+func (m *Birthdays) Commands() []string {
+	return []string{
+		"birthdays",
+	}
+}
+
+func (m *Birthdays) Init(session *discordgo.Session) {
+	go m.announcementLoop()
+	m.logger().Info("started announcementLoop loop (10m)")
+}
+
+func (m *Birthdays) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	if !helpers.ModuleIsAllowed(msg.ChannelID, msg.ID, msg.Author.ID, helpers.ModulePermBirthdays) {
+		return
+	}
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := m.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (m *Birthdays) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if len(args) < 1 {
+		return m.actionUpcoming
+	}
+
+	switch args[0] {
+	case "upcoming", "list":
+		return m.actionUpcoming
+	case "hide-year":
+		return m.actionHideYear
+	case "opt-out", "opt-in":
+		return m.actionOptOut
+	case "set-channel":
+		return m.actionSetChannel
+	case "set-message":
+		return m.actionSetMessage
+	case "set-role":
+		return m.actionSetRole
+	case "status", "settings":
+		return m.actionStatus
+	}
+
+	*out = m.newMsg("bot.arguments.invalid")
+	return m.actionFinish
+}
+
+// [p]birthdays [upcoming]
+func (m *Birthdays) actionUpcoming(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	upcoming, err := GetUpcomingBirthdays(channel.GuildID, time.Now(), time.Now().AddDate(0, 0, birthdaysUpcomingDays))
+	helpers.Relax(err)
+
+	if len(upcoming) <= 0 {
+		*out = m.newMsg("plugins.birthdays.upcoming-none", birthdaysUpcomingDays)
+		return m.actionFinish
+	}
+
+	upcomingText := helpers.GetTextF("plugins.birthdays.upcoming-title", birthdaysUpcomingDays) + "\n"
+	for _, item := range upcoming {
+		dateText := item.Birthday.Format("Jan 2")
+		if helpers.IsBirthday(item.Userdata, time.Now()) {
+			dateText = helpers.GetText("plugins.birthdays.upcoming-today")
+		}
+		ageText := ""
+		if age := helpers.GetBirthdayAge(item.Userdata, item.Birthday); age > 0 {
+			ageText = helpers.GetTextF("plugins.birthdays.upcoming-age", age)
+		}
+		upcomingText += helpers.GetTextF("plugins.birthdays.upcoming-entry",
+			dateText, item.Member.User.Username, item.Member.User.Discriminator, ageText) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(upcomingText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+// [p]birthdays hide-year
+func (m *Birthdays) actionHideYear(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	userdata, err := helpers.GetUserUserdata(in.Author.ID)
+	helpers.Relax(err)
+
+	userdata.BirthdayHideYear = !userdata.BirthdayHideYear
+	err = helpers.MDbUpdate(models.ProfileUserdataTable, userdata.ID, userdata)
+	helpers.Relax(err)
+
+	if userdata.BirthdayHideYear {
+		*out = m.newMsg("plugins.birthdays.hide-year-enabled")
+	} else {
+		*out = m.newMsg("plugins.birthdays.hide-year-disabled")
+	}
+	return m.actionFinish
+}
+
+// [p]birthdays opt-out
+func (m *Birthdays) actionOptOut(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	userdata, err := helpers.GetUserUserdata(in.Author.ID)
+	helpers.Relax(err)
+
+	optedOut := false
+	newOptOutGuildIDs := make([]string, 0)
+	for _, guildID := range userdata.BirthdayOptOutGuildIDs {
+		if guildID == channel.GuildID {
+			optedOut = true
+			continue
+		}
+		newOptOutGuildIDs = append(newOptOutGuildIDs, guildID)
+	}
+	if !optedOut {
+		newOptOutGuildIDs = append(newOptOutGuildIDs, channel.GuildID)
+	}
+
+	userdata.BirthdayOptOutGuildIDs = newOptOutGuildIDs
+	err = helpers.MDbUpdate(models.ProfileUserdataTable, userdata.ID, userdata)
+	helpers.Relax(err)
+
+	if optedOut {
+		*out = m.newMsg("plugins.birthdays.opt-in-success")
+	} else {
+		*out = m.newMsg("plugins.birthdays.opt-out-success")
+	}
+	return m.actionFinish
+}
+
+// [p]birthdays set-channel <#channel or none>
+func (m *Birthdays) actionSetChannel(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	if !helpers.IsAdmin(in) {
+		*out = m.newMsg("admin.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if args[1] == "none" || args[1] == "off" {
+		settings.BirthdaysChannelID = ""
+		err = helpers.GuildSettingsSet(channel.GuildID, settings)
+		helpers.Relax(err)
+
+		*out = m.newMsg("plugins.birthdays.set-channel-disabled")
+		return m.actionFinish
+	}
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.GuildID != channel.GuildID {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	settings.BirthdaysChannelID = targetChannel.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = m.newMsg("plugins.birthdays.set-channel-success", targetChannel.ID)
+	return m.actionFinish
+}
+
+// [p]birthdays set-message [<embed code>]
+func (m *Birthdays) actionSetMessage(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	if !helpers.IsAdmin(in) {
+		*out = m.newMsg("admin.no_permission")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	var code string
+	if len(args) >= 2 {
+		code = strings.TrimSpace(in.Content[strings.Index(in.Content, args[0])+len(args[0]):])
+	}
+	settings.BirthdaysCode = code
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	if code == "" {
+		*out = m.newMsg("plugins.birthdays.set-message-reset")
+	} else {
+		*out = m.newMsg("plugins.birthdays.set-message-success")
+	}
+	return m.actionFinish
+}
+
+// [p]birthdays set-role <role or none>
+func (m *Birthdays) actionSetRole(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	if !helpers.IsAdmin(in) {
+		*out = m.newMsg("admin.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	guild, err := helpers.GetGuild(channel.GuildID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if args[1] == "none" || args[1] == "off" {
+		settings.BirthdaysRoleID = ""
+		err = helpers.GuildSettingsSet(channel.GuildID, settings)
+		helpers.Relax(err)
+
+		*out = m.newMsg("plugins.birthdays.set-role-disabled")
+		return m.actionFinish
+	}
+
+	roleText := strings.TrimSpace(strings.Join(args[1:], " "))
+	var targetRole *discordgo.Role
+	for _, role := range guild.Roles {
+		if role.ID == roleText || "<@&"+role.ID+">" == roleText ||
+			strings.ToLower(role.Name) == strings.ToLower(roleText) {
+			targetRole = role
+			break
+		}
+	}
+	if targetRole == nil {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	settings.BirthdaysRoleID = targetRole.ID
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	*out = m.newMsg("plugins.birthdays.set-role-success", targetRole.Name)
+	return m.actionFinish
+}
+
+// [p]birthdays status
+func (m *Birthdays) actionStatus(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	if !helpers.IsMod(in) {
+		*out = m.newMsg("mod.no_permission")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if settings.BirthdaysChannelID == "" {
+		*out = m.newMsg("plugins.birthdays.status-disabled")
+		return m.actionFinish
+	}
+
+	roleText := helpers.GetText("plugins.birthdays.status-none")
+	if settings.BirthdaysRoleID != "" {
+		roleText = "<@&" + settings.BirthdaysRoleID + ">"
+	}
+	code := settings.BirthdaysCode
+	if code == "" {
+		code = helpers.GetText("plugins.birthdays.default-message")
+	}
+
+	*out = m.newMsg("plugins.birthdays.status", settings.BirthdaysChannelID, roleText, code)
+	return m.actionFinish
+}
+
+func (m *Birthdays) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) birthdaysAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (m *Birthdays) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (m *Birthdays) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "birthdays")
+}
+
+// announcementLoop announces birthdays as soon as the day starts in the timezone of the user
+func (m *Birthdays) announcementLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			m.logger().Error("the announcementLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.announcementLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(10 * time.Minute)
+
+		guildIDs := make([]string, 0)
+		for _, guild := range cache.GetSession().State.Guilds {
+			if helpers.GuildSettingsGetCached(guild.ID).BirthdaysChannelID != "" {
+				guildIDs = append(guildIDs, guild.ID)
+			}
+		}
+		if len(guildIDs) <= 0 {
+			continue
+		}
+
+		var userdataBucket []models.ProfileUserdataEntry
+		err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ProfileUserdataTable).Find(
+			bson.M{"birthday": bson.M{"$ne": ""}},
+		)).All(&userdataBucket)
+		helpers.Relax(err)
+
+		now := time.Now()
+		for _, userdata := range userdataBucket {
+			if !helpers.IsBirthday(userdata, now) {
+				continue
+			}
+			for _, guildID := range guildIDs {
+				if birthdaysOptedOut(userdata, guildID) {
+					continue
+				}
+				err = m.announce(guildID, userdata, now)
+				helpers.RelaxLog(err)
+			}
+		}
+	}
+}
+
+func (m *Birthdays) announce(guildID string, userdata models.ProfileUserdataEntry, now time.Time) (err error) {
+	member, err := helpers.GetGuildMemberWithoutApi(guildID, userdata.UserID)
+	if err != nil || member == nil || member.User == nil || member.User.Bot {
+		return nil
+	}
+
+	birthday, err := helpers.GetNextBirthday(userdata, now)
+	if err != nil {
+		return err
+	}
+
+	announced, err := helpers.MdbCount(models.BirthdayAnnouncementsTable, bson.M{
+		"guildid": guildID,
+		"userid":  userdata.UserID,
+		"year":    birthday.Year(),
+	})
+	if err != nil || announced > 0 {
+		return err
+	}
+
+	// insert first, announcing twice would be worse than missing an announcement
+	announcementID, err := helpers.MDbInsert(models.BirthdayAnnouncementsTable, models.BirthdayAnnouncementEntry{
+		GuildID:     guildID,
+		UserID:      userdata.UserID,
+		Year:        birthday.Year(),
+		AnnouncedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.logger().WithField("GuildID", guildID).WithField("UserID", userdata.UserID).Info("announcing birthday")
+
+	settings := helpers.GuildSettingsGetCached(guildID)
+
+	code := settings.BirthdaysCode
+	if code == "" {
+		code = helpers.GetText("plugins.birthdays.default-message")
+	}
+	code = replaceBirthdayText(code, member, helpers.GetBirthdayAge(userdata, birthday))
+
+	messageSend := &discordgo.MessageSend{
+		Content: code,
+	}
+	if helpers.IsEmbedCode(code) {
+		ptext, embed, err := helpers.ParseEmbedCode(code)
+		if err == nil {
+			messageSend.Content = ptext
+			messageSend.Embed = embed
+		}
+	}
+	messages, err := helpers.SendComplex(settings.BirthdaysChannelID, messageSend)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); !ok ||
+			(errD.Message.Code != discordgo.ErrCodeMissingPermissions &&
+				errD.Message.Code != discordgo.ErrCodeMissingAccess &&
+				errD.Message.Code != discordgo.ErrCodeUnknownChannel) {
+			helpers.RelaxLog(err)
+		}
+	} else {
+		messageIDs := make([]string, 0)
+		for _, message := range messages {
+			messageIDs = append(messageIDs, message.ID)
+		}
+		err = helpers.MDbUpdateQueryWithoutLogging(models.BirthdayAnnouncementsTable,
+			bson.M{"_id": announcementID}, bson.M{"$set": bson.M{"messageids": messageIDs}})
+		helpers.RelaxLog(err)
+	}
+
+	if settings.BirthdaysRoleID != "" {
+		err = cache.GetSession().GuildMemberRoleAdd(guildID, userdata.UserID, settings.BirthdaysRoleID)
+		if err != nil {
+			return err
+		}
+
+		signature := BirthdayRoleRemoveSignature(guildID, userdata.UserID, settings.BirthdaysRoleID)
+		removeAt := now.Add(birthdaysRoleDuration)
+		signature.ETA = &removeAt
+
+		_, err = cache.GetMachineryServer().SendTask(signature)
+		return err
+	}
+
+	return nil
+}
+
+func replaceBirthdayText(text string, member *discordgo.Member, age int) string {
+	text = strings.Replace(text, "{USER_USERNAME}", member.User.Username, -1)
+	text = strings.Replace(text, "{USER_ID}", member.User.ID, -1)
+	text = strings.Replace(text, "{USER_DISCRIMINATOR}", member.User.Discriminator, -1)
+	text = strings.Replace(text, "{USER_MENTION}", fmt.Sprintf("<@%s>", member.User.ID), -1)
+	text = strings.Replace(text, "{USER_AVATARURL}", member.User.AvatarURL(""), -1)
+
+	ageText := ""
+	if age > 0 {
+		ageText = strconv.Itoa(age)
+	}
+	text = strings.Replace(text, "{USER_AGE}", ageText, -1)
+
+	guild, err := helpers.GetGuild(member.GuildID)
+	helpers.RelaxLog(err)
+	if err == nil {
+		text = strings.Replace(text, "{GUILD_NAME}", guild.Name, -1)
+		text = strings.Replace(text, "{GUILD_ID}", guild.ID, -1)
+	}
+
+	return text
+}
+
+func birthdaysOptedOut(userdata models.ProfileUserdataEntry, guildID string) bool {
+	for _, optOutGuildID := range userdata.BirthdayOptOutGuildIDs {
+		if optOutGuildID == guildID {
+			return true
+		}
+	}
+	return false
+}
+
+// UpcomingBirthday is a birthday of a guild member
+type UpcomingBirthday struct {
+	Member   *discordgo.Member
+	Userdata models.ProfileUserdataEntry
+	Birthday time.Time
+}
+
+// GetUpcomingBirthdays returns the birthdays of the members of the guild between from and until, ordered by date
+// members who opted out on the guild are skipped
+func GetUpcomingBirthdays(guildID string, from, until time.Time) (upcoming []UpcomingBirthday, err error) {
+	upcoming = make([]UpcomingBirthday, 0)
+
+	var userdataBucket []models.ProfileUserdataEntry
+	err = helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ProfileUserdataTable).Find(
+		bson.M{"birthday": bson.M{"$ne": ""}, "birthdayoptoutguildids": bson.M{"$ne": guildID}},
+	)).All(&userdataBucket)
+	if err != nil {
+		return upcoming, err
+	}
+
+	for _, userdata := range userdataBucket {
+		birthday, err := helpers.GetNextBirthday(userdata, from)
+		if err != nil || birthday.After(until) {
+			continue
+		}
+		member, err := helpers.GetGuildMemberWithoutApi(guildID, userdata.UserID)
+		if err != nil || member == nil || member.User == nil || member.User.Bot {
+			continue
+		}
+		upcoming = append(upcoming, UpcomingBirthday{
+			Member:   member,
+			Userdata: userdata,
+			Birthday: birthday,
+		})
+	}
+
+	sort.Slice(upcoming, func(i, j int) bool {
+		return upcoming[i].Birthday.Before(upcoming[j].Birthday)
+	})
+	return upcoming, nil
+}
+
+func BirthdayRoleRemoveSignature(guildID, userID, roleID string) (signature *tasks.Signature) {
+	signature = &tasks.Signature{
+		Name: "remove_birthday_role",
+		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: guildID,
+			},
+			{
+				Type:  "string",
+				Value: userID,
+			},
+			{
+				Type:  "string",
+				Value: roleID,
+			},
+		},
+	}
+	signature.RetryCount = 3
+	signature.OnError = []*tasks.Signature{{Name: "log_error"}}
+	return signature
+}
+
+// BirthdayRoleRemove removes the birthday role again
+func BirthdayRoleRemove(guildID, userID, roleID string) (err error) {
+	err = cache.GetSession().GuildMemberRoleRemove(guildID, userID, roleID)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok {
+			if errD.Message.Code == discordgo.ErrCodeUnknownMember ||
+				errD.Message.Code == discordgo.ErrCodeUnknownRole ||
+				errD.Message.Code == discordgo.ErrCodeMissingAccess ||
+				errD.Message.Code == discordgo.ErrCodeMissingPermissions {
+				return nil
+			}
+		}
+	}
+	return err
+}
@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/Seklfreak/Robyul2/cache"
 	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
 	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
 )
 
 type Charts struct{}
@@ -41,6 +44,7 @@ func (m *Charts) Commands() []string {
 		"melon",
 		"ichart",
 		"gaon",
+		"charts",
 	}
 }
 
@@ -182,24 +186,8 @@ type MelonSearchAlbumsResults struct {
 	} `json:"melon"`
 }
 
-type GenericSongScore struct {
-	Title         string
-	Artist        string
-	Album         string
-	CurrentRank   int
-	PastRank      int
-	IsNew         bool
-	MusicVideoUrl string
-}
-type GenericAlbumScore struct {
-	Artist      string
-	Album       string
-	CurrentRank int
-	PastRank    int
-	IsNew       bool
-}
-
 func (m *Charts) Init(session *discordgo.Session) {
+	go m.chartsTrackerLoop()
 }
 
 func (m *Charts) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
@@ -215,7 +203,8 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 			switch args[0] {
 			case "realtime":
 				session.ChannelTyping(msg.ChannelID)
-				time, realtimeStats := m.GetMelonRealtimeStats()
+				time, realtimeStats, err := m.GetMelonRealtimeStats(10)
+				helpers.Relax(err)
 				chartsEmbed := &discordgo.MessageEmbed{
 					Title:  helpers.GetTextF("plugins.charts.realtime-melon-embed-title", time),
 					URL:    melonFriendlyRealtimeStats,
@@ -245,12 +234,13 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: chartsFieldValue,
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 				return
 			case "daily":
 				session.ChannelTyping(msg.ChannelID)
-				time, dailyStats := m.GetMelonDailyStats()
+				time, dailyStats, err := m.GetMelonDailyStats(10)
+				helpers.Relax(err)
 				chartsEmbed := &discordgo.MessageEmbed{
 					Title:  helpers.GetTextF("plugins.charts.daily-melon-embed-title", time),
 					URL:    melonFriendlyDailyStats,
@@ -280,7 +270,7 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: chartsFieldValue,
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 				return
 			}
@@ -290,15 +280,16 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 			switch args[0] {
 			case "realtime":
 				session.ChannelTyping(msg.ChannelID)
-				time, songRanks, maintenance, overloaded := m.GetIChartRealtimeStats()
+				time, songRanks, maintenance, overloaded, err := m.GetIChartRealtimeStats(10)
+				helpers.Relax(err)
 
 				if maintenance == true {
-					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-maintenance"))
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-maintenance"))
 					helpers.Relax(err)
 					return
 				}
 				if overloaded == true {
-					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-overloaded"))
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-overloaded"))
 					helpers.Relax(err)
 					return
 				}
@@ -332,19 +323,20 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: chartsFieldValue,
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 			case "week", "weekly":
 				session.ChannelTyping(msg.ChannelID)
-				time, songRanks, maintenance, overloaded := m.GetIChartWeekStats()
+				time, songRanks, maintenance, overloaded, err := m.GetIChartWeekStats(10)
+				helpers.Relax(err)
 
 				if maintenance == true {
-					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-maintenance"))
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-maintenance"))
 					helpers.Relax(err)
 					return
 				}
 				if overloaded == true {
-					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-overloaded"))
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.ichart-overloaded"))
 					helpers.Relax(err)
 					return
 				}
@@ -377,7 +369,7 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: chartsFieldValue,
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 			}
 		}
@@ -386,7 +378,8 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 			switch args[0] {
 			case "week", "weekly":
 				session.ChannelTyping(msg.ChannelID)
-				time, albumRanks := m.GetGaonWeekStats()
+				time, albumRanks, err := m.GetGaonWeekStats(10)
+				helpers.Relax(err)
 				chartsEmbed := &discordgo.MessageEmbed{
 					Title:  helpers.GetTextF("plugins.charts.week-gaon-embed-title", time),
 					URL:    gaonFriendlyWeeklyCharts,
@@ -411,11 +404,12 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: fmt.Sprintf("**%s** by **%s**", album.Album, album.Artist),
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 			case "month", "monthly":
 				session.ChannelTyping(msg.ChannelID)
-				time, albumRanks := m.GetGaonMonthStats()
+				time, albumRanks, err := m.GetGaonMonthStats(10)
+				helpers.Relax(err)
 				chartsEmbed := &discordgo.MessageEmbed{
 					Title:  helpers.GetTextF("plugins.charts.month-gaon-embed-title", time),
 					URL:    gaonFriendlyMonthlyCharts,
@@ -440,11 +434,12 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: fmt.Sprintf("**%s** by **%s**", album.Album, album.Artist),
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 			case "year", "yearly":
 				session.ChannelTyping(msg.ChannelID)
-				time, albumRanks := m.GetGaonYearStats()
+				time, albumRanks, err := m.GetGaonYearStats(10)
+				helpers.Relax(err)
 				chartsEmbed := &discordgo.MessageEmbed{
 					Title:  helpers.GetTextF("plugins.charts.year-gaon-embed-title", time),
 					URL:    gaonFriendlyYearlyCharts,
@@ -469,81 +464,54 @@ func (m *Charts) Action(command string, content string, msg *discordgo.Message,
 						Value: fmt.Sprintf("**%s** by **%s**", album.Album, album.Artist),
 					})
 				}
-				_, err := helpers.SendEmbed(msg.ChannelID, chartsEmbed)
+				_, err = helpers.SendEmbed(msg.ChannelID, chartsEmbed)
 				helpers.Relax(err)
 			}
 		}
+	case "charts":
+		if len(args) < 1 {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+			return
+		}
+
+		switch args[0] {
+		case "watch", "add": // [p]charts watch <#channel> artist|song <name>
+			helpers.RequireMod(msg, func() {
+				m.addWatch(msg, args[1:])
+			})
+		case "unwatch", "delete", "del", "remove": // [p]charts unwatch <id>
+			helpers.RequireMod(msg, func() {
+				m.removeWatch(msg, args[1:])
+			})
+		case "watches", "list": // [p]charts watches
+			m.listWatches(msg)
+		case "history": // [p]charts history [chart] <song>
+			session.ChannelTyping(msg.ChannelID)
+			m.showHistory(msg, args[1:])
+		default:
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		}
 	}
 }
 
-func (m *Charts) GetMelonRealtimeStats() (time string, ranks []GenericSongScore) {
+func (m *Charts) GetMelonRealtimeStats(limit int) (time string, ranks []helpers.ChartsSongScore, err error) {
 	doc, err := goquery.NewDocument(melonEndpointRealtimeCharts)
-	helpers.Relax(err)
-
-	ranks = make([]GenericSongScore, 0)
-
-	time = doc.Find(".calendar_prid > .yyyymmdd > .year").Text() + " " + doc.Find(".calendar_prid > .hhmm > .hour").Text()
-
-	for i := 0; i < 10; i++ {
-		currentRank := GenericSongScore{}
-		node := goquery.NewDocumentFromNode(doc.Find(".lst50").Get(i))
-
-		currentRank.Title = node.Find(".rank01 > span > a").Text()
-		currentRank.Artist = node.Find(".rank02 > a").Text()
-		currentRank.Album = node.Find(".rank03 > a").Text()
-		currentRankN, _ := strconv.Atoi(node.Find(".rank").Text())
-		currentRank.CurrentRank = currentRankN
-		currentRank.PastRank = currentRank.CurrentRank
-
-		rankingChangeDoc := goquery.NewDocumentFromNode(node.Find("td").Get(2))
-		if len(rankingChangeDoc.Find(".up").Nodes) > 0 {
-			rankUpChangeN, _ := strconv.Atoi(rankingChangeDoc.Find(".up").Text())
-			currentRank.PastRank += rankUpChangeN
-		}
-		if len(rankingChangeDoc.Find(".down").Nodes) > 0 {
-			rankDownChangeN, _ := strconv.Atoi(rankingChangeDoc.Find(".down").Text())
-			currentRank.PastRank -= rankDownChangeN
-		}
-
-		ranks = append(ranks, currentRank)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return time, ranks
+	time, ranks = helpers.ParseMelonChart(doc, true, limit)
+	return time, ranks, nil
 }
 
-func (m *Charts) GetMelonDailyStats() (time string, ranks []GenericSongScore) {
+func (m *Charts) GetMelonDailyStats(limit int) (time string, ranks []helpers.ChartsSongScore, err error) {
 	doc, err := goquery.NewDocument(melonEndpointDailyCharts)
-	helpers.Relax(err)
-
-	ranks = make([]GenericSongScore, 0)
-
-	time = doc.Find(".calendar_prid > .yyyymmdd > .year").Text()
-
-	for i := 0; i < 10; i++ {
-		currentRank := GenericSongScore{}
-		node := goquery.NewDocumentFromNode(doc.Find(".lst50").Get(i))
-
-		currentRank.Title = node.Find(".rank01 > span > a").Text()
-		currentRank.Artist = node.Find(".rank02 > a").Text()
-		currentRank.Album = node.Find(".rank03 > a").Text()
-		currentRankN, _ := strconv.Atoi(node.Find(".rank").Text())
-		currentRank.CurrentRank = currentRankN
-		currentRank.PastRank = currentRank.CurrentRank
-
-		rankingChangeDoc := goquery.NewDocumentFromNode(node.Find("td").Get(2))
-		if len(rankingChangeDoc.Find(".up").Nodes) > 0 {
-			rankUpChangeN, _ := strconv.Atoi(rankingChangeDoc.Find(".up").Text())
-			currentRank.PastRank += rankUpChangeN
-		}
-		if len(rankingChangeDoc.Find(".down").Nodes) > 0 {
-			rankDownChangeN, _ := strconv.Atoi(rankingChangeDoc.Find(".down").Text())
-			currentRank.PastRank -= rankDownChangeN
-		}
-
-		ranks = append(ranks, currentRank)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return time, ranks
+	time, ranks = helpers.ParseMelonChart(doc, false, limit)
+	return time, ranks, nil
 }
 
 func (m *Charts) DoMelonRequest(url string) []byte {
@@ -572,279 +540,462 @@ func (m *Charts) DoMelonRequest(url string) []byte {
 	return buf.Bytes()
 }
 
-func (m *Charts) GetIChartRealtimeStats() (string, []GenericSongScore, bool, bool) {
+func (m *Charts) GetIChartRealtimeStats(limit int) (time string, ranks []helpers.ChartsSongScore, maintenance bool, overloaded bool, err error) {
 	doc, err := goquery.NewDocument(ichartPageRealtimeCharts)
-	helpers.Relax(err)
+	if err != nil {
+		return "", nil, false, false, err
+	}
+
+	time, ranks, maintenance, overloaded = helpers.ParseIChartChart(doc, limit)
+	return time, ranks, maintenance, overloaded, nil
+}
+
+func (m *Charts) GetIChartWeekStats(limit int) (time string, ranks []helpers.ChartsSongScore, maintenance bool, overloaded bool, err error) {
+	doc, err := goquery.NewDocument(ichartPageWeeklyCharts)
+	if err != nil {
+		return "", nil, false, false, err
+	}
+
+	time, ranks, maintenance, overloaded = helpers.ParseIChartChart(doc, limit)
+	return time, ranks, maintenance, overloaded, nil
+}
+
+func (m *Charts) GetGaonWeekStats(limit int) (time string, ranks []helpers.ChartsAlbumScore, err error) {
+	return m.getGaonStats(gaonPageWeeklyCharts, limit)
+}
 
-	var ranks []GenericSongScore
+func (m *Charts) GetGaonMonthStats(limit int) (time string, ranks []helpers.ChartsAlbumScore, err error) {
+	return m.getGaonStats(gaonPageMonthlyCharts, limit)
+}
+
+func (m *Charts) GetGaonYearStats(limit int) (time string, ranks []helpers.ChartsAlbumScore, err error) {
+	return m.getGaonStats(gaonPageYearlyCharts, limit)
+}
 
-	if m.IChartInMaintenance(doc) == true {
-		return "", ranks, true, false
+func (m *Charts) getGaonStats(url string, limit int) (time string, ranks []helpers.ChartsAlbumScore, err error) {
+	doc, err := goquery.NewDocument(url)
+	if err != nil {
+		return "", nil, err
 	}
 
-	if m.IChartOverloaded(doc) == true {
-		return "", ranks, false, true
+	time, ranks = helpers.ParseGaonChart(doc, limit)
+	return time, ranks, nil
+}
+
+const (
+	chartsTrackerInterval    = 5 * time.Minute
+	chartsHistorySnapshots   = 100
+	chartsHistoryMaxSeries   = 5
+	chartsMaxWatchesPerGuild = 50
+)
+
+// chartsTrackedChart is a chart the tracker stores snapshots of
+type chartsTrackedChart struct {
+	Name        string // as used in the snapshots and the history command
+	DisplayName string
+	Interval    time.Duration // how often the chart is checked for a new chart time
+	LabelFormat string        // time format of the history labels
+	Fetch       func() (chartTime string, ranks []models.ChartsRank, err error)
+}
+
+var chartsHistoryColors = []string{"#43C85D", "#7289DA", "#FAA61A", "#F04747", "#B9BBBE"}
+
+func (m *Charts) trackedCharts() []chartsTrackedChart {
+	return []chartsTrackedChart{
+		{Name: "melon-realtime", DisplayName: "Melon Realtime", Interval: 1 * time.Hour, LabelFormat: "Jan 2 15h",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				chartTime, ranks, err := m.GetMelonRealtimeStats(50)
+				return chartTime, m.songsToRanks(ranks), err
+			}},
+		{Name: "melon-daily", DisplayName: "Melon Daily", Interval: 6 * time.Hour, LabelFormat: "Jan 2",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				chartTime, ranks, err := m.GetMelonDailyStats(50)
+				return chartTime, m.songsToRanks(ranks), err
+			}},
+		{Name: "ichart-realtime", DisplayName: "iChart Realtime", Interval: 1 * time.Hour, LabelFormat: "Jan 2 15h",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				// maintenance and overloads return no ranks and are skipped
+				chartTime, ranks, _, _, err := m.GetIChartRealtimeStats(50)
+				return chartTime, m.songsToRanks(ranks), err
+			}},
+		{Name: "ichart-weekly", DisplayName: "iChart Weekly", Interval: 6 * time.Hour, LabelFormat: "Jan 2",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				chartTime, ranks, _, _, err := m.GetIChartWeekStats(50)
+				return chartTime, m.songsToRanks(ranks), err
+			}},
+		{Name: "gaon-weekly", DisplayName: "Gaon Weekly", Interval: 12 * time.Hour, LabelFormat: "Jan 2",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				chartTime, ranks, err := m.GetGaonWeekStats(50)
+				return chartTime, m.albumsToRanks(ranks), err
+			}},
+		{Name: "gaon-monthly", DisplayName: "Gaon Monthly", Interval: 12 * time.Hour, LabelFormat: "Jan 2006",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				chartTime, ranks, err := m.GetGaonMonthStats(50)
+				return chartTime, m.albumsToRanks(ranks), err
+			}},
+		{Name: "gaon-yearly", DisplayName: "Gaon Yearly", Interval: 12 * time.Hour, LabelFormat: "2006",
+			Fetch: func() (string, []models.ChartsRank, error) {
+				chartTime, ranks, err := m.GetGaonYearStats(50)
+				return chartTime, m.albumsToRanks(ranks), err
+			}},
 	}
+}
 
-	time := strings.TrimSpace(strings.Replace(doc.Find("#content > div.ichart_score_title > div.ichart_score_title_right.minitext3").Text(), "기준", "", -1))
-	for i := 0; i < 10; i++ {
-		ranks = append(ranks,
-			GenericSongScore{
-				Title:       "",
-				Artist:      "",
-				Album:       "",
-				CurrentRank: i + 1,
-				PastRank:    0,
-			})
-		if i == 0 {
-			ranks[i].Title = doc.Find("#score_1st > div.ichart_score_song > div.ichart_score_song1 > b").Text()
-			ranks[i].Artist = doc.Find("#score_1st > div.ichart_score_artist > div.ichart_score_artist1 > b").Text()
-			ranks[i].Album = doc.Find("#score_1st > div.ichart_score_song > div.ichart_score_song2 > span > a").Text()
-			helpers.Relax(err)
-			ranks[i].PastRank = ranks[i].CurrentRank
-			if musicVideoUrl, ok := doc.Find("#yttop").Attr("href"); ok {
-				ranks[i].MusicVideoUrl = m.IChartHrefExtractMVUrl(musicVideoUrl)
-			}
-			if len(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow1").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow1").Parent().Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
-			}
-			if len(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow2").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow2").Parent().Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
-			}
-		} else {
-			itemDoc := goquery.NewDocumentFromNode(doc.Find("#content > div.spage_intistore_body > div.spage_score_item").Get(i - 1))
-			submenuDoc := goquery.NewDocumentFromNode(doc.Find("#content > div.spage_intistore_body > div.ichart_submenu").Get(i - 1))
-			ranks[i].Title = itemDoc.Find("div.ichart_score2_song > div.ichart_score2_song1").Text()
-			ranks[i].Artist = itemDoc.Find("div.ichart_score2_artist > div.ichart_score2_artist1").Text()
-			ranks[i].Album = itemDoc.Find("div.ichart_score2_song > div.ichart_score2_song2 > span > a").Text()
-			ranks[i].PastRank = ranks[i].CurrentRank
-			if musicVideoUrl, ok := submenuDoc.Find("ul > li.ichart_mv > a").Attr("href"); ok {
-				ranks[i].MusicVideoUrl = m.IChartHrefExtractMVUrl(musicVideoUrl)
-			}
-			if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow1").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(itemDoc.Find("div.ichart_score2_change.rank").Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
-			}
-			if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow2").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(itemDoc.Find("div.ichart_score2_change.rank").Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
+func (m *Charts) songsToRanks(songs []helpers.ChartsSongScore) (ranks []models.ChartsRank) {
+	for _, song := range songs {
+		ranks = append(ranks, models.ChartsRank{Rank: song.CurrentRank, Title: song.Title, Artist: song.Artist, Album: song.Album})
+	}
+	return ranks
+}
+
+// albumsToRanks uses the album name as title, album charts are watched and searched like songs
+func (m *Charts) albumsToRanks(albums []helpers.ChartsAlbumScore) (ranks []models.ChartsRank) {
+	for _, album := range albums {
+		ranks = append(ranks, models.ChartsRank{Rank: album.CurrentRank, Title: album.Album, Artist: album.Artist, Album: album.Album})
+	}
+	return ranks
+}
+
+func (m *Charts) chartsTrackerLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "charts").Error("The chartsTrackerLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.chartsTrackerLoop()
+		}()
+	}()
+
+	lastChecked := make(map[string]time.Time)
+	for {
+		for _, chart := range m.trackedCharts() {
+			if time.Since(lastChecked[chart.Name]) < chart.Interval {
+				continue
 			}
-			if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow4").Nodes) > 0 {
-				ranks[i].IsNew = true
+
+			err := m.trackChart(chart)
+			if err != nil {
+				log.WithField("module", "charts").Warnf("tracking chart %s failed: %s", chart.Name, err.Error())
+				continue
 			}
+			lastChecked[chart.Name] = time.Now()
 		}
-	}
 
-	return time, ranks, false, false
+		time.Sleep(chartsTrackerInterval)
+	}
 }
 
-func (m *Charts) IChartInMaintenance(doc *goquery.Document) bool {
-	if strings.Contains(doc.Text(), "서버 점검으로 인해 현재 서비스가 일시 중단되었습니다") { // maintenance
-		return true
+// trackChart stores a new snapshot of the chart if the chart time changed, and alerts the watching channels about the changes
+func (m *Charts) trackChart(chart chartsTrackedChart) (err error) {
+	chartTime, ranks, err := chart.Fetch()
+	if err != nil {
+		return err
+	}
+	if chartTime == "" || len(ranks) <= 0 {
+		return nil
 	}
-	return false
-}
 
-func (m *Charts) IChartOverloaded(doc *goquery.Document) bool {
-	isOverloaded := false
-	if strings.Contains(doc.Text(), "사이트 이용자가 많습니다") {
-		isOverloaded = true
+	var previousSnapshot models.ChartsSnapshotEntry
+	err = helpers.MdbOneWithoutLogging(
+		helpers.MdbCollection(models.ChartsSnapshotsTable).Find(bson.M{"chart": chart.Name}).Sort("-createdat"),
+		&previousSnapshot,
+	)
+	if err != nil && !helpers.IsMdbNotFound(err) {
+		return err
+	}
+	if previousSnapshot.ChartTime == chartTime {
+		return nil
+	}
+
+	_, err = helpers.MDbInsertWithoutLogging(models.ChartsSnapshotsTable, models.ChartsSnapshotEntry{
+		Chart:     chart.Name,
+		ChartTime: chartTime,
+		CreatedAt: time.Now(),
+		Ranks:     ranks,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = m.deleteOldSnapshots(chart)
+	if err != nil {
+		return err
 	}
-	return isOverloaded
-}
 
-func (m *Charts) IChartHrefExtractMVUrl(musicVideoUrl string) string {
-	if strings.Contains(musicVideoUrl, "javascript:show_youtube") {
-		parts := strings.Split(musicVideoUrl, "'")
-		if len(parts) == 3 {
-			return "https://youtu.be/" + parts[1]
+	keys := make([]string, 0)
+	for _, rank := range ranks {
+		keys = append(keys, helpers.ChartsRankKey(rank))
+	}
+
+	// the first snapshot of a chart only sets the peaks, every song would be an entry otherwise
+	if previousSnapshot.ChartTime != "" {
+		var peakEntries []models.ChartsPeakEntry
+		err = helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ChartsPeaksTable).Find(
+			bson.M{"chart": chart.Name, "key": bson.M{"$in": keys}},
+		)).All(&peakEntries)
+		if err != nil {
+			return err
+		}
+		peaks := make(map[string]int)
+		for _, peakEntry := range peakEntries {
+			peaks[peakEntry.Key] = peakEntry.Peak
+		}
+
+		m.sendAlerts(chart, helpers.ChartsRankChanges(previousSnapshot.Ranks, ranks, peaks))
+	}
+
+	for i, rank := range ranks {
+		err = helpers.MDbUpsertWithoutLogging(models.ChartsPeaksTable,
+			bson.M{"chart": chart.Name, "key": keys[i]},
+			bson.M{"$min": bson.M{"peak": rank.Rank}},
+		)
+		if err != nil {
+			return err
 		}
 	}
-	return ""
+
+	return nil
 }
 
-func (m *Charts) GetIChartWeekStats() (string, []GenericSongScore, bool, bool) {
-	doc, err := goquery.NewDocument(ichartPageWeeklyCharts)
-	helpers.Relax(err)
+// deleteOldSnapshots keeps the chartsHistorySnapshots newest snapshots of the chart, older snapshots aren't used by the history
+func (m *Charts) deleteOldSnapshots(chart chartsTrackedChart) (err error) {
+	var oldestSnapshot models.ChartsSnapshotEntry
+	err = helpers.MdbOneWithoutLogging(
+		helpers.MdbCollection(models.ChartsSnapshotsTable).Find(bson.M{"chart": chart.Name}).
+			Sort("-createdat").Skip(chartsHistorySnapshots-1),
+		&oldestSnapshot,
+	)
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			return nil
+		}
+		return err
+	}
 
-	var ranks []GenericSongScore
+	_, err = helpers.MdbCollection(models.ChartsSnapshotsTable).RemoveAll(bson.M{
+		"chart":     chart.Name,
+		"createdat": bson.M{"$lt": oldestSnapshot.CreatedAt},
+	})
+	return err
+}
 
-	if m.IChartInMaintenance(doc) == true {
-		return "", ranks, true, false
+// sendAlerts posts the changes to all channels watching the songs, with one message per channel
+func (m *Charts) sendAlerts(chart chartsTrackedChart, changes []helpers.ChartsRankChange) {
+	if len(changes) <= 0 {
+		return
 	}
 
-	if m.IChartOverloaded(doc) == true {
-		return "", ranks, false, true
+	var subscriptions []models.ChartsSubscriptionEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ChartsSubscriptionsTable).Find(nil)).All(&subscriptions)
+	if err != nil {
+		cache.GetLogger().WithField("module", "charts").Errorf("getting the chart watches of %s failed: %s", chart.Name, err.Error())
+		return
 	}
 
-	time := strings.TrimSpace(strings.Replace(doc.Find("#content > div.ichart_score_title > div.ichart_score_title_right.minitext3").Text(), "기준", "", -1))
-	for i := 0; i < 10; i++ {
-		ranks = append(ranks,
-			GenericSongScore{
-				Title:       "",
-				Artist:      "",
-				Album:       "",
-				CurrentRank: i + 1,
-				PastRank:    0,
-			})
-		if i == 0 {
-			ranks[i].Title = doc.Find("#score_1st > div.ichart_score_song > div.ichart_score_song1 > b").Text()
-			ranks[i].Artist = doc.Find("#score_1st > div.ichart_score_artist > div.ichart_score_artist1 > b").Text()
-			ranks[i].Album = doc.Find("#score_1st > div.ichart_score_song > div.ichart_score_song2 > span > a").Text()
-			helpers.Relax(err)
-			ranks[i].PastRank = ranks[i].CurrentRank
-			if musicVideoUrl, ok := doc.Find("#yttop").Attr("href"); ok {
-				ranks[i].MusicVideoUrl = m.IChartHrefExtractMVUrl(musicVideoUrl)
-			}
-			if len(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow1").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow1").Parent().Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
-			}
-			if len(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow2").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(doc.Find("#score_1st > div.ichart_score_change.rank > .arrow2").Parent().Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
-			}
-		} else {
-			itemDoc := goquery.NewDocumentFromNode(doc.Find("#content > div.spage_intistore_body > div.spage_score_item").Get(i - 1))
-			submenuDoc := goquery.NewDocumentFromNode(doc.Find("#content > div.spage_intistore_body > div.ichart_submenu").Get(i - 1))
-			ranks[i].Title = itemDoc.Find("div.ichart_score2_song > div.ichart_score2_song1").Text()
-			ranks[i].Artist = itemDoc.Find("div.ichart_score2_artist > div.ichart_score2_artist1").Text()
-			ranks[i].Album = itemDoc.Find("div.ichart_score2_song > div.ichart_score2_song2 > span > a").Text()
-			ranks[i].PastRank = ranks[i].CurrentRank
-			if musicVideoUrl, ok := submenuDoc.Find("ul > li.ichart_mv > a").Attr("href"); ok {
-				ranks[i].MusicVideoUrl = m.IChartHrefExtractMVUrl(musicVideoUrl)
+	channelIDs := make([]string, 0)
+	alerts := make(map[string][]string)
+	for _, subscription := range subscriptions {
+		for _, change := range changes {
+			if !helpers.ChartsSubscriptionMatches(subscription, change.Rank) {
+				continue
 			}
-			if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow1").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(itemDoc.Find("div.ichart_score2_change.rank").Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
+
+			alert := m.alertText(change)
+			if _, ok := alerts[subscription.ChannelID]; !ok {
+				channelIDs = append(channelIDs, subscription.ChannelID)
 			}
-			if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow2").Nodes) > 0 {
-				pastRankUncalculated, err := strconv.Atoi(itemDoc.Find("div.ichart_score2_change.rank").Text())
-				helpers.Relax(err)
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
+			// multiple watches of a channel can match the same song
+			duplicate := false
+			for _, previousAlert := range alerts[subscription.ChannelID] {
+				if previousAlert == alert {
+					duplicate = true
+					break
+				}
 			}
-			if len(itemDoc.Find("div.ichart_score2_change.rank > .arrow4").Nodes) > 0 {
-				ranks[i].IsNew = true
+			if !duplicate {
+				alerts[subscription.ChannelID] = append(alerts[subscription.ChannelID], alert)
 			}
 		}
 	}
 
-	return time, ranks, false, false
+	for _, channelID := range channelIDs {
+		_, err = helpers.SendMessage(channelID,
+			helpers.GetTextF("plugins.charts.alert-title", chart.DisplayName)+"\n"+strings.Join(alerts[channelID], "\n"))
+		if err != nil {
+			cache.GetLogger().WithField("module", "charts").Warnf("posting chart alerts in #%s failed: %s", channelID, err.Error())
+		}
+	}
 }
 
-func (m *Charts) GetGaonWeekStats() (string, []GenericAlbumScore) {
-	doc, err := goquery.NewDocument(gaonPageWeeklyCharts)
-	helpers.Relax(err)
+func (m *Charts) alertText(change helpers.ChartsRankChange) string {
+	switch change.Type {
+	case helpers.ChartsChangeTop:
+		return helpers.GetTextF("plugins.charts.alert-top", change.Rank.Title, change.Rank.Artist)
+	case helpers.ChartsChangeEntry:
+		return helpers.GetTextF("plugins.charts.alert-entry", change.Rank.Title, change.Rank.Artist, change.Rank.Rank)
+	case helpers.ChartsChangePeak:
+		return helpers.GetTextF("plugins.charts.alert-peak", change.Rank.Title, change.Rank.Artist, change.Rank.Rank, change.PreviousRank)
+	}
+	return helpers.GetTextF("plugins.charts.alert-dropout", change.Rank.Title, change.Rank.Artist, change.PreviousRank)
+}
 
-	time := strings.TrimSpace(strings.Replace(doc.Find("#wrap > div.now > div.fl").Text(), "Album Chart", "", -1))
-	var ranks []GenericAlbumScore
-	for i := 0; i < 10; i++ {
-		ranks = append(ranks,
-			GenericAlbumScore{
-				Album:       "",
-				CurrentRank: i + 1,
-				PastRank:    0,
-			})
-		ranks[i].Album = doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.subject > p:nth-child(1)", ranks[i].CurrentRank+1)).Text()
-		ranks[i].Artist = doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.subject > p.singer", ranks[i].CurrentRank+1)).Text()
-		ranks[i].PastRank = ranks[i].CurrentRank
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .up", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			pastRankUncalculated, err := strconv.Atoi(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .up", ranks[i].CurrentRank+1)).Text())
-			if err == nil {
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
-			}
-		}
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .down", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			pastRankUncalculated, err := strconv.Atoi(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .down", ranks[i].CurrentRank+1)).Text())
-			if err == nil {
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
-			}
-		}
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .new", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			ranks[i].IsNew = true
-		}
+func (m *Charts) addWatch(msg *discordgo.Message, args []string) {
+	if len(args) < 3 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
 	}
 
-	return time, ranks
+	targetChannel, err := helpers.GetChannelFromMention(msg, args[0])
+	if err != nil {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+
+	watchType := strings.ToLower(args[1])
+	if watchType != models.ChartsSubscriptionTypeArtist && watchType != models.ChartsSubscriptionTypeSong {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+
+	watchCount, err := helpers.MdbCollection(models.ChartsSubscriptionsTable).Find(bson.M{"guildid": targetChannel.GuildID}).Count()
+	helpers.Relax(err)
+	if watchCount >= chartsMaxWatchesPerGuild {
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.charts.watch-too-many", chartsMaxWatchesPerGuild))
+		return
+	}
+
+	query := strings.Join(args[2:], " ")
+	_, err = helpers.MDbInsert(models.ChartsSubscriptionsTable, models.ChartsSubscriptionEntry{
+		GuildID:       targetChannel.GuildID,
+		ChannelID:     targetChannel.ID,
+		AddedByUserID: msg.Author.ID,
+		Type:          watchType,
+		Query:         query,
+		CreatedAt:     time.Now(),
+	})
+	helpers.Relax(err)
+
+	helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.charts.watch-added", watchType, query, targetChannel.ID))
 }
 
-func (m *Charts) GetGaonMonthStats() (string, []GenericAlbumScore) {
-	doc, err := goquery.NewDocument(gaonPageMonthlyCharts)
+func (m *Charts) removeWatch(msg *discordgo.Message, args []string) {
+	if len(args) < 1 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
 	helpers.Relax(err)
 
-	time := strings.TrimSpace(strings.Replace(doc.Find("#wrap > div.now > div.fl").Text(), "Album Chart", "", -1))
-	var ranks []GenericAlbumScore
-	for i := 0; i < 10; i++ {
-		ranks = append(ranks,
-			GenericAlbumScore{
-				Album:       "",
-				CurrentRank: i + 1,
-				PastRank:    0,
-			})
-		ranks[i].Album = doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.subject > p:nth-child(1)", ranks[i].CurrentRank+1)).Text()
-		ranks[i].Artist = doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.subject > p.singer", ranks[i].CurrentRank+1)).Text()
-		ranks[i].PastRank = ranks[i].CurrentRank
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .up", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			pastRankUncalculated, err := strconv.Atoi(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .up", ranks[i].CurrentRank+1)).Text())
-			if err == nil {
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
-			}
-		}
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .down", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			pastRankUncalculated, err := strconv.Atoi(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .down", ranks[i].CurrentRank+1)).Text())
-			if err == nil {
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
-			}
-		}
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .new", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			ranks[i].IsNew = true
-		}
+	var subscription models.ChartsSubscriptionEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.ChartsSubscriptionsTable).Find(bson.M{"_id": helpers.HumanToMdbId(strings.TrimLeft(args[0], "#")), "guildid": channel.GuildID}),
+		&subscription,
+	)
+	if helpers.IsMdbNotFound(err) {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.watch-not-found"))
+		return
 	}
+	helpers.Relax(err)
+
+	err = helpers.MDbDelete(models.ChartsSubscriptionsTable, subscription.ID)
+	helpers.Relax(err)
 
-	return time, ranks
+	helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.charts.watch-removed", subscription.Type, subscription.Query, subscription.ChannelID))
 }
 
-func (m *Charts) GetGaonYearStats() (string, []GenericAlbumScore) {
-	doc, err := goquery.NewDocument(gaonPageYearlyCharts)
+func (m *Charts) listWatches(msg *discordgo.Message) {
+	channel, err := helpers.GetChannel(msg.ChannelID)
 	helpers.Relax(err)
 
-	time := strings.TrimSpace(strings.Replace(doc.Find("#wrap > div.now > div.fl").Text(), "Album Chart", "", -1))
-	var ranks []GenericAlbumScore
-	for i := 0; i < 10; i++ {
-		ranks = append(ranks,
-			GenericAlbumScore{
-				Album:       "",
-				CurrentRank: i + 1,
-				PastRank:    0,
-			})
-		ranks[i].Album = doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.subject > p:nth-child(1)", ranks[i].CurrentRank+1)).Text()
-		ranks[i].Artist = doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.subject > p.singer", ranks[i].CurrentRank+1)).Text()
-		ranks[i].PastRank = ranks[i].CurrentRank
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .up", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			pastRankUncalculated, err := strconv.Atoi(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .up", ranks[i].CurrentRank+1)).Text())
-			if err == nil {
-				ranks[i].PastRank = ranks[i].CurrentRank + pastRankUncalculated
+	var subscriptions []models.ChartsSubscriptionEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.ChartsSubscriptionsTable).Find(bson.M{"guildid": channel.GuildID}).Sort("createdat")).All(&subscriptions)
+	helpers.Relax(err)
+
+	if len(subscriptions) <= 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.charts.watches-none"))
+		return
+	}
+
+	resultMessage := ""
+	for _, subscription := range subscriptions {
+		resultMessage += fmt.Sprintf("`%s`: %s `%s` posting to <#%s>\n",
+			helpers.MdbIdToHuman(subscription.ID), subscription.Type, subscription.Query, subscription.ChannelID)
+	}
+	resultMessage += helpers.GetTextF("plugins.charts.watches-total", len(subscriptions))
+	for _, resultPage := range helpers.Pagify(resultMessage, "\n") {
+		_, err = helpers.SendMessage(msg.ChannelID, resultPage)
+		helpers.Relax(err)
+	}
+}
+
+// showHistory draws the ranks of a song in the last snapshots of a chart, songs with the same title by different artists get their own line
+func (m *Charts) showHistory(msg *discordgo.Message, args []string) {
+	trackedCharts := m.trackedCharts()
+	chart := trackedCharts[0]
+	if len(args) >= 2 {
+		for _, trackedChart := range trackedCharts {
+			if strings.ToLower(args[0]) == trackedChart.Name {
+				chart = trackedChart
+				args = args[1:]
+				break
 			}
 		}
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .down", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			pastRankUncalculated, err := strconv.Atoi(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .down", ranks[i].CurrentRank+1)).Text())
-			if err == nil {
-				ranks[i].PastRank = ranks[i].CurrentRank - pastRankUncalculated
+	}
+	if len(args) < 1 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+	song := models.ChartsSubscriptionEntry{Type: models.ChartsSubscriptionTypeSong, Query: strings.Join(args, " ")}
+
+	var snapshots []models.ChartsSnapshotEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.ChartsSnapshotsTable).Find(
+		bson.M{"chart": chart.Name},
+	).Sort("-createdat").Limit(chartsHistorySnapshots)).All(&snapshots)
+	helpers.Relax(err)
+
+	labels := make([]string, len(snapshots))
+	series := make([]helpers.ChartSeries, 0)
+	seriesIndexes := make(map[string]int)
+	// the snapshots are sorted newest first
+	for i, snapshot := range snapshots {
+		index := len(snapshots) - 1 - i
+		labels[index] = snapshot.CreatedAt.Format(chart.LabelFormat)
+
+		for _, rank := range snapshot.Ranks {
+			if !helpers.ChartsSubscriptionMatches(song, rank) {
+				continue
 			}
-		}
-		if len(doc.Find(fmt.Sprintf("#wrap > div.chart > table > tbody > tr:nth-child(%d) > td.change > .new", ranks[i].CurrentRank+1)).Nodes) > 0 {
-			ranks[i].IsNew = true
+
+			key := helpers.ChartsRankKey(rank)
+			seriesIndex, ok := seriesIndexes[key]
+			if !ok {
+				if len(series) >= chartsHistoryMaxSeries {
+					continue
+				}
+				seriesIndex = len(series)
+				seriesIndexes[key] = seriesIndex
+				series = append(series, helpers.ChartSeries{
+					Name:   rank.Title + " - " + rank.Artist,
+					Values: make([]float64, len(snapshots)),
+					Color:  chartsHistoryColors[seriesIndex%len(chartsHistoryColors)],
+				})
+			}
+			series[seriesIndex].Values[index] = float64(rank.Rank)
 		}
 	}
 
-	return time, ranks
+	if len(series) <= 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.charts.history-not-found", chart.DisplayName))
+		return
+	}
+
+	chartImage := helpers.DrawRankChart(
+		helpers.GetTextF("plugins.charts.history-chart-title", song.Query, chart.DisplayName),
+		labels, series, 900, 400,
+	)
+	_, err = helpers.SendFile(msg.ChannelID, "charts-history.png", bytes.NewReader(chartImage), "")
+	helpers.Relax(err)
 }
@@ -290,6 +290,7 @@ func (m *Config) actionStatus(args []string, in *discordgo.Message, out **discor
 	} else {
 		levelsText += "Disabled"
 	}
+	levelsText += fmt.Sprintf("\nRep Cooldown: %d hour(s)", int(helpers.GetRepCooldown(guildConfig.RepCooldownHours).Hours()))
 	levelsText += "\nIgnored Users: "
 	if guildConfig.LevelsIgnoredUserIDs == nil || len(guildConfig.LevelsIgnoredUserIDs) <= 0 {
 		levelsText += "None"
@@ -63,6 +63,44 @@ func (d *Donators) Action(command string, content string, msg *discordgo.Message
 				return
 			})
 			return
+		case "link": // [p]donators link <user> <donator name>, links a donator to a user, used for donator badges
+			helpers.RequireRobyulMod(msg, func() {
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
+					return
+				}
+
+				targetUser, err := helpers.GetUserFromMention(args[1])
+				if err != nil {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				name := strings.TrimSpace(strings.Replace(strings.Replace(content, args[0], "", 1), args[1], "", 1))
+
+				var donators []models.DonatorEntry
+				err = helpers.MDbIter(helpers.MdbCollection(models.DonatorsTable).Find(nil)).All(&donators)
+				helpers.Relax(err)
+
+				for _, donator := range donators {
+					if !strings.EqualFold(strings.TrimSpace(donator.Name), name) {
+						continue
+					}
+
+					donator.UserID = targetUser.ID
+					err = helpers.MDbUpdate(models.DonatorsTable, donator.ID, donator)
+					helpers.Relax(err)
+
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.donators.link-success", donator.Name, targetUser.Username))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.donators.link-not-found"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			})
+			return
 		}
 	}
 
@@ -686,6 +686,43 @@ func auditlogBackfillLoop() {
 					}
 				}
 				break
+			case models.AuditLogBackfillTypeMessageDelete:
+				logger().Infof("doing message delete backfill for guild #%s, count %d", backfill.GuildID, backfill.Count)
+				results, err := cache.GetSession().GuildAuditLog(backfill.GuildID, "", "", discordgo.AuditLogActionMessageDelete, backfill.Count)
+				if err != nil {
+					if errD, ok := err.(*discordgo.RESTError); ok && errD.Message.Code == discordgo.ErrCodeMissingPermissions {
+						continue
+					}
+				}
+				helpers.Relax(err)
+				metrics.EventlogAuditLogRequests.Add(1)
+
+				for _, result := range results.AuditLogEntries {
+					elasticTime := helpers.GetTimeFromSnowflake(result.ID)
+
+					// the target of message deletes is the author of the message
+					elasticItems, err := helpers.GetElasticPendingAuditLogBackfillMessageDeletes(elasticTime, backfill.GuildID, result.TargetID)
+					if err != nil {
+						if strings.Contains(err.Error(), "no fitting items found") {
+							continue
+						}
+					}
+					helpers.RelaxLog(err)
+
+					for _, elasticItem := range elasticItems {
+						err = helpers.EventlogLogUpdate(
+							elasticItem.ElasticID,
+							result.UserID,
+							nil,
+							nil,
+							result.Reason,
+							true,
+						)
+						helpers.RelaxLog(err)
+						successfulBackfills++
+					}
+				}
+				break
 			}
 
 		}
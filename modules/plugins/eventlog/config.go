@@ -0,0 +1,231 @@
+package eventlog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// [p]eventlog config [add|remove]
+func (h *Handler) actionConfig(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsAdmin(in) {
+		*out = h.newMsg("admin.no_permission")
+		return h.actionFinish
+	}
+
+	if len(args) >= 2 {
+		switch args[1] {
+		case "add":
+			return h.actionConfigAdd
+		case "remove", "delete":
+			return h.actionConfigRemove
+		}
+	}
+
+	return h.actionConfigList
+}
+
+// [p]eventlog config
+func (h *Handler) actionConfigList(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	var routesText string
+	for _, channelID := range settings.EventlogChannelIDs {
+		routesText += helpers.GetTextF("plugins.eventlog.config-list-all-channel", channelID) + "\n"
+	}
+	for i, route := range settings.EventlogRoutes {
+		routesText += "`#" + strconv.Itoa(i+1) + "` " + routeToText(route) + "\n"
+	}
+	if routesText == "" {
+		routesText = helpers.GetText("plugins.eventlog.config-list-none") + "\n"
+	}
+
+	*out = h.newMsg("plugins.eventlog.config-list", routesText, strings.Join(models.EventlogCategories, ", "))
+	return h.actionFinish
+}
+
+// [p]eventlog config add <#channel or channel id> <all, or categories and event types, comma separated> [target:<id>] [exclude-user:<@user or user id>]
+func (h *Handler) actionConfigAdd(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if len(args) < 4 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[2])
+	if err != nil || targetChannel.GuildID != channel.GuildID {
+		*out = h.newMsg("bot.arguments.invalid")
+		return h.actionFinish
+	}
+
+	route := models.EventlogRoute{
+		ChannelID:       targetChannel.ID,
+		ActionTypes:     make([]string, 0),
+		Categories:      make([]string, 0),
+		TargetIDs:       make([]string, 0),
+		ExcludedUserIDs: make([]string, 0),
+	}
+
+	if strings.ToLower(args[3]) != "all" {
+	NextItem:
+		for _, item := range strings.Split(strings.ToLower(args[3]), ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			for _, category := range models.EventlogCategories {
+				if item == category {
+					route.Categories = append(route.Categories, category)
+					continue NextItem
+				}
+			}
+			if !helpers.IsEventlogType(item) {
+				*out = h.newMsg("plugins.eventlog.config-add-invalid-type", item)
+				return h.actionFinish
+			}
+			route.ActionTypes = append(route.ActionTypes, item)
+		}
+	}
+
+	for _, filter := range args[4:] {
+		parts := strings.SplitN(filter, ":", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			*out = h.newMsg("plugins.eventlog.config-add-invalid-filter", filter)
+			return h.actionFinish
+		}
+
+		switch strings.ToLower(parts[0]) {
+		case "target":
+			route.TargetIDs = append(route.TargetIDs, strings.Trim(parts[1], "<@!#&>"))
+		case "exclude-user":
+			user, err := helpers.GetUserFromMention(parts[1])
+			if err != nil {
+				*out = h.newMsg("plugins.eventlog.config-add-invalid-filter", filter)
+				return h.actionFinish
+			}
+			route.ExcludedUserIDs = append(route.ExcludedUserIDs, user.ID)
+		default:
+			*out = h.newMsg("plugins.eventlog.config-add-invalid-filter", filter)
+			return h.actionFinish
+		}
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	settings.EventlogRoutes = append(settings.EventlogRoutes, route)
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, channel.GuildID,
+		models.EventlogTargetTypeGuild, in.Author.ID,
+		models.EventlogTypeRobyulEventlogConfigUpdate, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "eventlog_route_added_channelid",
+				Value: route.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "eventlog_route_added_actiontypes",
+				Value: strings.Join(route.ActionTypes, ","),
+			},
+			{
+				Key:   "eventlog_route_added_categories",
+				Value: strings.Join(route.Categories, ","),
+			},
+			{
+				Key:   "eventlog_route_added_targetids",
+				Value: strings.Join(route.TargetIDs, ","),
+			},
+			{
+				Key:   "eventlog_route_added_excludeduserids",
+				Value: strings.Join(route.ExcludedUserIDs, ","),
+				Type:  models.EventlogTargetTypeUser,
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = h.newMsg("plugins.eventlog.config-add-success", len(settings.EventlogRoutes), routeToText(route))
+	return h.actionFinish
+}
+
+// [p]eventlog config remove <route #>
+func (h *Handler) actionConfigRemove(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if len(args) < 3 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	routeNumber, err := strconv.Atoi(strings.TrimLeft(args[2], "#"))
+	if err != nil || routeNumber < 1 || routeNumber > len(settings.EventlogRoutes) {
+		*out = h.newMsg("plugins.eventlog.config-remove-not-found")
+		return h.actionFinish
+	}
+
+	route := settings.EventlogRoutes[routeNumber-1]
+	newRoutes := make([]models.EventlogRoute, 0)
+	newRoutes = append(newRoutes, settings.EventlogRoutes[:routeNumber-1]...)
+	newRoutes = append(newRoutes, settings.EventlogRoutes[routeNumber:]...)
+	settings.EventlogRoutes = newRoutes
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, channel.GuildID,
+		models.EventlogTargetTypeGuild, in.Author.ID,
+		models.EventlogTypeRobyulEventlogConfigUpdate, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "eventlog_route_removed_channelid",
+				Value: route.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "eventlog_route_removed_actiontypes",
+				Value: strings.Join(route.ActionTypes, ","),
+			},
+			{
+				Key:   "eventlog_route_removed_categories",
+				Value: strings.Join(route.Categories, ","),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = h.newMsg("plugins.eventlog.config-remove-success", routeNumber)
+	return h.actionFinish
+}
+
+func routeToText(route models.EventlogRoute) (text string) {
+	text = "<#" + route.ChannelID + ">: "
+
+	events := append(append([]string{}, route.Categories...), route.ActionTypes...)
+	if len(events) <= 0 {
+		text += helpers.GetText("plugins.eventlog.config-route-all")
+	} else {
+		text += "`" + strings.Join(events, "`, `") + "`"
+	}
+
+	if len(route.TargetIDs) > 0 {
+		text += helpers.GetTextF("plugins.eventlog.config-route-targets", strings.Join(route.TargetIDs, ", "))
+	}
+
+	if len(route.ExcludedUserIDs) > 0 {
+		text += helpers.GetTextF("plugins.eventlog.config-route-excluded-users", strings.Join(route.ExcludedUserIDs, ", "))
+	}
+
+	return text
+}
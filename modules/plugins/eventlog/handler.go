@@ -65,8 +65,12 @@ func (h *Handler) actionStart(args []string, in *discordgo.Message, out **discor
 	}
 
 	switch args[0] {
-	//case "foo
-	//	return h.actionFoo
+	case "ignore-channel":
+		return h.actionIgnoreChannel
+	case "ignored-channels":
+		return h.actionIgnoredChannels
+	case "config":
+		return h.actionConfig
 	}
 
 	*out = h.newMsg("bot.arguments.invalid")
@@ -137,6 +141,90 @@ func (h *Handler) actionToggleEventlog(args []string, in *discordgo.Message, out
 	return h.actionFinish
 }
 
+// [p]eventlog ignore-channel <#channel or channel id>
+func (h *Handler) actionIgnoreChannel(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsAdmin(in) {
+		*out = h.newMsg("admin.no_permission")
+		return h.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.GuildID != channel.GuildID {
+		*out = h.newMsg("bot.arguments.invalid")
+		return h.actionFinish
+	}
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+	beforeChannelIDs := settings.EventlogIgnoredChannelIDs
+
+	setMessage := "plugins.eventlog.channel-ignored"
+	newChannelIDs := make([]string, 0)
+	for _, ignoredChannelID := range beforeChannelIDs {
+		if ignoredChannelID == targetChannel.ID {
+			setMessage = "plugins.eventlog.channel-unignored"
+			continue
+		}
+		newChannelIDs = append(newChannelIDs, ignoredChannelID)
+	}
+	if setMessage == "plugins.eventlog.channel-ignored" {
+		newChannelIDs = append(newChannelIDs, targetChannel.ID)
+	}
+	settings.EventlogIgnoredChannelIDs = newChannelIDs
+
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, channel.GuildID,
+		models.EventlogTargetTypeGuild, in.Author.ID,
+		models.EventlogTypeRobyulEventlogConfigUpdate, "",
+		[]models.ElasticEventlogChange{
+			{
+				Key:      "eventlog_ignoredchannelids",
+				OldValue: strings.Join(beforeChannelIDs, ","),
+				NewValue: strings.Join(newChannelIDs, ","),
+				Type:     models.EventlogTargetTypeChannel,
+			},
+		},
+		nil, false)
+	helpers.RelaxLog(err)
+
+	*out = h.newMsg(setMessage, targetChannel.ID)
+	return h.actionFinish
+}
+
+// [p]eventlog ignored-channels
+func (h *Handler) actionIgnoredChannels(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsMod(in) {
+		*out = h.newMsg("mod.no_permission")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	ignoredChannelIDs := helpers.GuildSettingsGetCached(channel.GuildID).EventlogIgnoredChannelIDs
+	if len(ignoredChannelIDs) <= 0 {
+		*out = h.newMsg("plugins.eventlog.ignored-channels-none")
+		return h.actionFinish
+	}
+
+	var ignoredChannelsText string
+	for _, ignoredChannelID := range ignoredChannelIDs {
+		ignoredChannelsText += "<#" + ignoredChannelID + "> (#" + ignoredChannelID + ")\n"
+	}
+
+	*out = h.newMsg("plugins.eventlog.ignored-channels-list", ignoredChannelsText)
+	return h.actionFinish
+}
+
 func (h *Handler) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
 	_, err := helpers.SendComplex(in.ChannelID, *out)
 	helpers.RelaxMessage(err, in.ChannelID, in.ID)
@@ -432,6 +432,8 @@ func (m *Facebook) lookupFacebookPage(siteName string) (Facebook_Page, error) {
 }
 
 func (m *Facebook) postPostToChannel(channelID string, post Facebook_Post, facebookPage Facebook_Page) {
+	go helpers.OutgoingWebhookEmitFeedPost("facebook", channelID, facebookPage.Username, post.Url)
+
 	facebookNameModifier := ""
 	if facebookPage.Verified {
 		facebookNameModifier += " ☑"
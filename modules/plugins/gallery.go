@@ -3,8 +3,8 @@ package plugins
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
-
 	"time"
 
 	"github.com/Seklfreak/Robyul2/cache"
@@ -26,7 +26,8 @@ func (g *Gallery) Commands() []string {
 }
 
 const (
-	galleryUrlRegexText = `(<?https?:\/\/[^\s]+>?)`
+	galleryUrlRegexText     = `(<?https?:\/\/[^\s]+>?)`
+	galleryRememberDuration = 24 * time.Hour
 )
 
 var (
@@ -53,7 +54,7 @@ func (g *Gallery) Action(command string, content string, msg *discordgo.Message,
 	args := strings.Fields(content)
 	if len(args) >= 1 {
 		switch args[0] {
-		case "add": // [p]gallery add <source channel> <target channel>
+		case "add": // [p]gallery add <source channel> <target channel> [<target channel>...]
 			helpers.RequireMod(msg, func() {
 				if len(args) < 3 {
 					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
@@ -71,17 +72,26 @@ func (g *Gallery) Action(command string, content string, msg *discordgo.Message,
 					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
 					return
 				}
-				targetChannel, err := helpers.GetChannelFromMention(msg, args[2])
-				if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
-					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-					return
+				targetChannelIDs := make([]string, 0)
+				targetChannelNames := make([]string, 0)
+				for _, targetArg := range args[2:] {
+					targetChannel, err := helpers.GetChannelFromMention(msg, targetArg)
+					if err != nil || targetChannel.ID == "" || targetChannel.GuildID != channel.GuildID {
+						helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+						return
+					}
+					if g.containsChannelID(targetChannelIDs, targetChannel.ID) {
+						continue
+					}
+					targetChannelIDs = append(targetChannelIDs, targetChannel.ID)
+					targetChannelNames = append(targetChannelNames, fmt.Sprintf("#%s (%s)", targetChannel.Name, targetChannel.ID))
 				}
 
 				newID, err := helpers.MDbInsert(models.GalleryTable, models.GalleryEntry{
-					SourceChannelID: sourceChannel.ID,
-					TargetChannelID: targetChannel.ID,
-					GuildID:         channel.GuildID,
-					AddedByUserID:   msg.Author.ID,
+					SourceChannelID:  sourceChannel.ID,
+					TargetChannelIDs: targetChannelIDs,
+					GuildID:          channel.GuildID,
+					AddedByUserID:    msg.Author.ID,
 				})
 				helpers.Relax(err)
 
@@ -96,15 +106,15 @@ func (g *Gallery) Action(command string, content string, msg *discordgo.Message,
 							Type:  models.EventlogTargetTypeChannel,
 						},
 						{
-							Key:   "gallery_targetchannelid",
-							Value: targetChannel.ID,
+							Key:   "gallery_targetchannelids",
+							Value: strings.Join(targetChannelIDs, ","),
 							Type:  models.EventlogTargetTypeChannel,
 						},
 					}, false)
 				helpers.RelaxLog(err)
 
-				cache.GetLogger().WithField("module", "galleries").Info(fmt.Sprintf("Added Gallery on Server %s (%s) posting from #%s (%s) to #%s (%s)",
-					guild.Name, guild.ID, sourceChannel.Name, sourceChannel.ID, targetChannel.Name, targetChannel.ID))
+				cache.GetLogger().WithField("module", "galleries").Info(fmt.Sprintf("Added Gallery on Server %s (%s) posting from #%s (%s) to %s",
+					guild.Name, guild.ID, sourceChannel.Name, sourceChannel.ID, strings.Join(targetChannelNames, ", ")))
 				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.gallery.add-success"))
 				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 
@@ -127,14 +137,166 @@ func (g *Gallery) Action(command string, content string, msg *discordgo.Message,
 
 			resultMessage := ":frame_photo: Galleries on this server:\n"
 			for _, entry := range entryBucket {
-				resultMessage += fmt.Sprintf("`%s`: posting from <#%s> to <#%s>\n",
-					helpers.MdbIdToHuman(entry.ID), entry.SourceChannelID, entry.TargetChannelID)
+				targetChannelIDs := helpers.GetGalleryTargetChannelIDs(entry)
+				targetChannelMentions := make([]string, len(targetChannelIDs))
+				for i, targetChannelID := range targetChannelIDs {
+					targetChannelMentions[i] = "<#" + targetChannelID + ">"
+				}
+				resultMessage += fmt.Sprintf("`%s`: posting from <#%s> to %s%s\n",
+					helpers.MdbIdToHuman(entry.ID), entry.SourceChannelID, strings.Join(targetChannelMentions, ", "),
+					g.getRulesText(entry))
 			}
 			resultMessage += fmt.Sprintf("Found **%d** Galleries in total.", len(entryBucket))
 
 			_, err = helpers.SendMessage(msg.ChannelID, resultMessage)
 			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 			return
+		case "set": // [p]gallery set <gallery id> <option=value> [<option=value>...]
+			helpers.RequireMod(msg, func() {
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+
+				session.ChannelTyping(msg.ChannelID)
+
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
+
+				var entryBucket models.GalleryEntry
+				err = helpers.MdbOne(
+					helpers.MdbCollection(models.GalleryTable).Find(bson.M{"guildid": channel.GuildID, "_id": helpers.HumanToMdbId(args[1])}),
+					&entryBucket,
+				)
+				if helpers.IsMdbNotFound(err) {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.gallery.delete-not-found"))
+					return
+				}
+				helpers.Relax(err)
+
+				beforeEntry := entryBucket
+				// galleries created before multiple targets get migrated
+				entryBucket.TargetChannelIDs = helpers.GetGalleryTargetChannelIDs(entryBucket)
+				entryBucket.TargetChannelID = ""
+				addedTargetChannelIDs := make([]string, 0)
+				removedTargetChannelIDs := make([]string, 0)
+
+				for _, option := range args[2:] {
+					optionParts := strings.SplitN(option, "=", 2)
+					if len(optionParts) < 2 {
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.gallery.set-invalid-option", option))
+						return
+					}
+					var ok bool
+					switch strings.ToLower(optionParts[0]) {
+					case "types", "type":
+						entryBucket.MediaTypes, ok = helpers.ParseGalleryMediaTypes(optionParts[1])
+					case "domains", "domain":
+						entryBucket.LinkDomains, ok = helpers.ParseGalleryLinkDomains(optionParts[1])
+					case "min-size":
+						entryBucket.MinWidth, entryBucket.MinHeight, ok = helpers.ParseGalleryDimensions(optionParts[1])
+					case "skip-duplicates":
+						entryBucket.SuppressDuplicates, ok = helpers.ParseGalleryToggle(optionParts[1])
+					case "keep-on-delete":
+						entryBucket.KeepOnSourceDelete, ok = helpers.ParseGalleryToggle(optionParts[1])
+					case "add-target":
+						targetChannel, err := helpers.GetChannelFromMention(msg, optionParts[1])
+						if err == nil && targetChannel.ID != "" && targetChannel.GuildID == channel.GuildID {
+							ok = true
+							if !g.containsChannelID(entryBucket.TargetChannelIDs, targetChannel.ID) {
+								entryBucket.TargetChannelIDs = append(entryBucket.TargetChannelIDs, targetChannel.ID)
+								addedTargetChannelIDs = append(addedTargetChannelIDs, targetChannel.ID)
+							}
+						}
+					case "remove-target":
+						// the target channel might be deleted already, so the mention is not looked up
+						targetChannelID := strings.TrimSuffix(strings.TrimPrefix(optionParts[1], "<#"), ">")
+						for i, channelID := range entryBucket.TargetChannelIDs {
+							if channelID == targetChannelID {
+								entryBucket.TargetChannelIDs = append(entryBucket.TargetChannelIDs[:i], entryBucket.TargetChannelIDs[i+1:]...)
+								removedTargetChannelIDs = append(removedTargetChannelIDs, targetChannelID)
+								ok = true
+								break
+							}
+						}
+					}
+					if !ok {
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.gallery.set-invalid-option", option))
+						return
+					}
+				}
+
+				if len(entryBucket.TargetChannelIDs) <= 0 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.gallery.set-no-targets"))
+					return
+				}
+
+				err = helpers.MDbUpdate(models.GalleryTable, entryBucket.ID, entryBucket)
+				helpers.Relax(err)
+
+				changes := make([]models.ElasticEventlogChange, 0)
+				for _, change := range []models.ElasticEventlogChange{
+					{
+						Key:      "gallery_mediatypes",
+						OldValue: strings.Join(beforeEntry.MediaTypes, ","),
+						NewValue: strings.Join(entryBucket.MediaTypes, ","),
+					},
+					{
+						Key:      "gallery_linkdomains",
+						OldValue: strings.Join(beforeEntry.LinkDomains, ","),
+						NewValue: strings.Join(entryBucket.LinkDomains, ","),
+					},
+					{
+						Key:      "gallery_minsize",
+						OldValue: fmt.Sprintf("%dx%d", beforeEntry.MinWidth, beforeEntry.MinHeight),
+						NewValue: fmt.Sprintf("%dx%d", entryBucket.MinWidth, entryBucket.MinHeight),
+					},
+					{
+						Key:      "gallery_suppressduplicates",
+						OldValue: strconv.FormatBool(beforeEntry.SuppressDuplicates),
+						NewValue: strconv.FormatBool(entryBucket.SuppressDuplicates),
+					},
+					{
+						Key:      "gallery_keeponsourcedelete",
+						OldValue: strconv.FormatBool(beforeEntry.KeepOnSourceDelete),
+						NewValue: strconv.FormatBool(entryBucket.KeepOnSourceDelete),
+					},
+				} {
+					if change.OldValue != change.NewValue {
+						changes = append(changes, change)
+					}
+				}
+
+				options := make([]models.ElasticEventlogOption, 0)
+				if len(addedTargetChannelIDs) > 0 {
+					options = append(options, models.ElasticEventlogOption{
+						Key:   "gallery_targetchannelids_added",
+						Value: strings.Join(addedTargetChannelIDs, ","),
+						Type:  models.EventlogTargetTypeChannel,
+					})
+				}
+				if len(removedTargetChannelIDs) > 0 {
+					options = append(options, models.ElasticEventlogOption{
+						Key:   "gallery_targetchannelids_removed",
+						Value: strings.Join(removedTargetChannelIDs, ","),
+						Type:  models.EventlogTargetTypeChannel,
+					})
+				}
+
+				_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(entryBucket.ID),
+					models.EventlogTargetTypeRobyulGallery, msg.Author.ID,
+					models.EventlogTypeRobyulGalleryUpdate, "",
+					changes,
+					options, false)
+				helpers.RelaxLog(err)
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.gallery.set-success"))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+
+				galleries, err = g.GetGalleries()
+				helpers.RelaxLog(err)
+				return
+			})
 		case "delete", "del", "remove": // [p]gallery delete <gallery id>
 			helpers.RequireAdmin(msg, func() {
 				session.ChannelTyping(msg.ChannelID)
@@ -171,14 +333,14 @@ func (g *Gallery) Action(command string, content string, msg *discordgo.Message,
 							Value: entryBucket.SourceChannelID,
 						},
 						{
-							Key:   "gallery_targetchannelid",
-							Value: entryBucket.TargetChannelID,
+							Key:   "gallery_targetchannelids",
+							Value: strings.Join(helpers.GetGalleryTargetChannelIDs(entryBucket), ","),
 						},
 					}, false)
 				helpers.RelaxLog(err)
 
 				cache.GetLogger().WithField("module", "galleries").Info(fmt.Sprintf("Deleted Gallery on Server #%s posting from #%s to #%s",
-					channel.GuildID, entryBucket.SourceChannelID, entryBucket.TargetChannelID))
+					channel.GuildID, entryBucket.SourceChannelID, strings.Join(helpers.GetGalleryTargetChannelIDs(entryBucket), ", #")))
 				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.gallery.delete-success"))
 				helpers.Relax(err)
 
@@ -218,69 +380,205 @@ func (g *Gallery) OnMessage(content string, msg *discordgo.Message, session *dis
 						return
 					}
 				}
-				var linksToRepost []string
-				// get mirror attachements
-				if len(msg.Attachments) > 0 {
-					for _, attachement := range msg.Attachments {
-						linksToRepost = append(linksToRepost, attachement.URL)
+				// post to all targets
+				for _, post := range g.getPosts(gallery, msg) {
+					if gallery.SuppressDuplicates && g.isDuplicate(gallery, post) {
+						continue
 					}
-				}
-				// get mirror links
-				if strings.Contains(msg.Content, "http") {
-					linksFound := galleryUrlRegex.FindAllString(msg.Content, -1)
-					if len(linksFound) > 0 {
-						for _, linkFound := range linksFound {
-							if strings.HasPrefix(linkFound, "<") == false && strings.HasSuffix(linkFound, ">") == false {
-								linksToRepost = append(linksToRepost, linkFound)
-							}
-						}
+					for _, targetChannelID := range helpers.GetGalleryTargetChannelIDs(gallery) {
+						g.postToTarget(gallery, targetChannelID, msg, post.URL)
 					}
 				}
-				// check if we have target channel
-				_, err = helpers.GetChannelWithoutApi(gallery.TargetChannelID)
-				if err != nil {
-					continue TryNextGallery
-				}
-				// get webhook
-				webhook, err := helpers.GetWebhook(gallery.GuildID, gallery.TargetChannelID)
-				if err != nil && !strings.Contains(err.Error(), "no permission to manage webhooks") {
-					helpers.Relax(err)
-				}
-				// post mirror links
-				if len(linksToRepost) > 0 {
-					for _, linkToRepost := range linksToRepost {
-						var newMessage *discordgo.Message
-						if webhook != nil && webhook.ID != "" && webhook.Token != "" {
-							newMessage, err = helpers.WebhookExecuteWithResult(
-								webhook.ID,
-								webhook.Token,
-								&discordgo.WebhookParams{
-									Content:   fmt.Sprintf("posted %s in <#%s>", linkToRepost, gallery.SourceChannelID),
-									Username:  msg.Author.Username,
-									AvatarURL: helpers.GetAvatarUrl(msg.Author),
-								},
-							)
-							if err != nil {
-								helpers.RelaxLog(err)
-								continue
-							}
-						} else {
-							newMessages, err := helpers.SendMessage(gallery.TargetChannelID,
-								fmt.Sprintf("%s posted %s in <#%s>", msg.Author.Username, linkToRepost, gallery.SourceChannelID))
-							if err != nil {
-								helpers.RelaxLog(err)
-								continue
-							}
-							newMessage = newMessages[0]
-						}
-						err = g.rememberPostedMessage(msg, newMessage)
-						helpers.RelaxLog(err)
-						metrics.GalleryPostsSent.Add(1)
+			}
+		}
+	}()
+}
+
+type galleryPost struct {
+	URL          string
+	MediaType    string
+	IsAttachment bool
+	// size of attachments, links are checked while downloading
+	Size      int
+	ImageHash string
+}
+
+// getPosts returns the attachments and links of the message matching the media types, link domains, and minimum dimensions of the gallery
+func (g *Gallery) getPosts(gallery models.GalleryEntry, msg *discordgo.Message) (posts []galleryPost) {
+	candidates := make([]galleryPost, 0)
+	// get gallery attachments
+	for _, attachment := range msg.Attachments {
+		post := galleryPost{
+			URL:          attachment.URL,
+			MediaType:    helpers.GetGalleryMediaType(attachment.Filename, true),
+			IsAttachment: true,
+			Size:         attachment.Size,
+		}
+		if !helpers.GalleryMediaTypeAllowed(gallery, post.MediaType) ||
+			!helpers.GalleryDimensionsAllowed(gallery, attachment.Width, attachment.Height) {
+			continue
+		}
+		candidates = append(candidates, post)
+	}
+	// get gallery links
+	if strings.Contains(msg.Content, "http") {
+		for _, linkFound := range galleryUrlRegex.FindAllString(msg.Content, -1) {
+			if strings.HasPrefix(linkFound, "<") || strings.HasSuffix(linkFound, ">") {
+				continue
+			}
+			post := galleryPost{
+				URL:       linkFound,
+				MediaType: helpers.GetGalleryMediaType(linkFound, false),
+			}
+			if !helpers.GalleryMediaTypeAllowed(gallery, post.MediaType) ||
+				!helpers.GalleryLinkDomainAllowed(gallery, post.URL) {
+				continue
+			}
+			candidates = append(candidates, post)
+		}
+	}
+
+	posts = make([]galleryPost, 0)
+	for _, post := range candidates {
+		// images are downloaded to check the dimensions of linked images and for the duplicate detection
+		checkDimensions := !post.IsAttachment && (gallery.MinWidth > 0 || gallery.MinHeight > 0)
+		if (post.MediaType == models.GalleryMediaTypeImage || post.MediaType == models.GalleryMediaTypeGif) &&
+			(checkDimensions || gallery.SuppressDuplicates) && post.Size <= helpers.GalleryMaxDownloadSize {
+			imageData, err := helpers.DownloadGalleryFile(post.URL)
+			if err == nil {
+				img, _, err := helpers.DecodeImageBytes(imageData)
+				if err == nil {
+					if checkDimensions && !helpers.GalleryDimensionsAllowed(gallery, img.Bounds().Dx(), img.Bounds().Dy()) {
+						continue
 					}
+					post.ImageHash, err = helpers.GetImageHashString(img)
+					helpers.RelaxLog(err)
 				}
 			}
 		}
-	}()
+		posts = append(posts, post)
+	}
+
+	return posts
+}
+
+// isDuplicate returns true if the image or link has been posted in the gallery during the duplicate window, otherwise the post gets stored
+func (g *Gallery) isDuplicate(gallery models.GalleryEntry, post galleryPost) bool {
+	galleryID := helpers.MdbIdToHuman(gallery.ID)
+	normalizedURL := helpers.NormalizeGalleryURL(post.URL)
+
+	var hashBucket []models.GalleryHashEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.GalleryHashesTable).Find(bson.M{
+		"galleryid": galleryID,
+		"createdat": bson.M{"$gte": time.Now().Add(-helpers.GalleryDuplicateWindow)},
+	}).Sort("-createdat").Limit(1000)).All(&hashBucket)
+	if err != nil {
+		helpers.RelaxLog(err)
+		return false
+	}
+
+	for _, hashEntry := range hashBucket {
+		if hashEntry.URL == normalizedURL {
+			return true
+		}
+		if post.ImageHash != "" && hashEntry.ImageHash != "" {
+			distance, err := helpers.ImageHashStringComparison(post.ImageHash, hashEntry.ImageHash)
+			if err == nil && distance <= helpers.GalleryDuplicateMaxDistance {
+				return true
+			}
+		}
+	}
+
+	_, err = helpers.MDbInsertWithoutLogging(models.GalleryHashesTable, models.GalleryHashEntry{
+		GalleryID: galleryID,
+		ImageHash: post.ImageHash,
+		URL:       normalizedURL,
+		CreatedAt: time.Now(),
+	})
+	helpers.RelaxLog(err)
+
+	_, err = helpers.MdbCollection(models.GalleryHashesTable).RemoveAll(bson.M{
+		"galleryid": galleryID,
+		"createdat": bson.M{"$lt": time.Now().Add(-helpers.GalleryDuplicateWindow)},
+	})
+	helpers.RelaxLog(err)
+
+	return false
+}
+
+func (g *Gallery) postToTarget(gallery models.GalleryEntry, targetChannelID string, msg *discordgo.Message, link string) {
+	// check if we have target channel
+	_, err := helpers.GetChannelWithoutApi(targetChannelID)
+	if err != nil {
+		return
+	}
+	// get webhook
+	webhook, err := helpers.GetWebhook(gallery.GuildID, targetChannelID)
+	if err != nil && !strings.Contains(err.Error(), "no permission to manage webhooks") {
+		helpers.RelaxLog(err)
+		return
+	}
+	// post gallery link
+	var newMessage *discordgo.Message
+	if webhook != nil && webhook.ID != "" && webhook.Token != "" {
+		newMessage, err = helpers.WebhookExecuteWithResult(
+			webhook.ID,
+			webhook.Token,
+			&discordgo.WebhookParams{
+				Content:   fmt.Sprintf("posted %s in <#%s>", link, gallery.SourceChannelID),
+				Username:  msg.Author.Username,
+				AvatarURL: helpers.GetAvatarUrl(msg.Author),
+			},
+		)
+		if err != nil {
+			helpers.RelaxLog(err)
+			return
+		}
+	} else {
+		newMessages, err := helpers.SendMessage(targetChannelID,
+			fmt.Sprintf("%s posted %s in <#%s>", msg.Author.Username, link, gallery.SourceChannelID))
+		if err != nil {
+			helpers.RelaxLog(err)
+			return
+		}
+		newMessage = newMessages[0]
+	}
+	err = g.rememberPostedMessage(msg, newMessage)
+	helpers.RelaxLog(err)
+	metrics.GalleryPostsSent.Add(1)
+}
+
+// getRulesText returns the media types, link domains, minimum dimensions, and flags of the gallery for the list
+func (g *Gallery) getRulesText(entry models.GalleryEntry) (text string) {
+	rules := make([]string, 0)
+	if len(entry.MediaTypes) > 0 {
+		rules = append(rules, "types: "+strings.Join(entry.MediaTypes, ", "))
+	}
+	if len(entry.LinkDomains) > 0 {
+		rules = append(rules, "domains: "+strings.Join(entry.LinkDomains, ", "))
+	}
+	if entry.MinWidth > 0 || entry.MinHeight > 0 {
+		rules = append(rules, fmt.Sprintf("min size: %dx%d", entry.MinWidth, entry.MinHeight))
+	}
+	if entry.SuppressDuplicates {
+		rules = append(rules, "skipping duplicates")
+	}
+	if entry.KeepOnSourceDelete {
+		rules = append(rules, "keeping posts on delete")
+	}
+	if len(rules) <= 0 {
+		return ""
+	}
+	return " (" + strings.Join(rules, "; ") + ")"
+}
+
+func (g *Gallery) containsChannelID(channelIDs []string, channelID string) bool {
+	for _, item := range channelIDs {
+		if item == channelID {
+			return true
+		}
+	}
+	return false
 }
 
 type Gallery_PostedMessage struct {
@@ -290,7 +588,7 @@ type Gallery_PostedMessage struct {
 
 func (g *Gallery) rememberPostedMessage(sourceMessage *discordgo.Message, mirroredMessage *discordgo.Message) error {
 	redis := cache.GetRedisClient()
-	key := fmt.Sprintf("robyul2-discord:gallery:postedmessage:%s", sourceMessage.ID)
+	key := fmt.Sprintf(models.GalleryPostedMessagesRedisKey, sourceMessage.ID)
 
 	item := new(Gallery_PostedMessage)
 	item.ChannelID = mirroredMessage.ChannelID
@@ -306,13 +604,13 @@ func (g *Gallery) rememberPostedMessage(sourceMessage *discordgo.Message, mirror
 		return err
 	}
 
-	_, err = redis.Expire(key, time.Hour*1).Result()
+	_, err = redis.Expire(key, galleryRememberDuration).Result()
 	return err
 }
 
 func (g *Gallery) getRememberedMessages(sourceMessage *discordgo.Message) ([]Gallery_PostedMessage, error) {
 	redis := cache.GetRedisClient()
-	key := fmt.Sprintf("robyul2-discord:gallery:postedmessage:%s", sourceMessage.ID)
+	key := fmt.Sprintf(models.GalleryPostedMessagesRedisKey, sourceMessage.ID)
 
 	length, err := redis.LLen(key).Result()
 	if err != nil {
@@ -372,6 +670,10 @@ func (g *Gallery) OnMessageDelete(msg *discordgo.MessageDelete, session *discord
 
 		for _, gallery := range galleries {
 			if gallery.SourceChannelID == msg.ChannelID {
+				if gallery.KeepOnSourceDelete {
+					continue
+				}
+
 				rememberedMessages, err = g.getRememberedMessages(msg.Message)
 				helpers.Relax(err)
 
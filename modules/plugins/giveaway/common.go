@@ -0,0 +1,534 @@
+package giveaway
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Seklfreak/Robyul2/modules/plugins/levels"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/globalsign/mgo/bson"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	giveawayEmoji = "🎉"
+	giveawayColor = 0x0FADED
+)
+
+type giveawayCacheEntry struct {
+	ID        bson.ObjectId
+	MessageID string
+}
+
+var (
+	giveawayIDsCache       []giveawayCacheEntry
+	giveawayIDsCacheLock   sync.RWMutex
+	giveawayEntryLocks     = make(map[string]*sync.Mutex)
+	giveawayEntryLocksLock sync.Mutex
+
+	durationRegex     = regexp.MustCompile(`^(\d+[wdhms])+$`)
+	durationPartRegex = regexp.MustCompile(`(\d+)([wdhms])`)
+
+	errGiveawayNoEntrantsLeft = errors.New("no entrants left")
+)
+
+func logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "giveaway")
+}
+
+// parseDuration parses durations like 1w2d12h30m
+func parseDuration(input string) (duration time.Duration, err error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if !durationRegex.MatchString(input) {
+		return 0, errors.New("invalid duration")
+	}
+
+	for _, part := range durationPartRegex.FindAllStringSubmatch(input, -1) {
+		value, err := strconv.Atoi(part[1])
+		if err != nil {
+			return 0, err
+		}
+		switch part[2] {
+		case "w":
+			duration += time.Duration(value) * time.Hour * 24 * 7
+		case "d":
+			duration += time.Duration(value) * time.Hour * 24
+		case "h":
+			duration += time.Duration(value) * time.Hour
+		case "m":
+			duration += time.Duration(value) * time.Minute
+		case "s":
+			duration += time.Duration(value) * time.Second
+		}
+	}
+	return duration, nil
+}
+
+func refreshGiveawayIDsCache() (err error) {
+	var entryBucket []models.GiveawayEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.GiveawaysTable).
+			Find(bson.M{"active": true}).
+			Select(bson.M{"_id": 1, "messageid": 1}),
+	).All(&entryBucket)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]giveawayCacheEntry, 0)
+	for _, entry := range entryBucket {
+		ids = append(ids, giveawayCacheEntry{
+			ID:        entry.ID,
+			MessageID: entry.MessageID,
+		})
+	}
+
+	giveawayIDsCacheLock.Lock()
+	giveawayIDsCache = ids
+	giveawayIDsCacheLock.Unlock()
+	return nil
+}
+
+func getCachedGiveawayIDForMessage(messageID string) (id bson.ObjectId, found bool) {
+	giveawayIDsCacheLock.RLock()
+	defer giveawayIDsCacheLock.RUnlock()
+
+	for _, cacheEntry := range giveawayIDsCache {
+		if cacheEntry.MessageID == messageID {
+			return cacheEntry.ID, true
+		}
+	}
+	return "", false
+}
+
+func lockGiveaway(id bson.ObjectId) {
+	giveawayEntryLocksLock.Lock()
+	if _, ok := giveawayEntryLocks[string(id)]; !ok {
+		giveawayEntryLocks[string(id)] = new(sync.Mutex)
+	}
+	lock := giveawayEntryLocks[string(id)]
+	giveawayEntryLocksLock.Unlock()
+
+	lock.Lock()
+}
+
+func unlockGiveaway(id bson.ObjectId) {
+	giveawayEntryLocksLock.Lock()
+	lock, ok := giveawayEntryLocks[string(id)]
+	giveawayEntryLocksLock.Unlock()
+
+	if ok {
+		lock.Unlock()
+	}
+}
+
+func getGiveaway(id bson.ObjectId) (giveaway models.GiveawayEntry, err error) {
+	err = helpers.MdbOneWithoutLogging(
+		helpers.MdbCollection(models.GiveawaysTable).Find(bson.M{"_id": id}),
+		&giveaway,
+	)
+	return giveaway, err
+}
+
+// findGiveaway looks up a giveaway of a guild by its human ID or by its message ID
+func findGiveaway(guildID, input string) (giveaway models.GiveawayEntry, err error) {
+	if helpers.IsSnowflake(input) {
+		err = helpers.MdbOne(
+			helpers.MdbCollection(models.GiveawaysTable).Find(bson.M{"guildid": guildID, "messageid": input}),
+			&giveaway,
+		)
+		return giveaway, err
+	}
+
+	id := helpers.HumanToMdbId(input)
+	if id == "" {
+		return giveaway, errors.New("invalid giveaway id")
+	}
+
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.GiveawaysTable).Find(bson.M{"guildid": guildID, "_id": id}),
+		&giveaway,
+	)
+	return giveaway, err
+}
+
+// checkRequirements returns an empty reason if the user is allowed to enter the giveaway
+func checkRequirements(giveaway models.GiveawayEntry, userID string) (reason string) {
+	requirements := giveaway.Requirements
+
+	if requirements.MinimumAccountAge > 0 {
+		if time.Since(helpers.GetTimeFromSnowflake(userID)) < requirements.MinimumAccountAge {
+			return helpers.GetTextF("plugins.giveaway.requirement-account-age",
+				helpers.HumanizeDuration(requirements.MinimumAccountAge))
+		}
+	}
+
+	if requirements.RoleID != "" || requirements.MinimumServerTenure > 0 {
+		member, err := helpers.GetGuildMember(giveaway.GuildID, userID)
+		if err != nil {
+			return helpers.GetText("plugins.giveaway.requirement-member")
+		}
+
+		if requirements.RoleID != "" {
+			var hasRole bool
+			for _, memberRoleID := range member.Roles {
+				if memberRoleID == requirements.RoleID {
+					hasRole = true
+					break
+				}
+			}
+			if !hasRole {
+				roleName := requirements.RoleID
+				role, err := cache.GetSession().State.Role(giveaway.GuildID, requirements.RoleID)
+				if err == nil {
+					roleName = role.Name
+				}
+				return helpers.GetTextF("plugins.giveaway.requirement-role", roleName)
+			}
+		}
+
+		if requirements.MinimumServerTenure > 0 {
+			if time.Since(getJoinedAt(giveaway.GuildID, member)) < requirements.MinimumServerTenure {
+				return helpers.GetTextF("plugins.giveaway.requirement-server-tenure",
+					helpers.HumanizeDuration(requirements.MinimumServerTenure))
+			}
+		}
+	}
+
+	if requirements.MinimumLevel > 0 {
+		var serverUser models.LevelsServerusersEntry
+		err := helpers.MdbOneWithoutLogging(
+			helpers.MdbCollection(models.LevelsServerusersTable).Find(bson.M{"userid": userID, "guildid": giveaway.GuildID}),
+			&serverUser,
+		)
+		if err != nil && !helpers.IsMdbNotFound(err) {
+			helpers.RelaxLog(err)
+		}
+		if levels.GetLevelFromExp(serverUser.Exp) < requirements.MinimumLevel {
+			return helpers.GetTextF("plugins.giveaway.requirement-level", requirements.MinimumLevel)
+		}
+	}
+
+	return ""
+}
+
+// getJoinedAt returns the earliest known join time of a member
+// the join log is preferred over the member object because rejoining resets the member join date
+func getJoinedAt(guildID string, member *discordgo.Member) (joinedAt time.Time) {
+	var firstJoin models.ModJoinlogEntry
+	err := helpers.MdbOneWithoutLogging(
+		helpers.MdbCollection(models.ModJoinlogTable).
+			Find(bson.M{"guildid": guildID, "userid": member.User.ID}).Sort("joinedat"),
+		&firstJoin,
+	)
+	if err == nil && !firstJoin.JoinedAt.IsZero() {
+		joinedAt = firstJoin.JoinedAt
+	}
+
+	if member.JoinedAt != "" {
+		memberJoinedAt, err := discordgo.Timestamp(member.JoinedAt).Parse()
+		if err == nil && (joinedAt.IsZero() || memberJoinedAt.Before(joinedAt)) {
+			joinedAt = memberJoinedAt
+		}
+	}
+
+	if joinedAt.IsZero() {
+		joinedAt = time.Now()
+	}
+	return joinedAt
+}
+
+func getRequirementsText(requirements models.GiveawayRequirements) (text string) {
+	lines := make([]string, 0)
+	if requirements.MinimumLevel > 0 {
+		lines = append(lines, fmt.Sprintf("Level %d or higher", requirements.MinimumLevel))
+	}
+	if requirements.RoleID != "" {
+		lines = append(lines, fmt.Sprintf("Role <@&%s>", requirements.RoleID))
+	}
+	if requirements.MinimumAccountAge > 0 {
+		lines = append(lines, fmt.Sprintf("Account older than %s", helpers.HumanizeDuration(requirements.MinimumAccountAge)))
+	}
+	if requirements.MinimumServerTenure > 0 {
+		lines = append(lines, fmt.Sprintf("Member of the server for %s", helpers.HumanizeDuration(requirements.MinimumServerTenure)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func getMentionsText(userIDs []string) string {
+	mentions := make([]string, 0)
+	for _, userID := range userIDs {
+		mentions = append(mentions, "<@"+userID+">")
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func getGiveawayEmbed(giveaway models.GiveawayEntry) *discordgo.MessageEmbed {
+	createdBy, err := helpers.GetUserWithoutAPI(giveaway.CreatedByUserID)
+	if err != nil {
+		createdBy = new(discordgo.User)
+		createdBy.Username = "N/A"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color:     giveawayColor,
+		Title:     giveawayEmoji + " " + giveaway.Prize,
+		Timestamp: giveaway.EndsAt.Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Hosted by %s | Giveaway #%s | Ends at",
+				createdBy.Username, helpers.MdbIdToHuman(giveaway.ID)),
+			IconURL: createdBy.AvatarURL("64"),
+		},
+	}
+
+	if giveaway.Active {
+		timeLeft := giveaway.EndsAt.Sub(time.Now())
+		if timeLeft < time.Minute {
+			timeLeft = time.Minute
+		}
+		embed.Description = helpers.GetTextF("plugins.giveaway.embed-active",
+			giveawayEmoji, helpers.HumanizeDuration(timeLeft.Truncate(time.Minute)),
+			giveaway.WinnersCount, humanize.Comma(int64(len(giveaway.EntrantUserIDs))))
+	} else {
+		winnersText := getMentionsText(giveaway.WinnerUserIDs)
+		if winnersText == "" {
+			winnersText = helpers.GetText("plugins.giveaway.no-winners")
+		}
+		embed.Color = helpers.GetDiscordColorFromHex("#73d016")
+		embed.Description = helpers.GetTextF("plugins.giveaway.embed-ended",
+			winnersText, humanize.Comma(int64(len(giveaway.EntrantUserIDs))))
+		embed.Footer.Text = fmt.Sprintf("Hosted by %s | Giveaway #%s | Ended at",
+			createdBy.Username, helpers.MdbIdToHuman(giveaway.ID))
+		embed.Timestamp = giveaway.EndedAt.Format(time.RFC3339)
+	}
+
+	requirementsText := getRequirementsText(giveaway.Requirements)
+	if requirementsText != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Requirements",
+			Value: requirementsText,
+		})
+	}
+
+	return embed
+}
+
+// drawGiveaway draws winners for a giveaway, stores the drawing and announces the winners
+// previous winners are excluded, so this is used for ending a giveaway and for rerolls
+func drawGiveaway(giveaway *models.GiveawayEntry, count int, userID string) (draw models.GiveawayDraw, err error) {
+	seed, err := newSeed()
+	if err != nil {
+		return draw, err
+	}
+
+	excludedUserIDs := make([]string, 0)
+	for _, previousDraw := range giveaway.Draws {
+		excludedUserIDs = append(excludedUserIDs, previousDraw.WinnerUserIDs...)
+	}
+
+	draw = models.GiveawayDraw{
+		DrawnAt:         time.Now(),
+		DrawnByUserID:   userID,
+		Seed:            seed,
+		EntrantsHash:    hashEntrants(giveaway.EntrantUserIDs),
+		EntrantsCount:   len(giveaway.EntrantUserIDs),
+		ExcludedUserIDs: excludedUserIDs,
+		WinnerUserIDs:   drawWinners(seed, giveaway.EntrantUserIDs, excludedUserIDs, count),
+	}
+	giveaway.Draws = append(giveaway.Draws, draw)
+
+	return draw, nil
+}
+
+func logDraw(giveaway models.GiveawayEntry, draw models.GiveawayDraw, actionType string) {
+	_, err := helpers.EventlogLog(draw.DrawnAt, giveaway.GuildID, helpers.MdbIdToHuman(giveaway.ID),
+		models.EventlogTargetTypeRobyulGiveaway, draw.DrawnByUserID,
+		actionType, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "giveaway_prize",
+				Value: giveaway.Prize,
+			},
+			{
+				Key:   "giveaway_seed",
+				Value: draw.Seed,
+			},
+			{
+				Key:   "giveaway_entrants_hash",
+				Value: draw.EntrantsHash,
+			},
+			{
+				Key:   "giveaway_entrants_count",
+				Value: strconv.Itoa(draw.EntrantsCount),
+			},
+			{
+				Key:   "giveaway_excluded_userids",
+				Value: strings.Join(draw.ExcludedUserIDs, ","),
+				Type:  models.EventlogTargetTypeUser,
+			},
+			{
+				Key:   "giveaway_winner_userids",
+				Value: strings.Join(draw.WinnerUserIDs, ","),
+				Type:  models.EventlogTargetTypeUser,
+			},
+		}, false)
+	helpers.RelaxLog(err)
+}
+
+func announceWinners(giveaway models.GiveawayEntry, winnerUserIDs []string, reroll bool) {
+	if len(winnerUserIDs) <= 0 {
+		_, err := helpers.SendMessage(giveaway.ChannelID,
+			helpers.GetTextF("plugins.giveaway.announce-no-winners", giveaway.Prize))
+		helpers.RelaxLog(err)
+		return
+	}
+
+	announceKey := "plugins.giveaway.announce-winners"
+	if reroll {
+		announceKey = "plugins.giveaway.announce-winners-reroll"
+	}
+	_, err := helpers.SendMessage(giveaway.ChannelID,
+		helpers.GetTextF(announceKey, giveawayEmoji, getMentionsText(winnerUserIDs), giveaway.Prize))
+	helpers.RelaxLog(err)
+
+	guildName := giveaway.GuildID
+	guild, err := helpers.GetGuildWithoutApi(giveaway.GuildID)
+	if err == nil {
+		guildName = guild.Name
+	}
+
+	for _, winnerUserID := range winnerUserIDs {
+		dmChannel, err := cache.GetSession().UserChannelCreate(winnerUserID)
+		if err != nil {
+			continue
+		}
+		helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.giveaway.winner-dm",
+			giveawayEmoji, giveaway.Prize, guildName, giveaway.GuildID, giveaway.ChannelID, giveaway.MessageID))
+	}
+}
+
+// endGiveaway draws the winners of an active giveaway and announces them
+func endGiveaway(id bson.ObjectId, userID string) (err error) {
+	lockGiveaway(id)
+	defer unlockGiveaway(id)
+
+	giveaway, err := getGiveaway(id)
+	if err != nil {
+		return err
+	}
+
+	if !giveaway.Active {
+		return nil
+	}
+
+	draw, err := drawGiveaway(&giveaway, giveaway.WinnersCount, userID)
+	if err != nil {
+		return err
+	}
+
+	giveaway.Active = false
+	giveaway.EndedAt = draw.DrawnAt
+	giveaway.WinnerUserIDs = draw.WinnerUserIDs
+
+	err = helpers.MDbUpdate(models.GiveawaysTable, giveaway.ID, giveaway)
+	if err != nil {
+		return err
+	}
+
+	err = refreshGiveawayIDsCache()
+	helpers.RelaxLog(err)
+
+	_, err = helpers.EditEmbed(giveaway.ChannelID, giveaway.MessageID, getGiveawayEmbed(giveaway))
+	helpers.RelaxLog(err)
+
+	logDraw(giveaway, draw, models.EventlogTypeRobyulGiveawayEnd)
+
+	announceWinners(giveaway, draw.WinnerUserIDs, false)
+	return nil
+}
+
+// rerollGiveaway draws new winners for an ended giveaway, previous winners can not win again
+func rerollGiveaway(id bson.ObjectId, count int, userID string) (draw models.GiveawayDraw, err error) {
+	lockGiveaway(id)
+	defer unlockGiveaway(id)
+
+	giveaway, err := getGiveaway(id)
+	if err != nil {
+		return draw, err
+	}
+
+	if giveaway.Active {
+		return draw, errors.New("giveaway is still active")
+	}
+
+	draw, err = drawGiveaway(&giveaway, count, userID)
+	if err != nil {
+		return draw, err
+	}
+
+	// an empty draw isn't stored or announced
+	if len(draw.WinnerUserIDs) <= 0 {
+		return draw, errGiveawayNoEntrantsLeft
+	}
+
+	giveaway.WinnerUserIDs = append(giveaway.WinnerUserIDs, draw.WinnerUserIDs...)
+
+	err = helpers.MDbUpdate(models.GiveawaysTable, giveaway.ID, giveaway)
+	if err != nil {
+		return draw, err
+	}
+
+	_, err = helpers.EditEmbed(giveaway.ChannelID, giveaway.MessageID, getGiveawayEmbed(giveaway))
+	helpers.RelaxLog(err)
+
+	logDraw(giveaway, draw, models.EventlogTypeRobyulGiveawayReroll)
+
+	announceWinners(giveaway, draw.WinnerUserIDs, true)
+	return draw, nil
+}
+
+// giveawayLoop ends due giveaways and keeps the countdown of active giveaways up to date
+func giveawayLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			logger().Error("the giveawayLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			giveawayLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(time.Minute * 1)
+
+		var activeGiveaways []models.GiveawayEntry
+		err := helpers.MDbIterWithoutLogging(
+			helpers.MdbCollection(models.GiveawaysTable).Find(bson.M{"active": true}),
+		).All(&activeGiveaways)
+		helpers.Relax(err)
+
+		for _, giveaway := range activeGiveaways {
+			if !time.Now().Before(giveaway.EndsAt) {
+				err = endGiveaway(giveaway.ID, "")
+				if err != nil {
+					logger().WithField("giveawayID", helpers.MdbIdToHuman(giveaway.ID)).Errorf(
+						"ending giveaway failed: %s", err.Error())
+				}
+				continue
+			}
+
+			_, err = helpers.EditEmbed(giveaway.ChannelID, giveaway.MessageID, getGiveawayEmbed(giveaway))
+			helpers.RelaxLog(err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package giveaway
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// newSeed returns a random hex encoded seed for a winner drawing
+func newSeed() (seed string, err error) {
+	data := make([]byte, 32)
+	_, err = rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// hashEntrants returns a hash over the sorted entrants so a drawing can be verified later
+func hashEntrants(entrantUserIDs []string) string {
+	sorted := make([]string, len(entrantUserIDs))
+	copy(sorted, entrantUserIDs)
+	sort.Strings(sorted)
+
+	hash := sha256.Sum256([]byte(strings.Join(sorted, ",")))
+	return hex.EncodeToString(hash[:])
+}
+
+// drawWinners picks count winners out of entrantUserIDs, skipping excludedUserIDs
+// every entrant gets a ticket of sha256(seed:userID), the lowest tickets win
+// the result only depends on the seed and the set of entrants, the order of the entrants does not matter
+func drawWinners(seed string, entrantUserIDs []string, excludedUserIDs []string, count int) (winnerUserIDs []string) {
+	winnerUserIDs = make([]string, 0)
+	if count <= 0 {
+		return winnerUserIDs
+	}
+
+	type ticket struct {
+		UserID string
+		Value  string
+	}
+
+	tickets := make([]ticket, 0)
+	seen := make(map[string]bool)
+NextEntrant:
+	for _, entrantUserID := range entrantUserIDs {
+		if seen[entrantUserID] {
+			continue
+		}
+		seen[entrantUserID] = true
+
+		for _, excludedUserID := range excludedUserIDs {
+			if excludedUserID == entrantUserID {
+				continue NextEntrant
+			}
+		}
+
+		hash := sha256.Sum256([]byte(seed + ":" + entrantUserID))
+		tickets = append(tickets, ticket{
+			UserID: entrantUserID,
+			Value:  hex.EncodeToString(hash[:]),
+		})
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		if tickets[i].Value == tickets[j].Value {
+			return tickets[i].UserID < tickets[j].UserID
+		}
+		return tickets[i].Value < tickets[j].Value
+	})
+
+	for i := 0; i < count && i < len(tickets); i++ {
+		winnerUserIDs = append(winnerUserIDs, tickets[i].UserID)
+	}
+	return winnerUserIDs
+}
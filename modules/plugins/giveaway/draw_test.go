@@ -0,0 +1,71 @@
+package giveaway
+
+import (
+	"testing"
+)
+
+func TestDrawWinnersDeterministic(t *testing.T) {
+	entrants := []string{"100", "200", "300", "400", "500", "600"}
+	reversed := []string{"600", "500", "400", "300", "200", "100"}
+
+	first := drawWinners("seed", entrants, nil, 3)
+	second := drawWinners("seed", reversed, nil, 3)
+
+	if len(first) != 3 {
+		t.Fatalf("giveaway.drawWinners() returned %d winners, expected 3", len(first))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatal("giveaway.drawWinners() depends on the order of the entrants")
+		}
+	}
+}
+
+func TestDrawWinnersExcluded(t *testing.T) {
+	entrants := []string{"100", "200", "300"}
+
+	first := drawWinners("seed", entrants, nil, 1)
+	rerolled := drawWinners("seed", entrants, first, 3)
+
+	if len(rerolled) != 2 {
+		t.Fatalf("giveaway.drawWinners() returned %d winners, expected 2", len(rerolled))
+	}
+	for _, winner := range rerolled {
+		if winner == first[0] {
+			t.Fatal("giveaway.drawWinners() picked an excluded entrant")
+		}
+	}
+}
+
+func TestDrawWinnersDuplicates(t *testing.T) {
+	winners := drawWinners("seed", []string{"100", "100", "100"}, nil, 3)
+	if len(winners) != 1 {
+		t.Fatalf("giveaway.drawWinners() returned %d winners for a single entrant", len(winners))
+	}
+}
+
+func TestHashEntrants(t *testing.T) {
+	if hashEntrants([]string{"1", "2", "3"}) != hashEntrants([]string{"3", "1", "2"}) {
+		t.Fatal("giveaway.hashEntrants() depends on the order of the entrants")
+	}
+	if hashEntrants([]string{"1", "2"}) == hashEntrants([]string{"1", "2", "3"}) {
+		t.Fatal("giveaway.hashEntrants() returned the same hash for different entrants")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	duration, err := parseDuration("1d12h")
+	if err != nil || duration.Hours() != 36 {
+		t.Fatalf("giveaway.parseDuration() failed to parse 1d12h: %v", duration)
+	}
+
+	duration, err = parseDuration("2w")
+	if err != nil || duration.Hours() != 14*24 {
+		t.Fatalf("giveaway.parseDuration() failed to parse 2w: %v", duration)
+	}
+
+	_, err = parseDuration("soon")
+	if err == nil {
+		t.Fatal("giveaway.parseDuration() parsed an invalid duration")
+	}
+}
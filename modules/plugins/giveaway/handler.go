@@ -0,0 +1,535 @@
+package giveaway
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/globalsign/mgo/bson"
+)
+
+type Handler struct{}
+
+type action func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next action)
+
+func (h *Handler) Commands() []string {
+	return []string{
+		"giveaway",
+		"giveaways",
+	}
+}
+
+func (h *Handler) Init(session *discordgo.Session) {
+	defer helpers.Recover()
+
+	err := refreshGiveawayIDsCache()
+	helpers.Relax(err)
+
+	go giveawayLoop()
+	logger().Info("started giveawayLoop loop (1m)")
+}
+
+func (h *Handler) Uninit(session *discordgo.Session) {
+	defer helpers.Recover()
+}
+
+func (h *Handler) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	if !helpers.ModuleIsAllowed(msg.ChannelID, msg.ID, msg.Author.ID, helpers.ModulePermGiveaway) {
+		return
+	}
+
+	var result *discordgo.MessageSend
+	args, err := helpers.ToArgv(content)
+	if err != nil {
+		args = strings.Fields(content)
+	}
+
+	action := h.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (h *Handler) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if len(args) < 1 {
+		return h.actionList
+	}
+
+	switch args[0] {
+	case "start", "create":
+		return h.actionCreate
+	case "end":
+		return h.actionEnd
+	case "reroll":
+		return h.actionReroll
+	case "list":
+		return h.actionList
+	case "info", "audit":
+		return h.actionInfo
+	case "refresh":
+		return h.actionRefresh
+	}
+
+	*out = h.newMsg("bot.arguments.invalid")
+	return h.actionFinish
+}
+
+// [p]giveaway start <#channel> <winners> <duration> <prize> [level=<n>] [role=<role>] [account-age=<duration>] [tenure=<duration>]
+func (h *Handler) actionCreate(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsMod(in) {
+		*out = h.newMsg("mod.no_permission")
+		return h.actionFinish
+	}
+
+	if len(args) < 5 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil {
+		*out = h.newMsg("bot.arguments.invalid")
+		return h.actionFinish
+	}
+
+	winnersCount, err := strconv.Atoi(args[2])
+	if err != nil || winnersCount < 1 || winnersCount > 20 {
+		*out = h.newMsg("plugins.giveaway.create-invalid-winners")
+		return h.actionFinish
+	}
+
+	duration, err := parseDuration(args[3])
+	if err != nil || duration < time.Minute {
+		*out = h.newMsg("plugins.giveaway.create-invalid-duration")
+		return h.actionFinish
+	}
+
+	guild, err := helpers.GetGuild(targetChannel.GuildID)
+	helpers.Relax(err)
+
+	var requirements models.GiveawayRequirements
+	prizeParts := make([]string, 0)
+	for _, arg := range args[4:] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) < 2 {
+			prizeParts = append(prizeParts, arg)
+			continue
+		}
+
+		switch strings.ToLower(parts[0]) {
+		case "level":
+			requirements.MinimumLevel, err = strconv.Atoi(parts[1])
+			if err != nil || requirements.MinimumLevel < 0 {
+				*out = h.newMsg("plugins.giveaway.create-invalid-requirement", arg)
+				return h.actionFinish
+			}
+		case "role":
+			for _, role := range guild.Roles {
+				if role.ID == parts[1] || strings.ToLower(role.Name) == strings.ToLower(parts[1]) ||
+					"<@&"+role.ID+">" == parts[1] {
+					requirements.RoleID = role.ID
+				}
+			}
+			if requirements.RoleID == "" {
+				*out = h.newMsg("plugins.giveaway.create-invalid-requirement", arg)
+				return h.actionFinish
+			}
+		case "account-age", "accountage":
+			requirements.MinimumAccountAge, err = parseDuration(parts[1])
+			if err != nil {
+				*out = h.newMsg("plugins.giveaway.create-invalid-requirement", arg)
+				return h.actionFinish
+			}
+		case "tenure", "server-tenure":
+			requirements.MinimumServerTenure, err = parseDuration(parts[1])
+			if err != nil {
+				*out = h.newMsg("plugins.giveaway.create-invalid-requirement", arg)
+				return h.actionFinish
+			}
+		default:
+			prizeParts = append(prizeParts, arg)
+		}
+	}
+
+	prize := strings.TrimSpace(strings.Join(prizeParts, " "))
+	if prize == "" {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	postedMessages, err := helpers.SendEmbed(targetChannel.ID, &discordgo.MessageEmbed{
+		Color:       giveawayColor,
+		Description: "**Giveaway is being created...** :construction_site:",
+	})
+	helpers.RelaxEmbed(err, in.ChannelID, in.ID)
+	if len(postedMessages) <= 0 {
+		*out = h.newMsg("bot.errors.generic-nomessage")
+		return h.actionFinish
+	}
+	postedMessage := postedMessages[0]
+
+	newGiveaway := models.GiveawayEntry{
+		ID:              bson.NewObjectId(),
+		GuildID:         guild.ID,
+		ChannelID:       targetChannel.ID,
+		MessageID:       postedMessage.ID,
+		CreatedByUserID: in.Author.ID,
+		CreatedAt:       time.Now(),
+		EndsAt:          time.Now().Add(duration),
+		Prize:           prize,
+		WinnersCount:    winnersCount,
+		Requirements:    requirements,
+		Active:          true,
+		EntrantUserIDs:  make([]string, 0),
+		WinnerUserIDs:   make([]string, 0),
+		Draws:           make([]models.GiveawayDraw, 0),
+	}
+
+	_, err = helpers.MDbInsert(models.GiveawaysTable, newGiveaway)
+	helpers.Relax(err)
+
+	err = refreshGiveawayIDsCache()
+	helpers.Relax(err)
+
+	err = cache.GetSession().MessageReactionAdd(postedMessage.ChannelID, postedMessage.ID, giveawayEmoji)
+	helpers.RelaxLog(err)
+
+	_, err = helpers.EditEmbed(postedMessage.ChannelID, postedMessage.ID, getGiveawayEmbed(newGiveaway))
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, helpers.MdbIdToHuman(newGiveaway.ID),
+		models.EventlogTargetTypeRobyulGiveaway, in.Author.ID,
+		models.EventlogTypeRobyulGiveawayStart, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "giveaway_prize",
+				Value: newGiveaway.Prize,
+			},
+			{
+				Key:   "giveaway_channelid",
+				Value: newGiveaway.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "giveaway_winners",
+				Value: strconv.Itoa(newGiveaway.WinnersCount),
+			},
+			{
+				Key:   "giveaway_endsat",
+				Value: newGiveaway.EndsAt.Format(models.ISO8601),
+			},
+			{
+				Key:   "giveaway_requirements",
+				Value: getRequirementsText(newGiveaway.Requirements),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = h.newMsg("plugins.giveaway.create-success", helpers.MdbIdToHuman(newGiveaway.ID), targetChannel.ID)
+	return h.actionFinish
+}
+
+// [p]giveaway end <giveaway id or message id>
+func (h *Handler) actionEnd(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsMod(in) {
+		*out = h.newMsg("mod.no_permission")
+		return h.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	giveaway, err := findGiveaway(channel.GuildID, args[1])
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			*out = h.newMsg("plugins.giveaway.not-found")
+			return h.actionFinish
+		}
+		helpers.Relax(err)
+	}
+
+	if !giveaway.Active {
+		*out = h.newMsg("plugins.giveaway.end-not-active")
+		return h.actionFinish
+	}
+
+	err = endGiveaway(giveaway.ID, in.Author.ID)
+	helpers.Relax(err)
+
+	*out = h.newMsg("plugins.giveaway.end-success")
+	return h.actionFinish
+}
+
+// [p]giveaway reroll <giveaway id or message id> [<number of winners>]
+func (h *Handler) actionReroll(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsMod(in) {
+		*out = h.newMsg("mod.no_permission")
+		return h.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	giveaway, err := findGiveaway(channel.GuildID, args[1])
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			*out = h.newMsg("plugins.giveaway.not-found")
+			return h.actionFinish
+		}
+		helpers.Relax(err)
+	}
+
+	if giveaway.Active {
+		*out = h.newMsg("plugins.giveaway.reroll-still-active")
+		return h.actionFinish
+	}
+
+	count := 1
+	if len(args) >= 3 {
+		count, err = strconv.Atoi(args[2])
+		if err != nil || count < 1 || count > 20 {
+			*out = h.newMsg("plugins.giveaway.create-invalid-winners")
+			return h.actionFinish
+		}
+	}
+
+	_, err = rerollGiveaway(giveaway.ID, count, in.Author.ID)
+	if err == errGiveawayNoEntrantsLeft {
+		*out = h.newMsg("plugins.giveaway.reroll-no-entrants-left")
+		return h.actionFinish
+	}
+	helpers.Relax(err)
+
+	*out = h.newMsg("plugins.giveaway.reroll-success")
+	return h.actionFinish
+}
+
+// [p]giveaway list
+func (h *Handler) actionList(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var activeGiveaways []models.GiveawayEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.GiveawaysTable).Find(bson.M{"guildid": channel.GuildID, "active": true}).Sort("endsat"),
+	).All(&activeGiveaways)
+	helpers.Relax(err)
+
+	if len(activeGiveaways) <= 0 {
+		*out = h.newMsg("plugins.giveaway.list-none")
+		return h.actionFinish
+	}
+
+	var listText string
+	for _, giveaway := range activeGiveaways {
+		listText += helpers.GetTextF("plugins.giveaway.list-entry",
+			helpers.MdbIdToHuman(giveaway.ID), giveaway.Prize, giveaway.ChannelID,
+			humanize.Comma(int64(len(giveaway.EntrantUserIDs))),
+			helpers.HumanizeDuration(giveaway.EndsAt.Sub(time.Now()).Truncate(time.Minute))) + "\n"
+	}
+	listText += helpers.GetTextF("plugins.giveaway.list-sum", len(activeGiveaways))
+
+	*out = &discordgo.MessageSend{Content: listText}
+	return h.actionFinish
+}
+
+// [p]giveaway info <giveaway id or message id>
+func (h *Handler) actionInfo(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if len(args) < 2 {
+		*out = h.newMsg("bot.arguments.too-few")
+		return h.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	giveaway, err := findGiveaway(channel.GuildID, args[1])
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			*out = h.newMsg("plugins.giveaway.not-found")
+			return h.actionFinish
+		}
+		helpers.Relax(err)
+	}
+
+	embed := getGiveawayEmbed(giveaway)
+	for i, draw := range giveaway.Draws {
+		winnersText := getMentionsText(draw.WinnerUserIDs)
+		if winnersText == "" {
+			winnersText = helpers.GetText("plugins.giveaway.no-winners")
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Draw #" + strconv.Itoa(i+1) + " at " + draw.DrawnAt.UTC().Format(time.ANSIC) + " UTC",
+			Value: helpers.GetTextF("plugins.giveaway.info-draw",
+				draw.Seed, draw.EntrantsHash, draw.EntrantsCount, winnersText),
+		})
+	}
+
+	*out = &discordgo.MessageSend{Embed: embed}
+	return h.actionFinish
+}
+
+// [p]giveaway refresh
+func (h *Handler) actionRefresh(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	if !helpers.IsBotAdmin(in.Author.ID) {
+		*out = h.newMsg("botadmin.no_permission")
+		return h.actionFinish
+	}
+
+	err := refreshGiveawayIDsCache()
+	helpers.Relax(err)
+
+	*out = h.newMsg("plugins.giveaway.refreshed")
+	return h.actionFinish
+}
+
+func (h *Handler) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) action {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (h *Handler) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (h *Handler) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+	if reaction.UserID == session.State.User.ID {
+		return
+	}
+
+	giveawayID, found := getCachedGiveawayIDForMessage(reaction.MessageID)
+	if !found {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		if reaction.Emoji.APIName() != giveawayEmoji {
+			session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, reaction.Emoji.APIName(), reaction.UserID)
+			return
+		}
+
+		lockGiveaway(giveawayID)
+		defer unlockGiveaway(giveawayID)
+
+		giveaway, err := getGiveaway(giveawayID)
+		helpers.Relax(err)
+
+		if !giveaway.Active {
+			return
+		}
+
+		for _, entrantUserID := range giveaway.EntrantUserIDs {
+			if entrantUserID == reaction.UserID {
+				return
+			}
+		}
+
+		reason := checkRequirements(giveaway, reaction.UserID)
+		if reason != "" {
+			session.MessageReactionRemove(reaction.ChannelID, reaction.MessageID, reaction.Emoji.APIName(), reaction.UserID)
+
+			dmChannel, err := session.UserChannelCreate(reaction.UserID)
+			if err == nil {
+				helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.giveaway.entry-denied-dm", giveaway.Prize, reason))
+			}
+			return
+		}
+
+		giveaway.EntrantUserIDs = append(giveaway.EntrantUserIDs, reaction.UserID)
+		err = helpers.MDbUpdateWithoutLogging(models.GiveawaysTable, giveaway.ID, giveaway)
+		helpers.Relax(err)
+	}()
+}
+
+func (h *Handler) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+	if reaction.UserID == session.State.User.ID {
+		return
+	}
+
+	if reaction.Emoji.APIName() != giveawayEmoji {
+		return
+	}
+
+	giveawayID, found := getCachedGiveawayIDForMessage(reaction.MessageID)
+	if !found {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		lockGiveaway(giveawayID)
+		defer unlockGiveaway(giveawayID)
+
+		giveaway, err := getGiveaway(giveawayID)
+		helpers.Relax(err)
+
+		if !giveaway.Active {
+			return
+		}
+
+		without := make([]string, 0)
+		for _, entrantUserID := range giveaway.EntrantUserIDs {
+			if entrantUserID == reaction.UserID {
+				continue
+			}
+			without = append(without, entrantUserID)
+		}
+		if len(without) == len(giveaway.EntrantUserIDs) {
+			return
+		}
+
+		giveaway.EntrantUserIDs = without
+		err = helpers.MDbUpdateWithoutLogging(models.GiveawaysTable, giveaway.ID, giveaway)
+		helpers.Relax(err)
+	}()
+}
+
+func (h *Handler) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+
+}
+
+func (h *Handler) OnMessageDelete(msg *discordgo.MessageDelete, session *discordgo.Session) {
+
+}
+
+func (h *Handler) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (h *Handler) OnGuildMemberRemove(member *discordgo.Member, session *discordgo.Session) {
+
+}
+
+func (h *Handler) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
+
+}
+
+func (h *Handler) OnGuildBanRemove(user *discordgo.GuildBanRemove, session *discordgo.Session) {
+
+}
@@ -0,0 +1,706 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/sirupsen/logrus"
+)
+
+type idolCalendarAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next idolCalendarAction)
+
+type IdolCalendar struct{}
+
+// idolCalendarImportEntry is the format of JSON imports, CSV imports use the same columns
+type idolCalendarImportEntry struct {
+	Type  string
+	Date  string // 2006-01-02
+	Group string
+	Idol  string
+	Title string
+}
+
+var (
+	// all dates are in KST, no DST in Korea
+	idolCalendarLocation = time.FixedZone("KST", 9*60*60)
+)
+
+func (m *IdolCalendar) Commands() []string {
+	return []string{
+		"calendar",
+	}
+}
+
+func (m *IdolCalendar) Init(session *discordgo.Session) {
+	go m.postLoop()
+	m.logger().Info("started postLoop loop (10m)")
+}
+
+func (m *IdolCalendar) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	if !helpers.ModuleIsAllowed(msg.ChannelID, msg.ID, msg.Author.ID, helpers.ModulePermCalendar) {
+		return
+	}
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := m.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (m *IdolCalendar) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if len(args) < 1 {
+		return m.actionWeek
+	}
+
+	switch args[0] {
+	case "week":
+		return m.actionWeek
+	case "month":
+		return m.actionMonth
+	case "add":
+		return m.actionAdd
+	case "import":
+		return m.actionImport
+	case "remove", "delete":
+		return m.actionRemove
+	case "subscribe":
+		return m.actionSubscribe
+	case "unsubscribe":
+		return m.actionUnsubscribe
+	case "subscriptions":
+		return m.actionSubscriptions
+	}
+
+	*out = m.newMsg("bot.arguments.invalid")
+	return m.actionFinish
+}
+
+// [p]calendar [week] [<group>]
+func (m *IdolCalendar) actionWeek(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	from := idolCalendarToday()
+	return m.view(args, in, out, from, from.AddDate(0, 0, 7), "plugins.idolcalendar.week-title")
+}
+
+// [p]calendar month [<group>]
+func (m *IdolCalendar) actionMonth(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	from := idolCalendarToday()
+	return m.view(args, in, out, from, from.AddDate(0, 1, 0), "plugins.idolcalendar.month-title")
+}
+
+func (m *IdolCalendar) view(args []string, in *discordgo.Message, out **discordgo.MessageSend, from, until time.Time, titleKey string) idolCalendarAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var groupNames []string
+	if len(args) >= 2 {
+		groupNames = []string{strings.TrimSpace(strings.Join(args[1:], " "))}
+	} else {
+		groupNames, err = m.getSubscribedGroups(channel.GuildID)
+		helpers.Relax(err)
+	}
+
+	events, err := m.getEvents()
+	helpers.Relax(err)
+
+	occurrences := filterIdolCalendarOccurrences(helpers.IdolCalendarOccurrences(events, from, until), groupNames)
+	if len(occurrences) <= 0 {
+		*out = m.newMsg("plugins.idolcalendar.view-none")
+		return m.actionFinish
+	}
+
+	viewText := helpers.GetTextF(titleKey, from.Format("Jan 2"), until.AddDate(0, 0, -1).Format("Jan 2")) + "\n"
+	var lastDay string
+	for _, occurrence := range occurrences {
+		day := occurrence.Date.Format("Mon, Jan 2")
+		if day != lastDay {
+			viewText += "\n**" + day + "**\n"
+			lastDay = day
+		}
+		viewText += idolCalendarOccurrenceText(occurrence) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(viewText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+// [p]calendar add <type>,<YYYY-MM-DD>,<group>,[<idol>],[<title>]
+func (m *IdolCalendar) actionAdd(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	if !helpers.IsRobyulMod(in.Author.ID) {
+		*out = m.newMsg("robyulmod.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	recordText := strings.TrimSpace(in.Content[strings.Index(in.Content, args[0])+len(args[0]):])
+	records, err := csv.NewReader(strings.NewReader(recordText)).ReadAll()
+	if err != nil || len(records) != 1 {
+		*out = m.newMsg("plugins.idolcalendar.add-invalid")
+		return m.actionFinish
+	}
+
+	event, err := helpers.ParseIdolCalendarRecord(records[0])
+	if err != nil {
+		*out = m.newMsg("plugins.idolcalendar.add-invalid")
+		return m.actionFinish
+	}
+
+	newID, err := m.addEvent(event, in.Author.ID)
+	helpers.Relax(err)
+	if newID == "" {
+		*out = m.newMsg("plugins.idolcalendar.add-duplicate")
+		return m.actionFinish
+	}
+
+	*out = m.newMsg("plugins.idolcalendar.add-success",
+		event.Type, event.GroupName, event.Date.Format(helpers.IdolCalendarDateFormat), helpers.MdbIdToHuman(newID))
+	return m.actionFinish
+}
+
+// [p]calendar import (with a CSV or JSON file attached)
+func (m *IdolCalendar) actionImport(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	if !helpers.IsRobyulMod(in.Author.ID) {
+		*out = m.newMsg("robyulmod.no_permission")
+		return m.actionFinish
+	}
+
+	if len(in.Attachments) <= 0 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	data, err := helpers.NetGetUAWithError(in.Attachments[0].URL, helpers.DEFAULT_UA)
+	helpers.Relax(err)
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // removes BOM
+
+	var events []models.IdolCalendarEventEntry
+	if strings.HasSuffix(strings.ToLower(in.Attachments[0].Filename), ".json") {
+		events, err = parseIdolCalendarJSON(data)
+	} else {
+		events, err = parseIdolCalendarCSV(data)
+	}
+	if err != nil {
+		*out = m.newMsg("plugins.idolcalendar.import-invalid", err.Error())
+		return m.actionFinish
+	}
+
+	var imported, skipped int
+	for _, event := range events {
+		newID, err := m.addEvent(event, in.Author.ID)
+		helpers.Relax(err)
+		if newID != "" {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+
+	*out = m.newMsg("plugins.idolcalendar.import-success", imported, skipped)
+	return m.actionFinish
+}
+
+// [p]calendar remove <id>
+func (m *IdolCalendar) actionRemove(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	if !helpers.IsRobyulMod(in.Author.ID) {
+		*out = m.newMsg("robyulmod.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	var event models.IdolCalendarEventEntry
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.IdolCalendarEventsTable).Find(bson.M{"_id": helpers.HumanToMdbId(args[1])}),
+		&event,
+	)
+	if helpers.IsMdbNotFound(err) {
+		*out = m.newMsg("plugins.idolcalendar.not-found")
+		return m.actionFinish
+	}
+	helpers.Relax(err)
+
+	err = helpers.MDbDelete(models.IdolCalendarEventsTable, event.ID)
+	helpers.Relax(err)
+
+	*out = m.newMsg("plugins.idolcalendar.remove-success")
+	return m.actionFinish
+}
+
+// [p]calendar subscribe <#channel> [<group>, <group>, …]
+// without groups the groups behind the bias roles of the server are used
+func (m *IdolCalendar) actionSubscribe(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	if !helpers.IsMod(in) {
+		*out = m.newMsg("mod.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	sourceChannel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.GuildID != sourceChannel.GuildID {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	groupNames := make([]string, 0)
+	if len(args) >= 3 {
+		groupsText := strings.TrimSpace(in.Content[strings.Index(in.Content, args[1])+len(args[1]):])
+		for _, groupName := range strings.Split(groupsText, ",") {
+			groupName = strings.TrimSpace(groupName)
+			if groupName != "" {
+				groupNames = append(groupNames, groupName)
+			}
+		}
+	} else {
+		groupNames, err = m.getGroupsFromBiasRoles(targetChannel.GuildID)
+		helpers.Relax(err)
+	}
+	if len(groupNames) <= 0 {
+		*out = m.newMsg("plugins.idolcalendar.subscribe-no-groups")
+		return m.actionFinish
+	}
+
+	var entry models.IdolCalendarSubscriptionEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.IdolCalendarSubscriptionsTable).Find(bson.M{"channelid": targetChannel.ID}),
+		&entry,
+	)
+	if err != nil && !helpers.IsMdbNotFound(err) {
+		helpers.Relax(err)
+	}
+	if entry.ID == "" {
+		entry = models.IdolCalendarSubscriptionEntry{
+			ID:             bson.NewObjectId(),
+			GuildID:        targetChannel.GuildID,
+			ChannelID:      targetChannel.ID,
+			AddedByUserID:  in.Author.ID,
+			AddedAt:        time.Now(),
+			LastPostedDate: idolCalendarToday().Format(helpers.IdolCalendarDateFormat),
+		}
+	}
+	entry.GroupNames = groupNames
+
+	err = helpers.MDbUpsertID(models.IdolCalendarSubscriptionsTable, entry.ID, entry)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), targetChannel.GuildID, helpers.MdbIdToHuman(entry.ID),
+		models.EventlogTargetTypeRobyulIdolCalendar, in.Author.ID,
+		models.EventlogTypeRobyulIdolCalendarSubscribe, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "idolcalendar_channelid",
+				Value: targetChannel.ID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "idolcalendar_groups",
+				Value: strings.Join(groupNames, ", "),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.idolcalendar.subscribe-success", strings.Join(groupNames, ", "), targetChannel.ID)
+	return m.actionFinish
+}
+
+// [p]calendar unsubscribe <#channel>
+func (m *IdolCalendar) actionUnsubscribe(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	if !helpers.IsMod(in) {
+		*out = m.newMsg("mod.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	sourceChannel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.GuildID != sourceChannel.GuildID {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	var entry models.IdolCalendarSubscriptionEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.IdolCalendarSubscriptionsTable).Find(bson.M{"channelid": targetChannel.ID}),
+		&entry,
+	)
+	if helpers.IsMdbNotFound(err) {
+		*out = m.newMsg("plugins.idolcalendar.not-subscribed")
+		return m.actionFinish
+	}
+	helpers.Relax(err)
+
+	err = helpers.MDbDelete(models.IdolCalendarSubscriptionsTable, entry.ID)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), entry.GuildID, helpers.MdbIdToHuman(entry.ID),
+		models.EventlogTargetTypeRobyulIdolCalendar, in.Author.ID,
+		models.EventlogTypeRobyulIdolCalendarUnsubscribe, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "idolcalendar_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "idolcalendar_groups",
+				Value: strings.Join(entry.GroupNames, ", "),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.idolcalendar.unsubscribe-success", entry.ChannelID)
+	return m.actionFinish
+}
+
+// [p]calendar subscriptions
+func (m *IdolCalendar) actionSubscriptions(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	if !helpers.IsMod(in) {
+		*out = m.newMsg("mod.no_permission")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var entryBucket []models.IdolCalendarSubscriptionEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.IdolCalendarSubscriptionsTable).Find(bson.M{"guildid": channel.GuildID})).All(&entryBucket)
+	helpers.Relax(err)
+
+	if len(entryBucket) <= 0 {
+		*out = m.newMsg("plugins.idolcalendar.subscriptions-none")
+		return m.actionFinish
+	}
+
+	var listText string
+	for _, entry := range entryBucket {
+		listText += helpers.GetTextF("plugins.idolcalendar.subscriptions-entry", entry.ChannelID, strings.Join(entry.GroupNames, ", ")) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(listText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+func (m *IdolCalendar) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) idolCalendarAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (m *IdolCalendar) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (m *IdolCalendar) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "idolcalendar")
+}
+
+func (m *IdolCalendar) getEvents() (events []models.IdolCalendarEventEntry, err error) {
+	err = helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.IdolCalendarEventsTable).Find(nil)).All(&events)
+	return events, err
+}
+
+// addEvent adds the event, returns an empty ID if the same event exists already
+func (m *IdolCalendar) addEvent(event models.IdolCalendarEventEntry, userID string) (id bson.ObjectId, err error) {
+	count, err := helpers.MdbCount(models.IdolCalendarEventsTable, bson.M{
+		"type":      event.Type,
+		"groupname": bson.M{"$regex": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(event.GroupName) + "$", Options: "i"}},
+		"idolname":  bson.M{"$regex": bson.RegEx{Pattern: "^" + regexp.QuoteMeta(event.IdolName) + "$", Options: "i"}},
+		"date":      event.Date,
+	})
+	if err != nil || count > 0 {
+		return "", err
+	}
+
+	event.AddedByUserID = userID
+	event.AddedAt = time.Now()
+	return helpers.MDbInsert(models.IdolCalendarEventsTable, event)
+}
+
+// getSubscribedGroups returns the groups all channels of the guild are subscribed to
+func (m *IdolCalendar) getSubscribedGroups(guildID string) (groupNames []string, err error) {
+	var entryBucket []models.IdolCalendarSubscriptionEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.IdolCalendarSubscriptionsTable).Find(bson.M{"guildid": guildID})).All(&entryBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entryBucket {
+		groupNames = append(groupNames, entry.GroupNames...)
+	}
+	return groupNames, nil
+}
+
+// getGroupsFromBiasRoles returns the groups matching the bias roles of the guild
+func (m *IdolCalendar) getGroupsFromBiasRoles(guildID string) (groupNames []string, err error) {
+	var biasEntries []models.BiasEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.BiasTable).Find(bson.M{"guildid": guildID})).All(&biasEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := m.getEvents()
+	if err != nil {
+		return nil, err
+	}
+	var idols []models.BiasGameIdolEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.BiasGameIdolsTable).Find(nil).
+		Select(bson.M{"groupname": 1})).All(&idols)
+	if err != nil {
+		return nil, err
+	}
+
+	knownGroupNames := make([]string, 0)
+	for _, event := range events {
+		knownGroupNames = append(knownGroupNames, event.GroupName)
+	}
+	for _, idol := range idols {
+		knownGroupNames = append(knownGroupNames, idol.GroupName)
+	}
+
+	return helpers.IdolCalendarBiasRoleGroups(biasEntries, knownGroupNames), nil
+}
+
+// getMentionRoleIDs returns the bias roles of the guild matching the idol, or the group for group events
+func (m *IdolCalendar) getMentionRoleIDs(guildID string, biasEntries []models.BiasEntry, event models.IdolCalendarEventEntry) (roleIDs []string) {
+	guild, err := helpers.GetGuild(guildID)
+	if err != nil {
+		return nil
+	}
+
+	guildBiasEntries := make([]models.BiasEntry, 0)
+	for _, biasEntry := range biasEntries {
+		if biasEntry.GuildID == guildID {
+			guildBiasEntries = append(guildBiasEntries, biasEntry)
+		}
+	}
+
+	for _, biasEntry := range guildBiasEntries {
+		for _, category := range biasEntry.Categories {
+			for _, biasRole := range category.Roles {
+				if !helpers.IdolCalendarBiasRoleMatches(guildBiasEntries, category, biasRole, event.GroupName, event.IdolName) {
+					continue
+				}
+				for _, role := range guild.Roles {
+					if strings.ToLower(role.Name) == strings.ToLower(biasRole.Name) && !containsStringFold(roleIDs, role.ID) {
+						roleIDs = append(roleIDs, role.ID)
+					}
+				}
+			}
+		}
+	}
+	return roleIDs
+}
+
+// postLoop posts the events of the day once the day starts in KST
+func (m *IdolCalendar) postLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			m.logger().Error("the postLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.postLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(10 * time.Minute)
+
+		today := idolCalendarToday()
+		todayText := today.Format(helpers.IdolCalendarDateFormat)
+
+		var subscriptions []models.IdolCalendarSubscriptionEntry
+		err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.IdolCalendarSubscriptionsTable).Find(
+			bson.M{"lastposteddate": bson.M{"$ne": todayText}},
+		)).All(&subscriptions)
+		helpers.Relax(err)
+		if len(subscriptions) <= 0 {
+			continue
+		}
+
+		events, err := m.getEvents()
+		helpers.Relax(err)
+		occurrences := helpers.IdolCalendarOccurrences(events, today, today.AddDate(0, 0, 1))
+
+		var biasEntries []models.BiasEntry
+		err = helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.BiasTable).Find(nil)).All(&biasEntries)
+		helpers.Relax(err)
+
+		for _, subscription := range subscriptions {
+			// mark first, posting twice would be worse than missing a day
+			err = helpers.MDbUpdateQueryWithoutLogging(models.IdolCalendarSubscriptionsTable,
+				bson.M{"_id": subscription.ID}, bson.M{"$set": bson.M{"lastposteddate": todayText}})
+			if err != nil {
+				helpers.RelaxLog(err)
+				continue
+			}
+
+			for _, occurrence := range filterIdolCalendarOccurrences(occurrences, subscription.GroupNames) {
+				err = m.post(subscription, biasEntries, occurrence)
+				if err != nil {
+					if errD, ok := err.(*discordgo.RESTError); ok &&
+						(errD.Message.Code == discordgo.ErrCodeMissingPermissions ||
+							errD.Message.Code == discordgo.ErrCodeMissingAccess ||
+							errD.Message.Code == discordgo.ErrCodeUnknownChannel) {
+						continue
+					}
+					helpers.RelaxLog(err)
+				}
+			}
+		}
+	}
+}
+
+func (m *IdolCalendar) post(subscription models.IdolCalendarSubscriptionEntry, biasEntries []models.BiasEntry, occurrence helpers.IdolCalendarOccurrence) (err error) {
+	mentionsText, resetMentions := helpers.MentionRoles(subscription.GuildID,
+		m.getMentionRoleIDs(subscription.GuildID, biasEntries, occurrence.Event))
+	defer resetMentions()
+
+	_, err = helpers.SendMessage(subscription.ChannelID, strings.TrimSpace(mentionsText+idolCalendarOccurrenceText(occurrence)))
+	return err
+}
+
+// filterIdolCalendarOccurrences returns the occurrences of the given groups, all occurrences if no groups are given
+func filterIdolCalendarOccurrences(occurrences []helpers.IdolCalendarOccurrence, groupNames []string) (filtered []helpers.IdolCalendarOccurrence) {
+	if len(groupNames) <= 0 {
+		return occurrences
+	}
+	for _, occurrence := range occurrences {
+		if containsStringFold(groupNames, occurrence.Event.GroupName) {
+			filtered = append(filtered, occurrence)
+		}
+	}
+	return filtered
+}
+
+func idolCalendarOccurrenceText(occurrence helpers.IdolCalendarOccurrence) string {
+	event := occurrence.Event
+	switch event.Type {
+	case models.IdolCalendarEventTypeBirthday:
+		if occurrence.Years > 0 {
+			return helpers.GetTextF("plugins.idolcalendar.event-birthday-age", event.IdolName, event.GroupName, occurrence.Years)
+		}
+		return helpers.GetTextF("plugins.idolcalendar.event-birthday", event.IdolName, event.GroupName)
+	case models.IdolCalendarEventTypeDebut:
+		name := event.GroupName
+		if event.IdolName != "" {
+			name = event.IdolName + " (" + event.GroupName + ")"
+		}
+		if occurrence.Years > 0 {
+			return helpers.GetTextF("plugins.idolcalendar.event-debut-anniversary", name, occurrence.Years)
+		}
+		return helpers.GetTextF("plugins.idolcalendar.event-debut", name)
+	}
+
+	name := event.GroupName
+	if event.IdolName != "" {
+		name = event.IdolName + " (" + event.GroupName + ")"
+	}
+	return helpers.GetTextF("plugins.idolcalendar.event-release", name, event.Title)
+}
+
+func parseIdolCalendarCSV(data []byte) (events []models.IdolCalendarEventEntry, err error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	var line int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+
+		// optional header
+		if line == 1 && len(record) > 0 && strings.ToLower(strings.TrimSpace(record[0])) == "type" {
+			continue
+		}
+
+		event, err := helpers.ParseIdolCalendarRecord(record)
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func parseIdolCalendarJSON(data []byte) (events []models.IdolCalendarEventEntry, err error) {
+	var entries []idolCalendarImportEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		event, err := helpers.ParseIdolCalendarRecord([]string{entry.Type, entry.Date, entry.Group, entry.Idol, entry.Title})
+		if err != nil {
+			return nil, errors.New("entry " + strconv.Itoa(i+1) + ": " + err.Error())
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func idolCalendarToday() time.Time {
+	now := time.Now().In(idolCalendarLocation)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, idolCalendarLocation)
+}
+
+func containsStringFold(list []string, item string) bool {
+	for _, listItem := range list {
+		if strings.ToLower(listItem) == strings.ToLower(item) {
+			return true
+		}
+	}
+	return false
+}
@@ -12,6 +12,9 @@ import (
 )
 
 func (m *Handler) postPostToChannel(channelID string, post InstagramPostInformation, postType models.InstagramSendPostType) {
+	go helpers.OutgoingWebhookEmitFeedPost("instagram", channelID, post.Author.Username,
+		fmt.Sprintf(instagramFriendlyPost, post.Shortcode))
+
 	instagramNameModifier := ""
 	if post.Author.IsVerified {
 		instagramNameModifier += " ☑"
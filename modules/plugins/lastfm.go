@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,11 +28,19 @@ const (
 	lastfmHexColor           = "#d51007"
 	lastfmFriendlyUser       = "https://www.last.fm/user/%s"
 	lastfmYouTubeFriendlyUrl = "https://youtu.be/%s"
+	// how often the now playing channels are updated, takes longer if there are a lot of users to poll
+	lastfmNowPlayingInterval = 1 * time.Minute
+	lastfmChartsInterval     = 7 * 24 * time.Hour
 )
 
 var (
 	lastfmCachedStats        []LastFMAccountCachedStats
 	lastfmCombinedGuildStats []LastFMCombinedGuildStats
+	// all background requests share this limit, last.fm allows five requests per second averaged over five minutes
+	// this leaves enough requests for the commands
+	lastfmBackgroundRequests = time.Tick(500 * time.Millisecond)
+	// guild id => channel id and description of the last now playing update
+	lastfmNowPlayingLastUpdates = make(map[string]string)
 )
 
 type LastFMAccount_Safe_Entries struct {
@@ -82,6 +91,8 @@ func (m *LastFm) Init(session *discordgo.Session) {
 	lastfmCombinedGuildStats = make([]LastFMCombinedGuildStats, 0)
 
 	go m.generateDiscordStats()
+	go m.nowPlayingLoop()
+	go m.weeklyChartsLoop()
 }
 
 func (m *LastFm) generateDiscordStats() {
@@ -110,6 +121,7 @@ func (m *LastFm) generateDiscordStats() {
 			periods := []string{"overall", "7day", "1month", "3month", "6month", "12month"}
 
 			for _, period := range periods {
+				<-lastfmBackgroundRequests
 				lastfmTopTracks, err := helpers.GetLastFmClient().User.GetTopTracks(lastfm.P{
 					"limit":  50,
 					"user":   safeAccount.LastFmUsername,
@@ -329,10 +341,10 @@ func (m *LastFm) Action(command string, content string, msg *discordgo.Message,
 				err := helpers.MDbUpsert(
 					models.LastFmTable,
 					bson.M{"userid": msg.Author.ID},
-					models.LastFmEntry{
-						UserID:         msg.Author.ID,
-						LastFmUsername: lastfmUsername,
-					},
+					bson.M{"$set": bson.M{
+						"userid":         msg.Author.ID,
+						"lastfmusername": lastfmUsername,
+					}},
 				)
 				helpers.Relax(err)
 
@@ -341,6 +353,39 @@ func (m *LastFm) Action(command string, content string, msg *discordgo.Message,
 				helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
 				return
 			}
+		case "now-playing-channel", "np-channel":
+			helpers.RequireMod(msg, func() {
+				m.setGuildChannel(msg, args, false)
+			})
+			return
+		case "charts-channel":
+			helpers.RequireMod(msg, func() {
+				m.setGuildChannel(msg, args, true)
+			})
+			return
+		case "np-optout", "np-optin":
+			err := helpers.MDbUpdateQuery(
+				models.LastFmTable,
+				bson.M{"userid": msg.Author.ID},
+				bson.M{"$set": bson.M{"hidenowplaying": subCom == "np-optout"}},
+			)
+			if helpers.IsMdbNotFound(err) {
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
+				helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.lastfm.too-few", helpers.GetPrefixForServer(channel.GuildID)))
+				return
+			}
+			helpers.Relax(err)
+
+			if subCom == "np-optout" {
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.lastfm.np-optout-success"))
+			} else {
+				helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.lastfm.np-optin-success"))
+			}
+			return
+		case "taste":
+			m.compareTaste(msg, args, lastfmUsername)
+			return
 		case "np", "nowplaying":
 			var err error
 			targetUser := msg.Author
@@ -1274,3 +1319,535 @@ func (m *LastFm) Action(command string, content string, msg *discordgo.Message,
 	}
 
 }
+
+// setGuildChannel sets or disables the now playing or the weekly charts channel of the server
+func (m *LastFm) setGuildChannel(msg *discordgo.Message, args []string, charts bool) {
+	if len(args) < 2 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	var config models.LastFmGuildConfigEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.LastFmGuildConfigsTable).Find(bson.M{"guildid": channel.GuildID}),
+		&config,
+	)
+	if err != nil && !helpers.IsMdbNotFound(err) {
+		helpers.Relax(err)
+	}
+	config.GuildID = channel.GuildID
+
+	targetChannelID := ""
+	if args[1] != "off" && args[1] != "disable" {
+		targetChannel, err := helpers.GetChannelFromMention(msg, args[1])
+		if err != nil || targetChannel.GuildID != channel.GuildID {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+			return
+		}
+		targetChannelID = targetChannel.ID
+	}
+
+	if charts {
+		config.ChartsChannelID = targetChannelID
+	} else {
+		config.NowPlayingChannelID = targetChannelID
+		config.NowPlayingMessageID = ""
+	}
+
+	if config.ID == "" {
+		_, err = helpers.MDbInsert(models.LastFmGuildConfigsTable, config)
+	} else {
+		err = helpers.MDbUpdate(models.LastFmGuildConfigsTable, config.ID, config)
+	}
+	helpers.Relax(err)
+
+	switch {
+	case targetChannelID == "":
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.lastfm.channel-disabled"))
+	case charts:
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.lastfm.charts-channel-set", targetChannelID))
+	default:
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.lastfm.np-channel-set", targetChannelID))
+	}
+}
+
+func (m *LastFm) nowPlayingLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "lastfm").Error("The nowPlayingLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.nowPlayingLoop()
+		}()
+	}()
+
+	for {
+		started := time.Now()
+		m.updateNowPlayingChannels()
+
+		if time.Since(started) < lastfmNowPlayingInterval {
+			time.Sleep(lastfmNowPlayingInterval - time.Since(started))
+		}
+	}
+}
+
+// updateNowPlayingChannels polls the users on servers with a now playing channel and updates the channels
+func (m *LastFm) updateNowPlayingChannels() {
+	var guildConfigs []models.LastFmGuildConfigEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.LastFmGuildConfigsTable).Find(
+		bson.M{"nowplayingchannelid": bson.M{"$ne": ""}},
+	)).All(&guildConfigs)
+	helpers.Relax(err)
+
+	if len(guildConfigs) <= 0 {
+		return
+	}
+
+	var accounts []models.LastFmEntry
+	err = helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.LastFmTable).Find(
+		bson.M{"hidenowplaying": bson.M{"$ne": true}},
+	)).All(&accounts)
+	helpers.Relax(err)
+
+	// user id => current track
+	nowPlaying := make(map[string]LastFMSongInfo)
+	for _, account := range accounts {
+		// only poll users who are on a server with a now playing channel
+		var onGuild bool
+		for _, guildConfig := range guildConfigs {
+			if helpers.GetIsInGuild(guildConfig.GuildID, account.UserID) {
+				onGuild = true
+				break
+			}
+		}
+		if !onGuild {
+			continue
+		}
+
+		<-lastfmBackgroundRequests
+		lastfmRecentTracks, err := helpers.GetLastFmClient().User.GetRecentTracks(lastfm.P{
+			"limit": 1,
+			"user":  account.LastFmUsername,
+		})
+		metrics.LastFmRequests.Add(1)
+		if err != nil || len(lastfmRecentTracks.Tracks) <= 0 || lastfmRecentTracks.Tracks[0].NowPlaying != "true" {
+			continue
+		}
+
+		nowPlaying[account.UserID] = LastFMSongInfo{
+			Name:       lastfmRecentTracks.Tracks[0].Name,
+			Url:        lastfmRecentTracks.Tracks[0].Url,
+			ArtistName: lastfmRecentTracks.Tracks[0].Artist.Name,
+		}
+	}
+
+	for _, guildConfig := range guildConfigs {
+		var lines []string
+		for userID, track := range nowPlaying {
+			member, err := helpers.GetGuildMemberWithoutApi(guildConfig.GuildID, userID)
+			if err != nil || member == nil || member.User == nil {
+				continue
+			}
+			displayName := member.User.Username
+			if member.Nick != "" {
+				displayName = member.Nick
+			}
+
+			lines = append(lines, fmt.Sprintf("**%s**: [**%s** by **%s**](%s)",
+				displayName, track.Name, track.ArtistName, helpers.EscapeLinkForMarkdown(track.Url)))
+		}
+		sort.Strings(lines)
+
+		m.updateNowPlayingMessage(guildConfig, lines)
+	}
+}
+
+// updateNowPlayingMessage edits the now playing message of the server, or posts a new one if it has been deleted
+func (m *LastFm) updateNowPlayingMessage(guildConfig models.LastFmGuildConfigEntry, lines []string) {
+	description := helpers.GetText("plugins.lastfm.np-channel-nobody")
+	if len(lines) > 0 {
+		description = ""
+		for i, line := range lines {
+			if len(description)+len(line) > 1900 {
+				description += fmt.Sprintf("*and %d more*", len(lines)-i)
+				break
+			}
+			description += line + "\n"
+		}
+	}
+
+	// skip the update if nothing changed
+	lastUpdate := guildConfig.NowPlayingChannelID + "\n" + guildConfig.NowPlayingMessageID + "\n" + description
+	if lastfmNowPlayingLastUpdates[guildConfig.GuildID] == lastUpdate {
+		return
+	}
+
+	nowPlayingEmbed := &discordgo.MessageEmbed{
+		Title:       helpers.GetText("plugins.lastfm.np-channel-embed-title"),
+		Description: description,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    helpers.GetText("plugins.lastfm.embed-footer") + " | opt out with lastfm np-optout",
+			IconURL: helpers.GetText("plugins.lastfm.embed-footer-imageurl"),
+		},
+		Color: helpers.GetDiscordColorFromHex(lastfmHexColor),
+	}
+
+	if guildConfig.NowPlayingMessageID != "" {
+		_, err := helpers.EditEmbed(guildConfig.NowPlayingChannelID, guildConfig.NowPlayingMessageID, nowPlayingEmbed)
+		if err == nil {
+			lastfmNowPlayingLastUpdates[guildConfig.GuildID] = lastUpdate
+			return
+		}
+	}
+
+	messages, err := helpers.SendEmbed(guildConfig.NowPlayingChannelID, nowPlayingEmbed)
+	if err != nil || len(messages) <= 0 {
+		cache.GetLogger().WithField("module", "lastfm").Warnf("posting now playing message in #%s failed: %v",
+			guildConfig.NowPlayingChannelID, err)
+		return
+	}
+
+	guildConfig.NowPlayingMessageID = messages[0].ID
+	err = helpers.MDbUpdateWithoutLogging(models.LastFmGuildConfigsTable, guildConfig.ID, guildConfig)
+	helpers.RelaxLog(err)
+
+	lastfmNowPlayingLastUpdates[guildConfig.GuildID] = guildConfig.NowPlayingChannelID + "\n" + guildConfig.NowPlayingMessageID + "\n" + description
+}
+
+func (m *LastFm) weeklyChartsLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "lastfm").Error("The weeklyChartsLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.weeklyChartsLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(1 * time.Hour)
+
+		var guildConfigs []models.LastFmGuildConfigEntry
+		err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.LastFmGuildConfigsTable).Find(bson.M{
+			"chartschannelid":    bson.M{"$ne": ""},
+			"chartslastpostedat": bson.M{"$lte": time.Now().Add(-lastfmChartsInterval)},
+		})).All(&guildConfigs)
+		helpers.Relax(err)
+
+		for _, guildConfig := range guildConfigs {
+			m.postWeeklyCharts(guildConfig)
+
+			guildConfig.ChartsLastPostedAt = time.Now()
+			err = helpers.MDbUpdateWithoutLogging(models.LastFmGuildConfigsTable, guildConfig.ID, guildConfig)
+			helpers.RelaxLog(err)
+		}
+	}
+}
+
+// postWeeklyCharts posts the top tracks, artists, and albums of the last seven days of the members of the server
+func (m *LastFm) postWeeklyCharts(guildConfig models.LastFmGuildConfigEntry) {
+	var accounts []models.LastFmEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.LastFmTable).Find(nil)).All(&accounts)
+	helpers.Relax(err)
+
+	topTracks := make(map[string]*LastFMSongInfo)
+	topArtists := make(map[string]*LastFMSongInfo)
+	topAlbums := make(map[string]*LastFMSongInfo)
+	var numberOfUsers int
+	for _, account := range accounts {
+		if !helpers.GetIsInGuild(guildConfig.GuildID, account.UserID) {
+			continue
+		}
+		numberOfUsers++
+
+		<-lastfmBackgroundRequests
+		lastfmTopTracks, err := helpers.GetLastFmClient().User.GetTopTracks(lastfm.P{
+			"limit":  50,
+			"period": "7day",
+			"user":   account.LastFmUsername,
+		})
+		metrics.LastFmRequests.Add(1)
+		if err == nil {
+			for _, track := range lastfmTopTracks.Tracks {
+				songInfo := LastFMSongInfo{Name: track.Name, Url: track.Url, ArtistName: track.Artist.Name}
+				for _, image := range track.Images {
+					if image.Size == "extralarge" {
+						songInfo.ImageURL = image.Url
+					}
+				}
+				songInfo.Plays, _ = strconv.Atoi(track.PlayCount)
+				addToLastFmChart(topTracks, songInfo)
+			}
+		}
+
+		<-lastfmBackgroundRequests
+		lastfmTopArtists, err := helpers.GetLastFmClient().User.GetTopArtists(lastfm.P{
+			"limit":  50,
+			"period": "7day",
+			"user":   account.LastFmUsername,
+		})
+		metrics.LastFmRequests.Add(1)
+		if err == nil {
+			for _, artist := range lastfmTopArtists.Artists {
+				songInfo := LastFMSongInfo{Name: artist.Name, Url: artist.Url}
+				for _, image := range artist.Images {
+					if image.Size == "extralarge" {
+						songInfo.ImageURL = image.Url
+					}
+				}
+				songInfo.Plays, _ = strconv.Atoi(artist.PlayCount)
+				addToLastFmChart(topArtists, songInfo)
+			}
+		}
+
+		<-lastfmBackgroundRequests
+		lastfmTopAlbums, err := helpers.GetLastFmClient().User.GetTopAlbums(lastfm.P{
+			"limit":  50,
+			"period": "7day",
+			"user":   account.LastFmUsername,
+		})
+		metrics.LastFmRequests.Add(1)
+		if err == nil {
+			for _, album := range lastfmTopAlbums.Albums {
+				songInfo := LastFMSongInfo{Name: album.Name, Url: album.Url, ArtistName: album.Artist.Name}
+				for _, image := range album.Images {
+					if image.Size == "extralarge" {
+						songInfo.ImageURL = image.Url
+					}
+				}
+				songInfo.Plays, _ = strconv.Atoi(album.PlayCount)
+				addToLastFmChart(topAlbums, songInfo)
+			}
+		}
+	}
+
+	if numberOfUsers <= 0 {
+		return
+	}
+
+	for _, chart := range []struct {
+		title   string
+		entries map[string]*LastFMSongInfo
+	}{
+		{"Top Tracks", topTracks},
+		{"Top Artists", topArtists},
+		{"Top Albums", topAlbums},
+	} {
+		if len(chart.entries) <= 0 {
+			continue
+		}
+		m.postWeeklyChart(guildConfig.ChartsChannelID, chart.title, sortLastFmChart(chart.entries), numberOfUsers)
+	}
+}
+
+// postWeeklyChart posts the top ten entries of a chart with a collage of the top nine
+func (m *LastFm) postWeeklyChart(channelID, title string, entries []LastFMSongInfo, numberOfUsers int) {
+	if len(entries) > 10 {
+		entries = entries[:10]
+	}
+
+	var description string
+	imageUrls := make([]string, 0)
+	tileDescriptions := make([]string, 0)
+	for i, entry := range entries {
+		name := fmt.Sprintf("**%s**", entry.Name)
+		tileDescription := entry.Name
+		if entry.ArtistName != "" {
+			name += fmt.Sprintf(" by **%s**", entry.ArtistName)
+			tileDescription += "\n" + entry.ArtistName
+		}
+		description += fmt.Sprintf("**#%d** [%s](%s) (%s plays by %s users)\n",
+			i+1, name, helpers.EscapeLinkForMarkdown(entry.Url),
+			humanize.Comma(int64(entry.Plays)), humanize.Comma(int64(entry.Users)))
+
+		if i < 9 {
+			imageUrls = append(imageUrls, entry.ImageURL)
+			tileDescriptions = append(tileDescriptions, tileDescription)
+		}
+	}
+
+	collageBytes := helpers.CollageFromUrls(
+		imageUrls,
+		tileDescriptions,
+		900, 900,
+		300, 300,
+		helpers.DISCORD_DARK_THEME_BACKGROUND_HEX,
+	)
+
+	_, err := helpers.SendComplex(channelID, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Title:       helpers.GetTextF("plugins.lastfm.charts-embed-title", title),
+			Description: description,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf(
+					"%s | %d last.fm users on this server",
+					helpers.GetText("plugins.lastfm.embed-footer"),
+					numberOfUsers),
+				IconURL: helpers.GetText("plugins.lastfm.embed-footer-imageurl"),
+			},
+			Color: helpers.GetDiscordColorFromHex(lastfmHexColor),
+			Image: &discordgo.MessageEmbedImage{
+				URL: "attachment://Robyul-LastFM-Collage.png",
+			},
+		},
+		Files: []*discordgo.File{{
+			Name:   "Robyul-LastFM-Collage.png",
+			Reader: bytes.NewReader(collageBytes),
+		}},
+	})
+	if err != nil {
+		cache.GetLogger().WithField("module", "lastfm").Warnf("posting weekly charts in #%s failed: %s", channelID, err.Error())
+	}
+}
+
+// addToLastFmChart adds the plays of an user to the chart, entries are compared case insensitive
+func addToLastFmChart(chart map[string]*LastFMSongInfo, songInfo LastFMSongInfo) {
+	key := strings.ToLower(songInfo.ArtistName) + "\n" + strings.ToLower(songInfo.Name)
+	if entry, ok := chart[key]; ok {
+		entry.Plays += songInfo.Plays
+		entry.Users++
+		if entry.ImageURL == "" {
+			entry.ImageURL = songInfo.ImageURL
+		}
+		return
+	}
+
+	songInfo.Users = 1
+	chart[key] = &songInfo
+}
+
+func sortLastFmChart(chart map[string]*LastFMSongInfo) (entries []LastFMSongInfo) {
+	for _, entry := range chart {
+		entries = append(entries, *entry)
+	}
+	slice.Sort(entries[:], func(i, j int) bool {
+		if entries[i].Plays == entries[j].Plays {
+			return entries[i].Users > entries[j].Users
+		}
+		return entries[i].Plays > entries[j].Plays
+	})
+	return entries
+}
+
+// compareTaste compares the top artists of the author with the top artists of another user
+func (m *LastFm) compareTaste(msg *discordgo.Message, args []string, lastfmUsername string) {
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	if len(args) < 2 || lastfmUsername == "" {
+		helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.lastfm.too-few", helpers.GetPrefixForServer(channel.GuildID)))
+		return
+	}
+
+	targetUsername := args[1]
+	targetUser, err := helpers.GetUserFromMention(targetUsername)
+	if err == nil {
+		targetUsername = helpers.GetLastFmUsername(targetUser.ID)
+		if targetUsername == "" {
+			helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.lastfm.taste-not-registered"))
+			return
+		}
+	}
+
+	timeLookup := "overall"
+	timeString := "all time"
+	if len(args) >= 3 {
+		switch args[2] {
+		case "7days", "week", "7day":
+			timeString = "the last seven days"
+			timeLookup = "7day"
+		case "1month", "month", "1months":
+			timeString = "the last month"
+			timeLookup = "1month"
+		case "3month", "3months":
+			timeString = "the last three months"
+			timeLookup = "3month"
+		case "6month", "6months":
+			timeString = "the last six months"
+			timeLookup = "6month"
+		case "12month", "year", "12months":
+			timeString = "the last twelve months"
+			timeLookup = "12month"
+		}
+	}
+
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	// artist => plays, artist names are compared case insensitive
+	artistPlays := make([]map[string]int, 2)
+	artistNames := make(map[string]string)
+	for i, username := range []string{lastfmUsername, targetUsername} {
+		lastfmTopArtists, err := helpers.GetLastFmClient().User.GetTopArtists(lastfm.P{
+			"limit":  200,
+			"period": timeLookup,
+			"user":   username,
+		})
+		metrics.LastFmRequests.Add(1)
+		if err != nil {
+			if e, ok := err.(*lastfm.LastfmError); ok {
+				helpers.SendMessage(msg.ChannelID, fmt.Sprintf("Error: `%s`", e.Message))
+				return
+			}
+			helpers.Relax(err)
+		}
+
+		artistPlays[i] = make(map[string]int)
+		for _, artist := range lastfmTopArtists.Artists {
+			artistPlays[i][strings.ToLower(artist.Name)], _ = strconv.Atoi(artist.PlayCount)
+			artistNames[strings.ToLower(artist.Name)] = artist.Name
+		}
+	}
+
+	sharedArtists, compatibility := helpers.LastFmTasteCompatibility(artistPlays[0], artistPlays[1])
+
+	compatibilityText := "very low"
+	switch {
+	case compatibility >= 50:
+		compatibilityText = "super"
+	case compatibility >= 30:
+		compatibilityText = "very high"
+	case compatibility >= 15:
+		compatibilityText = "high"
+	case compatibility >= 5:
+		compatibilityText = "medium"
+	case compatibility > 0:
+		compatibilityText = "low"
+	}
+
+	tasteEmbed := &discordgo.MessageEmbed{
+		Description: fmt.Sprintf("of **%s**\nYour musical compatibility is **%s** (%d%%) with %d shared artists.",
+			timeString, compatibilityText, compatibility, len(sharedArtists)),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    helpers.GetText("plugins.lastfm.embed-footer"),
+			IconURL: helpers.GetText("plugins.lastfm.embed-footer-imageurl"),
+		},
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: helpers.GetTextF("plugins.lastfm.taste-embed-title", lastfmUsername, targetUsername),
+			URL:  fmt.Sprintf(lastfmFriendlyUser, targetUsername),
+		},
+		Fields: []*discordgo.MessageEmbedField{},
+		Color:  helpers.GetDiscordColorFromHex(lastfmHexColor),
+	}
+	for i, artist := range sharedArtists {
+		tasteEmbed.Fields = append(tasteEmbed.Fields, &discordgo.MessageEmbedField{
+			Name: artistNames[artist],
+			Value: fmt.Sprintf("%s: %s plays, %s: %s plays",
+				lastfmUsername, humanize.Comma(int64(artistPlays[0][artist])),
+				targetUsername, humanize.Comma(int64(artistPlays[1][artist]))),
+			Inline: false,
+		})
+		if i == 9 {
+			break
+		}
+	}
+
+	_, err = helpers.SendEmbed(msg.ChannelID, tasteEmbed)
+	helpers.RelaxEmbed(err, msg.ChannelID, msg.ID)
+}
@@ -0,0 +1,446 @@
+package levels
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/metrics"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Seklfreak/lastfm-go/lastfm"
+	"github.com/bradfitz/slice"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// badges with criteria are awarded automatically once a user meets all of them
+// users are checked at most once per badgeCheckInterval per server after they earned EXP, or when they look at their badge showcase
+
+const (
+	badgeCheckInterval = 1 * time.Hour
+	badgeCheckLoopWait = 5 * time.Minute
+)
+
+type badgeCheck struct {
+	GuildID string
+	UserID  string
+}
+
+var (
+	pendingBadgeChecks     = make(map[string]badgeCheck)
+	lastBadgeChecks        = make(map[string]time.Time)
+	pendingBadgeChecksLock sync.Mutex
+)
+
+func (m *Levels) actionBadgeCriteria(args []string, msg *discordgo.Message, channel *discordgo.Channel) {
+	helpers.RequireAdmin(msg, func() {
+		if len(args) < 4 {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		badge := getBadge(args[2], args[3], channel.GuildID)
+		if badge.ID == "" {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.badge-error-not-found"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		// [p]profile badge criteria <category name> <badge name>
+		if len(args) < 5 {
+			criteriaText := helpers.FormatProfileBadgeCriteria(badge.Criteria)
+			if criteriaText == "" {
+				criteriaText = "none"
+			}
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-criteria-list",
+				badge.Category, badge.Name, criteriaText, strings.Join(helpers.ProfileBadgeMetrics, ", ")))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		if badge.GuildID == "global" && !helpers.IsBotAdmin(msg.Author.ID) {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.edit-badge-error-not-allowed"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+
+		metric := strings.ToLower(args[4])
+		switch {
+		case metric == "clear": // [p]profile badge criteria <category name> <badge name> clear
+			badge.Criteria = make([]models.ProfileBadgeCriterion, 0)
+		case !helpers.ProfileBadgeMetricIsValid(metric):
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-criteria-invalid-metric",
+				strings.Join(helpers.ProfileBadgeMetrics, ", ")))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		default: // [p]profile badge criteria <category name> <badge name> <metric> <threshold, 0 to remove>
+			var threshold int64 = 1
+			if metric != models.ProfileBadgeMetricDonator {
+				if len(args) < 6 {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+				var err error
+				threshold, err = strconv.ParseInt(strings.Replace(args[5], ",", "", -1), 10, 64)
+				if err != nil || threshold < 0 {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+			} else if len(args) >= 6 && (args[5] == "0" || strings.ToLower(args[5]) == "off") {
+				threshold = 0
+			}
+
+			badge.Criteria = helpers.SetProfileBadgeCriterion(badge.Criteria, metric, threshold)
+			if len(badge.Criteria) > helpers.ProfileBadgeMaxCriteria {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-criteria-too-many", helpers.ProfileBadgeMaxCriteria))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+		}
+
+		err := helpers.MDbUpdate(models.ProfileBadgesTable, badge.ID, badge)
+		helpers.Relax(err)
+
+		_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(badge.ID),
+			models.EventlogTargetTypeRobyulBadge, msg.Author.ID,
+			models.EventlogTypeRobyulBadgeCriteria, "", nil,
+			[]models.ElasticEventlogOption{
+				{
+					Key:   "badge_category",
+					Value: badge.Category,
+				},
+				{
+					Key:   "badge_name",
+					Value: badge.Name,
+				},
+				{
+					Key:   "badge_criteria",
+					Value: helpers.FormatProfileBadgeCriteria(badge.Criteria),
+				},
+			}, false)
+		helpers.RelaxLog(err)
+
+		if len(badge.Criteria) <= 0 {
+			_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-criteria-cleared", badge.Category, badge.Name))
+		} else {
+			_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.badge-criteria-set",
+				badge.Category, badge.Name, helpers.FormatProfileBadgeCriteria(badge.Criteria)))
+		}
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+	})
+}
+
+// [p]profile badge showcase
+func (m *Levels) actionBadgeShowcase(msg *discordgo.Message, channel *discordgo.Channel) {
+	cache.GetSession().ChannelTyping(msg.ChannelID)
+
+	// award badges the user already qualifies for before showing the progress
+	checkBadgeAwards(channel.GuildID, msg.Author.ID)
+
+	awardedBadgeIDs := getBadgeAwardedIDs(msg.Author.ID)
+
+	var unearnedBadges []models.ProfileBadgeEntry
+	for _, badge := range getServerBadges(channel.GuildID) {
+		if len(badge.Criteria) <= 0 || awardedBadgeIDs[badge.GetID()] {
+			continue
+		}
+		unearnedBadges = append(unearnedBadges, badge)
+	}
+
+	if len(unearnedBadges) <= 0 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.badge-showcase-none"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	type badgeProgress struct {
+		Badge    models.ProfileBadgeEntry
+		Progress []helpers.ProfileBadgeCriterionProgress
+		Percent  int
+	}
+
+	metricValues := make(map[string]map[string]int64)
+	var badgesProgress []badgeProgress
+	for _, badge := range unearnedBadges {
+		if _, ok := metricValues[badge.GuildID]; !ok {
+			metricValues[badge.GuildID] = make(map[string]int64)
+		}
+		getBadgeMetricValues(metricValues[badge.GuildID], badge.Criteria, badge.GuildID, channel.GuildID, msg.Author.ID)
+
+		progress, _ := helpers.GetProfileBadgeProgress(badge.Criteria, metricValues[badge.GuildID])
+		badgesProgress = append(badgesProgress, badgeProgress{
+			Badge:    badge,
+			Progress: progress,
+			Percent:  helpers.ProfileBadgeProgressPercent(progress),
+		})
+	}
+
+	slice.Sort(badgesProgress, func(i, j int) bool {
+		return badgesProgress[i].Percent > badgesProgress[j].Percent
+	})
+
+	resultText := helpers.GetTextF("plugins.levels.badge-showcase-title", msg.Author.Username) + "\n"
+	for _, entry := range badgesProgress {
+		var progressTexts []string
+		for _, criterionProgress := range entry.Progress {
+			progressTexts = append(progressTexts, helpers.FormatProfileBadgeProgress(criterionProgress))
+		}
+
+		globalText := ""
+		if entry.Badge.GuildID == "global" {
+			globalText = "GLOBAL "
+		}
+		resultText += fmt.Sprintf("**%s%s %s** (%d%%): %s\n",
+			globalText, entry.Badge.Category, entry.Badge.Name, entry.Percent, strings.Join(progressTexts, ", "))
+	}
+
+	for _, page := range helpers.Pagify(resultText, "\n") {
+		_, err := helpers.SendMessage(msg.ChannelID, page)
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+	}
+}
+
+// queueBadgeCheck schedules a check of the criteria badges for the user, unless the user has been checked recently
+func queueBadgeCheck(guildID, userID string) {
+	key := guildID + userID
+
+	pendingBadgeChecksLock.Lock()
+	defer pendingBadgeChecksLock.Unlock()
+
+	if lastCheck, ok := lastBadgeChecks[key]; ok && time.Since(lastCheck) < badgeCheckInterval {
+		return
+	}
+	lastBadgeChecks[key] = time.Now()
+	pendingBadgeChecks[key] = badgeCheck{GuildID: guildID, UserID: userID}
+}
+
+func processBadgeChecksLoop() {
+	log := cache.GetLogger()
+
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			log.WithField("module", "levels").Info("The processBadgeChecksLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			processBadgeChecksLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(badgeCheckLoopWait)
+
+		pendingBadgeChecksLock.Lock()
+		checks := pendingBadgeChecks
+		pendingBadgeChecks = make(map[string]badgeCheck)
+		for key, lastCheck := range lastBadgeChecks {
+			if time.Since(lastCheck) >= badgeCheckInterval {
+				delete(lastBadgeChecks, key)
+			}
+		}
+		pendingBadgeChecksLock.Unlock()
+
+		if len(checks) <= 0 {
+			continue
+		}
+
+		var badgesWithCriteria []models.ProfileBadgeEntry
+		err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ProfileBadgesTable).Find(
+			bson.M{"criteria.0": bson.M{"$exists": true}},
+		)).All(&badgesWithCriteria)
+		helpers.Relax(err)
+
+		if len(badgesWithCriteria) <= 0 {
+			continue
+		}
+
+		guildsWithCriteria := make(map[string]bool)
+		for _, badge := range badgesWithCriteria {
+			guildsWithCriteria[badge.GuildID] = true
+		}
+
+		for _, check := range checks {
+			if !guildsWithCriteria["global"] && !guildsWithCriteria[check.GuildID] {
+				continue
+			}
+			checkBadgeAwards(check.GuildID, check.UserID)
+		}
+
+		log.WithField("module", "levels").Infof("checked %d users for criteria badges", len(checks))
+	}
+}
+
+// checkBadgeAwards awards all global and server badges with criteria the user meets, and notifies the user about them
+func checkBadgeAwards(guildID, userID string) {
+	awardedBadgeIDs := getBadgeAwardedIDs(userID)
+
+	metricValues := make(map[string]map[string]int64)
+	for _, badge := range getServerBadges(guildID) {
+		if len(badge.Criteria) <= 0 || awardedBadgeIDs[badge.GetID()] {
+			continue
+		}
+
+		if _, ok := metricValues[badge.GuildID]; !ok {
+			metricValues[badge.GuildID] = make(map[string]int64)
+		}
+		getBadgeMetricValues(metricValues[badge.GuildID], badge.Criteria, badge.GuildID, guildID, userID)
+
+		if _, met := helpers.GetProfileBadgeProgress(badge.Criteria, metricValues[badge.GuildID]); !met {
+			continue
+		}
+
+		_, err := helpers.MDbInsertWithoutLogging(models.ProfileBadgeAwardsTable, models.ProfileBadgeAwardEntry{
+			BadgeID:   badge.GetID(),
+			UserID:    userID,
+			GuildID:   guildID,
+			AwardedAt: time.Now(),
+		})
+		if err != nil {
+			// awarded by a check running at the same time, badgeid and userid are unique
+			if !mgo.IsDup(err) {
+				helpers.RelaxLog(err)
+			}
+			continue
+		}
+		awardedBadgeIDs[badge.GetID()] = true
+
+		go notifyBadgeAwarded(badge, guildID, userID)
+	}
+}
+
+// getBadgeAwardedIDs returns the IDs of all badges that have been awarded to the user
+func getBadgeAwardedIDs(userID string) (badgeIDs map[string]bool) {
+	badgeIDs = make(map[string]bool)
+
+	var awards []models.ProfileBadgeAwardEntry
+	err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ProfileBadgeAwardsTable).Find(
+		bson.M{"userid": userID},
+	)).All(&awards)
+	helpers.Relax(err)
+
+	for _, award := range awards {
+		badgeIDs[award.BadgeID] = true
+	}
+	return badgeIDs
+}
+
+// getBadgeMetricValues adds the values of the user for all metrics of criteria which are not in values yet
+// server metrics are counted on the badge server, or across all servers for global badges. the tenure of global badges is measured on the server the check runs for
+func getBadgeMetricValues(values map[string]int64, criteria []models.ProfileBadgeCriterion, badgeGuildID, guildID, userID string) {
+	for _, criterion := range criteria {
+		if _, ok := values[criterion.Metric]; ok {
+			continue
+		}
+
+		var value int64
+		switch criterion.Metric {
+		case models.ProfileBadgeMetricMessages:
+			if badgeGuildID != "global" {
+				var serverUser models.LevelsServerusersEntry
+				err := helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.LevelsServerusersTable).Find(
+					bson.M{"userid": userID, "guildid": badgeGuildID},
+				), &serverUser)
+				if err != nil && !helpers.IsMdbNotFound(err) {
+					helpers.RelaxLog(err)
+				}
+				value = serverUser.Messages
+				break
+			}
+			var result struct {
+				Messages int64
+			}
+			err := helpers.MdbPipeOneWithoutLogging(models.LevelsServerusersTable, []bson.M{
+				{"$match": bson.M{"userid": userID}},
+				{"$group": bson.M{"_id": nil, "messages": bson.M{"$sum": "$messages"}}},
+			}, &result)
+			if err != nil && !helpers.IsMdbNotFound(err) {
+				helpers.RelaxLog(err)
+			}
+			value = result.Messages
+		case models.ProfileBadgeMetricRep:
+			userData, err := helpers.GetUserUserdata(userID)
+			helpers.RelaxLog(err)
+			value = int64(userData.Rep)
+		case models.ProfileBadgeMetricTenureDays:
+			member, err := helpers.GetGuildMemberWithoutApi(guildID, userID)
+			if err != nil || member.JoinedAt == "" {
+				break
+			}
+			joinedAt, err := discordgo.Timestamp(member.JoinedAt).Parse()
+			if err != nil {
+				break
+			}
+			value = int64(time.Since(joinedAt).Hours() / 24)
+		case models.ProfileBadgeMetricStarboard:
+			query := bson.M{"authorid": userID}
+			if badgeGuildID != "global" {
+				query["guildid"] = badgeGuildID
+			}
+			count, err := helpers.MdbCountWithoutLogging(models.StarboardEntriesTable, query)
+			helpers.RelaxLog(err)
+			value = int64(count)
+		case models.ProfileBadgeMetricBiasgameGames:
+			query := bson.M{"userid": userID}
+			if badgeGuildID != "global" {
+				query["guildid"] = badgeGuildID
+			}
+			count, err := helpers.MdbCountWithoutLogging(models.BiasGameTable, query)
+			helpers.RelaxLog(err)
+			value = int64(count)
+		case models.ProfileBadgeMetricLastFmScrobbles:
+			lastfmUsername := helpers.GetLastFmUsername(userID)
+			if lastfmUsername == "" {
+				break
+			}
+			lastfmUser, err := helpers.GetLastFmClient().User.GetInfo(lastfm.P{"user": lastfmUsername})
+			metrics.LastFmRequests.Add(1)
+			if err != nil {
+				if !strings.Contains(err.Error(), "User not found") {
+					helpers.RelaxLog(err)
+				}
+				break
+			}
+			value, err = strconv.ParseInt(lastfmUser.PlayCount, 10, 64)
+			if err != nil {
+				value = 0
+			}
+		case models.ProfileBadgeMetricDonator:
+			count, err := helpers.MdbCountWithoutLogging(models.DonatorsTable, bson.M{"userid": userID})
+			helpers.RelaxLog(err)
+			if count > 0 {
+				value = 1
+			}
+		}
+		values[criterion.Metric] = value
+	}
+}
+
+func notifyBadgeAwarded(badge models.ProfileBadgeEntry, guildID, userID string) {
+	defer helpers.Recover()
+
+	var message string
+	if badge.GuildID == "global" {
+		message = helpers.GetTextF("plugins.levels.badge-awarded-global-dm", badge.Category, badge.Name, helpers.FormatProfileBadgeCriteria(badge.Criteria))
+	} else {
+		guild, err := helpers.GetGuild(badge.GuildID)
+		if err != nil {
+			return
+		}
+		message = helpers.GetTextF("plugins.levels.badge-awarded-dm", badge.Category, badge.Name, guild.Name, helpers.FormatProfileBadgeCriteria(badge.Criteria))
+	}
+	message += "\n" + helpers.GetTextF("plugins.levels.badge-awarded-dm-footer", helpers.GetPrefixForServer(guildID))
+
+	dmChannel, err := cache.GetSession().UserChannelCreate(userID)
+	if err != nil {
+		return
+	}
+	// users can have their DMs disabled
+	helpers.SendMessage(dmChannel.ID, message)
+}
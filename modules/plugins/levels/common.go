@@ -216,12 +216,15 @@ func processExpStackLoop() {
 			levelBefore := GetLevelFromExp(levelsServerUser.Exp)
 
 			levelsServerUser.Exp += getRandomExpForMessage()
+			levelsServerUser.Messages++
 
 			levelAfter := GetLevelFromExp(levelsServerUser.Exp)
 
 			err = helpers.MDbUpdateWithoutLogging(models.LevelsServerusersTable, levelsServerUser.ID, levelsServerUser)
 			helpers.Relax(err)
 
+			queueBadgeCheck(expItem.GuildID, expItem.UserID)
+
 			if expBefore <= 0 || levelBefore != levelAfter {
 				// apply roles
 				err := applyLevelsRoles(expItem.GuildID, expItem.UserID, levelAfter)
@@ -230,6 +233,18 @@ func processExpStackLoop() {
 					errD.Message.Code != discordgo.ErrCodeMissingAccess) {
 					helpers.RelaxLog(err)
 				}
+				if levelAfter > levelBefore {
+					go func(expItem ProcessExpInfo, levelBefore, levelAfter int) {
+						defer helpers.Recover()
+
+						helpers.OutgoingWebhookEmit(expItem.GuildID, models.OutgoingWebhookEventLevelUp, models.OutgoingWebhookLevelUpData{
+							UserID:      expItem.UserID,
+							ChannelID:   expItem.ChannelID,
+							LevelBefore: levelBefore,
+							LevelAfter:  levelAfter,
+						})
+					}(expItem, levelBefore, levelAfter)
+				}
 				guildSettings := helpers.GuildSettingsGetCached(expItem.GuildID)
 				// send level notifications
 				if levelAfter > levelBefore && guildSettings.LevelsNotificationCode != "" {
@@ -334,7 +349,7 @@ func (b *Levels) BucketInit() {
 }
 
 func applyLevelsRoles(guildID string, userID string, level int) (err error) {
-	apply, remove := getLevelsRoles(guildID, level)
+	apply, remove := getLevelsRoles(guildID, userID, level)
 	member, err := helpers.GetGuildMemberWithoutApi(guildID, userID)
 	if err != nil {
 		cache.GetLogger().WithField("module", "levels").Warnf("failed to get guild member to apply level roles: %s", err.Error())
@@ -6,13 +6,9 @@ import (
 	"fmt"
 	"html"
 	"image"
-	"image/color"
-	"image/draw"
-	"image/gif"
 	_ "image/jpeg"
 	"image/png"
 	"io/ioutil"
-	"math"
 	"net/url"
 	"os"
 	"sort"
@@ -27,15 +23,12 @@ import (
 	"github.com/Seklfreak/Robyul2/models"
 	"github.com/Seklfreak/Robyul2/ratelimits"
 	"github.com/Seklfreak/lastfm-go/lastfm"
-	"github.com/andybons/gogif"
 	"github.com/bradfitz/slice"
 	"github.com/bwmarrin/discordgo"
 	"github.com/dustin/go-humanize"
-	"github.com/getsentry/raven-go"
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"github.com/lucasb-eyer/go-colorful"
-	"github.com/nfnt/resize"
 	"gopkg.in/oleiade/lane.v1"
 )
 
@@ -103,14 +96,19 @@ var (
 	htmlTemplateString       string
 	levelsEnv                = os.Environ()
 	topCache                 []Cache_Levels_top
+	profileFonts             *helpers.ProfileCardFonts
+	profileEmojiCache        = make(map[string]image.Image)
+	profileEmojiFiles        = make(map[string]bool) // twemoji file names without the extension, loaded in Init
+	profileEmojiCacheLock    sync.Mutex
 	activeBadgePickerUserIDs map[string]string
 	repCommandLocks          = make(map[string]*sync.Mutex)
 )
 
 const (
-	BadgeLimt          = 18
-	TimeAtUserFormat   = "Mon, 15:04"
-	TimeBirthdayFormat = "01/02"
+	BadgeLimt                  = 18
+	TimeAtUserFormat           = "Mon, 15:04"
+	TimeBirthdayFormat         = "01/02"
+	TimeBirthdayWithYearFormat = "01/02/2006"
 )
 
 func (m *Levels) Init(session *discordgo.Session) {
@@ -123,6 +121,21 @@ func (m *Levels) Init(session *discordgo.Session) {
 	htmlTemplate, err := ioutil.ReadFile(assetsPath + "profile.html")
 	helpers.Relax(err)
 	htmlTemplateString = string(htmlTemplate)
+	emojiFiles, err := ioutil.ReadDir(assetsPath + "twemoji72/")
+	helpers.Relax(err)
+	for _, emojiFile := range emojiFiles {
+		if strings.HasSuffix(emojiFile.Name(), ".png") {
+			profileEmojiFiles[strings.TrimSuffix(emojiFile.Name(), ".png")] = true
+		}
+	}
+	var fontsData [][]byte
+	for _, fontFilename := range []string{"Roboto/Roboto-Regular.ttf", "Roboto/Roboto-Bold.ttf", "UnDotum.ttf", "UnDotumBold.ttf"} {
+		fontData, err := ioutil.ReadFile(assetsPath + fontFilename)
+		helpers.Relax(err)
+		fontsData = append(fontsData, fontData)
+	}
+	profileFonts, err = helpers.ParseProfileCardFonts(fontsData[0], fontsData[1], fontsData[2], fontsData[3])
+	helpers.Relax(err)
 
 	go processExpStackLoop()
 	log.WithField("module", "levels").Info("Started processExpStackLoop")
@@ -133,6 +146,9 @@ func (m *Levels) Init(session *discordgo.Session) {
 	activeBadgePickerUserIDs = make(map[string]string, 0)
 
 	go setServerFeaturesLoop()
+
+	go processBadgeChecksLoop()
+	log.WithField("module", "levels").Info("Started processBadgeChecksLoop")
 }
 
 func (l *Levels) Uninit(session *discordgo.Session) {
@@ -145,86 +161,8 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 	}
 
 	switch command {
-	case "rep": // [p]rep <user id/mention>
-		session.ChannelTyping(msg.ChannelID)
-		args := strings.Fields(content)
-
-		m.lockRepUser(msg.Author.ID)
-		defer m.unlockRepUser(msg.Author.ID)
-
-		userData, err := helpers.GetUserUserdata(msg.Author.ID)
-		helpers.Relax(err)
-
-		if len(args) <= 0 {
-			if time.Since(userData.LastRepped).Hours() < 12 {
-				timeUntil := time.Until(userData.LastRepped.Add(time.Hour * 12))
-				if timeUntil.Minutes() < 1 {
-					helpers.SendMessage(msg.ChannelID,
-						helpers.GetTextF("plugins.levels.rep-next-rep-seconds", int(math.Floor(timeUntil.Seconds()))))
-				} else {
-					helpers.SendMessage(msg.ChannelID,
-						helpers.GetTextF("plugins.levels.rep-next-rep",
-							int(math.Floor(timeUntil.Hours())),
-							int(math.Floor(timeUntil.Minutes()))-(int(math.Floor(timeUntil.Hours()))*60)))
-				}
-			} else {
-				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-target"))
-				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			}
-			return
-		}
-
-		if time.Since(userData.LastRepped).Hours() < 12 {
-			timeUntil := time.Until(userData.LastRepped.Add(time.Hour * 12))
-			if timeUntil.Minutes() < 1 {
-				helpers.SendMessage(msg.ChannelID,
-					helpers.GetTextF("plugins.levels.rep-error-timelimit-seconds", int(math.Floor(timeUntil.Seconds()))))
-			} else {
-				helpers.SendMessage(msg.ChannelID,
-					helpers.GetTextF("plugins.levels.rep-error-timelimit",
-						int(math.Floor(timeUntil.Hours())),
-						int(math.Floor(timeUntil.Minutes()))-(int(math.Floor(timeUntil.Hours()))*60)))
-			}
-			return
-		}
-
-		targetUser, err := helpers.GetUserFromMention(args[0])
-		if err != nil || targetUser == nil || targetUser.ID == "" {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
-		}
-
-		// Don't rep this bot account, other bots, or oneself
-		if targetUser.ID == session.State.User.ID {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-session"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
-		}
-		if targetUser.ID == msg.Author.ID {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-self"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
-		}
-		if targetUser.Bot == true {
-			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-bot"))
-			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-			return
-		}
-
-		targetUserData, err := helpers.GetUserUserdata(targetUser.ID)
-		helpers.Relax(err)
-		targetUserData.Rep += 1
-		err = helpers.MDbUpdate(models.ProfileUserdataTable, targetUserData.ID, targetUserData)
-		helpers.Relax(err)
-
-		userData.LastRepped = time.Now()
-		err = helpers.MDbUpdate(models.ProfileUserdataTable, userData.ID, userData)
-		helpers.Relax(err)
-
-		_, err = helpers.SendMessage(msg.ChannelID,
-			helpers.GetTextF("plugins.levels.rep-success", targetUser.Username))
-		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+	case "rep": // [p]rep <user id/mention> [<reason>]
+		m.actionRep(content, msg, session)
 		return
 	case "profile", "gif-profile": // [p]profile
 		channel, err := helpers.GetChannel(msg.ChannelID)
@@ -650,8 +588,13 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 						helpers.MdbCollection(models.ProfileBackgroundsTable).Find(bson.M{"name": strings.ToLower(args[1])}),
 						&entryBucket,
 					)
-					if helpers.IsMdbNotFound(err) {
-						searchResult := m.ProfileBackgroundSearch(args[1])
+					if helpers.IsMdbNotFound(err) || !helpers.ProfileBackgroundIsUsable(entryBucket, channel.GuildID) {
+						searchResult := make([]models.ProfileBackgroundEntry, 0)
+						for _, entry := range m.ProfileBackgroundSearch(args[1]) {
+							if helpers.ProfileBackgroundIsUsable(entry, channel.GuildID) {
+								searchResult = append(searchResult, entry)
+							}
+						}
 
 						if len(searchResult) <= 0 {
 							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.profile-background-set-error-not-found"))
@@ -688,6 +631,9 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 					return
 				}
+			case "theme", "themes":
+				m.actionThemes(args, content, msg, channel)
+				return
 			case "badge", "badges":
 				if len(args) >= 2 {
 					switch args[1] {
@@ -960,7 +906,9 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 								}
 
 								var requirementText string
-								if badge.RoleRequirement != "" {
+								if len(badge.Criteria) > 0 {
+									requirementText = "Criteria: " + helpers.FormatProfileBadgeCriteria(badge.Criteria)
+								} else if badge.RoleRequirement != "" {
 									requirementRole, err := session.State.Role(badge.GuildID, badge.RoleRequirement)
 									if err == nil {
 										requirementText = fmt.Sprintf("Role: %s (`#%s`)", requirementRole.Name, requirementRole.ID)
@@ -1355,6 +1303,12 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 							}
 						})
 						return
+					case "criteria": // [p]profile badge criteria <category name> <badge name> [<metric> <threshold>|clear]
+						m.actionBadgeCriteria(args, msg, channel)
+						return
+					case "showcase", "progress": // [p]profile badge showcase
+						m.actionBadgeShowcase(msg, channel)
+						return
 					case "move": // [p]profile badge move <category name> <badge name> <#>
 						session.ChannelTyping(msg.ChannelID)
 						if len(args) < 5 {
@@ -1713,19 +1667,26 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 				var err error
 
 				newBirthday := ""
+				var newBirthdayYear int
 				if len(args) >= 2 {
-					_, err = time.Parse(TimeBirthdayFormat, args[1])
+					// the year is optional: MM/DD or MM/DD/YYYY
+					birthdayTime, err := time.Parse(TimeBirthdayFormat, args[1])
 					if err != nil {
-						_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.profile-birthday-set-error-format"))
-						helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
-						return
+						birthdayTime, err = time.Parse(TimeBirthdayWithYearFormat, args[1])
+						if err != nil || birthdayTime.Year() < 1900 || birthdayTime.After(time.Now()) {
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.profile-birthday-set-error-format"))
+							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+							return
+						}
+						newBirthdayYear = birthdayTime.Year()
 					}
-					newBirthday = args[1]
+					newBirthday = birthdayTime.Format(TimeBirthdayFormat)
 				}
 
 				userUserdata, err := helpers.GetUserUserdata(msg.Author.ID)
 				helpers.Relax(err)
 				userUserdata.Birthday = newBirthday
+				userUserdata.BirthdayYear = newBirthdayYear
 				err = helpers.MDbUpdate(models.ProfileUserdataTable, userUserdata.ID, userUserdata)
 				helpers.Relax(err)
 
@@ -2353,9 +2314,10 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 				}
 
 				switch args[1] {
-				case "add":
+				case "add", "add-rep":
 					helpers.RequireMod(msg, func() {
 						// [p]levels role add <role name or id> <start level> [<last level>]
+						// [p]levels role add-rep <role name or id> <start rep> [<last rep>]
 						if len(args) < 4 {
 							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
 							helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
@@ -2409,7 +2371,12 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 							return
 						}
 
-						_, err = m.createLevelsRoleEntry(channel.GuildID, targetRole.ID, startLevel, lastLevel)
+						roleType := models.LevelsRoleTypeLevel
+						if args[1] == "add-rep" {
+							roleType = models.LevelsRoleTypeRep
+						}
+
+						_, err = m.createLevelsRoleEntry(channel.GuildID, targetRole.ID, roleType, startLevel, lastLevel)
 						helpers.Relax(err)
 
 						options := []models.ElasticEventlogOption{
@@ -2419,6 +2386,13 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 							},
 						}
 
+						if roleType == models.LevelsRoleTypeRep {
+							options = append(options, models.ElasticEventlogOption{
+								Key:   "role_type",
+								Value: "rep",
+							})
+						}
+
 						if lastLevel >= 0 {
 							options = append(options, models.ElasticEventlogOption{
 								Key:   "role_lastlevel",
@@ -2514,8 +2488,13 @@ func (m *Levels) Action(command string, content string, msg *discordgo.Message,
 								lastLevelText = "∞"
 							}
 
-							message += fmt.Sprintf("`%s`: Role `%s` (`#%s`) from level %d to level %s\n",
-								helpers.MdbIdToHuman(entry.ID), role.Name, role.ID, entry.StartLevel, lastLevelText)
+							requirementName := "level"
+							if entry.Type == models.LevelsRoleTypeRep {
+								requirementName = "rep"
+							}
+
+							message += fmt.Sprintf("`%s`: Role `%s` (`#%s`) from %s %d to %s %s\n",
+								helpers.MdbIdToHuman(entry.ID), role.Name, role.ID, requirementName, entry.StartLevel, requirementName, lastLevelText)
 						}
 						message += fmt.Sprintf("_found %d role(s) in total_", len(entries))
 
@@ -3071,11 +3050,37 @@ func (l *Levels) BadgePickerHelpText() string {
 	return "\nSay `categories` to display all categories, `category name` to choose a category, `badge name` to choose a badge, `reset` to remove all badges displayed on your profile, `exit` to exit and save. To remove a badge from your Profile pick the badge again.\n"
 }
 
-func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild, web bool) (string, error) {
+var errProfileWithoutLevels = errors.New("user has no levels yet")
+
+// profileData is everything shown on a profile, shared by the html profiles for the website and the profile images
+type profileData struct {
+	Userdata           models.ProfileUserdataEntry
+	AvatarUrl          string // always a png
+	AvatarUrlGif       string // empty if the avatar isn't animated
+	UserAndNick        string
+	UserWithDisc       string
+	Title              string
+	Bio                string
+	Badges             []models.ProfileBadgeEntry
+	ServerLevel        int
+	ServerLevelPercent int
+	ServerRank         string
+	GlobalLevel        int
+	GlobalRank         string
+	UserTime           string // empty if the user didn't set a timezone
+	Birthday           string // empty if the user didn't set a birthday
+	NowPlaying         string // track by artist
+	TopArtist          string // artist with play count if short enough
+}
+
+func (m *Levels) getProfileData(member *discordgo.Member, guild *discordgo.Guild) (data profileData, err error) {
 	var levelsServersUser []models.LevelsServerusersEntry
-	err := helpers.MDbIter(helpers.MdbCollection(models.LevelsServerusersTable).Find(bson.M{"userid": member.User.ID})).All(&levelsServersUser)
-	if err != nil || levelsServersUser == nil {
-		return "", err
+	err = helpers.MDbIter(helpers.MdbCollection(models.LevelsServerusersTable).Find(bson.M{"userid": member.User.ID})).All(&levelsServersUser)
+	if err != nil {
+		return data, err
+	}
+	if levelsServersUser == nil {
+		return data, errProfileWithoutLevels
 	}
 
 	var levelThisServerUser models.LevelsServerusersEntry
@@ -3086,105 +3091,82 @@ func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild
 		}
 		totalExp += levelsServerUser.Exp
 	}
+	data.ServerLevel = GetLevelFromExp(levelThisServerUser.Exp)
+	data.ServerLevelPercent = GetProgressToNextLevelFromExp(levelThisServerUser.Exp)
+	data.GlobalLevel = GetLevelFromExp(totalExp)
 
-	serverRank := "N/A"
-	globalRank := "N/A"
+	data.ServerRank = "N/A"
+	data.GlobalRank = "N/A"
 	for _, serverCache := range topCache {
 		if serverCache.GuildID == "global" {
 			for i, pair := range serverCache.Levels {
 				if pair.Key == member.User.ID {
-					globalRank = strconv.Itoa(i + 1)
+					data.GlobalRank = strconv.Itoa(i + 1)
 				}
 			}
 		} else if serverCache.GuildID == guild.ID {
 			for i, pair := range serverCache.Levels {
 				if pair.Key == member.User.ID {
-					serverRank = strconv.Itoa(i + 1)
+					data.ServerRank = strconv.Itoa(i + 1)
 				}
 			}
 		}
 	}
 
-	userData, err := helpers.GetUserUserdata(member.User.ID)
+	data.Userdata, err = helpers.GetUserUserdata(member.User.ID)
 	if err != nil {
-		return "", err
+		return data, err
 	}
+	userData := data.Userdata
 
-	avatarUrl := helpers.GetAvatarUrl(member.User)
-	avatarUrlGif := ""
-	if avatarUrl != "" {
-		avatarUrl = strings.Replace(avatarUrl, "size=1024", "size=128", -1)
-		if strings.Contains(avatarUrl, "gif") {
-			avatarUrlGif = avatarUrl
+	data.AvatarUrl = helpers.GetAvatarUrl(member.User)
+	if data.AvatarUrl != "" {
+		data.AvatarUrl = strings.Replace(data.AvatarUrl, "size=1024", "size=128", -1)
+		if strings.Contains(data.AvatarUrl, "gif") {
+			data.AvatarUrlGif = data.AvatarUrl
 		}
-		avatarUrl = strings.Replace(avatarUrl, "gif", "png", -1)
-		avatarUrl = strings.Replace(avatarUrl, "jpg", "png", -1)
-	}
-	if web == true && avatarUrlGif != "" {
-		avatarUrl = avatarUrlGif
+		data.AvatarUrl = strings.Replace(data.AvatarUrl, "gif", "png", -1)
+		data.AvatarUrl = strings.Replace(data.AvatarUrl, "jpg", "png", -1)
 	}
-	if avatarUrl == "" {
-		avatarUrl = "http://i.imgur.com/osAqNL6.png"
+	if data.AvatarUrl == "" {
+		data.AvatarUrl = "http://i.imgur.com/osAqNL6.png"
 	}
-	userAndNick := member.User.Username
+	data.UserAndNick = member.User.Username
 	if member.Nick != "" {
-		userAndNick = fmt.Sprintf("%s (%s)", member.User.Username, member.Nick)
+		data.UserAndNick = fmt.Sprintf("%s (%s)", member.User.Username, member.Nick)
 	}
-	userWithDisc := member.User.Username + "#" + member.User.Discriminator
-	if helpers.RuneLength(userWithDisc) >= 15 {
-		userWithDisc = member.User.Username
+	data.UserWithDisc = member.User.Username + "#" + member.User.Discriminator
+	if helpers.RuneLength(data.UserWithDisc) >= 15 {
+		data.UserWithDisc = member.User.Username
 	}
-	title := userData.Title
-	if title == "" {
-		title = "Robyul's friend"
+	data.Title = userData.Title
+	if data.Title == "" {
+		data.Title = "Robyul's friend"
 	}
-	bio := userData.Bio
-	if bio == "" {
-		bio = "Robyul would like to know more about me!"
+	data.Bio = userData.Bio
+	if data.Bio == "" {
+		data.Bio = "Robyul would like to know more about me!"
 	}
 
-	badgesToDisplay := make([]models.ProfileBadgeEntry, 0)
+	data.Badges = make([]models.ProfileBadgeEntry, 0)
 	availableBadges := getBadgesAvailableQuick(member.User, userData.ActiveBadgeIDs)
 	for _, activeBadgeID := range userData.ActiveBadgeIDs {
 		for _, availableBadge := range availableBadges {
 			if activeBadgeID == availableBadge.GetID() {
-				badgesToDisplay = append(badgesToDisplay, availableBadge)
+				data.Badges = append(data.Badges, availableBadge)
 			}
 		}
 	}
-	var badgesHTML1, badgesHTML2 string
-	for i, badge := range badgesToDisplay {
-		if i <= 8 {
-			badgesHTML1 += fmt.Sprintf("<img src=\"%s\" style=\"border: 2px solid #%s;\">", getBadgeUrl(badge), badge.BorderColor)
-		} else {
-			badgesHTML2 += fmt.Sprintf("<img src=\"%s\" style=\"border: 2px solid #%s;\">", getBadgeUrl(badge), badge.BorderColor)
-		}
-	}
 
-	backgroundColor, err := colorful.Hex("#" + m.GetBackgroundColor(userData))
-	if err != nil {
-		backgroundColor, err = colorful.Hex("#000000")
-		if err != nil {
-			return "", err
-		}
-	}
-	backgroundColorString := fmt.Sprintf("rgba(%d, %d, %d, %s)",
-		int(backgroundColor.R*255), int(backgroundColor.G*255), int(backgroundColor.B*255),
-		m.GetBackgroundOpacity(userData))
-	detailColorString := fmt.Sprintf("rgba(0, 0, 0, %s)",
-		m.GetDetailOpacity(userData))
-
-	userTimeText := ""
 	if userData.Timezone != "" {
 		userLocation, err := time.LoadLocation(userData.Timezone)
 		if err == nil {
-			userTimeText = "<i class=\"fa fa-clock-o\" aria-hidden=\"true\"></i> " + time.Now().In(userLocation).Format(TimeAtUserFormat)
+			data.UserTime = time.Now().In(userLocation).Format(TimeAtUserFormat)
 		}
 	}
 
-	userBirthdayText := ""
-	isBirthday := false
 	if userData.Birthday != "" {
+		isBirthday := false
 		userLocation, err := time.LoadLocation("Etc/UTC")
 		if err == nil {
 			if userData.Timezone != "" {
@@ -3202,13 +3184,12 @@ func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild
 			}
 		}
 
-		userBirthdayText = "<i class=\"fa fa-birthday-cake\" aria-hidden=\"true\"></i> " + userData.Birthday
+		data.Birthday = userData.Birthday
 		if isBirthday {
-			userBirthdayText = "<i class=\"fa fa-birthday-cake\" aria-hidden=\"true\"></i> Today!"
+			data.Birthday = "Today!"
 		}
 	}
 
-	var playingStatus string
 	if !userData.HideLastFm {
 		lastfmUsername := helpers.GetLastFmUsername(member.User.ID)
 		if lastfmUsername != "" {
@@ -3220,7 +3201,7 @@ func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild
 				helpers.RelaxLog(err)
 			}
 			if err == nil && recentTracks.Tracks != nil && len(recentTracks.Tracks) >= 1 && recentTracks.Tracks[0].NowPlaying == "true" {
-				playingStatus += fmt.Sprintf("<i class=\"fa fa-music\" aria-hidden=\"true\"></i> %s by %s",
+				data.NowPlaying = fmt.Sprintf("%s by %s",
 					recentTracks.Tracks[0].Name, recentTracks.Tracks[0].Artist.Name)
 			}
 			topArtists, err := helpers.GetLastFmClient().User.GetTopArtists(lastfm.P{
@@ -3232,38 +3213,95 @@ func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild
 				helpers.RelaxLog(err)
 			}
 			if err == nil && topArtists.Artists != nil && len(topArtists.Artists) >= 1 {
-				if playingStatus != "" {
-					playingStatus += "<br>"
-				}
 				playCountN, err := strconv.Atoi(topArtists.Artists[0].PlayCount)
 				helpers.RelaxLog(err)
 				if err == nil {
-					playingStatus += fmt.Sprintf("<i class=\"fa fa-users\" aria-hidden=\"true\"></i> %s",
-						topArtists.Artists[0].Name)
+					data.TopArtist = topArtists.Artists[0].Name
 					playCountText := fmt.Sprintf("(%s plays)", humanize.Comma(int64(playCountN)))
 					if helpers.RuneLength(topArtists.Artists[0].Name)+1+helpers.RuneLength(playCountText) <= 20 {
-						playingStatus += " " + playCountText
+						data.TopArtist += " " + playCountText
 					}
 				}
 			}
 		}
 	}
 
+	return data, nil
+}
+
+func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild, web bool) (string, error) {
+	data, err := m.getProfileData(member, guild)
+	if err == errProfileWithoutLevels {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	userData := data.Userdata
+
+	avatarUrl := data.AvatarUrl
+	if web == true && data.AvatarUrlGif != "" {
+		avatarUrl = data.AvatarUrlGif
+	}
+
+	var badgesHTML1, badgesHTML2 string
+	for i, badge := range data.Badges {
+		if i <= 8 {
+			badgesHTML1 += fmt.Sprintf("<img src=\"%s\" style=\"border: 2px solid #%s;\">", getBadgeUrl(badge), badge.BorderColor)
+		} else {
+			badgesHTML2 += fmt.Sprintf("<img src=\"%s\" style=\"border: 2px solid #%s;\">", getBadgeUrl(badge), badge.BorderColor)
+		}
+	}
+
+	backgroundColor, err := colorful.Hex("#" + m.GetBackgroundColor(userData))
+	if err != nil {
+		backgroundColor, err = colorful.Hex("#000000")
+		if err != nil {
+			return "", err
+		}
+	}
+	backgroundColorString := fmt.Sprintf("rgba(%d, %d, %d, %s)",
+		int(backgroundColor.R*255), int(backgroundColor.G*255), int(backgroundColor.B*255),
+		m.GetBackgroundOpacity(userData))
+	detailColorString := fmt.Sprintf("rgba(0, 0, 0, %s)",
+		m.GetDetailOpacity(userData))
+
+	userTimeText := ""
+	if data.UserTime != "" {
+		userTimeText = "<i class=\"fa fa-clock-o\" aria-hidden=\"true\"></i> " + data.UserTime
+	}
+
+	userBirthdayText := ""
+	if data.Birthday != "" {
+		userBirthdayText = "<i class=\"fa fa-birthday-cake\" aria-hidden=\"true\"></i> " + data.Birthday
+	}
+
+	var playingStatus string
+	if data.NowPlaying != "" {
+		playingStatus += "<i class=\"fa fa-music\" aria-hidden=\"true\"></i> " + data.NowPlaying
+	}
+	if data.TopArtist != "" {
+		if playingStatus != "" {
+			playingStatus += "<br>"
+		}
+		playingStatus += "<i class=\"fa fa-users\" aria-hidden=\"true\"></i> " + data.TopArtist
+	}
+
 	expOpacity := m.GetExpOpacity(userData)
 	badgeOpacity := m.GetBadgeOpacity(userData)
 
 	tempTemplateHtml := strings.Replace(htmlTemplateString, "{USER_USERNAME}", html.EscapeString(member.User.Username), -1)
 	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_NICKNAME}", html.EscapeString(member.Nick), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AND_NICKNAME}", html.EscapeString(userAndNick), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USERNAME_WITH_DISC}", html.EscapeString(userWithDisc), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AND_NICKNAME}", html.EscapeString(data.UserAndNick), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USERNAME_WITH_DISC}", html.EscapeString(data.UserWithDisc), -1)
 	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_AVATAR_URL}", html.EscapeString(avatarUrl), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TITLE}", html.EscapeString(title), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIO}", html.EscapeString(bio), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL}", strconv.Itoa(GetLevelFromExp(levelThisServerUser.Exp)), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_RANK}", serverRank, -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL_PERCENT}", strconv.Itoa(GetProgressToNextLevelFromExp(levelThisServerUser.Exp)), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_LEVEL}", strconv.Itoa(GetLevelFromExp(totalExp)), -1)
-	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_RANK}", globalRank, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_TITLE}", html.EscapeString(data.Title), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BIO}", html.EscapeString(data.Bio), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL}", strconv.Itoa(data.ServerLevel), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_RANK}", data.ServerRank, -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_SERVER_LEVEL_PERCENT}", strconv.Itoa(data.ServerLevelPercent), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_LEVEL}", strconv.Itoa(data.GlobalLevel), -1)
+	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_GLOBAL_RANK}", data.GlobalRank, -1)
 	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BACKGROUND_URL}", m.GetProfileBackgroundUrl(userData), -1)
 	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_REP}", strconv.Itoa(userData.Rep), -1)
 	tempTemplateHtml = strings.Replace(tempTemplateHtml, "{USER_BADGES_HTML_1}", badgesHTML1, -1)
@@ -3287,150 +3325,126 @@ func (m *Levels) GetProfileHTML(member *discordgo.Member, guild *discordgo.Guild
 	return tempTemplateHtml, nil
 }
 
+// GetProfile draws the profile image of a member, gifP animates gif backgrounds and avatars
 func (m *Levels) GetProfile(member *discordgo.Member, guild *discordgo.Guild, gifP bool) ([]byte, string, error) {
-	tempTemplateHtml, err := m.GetProfileHTML(member, guild, false)
+	data, err := m.getProfileData(member, guild)
 	if err != nil {
 		return []byte{}, "", err
 	}
+	userData := data.Userdata
 
 	start := time.Now()
-	imageBytes, err := helpers.TakeHTMLScreenshot(tempTemplateHtml, 400, 300)
+
+	card := helpers.ProfileCard{
+		Username:           data.UserWithDisc,
+		Title:              data.Title,
+		Bio:                data.Bio,
+		ServerLevel:        strconv.Itoa(data.ServerLevel),
+		ServerRank:         data.ServerRank,
+		ServerLevelPercent: data.ServerLevelPercent,
+		GlobalLevel:        strconv.Itoa(data.GlobalLevel),
+		GlobalRank:         data.GlobalRank,
+		Rep:                userData.Rep,
+		BackgroundColor:    m.GetBackgroundColor(userData),
+		AccentColor:        m.GetAccentColor(userData),
+		TextColor:          m.GetTextColor(userData),
+		BackgroundOpacity:  parseProfileOpacity(m.GetBackgroundOpacity(userData), 0.5),
+		DetailOpacity:      parseProfileOpacity(m.GetDetailOpacity(userData), 0.5),
+		EXPOpacity:         parseProfileOpacity(m.GetExpOpacity(userData), 0.5),
+		BadgeOpacity:       parseProfileOpacity(m.GetBadgeOpacity(userData), 1),
+		Emoji:              profileEmoji,
+	}
+	if data.NowPlaying != "" {
+		card.Playing = append(card.Playing, "🎵 "+data.NowPlaying)
+	}
+	if data.TopArtist != "" {
+		card.Playing = append(card.Playing, "👥 "+data.TopArtist)
+	}
+	if data.UserTime != "" {
+		card.Stats = "🕒 " + data.UserTime
+	}
+	if data.Birthday != "" {
+		card.Stats = strings.TrimSpace(card.Stats + " 🎂 " + data.Birthday)
+	}
+
+	card.Background, card.BackgroundDelay, err = getProfileImageFrames(m.GetProfileBackgroundUrl(userData))
 	if err != nil {
 		return []byte{}, "", err
 	}
-	elapsed := time.Since(start)
-	cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("took screenshot of profile in %s", elapsed.String()))
 
-	metrics.LevelImagesGenerated.Add(1)
-
-	avatarUrlGif := helpers.GetAvatarUrl(member.User)
-	if avatarUrlGif != "" {
-		avatarUrlGif = strings.Replace(avatarUrlGif, "size=1024", "size=128", -1)
-		if !strings.Contains(avatarUrlGif, "gif") {
-			avatarUrlGif = ""
-		}
+	avatarUrl := data.AvatarUrl
+	if gifP == true && data.AvatarUrlGif != "" {
+		avatarUrl = data.AvatarUrlGif
+	}
+	card.Avatar, card.AvatarDelay, err = getProfileImageFrames(avatarUrl)
+	if err != nil {
+		return []byte{}, "", err
 	}
 
-	if avatarUrlGif != "" && gifP == true {
-		outGif := &gif.GIF{}
-
-		decodedFirstFrame, err := png.Decode(bytes.NewReader(imageBytes))
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
-		}
-		buf := bytes.Buffer{}
-		err = gif.Encode(&buf, decodedFirstFrame, nil)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
-		}
-		firstFrame, err := gif.Decode(&buf)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
-		}
-
-		avatarGifBytes, err := helpers.NetGetUAWithError(avatarUrlGif, helpers.DEFAULT_UA)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
+	for _, badge := range data.Badges {
+		profileBadge := helpers.ProfileCardBadge{BorderColor: badge.BorderColor}
+		badgeFrames, _, err := getProfileImageFrames(getBadgeUrl(badge))
+		if err == nil {
+			profileBadge.Image = badgeFrames[0]
+		} else {
+			helpers.RelaxLog(err)
 		}
+		card.Badges = append(card.Badges, profileBadge)
+	}
 
-		avatarGif, err := gif.DecodeAll(bytes.NewReader(avatarGifBytes))
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
-		}
+	imageBytes, extension, err := helpers.DrawProfileCard(card, profileFonts, gifP)
+	if err != nil {
+		return []byte{}, "", err
+	}
+	elapsed := time.Since(start)
+	cache.GetLogger().WithField("module", "levels").Info(fmt.Sprintf("drew profile in %s", elapsed.String()))
 
-		fullRect := image.Rect(0, 0, 400, 300)
-		pm := image.NewPaletted(fullRect, nil)
-		q := gogif.MedianCutQuantizer{NumColor: 256}
-		q.Quantize(pm, fullRect, decodedFirstFrame, image.ZP)
-		draw.FloydSteinberg.Draw(pm, fullRect, firstFrame, image.ZP)
-
-		outGif.Image = append(outGif.Image, pm)
-		outGif.Delay = append(outGif.Delay, avatarGif.Delay[0])
-
-		resizeRect := image.Rect(0, 0, 128, 128)
-		cutImage := image.NewRGBA(resizeRect)
-		resizedRect := image.Rect(4, 64+90, 4+80, 64+80+90)
-
-		for i, avatarGifFrame := range avatarGif.Image {
-			resizedImage := resize.Resize(80, 80, avatarGifFrame, resize.NearestNeighbor)
-			draw.DrawMask(
-				cutImage, resizedImage.Bounds(), resizedImage, image.ZP,
-				&circle{image.Pt(40, 40), 40}, image.ZP, draw.Over)
-			avatarGifFrame.Palette = append(avatarGifFrame.Palette, image.Transparent)
-			paletteHasTransparency := false
-			newPalette := make([]color.Color, 0)
-			for i, color := range avatarGifFrame.Palette {
-				if color == image.Transparent {
-					paletteHasTransparency = true
-				}
-				newPalette = append(newPalette, color)
-				if i == 254 && paletteHasTransparency == false {
-					newPalette = append(newPalette, image.Transparent)
-					break
-				}
-			}
-			pm = image.NewPaletted(resizedRect, newPalette)
-			draw.FloydSteinberg.Draw(pm, resizedRect, cutImage, image.ZP)
-			outGif.Image = append(outGif.Image, pm)
-			outGif.Delay = append(outGif.Delay, avatarGif.Delay[i])
-		}
+	metrics.LevelImagesGenerated.Add(1)
 
-		outGif.LoopCount = -1
+	return imageBytes, extension, nil
+}
 
-		buf = bytes.Buffer{}
-		err = gif.EncodeAll(&buf, outGif)
-		if err != nil {
-			raven.SetUserContext(&raven.User{
-				Username: member.User.Username + "#" + member.User.Discriminator,
-			})
-			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-			goto ReturnImageBytes
-		}
-		return buf.Bytes(), "gif", nil
+// getProfileImageFrames downloads an image for a profile and decodes all of its frames
+func getProfileImageFrames(link string) (frames []image.Image, delays []int, err error) {
+	imageData, err := helpers.NetGetUAWithError(link, helpers.DEFAULT_UA)
+	if err != nil {
+		return nil, nil, err
 	}
-ReturnImageBytes:
-	return imageBytes, "png", nil
+	return helpers.DecodeProfileCardFrames(imageData)
 }
 
-type circle struct {
-	p image.Point
-	r int
+func parseProfileOpacity(opacity string, fallback float64) float64 {
+	parsedOpacity, err := strconv.ParseFloat(opacity, 64)
+	if err != nil {
+		return fallback
+	}
+	return parsedOpacity
 }
 
-func (c *circle) ColorModel() color.Model {
-	return color.AlphaModel
-}
+// profileEmoji returns the twemoji at the beginning of text, matches the longest emoji file like the emoji enlarging in bot.go
+func profileEmoji(text string) (emoji image.Image, length int) {
+	found, length := helpers.MatchProfileCardEmoji(text, profileEmojiFiles)
+	if found == "" {
+		return nil, 0
+	}
 
-func (c *circle) Bounds() image.Rectangle {
-	return image.Rect(c.p.X-c.r, c.p.Y-c.r, c.p.X+c.r, c.p.Y+c.r)
-}
+	profileEmojiCacheLock.Lock()
+	defer profileEmojiCacheLock.Unlock()
+	if cachedEmoji, ok := profileEmojiCache[found]; ok {
+		return cachedEmoji, length
+	}
 
-func (c *circle) At(x, y int) color.Color {
-	xx, yy, rr := float64(x-c.p.X)+0.5, float64(y-c.p.Y)+0.5, float64(c.r)
-	if xx*xx+yy*yy < rr*rr {
-		return color.Alpha{255}
+	emojiFile, err := os.Open(assetsPath + "twemoji72/" + found + ".png")
+	if err != nil {
+		return nil, 0
+	}
+	defer emojiFile.Close()
+	emoji, err = png.Decode(emojiFile)
+	if err != nil {
+		return nil, 0
 	}
-	return color.Alpha{0}
+	profileEmojiCache[found] = emoji
+	return emoji, length
 }
 
 func (m *Levels) GetBackgroundColor(userUserdata models.ProfileUserdataEntry) string {
@@ -3701,6 +3715,7 @@ func (b *Levels) OnMessageDelete(msg *discordgo.MessageDelete, session *discordg
 func (l *Levels) createLevelsRoleEntry(
 	guildID string,
 	roleID string,
+	roleType models.LevelsRoleType,
 	startLevel int,
 	lastLevel int,
 ) (result models.LevelsRoleEntry, err error) {
@@ -3709,6 +3724,7 @@ func (l *Levels) createLevelsRoleEntry(
 		models.LevelsRoleEntry{
 			GuildID:    guildID,
 			RoleID:     roleID,
+			Type:       roleType,
 			StartLevel: startLevel,
 			LastLevel:  lastLevel,
 		},
@@ -27,7 +27,8 @@ func getLevelsServerUserOrCreateNewWithoutLogging(guildid string, userid string)
 	return serveruser, err
 }
 
-func getLevelsRoles(guildID string, currentLevel int) (apply []*discordgo.Role, remove []*discordgo.Role) {
+// getLevelsRoles returns the level and rep roles to apply and to remove for the user
+func getLevelsRoles(guildID string, userID string, currentLevel int) (apply []*discordgo.Role, remove []*discordgo.Role) {
 	apply = make([]*discordgo.Role, 0)
 	remove = make([]*discordgo.Role, 0)
 
@@ -42,13 +43,22 @@ func getLevelsRoles(guildID string, currentLevel int) (apply []*discordgo.Role,
 		return
 	}
 
+	currentRep := -1
 	for _, entry := range entryBucket {
 		role, err := cache.GetSession().State.Role(guildID, entry.RoleID)
 		if err != nil {
 			continue
 		}
 
-		if currentLevel >= entry.StartLevel && (entry.LastLevel < 0 || currentLevel <= entry.LastLevel) {
+		current := currentLevel
+		if entry.Type == models.LevelsRoleTypeRep {
+			if currentRep < 0 {
+				currentRep = getServerRepForUser(guildID, userID)
+			}
+			current = currentRep
+		}
+
+		if current >= entry.StartLevel && (entry.LastLevel < 0 || current <= entry.LastLevel) {
 			apply = append(apply, role)
 		} else {
 			remove = append(remove, role)
@@ -209,6 +219,7 @@ func getBadgesAvailable(user *discordgo.User, sourceServerID string) []models.Pr
 	}
 
 	levelCache := make(map[string]int, 0)
+	var awardedBadgeIDs map[string]bool
 
 	var availableBadges []models.ProfileBadgeEntry
 	for _, foundBadge := range allBadges {
@@ -248,6 +259,14 @@ func getBadgesAvailable(user *discordgo.User, sourceServerID string) []models.Pr
 			}
 		}
 
+		// Criteria Check, badges with criteria are awarded by processBadgeChecksLoop
+		if len(foundBadge.Criteria) > 0 {
+			if awardedBadgeIDs == nil {
+				awardedBadgeIDs = getBadgeAwardedIDs(user.ID)
+			}
+			isAllowed = awardedBadgeIDs[foundBadge.GetID()]
+		}
+
 		// User is in allowed user list?
 		for _, allowedUserID := range foundBadge.AllowedUserIDs {
 			if allowedUserID == user.ID {
@@ -0,0 +1,361 @@
+package levels
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/globalsign/mgo/bson"
+)
+
+// every rep is stored as a models.ProfileRepEntry, the total on the profile is kept in models.ProfileUserdataEntry.Rep
+
+const (
+	repHistoryLimit     = 20
+	repLeaderboardLimit = 10
+)
+
+func (m *Levels) actionRep(content string, msg *discordgo.Message, session *discordgo.Session) {
+	session.ChannelTyping(msg.ChannelID)
+	args := strings.Fields(content)
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "history": // [p]rep history [<user id/mention>]
+			m.repHistory(args, msg, channel)
+			return
+		case "top", "leaderboard": // [p]rep top
+			m.repLeaderboard(msg, channel, false)
+			return
+		case "global-top", "global-leaderboard", "globaltop": // [p]rep global-top
+			m.repLeaderboard(msg, channel, true)
+			return
+		case "set-cooldown": // [p]rep set-cooldown <hours, 0 to reset>
+			helpers.RequireMod(msg, func() {
+				if len(args) < 2 {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+				hours, err := strconv.Atoi(args[1])
+				if err != nil || hours < 0 {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+				if hours > 0 && time.Duration(hours)*time.Hour < helpers.RepDefaultCooldown {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-cooldown-too-short",
+						int(helpers.RepDefaultCooldown.Hours())))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+
+				guildConfig := helpers.GuildSettingsGetCached(channel.GuildID)
+				guildConfig.RepCooldownHours = hours
+				err = helpers.GuildSettingsSet(channel.GuildID, guildConfig)
+				helpers.Relax(err)
+
+				_, err = helpers.EventlogLog(time.Now(), channel.GuildID, channel.GuildID,
+					models.EventlogTargetTypeGuild, msg.Author.ID,
+					models.EventlogTypeRobyulRepCooldownSet, "",
+					nil,
+					[]models.ElasticEventlogOption{
+						{
+							Key:   "rep_cooldown_hours",
+							Value: strconv.Itoa(hours),
+						},
+					}, false)
+				helpers.RelaxLog(err)
+
+				_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-cooldown-set",
+					int(helpers.GetRepCooldown(hours).Hours())))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			})
+			return
+		}
+	}
+
+	m.repGive(args, content, msg, channel, session)
+}
+
+func (m *Levels) repGive(args []string, content string, msg *discordgo.Message, channel *discordgo.Channel, session *discordgo.Session) {
+	m.lockRepUser(msg.Author.ID)
+	defer m.unlockRepUser(msg.Author.ID)
+
+	cooldown := helpers.GetRepCooldown(helpers.GuildSettingsGetCached(channel.GuildID).RepCooldownHours)
+	accountCreatedAt := helpers.GetTimeFromSnowflake(msg.Author.ID)
+	lastGiven := getLastRepTime(bson.M{"giveruserid": msg.Author.ID, "guildid": channel.GuildID})
+	// rep counts towards the global total, so the default cooldown applies across all servers
+	giverData, err := helpers.GetUserUserdata(msg.Author.ID)
+	helpers.Relax(err)
+
+	if len(args) <= 0 {
+		wait, err := helpers.CheckRepAllowed(accountCreatedAt, lastGiven, giverData.LastRepped, time.Time{}, cooldown, time.Now())
+		switch err {
+		case helpers.ErrRepCooldown:
+			if wait.Minutes() < 1 {
+				helpers.SendMessage(msg.ChannelID,
+					helpers.GetTextF("plugins.levels.rep-next-rep-seconds", int(math.Floor(wait.Seconds()))))
+			} else {
+				helpers.SendMessage(msg.ChannelID,
+					helpers.GetTextF("plugins.levels.rep-next-rep",
+						int(math.Floor(wait.Hours())),
+						int(math.Floor(wait.Minutes()))-(int(math.Floor(wait.Hours()))*60)))
+			}
+		case helpers.ErrRepAccountTooNew:
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-error-account-age",
+				int(helpers.RepMinimumAccountAge.Hours()/24)))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		default:
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-target"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		}
+		return
+	}
+
+	targetUser, err := helpers.GetUserFromMention(args[0])
+	if err != nil || targetUser == nil || targetUser.ID == "" {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	// Don't rep this bot account, other bots, or oneself
+	if targetUser.ID == session.State.User.ID {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-session"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	if targetUser.ID == msg.Author.ID {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-self"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	if targetUser.Bot == true {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-error-bot"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	reason := strings.TrimSpace(strings.Replace(content, args[0], "", 1))
+	if helpers.RuneLength(reason) > helpers.RepMaxReasonLength {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-error-reason-too-long", helpers.RepMaxReasonLength))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	// mutual rep is checked across all servers
+	lastReceivedFromTarget := getLastRepTime(bson.M{"giveruserid": targetUser.ID, "receiveruserid": msg.Author.ID})
+
+	wait, err := helpers.CheckRepAllowed(accountCreatedAt, lastGiven, giverData.LastRepped, lastReceivedFromTarget, cooldown, time.Now())
+	switch err {
+	case helpers.ErrRepCooldown:
+		if wait.Minutes() < 1 {
+			helpers.SendMessage(msg.ChannelID,
+				helpers.GetTextF("plugins.levels.rep-error-timelimit-seconds", int(math.Floor(wait.Seconds()))))
+		} else {
+			helpers.SendMessage(msg.ChannelID,
+				helpers.GetTextF("plugins.levels.rep-error-timelimit",
+					int(math.Floor(wait.Hours())),
+					int(math.Floor(wait.Minutes()))-(int(math.Floor(wait.Hours()))*60)))
+		}
+		return
+	case helpers.ErrRepAccountTooNew:
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-error-account-age",
+			int(helpers.RepMinimumAccountAge.Hours()/24)))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	case helpers.ErrRepMutual:
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-error-mutual",
+			targetUser.Username, int(helpers.RepMutualWindow.Hours()/24)))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	_, err = helpers.MDbInsert(models.ProfileRepTable, models.ProfileRepEntry{
+		GuildID:        channel.GuildID,
+		GiverUserID:    msg.Author.ID,
+		ReceiverUserID: targetUser.ID,
+		Reason:         reason,
+		CreatedAt:      time.Now(),
+	})
+	helpers.Relax(err)
+
+	// only the changed fields are updated, writing the whole userdata would overwrite concurrent changes
+	targetUserData, err := helpers.GetUserUserdata(targetUser.ID)
+	helpers.Relax(err)
+	err = helpers.MDbUpdateQuery(models.ProfileUserdataTable, bson.M{"_id": targetUserData.ID}, bson.M{"$inc": bson.M{"rep": 1}})
+	helpers.Relax(err)
+
+	userData, err := helpers.GetUserUserdata(msg.Author.ID)
+	helpers.Relax(err)
+	err = helpers.MDbUpdateQuery(models.ProfileUserdataTable, bson.M{"_id": userData.ID}, bson.M{"$set": bson.M{"lastrepped": time.Now()}})
+	helpers.Relax(err)
+
+	go func() {
+		defer helpers.Recover()
+
+		// apply rep roles
+		err := applyLevelsRoles(channel.GuildID, targetUser.ID, getLevelForUser(targetUser.ID, channel.GuildID))
+		if errD, ok := err.(*discordgo.RESTError); !ok || (errD.Message.Code != discordgo.ErrCodeUnknownMember &&
+			errD.Message.Code != discordgo.ErrCodeMissingAccess && errD.Message.Code != discordgo.ErrCodeMissingPermissions) {
+			helpers.RelaxLog(err)
+		}
+	}()
+
+	_, err = helpers.SendMessage(msg.ChannelID,
+		helpers.GetTextF("plugins.levels.rep-success", targetUser.Username))
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) repHistory(args []string, msg *discordgo.Message, channel *discordgo.Channel) {
+	targetUser := msg.Author
+	if len(args) >= 2 {
+		var err error
+		targetUser, err = helpers.GetUserFromMention(args[1])
+		if err != nil || targetUser == nil || targetUser.ID == "" {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+	}
+
+	query := bson.M{"receiveruserid": targetUser.ID, "guildid": channel.GuildID}
+
+	var entries []models.ProfileRepEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.ProfileRepTable).Find(query).Sort("-createdat").Limit(repHistoryLimit)).All(&entries)
+	helpers.Relax(err)
+
+	if len(entries) <= 0 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.rep-history-none", targetUser.Username))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	serverRep, err := helpers.MdbCount(models.ProfileRepTable, query)
+	helpers.Relax(err)
+
+	userData, err := helpers.GetUserUserdata(targetUser.ID)
+	helpers.Relax(err)
+
+	resultText := helpers.GetTextF("plugins.levels.rep-history-title", targetUser.Username, serverRep, userData.Rep) + "\n"
+	for _, entry := range entries {
+		giverName := "N/A"
+		giver, err := helpers.GetUserWithoutAPI(entry.GiverUserID)
+		if err == nil {
+			giverName = giver.Username
+		}
+
+		reasonText := ""
+		if entry.Reason != "" {
+			reasonText = ": " + entry.Reason
+		}
+
+		resultText += fmt.Sprintf("`%s` from **%s**%s\n", humanize.Time(entry.CreatedAt), giverName, reasonText)
+	}
+	if serverRep > len(entries) {
+		resultText += helpers.GetTextF("plugins.levels.rep-history-more", serverRep-len(entries)) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(resultText, "\n") {
+		_, err := helpers.SendMessage(msg.ChannelID, page)
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+	}
+}
+
+func (m *Levels) repLeaderboard(msg *discordgo.Message, channel *discordgo.Channel, global bool) {
+	type repRanking struct {
+		UserID string `bson:"_id"`
+		Rep    int
+	}
+
+	var rankings []repRanking
+	var title string
+	if global {
+		// the global leaderboard uses the totals, those include rep given before entries were stored
+		var usersData []models.ProfileUserdataEntry
+		err := helpers.MDbIter(helpers.MdbCollection(models.ProfileUserdataTable).Find(
+			bson.M{"rep": bson.M{"$gt": 0}},
+		).Sort("-rep").Limit(repLeaderboardLimit)).All(&usersData)
+		helpers.Relax(err)
+
+		for _, userData := range usersData {
+			rankings = append(rankings, repRanking{UserID: userData.UserID, Rep: userData.Rep})
+		}
+		title = helpers.GetText("plugins.levels.rep-top-global-embed-title")
+	} else {
+		err := helpers.MdbPipeAll(models.ProfileRepTable, []bson.M{
+			{"$match": bson.M{"guildid": channel.GuildID}},
+			{"$group": bson.M{"_id": "$receiveruserid", "rep": bson.M{"$sum": 1}}},
+			{"$sort": bson.M{"rep": -1}},
+			{"$limit": repLeaderboardLimit},
+		}, &rankings)
+		helpers.Relax(err)
+
+		guild, err := helpers.GetGuild(channel.GuildID)
+		helpers.Relax(err)
+		title = helpers.GetTextF("plugins.levels.rep-top-server-embed-title", guild.Name)
+	}
+
+	if len(rankings) <= 0 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.rep-top-none"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	topRepEmbed := &discordgo.MessageEmbed{
+		Color:  0x0FADED,
+		Title:  title,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+
+	for i, ranking := range rankings {
+		username := "N/A"
+		user, err := helpers.GetUserWithoutAPI(ranking.UserID)
+		if err == nil {
+			username = user.Username
+		}
+
+		topRepEmbed.Fields = append(topRepEmbed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%d. %s", i+1, username),
+			Value:  fmt.Sprintf("Rep: %s", humanize.Comma(int64(ranking.Rep))),
+			Inline: false,
+		})
+	}
+
+	_, err := helpers.SendEmbed(msg.ChannelID, topRepEmbed)
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+// getLastRepTime returns the time of the newest rep entry matching query, or a zero time
+func getLastRepTime(query bson.M) time.Time {
+	var lastEntry models.ProfileRepEntry
+	err := helpers.MdbOneWithoutLogging(helpers.MdbCollection(models.ProfileRepTable).Find(query).Sort("-createdat"), &lastEntry)
+	if err != nil {
+		if !helpers.IsMdbNotFound(err) {
+			helpers.RelaxLog(err)
+		}
+		return time.Time{}
+	}
+	return lastEntry.CreatedAt
+}
+
+// getServerRepForUser returns the rep the user received on the server, used for rep roles
+func getServerRepForUser(guildID, userID string) int {
+	count, err := helpers.MdbCountWithoutLogging(models.ProfileRepTable, bson.M{"guildid": guildID, "receiveruserid": userID})
+	if err != nil {
+		cache.GetLogger().WithField("module", "levels").Warnf("failed to count rep of user #%s: %s", userID, err.Error())
+		return 0
+	}
+	return count
+}
@@ -0,0 +1,650 @@
+package levels
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/metrics"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bradfitz/slice"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// profile themes are backgrounds, optionally with colours and opacities, submitted by users
+// global submissions are reviewed by Robyul mods, server exclusive submissions by the moderators of the server
+
+const (
+	themesMaxPendingPerUser = 3
+	themesGalleryPerPage    = 10
+)
+
+func (m *Levels) actionThemes(args []string, content string, msg *discordgo.Message, channel *discordgo.Channel) {
+	if len(args) < 2 {
+		m.themesGallery(msg, channel, "")
+		return
+	}
+
+	switch args[1] {
+	case "list", "gallery": // [p]profile themes list [<tag>]
+		var tag string
+		if len(args) >= 3 {
+			tag = args[2]
+		}
+		m.themesGallery(msg, channel, tag)
+		return
+	case "use", "set": // [p]profile theme use <name>
+		if len(args) < 3 {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+		m.themesUse(msg, channel, strings.ToLower(args[2]))
+		return
+	case "submit", "submit-server": // [p]profile theme submit[-server] <background or theme> <name> <tag, tag, …> [+ ATTACHMENT]
+		m.themesSubmit(args, content, msg, channel, args[1] == "submit-server")
+		return
+	case "queue": // [p]profile theme queue
+		m.themesQueue(msg, channel)
+		return
+	case "approve": // [p]profile theme approve <submission id>
+		if len(args) < 3 {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+		m.themesReview(msg, channel, args[2], true, "")
+		return
+	case "reject": // [p]profile theme reject <submission id> <reason>
+		if len(args) < 4 {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+		reason := strings.TrimSpace(strings.Replace(content, strings.Join(args[:3], " "), "", 1))
+		m.themesReview(msg, channel, args[2], false, reason)
+		return
+	case "delete", "remove": // [p]profile theme delete <name>
+		if len(args) < 3 {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+			return
+		}
+		m.themesDelete(msg, channel, strings.ToLower(args[2]))
+		return
+	}
+
+	_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) themesGallery(msg *discordgo.Message, channel *discordgo.Channel, tag string) {
+	var allBackgrounds []models.ProfileBackgroundEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.ProfileBackgroundsTable).Find(nil).Sort("name")).All(&allBackgrounds)
+	helpers.Relax(err)
+
+	var approvedThemes []models.ProfileThemeEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.ProfileThemesTable).Find(bson.M{
+		"kind":   models.ProfileThemeKindTheme,
+		"status": models.ProfileThemeStatusApproved,
+	})).All(&approvedThemes)
+	helpers.Relax(err)
+	fullThemes := make(map[string]bool)
+	for _, theme := range approvedThemes {
+		fullThemes[theme.Name] = true
+	}
+
+	usageCounts, err := helpers.GetProfileBackgroundUsageCounts()
+	helpers.Relax(err)
+
+	backgrounds := make([]models.ProfileBackgroundEntry, 0)
+	for _, background := range allBackgrounds {
+		if helpers.ProfileBackgroundIsUsable(background, channel.GuildID) && helpers.ProfileBackgroundMatchesTag(background, tag) {
+			backgrounds = append(backgrounds, background)
+		}
+	}
+	if len(backgrounds) <= 0 {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.themes-gallery-empty"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	slice.Sort(backgrounds, func(i, j int) bool {
+		return usageCounts[strings.ToLower(backgrounds[i].Name)] > usageCounts[strings.ToLower(backgrounds[j].Name)]
+	})
+
+	embed := &discordgo.MessageEmbed{
+		Title: helpers.GetText("plugins.levels.themes-gallery-title"),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: helpers.GetTextF("plugins.levels.themes-gallery-footer", helpers.GetPrefixForServer(channel.GuildID)),
+		},
+		Color: 0x0FADED,
+	}
+	for _, background := range backgrounds {
+		name := background.Name
+		if fullThemes[background.Name] {
+			name += " 🎨"
+		}
+		if background.GuildID != "" {
+			name += " " + helpers.GetText("plugins.levels.themes-gallery-exclusive")
+		}
+
+		link := background.URL
+		if link == "" {
+			link, err = helpers.GetFileLink(background.ObjectName)
+			if err != nil {
+				helpers.RelaxLog(err)
+				continue
+			}
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: name,
+			Value: helpers.GetTextF("plugins.levels.themes-gallery-entry",
+				strings.Join(background.Tags, ", "), usageCounts[strings.ToLower(background.Name)], link),
+			Inline: false,
+		})
+	}
+
+	err = helpers.SendPagedMessage(msg, embed, themesGalleryPerPage)
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) themesUse(msg *discordgo.Message, channel *discordgo.Channel, name string) {
+	var background models.ProfileBackgroundEntry
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.ProfileBackgroundsTable).Find(bson.M{"name": name}),
+		&background,
+	)
+	if helpers.IsMdbNotFound(err) || !helpers.ProfileBackgroundIsUsable(background, channel.GuildID) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-not-found"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	helpers.Relax(err)
+
+	var theme models.ProfileThemeEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.ProfileThemesTable).Find(bson.M{"name": name, "status": models.ProfileThemeStatusApproved}),
+		&theme,
+	)
+	if err != nil && !helpers.IsMdbNotFound(err) {
+		helpers.Relax(err)
+	}
+
+	userUserdata, err := helpers.GetUserUserdata(msg.Author.ID)
+	helpers.Relax(err)
+
+	// delete old background object if set
+	if userUserdata.BackgroundObjectName != "" {
+		err = helpers.DeleteFile(userUserdata.BackgroundObjectName)
+		helpers.RelaxLog(err)
+	}
+
+	userUserdata.Background = name
+	userUserdata.BackgroundObjectName = ""
+	if theme.Kind == models.ProfileThemeKindTheme {
+		userUserdata.BackgroundColor = theme.BackgroundColor
+		userUserdata.AccentColor = theme.AccentColor
+		userUserdata.TextColor = theme.TextColor
+		userUserdata.BackgroundOpacity = theme.BackgroundOpacity
+		userUserdata.DetailOpacity = theme.DetailOpacity
+		userUserdata.EXPOpacity = theme.EXPOpacity
+		userUserdata.BadgeOpacity = theme.BadgeOpacity
+	}
+	err = helpers.MDbUpdate(models.ProfileUserdataTable, userUserdata.ID, userUserdata)
+	helpers.Relax(err)
+
+	_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.theme-use-success", name))
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) themesSubmit(args []string, content string, msg *discordgo.Message, channel *discordgo.Channel, serverExclusive bool) {
+	if len(args) < 5 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	kind := strings.ToLower(args[2])
+	if kind != models.ProfileThemeKindBackground && kind != models.ProfileThemeKindTheme {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-submit-invalid-kind"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	name := strings.ToLower(args[3])
+	if !helpers.ProfileThemeNameIsValid(name) {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-submit-invalid-name"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	tags := helpers.ParseProfileThemeTags(strings.TrimSpace(strings.Replace(content, strings.Join(args[:4], " "), "", 1)))
+	if len(tags) <= 0 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	if len(tags) > helpers.ProfileThemeMaxTags {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.theme-submit-too-many-tags", helpers.ProfileThemeMaxTags))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	if helpers.UseruploadsIsDisabled(msg.Author.ID) {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.errors.useruploads-disabled"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	pendingSubmissions, err := helpers.MdbCollection(models.ProfileThemesTable).Find(bson.M{
+		"submittedbyuserid": msg.Author.ID,
+		"status":            models.ProfileThemeStatusPending,
+	}).Count()
+	helpers.Relax(err)
+	if pendingSubmissions >= themesMaxPendingPerUser {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.theme-submit-too-many-pending", pendingSubmissions))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	if m.themeNameIsTaken(name) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.new-profile-background-add-error-duplicate"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	userUserdata, err := helpers.GetUserUserdata(msg.Author.ID)
+	helpers.Relax(err)
+
+	var sourceUrl string
+	if len(msg.Attachments) > 0 {
+		sourceUrl = msg.Attachments[0].URL
+	} else if kind == models.ProfileThemeKindTheme {
+		// themes without an attachment use the current background
+		sourceUrl = m.GetProfileBackgroundUrl(userUserdata)
+	} else {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-submit-no-attachment"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	quitChannel := helpers.StartTypingLoop(msg.ChannelID)
+	defer func() { quitChannel <- 0 }()
+
+	bytesData, fits, err := prepareThemeBackground(sourceUrl)
+	if err != nil {
+		helpers.RelaxLog(err)
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.user-background-upload-failed"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	if !fits {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.user-background-wrong-dimensions"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	metrics.CloudVisionApiRequests.Add(1)
+	safetyRatings, err := helpers.GetPictureSafetyRatings(bytes.NewReader(bytesData))
+	if err != nil {
+		helpers.RelaxLog(err)
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.user-background-upload-failed"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	if !safetyRatings.Safe() {
+		go func() {
+			defer helpers.Recover()
+
+			logChannelID, _ := helpers.GetBotConfigString(models.UserProfileBackgroundLogChannelKey)
+			if logChannelID != "" {
+				err := m.logUserBackgroundNotSafe(logChannelID, msg.ChannelID, msg.Author.ID, sourceUrl)
+				helpers.RelaxLog(err)
+			}
+		}()
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.user-background-not-safe"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	objectName, err := helpers.AddFile("", bytesData, helpers.AddFileMetadata{
+		ChannelID: msg.ChannelID,
+		UserID:    msg.Author.ID,
+	}, "levels", true)
+	if err != nil {
+		helpers.RelaxLog(err)
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.user-background-upload-failed"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	theme := models.ProfileThemeEntry{
+		Name:                 name,
+		Kind:                 kind,
+		Tags:                 tags,
+		SubmittedByUserID:    msg.Author.ID,
+		SubmittedInChannelID: msg.ChannelID,
+		SubmittedAt:          time.Now(),
+		Status:               models.ProfileThemeStatusPending,
+		ObjectName:           objectName,
+		SafetyRatings:        safetyRatings.String(),
+	}
+	if serverExclusive {
+		theme.GuildID = channel.GuildID
+	}
+	if kind == models.ProfileThemeKindTheme {
+		theme.BackgroundColor = m.GetBackgroundColor(userUserdata)
+		theme.AccentColor = m.GetAccentColor(userUserdata)
+		theme.TextColor = m.GetTextColor(userUserdata)
+		theme.BackgroundOpacity = m.GetBackgroundOpacity(userUserdata)
+		theme.DetailOpacity = m.GetDetailOpacity(userUserdata)
+		theme.EXPOpacity = m.GetExpOpacity(userUserdata)
+		theme.BadgeOpacity = m.GetBadgeOpacity(userUserdata)
+	}
+	theme.ID, err = helpers.MDbInsert(models.ProfileThemesTable, theme)
+	helpers.Relax(err)
+
+	if !serverExclusive {
+		go func() {
+			defer helpers.Recover()
+
+			logChannelID, _ := helpers.GetBotConfigString(models.UserProfileBackgroundLogChannelKey)
+			if logChannelID != "" {
+				err := m.logThemeSubmitted(logChannelID, theme)
+				helpers.RelaxLog(err)
+			}
+		}()
+	}
+
+	quitChannel <- 0
+	message := helpers.GetTextF("plugins.levels.theme-submit-success", name, helpers.MdbIdToHuman(theme.ID))
+	if serverExclusive {
+		message = helpers.GetTextF("plugins.levels.theme-submit-success-server", name, helpers.MdbIdToHuman(theme.ID))
+	}
+	_, err = helpers.SendMessage(msg.ChannelID, message)
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) themesQueue(msg *discordgo.Message, channel *discordgo.Channel) {
+	guildIDs := make([]string, 0)
+	if helpers.IsRobyulMod(msg.Author.ID) {
+		guildIDs = append(guildIDs, "")
+	}
+	if helpers.IsMod(msg) {
+		guildIDs = append(guildIDs, channel.GuildID)
+	}
+	if len(guildIDs) <= 0 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("mod.no_permission"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	var pendingThemes []models.ProfileThemeEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.ProfileThemesTable).Find(bson.M{
+		"status":  models.ProfileThemeStatusPending,
+		"guildid": bson.M{"$in": guildIDs},
+	}).Sort("submittedat")).All(&pendingThemes)
+	helpers.Relax(err)
+
+	if len(pendingThemes) <= 0 {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-queue-empty"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	queueText := helpers.GetTextF("plugins.levels.theme-queue-title", len(pendingThemes)) + "\n"
+	for _, theme := range pendingThemes {
+		link, err := helpers.GetFileLink(theme.ObjectName)
+		helpers.RelaxLog(err)
+
+		scope := helpers.GetText("plugins.levels.theme-queue-global")
+		if theme.GuildID != "" {
+			scope = helpers.GetText("plugins.levels.themes-gallery-exclusive")
+		}
+		queueText += helpers.GetTextF("plugins.levels.theme-queue-entry",
+			helpers.MdbIdToHuman(theme.ID), theme.Name, theme.Kind, scope, theme.SubmittedByUserID,
+			strings.Join(theme.Tags, ", "), theme.SafetyRatings, link) + "\n"
+	}
+	prefix := helpers.GetPrefixForServer(channel.GuildID)
+	queueText += helpers.GetTextF("plugins.levels.theme-queue-footer", prefix, prefix)
+
+	for _, page := range helpers.Pagify(queueText, "\n") {
+		_, err = helpers.SendMessage(msg.ChannelID, page)
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+	}
+}
+
+func (m *Levels) themesReview(msg *discordgo.Message, channel *discordgo.Channel, submissionID string, approve bool, reason string) {
+	var theme models.ProfileThemeEntry
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.ProfileThemesTable).Find(bson.M{
+			"_id":    helpers.HumanToMdbId(submissionID),
+			"status": models.ProfileThemeStatusPending,
+		}),
+		&theme,
+	)
+	if helpers.IsMdbNotFound(err) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-review-not-found"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	helpers.Relax(err)
+
+	if !canManageTheme(msg, channel, theme.GuildID) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("mod.no_permission"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	theme.ReviewedByUserID = msg.Author.ID
+	theme.ReviewedAt = time.Now()
+
+	if !approve {
+		theme.Status = models.ProfileThemeStatusRejected
+		theme.RejectReason = reason
+		err = helpers.MDbUpdate(models.ProfileThemesTable, theme.ID, theme)
+		helpers.Relax(err)
+
+		err = helpers.DeleteFile(theme.ObjectName)
+		helpers.RelaxLog(err)
+
+		go notifyThemeSubmitter(theme, helpers.GetTextF("plugins.levels.theme-dm-rejected", theme.Name, reason))
+
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.theme-reject-success", theme.Name))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	if m.backgroundNameIsTaken(theme.Name) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.new-profile-background-add-error-duplicate"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	_, err = helpers.MDbInsert(
+		models.ProfileBackgroundsTable,
+		models.ProfileBackgroundEntry{
+			Name:       theme.Name,
+			ObjectName: theme.ObjectName,
+			CreatedAt:  time.Now(),
+			Tags:       theme.Tags,
+			GuildID:    theme.GuildID,
+		},
+	)
+	helpers.Relax(err)
+
+	theme.Status = models.ProfileThemeStatusApproved
+	err = helpers.MDbUpdate(models.ProfileThemesTable, theme.ID, theme)
+	helpers.Relax(err)
+
+	prefix := helpers.GetPrefixForServer(channel.GuildID)
+	go notifyThemeSubmitter(theme, helpers.GetTextF("plugins.levels.theme-dm-approved", theme.Name, prefix, theme.Name))
+
+	_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.levels.theme-approve-success", theme.Name))
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (m *Levels) themesDelete(msg *discordgo.Message, channel *discordgo.Channel, name string) {
+	var background models.ProfileBackgroundEntry
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.ProfileBackgroundsTable).Find(bson.M{"name": name}),
+		&background,
+	)
+	if helpers.IsMdbNotFound(err) || !helpers.ProfileBackgroundIsUsable(background, channel.GuildID) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.theme-not-found"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+	helpers.Relax(err)
+
+	if !canManageTheme(msg, channel, background.GuildID) {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("mod.no_permission"))
+		helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		return
+	}
+
+	if !helpers.ConfirmEmbed(
+		msg.ChannelID, msg.Author, helpers.GetTextF("plugins.levels.profile-background-delete-confirm",
+			background.Name, m.GetProfileBackgroundUrlByName(background.Name)),
+		"✅", "🚫") {
+		return
+	}
+
+	err = helpers.MDbDelete(models.ProfileBackgroundsTable, background.ID)
+	helpers.Relax(err)
+
+	_, err = helpers.MdbCollection(models.ProfileThemesTable).RemoveAll(bson.M{"name": background.Name, "status": models.ProfileThemeStatusApproved})
+	helpers.Relax(err)
+
+	if background.ObjectName != "" {
+		err = helpers.DeleteFile(background.ObjectName)
+		helpers.RelaxLog(err)
+	}
+
+	_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.levels.profile-background-delete-success"))
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+// canManageTheme checks if the author can review or delete themes, global themes need a Robyul mod, server exclusive themes a moderator of the server
+func canManageTheme(msg *discordgo.Message, channel *discordgo.Channel, guildID string) bool {
+	if guildID == "" {
+		return helpers.IsRobyulMod(msg.Author.ID)
+	}
+	return guildID == channel.GuildID && helpers.IsMod(msg)
+}
+
+func (m *Levels) backgroundNameIsTaken(name string) bool {
+	count, err := helpers.MdbCollection(models.ProfileBackgroundsTable).Find(bson.M{"name": name}).Count()
+	helpers.Relax(err)
+	return count > 0
+}
+
+// themeNameIsTaken also checks pending submissions, two submissions with the same name can't both be approved
+func (m *Levels) themeNameIsTaken(name string) bool {
+	if m.backgroundNameIsTaken(name) {
+		return true
+	}
+	count, err := helpers.MdbCollection(models.ProfileThemesTable).Find(bson.M{"name": name, "status": models.ProfileThemeStatusPending}).Count()
+	helpers.Relax(err)
+	return count > 0
+}
+
+// prepareThemeBackground downloads a background, fits is false if it is smaller than 400x300px, bigger backgrounds get scaled down
+func prepareThemeBackground(link string) (bytesData []byte, fits bool, err error) {
+	bytesData, err = helpers.NetGetUAWithErrorAndTimeout(link, helpers.DEFAULT_UA, time.Second*15)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(bytesData) > 2e+6 {
+		return nil, false, nil
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(bytesData))
+	if err != nil {
+		return nil, false, err
+	}
+	if imageConfig.Width < 400 || imageConfig.Height < 300 {
+		return nil, false, nil
+	}
+
+	if imageConfig.Width > 400 || imageConfig.Height > 300 {
+		bytesData, err = helpers.ScaleImage(bytesData, 400, 300)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return bytesData, true, nil
+}
+
+func notifyThemeSubmitter(theme models.ProfileThemeEntry, message string) {
+	defer helpers.Recover()
+
+	dmChannel, err := cache.GetSession().UserChannelCreate(theme.SubmittedByUserID)
+	if err != nil {
+		return
+	}
+	// users can have their DMs disabled
+	helpers.SendMessage(dmChannel.ID, message)
+}
+
+func (m *Levels) logThemeSubmitted(targetChannelID string, theme models.ProfileThemeEntry) (err error) {
+	author, err := helpers.GetUser(theme.SubmittedByUserID)
+	if err != nil {
+		return err
+	}
+
+	targetChannel, err := helpers.GetChannel(targetChannelID)
+	if err != nil {
+		return err
+	}
+
+	backgroundUrl, err := helpers.GetFileLink(theme.ObjectName)
+	if err != nil {
+		return err
+	}
+
+	_, err = helpers.SendEmbed(targetChannelID, &discordgo.MessageEmbed{
+		URL:   backgroundUrl,
+		Title: fmt.Sprintf("New %s submitted for review: %s 📥", theme.Kind, theme.Name),
+		Color: 0,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Safe search: " + theme.SafetyRatings,
+		},
+		Image: &discordgo.MessageEmbedImage{
+			URL: backgroundUrl,
+		},
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    author.Username + "#" + author.Discriminator + " (#" + author.ID + ")",
+			IconURL: author.AvatarURL("64"),
+		},
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Tags:",
+				Value:  strings.Join(theme.Tags, ", "),
+				Inline: false,
+			},
+			{
+				Name: "Approve the submission:",
+				Value: fmt.Sprintf("`%sprofile theme approve %s`",
+					helpers.GetPrefixForServer(targetChannel.GuildID),
+					helpers.MdbIdToHuman(theme.ID)),
+				Inline: false,
+			},
+			{
+				Name: "Reject the submission:",
+				Value: fmt.Sprintf("`%sprofile theme reject %s <reason>`",
+					helpers.GetPrefixForServer(targetChannel.GuildID),
+					helpers.MdbIdToHuman(theme.ID)),
+				Inline: false,
+			},
+		},
+	})
+	return err
+}
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
@@ -29,6 +30,17 @@ func (m *Mirror) Commands() []string {
 	}
 }
 
+const (
+	// messages per channel and window, messages above the limit are not mirrored
+	mirrorRateLimitMessages = 10
+	mirrorRateLimitWindow   = 10 * time.Second
+	// how long mirrored copies are remembered to propagate edits, deletes, and reactions
+	mirrorRememberDuration = 24 * time.Hour
+	// the total size of the attachments uploaded with a copy, the other attachments are posted as links
+	mirrorMaxUploadSize = 8 * 1024 * 1024
+	mirrorQuoteLength   = 100
+)
+
 var (
 	mirrors []models.MirrorEntry
 	// one lock for every channel ID
@@ -39,6 +51,8 @@ func (m *Mirror) Init(session *discordgo.Session) {
 	var err error
 	mirrors, err = m.GetMirrors()
 	helpers.Relax(err)
+
+	session.AddHandler(m.OnMessageUpdate)
 }
 
 func (m *Mirror) Uninit(session *discordgo.Session) {
@@ -236,8 +250,8 @@ func (m *Mirror) Action(command string, content string, msg *discordgo.Message,
 					case models.MirrorTypeText:
 						entryTypeText = "text"
 					}
-					resultMessage += fmt.Sprintf(":satellite: Mirror `%s` (Mode: `%s`, %d channels):\n",
-						helpers.MdbIdToHuman(entry.ID), entryTypeText, len(entry.ConnectedChannels))
+					resultMessage += fmt.Sprintf(":satellite: Mirror `%s` (Mode: `%s`, %d channels, %d blocked users, reactions: `%t`):\n",
+						helpers.MdbIdToHuman(entry.ID), entryTypeText, len(entry.ConnectedChannels), len(entry.BlockedUserIDs), entry.BridgeReactions)
 					for _, mirroredChannelEntry := range entry.ConnectedChannels {
 						mirroredChannel, err := helpers.GetChannel(mirroredChannelEntry.ChannelID)
 						if err != nil {
@@ -309,6 +323,130 @@ func (m *Mirror) Action(command string, content string, msg *discordgo.Message,
 				return
 			})
 			return
+		case "block": // [p]mirror block <mirror id> <user>, blocks or unblocks the user
+			session.ChannelTyping(msg.ChannelID)
+			helpers.RequireRobyulMod(msg, func() {
+				if len(args) < 3 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
+
+				var mirrorEntry models.MirrorEntry
+				err = helpers.MdbOne(
+					helpers.MdbCollection(models.MirrorsTable).Find(bson.M{"_id": helpers.HumanToMdbId(args[1])}),
+					&mirrorEntry,
+				)
+				if helpers.IsMdbNotFound(err) {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+				helpers.Relax(err)
+
+				targetUser, err := helpers.GetUserFromMention(args[2])
+				if err != nil || targetUser.ID == "" {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+
+				blockedUserIDsWithout := make([]string, 0)
+				for _, blockedUserID := range mirrorEntry.BlockedUserIDs {
+					if blockedUserID != targetUser.ID {
+						blockedUserIDsWithout = append(blockedUserIDsWithout, blockedUserID)
+					}
+				}
+
+				optionKey := "mirror_blockeduserids_added"
+				message := helpers.GetTextF("plugins.mirror.block-added", targetUser.Username)
+				if len(blockedUserIDsWithout) != len(mirrorEntry.BlockedUserIDs) {
+					mirrorEntry.BlockedUserIDs = blockedUserIDsWithout
+					optionKey = "mirror_blockeduserids_removed"
+					message = helpers.GetTextF("plugins.mirror.block-removed", targetUser.Username)
+				} else {
+					mirrorEntry.BlockedUserIDs = append(mirrorEntry.BlockedUserIDs, targetUser.ID)
+				}
+
+				err = helpers.MDbUpdate(models.MirrorsTable, mirrorEntry.ID, mirrorEntry)
+				helpers.Relax(err)
+
+				mirrors, err = m.GetMirrors()
+				helpers.Relax(err)
+
+				_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(mirrorEntry.ID),
+					models.EventlogTargetTypeRobyulMirror, msg.Author.ID,
+					models.EventlogTypeRobyulMirrorUpdate, "",
+					nil,
+					[]models.ElasticEventlogOption{
+						{
+							Key:   optionKey,
+							Value: targetUser.ID,
+							Type:  models.EventlogTargetTypeUser,
+						},
+					}, false)
+				helpers.RelaxLog(err)
+
+				_, err = helpers.SendMessage(msg.ChannelID, message)
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			})
+			return
+		case "reactions": // [p]mirror reactions <mirror id>
+			session.ChannelTyping(msg.ChannelID)
+			helpers.RequireRobyulMod(msg, func() {
+				if len(args) < 2 {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+					return
+				}
+
+				channel, err := helpers.GetChannel(msg.ChannelID)
+				helpers.Relax(err)
+
+				var mirrorEntry models.MirrorEntry
+				err = helpers.MdbOne(
+					helpers.MdbCollection(models.MirrorsTable).Find(bson.M{"_id": helpers.HumanToMdbId(args[1])}),
+					&mirrorEntry,
+				)
+				if helpers.IsMdbNotFound(err) {
+					helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+					return
+				}
+				helpers.Relax(err)
+
+				mirrorEntry.BridgeReactions = !mirrorEntry.BridgeReactions
+
+				err = helpers.MDbUpdate(models.MirrorsTable, mirrorEntry.ID, mirrorEntry)
+				helpers.Relax(err)
+
+				mirrors, err = m.GetMirrors()
+				helpers.Relax(err)
+
+				_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(mirrorEntry.ID),
+					models.EventlogTargetTypeRobyulMirror, msg.Author.ID,
+					models.EventlogTypeRobyulMirrorUpdate, "",
+					[]models.ElasticEventlogChange{
+						{
+							Key:      "mirror_bridgereactions",
+							OldValue: strconv.FormatBool(!mirrorEntry.BridgeReactions),
+							NewValue: strconv.FormatBool(mirrorEntry.BridgeReactions),
+						},
+					},
+					nil, false)
+				helpers.RelaxLog(err)
+
+				message := helpers.GetText("plugins.mirror.reactions-disabled")
+				if mirrorEntry.BridgeReactions {
+					message = helpers.GetText("plugins.mirror.reactions-enabled")
+					if mirrorEntry.Type != models.MirrorTypeText {
+						message += "\n" + helpers.GetText("plugins.mirror.reactions-text-only")
+					}
+				}
+				_, err = helpers.SendMessage(msg.ChannelID, message)
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			})
+			return
 		case "refresh": // [p]mirror refresh
 			session.ChannelTyping(msg.ChannelID)
 			helpers.RequireRobyulMod(msg, func() {
@@ -326,6 +464,9 @@ func (m *Mirror) Action(command string, content string, msg *discordgo.Message,
 }
 
 func (m *Mirror) OnMessage(content string, msg *discordgo.Message, session *discordgo.Session) {
+	// the message is rate limited and downloaded once, even if the channel is part of multiple mirrors
+	var counted bool
+	var attachments []mirrorAttachment
 TryNextMirror:
 	for _, mirrorEntry := range mirrors {
 		for _, mirroredChannelEntry := range mirrorEntry.ConnectedChannels {
@@ -334,6 +475,12 @@ TryNextMirror:
 				if msg.Author.Bot == true {
 					continue TryNextMirror
 				}
+				// ignore blocked users
+				for _, blockedUserID := range mirrorEntry.BlockedUserIDs {
+					if blockedUserID == msg.Author.ID {
+						continue TryNextMirror
+					}
+				}
 				sourceChannel, err := helpers.GetChannel(msg.ChannelID)
 				helpers.Relax(err)
 				// ignore commands
@@ -343,48 +490,70 @@ TryNextMirror:
 						return
 					}
 				}
-				var linksToRepost []string
-				// get mirror attachements
-				if len(msg.Attachments) > 0 {
-					for _, attachement := range msg.Attachments {
-						linksToRepost = append(linksToRepost, attachement.URL)
-					}
-				}
-				// get mirror links
-				if strings.Contains(msg.Content, "http") {
-					linksFound := galleryUrlRegex.FindAllString(msg.Content, -1)
-					if len(linksFound) > 0 {
-						for _, linkFound := range linksFound {
-							if strings.HasPrefix(linkFound, "<") == false && strings.HasSuffix(linkFound, ">") == false {
-								linksToRepost = append(linksToRepost, linkFound)
-							}
-						}
-					}
-				}
-				// get full content message
-				newContent := msg.Content
-				if len(msg.Attachments) > 0 {
-					for _, attachement := range msg.Attachments {
-						newContent += "\n" + attachement.URL
+				if !counted {
+					counted = true
+					// drop messages above the rate limit of the source channel
+					if !m.allowMessage(msg.ChannelID) {
+						cache.GetLogger().WithField("module", "mirror").WithField("channelID", msg.ChannelID).Warn(
+							"dropped message above the mirror rate limit")
+						return
 					}
+					// download attachments to reupload them
+					attachments = m.downloadAttachments(msg)
 				}
 				switch mirrorEntry.Type {
 				case models.MirrorTypeText:
-					m.postMirrorMessage(mirrorEntry, msg, msg.Author, newContent)
+					m.postMirrorMessage(mirrorEntry, msg, sourceChannel.GuildID, msg.Author, func(targetChannelID string) string {
+						return m.getTextContent(msg, targetChannelID)
+					}, attachments)
 					break
 				default:
-					// post mirror links
-					if len(linksToRepost) > 0 {
-						sourceGuild, err := helpers.GetGuild(sourceChannel.GuildID)
-						helpers.Relax(err)
-						for _, linkToRepost := range linksToRepost {
-							m.postMirrorMessage(mirrorEntry, msg, msg.Author,
-								fmt.Sprintf("posted %s in `#%s` on the `%s` server (<#%s>)",
-									linkToRepost, sourceChannel.Name, sourceGuild.Name, sourceChannel.ID,
-								),
-							)
+					var linksToRepost []string
+					// get mirror links
+					if strings.Contains(msg.Content, "http") {
+						linksFound := galleryUrlRegex.FindAllString(msg.Content, -1)
+						if len(linksFound) > 0 {
+							for _, linkFound := range linksFound {
+								if strings.HasPrefix(linkFound, "<") == false && strings.HasSuffix(linkFound, ">") == false {
+									linksToRepost = append(linksToRepost, linkFound)
+								}
+							}
+						}
+					}
+					// attachments we were unable to download are posted as links
+					for _, attachment := range msg.Attachments {
+						var downloaded bool
+						for _, downloadedAttachment := range attachments {
+							if downloadedAttachment.ID == attachment.ID {
+								downloaded = true
+							}
+						}
+						if !downloaded {
+							linksToRepost = append(linksToRepost, attachment.URL)
 						}
 					}
+					if len(linksToRepost) <= 0 && len(attachments) <= 0 {
+						break
+					}
+					sourceGuild, err := helpers.GetGuild(sourceChannel.GuildID)
+					helpers.Relax(err)
+					// post mirror attachments
+					for _, attachment := range attachments {
+						m.postMirrorMessage(mirrorEntry, msg, sourceChannel.GuildID, msg.Author, func(targetChannelID string) string {
+							return fmt.Sprintf("posted in `#%s` on the `%s` server (<#%s>)",
+								sourceChannel.Name, sourceGuild.Name, sourceChannel.ID,
+							)
+						}, []mirrorAttachment{attachment})
+					}
+					// post mirror links
+					for _, linkToRepost := range linksToRepost {
+						linkMessage := fmt.Sprintf("posted %s in `#%s` on the `%s` server (<#%s>)",
+							linkToRepost, sourceChannel.Name, sourceGuild.Name, sourceChannel.ID,
+						)
+						m.postMirrorMessage(mirrorEntry, msg, sourceChannel.GuildID, msg.Author, func(targetChannelID string) string {
+							return linkMessage
+						}, nil)
+					}
 					break
 				}
 			}
@@ -393,7 +562,175 @@ TryNextMirror:
 
 }
 
-func (m *Mirror) postMirrorMessage(mirrorEntry models.MirrorEntry, sourceMessage *discordgo.Message, author *discordgo.User, message string) {
+// OnMessageUpdate propagates edits of mirrored messages to all copies of text mirrors
+func (m *Mirror) OnMessageUpdate(session *discordgo.Session, msg *discordgo.MessageUpdate) {
+	// updates without author are embed updates
+	if msg.Author == nil || msg.Author.Bot {
+		return
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		for _, mirrorEntry := range mirrors {
+			if mirrorEntry.Type != models.MirrorTypeText || !m.hasChannel(mirrorEntry, msg.ChannelID) {
+				continue
+			}
+
+			m.updateMirroredMessages(mirrorEntry, msg.Message)
+		}
+	}()
+}
+
+type mirrorAttachment struct {
+	ID          string
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// downloadAttachments downloads the attachments of the message fitting into mirrorMaxUploadSize together
+func (m *Mirror) downloadAttachments(msg *discordgo.Message) (attachments []mirrorAttachment) {
+	attachments = make([]mirrorAttachment, 0)
+	uploadAttachmentIDs := helpers.MirrorUploadAttachmentIDs(msg.Attachments, mirrorMaxUploadSize)
+	for _, attachment := range msg.Attachments {
+		if !uploadAttachmentIDs[attachment.ID] {
+			continue
+		}
+
+		data, err := helpers.NetGetUAWithError(attachment.URL, helpers.DEFAULT_UA)
+		if err != nil {
+			cache.GetLogger().WithField("module", "mirror").WithField("url", attachment.URL).Warn(
+				"downloading attachment failed: " + err.Error())
+			continue
+		}
+
+		contentType, _ := helpers.SniffMime(data)
+		attachments = append(attachments, mirrorAttachment{
+			ID:          attachment.ID,
+			Filename:    attachment.Filename,
+			ContentType: contentType,
+			Data:        data,
+		})
+	}
+	return attachments
+}
+
+// getTextContent returns the content of a mirrored copy in the target channel
+// links to mirrored messages are replaced with the link to the copy in the target channel and the first linked message is quoted
+func (m *Mirror) getTextContent(msg *discordgo.Message, targetChannelID string) (content string) {
+	content = helpers.MirrorRewriteMessageLinks(msg.Content, func(messageID string) (link string, ok bool) {
+		return m.getMirroredMessageLink(messageID, targetChannelID)
+	})
+
+	uploadAttachmentIDs := helpers.MirrorUploadAttachmentIDs(msg.Attachments, mirrorMaxUploadSize)
+	for _, attachment := range msg.Attachments {
+		if !uploadAttachmentIDs[attachment.ID] {
+			content += "\n" + attachment.URL
+		}
+	}
+
+	if quote := m.getQuote(msg.Content); quote != "" {
+		content = quote + "\n" + content
+	}
+
+	return content
+}
+
+// getQuote returns a quote of the first mirrored message linked in the content
+func (m *Mirror) getQuote(content string) (quote string) {
+	parts := helpers.MirrorMessageLinkRegex.FindStringSubmatch(content)
+	if len(parts) < 2 {
+		return ""
+	}
+
+	source, err := m.getSourceMessage(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	quotedMessage, err := helpers.GetMessage(source.ChannelID, source.MessageID)
+	if err != nil || quotedMessage == nil || quotedMessage.Author == nil {
+		return ""
+	}
+
+	quotedContent := []rune(strings.Replace(quotedMessage.Content, "\n", " ", -1))
+	if len(quotedContent) > mirrorQuoteLength {
+		quotedContent = append(quotedContent[:mirrorQuoteLength], '…')
+	}
+
+	return fmt.Sprintf("> **%s**: %s", quotedMessage.Author.Username, string(quotedContent))
+}
+
+// getMirroredMessageLink returns the link to the copy of a mirrored message in the target channel
+func (m *Mirror) getMirroredMessageLink(messageID, targetChannelID string) (link string, ok bool) {
+	source, err := m.getSourceMessage(messageID)
+	if err != nil {
+		return "", false
+	}
+
+	if source.ChannelID == targetChannelID {
+		return helpers.GetMessageLink(source.GuildID, source.ChannelID, source.MessageID), true
+	}
+
+	rememberedMessages, err := m.getRememberedMessages(source.MessageID)
+	if err != nil {
+		return "", false
+	}
+	for _, rememberedMessage := range rememberedMessages {
+		if rememberedMessage.ChannelID == targetChannelID {
+			return helpers.GetMessageLink(rememberedMessage.GuildID, rememberedMessage.ChannelID, rememberedMessage.MessageID), true
+		}
+	}
+
+	return "", false
+}
+
+// updateMirroredMessages edits all copies of the source message to the current content and reactions
+func (m *Mirror) updateMirroredMessages(mirrorEntry models.MirrorEntry, sourceMessage *discordgo.Message) {
+	rememberedMessages, err := m.getRememberedMessages(sourceMessage.ID)
+	if err != nil {
+		helpers.RelaxLog(err)
+		return
+	}
+	if len(rememberedMessages) <= 0 {
+		return
+	}
+
+	var reactionsText string
+	if mirrorEntry.BridgeReactions {
+		reactionsText, err = m.getReactionsText(sourceMessage.ID)
+		helpers.RelaxLog(err)
+	}
+
+	for _, rememberedMessage := range rememberedMessages {
+		webhook, err := helpers.GetWebhook(rememberedMessage.GuildID, rememberedMessage.ChannelID)
+		// webhooks can only edit their own messages
+		if err != nil || webhook.ID != rememberedMessage.WebhookID {
+			continue
+		}
+
+		content := m.getTextContent(sourceMessage, rememberedMessage.ChannelID)
+		if reactionsText != "" {
+			content += "\n" + reactionsText
+		}
+
+		err = helpers.WebhookEditMessage(webhook.ID, webhook.Token, rememberedMessage.MessageID, &discordgo.WebhookParams{
+			Content: content,
+		})
+		if err != nil {
+			cache.GetLogger().WithFields(logrus.Fields{
+				"module":            "mirror",
+				"sourceChannelID":   sourceMessage.ChannelID,
+				"sourceMessageID":   sourceMessage.ID,
+				"mirroredChannelID": rememberedMessage.ChannelID,
+				"mirroredMessageID": rememberedMessage.MessageID,
+			}).Warn("Editing mirrored message failed: ", err.Error())
+		}
+	}
+}
+
+func (m *Mirror) postMirrorMessage(mirrorEntry models.MirrorEntry, sourceMessage *discordgo.Message, sourceGuildID string, author *discordgo.User, getContent func(targetChannelID string) string, attachments []mirrorAttachment) {
 	for _, channelToMirrorToEntry := range mirrorEntry.ConnectedChannels {
 		if channelToMirrorToEntry.ChannelID != sourceMessage.ChannelID {
 			robyulIsOnTargetGuild := false
@@ -407,22 +744,63 @@ func (m *Mirror) postMirrorMessage(mirrorEntry models.MirrorEntry, sourceMessage
 				if err != nil {
 					continue
 				}
-				result, err := helpers.WebhookExecuteWithResult(
+				files := make([]*discordgo.File, 0)
+				for _, attachment := range attachments {
+					files = append(files, &discordgo.File{
+						Name:        attachment.Filename,
+						ContentType: attachment.ContentType,
+						Reader:      bytes.NewReader(attachment.Data),
+					})
+				}
+				result, err := helpers.WebhookExecuteWithFilesWithResult(
 					webhook.ID, webhook.Token,
 					&discordgo.WebhookParams{
-						Content:   message,
+						Content:   getContent(channelToMirrorToEntry.ChannelID),
 						Username:  author.Username,
 						AvatarURL: helpers.GetAvatarUrl(author),
-					})
-				helpers.RelaxLog(err)
+					}, files)
+				if err != nil {
+					helpers.RelaxLog(err)
+					continue
+				}
 				metrics.MirrorsPostsSent.Add(1)
-				err = m.rememberPostedMessage(sourceMessage, result)
+				err = m.rememberPostedMessage(sourceMessage, sourceGuildID, Mirror_PostedMessage{
+					GuildID:   channelToMirrorToEntry.GuildID,
+					ChannelID: result.ChannelID,
+					MessageID: result.ID,
+					WebhookID: webhook.ID,
+				})
 				helpers.RelaxLog(err)
 			}
 		}
 	}
 }
 
+// allowMessage counts a message of the channel, returns false if the channel is above the rate limit
+func (m *Mirror) allowMessage(channelID string) (allowed bool) {
+	key := fmt.Sprintf(models.MirrorRateLimitRedisKey, channelID)
+
+	count, err := cache.GetRedisClient().Incr(key).Result()
+	if err != nil {
+		helpers.RelaxLog(err)
+		return true
+	}
+	if count == 1 {
+		cache.GetRedisClient().Expire(key, mirrorRateLimitWindow)
+	}
+
+	return count <= mirrorRateLimitMessages
+}
+
+func (m *Mirror) hasChannel(mirrorEntry models.MirrorEntry, channelID string) bool {
+	for _, mirroredChannelEntry := range mirrorEntry.ConnectedChannels {
+		if mirroredChannelEntry.ChannelID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Mirror) OnGuildMemberAdd(member *discordgo.Member, session *discordgo.Session) {
 }
 
@@ -435,23 +813,18 @@ func (m *Mirror) GetMirrors() (entryBucket []models.MirrorEntry, err error) {
 }
 
 type Mirror_PostedMessage struct {
+	GuildID   string
 	ChannelID string
 	MessageID string
+	WebhookID string
 }
 
-func (m *Mirror) getRememberedMessageKey(sourceMessageID string) (key string) {
-	return fmt.Sprintf("robyul2-discord:mirror:postedmessage:%s", sourceMessageID)
-}
-
-func (m *Mirror) rememberPostedMessage(sourceMessage *discordgo.Message, mirroredMessage *discordgo.Message) error {
+// rememberPostedMessage remembers the mirrored copy of the source message, and the source message of the copy
+func (m *Mirror) rememberPostedMessage(sourceMessage *discordgo.Message, sourceGuildID string, mirroredMessage Mirror_PostedMessage) error {
 	redis := cache.GetRedisClient()
-	key := m.getRememberedMessageKey(sourceMessage.ID)
-
-	item := new(Mirror_PostedMessage)
-	item.ChannelID = mirroredMessage.ChannelID
-	item.MessageID = mirroredMessage.ID
+	key := fmt.Sprintf(models.MirrorPostedMessagesRedisKey, sourceMessage.ID)
 
-	itemBytes, err := msgpack.Marshal(&item)
+	itemBytes, err := msgpack.Marshal(&mirroredMessage)
 	if err != nil {
 		return err
 	}
@@ -461,13 +834,34 @@ func (m *Mirror) rememberPostedMessage(sourceMessage *discordgo.Message, mirrore
 		return err
 	}
 
-	_, err = redis.Expire(key, time.Hour*1).Result()
-	return err
+	_, err = redis.Expire(key, mirrorRememberDuration).Result()
+	if err != nil {
+		return err
+	}
+
+	sourceBytes, err := msgpack.Marshal(&Mirror_PostedMessage{
+		GuildID:   sourceGuildID,
+		ChannelID: sourceMessage.ChannelID,
+		MessageID: sourceMessage.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the source message points to itself, so links to the source and to the copies can be resolved the same way
+	for _, messageID := range []string{sourceMessage.ID, mirroredMessage.MessageID} {
+		_, err = redis.Set(fmt.Sprintf(models.MirrorSourceMessageRedisKey, messageID), sourceBytes, mirrorRememberDuration).Result()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (m *Mirror) getRememberedMessages(sourceMessage *discordgo.Message) ([]Mirror_PostedMessage, error) {
+func (m *Mirror) getRememberedMessages(sourceMessageID string) ([]Mirror_PostedMessage, error) {
 	redis := cache.GetRedisClient()
-	key := m.getRememberedMessageKey(sourceMessage.ID)
+	key := fmt.Sprintf(models.MirrorPostedMessagesRedisKey, sourceMessageID)
 
 	length, err := redis.LLen(key).Result()
 	if err != nil {
@@ -496,11 +890,74 @@ func (m *Mirror) getRememberedMessages(sourceMessage *discordgo.Message) ([]Mirr
 	return rememberedMessages, nil
 }
 
+// getSourceMessage returns the source message of a mirrored copy or of a source message itself
+func (m *Mirror) getSourceMessage(messageID string) (source Mirror_PostedMessage, err error) {
+	sourceBytes, err := cache.GetRedisClient().Get(fmt.Sprintf(models.MirrorSourceMessageRedisKey, messageID)).Bytes()
+	if err != nil {
+		return source, err
+	}
+
+	err = msgpack.Unmarshal(sourceBytes, &source)
+	return source, err
+}
+
+func (m *Mirror) getReactionsText(sourceMessageID string) (text string, err error) {
+	result, err := cache.GetRedisClient().HGetAll(fmt.Sprintf(models.MirrorReactionsRedisKey, sourceMessageID)).Result()
+	if err != nil {
+		return "", err
+	}
+
+	counts := make(map[string]int)
+	for emoji, countText := range result {
+		counts[emoji], _ = strconv.Atoi(countText)
+	}
+
+	return helpers.MirrorFormatReactions(counts), nil
+}
+
+// bridgeReaction counts a reaction on a source message or one of its copies and updates the counts below the copies
+func (m *Mirror) bridgeReaction(messageID string, emoji discordgo.Emoji, change int64) {
+	source, err := m.getSourceMessage(messageID)
+	if err != nil {
+		return
+	}
+
+	for _, mirrorEntry := range mirrors {
+		if !mirrorEntry.BridgeReactions || mirrorEntry.Type != models.MirrorTypeText || !m.hasChannel(mirrorEntry, source.ChannelID) {
+			continue
+		}
+
+		key := fmt.Sprintf(models.MirrorReactionsRedisKey, source.MessageID)
+		_, err = cache.GetRedisClient().HIncrBy(key, emoji.APIName(), change).Result()
+		if err != nil {
+			helpers.RelaxLog(err)
+			return
+		}
+		cache.GetRedisClient().Expire(key, mirrorRememberDuration)
+
+		sourceMessage, err := helpers.GetMessage(source.ChannelID, source.MessageID)
+		if err != nil {
+			return
+		}
+
+		m.updateMirroredMessages(mirrorEntry, sourceMessage)
+		return
+	}
+}
+
 func (m *Mirror) OnReactionAdd(reaction *discordgo.MessageReactionAdd, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
 
+		m.bridgeReaction(reaction.MessageID, reaction.Emoji, 1)
+	}()
 }
 func (m *Mirror) OnReactionRemove(reaction *discordgo.MessageReactionRemove, session *discordgo.Session) {
+	go func() {
+		defer helpers.Recover()
 
+		m.bridgeReaction(reaction.MessageID, reaction.Emoji, -1)
+	}()
 }
 func (m *Mirror) OnGuildBanAdd(user *discordgo.GuildBanAdd, session *discordgo.Session) {
 
@@ -515,28 +972,37 @@ func (m *Mirror) OnMessageDelete(msg *discordgo.MessageDelete, session *discordg
 		var rememberedMessages []Mirror_PostedMessage
 
 		for _, mirror := range mirrors {
-			for _, mirrorChannel := range mirror.ConnectedChannels {
-				if mirrorChannel.ChannelID == msg.ChannelID {
-					rememberedMessages, err = m.getRememberedMessages(msg.Message)
-					helpers.Relax(err)
+			if !m.hasChannel(mirror, msg.ChannelID) {
+				continue
+			}
 
-					for _, messageData := range rememberedMessages {
-						err = session.ChannelMessageDelete(messageData.ChannelID, messageData.MessageID)
-						if err != nil {
-							cache.GetLogger().WithFields(logrus.Fields{
-								"module":            "mirror",
-								"sourceChannelID":   msg.ChannelID,
-								"sourceMessageID":   msg.ID,
-								"sourceAuthorID":    msg.Author.ID,
-								"mirroredChannelID": messageData.ChannelID,
-								"mirroredMessageID": messageData.MessageID,
-							}).Warn(
-								"Deleting mirrored message failed:", err.Error(),
-							)
-						}
-					}
+			rememberedMessages, err = m.getRememberedMessages(msg.ID)
+			helpers.Relax(err)
+
+			for _, messageData := range rememberedMessages {
+				err = session.ChannelMessageDelete(messageData.ChannelID, messageData.MessageID)
+				if err != nil {
+					cache.GetLogger().WithFields(logrus.Fields{
+						"module":            "mirror",
+						"sourceChannelID":   msg.ChannelID,
+						"sourceMessageID":   msg.ID,
+						"mirroredChannelID": messageData.ChannelID,
+						"mirroredMessageID": messageData.MessageID,
+					}).Warn(
+						"Deleting mirrored message failed:", err.Error(),
+					)
 				}
+				cache.GetRedisClient().Del(fmt.Sprintf(models.MirrorSourceMessageRedisKey, messageData.MessageID))
 			}
+
+			if len(rememberedMessages) > 0 {
+				cache.GetRedisClient().Del(
+					fmt.Sprintf(models.MirrorPostedMessagesRedisKey, msg.ID),
+					fmt.Sprintf(models.MirrorSourceMessageRedisKey, msg.ID),
+					fmt.Sprintf(models.MirrorReactionsRedisKey, msg.ID),
+				)
+			}
+			return
 		}
 	}()
 }
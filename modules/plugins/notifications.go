@@ -21,6 +21,7 @@ type Notifications struct{}
 
 var (
 	notificationSettingsCache      []models.NotificationsEntry
+	notificationExpressionsCache   = make(map[bson.ObjectId]*regexp.Regexp)
 	ignoredChannelsCache           []models.NotificationsIgnoredChannelsEntry
 	ValidTextDelimiters            = []string{" ", ".", ",", "?", "!", ";", "(", ")", "=", "\"", "'", "`", "´", "_", "~", "+", "-", "/", ":", "*", "\n", "…", "’", "“"}
 	NotificationsWhitelistedBotIDs = []string{
@@ -39,7 +40,12 @@ var (
 )
 
 const (
-	UserConfigNotificationsLayoutModeKey = "notifications:layout-mode"
+	UserConfigNotificationsLayoutModeKey     = "notifications:layout-mode"
+	UserConfigNotificationsDigestIntervalKey = "notifications:digest-interval"
+	UserConfigNotificationsSnoozeUntilKey    = "notifications:snooze-until"
+	UserConfigNotificationsQuietHoursKey     = "notifications:quiet-hours"
+
+	notificationsDigestContentLength = 200
 )
 
 func (m *Notifications) Commands() []string {
@@ -59,6 +65,7 @@ func (m *Notifications) Init(session *discordgo.Session) {
 		err := m.refreshNotificationSettingsCache()
 		helpers.RelaxLog(err)
 	}()
+	go m.digestLoop()
 }
 
 func (m *Notifications) Uninit(session *discordgo.Session) {
@@ -73,7 +80,7 @@ func (m *Notifications) Action(command string, content string, msg *discordgo.Me
 	args := strings.Fields(content)
 	if len(args) > 0 {
 		switch args[0] {
-		case "add": // [p]notifications add <keyword(s)>
+		case "add": // [p]notifications add [global] [mode=word|regex] [channel=<#channel>] <keyword(s)>
 			if len(args) < 2 {
 				helpers.SendMessage(msg.ChannelID, helpers.GetTextF("bot.arguments.too-few"))
 				return
@@ -90,6 +97,63 @@ func (m *Notifications) Action(command string, content string, msg *discordgo.Me
 				keywordGuild = "global"
 			}
 
+			// parse options in front of the keyword
+			matchMode := models.NotificationsMatchModeDefault
+			channelIDs := make([]string, 0)
+		OptionsLoop:
+			for {
+				fields := strings.Fields(keywords)
+				if len(fields) <= 1 {
+					break
+				}
+				optionParts := strings.SplitN(fields[0], "=", 2)
+				if len(optionParts) < 2 {
+					break
+				}
+
+				switch strings.ToLower(optionParts[0]) {
+				case "mode":
+					switch strings.ToLower(optionParts[1]) {
+					case models.NotificationsMatchModeWord, models.NotificationsMatchModeRegex:
+						matchMode = strings.ToLower(optionParts[1])
+					default:
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.keyword-add-invalid-option", msg.Author.ID, fields[0]))
+						return
+					}
+				case "channel":
+					targetChannel, err := helpers.GetChannelOrCategoryFromMention(msg, optionParts[1])
+					if err != nil || targetChannel.GuildID != guild.ID {
+						helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.keyword-add-invalid-option", msg.Author.ID, fields[0]))
+						return
+					}
+					channelIDs = append(channelIDs, targetChannel.ID)
+				default:
+					// not an option, the keyword starts here
+					break OptionsLoop
+				}
+
+				keywords = strings.TrimSpace(strings.TrimPrefix(keywords, fields[0]))
+			}
+
+			if keywordGuild == "global" && len(channelIDs) > 0 {
+				helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.keyword-add-error-global-channels", msg.Author.ID))
+				return
+			}
+			if _, err = helpers.CompileNotificationKeyword(keywords, matchMode); err != nil {
+				helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.keyword-add-invalid-regex", msg.Author.ID, helpers.NotificationsRegexMaxLength))
+				return
+			}
+			if matchMode == models.NotificationsMatchModeRegex {
+				regexCount, err := helpers.MdbCount(models.NotificationsTable, bson.M{
+					"userid": msg.Author.ID, "matchmode": models.NotificationsMatchModeRegex,
+				})
+				helpers.Relax(err)
+				if regexCount >= helpers.NotificationsRegexPerUser {
+					helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.keyword-add-regex-too-many", msg.Author.ID, helpers.NotificationsRegexPerUser))
+					return
+				}
+			}
+
 			var entryBucket models.NotificationsEntry
 			err = helpers.MdbOne(
 				helpers.MdbCollection(models.NotificationsTable).Find(
@@ -114,9 +178,11 @@ func (m *Notifications) Action(command string, content string, msg *discordgo.Me
 				models.NotificationsTable,
 				bson.M{"userid": msg.Author.ID, "guildid": keywordGuild, "keyword": keywords},
 				models.NotificationsEntry{
-					Keyword: keywords,
-					GuildID: keywordGuild,
-					UserID:  msg.Author.ID,
+					Keyword:    keywords,
+					GuildID:    keywordGuild,
+					UserID:     msg.Author.ID,
+					MatchMode:  matchMode,
+					ChannelIDs: channelIDs,
 				},
 			)
 			helpers.Relax(err)
@@ -200,6 +266,15 @@ func (m *Notifications) Action(command string, content string, msg *discordgo.Me
 				if entry.GuildID == "global" {
 					resultMessage += " `[Global Keyword]` :globe_with_meridians:"
 				}
+				switch entry.MatchMode {
+				case models.NotificationsMatchModeWord:
+					resultMessage += " `[Whole Word]`"
+				case models.NotificationsMatchModeRegex:
+					resultMessage += " `[Regex]`"
+				}
+				if len(entry.ChannelIDs) > 0 {
+					resultMessage += " in <#" + strings.Join(entry.ChannelIDs, ">, <#") + ">"
+				}
 				resultMessage += "\n"
 			}
 			resultMessage += fmt.Sprintf("Found **%d** Keywords in total.", len(entryBucket))
@@ -384,6 +459,112 @@ func (m *Notifications) Action(command string, content string, msg *discordgo.Me
 					}()
 				})
 			}
+		case "snooze": // [p]notifications snooze [<duration>|off]
+			session.ChannelTyping(msg.ChannelID)
+
+			if len(args) < 2 {
+				snoozeUntil := time.Unix(int64(helpers.GetUserConfigInt(msg.Author.ID, UserConfigNotificationsSnoozeUntilKey, 0)), 0)
+				if !snoozeUntil.After(time.Now()) {
+					_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.notifications.snooze-status-none"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.snooze-status",
+					helpers.HumanizeDuration(time.Until(snoozeUntil))))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			var duration time.Duration
+			if strings.ToLower(args[1]) != "off" {
+				var err error
+				duration, err = time.ParseDuration(args[1])
+				if err != nil || duration < time.Minute || duration > helpers.NotificationsSnoozeMax {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.notifications.snooze-invalid"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+			}
+
+			var snoozeUntil int
+			message := helpers.GetText("plugins.notifications.snooze-removed")
+			if duration > 0 {
+				snoozeUntil = int(time.Now().Add(duration).Unix())
+				message = helpers.GetTextF("plugins.notifications.snooze-set", helpers.HumanizeDuration(duration))
+			}
+
+			err := helpers.SetUserConfigInt(msg.Author.ID, UserConfigNotificationsSnoozeUntilKey, snoozeUntil)
+			helpers.Relax(err)
+
+			_, err = helpers.SendMessage(msg.ChannelID, message)
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		case "quiet-hours", "quiethours": // [p]notifications quiet-hours [<start hour>-<end hour>|off]
+			session.ChannelTyping(msg.ChannelID)
+
+			userdata, err := helpers.GetUserUserdata(msg.Author.ID)
+			helpers.Relax(err)
+			location := helpers.GetUserLocation(userdata)
+
+			if len(args) < 2 {
+				start, end, ok := helpers.ParseQuietHours(helpers.GetUserConfigString(msg.Author.ID, UserConfigNotificationsQuietHoursKey, ""))
+				message := helpers.GetText("plugins.notifications.quiet-hours-status-none")
+				if ok {
+					message = helpers.GetTextF("plugins.notifications.quiet-hours-status", start, end, location.String())
+				}
+				_, err = helpers.SendMessage(msg.ChannelID, message)
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			var quietHours string
+			message := helpers.GetText("plugins.notifications.quiet-hours-removed")
+			if strings.ToLower(args[1]) != "off" {
+				start, end, ok := helpers.ParseQuietHours(args[1])
+				if !ok {
+					_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.notifications.quiet-hours-invalid"))
+					helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+					return
+				}
+				quietHours = fmt.Sprintf("%d-%d", start, end)
+				message = helpers.GetTextF("plugins.notifications.quiet-hours-set", start, end, location.String())
+			}
+
+			err = helpers.SetUserConfigString(msg.Author.ID, UserConfigNotificationsQuietHoursKey, quietHours)
+			helpers.Relax(err)
+
+			_, err = helpers.SendMessage(msg.ChannelID, message)
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+		case "digest": // [p]notifications digest [<interval>|off]
+			session.ChannelTyping(msg.ChannelID)
+
+			if len(args) < 2 {
+				interval := helpers.GetUserConfigInt(msg.Author.ID, UserConfigNotificationsDigestIntervalKey, 0)
+				message := helpers.GetText("plugins.notifications.digest-status-none")
+				if interval > 0 {
+					message = helpers.GetTextF("plugins.notifications.digest-status", interval)
+				}
+				_, err := helpers.SendMessage(msg.ChannelID, message)
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			interval, ok := helpers.ParseDigestInterval(args[1])
+			if !ok {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.notifications.digest-invalid",
+					helpers.NotificationsDigestMinInterval, helpers.NotificationsDigestMaxInterval))
+				helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+				return
+			}
+
+			err := helpers.SetUserConfigInt(msg.Author.ID, UserConfigNotificationsDigestIntervalKey, interval)
+			helpers.Relax(err)
+
+			message := helpers.GetText("plugins.notifications.digest-disabled")
+			if interval > 0 {
+				message = helpers.GetTextF("plugins.notifications.digest-enabled", interval)
+			}
+			_, err = helpers.SendMessage(msg.ChannelID, message)
+			helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
 		case "toggle-mode", "toggle-modes":
 			session.ChannelTyping(msg.ChannelID)
 
@@ -529,25 +710,24 @@ NextKeyword:
 				}
 			}
 
-			matchContent := strings.ToLower(strings.TrimSpace(msg.Content))
-			doesMatch := false
-			for _, combination := range m.getAllDelimiterCombinations(ValidTextDelimiters) {
-				if strings.Contains(matchContent, strings.ToLower(combination.Start+notificationSetting.Keyword+combination.End)) {
-					doesMatch = true
-				}
-			}
-			for _, delimiter := range ValidTextDelimiters {
-				if strings.HasPrefix(matchContent, strings.ToLower(notificationSetting.Keyword+delimiter)) {
-					doesMatch = true
+			// ignore messages outside of the channels or categories the keyword is scoped to
+			if len(notificationSetting.ChannelIDs) > 0 {
+				var inScope bool
+				for _, scopedChannelID := range notificationSetting.ChannelIDs {
+					if scopedChannelID == channel.ID || scopedChannelID == channel.ParentID {
+						inScope = true
+					}
 				}
-			}
-			for _, delimiter := range ValidTextDelimiters {
-				if strings.HasSuffix(matchContent, strings.ToLower(delimiter+notificationSetting.Keyword)) {
-					doesMatch = true
+				if !inScope {
+					continue NextKeyword
 				}
 			}
-			if matchContent == strings.ToLower(notificationSetting.Keyword) {
-				doesMatch = true
+
+			var doesMatch bool
+			if notificationSetting.MatchMode != models.NotificationsMatchModeDefault {
+				doesMatch = helpers.NotificationKeywordMatches(notificationExpressionsCache[notificationSetting.ID], msg.Content)
+			} else {
+				doesMatch = m.keywordMatches(notificationSetting.Keyword, msg.Content)
 			}
 			if doesMatch == true {
 				memberToNotify, err := helpers.GetGuildMemberWithoutApi(guild.ID, notificationSetting.UserID)
@@ -713,20 +893,16 @@ NextKeyword:
 			continue
 		}
 
+		if m.deferNotification(pendingNotification, msg.Message, guild.ID, messageTime) {
+			continue
+		}
+
 		dmChannel, err := session.UserChannelCreate(pendingNotification.Member.User.ID)
 		if err != nil {
 			cache.GetLogger().WithField("module", "notifications").WithField("channelID", channel.ID).WithField("userID", pendingNotification.Member.User.ID).Warn("error creating DM channel: " + err.Error())
 			continue
 		}
-		keywordsTriggeredText := ""
-		for i, keyword := range pendingNotification.Keywords {
-			keywordsTriggeredText += fmt.Sprintf("`%s`", keyword)
-			if i+2 < len(pendingNotification.Keywords) {
-				keywordsTriggeredText += ", "
-			} else if (len(pendingNotification.Keywords) - (i + 1)) > 0 {
-				keywordsTriggeredText += " and "
-			}
-		}
+		keywordsTriggeredText := m.getKeywordsText(pendingNotification.Keywords)
 
 		if pendingNotification.Author == nil {
 			cache.GetLogger().WithField("module", "notifications").WithField("channelID", channel.ID).Warn("notification source member is nil")
@@ -818,6 +994,166 @@ NextKeyword:
 	}
 }
 
+// getKeywordsText returns the keywords as a list like "`a`, `b` and `c`"
+func (m *Notifications) getKeywordsText(keywords []string) (keywordsText string) {
+	for i, keyword := range keywords {
+		keywordsText += fmt.Sprintf("`%s`", keyword)
+		if i+2 < len(keywords) {
+			keywordsText += ", "
+		} else if (len(keywords) - (i + 1)) > 0 {
+			keywordsText += " and "
+		}
+	}
+	return keywordsText
+}
+
+// deferNotification returns true if the notification should not be sent right now
+// notifications of snoozed users are dropped, during quiet hours, in digest mode, or above the hourly cap they are queued for the next digest
+func (m *Notifications) deferNotification(pendingNotification PendingNotification, msg *discordgo.Message, guildID string, messageTime time.Time) (deferred bool) {
+	userID := pendingNotification.Member.User.ID
+
+	if m.isSnoozed(userID) {
+		return true
+	}
+
+	if helpers.GetUserConfigInt(userID, UserConfigNotificationsDigestIntervalKey, 0) <= 0 &&
+		!m.inQuietHours(userID) &&
+		helpers.NotificationsAllowImmediate(userID) {
+		return false
+	}
+
+	queuedEntries, err := helpers.MdbCount(models.NotificationsDigestTable, bson.M{"userid": userID})
+	if err != nil {
+		helpers.RelaxLog(err)
+		return true
+	}
+	if queuedEntries >= helpers.NotificationsDigestMaxEntries {
+		return true
+	}
+
+	_, err = helpers.MDbInsertWithoutLogging(models.NotificationsDigestTable, models.NotificationsDigestEntry{
+		UserID:     userID,
+		GuildID:    guildID,
+		ChannelID:  msg.ChannelID,
+		MessageID:  msg.ID,
+		AuthorID:   msg.Author.ID,
+		AuthorName: msg.Author.Username,
+		Keywords:   pendingNotification.Keywords,
+		Content:    helpers.ReplaceEmojis(msg.Content),
+		CreatedAt:  messageTime,
+	})
+	helpers.RelaxLog(err)
+	return true
+}
+
+func (m *Notifications) isSnoozed(userID string) bool {
+	return int64(helpers.GetUserConfigInt(userID, UserConfigNotificationsSnoozeUntilKey, 0)) > time.Now().Unix()
+}
+
+// inQuietHours returns true during the quiet hours of the user, in the timezone set in the profile of the user
+func (m *Notifications) inQuietHours(userID string) bool {
+	start, end, ok := helpers.ParseQuietHours(helpers.GetUserConfigString(userID, UserConfigNotificationsQuietHoursKey, ""))
+	if !ok {
+		return false
+	}
+
+	userdata, err := helpers.GetUserUserdata(userID)
+	if err != nil {
+		helpers.RelaxLog(err)
+	}
+
+	return helpers.InQuietHours(start, end, time.Now().In(helpers.GetUserLocation(userdata)))
+}
+
+// digestLoop sends the queued matches of every user once the digest interval of the user passed
+func (m *Notifications) digestLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			cache.GetLogger().WithField("module", "notifications").Error("the digestLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.digestLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(1 * time.Minute)
+
+		var entryBucket []models.NotificationsDigestEntry
+		err := helpers.MDbIterWithoutLogging(
+			helpers.MdbCollection(models.NotificationsDigestTable).Find(nil).Sort("createdat"),
+		).All(&entryBucket)
+		helpers.Relax(err)
+
+		entriesByUser := make(map[string][]models.NotificationsDigestEntry)
+		for _, entry := range entryBucket {
+			entriesByUser[entry.UserID] = append(entriesByUser[entry.UserID], entry)
+		}
+
+		for userID, entries := range entriesByUser {
+			interval := helpers.GetUserConfigInt(userID, UserConfigNotificationsDigestIntervalKey, 0)
+			if interval <= 0 {
+				interval = helpers.NotificationsDigestDefaultInterval
+			}
+			if entries[0].CreatedAt.Add(time.Duration(interval) * time.Minute).After(time.Now()) {
+				continue
+			}
+			if m.isSnoozed(userID) || m.inQuietHours(userID) {
+				continue
+			}
+
+			err = m.sendDigest(userID, entries)
+			if err != nil {
+				cache.GetLogger().WithField("module", "notifications").WithField("userID", userID).Warn("error sending digest: " + err.Error())
+			}
+
+			// entries are removed even if sending failed, users with closed DMs would never receive them anyway
+			entryIDs := make([]bson.ObjectId, 0)
+			for _, entry := range entries {
+				entryIDs = append(entryIDs, entry.ID)
+			}
+			_, err = helpers.MdbCollection(models.NotificationsDigestTable).RemoveAll(bson.M{"_id": bson.M{"$in": entryIDs}})
+			helpers.RelaxLog(err)
+		}
+	}
+}
+
+func (m *Notifications) sendDigest(userID string, entries []models.NotificationsDigestEntry) (err error) {
+	dmChannel, err := cache.GetSession().UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+
+	digestText := helpers.GetTextF("plugins.notifications.digest-title", len(entries)) + "\n"
+	for _, entry := range entries {
+		guildName := entry.GuildID
+		guild, err := helpers.GetGuildWithoutApi(entry.GuildID)
+		if err == nil {
+			guildName = guild.Name
+		}
+
+		content := []rune(entry.Content)
+		if len(content) > notificationsDigestContentLength {
+			content = append(content[:notificationsDigestContentLength], '…')
+		}
+
+		digestText += helpers.GetTextF("plugins.notifications.digest-entry",
+			entry.AuthorName, m.getKeywordsText(entry.Keywords), entry.ChannelID, guildName,
+			entry.CreatedAt.UTC().Format("15:04:05"), helpers.GetMessageLink(entry.GuildID, entry.ChannelID, entry.MessageID),
+			string(content)) + "\n"
+	}
+
+	for _, page := range helpers.Pagify(digestText, "\n") {
+		_, err = helpers.SendMessage(dmChannel.ID, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	metrics.KeywordNotificationsSentCount.Add(1)
+	return nil
+}
+
 func (m *Notifications) refreshNotificationSettingsCache() (err error) {
 	err = helpers.MDbIter(helpers.MdbCollection(models.NotificationsTable).Find(nil)).All(&notificationSettingsCache)
 	if err != nil {
@@ -828,11 +1164,42 @@ func (m *Notifications) refreshNotificationSettingsCache() (err error) {
 		return err
 	}
 
+	newExpressionsCache := make(map[bson.ObjectId]*regexp.Regexp)
+	for _, notificationSetting := range notificationSettingsCache {
+		if notificationSetting.MatchMode == models.NotificationsMatchModeDefault {
+			continue
+		}
+		expression, err := helpers.CompileNotificationKeyword(notificationSetting.Keyword, notificationSetting.MatchMode)
+		if err != nil {
+			cache.GetLogger().WithField("module", "notifications").WithField("notificationID", helpers.MdbIdToHuman(notificationSetting.ID)).Warn("error compiling keyword: " + err.Error())
+			continue
+		}
+		newExpressionsCache[notificationSetting.ID] = expression
+	}
+	notificationExpressionsCache = newExpressionsCache
+
 	cache.GetLogger().WithField("module", "notifications").Info(fmt.Sprintf("Refreshed Notification Settings Cache: Got %d keywords and %d ignored channels",
 		len(notificationSettingsCache), len(ignoredChannelsCache)))
 	return nil
 }
 
+// keywordMatches matches keywords in the default mode, the keyword has to be surrounded by ValidTextDelimiters
+func (m *Notifications) keywordMatches(keyword, content string) (doesMatch bool) {
+	matchContent := strings.ToLower(strings.TrimSpace(content))
+	keyword = strings.ToLower(keyword)
+	for _, combination := range m.getAllDelimiterCombinations(ValidTextDelimiters) {
+		if strings.Contains(matchContent, combination.Start+keyword+combination.End) {
+			return true
+		}
+	}
+	for _, delimiter := range ValidTextDelimiters {
+		if strings.HasPrefix(matchContent, keyword+delimiter) || strings.HasSuffix(matchContent, delimiter+keyword) {
+			return true
+		}
+	}
+	return matchContent == keyword
+}
+
 type delimiterCombination struct {
 	Start string
 	End   string
@@ -0,0 +1,297 @@
+package plugins
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+type outgoingWebhooksAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next outgoingWebhooksAction)
+
+type OutgoingWebhooks struct{}
+
+const (
+	outgoingWebhooksDeliveriesShown = 10
+)
+
+func (m *OutgoingWebhooks) Commands() []string {
+	return []string{
+		"outgoing-webhook",
+		"outgoing-webhooks",
+	}
+}
+
+func (m *OutgoingWebhooks) Init(session *discordgo.Session) {
+}
+
+func (m *OutgoingWebhooks) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := m.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (m *OutgoingWebhooks) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) outgoingWebhooksAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if !helpers.IsAdmin(in) {
+		*out = m.newMsg("admin.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 1 {
+		return m.actionList
+	}
+
+	switch args[0] {
+	case "add", "create":
+		return m.actionAdd
+	case "list":
+		return m.actionList
+	case "remove", "delete":
+		return m.actionRemove
+	case "deliveries", "log":
+		return m.actionDeliveries
+	}
+
+	*out = m.newMsg("bot.arguments.invalid")
+	return m.actionFinish
+}
+
+// [p]outgoing-webhook add <https url> <event types, comma separated>
+func (m *OutgoingWebhooks) actionAdd(args []string, in *discordgo.Message, out **discordgo.MessageSend) outgoingWebhooksAction {
+	if len(args) < 3 {
+		*out = m.newMsg("plugins.outgoingwebhooks.add-help", strings.Join(models.OutgoingWebhookEvents, "`, `"))
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	webhookURL := strings.Trim(args[1], "<>")
+	if helpers.ValidateOutgoingWebhookURL(webhookURL) != nil {
+		*out = m.newMsg("plugins.outgoingwebhooks.add-invalid-url")
+		return m.actionFinish
+	}
+
+	eventTypes, ok := helpers.ParseOutgoingWebhookEvents(args[2])
+	if !ok {
+		*out = m.newMsg("plugins.outgoingwebhooks.add-invalid-events", strings.Join(models.OutgoingWebhookEvents, "`, `"))
+		return m.actionFinish
+	}
+
+	existingWebhooks, err := helpers.MdbCount(models.OutgoingWebhooksTable, bson.M{"guildid": channel.GuildID})
+	helpers.Relax(err)
+	if existingWebhooks >= helpers.OutgoingWebhooksPerGuild {
+		*out = m.newMsg("plugins.outgoingwebhooks.add-too-many", helpers.OutgoingWebhooksPerGuild)
+		return m.actionFinish
+	}
+
+	secret, err := helpers.NewOutgoingWebhookSecret()
+	helpers.Relax(err)
+
+	// the secret is only sent by DM, never to the channel
+	dmChannel, err := cache.GetSession().UserChannelCreate(in.Author.ID)
+	if err != nil {
+		*out = m.newMsg("plugins.outgoingwebhooks.add-dm-failed")
+		return m.actionFinish
+	}
+
+	newEntry := models.OutgoingWebhookEntry{
+		ID:              bson.NewObjectId(),
+		GuildID:         channel.GuildID,
+		URL:             webhookURL,
+		Secret:          secret,
+		EventTypes:      eventTypes,
+		CreatedByUserID: in.Author.ID,
+		CreatedAt:       time.Now(),
+	}
+
+	_, err = helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.outgoingwebhooks.add-dm",
+		newEntry.URL, channel.GuildID, strings.Join(eventTypes, "`, `"), secret))
+	if err != nil {
+		*out = m.newMsg("plugins.outgoingwebhooks.add-dm-failed")
+		return m.actionFinish
+	}
+
+	_, err = helpers.MDbInsert(models.OutgoingWebhooksTable, newEntry)
+	helpers.Relax(err)
+	helpers.OutgoingWebhooksCacheReset(channel.GuildID)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(newEntry.ID),
+		models.EventlogTargetTypeRobyulOutgoingWebhook, in.Author.ID,
+		models.EventlogTypeRobyulOutgoingWebhookAdd, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "outgoingwebhook_url",
+				Value: newEntry.URL,
+			},
+			{
+				Key:   "outgoingwebhook_events",
+				Value: strings.Join(newEntry.EventTypes, ","),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.outgoingwebhooks.add-success", helpers.MdbIdToHuman(newEntry.ID))
+	return m.actionFinish
+}
+
+// [p]outgoing-webhook list
+func (m *OutgoingWebhooks) actionList(args []string, in *discordgo.Message, out **discordgo.MessageSend) outgoingWebhooksAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var entryBucket []models.OutgoingWebhookEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.OutgoingWebhooksTable).Find(bson.M{"guildid": channel.GuildID}).Sort("createdat"),
+	).All(&entryBucket)
+	helpers.Relax(err)
+
+	if len(entryBucket) <= 0 {
+		*out = m.newMsg("plugins.outgoingwebhooks.list-none")
+		return m.actionFinish
+	}
+
+	var listText string
+	for _, entry := range entryBucket {
+		listText += helpers.GetTextF("plugins.outgoingwebhooks.list-entry",
+			helpers.MdbIdToHuman(entry.ID), entry.URL, strings.Join(entry.EventTypes, "`, `"),
+			entry.CreatedByUserID) + "\n"
+	}
+	listText += helpers.GetTextF("plugins.outgoingwebhooks.list-sum", len(entryBucket))
+
+	for _, page := range helpers.Pagify(listText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+// [p]outgoing-webhook remove <id>
+func (m *OutgoingWebhooks) actionRemove(args []string, in *discordgo.Message, out **discordgo.MessageSend) outgoingWebhooksAction {
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	entry, ok := m.getWebhook(channel.GuildID, args[1])
+	if !ok {
+		*out = m.newMsg("plugins.outgoingwebhooks.not-found")
+		return m.actionFinish
+	}
+
+	err = helpers.MDbDelete(models.OutgoingWebhooksTable, entry.ID)
+	helpers.Relax(err)
+	helpers.OutgoingWebhooksCacheReset(channel.GuildID)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(entry.ID),
+		models.EventlogTargetTypeRobyulOutgoingWebhook, in.Author.ID,
+		models.EventlogTypeRobyulOutgoingWebhookRemove, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "outgoingwebhook_url",
+				Value: entry.URL,
+			},
+			{
+				Key:   "outgoingwebhook_events",
+				Value: strings.Join(entry.EventTypes, ","),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.outgoingwebhooks.remove-success", entry.URL)
+	return m.actionFinish
+}
+
+// [p]outgoing-webhook deliveries <id>
+func (m *OutgoingWebhooks) actionDeliveries(args []string, in *discordgo.Message, out **discordgo.MessageSend) outgoingWebhooksAction {
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	entry, ok := m.getWebhook(channel.GuildID, args[1])
+	if !ok {
+		*out = m.newMsg("plugins.outgoingwebhooks.not-found")
+		return m.actionFinish
+	}
+
+	var deliveryBucket []models.OutgoingWebhookDeliveryEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.OutgoingWebhookDeliveriesTable).Find(bson.M{
+			"webhookid": helpers.MdbIdToHuman(entry.ID),
+			"guildid":   channel.GuildID,
+		}).Sort("-createdat").Limit(outgoingWebhooksDeliveriesShown),
+	).All(&deliveryBucket)
+	helpers.Relax(err)
+
+	if len(deliveryBucket) <= 0 {
+		*out = m.newMsg("plugins.outgoingwebhooks.deliveries-none")
+		return m.actionFinish
+	}
+
+	deliveriesText := helpers.GetTextF("plugins.outgoingwebhooks.deliveries-title", entry.URL) + "\n"
+	for _, delivery := range deliveryBucket {
+		lastErrorText := ""
+		if delivery.LastError != "" {
+			lastErrorText = helpers.GetTextF("plugins.outgoingwebhooks.deliveries-entry-error", delivery.LastError)
+		}
+		deliveriesText += helpers.GetTextF("plugins.outgoingwebhooks.deliveries-entry",
+			helpers.MdbIdToHuman(delivery.ID), delivery.EventType, delivery.Status, delivery.Attempts,
+			delivery.LastStatusCode, delivery.CreatedAt.Format(time.ANSIC)) + lastErrorText + "\n"
+	}
+
+	for _, page := range helpers.Pagify(deliveriesText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+func (m *OutgoingWebhooks) getWebhook(guildID, humanID string) (entry models.OutgoingWebhookEntry, ok bool) {
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.OutgoingWebhooksTable).Find(bson.M{
+			"_id":     helpers.HumanToMdbId(strings.TrimLeft(humanID, "#")),
+			"guildid": guildID,
+		}),
+		&entry,
+	)
+	if err != nil {
+		if !helpers.IsMdbNotFound(err) {
+			helpers.Relax(err)
+		}
+		return entry, false
+	}
+	return entry, true
+}
+
+func (m *OutgoingWebhooks) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) outgoingWebhooksAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (m *OutgoingWebhooks) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
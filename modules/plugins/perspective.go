@@ -14,6 +14,7 @@ import (
 	"github.com/Seklfreak/Robyul2/cache"
 	"github.com/Seklfreak/Robyul2/helpers"
 	"github.com/Seklfreak/Robyul2/metrics"
+	"github.com/Seklfreak/Robyul2/models"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -348,6 +349,19 @@ func (m *Perspective) sendWarning(guildID, channelID, userID string, avgResults
 	}
 	warningEmbed.Description += "`Severe Toxicity` / `Inflammatory` / `Obscene`"
 
+	go func() {
+		defer helpers.Recover()
+
+		helpers.OutgoingWebhookEmit(guildID, models.OutgoingWebhookEventWarning, models.OutgoingWebhookWarningData{
+			UserID:         userID,
+			ChannelID:      channelID,
+			Source:         "perspective",
+			SevereToxicity: avgResults.SevereToxicity,
+			Inflammatory:   avgResults.Inflammatory,
+			Obscene:        avgResults.Obscene,
+		})
+	}()
+
 	_, err = helpers.SendEmbed(settings.PerspectiveChannelID, warningEmbed)
 	return err
 }
@@ -0,0 +1,403 @@
+package plugins
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/sirupsen/logrus"
+)
+
+type privacyAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next privacyAction)
+
+type Privacy struct{}
+
+const (
+	privacyExportRatelimit = 24 * time.Hour
+	// discord rejects larger attachments
+	privacyExportMaxAttachmentSize = 8 * 1024 * 1024
+)
+
+func (m *Privacy) Commands() []string {
+	return []string{
+		"privacy",
+		"retention",
+	}
+}
+
+func (m *Privacy) Init(session *discordgo.Session) {
+	go m.retentionLoop()
+	go m.exportCleanupLoop()
+}
+
+func (m *Privacy) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := m.actionStart
+	if command == "retention" {
+		action = m.actionRetention
+	}
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (m *Privacy) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) privacyAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if len(args) < 1 {
+		*out = m.newMsg("plugins.privacy.help")
+		return m.actionFinish
+	}
+
+	switch args[0] {
+	case "export":
+		return m.actionExport
+	case "erase":
+		return m.actionErase
+	}
+
+	*out = m.newMsg("bot.arguments.invalid")
+	return m.actionFinish
+}
+
+// [p]retention [<messages|joins|leaves|voice> <days|off>]
+func (m *Privacy) actionRetention(args []string, in *discordgo.Message, out **discordgo.MessageSend) privacyAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if !helpers.IsAdmin(in) {
+		*out = m.newMsg("admin.no_permission")
+		return m.actionFinish
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	settings := helpers.GuildSettingsGetCached(channel.GuildID)
+
+	if len(args) < 2 {
+		var retentionText string
+		for _, retentionIndex := range models.RetentionIndexes {
+			retentionText += helpers.GetTextF("plugins.privacy.retention-entry",
+				retentionIndex, m.retentionDaysText(helpers.GetRetentionDays(settings, retentionIndex))) + "\n"
+		}
+		retentionText += helpers.GetText("plugins.privacy.retention-help")
+		*out = &discordgo.MessageSend{Content: retentionText}
+		return m.actionFinish
+	}
+
+	retentionIndex := strings.ToLower(args[0])
+	days, ok := helpers.ParseRetentionDays(args[1])
+	if !ok {
+		*out = m.newMsg("plugins.privacy.retention-invalid-days", helpers.RetentionMaxDays)
+		return m.actionFinish
+	}
+
+	daysBefore := helpers.GetRetentionDays(settings, retentionIndex)
+	if !helpers.SetRetentionDays(&settings, retentionIndex, days) {
+		*out = m.newMsg("plugins.privacy.retention-invalid-index", strings.Join(models.RetentionIndexes, "`, `"))
+		return m.actionFinish
+	}
+
+	err = helpers.GuildSettingsSet(channel.GuildID, settings)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, channel.GuildID,
+		models.EventlogTargetTypeGuild, in.Author.ID,
+		models.EventlogTypeRobyulChatlogRetentionUpdate, "",
+		[]models.ElasticEventlogChange{
+			{
+				Key:      "chatlog_retention_" + retentionIndex + "_days",
+				OldValue: strconv.Itoa(daysBefore),
+				NewValue: strconv.Itoa(days),
+			},
+		},
+		nil, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.privacy.retention-set-success", retentionIndex, m.retentionDaysText(days))
+	return m.actionFinish
+}
+
+// [p]privacy export
+func (m *Privacy) actionExport(args []string, in *discordgo.Message, out **discordgo.MessageSend) privacyAction {
+	set, err := cache.GetRedisClient().SetNX(
+		fmt.Sprintf(models.PrivacyExportRatelimitRedisKey, in.Author.ID), "1", privacyExportRatelimit).Result()
+	helpers.Relax(err)
+	if !set {
+		*out = m.newMsg("plugins.privacy.export-ratelimited")
+		return m.actionFinish
+	}
+
+	dmChannel, err := cache.GetSession().UserChannelCreate(in.Author.ID)
+	if err != nil {
+		cache.GetRedisClient().Del(fmt.Sprintf(models.PrivacyExportRatelimitRedisKey, in.Author.ID))
+		*out = m.newMsg("plugins.privacy.export-dm-failed")
+		return m.actionFinish
+	}
+
+	go func() {
+		defer helpers.Recover()
+
+		archive, err := m.buildExport(in.Author.ID)
+		if err != nil {
+			m.logger().WithField("userID", in.Author.ID).Errorf("building privacy export failed: %s", err.Error())
+			_, err = helpers.SendMessage(dmChannel.ID, helpers.GetText("plugins.privacy.export-failed"))
+			helpers.RelaxLog(err)
+			return
+		}
+
+		filename := "robyul-privacy-export-" + in.Author.ID + ".zip"
+		if len(archive) <= privacyExportMaxAttachmentSize {
+			_, err = helpers.SendFile(dmChannel.ID, filename, bytes.NewReader(archive),
+				helpers.GetText("plugins.privacy.export-dm"))
+			helpers.RelaxLog(err)
+			return
+		}
+
+		objectName, err := helpers.AddFile("", archive, helpers.AddFileMetadata{
+			Filename: filename,
+			UserID:   in.Author.ID,
+		}, helpers.PrivacyExportStorageSource, true)
+		helpers.Relax(err)
+		link, err := helpers.GetFileLink(objectName)
+		helpers.Relax(err)
+
+		_, err = helpers.SendMessage(dmChannel.ID, helpers.GetTextF("plugins.privacy.export-dm-link",
+			link, int(helpers.PrivacyExportExpiry.Hours())))
+		helpers.RelaxLog(err)
+	}()
+
+	*out = m.newMsg("plugins.privacy.export-started")
+	return m.actionFinish
+}
+
+// builds a zip archive with one JSON file per index and collection containing data of the user
+func (m *Privacy) buildExport(userID string) (archive []byte, err error) {
+	files := make(map[string]interface{})
+
+	for name, index := range map[string]string{
+		"messages":         models.ElasticIndexMessages,
+		"joins":            models.ElasticIndexJoins,
+		"leaves":           models.ElasticIndexLeaves,
+		"presence_updates": models.ElasticIndexPresenceUpdates,
+		"voice_sessions":   models.ElasticIndexVoiceSessions,
+		"eventlogs":        models.ElasticIndexEventlogs,
+	} {
+		if !cache.HasElastic() {
+			break
+		}
+		files[name], err = helpers.ElasticGetUserDocuments(index, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var names []models.NamesEntry
+	var levels []models.LevelsServerusersEntry
+	var userdata []models.ProfileUserdataEntry
+	var reminders []models.RemindersEntry
+	var notifications []models.NotificationsEntry
+	var notificationsDigest []models.NotificationsDigestEntry
+	var lastFm []models.LastFmEntry
+	for name, collection := range map[string]struct {
+		table  models.MongoDbCollection
+		result interface{}
+	}{
+		"names":                {models.NamesTable, &names},
+		"levels":               {models.LevelsServerusersTable, &levels},
+		"profile":              {models.ProfileUserdataTable, &userdata},
+		"reminders":            {models.RemindersTable, &reminders},
+		"notifications":        {models.NotificationsTable, &notifications},
+		"notifications_digest": {models.NotificationsDigestTable, &notificationsDigest},
+		"lastfm":               {models.LastFmTable, &lastFm},
+	} {
+		err = helpers.MDbIterWithoutLogging(
+			helpers.MdbCollection(collection.table).Find(bson.M{"userid": userID}),
+		).All(collection.result)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = collection.result
+	}
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for name, data := range files {
+		fileWriter, err := zipWriter.Create(name + ".json")
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(fileWriter)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = zipWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// [p]privacy erase <user id>
+func (m *Privacy) actionErase(args []string, in *discordgo.Message, out **discordgo.MessageSend) privacyAction {
+	if !helpers.IsBotAdmin(in.Author.ID) {
+		*out = m.newMsg("botadmin.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	userID := strings.Trim(args[1], "<@!>")
+	if _, err := strconv.ParseUint(userID, 10, 64); err != nil {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	if !helpers.ConfirmEmbed(in.ChannelID, in.Author,
+		helpers.GetTextF("plugins.privacy.erase-confirm", userID), "✅", "🚫") {
+		return nil
+	}
+
+	err := helpers.MdbEraseUser(userID)
+	helpers.Relax(err)
+
+	if cache.HasElastic() {
+		err = helpers.ElasticEraseUser(userID)
+		helpers.Relax(err)
+	}
+
+	m.logger().WithField("userID", userID).Infof("erased user on request of %s (#%s)",
+		in.Author.Username, in.Author.ID)
+
+	*out = m.newMsg("plugins.privacy.erase-success", userID)
+	return m.actionFinish
+}
+
+// exportCleanupLoop deletes uploaded exports after helpers.PrivacyExportExpiry
+func (m *Privacy) exportCleanupLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			m.logger().Error("the exportCleanupLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.exportCleanupLoop()
+		}()
+	}()
+
+	for {
+		err := helpers.DeleteExpiredPrivacyExports()
+		helpers.RelaxLog(err)
+
+		time.Sleep(10 * time.Minute)
+	}
+}
+
+// retentionLoop purges documents and unused eventlog attachments older than the retention of their guild
+func (m *Privacy) retentionLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			m.logger().Error("the retentionLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.retentionLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(1 * time.Hour)
+
+		if !cache.HasElastic() {
+			continue
+		}
+
+		for _, guild := range cache.GetSession().State.Guilds {
+			settings := helpers.GuildSettingsGetCached(guild.ID)
+
+			// unused attachments are kept as long as the messages they have been stored for
+			attachmentExpiry := helpers.EventlogAttachmentDefaultExpiry
+			if days := helpers.GetRetentionDays(settings, models.RetentionIndexMessages); days > 0 {
+				attachmentExpiry = time.Duration(days) * 24 * time.Hour
+			}
+			deletedAttachments, err := helpers.DeleteExpiredEventlogAttachments(guild.ID, time.Now().Add(-attachmentExpiry))
+			if err != nil {
+				m.logger().WithField("guildID", guild.ID).Errorf("deleting eventlog attachments failed: %s", err.Error())
+			}
+			if deletedAttachments > 0 {
+				m.logger().WithField("guildID", guild.ID).Infof("deleted %d eventlog attachments", deletedAttachments)
+			}
+
+			for _, retentionIndex := range models.RetentionIndexes {
+				days := helpers.GetRetentionDays(settings, retentionIndex)
+				if days <= 0 {
+					continue
+				}
+
+				deleted, err := helpers.ElasticPurgeBefore(helpers.GetRetentionElasticIndex(retentionIndex), guild.ID,
+					time.Now().Add(-time.Duration(days)*24*time.Hour))
+				if err != nil {
+					m.logger().WithField("guildID", guild.ID).Errorf("purging %s failed: %s", retentionIndex, err.Error())
+					continue
+				}
+				if deleted > 0 {
+					m.logger().WithField("guildID", guild.ID).Infof("purged %d %s documents", deleted, retentionIndex)
+				}
+			}
+		}
+
+		// presence updates are not stored per guild
+		if helpers.GetConfig().Exists("elasticsearch", "presence_retention_days") {
+			days, _ := helpers.GetConfig().Path("elasticsearch.presence_retention_days").Data().(float64)
+			if days > 0 {
+				deleted, err := helpers.ElasticPurgeBefore(models.ElasticIndexPresenceUpdates, "",
+					time.Now().Add(-time.Duration(days)*24*time.Hour))
+				helpers.RelaxLog(err)
+				if deleted > 0 {
+					m.logger().Infof("purged %d presence update documents", deleted)
+				}
+			}
+		}
+	}
+}
+
+func (m *Privacy) retentionDaysText(days int) string {
+	if days <= 0 {
+		return helpers.GetText("plugins.privacy.retention-forever")
+	}
+	return helpers.GetTextF("plugins.privacy.retention-days", days)
+}
+
+func (m *Privacy) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) privacyAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (m *Privacy) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (m *Privacy) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "privacy")
+}
@@ -61,9 +61,6 @@ func (rp *RandomPictures) Init(session *discordgo.Session) {
 		defer helpers.Recover()
 
 		for {
-			var marshalled []byte
-			redisClient := cache.GetRedisClient()
-
 			var rpSources []models.RandompictureSourceEntry
 			err := helpers.MDbIter(helpers.MdbCollection(models.RandompictureSourcesTable).Find(nil)).All(&rpSources)
 			if len(rpSources) <= 0 {
@@ -72,40 +69,12 @@ func (rp *RandomPictures) Init(session *discordgo.Session) {
 			}
 			helpers.Relax(err)
 
-			log.WithField("module", "randompictures").Info("gathering google drive picture cache")
+			log.WithField("module", "randompictures").Info("gathering picture cache")
 			for _, sourceEntry := range rpSources {
-				var key1 string
-				var key2 string
-				var fileHash string
-				var i int
-				var entry *drive.File
-				for i, entry = range rp.getFileCache(sourceEntry) {
-					fileHash = rp.GetFileHash(sourceEntry.ID, sourceEntry.PreviousID, entry.Id)
-					key1 = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-n:%s:entry:%d", helpers.MdbIdToHuman(sourceEntry.ID), i+1)
-					key2 = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-hash:%s", fileHash)
-					marshalled, err = msgpack.Marshal(entry)
-					if err != nil {
-						raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-						continue
-					}
-					err = redisClient.Set(key1, fileHash, 7*24*time.Hour).Err()
-					if err != nil {
-						raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-						continue
-					}
-					err = redisClient.Set(key2, marshalled, 7*24*time.Hour).Err()
-					if err != nil {
-						raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-						continue
-					}
-				}
-				key := fmt.Sprintf("robyul2-discord:randompictures:filescache:%s:entry:%s", helpers.MdbIdToHuman(sourceEntry.ID), "count")
-				err = redisClient.Set(key, i+1, 7*24*time.Hour).Err()
+				_, err = rp.cacheSourceItems(sourceEntry, 7*24*time.Hour)
 				if err != nil {
-					raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-					continue
+					log.WithField("module", "randompictures").Warnf("gathering picture cache for %s failed: %s", helpers.MdbIdToHuman(sourceEntry.ID), err.Error())
 				}
-				rp.updateImagesCachedMetric()
 			}
 
 			time.Sleep(12 * time.Hour)
@@ -149,10 +118,10 @@ func (rp *RandomPictures) Init(session *discordgo.Session) {
 							key = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-hash:%s", fileHash)
 							resultBytes, err := redisClient.Get(key).Bytes()
 							if err == nil {
-								var gPicture *drive.File
-								msgpack.Unmarshal(resultBytes, &gPicture)
+								var item *randomPicturesItem
+								msgpack.Unmarshal(resultBytes, &item)
 								defer helpers.Recover()
-								err = rp.postItem(sourceEntry.GuildID, postToChannelID, "", gPicture, sourceEntry.ID, sourceEntry.PreviousID, strconv.Itoa(chosenPicN))
+								err = rp.postItem(sourceEntry, postToChannelID, "", item, strconv.Itoa(chosenPicN))
 								if err != nil {
 									if errG, ok := err.(*googleapi.Error); ok {
 										if strings.Contains("The download quota for this file has been exceeded", errG.Error()) {
@@ -305,119 +274,22 @@ func (rp *RandomPictures) Action(command string, content string, msg *discordgo.
 		args := strings.Fields(content)
 		if len(args) > 0 {
 			switch args[0] {
-			case "new-config": // [p]randompictures new-config
+			case "new-config": // [p]randompictures new-config [provider=<provider>] alias=<aliases> <folder=|album=|subreddit=> [channel=] [skiproles=]
 				helpers.RequireRobyulMod(msg, func() {
 					session.ChannelTyping(msg.ChannelID)
-
-					if len(args) <= 1 {
-						_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
-						helpers.Relax(err)
-						return
-					}
-
-					channel, err := helpers.GetChannel(msg.ChannelID)
-					helpers.Relax(err)
-
-					guild, err := helpers.GetGuild(channel.GuildID)
-					helpers.Relax(err)
-
-					postToChannelIDs := make([]string, 0)
-					driveFolderIDs := make([]string, 0)
-					aliases := make([]string, 0)
-					blacklistedRoleIDs := make([]string, 0)
-					data := helpers.ParseKeyValueString(
-						strings.TrimSpace(strings.Replace(content, args[0], "", 1)),
-					)
-					if channelIDsText, ok := data["channel"]; ok {
-						postToChannelIDsParsed := strings.Split(channelIDsText, ",")
-						for _, parsedID := range postToChannelIDsParsed {
-							channelParsed, err := helpers.GetChannelFromMention(msg, parsedID)
-							if err != nil || channelParsed == nil || channelParsed.ID == "" {
-								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-								helpers.Relax(err)
-								return
-							}
-							postToChannelIDs = append(postToChannelIDs, channelParsed.ID)
-						}
-					}
-					if folderIDsText, ok := data["folder"]; ok {
-						folderIDsParsed := strings.Split(folderIDsText, ",")
-						for _, parsedID := range folderIDsParsed {
-							result, err := driveService.Files.List().Q(fmt.Sprintf(driveSearchText, parsedID)).Fields(googleapi.Field(driveFieldsText)).PageSize(1).Do()
-							if err != nil || len(result.Files) <= 0 {
-								_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-								helpers.Relax(err)
-								return
-							}
-							driveFolderIDs = append(driveFolderIDs, parsedID)
-						}
-					}
-					if aliasesText, ok := data["alias"]; ok {
-						aliasesParsed := strings.Split(aliasesText, ",")
-						for _, parsedAlias := range aliasesParsed {
-							parsedAlias = strings.TrimSpace(parsedAlias)
-							aliases = append(aliases, parsedAlias)
-						}
-					}
-					if blacklistedRoleIDsText, ok := data["skiproles"]; ok {
-						blacklistedRoleIDsParsed := strings.Split(blacklistedRoleIDsText, ",")
-						for _, parsedRoleID := range blacklistedRoleIDsParsed {
-							parsedRoleID = strings.TrimSpace(parsedRoleID)
-							for _, guildRole := range guild.Roles {
-								if guildRole.ID == parsedRoleID {
-									blacklistedRoleIDs = append(blacklistedRoleIDs, parsedRoleID)
-									break
-								}
-							}
-						}
-					}
-
-					if len(aliases) <= 0 || len(driveFolderIDs) <= 0 {
-						_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
-						helpers.Relax(err)
-						return
-					}
-
-					newID, err := helpers.MDbInsert(
-						models.RandompictureSourcesTable,
-						models.RandompictureSourceEntry{
-							PostToChannelIDs:   postToChannelIDs,
-							DriveFolderIDs:     driveFolderIDs,
-							Aliases:            aliases,
-							GuildID:            channel.GuildID,
-							BlacklistedRoleIDs: blacklistedRoleIDs,
-						},
-					)
-					helpers.Relax(err)
-
-					_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(newID),
-						models.EventlogTargetTypeRobyulRandomPictureSource, msg.Author.ID,
-						models.EventlogTypeRobyulRandomPictureSourceCreate, "",
-						nil,
-						[]models.ElasticEventlogOption{
-							{
-								Key:   "randompicture_source_posttochannelids",
-								Value: strings.Join(postToChannelIDs, ","),
-								Type:  models.EventlogTargetTypeChannel,
-							},
-							{
-								Key:   "randompicture_source_drivefolderids",
-								Value: strings.Join(driveFolderIDs, ","),
-							},
-							{
-								Key:   "randompicture_source_aliases",
-								Value: strings.Join(aliases, ","),
-							},
-							{
-								Key:   "randompicture_source_blacklistedroleids",
-								Value: strings.Join(blacklistedRoleIDs, ","),
-								Type:  models.EventlogTargetTypeRole,
-							},
-						}, false)
-					helpers.RelaxLog(err)
-
-					_, err = helpers.SendMessage(msg.ChannelID, fmt.Sprintf("Created a new entry in the Database: `%s`.", helpers.MdbIdToHuman(newID)))
-					helpers.Relax(err)
+					rp.createSource(msg, content, args, false)
+				})
+				return
+			case "add": // [p]randompictures add provider=<storage|imgur|reddit> alias=<aliases> [album=|subreddit=] [channel=] [skiproles=]
+				helpers.RequireMod(msg, func() {
+					session.ChannelTyping(msg.ChannelID)
+					rp.createSource(msg, content, args, true)
+				})
+				return
+			case "upload": // [p]randompictures upload <source id> + attachments
+				helpers.RequireMod(msg, func() {
+					session.ChannelTyping(msg.ChannelID)
+					rp.uploadToSource(msg, args)
 				})
 				return
 			case "list": // [p]randompictures list
@@ -452,10 +324,10 @@ func (rp *RandomPictures) Action(command string, content string, msg *discordgo.
 							totalCachedImages += pictureCount
 						}
 
-						listText += fmt.Sprintf(":arrow_forward: `%s`: on %s (`#%s`), %d Aliases (`%s`), %d Folders, %d Channels, %d Skipped Roles, %s\n",
+						listText += fmt.Sprintf(":arrow_forward: `%s`: on %s (`#%s`), %d Aliases (`%s`), %s, %d Channels, %d Skipped Roles, %s\n",
 							helpers.MdbIdToHuman(rpSource.ID), rpSourceGuild.Name, rpSourceGuild.ID,
 							len(rpSource.Aliases), strings.Join(rpSource.Aliases, ","),
-							len(rpSource.DriveFolderIDs),
+							rp.sourceDescription(rpSource),
 							len(rpSource.PostToChannelIDs), len(rpSource.BlacklistedRoleIDs),
 							cacheText)
 						totalSources += 1
@@ -499,6 +371,16 @@ func (rp *RandomPictures) Action(command string, content string, msg *discordgo.
 					err = helpers.MDbDelete(models.RandompictureSourcesTable, entryBucket.ID)
 					helpers.Relax(err)
 
+					// uploaded pictures are only used by their source
+					if entryBucket.Provider == models.RandompictureProviderStorage {
+						items, err := rp.getProvider(entryBucket).List(entryBucket)
+						helpers.RelaxLog(err)
+						for _, item := range items {
+							err = helpers.DeleteFile(item.ID)
+							helpers.RelaxLog(err)
+						}
+					}
+
 					_, err = helpers.EventlogLog(time.Now(), entryBucket.GuildID, helpers.MdbIdToHuman(entryBucket.ID),
 						models.EventlogTargetTypeRobyulRandomPictureSource, msg.Author.ID,
 						models.EventlogTypeRobyulRandomPictureSourceRemove, "",
@@ -554,44 +436,10 @@ func (rp *RandomPictures) Action(command string, content string, msg *discordgo.
 
 					for _, rpSource := range rpSources {
 						if helpers.MdbIdToHuman(rpSource.ID) == args[1] {
-							var fileHash string
-							var key string
-							var i int
-							var entry *drive.File
-							var marshalled []byte
-							redisClient := cache.GetRedisClient()
 							helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.refresh-started"))
-							for i, entry = range rp.getFileCache(rpSource) {
-								key = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-n:%s:entry:%d", helpers.MdbIdToHuman(rpSource.ID), i+1)
-								fileHash = rp.GetFileHash(rpSource.ID, rpSource.PreviousID, entry.Id)
-								if fileHash == "" {
-									continue
-								}
-								err = redisClient.Set(key, fileHash, time.Hour*24).Err()
-								if err != nil {
-									raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-									continue
-								}
-								key = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-hash:%s", fileHash)
-								marshalled, err = msgpack.Marshal(entry)
-								if err != nil {
-									raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-									continue
-								}
-								err = redisClient.Set(key, marshalled, time.Hour*24).Err()
-								if err != nil {
-									raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-									continue
-								}
-							}
-							key = fmt.Sprintf("robyul2-discord:randompictures:filescache:%s:entry:%s", helpers.MdbIdToHuman(rpSource.ID), "count")
-							err = redisClient.Set(key, i+1, time.Hour*24).Err()
-							if err != nil {
-								raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
-								continue
-							}
-							rp.updateImagesCachedMetric()
-							_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.refresh-success"))
+							_, err = rp.cacheSourceItems(rpSource, 24*time.Hour)
+							helpers.Relax(err)
+							_, err = helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.refresh-success"))
 							helpers.Relax(err)
 							return
 						}
@@ -737,6 +585,309 @@ func (rp *RandomPictures) Action(command string, content string, msg *discordgo.
 
 }
 
+// createSource creates a new source from the key value options of the new-config and add commands
+// limited	: true for sources created by server moderators, they can not use google drive and are limited to randomPicturesMaxSourcesPerGuild sources
+func (rp *RandomPictures) createSource(msg *discordgo.Message, content string, args []string, limited bool) {
+	if len(args) <= 1 {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		helpers.Relax(err)
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	guild, err := helpers.GetGuild(channel.GuildID)
+	helpers.Relax(err)
+
+	postToChannelIDs := make([]string, 0)
+	driveFolderIDs := make([]string, 0)
+	imgurAlbumIDs := make([]string, 0)
+	subreddits := make([]string, 0)
+	aliases := make([]string, 0)
+	blacklistedRoleIDs := make([]string, 0)
+	data := helpers.ParseKeyValueString(
+		strings.TrimSpace(strings.Replace(content, args[0], "", 1)),
+	)
+
+	provider := models.RandompictureProviderDrive
+	if providerText, ok := data["provider"]; ok {
+		provider = strings.ToLower(strings.TrimSpace(providerText))
+	}
+	switch provider {
+	case models.RandompictureProviderDrive:
+		if limited {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.add-drive-not-allowed"))
+			helpers.Relax(err)
+			return
+		}
+	case models.RandompictureProviderStorage, models.RandompictureProviderImgur, models.RandompictureProviderReddit:
+	default:
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.add-invalid-provider"))
+		helpers.Relax(err)
+		return
+	}
+
+	if limited {
+		sourcesCount, err := helpers.MdbCollection(models.RandompictureSourcesTable).Find(bson.M{"guildid": channel.GuildID}).Count()
+		helpers.Relax(err)
+		if sourcesCount >= randomPicturesMaxSourcesPerGuild {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.randompictures.add-too-many", randomPicturesMaxSourcesPerGuild))
+			helpers.Relax(err)
+			return
+		}
+	}
+
+	if channelIDsText, ok := data["channel"]; ok {
+		postToChannelIDsParsed := strings.Split(channelIDsText, ",")
+		for _, parsedID := range postToChannelIDsParsed {
+			channelParsed, err := helpers.GetChannelFromMention(msg, parsedID)
+			if err != nil || channelParsed == nil || channelParsed.ID == "" || channelParsed.GuildID != channel.GuildID {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				helpers.Relax(err)
+				return
+			}
+			postToChannelIDs = append(postToChannelIDs, channelParsed.ID)
+		}
+	}
+	if folderIDsText, ok := data["folder"]; ok && provider == models.RandompictureProviderDrive {
+		folderIDsParsed := strings.Split(folderIDsText, ",")
+		for _, parsedID := range folderIDsParsed {
+			result, err := driveService.Files.List().Q(fmt.Sprintf(driveSearchText, parsedID)).Fields(googleapi.Field(driveFieldsText)).PageSize(1).Do()
+			if err != nil || len(result.Files) <= 0 {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				helpers.Relax(err)
+				return
+			}
+			driveFolderIDs = append(driveFolderIDs, parsedID)
+		}
+	}
+	if albumIDsText, ok := data["album"]; ok && provider == models.RandompictureProviderImgur {
+		albumIDsParsed := strings.Split(albumIDsText, ",")
+		for _, parsedID := range albumIDsParsed {
+			// accept album links, for example https://imgur.com/a/Z0Jy3
+			parsedID = strings.TrimSpace(parsedID)
+			parsedID = parsedID[strings.LastIndex(parsedID, "/")+1:]
+			images, err := helpers.ImgurAlbumImages(parsedID)
+			if err != nil || len(images) <= 0 {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				helpers.Relax(err)
+				return
+			}
+			imgurAlbumIDs = append(imgurAlbumIDs, parsedID)
+		}
+	}
+	if subredditsText, ok := data["subreddit"]; ok && provider == models.RandompictureProviderReddit {
+		if redditSession == nil {
+			_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.add-reddit-unavailable"))
+			helpers.Relax(err)
+			return
+		}
+		subredditsParsed := strings.Split(subredditsText, ",")
+		for _, parsedSubreddit := range subredditsParsed {
+			parsedSubreddit = strings.Replace(strings.TrimLeft(strings.TrimSpace(parsedSubreddit), "/"), "r/", "", -1)
+			subredditData, err := redditSession.AboutSubreddit(parsedSubreddit)
+			if err != nil || subredditData.ID == "" {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+				helpers.Relax(err)
+				return
+			}
+			if subredditData.IsNSFW {
+				_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.add-nsfw-subreddit"))
+				helpers.Relax(err)
+				return
+			}
+			subreddits = append(subreddits, subredditData.Name)
+		}
+	}
+	if aliasesText, ok := data["alias"]; ok {
+		aliasesParsed := strings.Split(aliasesText, ",")
+		for _, parsedAlias := range aliasesParsed {
+			parsedAlias = strings.TrimSpace(parsedAlias)
+			aliases = append(aliases, parsedAlias)
+		}
+	}
+	if blacklistedRoleIDsText, ok := data["skiproles"]; ok {
+		blacklistedRoleIDsParsed := strings.Split(blacklistedRoleIDsText, ",")
+		for _, parsedRoleID := range blacklistedRoleIDsParsed {
+			parsedRoleID = strings.TrimSpace(parsedRoleID)
+			for _, guildRole := range guild.Roles {
+				if guildRole.ID == parsedRoleID {
+					blacklistedRoleIDs = append(blacklistedRoleIDs, parsedRoleID)
+					break
+				}
+			}
+		}
+	}
+
+	if len(aliases) <= 0 ||
+		(provider == models.RandompictureProviderDrive && len(driveFolderIDs) <= 0) ||
+		(provider == models.RandompictureProviderImgur && len(imgurAlbumIDs) <= 0) ||
+		(provider == models.RandompictureProviderReddit && len(subreddits) <= 0) {
+		_, err := helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		helpers.Relax(err)
+		return
+	}
+
+	newSource := models.RandompictureSourceEntry{
+		PostToChannelIDs:   postToChannelIDs,
+		Provider:           provider,
+		DriveFolderIDs:     driveFolderIDs,
+		ImgurAlbumIDs:      imgurAlbumIDs,
+		Subreddits:         subreddits,
+		Aliases:            aliases,
+		GuildID:            channel.GuildID,
+		BlacklistedRoleIDs: blacklistedRoleIDs,
+		AddedByUserID:      msg.Author.ID,
+	}
+	newSource.ID, err = helpers.MDbInsert(models.RandompictureSourcesTable, newSource)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), channel.GuildID, helpers.MdbIdToHuman(newSource.ID),
+		models.EventlogTargetTypeRobyulRandomPictureSource, msg.Author.ID,
+		models.EventlogTypeRobyulRandomPictureSourceCreate, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "randompicture_source_posttochannelids",
+				Value: strings.Join(postToChannelIDs, ","),
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "randompicture_source_provider",
+				Value: provider,
+			},
+			{
+				Key:   "randompicture_source_drivefolderids",
+				Value: strings.Join(driveFolderIDs, ","),
+			},
+			{
+				Key:   "randompicture_source_imguralbumids",
+				Value: strings.Join(imgurAlbumIDs, ","),
+			},
+			{
+				Key:   "randompicture_source_subreddits",
+				Value: strings.Join(subreddits, ","),
+			},
+			{
+				Key:   "randompicture_source_aliases",
+				Value: strings.Join(aliases, ","),
+			},
+			{
+				Key:   "randompicture_source_blacklistedroleids",
+				Value: strings.Join(blacklistedRoleIDs, ","),
+				Type:  models.EventlogTargetTypeRole,
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	// fill the cache now to not wait for the next cache loop
+	go func() {
+		defer helpers.Recover()
+
+		_, err := rp.cacheSourceItems(newSource, 7*24*time.Hour)
+		helpers.RelaxLog(err)
+	}()
+
+	if provider == models.RandompictureProviderStorage {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.randompictures.add-success-storage", helpers.MdbIdToHuman(newSource.ID)))
+	} else {
+		_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.randompictures.add-success", helpers.MdbIdToHuman(newSource.ID)))
+	}
+	helpers.Relax(err)
+}
+
+// uploadToSource stores the attachments of the message in the object storage and adds them to a storage source
+func (rp *RandomPictures) uploadToSource(msg *discordgo.Message, args []string) {
+	if len(args) < 2 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.too-few"))
+		return
+	}
+
+	channel, err := helpers.GetChannel(msg.ChannelID)
+	helpers.Relax(err)
+
+	var source models.RandompictureSourceEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.RandompictureSourcesTable).Find(bson.M{"guildid": channel.GuildID, "_id": helpers.HumanToMdbId(args[1])}),
+		&source,
+	)
+	if helpers.IsMdbNotFound(err) {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("bot.arguments.invalid"))
+		return
+	}
+	helpers.Relax(err)
+
+	if source.Provider != models.RandompictureProviderStorage {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.upload-wrong-provider"))
+		return
+	}
+
+	if len(msg.Attachments) <= 0 {
+		helpers.SendMessage(msg.ChannelID, helpers.GetText("plugins.randompictures.upload-no-attachments"))
+		return
+	}
+
+	items, err := rp.getProvider(source).List(source)
+	helpers.Relax(err)
+	picturesCount := len(items)
+
+	var uploaded, skipped int
+	for _, attachment := range msg.Attachments {
+		if picturesCount >= randomPicturesMaxStoragePictures {
+			skipped += len(msg.Attachments) - uploaded - skipped
+			break
+		}
+		if attachment.Size > randomPicturesMaxFileSize {
+			skipped++
+			continue
+		}
+
+		data, err := helpers.NetGetUAWithError(attachment.URL, helpers.DEFAULT_UA)
+		helpers.Relax(err)
+
+		mimeType, _ := helpers.SniffMime(data)
+		if mimeType != "image/jpeg" && mimeType != "image/png" && mimeType != "image/gif" {
+			skipped++
+			continue
+		}
+
+		_, err = helpers.AddFile("", data, helpers.AddFileMetadata{
+			Filename:  attachment.Filename,
+			ChannelID: msg.ChannelID,
+			UserID:    msg.Author.ID,
+			AdditionalMetadata: map[string]string{
+				randomPicturesStorageMetadataKey: helpers.MdbIdToHuman(source.ID),
+			},
+		}, randomPicturesStorageSource, true)
+		helpers.Relax(err)
+
+		uploaded++
+		picturesCount++
+	}
+
+	if uploaded > 0 {
+		_, err = rp.cacheSourceItems(source, 7*24*time.Hour)
+		helpers.Relax(err)
+	}
+
+	_, err = helpers.SendMessage(msg.ChannelID, helpers.GetTextF("plugins.randompictures.upload-success",
+		uploaded, skipped, picturesCount, randomPicturesMaxStoragePictures))
+	helpers.RelaxMessage(err, msg.ChannelID, msg.ID)
+}
+
+func (rp *RandomPictures) sourceDescription(source models.RandompictureSourceEntry) string {
+	switch source.Provider {
+	case models.RandompictureProviderStorage:
+		return "Uploads"
+	case models.RandompictureProviderImgur:
+		return fmt.Sprintf("%d Imgur Albums", len(source.ImgurAlbumIDs))
+	case models.RandompictureProviderReddit:
+		return fmt.Sprintf("%d Subreddits (`%s`)", len(source.Subreddits), strings.Join(source.Subreddits, ","))
+	}
+	return fmt.Sprintf("%d Folders", len(source.DriveFolderIDs))
+}
+
 func (rp *RandomPictures) postRandomItemFromContent(channel *discordgo.Channel, msg *discordgo.Message, content string, initialMessage *discordgo.Message, rpSources []models.RandompictureSourceEntry) (bool, error) {
 	var matchEntry models.RandompictureSourceEntry
 	if content != "" { // match <name>
@@ -821,9 +972,9 @@ func (rp *RandomPictures) postRandomItemFromContent(channel *discordgo.Channel,
 			if err != nil {
 				return false, errors.New("invalid picture data cached")
 			}
-			var gPicture *drive.File
-			msgpack.Unmarshal(resultBytes, &gPicture)
-			err = rp.postItem(channel.GuildID, msg.ChannelID, initialMessage.ID, gPicture, matchEntry.ID, matchEntry.PreviousID, strconv.Itoa(chosenPicN))
+			var item *randomPicturesItem
+			msgpack.Unmarshal(resultBytes, &item)
+			err = rp.postItem(matchEntry, msg.ChannelID, initialMessage.ID, item, strconv.Itoa(chosenPicN))
 			if err == nil {
 				return true, nil
 			} else {
@@ -834,6 +985,53 @@ func (rp *RandomPictures) postRandomItemFromContent(channel *discordgo.Channel,
 	return false, errors.New("unable to match to source")
 }
 
+// cacheSourceItems stores the pictures of the source in redis, they are picked by their number and resolved by their hash
+func (rp *RandomPictures) cacheSourceItems(sourceEntry models.RandompictureSourceEntry, expiration time.Duration) (count int, err error) {
+	items, err := rp.getProvider(sourceEntry).List(sourceEntry)
+	if err != nil {
+		return 0, err
+	}
+
+	redisClient := cache.GetRedisClient()
+	var key string
+	var fileHash string
+	var marshalled []byte
+	for i, item := range items {
+		fileHash = rp.GetFileHash(sourceEntry.ID, sourceEntry.PreviousID, item.ID)
+		key = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-n:%s:entry:%d", helpers.MdbIdToHuman(sourceEntry.ID), i+1)
+		err = redisClient.Set(key, fileHash, expiration).Err()
+		if err != nil {
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			continue
+		}
+		key = fmt.Sprintf("robyul2-discord:randompictures:filescache:by-hash:%s", fileHash)
+		marshalled, err = msgpack.Marshal(item)
+		if err != nil {
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			continue
+		}
+		err = redisClient.Set(key, marshalled, expiration).Err()
+		if err != nil {
+			raven.CaptureError(fmt.Errorf("%#v", err), map[string]string{})
+			continue
+		}
+	}
+
+	key = fmt.Sprintf("robyul2-discord:randompictures:filescache:%s:entry:%s", helpers.MdbIdToHuman(sourceEntry.ID), "count")
+	// sources without pictures have no count, to not pick from an empty cache
+	if len(items) <= 0 {
+		err = redisClient.Del(key).Err()
+	} else {
+		err = redisClient.Set(key, len(items), expiration).Err()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rp.updateImagesCachedMetric()
+	return len(items), nil
+}
+
 func (rp *RandomPictures) getFileCache(sourceEntry models.RandompictureSourceEntry) []*drive.File {
 	var allFiles []*drive.File
 
@@ -940,23 +1138,17 @@ func (rp *RandomPictures) updateImagesCachedMetric() {
 	metrics.RandomPictureSourcesImagesCachedCount.Set(totalImages)
 }
 
-func (rp *RandomPictures) postItem(guildID string, channelID string, messageID string, file *drive.File, sourceID bson.ObjectId, previousSourceID, pictureID string) error {
-	// fmt.Println("channelID:", channelID, "messageID:", messageID, "file:", file.Name, "sourceID:", sourceID, "pictureID:", pictureID)
-	file, err := driveService.Files.Get(file.Id).Fields(googleapi.Field(driveFieldsSingleText)).Do()
-	if err != nil {
-		return err
+func (rp *RandomPictures) postItem(source models.RandompictureSourceEntry, channelID string, messageID string, item *randomPicturesItem, pictureID string) error {
+	if item == nil {
+		return errors.New("invalid picture data cached")
 	}
 
-	camerModelText := ""
-	if file.ImageMediaMetadata.CameraModel != "" {
-		camerModelText = fmt.Sprintf(" 📷 `%s`", file.ImageMediaMetadata.CameraModel)
+	linkToPost, name, details, err := rp.getProvider(source).Resolve(source, item)
+	if err != nil {
+		return err
 	}
 
-	linkToPost := helpers.GetConfig().Path("imageproxy.base_url").Data().(string)
-
-	splitFilename := strings.Split(file.Name, ".")
-
-	linkToPost = fmt.Sprintf(linkToPost, rp.GetFileHash(sourceID, previousSourceID, file.Id), url.QueryEscape(strings.Join(splitFilename[0:len(splitFilename)-1], "-")+"."+strings.ToLower(splitFilename[len(splitFilename)-1])))
+	guildID := source.GuildID
 	linkToHistory := helpers.GetConfig().Path("website.randompictures_base_url").Data().(string) + guildID
 
 	// open link to prepare cache
@@ -981,7 +1173,7 @@ func (rp *RandomPictures) postItem(guildID string, channelID string, messageID s
 	}
 	helpers.RelaxLog(err)
 
-	err = rp.appendLinkToServerHistory(linkToPost, sourceID, pictureID, file.Name, guildID)
+	err = rp.appendLinkToServerHistory(linkToPost, source.ID, pictureID, name, guildID)
 	helpers.RelaxLog(err)
 
 	var shortUrl string
@@ -996,7 +1188,7 @@ func (rp *RandomPictures) postItem(guildID string, channelID string, messageID s
 
 	embed := &discordgo.MessageEmbed{
 		URL:   shortUrl,
-		Title: "🏷 " + file.Name + camerModelText,
+		Title: "🏷 " + name + details,
 		Author: &discordgo.MessageEmbedAuthor{
 			URL:  linkToHistory,
 			Name: "🖼  Gallery",
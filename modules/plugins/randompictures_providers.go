@@ -0,0 +1,169 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/globalsign/mgo/bson"
+	"github.com/jzelinskie/geddit"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	randomPicturesMaxFileSize        = 8000000 // discords file size limit
+	randomPicturesMaxSourcesPerGuild = 5       // for sources added by server moderators
+	randomPicturesMaxStoragePictures = 250
+	randomPicturesStorageSource      = "randompictures"
+	randomPicturesStorageMetadataKey = "randompictures_sourceid"
+)
+
+// randomPicturesItem is a cached picture of a source
+type randomPicturesItem struct {
+	ID   string `msgpack:"Id"` // named like the field of the drive files cached before there were other providers
+	Name string
+	Link string // the direct link, empty for google drive files
+	Size int64
+}
+
+// randomPicturesProvider is a place pictures of random pictures sources are stored at
+type randomPicturesProvider interface {
+	// List returns all pictures of the source, it is used to fill the cache
+	List(source models.RandompictureSourceEntry) (items []*randomPicturesItem, err error)
+	// Resolve returns the link to post for a cached picture, and additional details to show after the name
+	Resolve(source models.RandompictureSourceEntry, item *randomPicturesItem) (link, name, details string, err error)
+}
+
+func (rp *RandomPictures) getProvider(source models.RandompictureSourceEntry) randomPicturesProvider {
+	switch helpers.RandomPicturesProvider(source) {
+	case models.RandompictureProviderStorage:
+		return &randomPicturesStorageProvider{}
+	case models.RandompictureProviderImgur:
+		return &randomPicturesImgurProvider{}
+	case models.RandompictureProviderReddit:
+		return &randomPicturesRedditProvider{}
+	}
+	return &randomPicturesDriveProvider{rp: rp}
+}
+
+type randomPicturesDriveProvider struct {
+	rp *RandomPictures
+}
+
+func (p *randomPicturesDriveProvider) List(source models.RandompictureSourceEntry) (items []*randomPicturesItem, err error) {
+	for _, file := range p.rp.getFileCache(source) {
+		items = append(items, &randomPicturesItem{ID: file.Id, Name: file.Name, Size: file.Size})
+	}
+	return items, nil
+}
+
+func (p *randomPicturesDriveProvider) Resolve(source models.RandompictureSourceEntry, item *randomPicturesItem) (link, name, details string, err error) {
+	var file *drive.File
+	file, err = driveService.Files.Get(item.ID).Fields(googleapi.Field(driveFieldsSingleText)).Do()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if file.ImageMediaMetadata != nil && file.ImageMediaMetadata.CameraModel != "" {
+		details = fmt.Sprintf(" 📷 `%s`", file.ImageMediaMetadata.CameraModel)
+	}
+
+	splitFilename := strings.Split(file.Name, ".")
+
+	link = fmt.Sprintf(helpers.GetConfig().Path("imageproxy.base_url").Data().(string),
+		p.rp.GetFileHash(source.ID, source.PreviousID, file.Id),
+		url.QueryEscape(strings.Join(splitFilename[0:len(splitFilename)-1], "-")+"."+strings.ToLower(splitFilename[len(splitFilename)-1])))
+
+	return link, file.Name, details, nil
+}
+
+// randomPicturesStorageProvider serves pictures uploaded to the object storage with [p]randompictures upload
+type randomPicturesStorageProvider struct{}
+
+func (p *randomPicturesStorageProvider) List(source models.RandompictureSourceEntry) (items []*randomPicturesItem, err error) {
+	var entries []models.StorageEntry
+	err = helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.StorageTable).Find(
+		bson.M{"metadata." + randomPicturesStorageMetadataKey: helpers.MdbIdToHuman(source.ID)},
+	)).All(&entries)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		items = append(items, &randomPicturesItem{ID: entry.ObjectName, Name: entry.Filename, Size: int64(entry.Filesize)})
+	}
+	return items, nil
+}
+
+func (p *randomPicturesStorageProvider) Resolve(source models.RandompictureSourceEntry, item *randomPicturesItem) (link, name, details string, err error) {
+	link, err = helpers.GetFileLink(item.ID)
+	return link, item.Name, "", err
+}
+
+type randomPicturesImgurProvider struct{}
+
+func (p *randomPicturesImgurProvider) List(source models.RandompictureSourceEntry) (items []*randomPicturesItem, err error) {
+	for _, albumID := range source.ImgurAlbumIDs {
+		images, err := helpers.ImgurAlbumImages(albumID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, image := range images {
+			if !strings.HasPrefix(image.Type, "image/") || image.Size > randomPicturesMaxFileSize {
+				continue
+			}
+
+			name := image.Title
+			if name == "" {
+				name = image.ID
+			}
+			items = append(items, &randomPicturesItem{ID: image.ID, Name: name, Link: image.Link, Size: image.Size})
+		}
+	}
+	return items, nil
+}
+
+func (p *randomPicturesImgurProvider) Resolve(source models.RandompictureSourceEntry, item *randomPicturesItem) (link, name, details string, err error) {
+	return item.Link, item.Name, "", nil
+}
+
+// randomPicturesRedditProvider serves the images of the current hot posts of subreddits, using the session of the reddit module
+type randomPicturesRedditProvider struct{}
+
+func (p *randomPicturesRedditProvider) List(source models.RandompictureSourceEntry) (items []*randomPicturesItem, err error) {
+	if redditSession == nil {
+		return nil, errors.New("reddit is not available")
+	}
+
+	for _, subreddit := range source.Subreddits {
+		submissions, err := redditSession.SubredditSubmissions(subreddit, geddit.HotSubmissions, geddit.ListingOptions{
+			Limit: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, submission := range submissions {
+			// sources can be posted in any channel
+			if submission.IsNSFW || !helpers.RandomPicturesIsImageLink(submission.URL) {
+				continue
+			}
+
+			items = append(items, &randomPicturesItem{
+				ID:   submission.ID,
+				Name: helpers.RandomPicturesRedditName(submission.Title),
+				Link: submission.URL,
+			})
+		}
+	}
+	return items, nil
+}
+
+func (p *randomPicturesRedditProvider) Resolve(source models.RandompictureSourceEntry, item *randomPicturesItem) (link, name, details string, err error) {
+	return item.Link, item.Name, "", nil
+}
@@ -168,6 +168,8 @@ func (r *Reddit) checkSubredditLoop() {
 }
 
 func (r *Reddit) postSubmission(channelID string, submission *geddit.Submission, postDirectLinks bool) (err error) {
+	go helpers.OutgoingWebhookEmitFeedPost("reddit", channelID, submission.Subreddit, RedditBaseUrl+submission.Permalink)
+
 	data := &discordgo.MessageSend{}
 
 	data.Content = "<" + RedditBaseUrl + submission.Permalink + ">"
@@ -0,0 +1,655 @@
+package plugins
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/olebedev/when"
+	"github.com/olebedev/when/rules/common"
+	"github.com/olebedev/when/rules/en"
+	"github.com/sirupsen/logrus"
+)
+
+type scheduleAction func(args []string, in *discordgo.Message, out **discordgo.MessageSend) (next scheduleAction)
+
+type Schedule struct {
+	parser *when.Parser
+}
+
+const (
+	// runs missed by more than this (for example because of downtime) are skipped
+	scheduledPostMaxDelay  = time.Hour
+	scheduledPostsPerGuild = 25
+)
+
+func (m *Schedule) Commands() []string {
+	return []string{
+		"schedule",
+	}
+}
+
+func (m *Schedule) Init(session *discordgo.Session) {
+	m.parser = when.New(nil)
+	m.parser.Add(en.All...)
+	m.parser.Add(common.All...)
+
+	go m.requeueLoop()
+	m.logger().Info("started requeueLoop loop (5m)")
+}
+
+func (m *Schedule) Action(command string, content string, msg *discordgo.Message, session *discordgo.Session) {
+	if !helpers.ModuleIsAllowed(msg.ChannelID, msg.ID, msg.Author.ID, helpers.ModulePermEmbedPost) {
+		return
+	}
+
+	var result *discordgo.MessageSend
+	args := strings.Fields(content)
+
+	action := m.actionStart
+	for action != nil {
+		action = action(args, msg, &result)
+	}
+}
+
+func (m *Schedule) actionStart(args []string, in *discordgo.Message, out **discordgo.MessageSend) scheduleAction {
+	cache.GetSession().ChannelTyping(in.ChannelID)
+
+	if !helpers.IsMod(in) {
+		*out = m.newMsg("mod.no_permission")
+		return m.actionFinish
+	}
+
+	if len(args) < 1 {
+		return m.actionList
+	}
+
+	switch args[0] {
+	case "post", "add":
+		return m.actionPost
+	case "list":
+		return m.actionList
+	case "cancel", "delete", "remove":
+		return m.actionCancel
+	case "preview":
+		return m.actionPreview
+	}
+
+	*out = m.newMsg("bot.arguments.invalid")
+	return m.actionFinish
+}
+
+// [p]schedule post <#channel> "<when or cron>" [ping=<role>] [delete-after=<duration>] <embed code>
+func (m *Schedule) actionPost(args []string, in *discordgo.Message, out **discordgo.MessageSend) scheduleAction {
+	if len(args) < 4 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	sourceChannel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	targetChannel, err := helpers.GetChannelFromMention(in, args[1])
+	if err != nil || targetChannel.GuildID != sourceChannel.GuildID {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	guild, err := helpers.GetGuild(targetChannel.GuildID)
+	helpers.Relax(err)
+
+	existingPosts, err := helpers.MdbCount(models.ScheduledPostsTable, bson.M{"guildid": guild.ID, "active": true})
+	helpers.Relax(err)
+	if existingPosts >= scheduledPostsPerGuild {
+		*out = m.newMsg("plugins.schedule.post-too-many", scheduledPostsPerGuild)
+		return m.actionFinish
+	}
+
+	// everything after the channel mention
+	rest := strings.TrimSpace(in.Content[strings.Index(in.Content, args[1])+len(args[1]):])
+
+	var timeText string
+	if strings.HasPrefix(rest, "\"") {
+		closingIndex := strings.Index(rest[1:], "\"")
+		if closingIndex < 0 {
+			*out = m.newMsg("plugins.schedule.post-invalid-time")
+			return m.actionFinish
+		}
+		timeText = rest[1 : closingIndex+1]
+		rest = strings.TrimSpace(rest[closingIndex+2:])
+	} else {
+		timeText = strings.Fields(rest)[0]
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, timeText))
+	}
+
+	newEntry := models.ScheduledPostEntry{
+		ID:              bson.NewObjectId(),
+		GuildID:         guild.ID,
+		ChannelID:       targetChannel.ID,
+		CreatedByUserID: in.Author.ID,
+		CreatedAt:       time.Now(),
+		Timezone:        "UTC",
+		MentionRoleIDs:  make([]string, 0),
+		Active:          true,
+	}
+
+	// parse options in front of the embed code
+OptionsLoop:
+	for {
+		fields := strings.Fields(rest)
+		if len(fields) <= 0 {
+			break
+		}
+		optionParts := strings.SplitN(fields[0], "=", 2)
+		if len(optionParts) < 2 {
+			break
+		}
+
+		switch strings.ToLower(optionParts[0]) {
+		case "ping":
+			var roleFound bool
+			for _, role := range guild.Roles {
+				if role.ID == optionParts[1] || "<@&"+role.ID+">" == optionParts[1] ||
+					strings.ToLower(role.Name) == strings.ToLower(optionParts[1]) {
+					newEntry.MentionRoleIDs = append(newEntry.MentionRoleIDs, role.ID)
+					roleFound = true
+					break
+				}
+			}
+			if !roleFound {
+				*out = m.newMsg("plugins.schedule.post-invalid-option", fields[0])
+				return m.actionFinish
+			}
+		case "delete-after", "deleteafter":
+			newEntry.DeleteAfter, err = time.ParseDuration(optionParts[1])
+			if err != nil || newEntry.DeleteAfter < time.Minute {
+				*out = m.newMsg("plugins.schedule.post-invalid-option", fields[0])
+				return m.actionFinish
+			}
+		default:
+			// not an option, the embed code starts here
+			break OptionsLoop
+		}
+
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[0]))
+	}
+
+	newEntry.EmbedCode = rest
+	if newEntry.EmbedCode == "" {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+	_, _, err = helpers.ParseEmbedCode(newEntry.EmbedCode)
+	if err != nil {
+		*out = m.newMsg("bot.arguments.invalid")
+		return m.actionFinish
+	}
+
+	location := time.UTC
+	userData, err := helpers.GetUserUserdata(in.Author.ID)
+	if err == nil && userData.Timezone != "" {
+		userLocation, err := time.LoadLocation(userData.Timezone)
+		if err == nil {
+			location = userLocation
+			newEntry.Timezone = userData.Timezone
+		}
+	}
+
+	if helpers.IsCronExpression(timeText) {
+		cronSchedule, err := helpers.ParseCron(timeText)
+		if err != nil {
+			*out = m.newMsg("plugins.schedule.post-invalid-cron", err.Error())
+			return m.actionFinish
+		}
+		newEntry.Cron = timeText
+		newEntry.NextRunAt = cronSchedule.Next(time.Now().In(location))
+	} else {
+		parsedTime, err := m.parser.Parse(timeText, time.Now().In(location))
+		if err != nil || parsedTime == nil {
+			*out = m.newMsg("plugins.schedule.post-invalid-time")
+			return m.actionFinish
+		}
+		newEntry.NextRunAt = parsedTime.Time
+	}
+
+	if newEntry.NextRunAt.IsZero() || !newEntry.NextRunAt.After(time.Now()) {
+		*out = m.newMsg("plugins.schedule.post-invalid-time")
+		return m.actionFinish
+	}
+
+	_, err = helpers.MDbInsert(models.ScheduledPostsTable, newEntry)
+	helpers.Relax(err)
+
+	err = queueScheduledPost(newEntry)
+	helpers.Relax(err)
+
+	options := []models.ElasticEventlogOption{
+		{
+			Key:   "scheduledpost_channelid",
+			Value: newEntry.ChannelID,
+			Type:  models.EventlogTargetTypeChannel,
+		},
+		{
+			Key:   "scheduledpost_embedcode",
+			Value: newEntry.EmbedCode,
+		},
+		{
+			Key:   "scheduledpost_nextrunat",
+			Value: newEntry.NextRunAt.Format(models.ISO8601),
+		},
+		{
+			Key:   "scheduledpost_cron",
+			Value: newEntry.Cron,
+		},
+		{
+			Key:   "scheduledpost_mention_roleids",
+			Value: strings.Join(newEntry.MentionRoleIDs, ","),
+			Type:  models.EventlogTargetTypeRole,
+		},
+	}
+	if newEntry.DeleteAfter > 0 {
+		options = append(options, models.ElasticEventlogOption{
+			Key:   "scheduledpost_deleteafter",
+			Value: newEntry.DeleteAfter.String(),
+		})
+	}
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, helpers.MdbIdToHuman(newEntry.ID),
+		models.EventlogTargetTypeRobyulScheduledPost, in.Author.ID,
+		models.EventlogTypeRobyulScheduledPostCreate, "",
+		nil, options, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.schedule.post-success",
+		helpers.MdbIdToHuman(newEntry.ID), newEntry.ChannelID,
+		newEntry.NextRunAt.Format(time.ANSIC)+" "+newEntry.Timezone)
+	return m.actionFinish
+}
+
+// [p]schedule list
+func (m *Schedule) actionList(args []string, in *discordgo.Message, out **discordgo.MessageSend) scheduleAction {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	var entryBucket []models.ScheduledPostEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.ScheduledPostsTable).Find(bson.M{"guildid": channel.GuildID, "active": true}).Sort("nextrunat"),
+	).All(&entryBucket)
+	helpers.Relax(err)
+
+	if len(entryBucket) <= 0 {
+		*out = m.newMsg("plugins.schedule.list-none")
+		return m.actionFinish
+	}
+
+	var listText string
+	for _, entry := range entryBucket {
+		repeatText := helpers.GetText("plugins.schedule.list-once")
+		if entry.Cron != "" {
+			repeatText = helpers.GetTextF("plugins.schedule.list-cron", entry.Cron)
+		}
+		listText += helpers.GetTextF("plugins.schedule.list-entry",
+			helpers.MdbIdToHuman(entry.ID), entry.ChannelID,
+			m.getNextRunAtInLocation(entry).Format(time.ANSIC)+" "+entry.Timezone, repeatText) + "\n"
+	}
+	listText += helpers.GetTextF("plugins.schedule.list-sum", len(entryBucket))
+
+	for _, page := range helpers.Pagify(listText, "\n") {
+		_, err = helpers.SendMessage(in.ChannelID, page)
+		helpers.RelaxMessage(err, in.ChannelID, in.ID)
+	}
+	return nil
+}
+
+// [p]schedule cancel <id>
+func (m *Schedule) actionCancel(args []string, in *discordgo.Message, out **discordgo.MessageSend) scheduleAction {
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	entry, err := m.getEntry(in, args[1])
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			*out = m.newMsg("plugins.schedule.not-found")
+			return m.actionFinish
+		}
+		helpers.Relax(err)
+	}
+
+	entry.Active = false
+	err = helpers.MDbUpdate(models.ScheduledPostsTable, entry.ID, entry)
+	helpers.Relax(err)
+
+	_, err = helpers.EventlogLog(time.Now(), entry.GuildID, helpers.MdbIdToHuman(entry.ID),
+		models.EventlogTargetTypeRobyulScheduledPost, in.Author.ID,
+		models.EventlogTypeRobyulScheduledPostDelete, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "scheduledpost_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "scheduledpost_embedcode",
+				Value: entry.EmbedCode,
+			},
+			{
+				Key:   "scheduledpost_cron",
+				Value: entry.Cron,
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	*out = m.newMsg("plugins.schedule.cancel-success")
+	return m.actionFinish
+}
+
+// [p]schedule preview <id>
+func (m *Schedule) actionPreview(args []string, in *discordgo.Message, out **discordgo.MessageSend) scheduleAction {
+	if len(args) < 2 {
+		*out = m.newMsg("bot.arguments.too-few")
+		return m.actionFinish
+	}
+
+	entry, err := m.getEntry(in, args[1])
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			*out = m.newMsg("plugins.schedule.not-found")
+			return m.actionFinish
+		}
+		helpers.Relax(err)
+	}
+
+	ptext, embed, err := helpers.ParseEmbedCode(entry.EmbedCode)
+	helpers.Relax(err)
+
+	mentionsText := ""
+	for _, roleID := range entry.MentionRoleIDs {
+		role, err := cache.GetSession().State.Role(entry.GuildID, roleID)
+		if err == nil {
+			mentionsText += "@" + role.Name + " "
+		}
+	}
+
+	*out = &discordgo.MessageSend{
+		Content: strings.TrimSpace(helpers.GetTextF("plugins.schedule.preview",
+			entry.ChannelID, m.getNextRunAtInLocation(entry).Format(time.ANSIC)+" "+entry.Timezone) +
+			"\n" + mentionsText + ptext),
+		Embed: embed,
+	}
+	return m.actionFinish
+}
+
+func (m *Schedule) actionFinish(args []string, in *discordgo.Message, out **discordgo.MessageSend) scheduleAction {
+	_, err := helpers.SendComplex(in.ChannelID, *out)
+	helpers.RelaxMessage(err, in.ChannelID, in.ID)
+
+	return nil
+}
+
+func (m *Schedule) newMsg(content string, replacements ...interface{}) *discordgo.MessageSend {
+	if len(replacements) < 1 {
+		return &discordgo.MessageSend{Content: helpers.GetText(content)}
+	}
+	return &discordgo.MessageSend{Content: helpers.GetTextF(content, replacements...)}
+}
+
+func (m *Schedule) logger() *logrus.Entry {
+	return cache.GetLogger().WithField("module", "schedule")
+}
+
+func (m *Schedule) getEntry(in *discordgo.Message, humanID string) (entry models.ScheduledPostEntry, err error) {
+	channel, err := helpers.GetChannel(in.ChannelID)
+	if err != nil {
+		return entry, err
+	}
+
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.ScheduledPostsTable).Find(bson.M{
+			"_id":     helpers.HumanToMdbId(humanID),
+			"guildid": channel.GuildID,
+			"active":  true,
+		}),
+		&entry,
+	)
+	return entry, err
+}
+
+func (m *Schedule) getNextRunAtInLocation(entry models.ScheduledPostEntry) time.Time {
+	location, err := time.LoadLocation(entry.Timezone)
+	if err != nil {
+		return entry.NextRunAt
+	}
+	return entry.NextRunAt.In(location)
+}
+
+// requeueLoop queues posts again which have been missed, for example if the task queue has been flushed
+// a post can be queued twice, ScheduledPostRun claims the run before posting so only one task will post
+func (m *Schedule) requeueLoop() {
+	defer helpers.Recover()
+	defer func() {
+		go func() {
+			m.logger().Error("the requeueLoop died. Please investigate! Will be restarted in 60 seconds")
+			time.Sleep(60 * time.Second)
+			m.requeueLoop()
+		}()
+	}()
+
+	for {
+		time.Sleep(5 * time.Minute)
+
+		var entryBucket []models.ScheduledPostEntry
+		err := helpers.MDbIterWithoutLogging(helpers.MdbCollection(models.ScheduledPostsTable).Find(bson.M{
+			"active":    true,
+			"nextrunat": bson.M{"$lt": time.Now().Add(-2 * time.Minute)},
+		})).All(&entryBucket)
+		helpers.Relax(err)
+
+		for _, entry := range entryBucket {
+			m.logger().WithField("scheduledPostID", helpers.MdbIdToHuman(entry.ID)).Info("requeueing missed scheduled post")
+			err = queueScheduledPost(entry)
+			helpers.RelaxLog(err)
+		}
+	}
+}
+
+func queueScheduledPost(entry models.ScheduledPostEntry) (err error) {
+	signature := ScheduledPostSignature(entry.ID, entry.NextRunAt)
+	runAt := entry.NextRunAt
+	signature.ETA = &runAt
+
+	_, err = cache.GetMachineryServer().SendTask(signature)
+	return err
+}
+
+func ScheduledPostSignature(entryID bson.ObjectId, runAt time.Time) (signature *tasks.Signature) {
+	signature = &tasks.Signature{
+		Name: "post_scheduled",
+		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: helpers.MdbIdToHuman(entryID),
+			},
+			{
+				Type:  "int64",
+				Value: runAt.Unix(),
+			},
+		},
+	}
+	signature.RetryCount = 3
+	signature.OnError = []*tasks.Signature{{Name: "log_error"}}
+	return signature
+}
+
+func ScheduledPostDeleteSignature(channelID, messageID string) (signature *tasks.Signature) {
+	signature = &tasks.Signature{
+		Name: "delete_scheduled_post",
+		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: channelID,
+			},
+			{
+				Type:  "string",
+				Value: messageID,
+			},
+		},
+	}
+	signature.RetryCount = 3
+	signature.OnError = []*tasks.Signature{{Name: "log_error"}}
+	return signature
+}
+
+// ScheduledPostRun posts a scheduled post and queues the next run, runAt has to match the next run of the post
+func ScheduledPostRun(humanID string, runAt int64) (err error) {
+	var entry models.ScheduledPostEntry
+	err = helpers.MdbOne(
+		helpers.MdbCollection(models.ScheduledPostsTable).Find(bson.M{"_id": helpers.HumanToMdbId(humanID)}),
+		&entry,
+	)
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// cancelled, or this run has already been done by another task
+	if !entry.Active || entry.NextRunAt.Unix() != runAt {
+		return nil
+	}
+
+	nextRunAt := time.Time{}
+	if entry.Cron != "" {
+		cronSchedule, err := helpers.ParseCron(entry.Cron)
+		if err == nil {
+			location, err := time.LoadLocation(entry.Timezone)
+			if err != nil {
+				location = time.UTC
+			}
+			nextRunAt = cronSchedule.Next(time.Now().In(location))
+		}
+	}
+
+	changes := bson.M{
+		"lastrunat": time.Now(),
+		"active":    !nextRunAt.IsZero(),
+	}
+	if !nextRunAt.IsZero() {
+		changes["nextrunat"] = nextRunAt
+	}
+	// claim the run before posting, if the same run has been queued twice only one task matches
+	err = helpers.MDbUpdateQuery(models.ScheduledPostsTable, bson.M{
+		"_id":       entry.ID,
+		"active":    true,
+		"nextrunat": entry.NextRunAt,
+	}, bson.M{
+		"$set": changes,
+		"$inc": bson.M{"runs": 1},
+	})
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if time.Since(entry.NextRunAt) <= scheduledPostMaxDelay {
+		errPost := postScheduledPost(entry)
+		if errPost != nil {
+			cache.GetLogger().WithField("module", "schedule").WithField("scheduledPostID", humanID).Errorf(
+				"posting scheduled post failed: %s", errPost.Error())
+		}
+	} else {
+		cache.GetLogger().WithField("module", "schedule").WithField("scheduledPostID", humanID).Warn(
+			"skipping scheduled post, run has been missed by more than ", scheduledPostMaxDelay.String())
+	}
+
+	if !nextRunAt.IsZero() {
+		entry.NextRunAt = nextRunAt
+		return queueScheduledPost(entry)
+	}
+	return nil
+}
+
+func postScheduledPost(entry models.ScheduledPostEntry) (err error) {
+	ptext, embed, err := helpers.ParseEmbedCode(entry.EmbedCode)
+	if err != nil {
+		return err
+	}
+
+	mentionsText, resetMentions := helpers.MentionRoles(entry.GuildID, entry.MentionRoleIDs)
+	defer resetMentions()
+
+	newMessages, err := helpers.SendComplex(entry.ChannelID, &discordgo.MessageSend{
+		Content: strings.TrimSpace(mentionsText + ptext),
+		Embed:   embed,
+	})
+	if err != nil {
+		return err
+	}
+
+	newMessageIDs := make([]string, 0)
+	for _, newMessage := range newMessages {
+		newMessageIDs = append(newMessageIDs, newMessage.ID)
+
+		if entry.DeleteAfter > 0 {
+			signature := ScheduledPostDeleteSignature(newMessage.ChannelID, newMessage.ID)
+			deleteAt := time.Now().Add(entry.DeleteAfter)
+			signature.ETA = &deleteAt
+
+			_, err = cache.GetMachineryServer().SendTask(signature)
+			helpers.RelaxLog(err)
+		}
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), entry.GuildID, strings.Join(newMessageIDs, ","),
+		models.EventlogTargetTypeMessage, entry.CreatedByUserID,
+		models.EventlogTypeRobyulPostCreate, "",
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "post_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "post_embedcode",
+				Value: entry.EmbedCode,
+			},
+			{
+				Key:   "post_scheduledpostid",
+				Value: helpers.MdbIdToHuman(entry.ID),
+			},
+			{
+				Key:   "post_run",
+				Value: strconv.Itoa(entry.Runs + 1),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	return nil
+}
+
+// ScheduledPostDeleteMessage deletes a posted scheduled post
+func ScheduledPostDeleteMessage(channelID, messageID string) (err error) {
+	err = cache.GetSession().ChannelMessageDelete(channelID, messageID)
+	if err != nil {
+		if errD, ok := err.(*discordgo.RESTError); ok {
+			if errD.Message.Code == discordgo.ErrCodeUnknownMessage ||
+				errD.Message.Code == discordgo.ErrCodeUnknownChannel ||
+				errD.Message.Code == discordgo.ErrCodeMissingAccess ||
+				errD.Message.Code == discordgo.ErrCodeMissingPermissions {
+				return nil
+			}
+		}
+	}
+	return err
+}
@@ -766,6 +766,21 @@ func (s *Starboard) PostOrUpdateDiscordMessage(starEntry models.StarboardEntry)
 		}
 		starEntry.StarboardMessageID = starboardPostMessages[0].ID
 		starEntry.StarboardMessageChannelID = starboardPostMessages[0].ChannelID
+
+		go func() {
+			defer helpers.Recover()
+
+			helpers.OutgoingWebhookEmit(starEntry.GuildID, models.OutgoingWebhookEventStarboardEntry, models.OutgoingWebhookStarboardEntryData{
+				MessageID:          starEntry.MessageID,
+				ChannelID:          starEntry.ChannelID,
+				AuthorID:           starEntry.AuthorID,
+				Content:            starEntry.MessageContent,
+				AttachmentURLs:     starEntry.MessageAttachmentURLs,
+				Stars:              starEntry.Stars,
+				StarboardMessageID: starEntry.StarboardMessageID,
+			})
+		}()
+
 		return s.setStarboardEntry(starEntry)
 	}
 }
@@ -425,6 +425,9 @@ func (m *Twitch) getTwitchStatus(name string) TwitchStatus {
 }
 
 func (m *Twitch) postTwitchLiveToChannel(entry models.TwitchEntry, twitchStatus TwitchStatus) {
+	go helpers.OutgoingWebhookEmitFeedPost("twitch", entry.ChannelID, twitchStatus.Stream.Channel.Name,
+		twitchStatus.Stream.Channel.URL)
+
 	twitchStreamName := twitchStatus.Stream.Channel.DisplayName
 	if strings.ToLower(twitchStatus.Stream.Channel.Name) != strings.ToLower(twitchStatus.Stream.Channel.DisplayName) {
 		twitchStreamName += fmt.Sprintf(" (%s)", twitchStatus.Stream.Channel.Name)
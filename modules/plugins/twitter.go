@@ -251,6 +251,11 @@ func (t *Twitter) updateTwitterStreamLoop() {
 	}
 }
 
+// TwitterStreamRequireUpdate restarts the twitter stream with the current feeds, for example after a feed has been removed
+func TwitterStreamRequireUpdate() {
+	twitterStreamNeedsUpdate = true
+}
+
 func (m *Twitter) checkTwitterFeedsLoop() {
 	defer helpers.Recover()
 	defer func() {
@@ -744,6 +749,9 @@ func (m *Twitter) Action(command string, content string, msg *discordgo.Message,
 }
 
 func (m *Twitter) postTweetToChannel(channelID string, tweet *twitter.Tweet, twitterUser *twitter.User, entry models.TwitterEntry) {
+	go helpers.OutgoingWebhookEmitFeedPost("twitter", channelID, twitterUser.ScreenName,
+		fmt.Sprintf(TwitterFriendlyStatus, twitterUser.ScreenName, tweet.IDStr))
+
 	if entry.PostMode == models.TwitterPostModeDiscordEmbed || entry.PostMode == models.TwitterPostModeText {
 		content := fmt.Sprintf("%s", fmt.Sprintf(TwitterFriendlyStatus, twitterUser.ScreenName, tweet.IDStr))
 		if entry.PostMode == models.TwitterPostModeText {
@@ -867,6 +875,9 @@ func (m *Twitter) postTweetToChannel(channelID string, tweet *twitter.Tweet, twi
 }
 
 func (m *Twitter) postAnacondaTweetToChannel(channelID string, tweet *anaconda.Tweet, twitterUser *anaconda.User, entry models.TwitterEntry) {
+	go helpers.OutgoingWebhookEmitFeedPost("twitter", channelID, twitterUser.ScreenName,
+		fmt.Sprintf(TwitterFriendlyStatus, twitterUser.ScreenName, tweet.IdStr))
+
 	if entry.PostMode == models.TwitterPostModeDiscordEmbed || entry.PostMode == models.TwitterPostModeText {
 		content := fmt.Sprintf("%s", fmt.Sprintf(TwitterFriendlyStatus, twitterUser.ScreenName, tweet.IdStr))
 		if entry.PostMode == models.TwitterPostModeText {
@@ -1,6 +1,8 @@
 package plugins
 
 import (
+	"bytes"
+	"strconv"
 	"strings"
 
 	"fmt"
@@ -21,6 +23,7 @@ type VanityInvite struct{}
 func (vi VanityInvite) Commands() []string {
 	return []string{
 		"vanity-invite",
+		"vanityinvite",
 		"custom-invite",
 	}
 }
@@ -56,6 +59,8 @@ func (vi VanityInvite) actionStart(args []string, in *discordgo.Message, out **d
 			return vi.actionRemove
 		case "set-log":
 			return vi.actionSetLog
+		case "stats", "statistics":
+			return vi.actionStats
 		}
 	}
 
@@ -193,6 +198,110 @@ func (vi VanityInvite) actionRemove(args []string, in *discordgo.Message, out **
 	return vi.actionFinish
 }
 
+// [p]custom-invite stats [<days>]
+func (vi VanityInvite) actionStats(args []string, in *discordgo.Message, out **discordgo.MessageSend) vanityInviteAction {
+	if !helpers.IsMod(in) {
+		*out = vi.newMsg("mod.no_permission")
+		return vi.actionFinish
+	}
+
+	days := 30
+	if len(args) >= 2 {
+		var err error
+		days, err = strconv.Atoi(args[1])
+		if err != nil || days < 2 || days > 90 {
+			*out = vi.newMsg("plugins.vanityinvite.stats-invalid-days")
+			return vi.actionFinish
+		}
+	}
+
+	channel, err := helpers.GetChannel(in.ChannelID)
+	helpers.Relax(err)
+
+	vanityInvite, _ := helpers.GetVanityUrlByGuildID(channel.GuildID)
+	if vanityInvite.VanityName == "" {
+		*out = vi.newMsg("plugins.vanityinvite.status-none")
+		return vi.actionFinish
+	}
+
+	from := time.Now().AddDate(0, 0, -days)
+
+	labels, clicks, joins, err := helpers.GetVanityInviteDailyHistogram(vanityInvite, days)
+	helpers.Relax(err)
+	referers, err := helpers.GetVanityInviteConversions(vanityInvite, "Referer", from)
+	helpers.Relax(err)
+	campaigns, err := helpers.GetVanityInviteConversions(vanityInvite, "Campaign", from)
+	helpers.Relax(err)
+	retention, err := helpers.GetVanityInviteRetention(vanityInvite, from)
+	helpers.Relax(err)
+
+	chart := helpers.DrawLineChart(
+		helpers.GetTextF("plugins.vanityinvite.stats-chart-title", vanityInvite.VanityNamePretty, days),
+		labels,
+		[]helpers.ChartSeries{
+			{Name: helpers.GetText("plugins.vanityinvite.stats-clicks"), Values: clicks, Color: "#7289da"},
+			{Name: helpers.GetText("plugins.vanityinvite.stats-joins"), Values: joins, Color: "#43b581"},
+		},
+		800, 400,
+	)
+
+	embed := &discordgo.MessageEmbed{
+		Title: helpers.GetTextF("plugins.vanityinvite.stats-embed-title", vanityInvite.VanityNamePretty, days),
+		Description: helpers.GetTextF("plugins.vanityinvite.stats-embed-description",
+			helpers.GetConfig().Path("website.vanityurl_domain").Data().(string), vanityInvite.VanityNamePretty),
+		Color: helpers.GetDiscordColorFromHex("#7289da"),
+		Image: &discordgo.MessageEmbedImage{URL: "attachment://vanityinvite-stats.png"},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: helpers.GetText("plugins.vanityinvite.stats-referers"), Value: vi.conversionsText(referers)},
+			{Name: helpers.GetText("plugins.vanityinvite.stats-campaigns"), Value: vi.conversionsText(campaigns)},
+			{Name: helpers.GetText("plugins.vanityinvite.stats-retention"), Value: vi.retentionText(retention)},
+		},
+	}
+
+	*out = &discordgo.MessageSend{
+		Embed: embed,
+		Files: []*discordgo.File{
+			{
+				Name:   "vanityinvite-stats.png",
+				Reader: bytes.NewReader(chart),
+			},
+		},
+	}
+	return vi.actionFinish
+}
+
+func (vi VanityInvite) conversionsText(conversions []helpers.VanityInviteConversion) (text string) {
+	for i, conversion := range conversions {
+		if i >= 10 {
+			break
+		}
+		key := conversion.Key
+		if key == "" {
+			key = helpers.GetText("plugins.vanityinvite.stats-direct")
+		}
+		text += helpers.GetTextF("plugins.vanityinvite.stats-conversion",
+			key, conversion.Clicks, conversion.Joins, conversion.Rate()*100) + "\n"
+	}
+	if text == "" {
+		text = helpers.GetText("plugins.vanityinvite.stats-none")
+	}
+	return text
+}
+
+func (vi VanityInvite) retentionText(retention []helpers.VanityInviteRetention) (text string) {
+	for _, item := range retention {
+		if item.Joined <= 0 {
+			continue
+		}
+		text += helpers.GetTextF("plugins.vanityinvite.stats-retention-entry",
+			item.Days, float64(item.Retained)/float64(item.Joined)*100, item.Retained, item.Joined) + "\n"
+	}
+	if text == "" {
+		text = helpers.GetText("plugins.vanityinvite.stats-none")
+	}
+	return text
+}
+
 // [p]custom-invite set-log <#channel or channel id>
 func (vi VanityInvite) actionSetLog(args []string, in *discordgo.Message, out **discordgo.MessageSend) vanityInviteAction {
 	if !helpers.IsRobyulMod(in.Author.ID) {
@@ -674,6 +674,8 @@ func (r *VLive) getVLiveChannelByVliveChannelId(channelId string) (models.VliveC
 }
 
 func (r *VLive) postVodToChannel(entry models.VliveEntry, vod models.VliveVideoInfo, vliveChannel models.VliveChannelInfo) {
+	go helpers.OutgoingWebhookEmitFeedPost("vlive", entry.ChannelID, vliveChannel.Name, vod.Url)
+
 	channelEmbed := &discordgo.MessageEmbed{
 		Title:     helpers.GetTextF("plugins.vlive.channel-embed-title-vod", vliveChannel.Name),
 		URL:       vod.Url,
@@ -727,6 +729,8 @@ func (r *VLive) postUpcomingToChannel(entry models.VliveEntry, vod models.VliveV
 }
 
 func (r *VLive) postLiveToChannel(entry models.VliveEntry, vod models.VliveVideoInfo, vliveChannel models.VliveChannelInfo) {
+	go helpers.OutgoingWebhookEmitFeedPost("vlive", entry.ChannelID, vliveChannel.Name, vod.Url)
+
 	channelEmbed := &discordgo.MessageEmbed{
 		Title:     helpers.GetTextF("plugins.vlive.channel-embed-title-live", vliveChannel.Name),
 		URL:       vod.Url,
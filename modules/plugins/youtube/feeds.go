@@ -134,6 +134,9 @@ func (f *feeds) checkChannelFeeds(e models.YoutubeChannelEntry) models.YoutubeCh
 			break
 		}
 
+		go helpers.OutgoingWebhookEmitFeedPost("youtube", e.ChannelID, feed.Snippet.ChannelTitle,
+			fmt.Sprintf(youtubeVideoBaseUrl, videoId))
+
 		newPostedVideos = append(newPostedVideos, videoId)
 
 		logger().WithFields(logrus.Fields{
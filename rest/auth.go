@@ -0,0 +1,325 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/cache"
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/bwmarrin/discordgo"
+	"github.com/emicklei/go-restful"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack"
+)
+
+const (
+	sessionLifetime     = time.Hour * 24 * 7
+	oauth2StateLifetime = time.Minute * 10
+)
+
+// webkeyAuthenticate only accepts the global webkey, sent in the Authorization header
+func webkeyAuthenticate(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	if !isWebkeyRequest(request) {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	request.SetAttribute("UserID", "global")
+	chain.ProcessFilter(request, response)
+}
+
+// authenticate returns a filter which accepts the global webkey, Discord OAuth2 sessions, and api tokens having the scope
+// api tokens are only accepted for the guild in the guild-id path parameter, an empty scope does not accept any api tokens
+func authenticate(scope string) restful.FilterFunction {
+	return func(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+		authorizationHeader := strings.TrimSpace(request.HeaderParameter("Authorization"))
+
+		if isWebkeyRequest(request) {
+			request.SetAttribute("UserID", "global")
+			chain.ProcessFilter(request, response)
+			return
+		}
+
+		if strings.HasPrefix(authorizationHeader, "Session ") {
+			session, err := getSession(strings.TrimSpace(strings.TrimPrefix(authorizationHeader, "Session ")))
+			if err == nil && session.UserID != "" {
+				request.SetAttribute("UserID", session.UserID)
+				chain.ProcessFilter(request, response)
+				return
+			}
+		}
+
+		if scope != "" && strings.HasPrefix(authorizationHeader, "Bearer ") {
+			apiTokenAuthenticate(scope, strings.TrimSpace(strings.TrimPrefix(authorizationHeader, "Bearer ")),
+				request, response, chain)
+			return
+		}
+
+		response.WriteErrorString(401, "401: Not Authorized")
+	}
+}
+
+func isWebkeyRequest(request *restful.Request) bool {
+	authorizationHeader := strings.TrimSpace(request.HeaderParameter("Authorization"))
+	if !strings.HasPrefix(authorizationHeader, "Webkey ") {
+		return false
+	}
+
+	webkey := strings.TrimSpace(strings.TrimPrefix(authorizationHeader, "Webkey "))
+	return webkey != "" && webkey == helpers.GetConfig().Path("website.webkey").Data().(string)
+}
+
+// api tokens are treated like the global webkey, restricted to the guild of the token
+func apiTokenAuthenticate(scope, token string, request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	entry, err := helpers.GetApiTokenByToken(token)
+	if err != nil {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	if !helpers.ApiTokenHasScope(entry, scope) || request.PathParameter("guild-id") != entry.GuildID {
+		response.WriteErrorString(403, "403: Forbidden")
+		logApiTokenRequest(entry, request, 403)
+		return
+	}
+
+	redis := cache.GetRedisClient()
+	key := fmt.Sprintf(models.ApiTokenRatelimitRedisKey, helpers.MdbIdToHuman(entry.ID), time.Now().Unix()/60)
+	requests, err := redis.Incr(key).Result()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	if requests == 1 {
+		redis.Expire(key, time.Minute*2)
+	}
+	if requests > helpers.ApiTokenRatelimit {
+		response.AddHeader("Retry-After", "60")
+		response.WriteErrorString(429, "429: Too Many Requests")
+		logApiTokenRequest(entry, request, 429)
+		return
+	}
+
+	request.SetAttribute("UserID", "global")
+	request.SetAttribute("ApiTokenID", helpers.MdbIdToHuman(entry.ID))
+	chain.ProcessFilter(request, response)
+
+	logApiTokenRequest(entry, request, response.StatusCode())
+}
+
+func logApiTokenRequest(entry models.ApiTokenEntry, request *restful.Request, statusCode int) {
+	now := time.Now()
+
+	err := helpers.MDbUpdateQueryWithoutLogging(models.ApiTokensTable,
+		bson.M{"_id": entry.ID}, bson.M{"$set": bson.M{"lastusedat": now}})
+	helpers.RelaxLog(err)
+
+	_, err = helpers.MDbInsertWithoutLogging(models.ApiTokenRequestsTable, models.ApiTokenRequestEntry{
+		TokenID:    helpers.MdbIdToHuman(entry.ID),
+		GuildID:    entry.GuildID,
+		Method:     request.Request.Method,
+		Path:       request.Request.URL.Path,
+		StatusCode: statusCode,
+		RemoteAddr: request.Request.RemoteAddr,
+		CreatedAt:  now,
+	})
+	helpers.RelaxLog(err)
+}
+
+func getSession(sessionToken string) (session models.ApiSessionRedisEntry, err error) {
+	if sessionToken == "" {
+		return session, errors.New("empty session token")
+	}
+
+	sessionData, err := cache.GetRedisClient().Get(sessionRedisKey(sessionToken)).Bytes()
+	if err != nil {
+		return session, err
+	}
+
+	err = msgpack.Unmarshal(sessionData, &session)
+	return session, err
+}
+
+// sessions are stored by the hash of the session token
+func sessionRedisKey(sessionToken string) string {
+	return fmt.Sprintf(models.ApiSessionRedisKey, helpers.HashApiToken(sessionToken))
+}
+
+func newRandomToken() (token string, err error) {
+	data := make([]byte, 32)
+	_, err = rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// AuthLogin redirects to the Discord OAuth2 authorization
+func AuthLogin(request *restful.Request, response *restful.Response) {
+	state, err := newRandomToken()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = cache.GetRedisClient().Set(fmt.Sprintf(models.ApiOAuth2StateRedisKey, state), "1", oauth2StateLifetime).Err()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	parameters := url.Values{}
+	parameters.Set("client_id", helpers.GetConfig().Path("discord.oauth2.client_id").Data().(string))
+	parameters.Set("redirect_uri", helpers.GetConfig().Path("discord.oauth2.redirect_uri").Data().(string))
+	parameters.Set("response_type", "code")
+	parameters.Set("scope", "identify")
+	parameters.Set("state", state)
+
+	http.Redirect(response.ResponseWriter, request.Request,
+		discordgo.EndpointOauth2+"authorize?"+parameters.Encode(), http.StatusFound)
+}
+
+// AuthCallback exchanges the Discord OAuth2 code for a new session
+func AuthCallback(request *restful.Request, response *restful.Response) {
+	code := request.QueryParameter("code")
+	state := request.QueryParameter("state")
+	if code == "" || state == "" {
+		response.WriteError(http.StatusBadRequest, errors.New("missing code or state"))
+		return
+	}
+
+	deleted, err := cache.GetRedisClient().Del(fmt.Sprintf(models.ApiOAuth2StateRedisKey, state)).Result()
+	if err != nil || deleted <= 0 {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	user, err := getOAuth2User(code)
+	if err != nil {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	sessionToken, err := newRandomToken()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	sessionData, err := msgpack.Marshal(models.ApiSessionRedisEntry{
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = cache.GetRedisClient().Set(sessionRedisKey(sessionToken), sessionData, sessionLifetime).Err()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	response.WriteEntity(models.Rest_Auth_Session{
+		SessionToken: sessionToken,
+		User: models.Rest_User{
+			ID:            user.ID,
+			Username:      user.Username,
+			AvatarHash:    user.Avatar,
+			Discriminator: user.Discriminator,
+			Bot:           user.Bot,
+		},
+		ExpiresAt: time.Now().Add(sessionLifetime),
+	})
+}
+
+// AuthLogout deletes the current session
+func AuthLogout(request *restful.Request, response *restful.Response) {
+	authorizationHeader := strings.TrimSpace(request.HeaderParameter("Authorization"))
+	if !strings.HasPrefix(authorizationHeader, "Session ") {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	err := cache.GetRedisClient().Del(
+		sessionRedisKey(strings.TrimSpace(strings.TrimPrefix(authorizationHeader, "Session ")))).Err()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// exchanges an OAuth2 code for an access token and returns the user of the token
+func getOAuth2User(code string) (user *discordgo.User, err error) {
+	client := &http.Client{
+		Timeout: time.Duration(15 * time.Second),
+	}
+
+	tokenResponse, err := client.PostForm(discordgo.EndpointOauth2+"token", url.Values{
+		"client_id":     {helpers.GetConfig().Path("discord.oauth2.client_id").Data().(string)},
+		"client_secret": {helpers.GetConfig().Path("discord.oauth2.client_secret").Data().(string)},
+		"redirect_uri":  {helpers.GetConfig().Path("discord.oauth2.redirect_uri").Data().(string)},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"scope":         {"identify"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tokenResponse.Body.Close()
+	if tokenResponse.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code exchanging oauth2 code: %d", tokenResponse.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+	}
+	err = json.NewDecoder(tokenResponse.Body).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	userRequest, err := http.NewRequest("GET", discordgo.EndpointUser("@me"), nil)
+	if err != nil {
+		return nil, err
+	}
+	userRequest.Header.Set("Authorization", token.TokenType+" "+token.AccessToken)
+	userRequest.Header.Set("User-Agent", helpers.DEFAULT_UA)
+
+	userResponse, err := client.Do(userRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer userResponse.Body.Close()
+	if userResponse.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code requesting oauth2 user: %d", userResponse.StatusCode)
+	}
+
+	userData, err := ioutil.ReadAll(userResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(userData, &user)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.ID == "" {
+		return nil, errors.New("invalid oauth2 user")
+	}
+
+	return user, nil
+}
@@ -90,7 +90,7 @@ func getGuildSettings(guildID, userID string) (settings models.Rest_Settings) {
 			newSetting.Values = make([]string, 0)
 		}
 
-		if !helpers.IsModByID(guildID, userID) {
+		if userID != "global" && !helpers.IsModByID(guildID, userID) {
 			newSetting.Values = nil
 		}
 
@@ -103,7 +103,7 @@ func getGuildSettings(guildID, userID string) (settings models.Rest_Settings) {
 func setGuildStringSetting(guildID, userID, key string, values []string) (err error) {
 	switch key {
 	case "eventlog_discord_channelid":
-		if !helpers.IsModByID(guildID, userID) {
+		if userID != "global" && !helpers.IsModByID(guildID, userID) {
 			return errors.New("not allowed")
 		}
 		var guild *discordgo.Guild
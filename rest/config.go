@@ -0,0 +1,1232 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/Seklfreak/Robyul2/modules/plugins"
+	"github.com/bwmarrin/discordgo"
+	"github.com/emicklei/go-restful"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+)
+
+var (
+	greeterTypes = map[string]models.GreeterType{
+		"join":  models.GreeterTypeJoin,
+		"leave": models.GreeterTypeLeave,
+		"ban":   models.GreeterTypeBan,
+	}
+	greeterSetEventlogTypes = map[models.GreeterType]string{
+		models.GreeterTypeJoin:  models.EventlogTypeRobyulGuildAnnouncementsJoinSet,
+		models.GreeterTypeLeave: models.EventlogTypeRobyulGuildAnnouncementsLeaveSet,
+		models.GreeterTypeBan:   models.EventlogTypeRobyulGuildAnnouncementsBanSet,
+	}
+	greeterRemoveEventlogTypes = map[models.GreeterType]string{
+		models.GreeterTypeJoin:  models.EventlogTypeRobyulGuildAnnouncementsJoinRemove,
+		models.GreeterTypeLeave: models.EventlogTypeRobyulGuildAnnouncementsLeaveRemove,
+		models.GreeterTypeBan:   models.EventlogTypeRobyulGuildAnnouncementsBanRemove,
+	}
+)
+
+// getConfigRequester checks if the requester is allowed to access the configuration of the guild in the path
+// userID is empty for the webkey and api tokens, reason should be used for all eventlog entries
+// writes an error and returns false if the requester is not allowed
+func getConfigRequester(request *restful.Request, response *restful.Response, level models.SettingLevel,
+) (guild *discordgo.Guild, userID, reason string, ok bool) {
+	guild, err := helpers.GetGuild(request.PathParameter("guild-id"))
+	if err != nil || guild == nil || guild.ID == "" {
+		response.WriteError(http.StatusNotFound, errors.New("Guild not found."))
+		return nil, "", "", false
+	}
+
+	requesterUserID := request.Attribute("UserID").(string)
+	if requesterUserID == "global" {
+		if apiTokenID, isApiToken := request.Attribute("ApiTokenID").(string); isApiToken && apiTokenID != "" {
+			return guild, "", "changed using the API token #" + apiTokenID, true
+		}
+		return guild, "", "changed using the dashboard", true
+	}
+
+	if level == helpers.SettingLevelAdmin {
+		ok = helpers.IsAdminByID(guild.ID, requesterUserID)
+	} else {
+		ok = helpers.IsModByID(guild.ID, requesterUserID)
+	}
+	if !ok {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return nil, "", "", false
+	}
+
+	return guild, requesterUserID, "changed using the dashboard", true
+}
+
+func guildHasChannel(guild *discordgo.Guild, channelID string) bool {
+	for _, channel := range guild.Channels {
+		if channel.ID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+func guildHasRole(guild *discordgo.Guild, roleID string) bool {
+	for _, role := range guild.Roles {
+		if role.ID == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the items of after which are not in before
+func configListAdded(before, after []string) (added []string) {
+	added = make([]string, 0)
+NextItem:
+	for _, afterItem := range after {
+		for _, beforeItem := range before {
+			if beforeItem == afterItem {
+				continue NextItem
+			}
+		}
+		added = append(added, afterItem)
+	}
+	return added
+}
+
+func GetConfigGreeters(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	var entryBucket []models.GreeterEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.GreeterTable).Find(bson.M{"guildid": guild.ID})).All(&entryBucket)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	greeters := make([]models.Rest_Config_Greeter, 0)
+	for _, entry := range entryBucket {
+		for typeName, greeterType := range greeterTypes {
+			if greeterType == entry.Type {
+				greeters = append(greeters, models.Rest_Config_Greeter{
+					Type:      typeName,
+					ChannelID: entry.ChannelID,
+					EmbedCode: entry.EmbedCode,
+				})
+			}
+		}
+	}
+
+	response.WriteEntity(greeters)
+}
+
+func PutConfigGreeter(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelAdmin)
+	if !ok {
+		return
+	}
+
+	greeter := new(models.Rest_Config_Greeter)
+	err := request.ReadEntity(&greeter)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	greeterType, ok := greeterTypes[strings.ToLower(greeter.Type)]
+	if !ok {
+		response.WriteError(http.StatusBadRequest, errors.New("invalid greeter type"))
+		return
+	}
+	if !guildHasChannel(guild, greeter.ChannelID) {
+		response.WriteError(http.StatusBadRequest, errors.New("channel not found"))
+		return
+	}
+	greeter.EmbedCode = strings.TrimSpace(greeter.EmbedCode)
+	if greeter.EmbedCode == "" {
+		response.WriteError(http.StatusBadRequest, errors.New("empty embed code"))
+		return
+	}
+
+	err = helpers.MDbUpsert(
+		models.GreeterTable,
+		bson.M{"type": greeterType, "guildid": guild.ID, "channelid": greeter.ChannelID},
+		models.GreeterEntry{
+			GuildID:   guild.ID,
+			ChannelID: greeter.ChannelID,
+			Type:      greeterType,
+			EmbedCode: greeter.EmbedCode,
+		},
+	)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, greeter.ChannelID,
+		models.EventlogTargetTypeChannel, userID,
+		greeterSetEventlogTypes[greeterType], reason,
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   strings.ToLower(greeter.Type) + "_text",
+				Value: greeter.EmbedCode,
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	response.WriteEntity(greeter)
+}
+
+func DeleteConfigGreeter(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelAdmin)
+	if !ok {
+		return
+	}
+
+	typeName := strings.ToLower(request.PathParameter("type"))
+	greeterType, ok := greeterTypes[typeName]
+	if !ok {
+		response.WriteError(http.StatusBadRequest, errors.New("invalid greeter type"))
+		return
+	}
+
+	var entry models.GreeterEntry
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.GreeterTable).Find(bson.M{
+			"type": greeterType, "guildid": guild.ID, "channelid": request.PathParameter("channel-id"),
+		}),
+		&entry,
+	)
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			response.WriteError(http.StatusNotFound, errors.New("greeter not found"))
+			return
+		}
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = helpers.MDbDelete(models.GreeterTable, entry.Id)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, entry.ChannelID,
+		models.EventlogTargetTypeChannel, userID,
+		greeterRemoveEventlogTypes[greeterType], reason,
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   typeName + "_text",
+				Value: entry.EmbedCode,
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func GetConfigAutoroles(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+
+	autoroles := make([]models.Rest_Config_Autorole, 0)
+	for _, roleID := range settings.AutoRoleIDs {
+		autoroles = append(autoroles, models.Rest_Config_Autorole{
+			RoleID: roleID,
+		})
+	}
+	for _, delayedRole := range settings.DelayedAutoRoles {
+		autoroles = append(autoroles, models.Rest_Config_Autorole{
+			RoleID:       delayedRole.RoleID,
+			DelaySeconds: int64(delayedRole.Delay / time.Second),
+		})
+	}
+
+	response.WriteEntity(autoroles)
+}
+
+func PutConfigAutorole(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelAdmin)
+	if !ok {
+		return
+	}
+
+	autorole := new(models.Rest_Config_Autorole)
+	err := request.ReadEntity(&autorole)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !guildHasRole(guild, autorole.RoleID) || autorole.DelaySeconds < 0 {
+		response.WriteError(http.StatusBadRequest, errors.New("invalid role or delay"))
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+
+	for _, roleID := range settings.AutoRoleIDs {
+		if roleID == autorole.RoleID {
+			response.WriteError(http.StatusConflict, errors.New("role is already an autorole"))
+			return
+		}
+	}
+	for _, delayedRole := range settings.DelayedAutoRoles {
+		if delayedRole.RoleID == autorole.RoleID {
+			response.WriteError(http.StatusConflict, errors.New("role is already an autorole"))
+			return
+		}
+	}
+
+	delay := time.Duration(autorole.DelaySeconds) * time.Second
+	if delay <= 0 {
+		settings.AutoRoleIDs = append(settings.AutoRoleIDs, autorole.RoleID)
+	} else {
+		settings.DelayedAutoRoles = append(settings.DelayedAutoRoles, models.DelayedAutoRole{
+			RoleID: autorole.RoleID,
+			Delay:  delay,
+		})
+	}
+
+	err = helpers.GuildSettingsSet(guild.ID, settings)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	options := make([]models.ElasticEventlogOption, 0)
+	if delay > 0 {
+		options = append(options, models.ElasticEventlogOption{
+			Key:   "autorole_delay",
+			Value: delay.String(),
+		})
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, autorole.RoleID,
+		models.EventlogTargetTypeRole, userID,
+		models.EventlogTypeRobyulAutoroleAdd, reason,
+		nil,
+		options, false)
+	helpers.RelaxLog(err)
+
+	response.WriteEntity(autorole)
+}
+
+func DeleteConfigAutorole(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelAdmin)
+	if !ok {
+		return
+	}
+
+	roleID := request.PathParameter("role-id")
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+
+	var removed bool
+	var delay time.Duration
+	newRoleIDs := make([]string, 0)
+	newDelayedRoles := make([]models.DelayedAutoRole, 0)
+	for _, autoroleID := range settings.AutoRoleIDs {
+		if autoroleID == roleID {
+			removed = true
+			continue
+		}
+		newRoleIDs = append(newRoleIDs, autoroleID)
+	}
+	for _, delayedRole := range settings.DelayedAutoRoles {
+		if delayedRole.RoleID == roleID {
+			removed = true
+			delay = delayedRole.Delay
+			continue
+		}
+		newDelayedRoles = append(newDelayedRoles, delayedRole)
+	}
+
+	if !removed {
+		response.WriteError(http.StatusNotFound, errors.New("autorole not found"))
+		return
+	}
+
+	settings.AutoRoleIDs = newRoleIDs
+	settings.DelayedAutoRoles = newDelayedRoles
+	err := helpers.GuildSettingsSet(guild.ID, settings)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	options := make([]models.ElasticEventlogOption, 0)
+	if delay > 0 {
+		options = append(options, models.ElasticEventlogOption{
+			Key:   "autorole_delay",
+			Value: delay.String(),
+		})
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, roleID,
+		models.EventlogTargetTypeRole, userID,
+		models.EventlogTypeRobyulAutoroleRemove, reason,
+		nil,
+		options, false)
+	helpers.RelaxLog(err)
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func GetConfigStarboard(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+
+	starboard := models.Rest_Config_Starboard{
+		ChannelID: settings.StarboardChannelID,
+		Minimum:   settings.StarboardMinimum,
+		Emoji:     settings.StarboardEmoji,
+	}
+	if starboard.Emoji == nil {
+		starboard.Emoji = make([]string, 0)
+	}
+
+	response.WriteEntity(starboard)
+}
+
+func PutConfigStarboard(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	starboard := new(models.Rest_Config_Starboard)
+	err := request.ReadEntity(&starboard)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !guildHasChannel(guild, starboard.ChannelID) {
+		response.WriteError(http.StatusBadRequest, errors.New("channel not found"))
+		return
+	}
+	if starboard.Minimum < 0 {
+		response.WriteError(http.StatusBadRequest, errors.New("invalid minimum"))
+		return
+	}
+
+	newEmoji := make([]string, 0)
+	for _, emoji := range starboard.Emoji {
+		if !helpers.IsEmoji(emoji) {
+			response.WriteError(http.StatusBadRequest, errors.New("invalid emoji"))
+			return
+		}
+		// custom emoji are stored by their name
+		if helpers.IsDiscordEmoji(emoji) {
+			discordEmoji, err := helpers.GetDiscordEmojiFromText(guild.ID, emoji)
+			if err != nil || discordEmoji == nil || discordEmoji.Name == "" {
+				response.WriteError(http.StatusBadRequest, errors.New("invalid emoji"))
+				return
+			}
+			emoji = discordEmoji.Name
+		}
+		if len(configListAdded(newEmoji, []string{emoji})) > 0 {
+			newEmoji = append(newEmoji, emoji)
+		}
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+	before := settings
+
+	settings.StarboardChannelID = starboard.ChannelID
+	settings.StarboardMinimum = starboard.Minimum
+	settings.StarboardEmoji = newEmoji
+	err = helpers.GuildSettingsSet(guild.ID, settings)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	changes := make([]models.ElasticEventlogChange, 0)
+	if before.StarboardChannelID != "" && before.StarboardChannelID != settings.StarboardChannelID {
+		changes = append(changes, models.ElasticEventlogChange{
+			Key:      "starboard_channelid",
+			OldValue: before.StarboardChannelID,
+			NewValue: settings.StarboardChannelID,
+			Type:     models.EventlogTargetTypeChannel,
+		})
+	}
+	if before.StarboardMinimum != settings.StarboardMinimum {
+		changes = append(changes, models.ElasticEventlogChange{
+			Key:      "starboard_minimum",
+			OldValue: strconv.Itoa(before.StarboardMinimum),
+			NewValue: strconv.Itoa(settings.StarboardMinimum),
+		})
+	}
+	if strings.Join(before.StarboardEmoji, ",") != strings.Join(settings.StarboardEmoji, ",") {
+		changes = append(changes, models.ElasticEventlogChange{
+			Key:      "starboard_emoji",
+			OldValue: strings.Join(before.StarboardEmoji, ","),
+			NewValue: strings.Join(settings.StarboardEmoji, ","),
+		})
+	}
+
+	actionType := models.EventlogTypeRobyulStarboardUpdate
+	if before.StarboardChannelID != settings.StarboardChannelID {
+		actionType = models.EventlogTypeRobyulStarboardCreate
+	}
+	if actionType == models.EventlogTypeRobyulStarboardCreate || len(changes) > 0 {
+		_, err = helpers.EventlogLog(time.Now(), guild.ID, settings.StarboardChannelID,
+			models.EventlogTargetTypeChannel, userID,
+			actionType, reason,
+			changes,
+			nil, false)
+		helpers.RelaxLog(err)
+	}
+
+	starboard.Emoji = newEmoji
+	response.WriteEntity(starboard)
+}
+
+func DeleteConfigStarboard(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+	if settings.StarboardChannelID == "" {
+		response.WriteError(http.StatusNotFound, errors.New("starboard not set"))
+		return
+	}
+
+	beforeChannelID := settings.StarboardChannelID
+	settings.StarboardChannelID = ""
+	err := helpers.GuildSettingsSet(guild.ID, settings)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, beforeChannelID,
+		models.EventlogTargetTypeChannel, userID,
+		models.EventlogTypeRobyulStarboardDelete, reason,
+		nil,
+		[]models.ElasticEventlogOption{
+			{
+				Key:   "starboard_emoji",
+				Value: strings.Join(settings.StarboardEmoji, ","),
+				Type:  models.EventlogTargetTypeEmoji,
+			},
+			{
+				Key:   "starboard_minimum",
+				Value: strconv.Itoa(settings.StarboardMinimum),
+			},
+		}, false)
+	helpers.RelaxLog(err)
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// returns the names of the modules, all if all modules are set
+func modulePermissionsToNames(permissions models.ModulePermissionsModule) (names []string) {
+	names = make([]string, 0)
+	if permissions <= 0 {
+		return names
+	}
+	if permissions&helpers.ModulePermAllPlaceholder == helpers.ModulePermAllPlaceholder {
+		return append(names, "all")
+	}
+	for _, module := range helpers.Modules {
+		if permissions&module.Permission == module.Permission && len(module.Names) > 0 {
+			names = append(names, module.Names[0])
+		}
+	}
+	return names
+}
+
+// parses module names like the modulepermissions command, all sets all modules
+func modulePermissionsFromNames(names []string) (permissions models.ModulePermissionsModule, err error) {
+NextName:
+	for _, name := range names {
+		if strings.ToLower(name) == "all" {
+			permissions |= helpers.ModulePermAll | helpers.ModulePermAllPlaceholder
+			continue
+		}
+		for _, module := range helpers.Modules {
+			for _, moduleName := range module.Names {
+				if strings.ToLower(moduleName) == strings.ToLower(name) {
+					permissions |= module.Permission
+					continue NextName
+				}
+			}
+		}
+		return 0, errors.New("module not found: " + name)
+	}
+	return permissions, nil
+}
+
+func GetConfigModulePermissions(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	modulePermissions := make([]models.Rest_Config_ModulePermission, 0)
+	for _, entry := range helpers.GetModulePermissionEntries(guild.ID) {
+		modulePermissions = append(modulePermissions, models.Rest_Config_ModulePermission{
+			Type:     entry.Type,
+			TargetID: entry.TargetID,
+			Allowed:  modulePermissionsToNames(entry.Allowed),
+			Denied:   modulePermissionsToNames(entry.Denied),
+		})
+	}
+
+	response.WriteEntity(modulePermissions)
+}
+
+func PutConfigModulePermission(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	modulePermission := new(models.Rest_Config_ModulePermission)
+	err := request.ReadEntity(&modulePermission)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	allowed, err := modulePermissionsFromNames(modulePermission.Allowed)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+	denied, err := modulePermissionsFromNames(modulePermission.Denied)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = setConfigModulePermission(guild, userID, reason,
+		modulePermission.Type, modulePermission.TargetID, allowed, denied)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	modulePermission.Allowed = modulePermissionsToNames(allowed)
+	modulePermission.Denied = modulePermissionsToNames(denied)
+	response.WriteEntity(modulePermission)
+}
+
+func DeleteConfigModulePermission(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	err := setConfigModulePermission(guild, userID, reason,
+		request.PathParameter("type"), request.PathParameter("target-id"), 0, 0)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// sets the allowed and denied modules for a channel or a role, logs every added and removed module like the command
+func setConfigModulePermission(guild *discordgo.Guild, userID, reason, targetType, targetID string,
+	allowed, denied models.ModulePermissionsModule) (err error) {
+	var beforeAllowed, beforeDenied models.ModulePermissionsModule
+	var eventlogTargetType string
+	var allowAdd, allowRemove, denyAdd, denyRemove string
+	switch targetType {
+	case "channel":
+		if !guildHasChannel(guild, targetID) {
+			return errors.New("channel not found")
+		}
+		beforeAllowed = helpers.GetAllowedForChannel(guild.ID, targetID)
+		beforeDenied = helpers.GetDeniedForChannel(guild.ID, targetID)
+		err = helpers.SetAllowedForChannel(guild.ID, targetID, allowed)
+		if err != nil {
+			return err
+		}
+		err = helpers.SetDeniedForChannel(guild.ID, targetID, denied)
+		eventlogTargetType = models.EventlogTargetTypeChannel
+		allowAdd, allowRemove = models.EventlogTypeRobyulModuleAllowChannelAdd, models.EventlogTypeRobyulModuleAllowChannelRemove
+		denyAdd, denyRemove = models.EventlogTypeRobyulModuleDenyChannelAdd, models.EventlogTypeRobyulModuleDenyChannelRemove
+	case "role":
+		if !guildHasRole(guild, targetID) {
+			return errors.New("role not found")
+		}
+		beforeAllowed = helpers.GetAllowedForRole(guild.ID, targetID)
+		beforeDenied = helpers.GetDeniedForRole(guild.ID, targetID)
+		err = helpers.SetAllowedForRole(guild.ID, targetID, allowed)
+		if err != nil {
+			return err
+		}
+		err = helpers.SetDeniedForRole(guild.ID, targetID, denied)
+		eventlogTargetType = models.EventlogTargetTypeRole
+		allowAdd, allowRemove = models.EventlogTypeRobyulModuleAllowRoleAdd, models.EventlogTypeRobyulModuleAllowRoleRemove
+		denyAdd, denyRemove = models.EventlogTypeRobyulModuleDenyRoleAdd, models.EventlogTypeRobyulModuleDenyRoleRemove
+	default:
+		return errors.New("invalid type")
+	}
+	if err != nil {
+		return err
+	}
+
+	logModules := func(actionType, optionKey string, modules []string) {
+		for _, module := range modules {
+			if module == "all" {
+				module = helpers.GetModuleNameById(helpers.ModulePermAll | helpers.ModulePermAllPlaceholder)
+			}
+			_, err := helpers.EventlogLog(time.Now(), guild.ID, targetID,
+				eventlogTargetType, userID,
+				actionType, reason,
+				nil,
+				[]models.ElasticEventlogOption{
+					{
+						Key:   optionKey,
+						Value: module,
+					},
+				}, false)
+			helpers.RelaxLog(err)
+		}
+	}
+
+	beforeAllowedNames, allowedNames := modulePermissionsToNames(beforeAllowed), modulePermissionsToNames(allowed)
+	beforeDeniedNames, deniedNames := modulePermissionsToNames(beforeDenied), modulePermissionsToNames(denied)
+	logModules(allowAdd, "module_allow_"+targetType+"_added", configListAdded(beforeAllowedNames, allowedNames))
+	logModules(allowRemove, "module_allow_"+targetType+"_removed", configListAdded(allowedNames, beforeAllowedNames))
+	logModules(denyAdd, "module_deny_"+targetType+"_added", configListAdded(beforeDeniedNames, deniedNames))
+	logModules(denyRemove, "module_deny_"+targetType+"_removed", configListAdded(deniedNames, beforeDeniedNames))
+
+	return nil
+}
+
+func GetConfigLevelsRoles(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	var entryBucket []models.LevelsRoleEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.LevelsRolesTable).Find(bson.M{"guildid": guild.ID})).All(&entryBucket)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	levelsRoles := make([]models.Rest_Config_LevelsRole, 0)
+	for _, entry := range entryBucket {
+		levelsRoles = append(levelsRoles, models.Rest_Config_LevelsRole{
+			ID:         helpers.MdbIdToHuman(entry.ID),
+			RoleID:     entry.RoleID,
+			StartLevel: entry.StartLevel,
+			LastLevel:  entry.LastLevel,
+			Rep:        entry.Type == models.LevelsRoleTypeRep,
+		})
+	}
+
+	response.WriteEntity(levelsRoles)
+}
+
+func PutConfigLevelsRole(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	levelsRole := new(models.Rest_Config_LevelsRole)
+	err := request.ReadEntity(&levelsRole)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !guildHasRole(guild, levelsRole.RoleID) || levelsRole.StartLevel < 0 ||
+		(levelsRole.LastLevel < 0 && levelsRole.LastLevel != -1) ||
+		(levelsRole.LastLevel != -1 && levelsRole.StartLevel > levelsRole.LastLevel) {
+		response.WriteError(http.StatusBadRequest, errors.New("invalid role or levels"))
+		return
+	}
+
+	roleType := models.LevelsRoleTypeLevel
+	if levelsRole.Rep {
+		roleType = models.LevelsRoleTypeRep
+	}
+
+	newID, err := helpers.MDbInsert(
+		models.LevelsRolesTable,
+		models.LevelsRoleEntry{
+			GuildID:    guild.ID,
+			RoleID:     levelsRole.RoleID,
+			Type:       roleType,
+			StartLevel: levelsRole.StartLevel,
+			LastLevel:  levelsRole.LastLevel,
+		},
+	)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	levelsRole.ID = helpers.MdbIdToHuman(newID)
+
+	options := []models.ElasticEventlogOption{
+		{
+			Key:   "role_startlevel",
+			Value: strconv.Itoa(levelsRole.StartLevel),
+		},
+	}
+	if levelsRole.LastLevel >= 0 {
+		options = append(options, models.ElasticEventlogOption{
+			Key:   "role_lastlevel",
+			Value: strconv.Itoa(levelsRole.LastLevel),
+		})
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, levelsRole.RoleID,
+		models.EventlogTargetTypeRole, userID,
+		models.EventlogTypeRobyulLevelsRoleAdd, reason,
+		nil,
+		options, false)
+	helpers.RelaxLog(err)
+
+	response.WriteEntity(levelsRole)
+}
+
+func DeleteConfigLevelsRole(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	var entry models.LevelsRoleEntry
+	err := helpers.MdbOne(
+		helpers.MdbCollection(models.LevelsRolesTable).Find(bson.M{
+			"_id": helpers.HumanToMdbId(request.PathParameter("levels-role-id")), "guildid": guild.ID,
+		}),
+		&entry,
+	)
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			response.WriteError(http.StatusNotFound, errors.New("levels role not found"))
+			return
+		}
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = helpers.MDbDelete(models.LevelsRolesTable, entry.ID)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	options := []models.ElasticEventlogOption{
+		{
+			Key:   "role_startlevel",
+			Value: strconv.Itoa(entry.StartLevel),
+		},
+	}
+	if entry.LastLevel >= 0 {
+		options = append(options, models.ElasticEventlogOption{
+			Key:   "role_lastlevel",
+			Value: strconv.Itoa(entry.LastLevel),
+		})
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, entry.RoleID,
+		models.EventlogTargetTypeRole, userID,
+		models.EventlogTypeRobyulLevelsRoleDelete, reason,
+		nil,
+		options, false)
+	helpers.RelaxLog(err)
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+func GetConfigPersistency(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+
+	persistency := models.Rest_Config_Persistency{
+		BiasRolesEnabled: settings.PersistencyBiasEnabled,
+		RoleIDs:          settings.PersistencyRoleIDs,
+	}
+	if persistency.RoleIDs == nil {
+		persistency.RoleIDs = make([]string, 0)
+	}
+
+	response.WriteEntity(persistency)
+}
+
+func PutConfigPersistency(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	persistency := new(models.Rest_Config_Persistency)
+	err := request.ReadEntity(&persistency)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	newRoleIDs := make([]string, 0)
+	for _, roleID := range persistency.RoleIDs {
+		if !guildHasRole(guild, roleID) {
+			response.WriteError(http.StatusBadRequest, errors.New("role not found"))
+			return
+		}
+		if len(configListAdded(newRoleIDs, []string{roleID})) > 0 {
+			newRoleIDs = append(newRoleIDs, roleID)
+		}
+	}
+
+	settings := helpers.GuildSettingsGetCached(guild.ID)
+	before := settings
+
+	settings.PersistencyBiasEnabled = persistency.BiasRolesEnabled
+	settings.PersistencyRoleIDs = newRoleIDs
+	err = helpers.GuildSettingsSet(guild.ID, settings)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if before.PersistencyBiasEnabled != settings.PersistencyBiasEnabled {
+		_, err = helpers.EventlogLog(time.Now(), guild.ID, guild.ID,
+			models.EventlogTargetTypeGuild, userID,
+			models.EventlogTypeRobyulPersistencyBiasRoles, reason,
+			[]models.ElasticEventlogChange{
+				{
+					Key:      "persistency_biasroles_persist",
+					OldValue: helpers.StoreBoolAsString(before.PersistencyBiasEnabled),
+					NewValue: helpers.StoreBoolAsString(settings.PersistencyBiasEnabled),
+				},
+			},
+			nil, false)
+		helpers.RelaxLog(err)
+	}
+
+	logRoles := func(actionType, optionKey string, roleIDs []string) {
+		if len(roleIDs) <= 0 {
+			return
+		}
+		_, err := helpers.EventlogLog(time.Now(), guild.ID, guild.ID,
+			models.EventlogTargetTypeGuild, userID,
+			actionType, reason,
+			[]models.ElasticEventlogChange{
+				{
+					Key:      "persistency_roleids",
+					OldValue: strings.Join(before.PersistencyRoleIDs, ","),
+					NewValue: strings.Join(settings.PersistencyRoleIDs, ","),
+					Type:     models.EventlogTargetTypeRole,
+				},
+			},
+			[]models.ElasticEventlogOption{
+				{
+					Key:   optionKey,
+					Value: strings.Join(roleIDs, ","),
+					Type:  models.EventlogTargetTypeRole,
+				},
+			}, false)
+		helpers.RelaxLog(err)
+	}
+	logRoles(models.EventlogTypeRobyulPersistencyRoleAdd, "persistency_roleids_added",
+		configListAdded(before.PersistencyRoleIDs, settings.PersistencyRoleIDs))
+	logRoles(models.EventlogTypeRobyulPersistencyRoleRemove, "persistency_roleids_removed",
+		configListAdded(settings.PersistencyRoleIDs, before.PersistencyRoleIDs))
+
+	persistency.RoleIDs = newRoleIDs
+	response.WriteEntity(persistency)
+}
+
+func GetConfigFeeds(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	feeds := make([]models.Rest_Config_Feed, 0)
+	query := bson.M{"guildid": guild.ID}
+
+	var vliveEntries []models.VliveEntry
+	err := helpers.MDbIter(helpers.MdbCollection(models.VliveTable).Find(query)).All(&vliveEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range vliveEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "vlive", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.VLiveChannel.Name, MentionRoleID: entry.MentionRoleID})
+	}
+
+	var youtubeEntries []models.YoutubeChannelEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.YoutubeChannelTable).Find(query)).All(&youtubeEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range youtubeEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "youtube", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.YoutubeChannelName})
+	}
+
+	var instagramEntries []models.InstagramEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.InstagramTable).Find(query)).All(&instagramEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range instagramEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "instagram", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.Username})
+	}
+
+	var redditEntries []models.RedditSubredditEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.RedditSubredditsTable).Find(query)).All(&redditEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range redditEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "reddit", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.SubredditName})
+	}
+
+	var facebookEntries []models.FacebookEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.FacebookTable).Find(query)).All(&facebookEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range facebookEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "facebook", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.Username})
+	}
+
+	var twitchEntries []models.TwitchEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.TwitchTable).Find(query)).All(&twitchEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range twitchEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "twitch", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.TwitchChannelName, MentionRoleID: entry.MentionRoleID})
+	}
+
+	var twitterEntries []models.TwitterEntry
+	err = helpers.MDbIter(helpers.MdbCollection(models.TwitterTable).Find(query)).All(&twitterEntries)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, entry := range twitterEntries {
+		feeds = append(feeds, models.Rest_Config_Feed{Type: "twitter", ID: helpers.MdbIdToHuman(entry.ID),
+			ChannelID: entry.ChannelID, Name: entry.AccountScreenName, MentionRoleID: entry.MentionRoleID})
+	}
+
+	response.WriteEntity(feeds)
+}
+
+// DeleteConfigFeed removes a feed, YouTube feeds can only be removed with the command because of the quota accounting
+func DeleteConfigFeed(request *restful.Request, response *restful.Response) {
+	guild, userID, reason, ok := getConfigRequester(request, response, helpers.SettingLevelMod)
+	if !ok {
+		return
+	}
+
+	query := bson.M{"_id": helpers.HumanToMdbId(request.PathParameter("feed-id")), "guildid": guild.ID}
+
+	var err error
+	var collection models.MongoDbCollection
+	var id bson.ObjectId
+	var eventlogTargetType, actionType string
+	var options []models.ElasticEventlogOption
+	switch request.PathParameter("type") {
+	case "vlive":
+		var entry models.VliveEntry
+		err = helpers.MdbOne(helpers.MdbCollection(models.VliveTable).Find(query), &entry)
+		collection, id = models.VliveTable, entry.ID
+		eventlogTargetType, actionType = models.EventlogTargetTypeRobyulVliveFeed, models.EventlogTypeRobyulVliveFeedRemove
+		options = []models.ElasticEventlogOption{
+			{
+				Key:   "vlive_feed_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "vlive_feed_vlivechannel_name",
+				Value: entry.VLiveChannel.Name,
+			},
+			{
+				Key:   "vlive_feed_vlivechannel_code",
+				Value: entry.VLiveChannel.Code,
+			},
+			{
+				Key:   "vlive_feed_mentionroleid",
+				Value: entry.MentionRoleID,
+				Type:  models.EventlogTargetTypeRole,
+			},
+		}
+	case "instagram":
+		var entry models.InstagramEntry
+		err = helpers.MdbOne(helpers.MdbCollection(models.InstagramTable).Find(query), &entry)
+		collection, id = models.InstagramTable, entry.ID
+		eventlogTargetType, actionType = models.EventlogTargetTypeRobyulInstagramFeed, models.EventlogTypeRobyulInstagramFeedRemove
+		options = []models.ElasticEventlogOption{
+			{
+				Key:   "instagram_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "instagram_sendposttype",
+				Value: strconv.Itoa(int(entry.SendPostType)),
+			},
+			{
+				Key:   "instagram_instagramuserid",
+				Value: entry.InstagramUserIDString,
+			},
+			{
+				Key:   "instagram_instagramusername",
+				Value: entry.Username,
+			},
+		}
+	case "reddit":
+		var entry models.RedditSubredditEntry
+		err = helpers.MdbOne(helpers.MdbCollection(models.RedditSubredditsTable).Find(query), &entry)
+		collection, id = models.RedditSubredditsTable, entry.ID
+		eventlogTargetType, actionType = models.EventlogTargetTypeRobyulRedditFeed, models.EventlogTypeRobyulRedditFeedRemove
+		options = []models.ElasticEventlogOption{
+			{
+				Key:   "reddit_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "reddit_postdirectlinks",
+				Value: helpers.StoreBoolAsString(entry.PostDirectLinks),
+			},
+			{
+				Key:   "reddit_postdelay",
+				Value: strconv.Itoa(entry.PostDelay),
+			},
+			{
+				Key:   "reddit_subredditname",
+				Value: entry.SubredditName,
+			},
+		}
+	case "facebook":
+		var entry models.FacebookEntry
+		err = helpers.MdbOne(helpers.MdbCollection(models.FacebookTable).Find(query), &entry)
+		collection, id = models.FacebookTable, entry.ID
+		eventlogTargetType, actionType = models.EventlogTargetTypeRobyulFacebookFeed, models.EventlogTypeRobyulFacebookFeedRemove
+		options = []models.ElasticEventlogOption{
+			{
+				Key:   "facebook_channelid",
+				Value: entry.ChannelID,
+			},
+			{
+				Key:   "facebook_facebookusername",
+				Value: entry.Username,
+			},
+		}
+	case "twitch":
+		var entry models.TwitchEntry
+		err = helpers.MdbOne(helpers.MdbCollection(models.TwitchTable).Find(query), &entry)
+		collection, id = models.TwitchTable, entry.ID
+		eventlogTargetType, actionType = models.EventlogTargetTypeRobyulTwitchFeed, models.EventlogTypeRobyulTwitchFeedRemove
+		options = []models.ElasticEventlogOption{
+			{
+				Key:   "twitch_feed_channelname",
+				Value: entry.TwitchChannelName,
+			},
+			{
+				Key:   "twitch_feed_mentionroleid",
+				Value: entry.MentionRoleID,
+				Type:  models.EventlogTargetTypeRole,
+			},
+		}
+	case "twitter":
+		var entry models.TwitterEntry
+		err = helpers.MdbOne(helpers.MdbCollection(models.TwitterTable).Find(query), &entry)
+		collection, id = models.TwitterTable, entry.ID
+		eventlogTargetType, actionType = models.EventlogTargetTypeRobyulTwitterFeed, models.EventlogTypeRobyulTwitterFeedRemove
+		options = []models.ElasticEventlogOption{
+			{
+				Key:   "twitter_channelid",
+				Value: entry.ChannelID,
+				Type:  models.EventlogTargetTypeChannel,
+			},
+			{
+				Key:   "twitter_accountscreename",
+				Value: entry.AccountScreenName,
+			},
+			{
+				Key:   "twitter_accountid",
+				Value: entry.AccountID,
+			},
+			{
+				Key:   "twitter_mentionroleid",
+				Value: entry.MentionRoleID,
+				Type:  models.EventlogTargetTypeRole,
+			},
+		}
+	default:
+		response.WriteError(http.StatusBadRequest, errors.New("invalid or unsupported feed type"))
+		return
+	}
+	if err != nil {
+		if helpers.IsMdbNotFound(err) {
+			response.WriteError(http.StatusNotFound, errors.New("feed not found"))
+			return
+		}
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = helpers.MDbDelete(collection, id)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if collection == models.TwitterTable {
+		plugins.TwitterStreamRequireUpdate()
+	}
+
+	_, err = helpers.EventlogLog(time.Now(), guild.ID, helpers.MdbIdToHuman(id),
+		eventlogTargetType, userID,
+		actionType, reason,
+		nil,
+		options, false)
+	helpers.RelaxLog(err)
+
+	response.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Seklfreak/Robyul2/helpers"
+	"github.com/Seklfreak/Robyul2/models"
+	"github.com/emicklei/go-restful"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+)
+
+const (
+	outgoingWebhookDeliveriesDefaultLimit = 50
+	outgoingWebhookDeliveriesMaxLimit     = 200
+)
+
+func GetOutgoingWebhooks(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelAdmin)
+	if !ok {
+		return
+	}
+
+	var entryBucket []models.OutgoingWebhookEntry
+	err := helpers.MDbIter(
+		helpers.MdbCollection(models.OutgoingWebhooksTable).Find(bson.M{"guildid": guild.ID}).Sort("createdat"),
+	).All(&entryBucket)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	webhooks := make([]models.Rest_OutgoingWebhook, 0)
+	for _, entry := range entryBucket {
+		webhooks = append(webhooks, models.Rest_OutgoingWebhook{
+			ID:              helpers.MdbIdToHuman(entry.ID),
+			URL:             entry.URL,
+			EventTypes:      entry.EventTypes,
+			CreatedByUserID: entry.CreatedByUserID,
+			CreatedAt:       entry.CreatedAt,
+		})
+	}
+
+	response.WriteEntity(webhooks)
+}
+
+// GetOutgoingWebhookDeliveries returns the latest deliveries of a webhook, the limit query parameter defaults to 50
+func GetOutgoingWebhookDeliveries(request *restful.Request, response *restful.Response) {
+	guild, _, _, ok := getConfigRequester(request, response, helpers.SettingLevelAdmin)
+	if !ok {
+		return
+	}
+
+	limit := outgoingWebhookDeliveriesDefaultLimit
+	if request.QueryParameter("limit") != "" {
+		var err error
+		limit, err = strconv.Atoi(request.QueryParameter("limit"))
+		if err != nil || limit <= 0 || limit > outgoingWebhookDeliveriesMaxLimit {
+			response.WriteError(http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+	}
+
+	webhookID := request.PathParameter("webhook-id")
+	count, err := helpers.MdbCount(models.OutgoingWebhooksTable, bson.M{
+		"_id": helpers.HumanToMdbId(webhookID), "guildid": guild.ID,
+	})
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	if count <= 0 {
+		response.WriteError(http.StatusNotFound, errors.New("outgoing webhook not found"))
+		return
+	}
+
+	var deliveryBucket []models.OutgoingWebhookDeliveryEntry
+	err = helpers.MDbIter(
+		helpers.MdbCollection(models.OutgoingWebhookDeliveriesTable).Find(bson.M{
+			"webhookid": webhookID,
+			"guildid":   guild.ID,
+		}).Sort("-createdat").Limit(limit),
+	).All(&deliveryBucket)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	deliveries := make([]models.Rest_OutgoingWebhook_Delivery, 0)
+	for _, delivery := range deliveryBucket {
+		deliveries = append(deliveries, models.Rest_OutgoingWebhook_Delivery{
+			ID:             helpers.MdbIdToHuman(delivery.ID),
+			WebhookID:      delivery.WebhookID,
+			EventType:      delivery.EventType,
+			Payload:        delivery.Payload,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			LastAttemptAt:  delivery.LastAttemptAt,
+		})
+	}
+
+	response.WriteEntity(deliveries)
+}
@@ -46,7 +46,7 @@ func NewRestServices() []*restful.WebService {
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	service.Route(service.GET("/{user-id}").Filter(sessionAndWebkeyAuthenticate).To(FindUser))
+	service.Route(service.GET("/{user-id}").Filter(authenticate("")).To(FindUser))
 	service.Route(service.GET("/{user-id}/guilds").Filter(webkeyAuthenticate).To(FindUserGuilds))
 	services = append(services, service)
 
@@ -87,8 +87,29 @@ func NewRestServices() []*restful.WebService {
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	service.Route(service.GET("/{guild-id}").Filter(sessionAndWebkeyAuthenticate).To(FindGuild))
-	service.Route(service.POST("/{guild-id}/set-settings").Filter(sessionAndWebkeyAuthenticate).To(SetGuildSettings).Reads(&models.Rest_Receive_SetSettings{}))
+	service.Route(service.GET("/{guild-id}").Filter(authenticate(models.ApiScopeGuildRead)).To(FindGuild))
+	service.Route(service.POST("/{guild-id}/set-settings").Filter(authenticate(models.ApiScopeSettingsWrite)).To(SetGuildSettings).Reads(&models.Rest_Receive_SetSettings{}))
+	service.Route(service.GET("/{guild-id}/config/greeters").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigGreeters))
+	service.Route(service.PUT("/{guild-id}/config/greeters").Filter(authenticate(models.ApiScopeSettingsWrite)).To(PutConfigGreeter).Reads(&models.Rest_Config_Greeter{}))
+	service.Route(service.DELETE("/{guild-id}/config/greeters/{type}/{channel-id}").Filter(authenticate(models.ApiScopeSettingsWrite)).To(DeleteConfigGreeter))
+	service.Route(service.GET("/{guild-id}/config/autoroles").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigAutoroles))
+	service.Route(service.PUT("/{guild-id}/config/autoroles").Filter(authenticate(models.ApiScopeSettingsWrite)).To(PutConfigAutorole).Reads(&models.Rest_Config_Autorole{}))
+	service.Route(service.DELETE("/{guild-id}/config/autoroles/{role-id}").Filter(authenticate(models.ApiScopeSettingsWrite)).To(DeleteConfigAutorole))
+	service.Route(service.GET("/{guild-id}/config/starboard").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigStarboard))
+	service.Route(service.PUT("/{guild-id}/config/starboard").Filter(authenticate(models.ApiScopeSettingsWrite)).To(PutConfigStarboard).Reads(&models.Rest_Config_Starboard{}))
+	service.Route(service.DELETE("/{guild-id}/config/starboard").Filter(authenticate(models.ApiScopeSettingsWrite)).To(DeleteConfigStarboard))
+	service.Route(service.GET("/{guild-id}/config/module-permissions").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigModulePermissions))
+	service.Route(service.PUT("/{guild-id}/config/module-permissions").Filter(authenticate(models.ApiScopeSettingsWrite)).To(PutConfigModulePermission).Reads(&models.Rest_Config_ModulePermission{}))
+	service.Route(service.DELETE("/{guild-id}/config/module-permissions/{type}/{target-id}").Filter(authenticate(models.ApiScopeSettingsWrite)).To(DeleteConfigModulePermission))
+	service.Route(service.GET("/{guild-id}/config/levels-roles").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigLevelsRoles))
+	service.Route(service.PUT("/{guild-id}/config/levels-roles").Filter(authenticate(models.ApiScopeSettingsWrite)).To(PutConfigLevelsRole).Reads(&models.Rest_Config_LevelsRole{}))
+	service.Route(service.DELETE("/{guild-id}/config/levels-roles/{levels-role-id}").Filter(authenticate(models.ApiScopeSettingsWrite)).To(DeleteConfigLevelsRole))
+	service.Route(service.GET("/{guild-id}/config/persistency").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigPersistency))
+	service.Route(service.PUT("/{guild-id}/config/persistency").Filter(authenticate(models.ApiScopeSettingsWrite)).To(PutConfigPersistency).Reads(&models.Rest_Config_Persistency{}))
+	service.Route(service.GET("/{guild-id}/config/feeds").Filter(authenticate(models.ApiScopeGuildRead)).To(GetConfigFeeds))
+	service.Route(service.DELETE("/{guild-id}/config/feeds/{type}/{feed-id}").Filter(authenticate(models.ApiScopeSettingsWrite)).To(DeleteConfigFeed))
+	service.Route(service.GET("/{guild-id}/outgoing-webhooks").Filter(authenticate(models.ApiScopeGuildRead)).To(GetOutgoingWebhooks))
+	service.Route(service.GET("/{guild-id}/outgoing-webhooks/{webhook-id}/deliveries").Filter(authenticate(models.ApiScopeGuildRead)).To(GetOutgoingWebhookDeliveries))
 	services = append(services, service)
 
 	service = new(restful.WebService)
@@ -106,11 +127,12 @@ func NewRestServices() []*restful.WebService {
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	service.Route(service.GET("/{guild-id}/messages/{interval}/count").Filter(sessionAndWebkeyAuthenticate).To(GetMessageStatisticsCount))
-	service.Route(service.GET("/{guild-id}/joins/{interval}/count").Filter(sessionAndWebkeyAuthenticate).To(GetJoinsStatisticsCount))
-	service.Route(service.GET("/{guild-id}/leaves/{interval}/count").Filter(sessionAndWebkeyAuthenticate).To(GetLeavesStatisticsCount))
-	service.Route(service.GET("/{guild-id}/serveractivity/{interval}/histogram/{count}").Filter(sessionAndWebkeyAuthenticate).To(GetServerActivityStatisticsHistogram))
-	service.Route(service.GET("/{guild-id}/vanityinvite/{interval}/histogram/{count}").Filter(sessionAndWebkeyAuthenticate).To(GetVanityInviteStatistics))
+	service.Route(service.GET("/{guild-id}/messages/{interval}/count").Filter(authenticate(models.ApiScopeStatsRead)).To(GetMessageStatisticsCount))
+	service.Route(service.GET("/{guild-id}/joins/{interval}/count").Filter(authenticate(models.ApiScopeStatsRead)).To(GetJoinsStatisticsCount))
+	service.Route(service.GET("/{guild-id}/leaves/{interval}/count").Filter(authenticate(models.ApiScopeStatsRead)).To(GetLeavesStatisticsCount))
+	service.Route(service.GET("/{guild-id}/serveractivity/{interval}/histogram/{count}").Filter(authenticate(models.ApiScopeStatsRead)).To(GetServerActivityStatisticsHistogram))
+	service.Route(service.GET("/{guild-id}/vanityinvite/{interval}/histogram/{count}").Filter(authenticate(models.ApiScopeStatsRead)).To(GetVanityInviteStatistics))
+	service.Route(service.GET("/{guild-id}/vanityinvite/{interval}/conversions/{count}").Filter(authenticate(models.ApiScopeStatsRead)).To(GetVanityInviteConversions))
 	service.Route(service.GET("/bot").Filter(webkeyAuthenticate).To(GotBotStatistics))
 	services = append(services, service)
 
@@ -120,7 +142,9 @@ func NewRestServices() []*restful.WebService {
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	service.Route(service.GET("/{guild-id}/{channel-id}/around/{message-id}").Filter(sessionAndWebkeyAuthenticate).To(GetChatlogAroundMessageID))
+	service.Route(service.GET("/{guild-id}/{channel-id}/around/{message-id}").Filter(authenticate(models.ApiScopeChatlogRead)).To(GetChatlogAroundMessageID))
+	service.Route(service.GET("/{guild-id}/search").Filter(authenticate(models.ApiScopeChatlogRead)).To(SearchChatlog))
+	service.Route(service.GET("/{guild-id}/user/{user-id}").Filter(authenticate(models.ApiScopeChatlogRead)).To(GetChatlogUserHistory))
 	services = append(services, service)
 
 	service = new(restful.WebService)
@@ -129,7 +153,8 @@ func NewRestServices() []*restful.WebService {
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	service.Route(service.GET("/{guild-id}").Filter(sessionAndWebkeyAuthenticate).To(GetEventlog))
+	service.Route(service.GET("/{guild-id}").Filter(authenticate(models.ApiScopeEventlogRead)).To(GetEventlog))
+	service.Route(service.GET("/{guild-id}/search").Filter(authenticate(models.ApiScopeEventlogRead)).To(SearchEventlog))
 	services = append(services, service)
 
 	service = new(restful.WebService)
@@ -157,78 +182,25 @@ func NewRestServices() []*restful.WebService {
 		Produces(restful.MIME_JSON)
 	service.Route(service.GET("").Filter(webkeyAuthenticate).To(GetAllBackgrounds))
 	services = append(services, service)
-	return services
-}
-
-func webkeyAuthenticate(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
-	authorizationHeader := strings.TrimSpace(request.HeaderParameter("Authorization"))
-
-	isAuthenticated := false
-
-	if strings.HasPrefix(authorizationHeader, "Webkey ") {
-		webkey := strings.TrimSpace(strings.Replace(authorizationHeader, "Webkey ", "", -1))
-		if webkey == helpers.GetConfig().Path("website.webkey").Data().(string) {
-			isAuthenticated = true
-			request.SetAttribute("UserID", "global")
-		}
-	}
-
-	queryWebkey := strings.TrimSpace(request.QueryParameter("webkey"))
-	if queryWebkey == helpers.GetConfig().Path("website.webkey").Data().(string) {
-		isAuthenticated = true
-		request.SetAttribute("UserID", "global")
-	}
-
-	if isAuthenticated == false {
-		response.WriteErrorString(401, "401: Not Authorized")
-		return
-	}
-
-	chain.ProcessFilter(request, response)
-	return
-}
 
-func sessionAndWebkeyAuthenticate(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
-	authorizationHeader := strings.TrimSpace(request.HeaderParameter("Authorization"))
-
-	isAuthenticated := false
-
-	if strings.HasPrefix(authorizationHeader, "Webkey ") {
-		webkey := strings.TrimSpace(strings.Replace(authorizationHeader, "Webkey ", "", -1))
-		if webkey == helpers.GetConfig().Path("website.webkey").Data().(string) {
-			isAuthenticated = true
-			request.SetAttribute("UserID", "global")
-		}
-	}
-
-	queryWebkey := strings.TrimSpace(request.QueryParameter("webkey"))
-	if queryWebkey == helpers.GetConfig().Path("website.webkey").Data().(string) {
-		isAuthenticated = true
-		request.SetAttribute("UserID", "global")
-	}
-
-	if strings.HasPrefix(authorizationHeader, "PHP-Session ") {
-		sessionID := strings.TrimSpace(strings.Replace(authorizationHeader, "PHP-Session ", "", -1))
-		key := "robyul2-web:robyul-session:" + sessionID
-		redis := cache.GetRedisClient()
-		sessionDataString, err := redis.Get(key).Result()
-		if err == nil {
-			var sessionData models.Website_Session_Data
-			msgpack.Unmarshal([]byte(sessionDataString), &sessionData)
-			if sessionData.DiscordUserID != "" {
-				isAuthenticated = true
-				request.SetAttribute("UserID", sessionData.DiscordUserID)
-			}
-		}
-	}
-
-	if isAuthenticated == false {
-		response.WriteErrorString(401, "401: Not Authorized")
-		return
-	}
+	service = new(restful.WebService)
+	service.
+		Path("/birthdays").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	service.Route(service.GET("/{guild-id}/{days}").Filter(authenticate(models.ApiScopeGuildRead)).To(GetBirthdayCalendar))
+	services = append(services, service)
 
-	chain.ProcessFilter(request, response)
-	return
+	service = new(restful.WebService)
+	service.
+		Path("/auth").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	service.Route(service.GET("/login").To(AuthLogin))
+	service.Route(service.GET("/callback").To(AuthCallback))
+	service.Route(service.POST("/logout").To(AuthLogout))
+	services = append(services, service)
+	return services
 }
 
 func GetAllBotGuilds(request *restful.Request, response *restful.Response) {
@@ -1088,6 +1060,85 @@ func GetVanityInviteStatistics(request *restful.Request, response *restful.Respo
 	response.WriteEntity(result)
 }
 
+func GetVanityInviteConversions(request *restful.Request, response *restful.Response) {
+	guildID := request.PathParameter("guild-id")
+	interval := request.PathParameter("interval")
+	count := request.PathParameter("count")
+
+	if request.Attribute("UserID").(string) != "global" {
+		if !helpers.IsModByID(guildID, request.Attribute("UserID").(string)) && !helpers.IsAdminByID(guildID, request.Attribute("UserID").(string)) {
+			response.WriteErrorString(401, "401: Not Authorized")
+			return
+		}
+	}
+
+	countNumber, err := strconv.Atoi(count)
+	if err != nil {
+		response.WriteError(http.StatusNoContent, errors.New("invalid count"))
+		return
+	}
+
+	vanityInvite, _ := helpers.GetVanityUrlByGuildID(guildID)
+	if vanityInvite.VanityName == "" {
+		response.WriteError(http.StatusNoContent, errors.New("vanity invite not found"))
+		return
+	}
+
+	minBound := helpers.GetMinTimeForInterval(interval, countNumber)
+	if minBound.IsZero() {
+		response.WriteError(http.StatusNoContent, errors.New("invalid interval"))
+		return
+	}
+
+	referers, err := helpers.GetVanityInviteConversions(vanityInvite, "Referer", minBound)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	campaigns, err := helpers.GetVanityInviteConversions(vanityInvite, "Campaign", minBound)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	retention, err := helpers.GetVanityInviteRetention(vanityInvite, minBound)
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	result := models.Rest_VanityInvite_Conversions{
+		From:      minBound,
+		Referers:  make([]models.Rest_VanityInvite_Conversion, 0),
+		Campaigns: make([]models.Rest_VanityInvite_Conversion, 0),
+		Retention: make([]models.Rest_VanityInvite_Retention, 0),
+	}
+	for _, conversion := range referers {
+		result.Referers = append(result.Referers, models.Rest_VanityInvite_Conversion{
+			Key:    conversion.Key,
+			Clicks: conversion.Clicks,
+			Joins:  conversion.Joins,
+			Rate:   conversion.Rate(),
+		})
+	}
+	for _, conversion := range campaigns {
+		result.Campaigns = append(result.Campaigns, models.Rest_VanityInvite_Conversion{
+			Key:    conversion.Key,
+			Clicks: conversion.Clicks,
+			Joins:  conversion.Joins,
+			Rate:   conversion.Rate(),
+		})
+	}
+	for _, item := range retention {
+		result.Retention = append(result.Retention, models.Rest_VanityInvite_Retention{
+			Days:     item.Days,
+			Joined:   item.Joined,
+			Retained: item.Retained,
+		})
+	}
+
+	response.WriteEntity(result)
+}
+
 func GotBotStatistics(request *restful.Request, response *restful.Response) {
 	users := make(map[string]string)
 
@@ -1196,6 +1247,7 @@ func GetChatlogAroundMessageID(request *restful.Request, response *restful.Respo
 		result = append(result, models.Rest_Chatlog_Message{
 			CreatedAt:      m.CreatedAt,
 			ID:             m.MessageID,
+			ChannelID:      m.ChannelID,
 			Content:        m.Content,
 			Attachments:    m.Attachments,
 			AuthorID:       m.UserID,
@@ -1242,6 +1294,7 @@ func GetChatlogAroundMessageID(request *restful.Request, response *restful.Respo
 		result = append(result, models.Rest_Chatlog_Message{
 			CreatedAt:      m.CreatedAt,
 			ID:             m.MessageID,
+			ChannelID:      m.ChannelID,
 			Content:        m.Content,
 			Attachments:    m.Attachments,
 			AuthorID:       m.UserID,
@@ -1286,6 +1339,7 @@ func GetChatlogAroundMessageID(request *restful.Request, response *restful.Respo
 		result = append([]models.Rest_Chatlog_Message{{
 			CreatedAt:      m.CreatedAt,
 			ID:             m.MessageID,
+			ChannelID:      m.ChannelID,
 			Content:        m.Content,
 			Attachments:    m.Attachments,
 			AuthorID:       m.UserID,
@@ -1307,6 +1361,110 @@ func GetChatlogAroundMessageID(request *restful.Request, response *restful.Respo
 	response.WriteEntity(result)
 }
 
+func SearchChatlog(request *restful.Request, response *restful.Response) {
+	searchChatlog(request, response, request.QueryParameter("user_id"))
+}
+
+func GetChatlogUserHistory(request *restful.Request, response *restful.Response) {
+	searchChatlog(request, response, request.PathParameter("user-id"))
+}
+
+func searchChatlog(request *restful.Request, response *restful.Response, userID string) {
+	guildID := request.PathParameter("guild-id")
+
+	if request.Attribute("UserID").(string) != "global" {
+		if !helpers.IsModByID(guildID, request.Attribute("UserID").(string)) && !helpers.HasPermissionByID(
+			guildID, request.Attribute("UserID").(string), discordgo.PermissionAdministrator) {
+			response.WriteErrorString(401, "401: Not Authorized")
+			return
+		}
+	}
+
+	if helpers.GuildSettingsGetCached(guildID).ChatlogDisabled {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	from, until, cursor, limit, err := getSearchParameters(request)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	messages, next, err := helpers.SearchElasticMessages(helpers.ElasticMessageQuery{
+		GuildID:     guildID,
+		ChannelID:   request.QueryParameter("channel_id"),
+		UserID:      userID,
+		From:        from,
+		Until:       until,
+		Search:      strings.TrimSpace(request.QueryParameter("query")),
+		OnlyDeleted: request.QueryParameter("deleted") == "true",
+		Cursor:      cursor,
+		Limit:       limit,
+	})
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	result := models.Rest_Chatlog_Search{
+		Messages:   make([]models.Rest_Chatlog_Message, 0),
+		NextCursor: next.String(),
+	}
+	for _, m := range messages {
+		author, _ := helpers.GetUserWithoutAPI(m.UserID)
+		if author == nil || author.ID == "" {
+			author = new(discordgo.User)
+			author.Username = "N/A"
+		}
+
+		result.Messages = append(result.Messages, models.Rest_Chatlog_Message{
+			CreatedAt:      m.CreatedAt,
+			ID:             m.MessageID,
+			ChannelID:      m.ChannelID,
+			Content:        m.Content,
+			Attachments:    m.Attachments,
+			AuthorID:       m.UserID,
+			AuthorUsername: author.Username,
+			Embeds:         m.Embeds,
+			Deleted:        m.Deleted,
+		})
+	}
+
+	response.WriteEntity(result)
+}
+
+// parses the query parameters shared by all searches: from and until (RFC3339), cursor, and limit
+func getSearchParameters(request *restful.Request) (from, until time.Time, cursor helpers.ElasticCursor, limit int, err error) {
+	if request.QueryParameter("from") != "" {
+		from, err = time.Parse(time.RFC3339, request.QueryParameter("from"))
+		if err != nil {
+			return from, until, cursor, limit, errors.New("invalid from")
+		}
+	}
+
+	if request.QueryParameter("until") != "" {
+		until, err = time.Parse(time.RFC3339, request.QueryParameter("until"))
+		if err != nil {
+			return from, until, cursor, limit, errors.New("invalid until")
+		}
+	}
+
+	cursor, err = helpers.ParseElasticCursor(request.QueryParameter("cursor"))
+	if err != nil {
+		return from, until, cursor, limit, err
+	}
+
+	if request.QueryParameter("limit") != "" {
+		limit, err = strconv.Atoi(request.QueryParameter("limit"))
+		if err != nil || limit < 1 || limit > helpers.ElasticSearchMaxLimit {
+			return from, until, cursor, limit, errors.New("invalid limit")
+		}
+	}
+
+	return from, until, cursor, limit, nil
+}
+
 func GetEventlog(request *restful.Request, response *restful.Response) {
 	guildID := request.PathParameter("guild-id")
 
@@ -1340,18 +1498,7 @@ func GetEventlog(request *restful.Request, response *restful.Response) {
 		return
 	}
 
-	eventlog := models.Rest_Eventlog{
-		Users:   make([]models.Rest_User, 0),
-		Entries: make([]models.Rest_Eventlog_Entry, 0),
-	}
-
-	lookupUserIDs := make([]string, 0)
-	lookupChannelIDs := make([]string, 0)
-	lookupRoleIDs := make([]string, 0)
-	lookupEmojiIDs := make([]string, 0)
-	lookupGuildIDs := make([]string, 0)
-
-	var alreadyLookingUp bool
+	elasticEventlogs := make([]models.ElasticEventlog, 0)
 	for _, item := range searchResult.Hits.Hits {
 		if item == nil {
 			continue
@@ -1363,7 +1510,76 @@ func GetEventlog(request *restful.Request, response *restful.Response) {
 			response.WriteError(http.StatusInternalServerError, err)
 			return
 		}
+		elasticEventlogs = append(elasticEventlogs, elasticEventlog)
+	}
+
+	response.WriteEntity(getRestEventlog(guildID, request.Attribute("UserID").(string), elasticEventlogs))
+}
+
+func SearchEventlog(request *restful.Request, response *restful.Response) {
+	guildID := request.PathParameter("guild-id")
 
+	if request.Attribute("UserID").(string) != "global" {
+		if !helpers.IsModByID(guildID, request.Attribute("UserID").(string)) {
+			response.WriteErrorString(401, "401: Not Authorized")
+			return
+		}
+	}
+
+	if helpers.GuildSettingsGetCached(guildID).EventlogDisabled {
+		response.WriteErrorString(401, "401: Not Authorized")
+		return
+	}
+
+	from, until, cursor, limit, err := getSearchParameters(request)
+	if err != nil {
+		response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+
+	var actionTypes []string
+	if request.QueryParameter("action_type") != "" {
+		actionTypes = strings.Split(request.QueryParameter("action_type"), ",")
+	}
+
+	elasticEventlogs, next, err := helpers.SearchElasticEventlogs(helpers.ElasticEventlogQuery{
+		GuildID:     guildID,
+		ActionTypes: actionTypes,
+		TargetID:    request.QueryParameter("target_id"),
+		UserID:      request.QueryParameter("user_id"),
+		ChannelID:   request.QueryParameter("channel_id"),
+		From:        from,
+		Until:       until,
+		Search:      strings.TrimSpace(request.QueryParameter("query")),
+		Cursor:      cursor,
+		Limit:       limit,
+	})
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	response.WriteEntity(models.Rest_Eventlog_Search{
+		Rest_Eventlog: getRestEventlog(guildID, request.Attribute("UserID").(string), elasticEventlogs),
+		NextCursor:    next.String(),
+	})
+}
+
+// looks up all users, channels, roles, emoji, and guilds the eventlogs refer to
+func getRestEventlog(guildID, requesterUserID string, elasticEventlogs []models.ElasticEventlog) (eventlog models.Rest_Eventlog) {
+	eventlog = models.Rest_Eventlog{
+		Users:   make([]models.Rest_User, 0),
+		Entries: make([]models.Rest_Eventlog_Entry, 0),
+	}
+
+	lookupUserIDs := make([]string, 0)
+	lookupChannelIDs := make([]string, 0)
+	lookupRoleIDs := make([]string, 0)
+	lookupEmojiIDs := make([]string, 0)
+	lookupGuildIDs := make([]string, 0)
+
+	var alreadyLookingUp bool
+	for _, elasticEventlog := range elasticEventlogs {
 		waitingForData := false
 
 		if elasticEventlog.WaitingFor.AuditLogBackfill {
@@ -1643,17 +1859,22 @@ func GetEventlog(request *restful.Request, response *restful.Response) {
 				BotPrefix: botPrefix,
 				Features:  getGuildFeatures(guild.ID),
 				Channels:  channels,
-				Settings:  getGuildSettings(guild.ID, request.Attribute("UserID").(string)),
+				Settings:  getGuildSettings(guild.ID, requesterUserID),
 			})
 		}
 	}
 
-	response.WriteEntity(eventlog)
+	return eventlog
 }
 
 func GetVanityInviteByName(request *restful.Request, response *restful.Response) {
 	vanityName := request.PathParameter("vanity-name")
 	referer := request.QueryParameter("referer")
+	campaign := request.QueryParameter("campaign")
+	if campaign == "" {
+		campaign = request.QueryParameter("c")
+	}
+	campaign = helpers.NormalizeVanityInviteCampaign(campaign)
 
 	vanityInvite, _ := helpers.GetVanityUrlByVanityName(vanityName)
 	if vanityInvite.GuildID == "" {
@@ -1668,7 +1889,7 @@ func GetVanityInviteByName(request *restful.Request, response *restful.Response)
 	}
 
 	go func() {
-		helpers.ElasticAddVanityInviteClick(vanityInvite, referer)
+		helpers.ElasticAddVanityInviteClick(vanityInvite, referer, campaign)
 	}()
 
 	response.WriteEntity(models.Rest_VanityInvite_Invite{
@@ -1731,8 +1952,19 @@ func GetAllBackgrounds(request *restful.Request, response *restful.Response) {
 		return
 	}
 
+	usageCounts, err := helpers.GetProfileBackgroundUsageCounts()
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
 	backgrounds := make([]models.Rest_Background, 0)
 	for _, entry := range entryBucket {
+		// server exclusive backgrounds are not public
+		if entry.GuildID != "" {
+			continue
+		}
+
 		link := entry.URL
 		if link == "" {
 			link, err = helpers.GetFileLink(entry.ObjectName)
@@ -1743,12 +1975,68 @@ func GetAllBackgrounds(request *restful.Request, response *restful.Response) {
 		}
 
 		backgrounds = append(backgrounds, models.Rest_Background{
-			Name: entry.Name,
-			URL:  link,
-			Tags: entry.Tags,
+			Name:       entry.Name,
+			URL:        link,
+			Tags:       entry.Tags,
+			UsageCount: usageCounts[strings.ToLower(entry.Name)],
 		})
 	}
 
 	response.WriteEntity(backgrounds)
 	return
 }
+
+func GetBirthdayCalendar(request *restful.Request, response *restful.Response) {
+	guildID := request.PathParameter("guild-id")
+
+	days, err := strconv.Atoi(request.PathParameter("days"))
+	if err != nil || days <= 0 || days > 366 {
+		response.WriteError(http.StatusBadRequest, errors.New("invalid days"))
+		return
+	}
+
+	guild, err := helpers.GetGuild(guildID)
+	if err != nil || guild == nil || guild.ID == "" {
+		response.WriteError(http.StatusNotFound, errors.New("Guild not found"))
+		return
+	}
+
+	if request.Attribute("UserID").(string) != "global" {
+		if !helpers.GetIsInGuild(guildID, request.Attribute("UserID").(string)) {
+			response.WriteErrorString(401, "401: Not Authorized")
+			return
+		}
+	}
+
+	upcoming, err := plugins.GetUpcomingBirthdays(guildID, time.Now(), time.Now().AddDate(0, 0, days))
+	if err != nil {
+		response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+
+	calendar := models.Rest_Birthday_Calendar{
+		GuildID: guildID,
+		Entries: make([]models.Rest_Birthday_Entry, 0),
+	}
+	for _, item := range upcoming {
+		year := item.Userdata.BirthdayYear
+		if item.Userdata.BirthdayHideYear {
+			year = 0
+		}
+		calendar.Entries = append(calendar.Entries, models.Rest_Birthday_Entry{
+			User: models.Rest_User{
+				ID:            item.Member.User.ID,
+				Username:      item.Member.User.Username,
+				AvatarHash:    item.Member.User.Avatar,
+				Discriminator: item.Member.User.Discriminator,
+				Bot:           item.Member.User.Bot,
+			},
+			Birthday: item.Userdata.Birthday,
+			Year:     year,
+			Age:      helpers.GetBirthdayAge(item.Userdata, item.Birthday),
+			Date:     item.Birthday,
+		})
+	}
+
+	response.WriteEntity(calendar)
+}